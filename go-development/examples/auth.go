@@ -9,8 +9,10 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/go-jose/go-jose.v2/jwt"
@@ -30,7 +32,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := r.Header.Get("X-User")
 		if user == "" {
-			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			WriteAuthError(w, r, unauthorizedError(CodeUnauthorized, "unauthorized", nil))
 			return
 		}
 
@@ -62,93 +64,405 @@ type Claims struct {
 	Roles  []string `json:"roles,omitempty"`
 }
 
+// Validation errors, so callers can tell clock problems from bad tokens.
+var (
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrMissingClaim     = errors.New("missing claim")
+	ErrTokenRevoked     = errors.New("token revoked")
+	ErrInvalidAudience  = errors.New("invalid audience")
+)
+
 // JWTValidator validates JWT tokens with support for key rotation.
 type JWTValidator struct {
-	issuers  map[string]struct{}
-	keys     []any
-	audience string
+	issuers   map[string]struct{}
+	keys      []any
+	audiences []string   // any match; empty = not checked
+	jwks      *jwksCache // set by NewJWTValidatorFromJWKS, replaces keys
+
+	leeway     int64 // seconds
+	required   []string
+	maxAge     int64 // seconds, 0 = unlimited
+	revocation RevocationChecker
+	algs       map[string]struct{} // nil = any algorithm matching the key
+	cache      *validationCache    // nil = disabled
+}
+
+// ValidatorOption configures a JWTValidator.
+type ValidatorOption func(*validatorOptions)
+
+type validatorOptions struct {
+	issuers    []string
+	audiences  []string
+	refresh    time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+	leeway     time.Duration
+	required   []string
+	maxAge     time.Duration
+	revocation RevocationChecker
+	algs       []string
+	cacheSize  int
+	cacheTTL   time.Duration
+}
+
+// WithIssuers sets the accepted token issuers (iss).
+func WithIssuers(issuers ...string) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.issuers = issuers
+	}
+}
+
+// WithAudiences accepts tokens containing any of the given audiences
+// (aud), in addition to the audience passed to NewJWTValidator.
+func WithAudiences(audiences ...string) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.audiences = append(o.audiences, audiences...)
+	}
+}
+
+// WithLeeway tolerates clock skew between the IdP and this service when
+// checking exp, nbf and iat. 30s is typical.
+func WithLeeway(d time.Duration) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.leeway = d
+	}
+}
+
+// WithRequiredClaims rejects tokens missing any of the given registered
+// claims: iss, sub, aud, exp, nbf, iat, jti. Without it a token with no
+// exp never expires.
+func WithRequiredClaims(names ...string) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.required = names
+	}
+}
+
+// WithMaxTokenAge rejects tokens issued (iat) longer ago than d, whatever
+// their exp. Tokens without iat are rejected.
+func WithMaxTokenAge(d time.Duration) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.maxAge = d
+	}
+}
+
+// WithAllowedAlgorithms rejects tokens whose alg header is not listed,
+// before any signature check. Always set it: it stops "none" tokens and
+// algorithm confusion (an HS256 token "signed" with the RSA public key).
+func WithAllowedAlgorithms(algs ...string) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.algs = algs
+	}
 }
 
 // NewJWTValidator creates a validator with given issuers and public keys.
-func NewJWTValidator(issuers []string, keys []any, audience string) *JWTValidator {
-	issuerSet := make(map[string]struct{}, len(issuers))
-	for _, iss := range issuers {
+// Keys may be *rsa.PublicKey, *ecdsa.PublicKey or []byte HS256 secrets.
+func NewJWTValidator(issuers []string, keys []any, audience string, opts ...ValidatorOption) *JWTValidator {
+	o := &validatorOptions{issuers: issuers}
+	if audience != "" {
+		o.audiences = []string{audience}
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	v := newValidator(o)
+	v.keys = keys
+	return v
+}
+
+func newValidator(o *validatorOptions) *JWTValidator {
+	issuerSet := make(map[string]struct{}, len(o.issuers))
+	for _, iss := range o.issuers {
 		issuerSet[iss] = struct{}{}
 	}
+	var algs map[string]struct{}
+	if len(o.algs) > 0 {
+		algs = make(map[string]struct{}, len(o.algs))
+		for _, alg := range o.algs {
+			algs[alg] = struct{}{}
+		}
+	}
+
 	return &JWTValidator{
-		issuers:  issuerSet,
-		keys:     keys,
-		audience: audience,
+		issuers:    issuerSet,
+		audiences:  o.audiences,
+		leeway:     int64(o.leeway / time.Second),
+		required:   o.required,
+		maxAge:     int64(o.maxAge / time.Second),
+		revocation: o.revocation,
+		algs:       algs,
+		cache:      newValidationCache(o.cacheSize, o.cacheTTL),
 	}
 }
 
 // Validate parses and validates a JWT token string.
 func (v *JWTValidator) Validate(tokenString string) (*Claims, error) {
-	tok, err := jwt.ParseSigned(tokenString)
+	return v.ValidateContext(context.Background(), tokenString)
+}
+
+// ValidateContext is Validate with a context for the revocation check and
+// JWKS fetches.
+func (v *JWTValidator) ValidateContext(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := v.validateCached(ctx, tokenString)
 	if err != nil {
-		return nil, fmt.Errorf("parse token: %w", err)
+		return nil, err
 	}
 
-	var claims Claims
-	var verified bool
-	for _, key := range v.keys {
-		if err := tok.Claims(key, &claims); err == nil {
-			verified = true
-			break
+	if err := v.checkRevoked(ctx, claims); err != nil {
+		if errors.Is(err, ErrTokenRevoked) {
+			v.cache.remove(tokenString)
+		}
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validateCached runs validate through the validation cache, if enabled.
+// Hits are rechecked against the clock; callers get their own copy of the
+// claims.
+func (v *JWTValidator) validateCached(ctx context.Context, tokenString string) (*Claims, error) {
+	if v.cache == nil {
+		return v.validate(ctx, tokenString)
+	}
+
+	now := time.Now()
+	if e, ok := v.cache.get(tokenString, now); ok {
+		if e.err != nil {
+			return nil, e.err
 		}
+		if err := v.checkTimes(e.claims, now.Unix()); err != nil {
+			return nil, err
+		}
+		claims := *e.claims
+		return &claims, nil
+	}
+
+	claims, err := v.validate(ctx, tokenString)
+	// A cancelled request says nothing about the token.
+	if ctx.Err() == nil {
+		v.cache.add(tokenString, claims, err, now, v.leeway)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := *claims
+	return &cp, nil
+}
+
+// validate checks everything but revocation.
+func (v *JWTValidator) validate(ctx context.Context, tokenString string) (*Claims, error) {
+	tok, err := jwt.ParseSigned(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("parse token: %w: %w", ErrMalformedToken, err)
 	}
-	if !verified {
-		return nil, errors.New("invalid signature")
+
+	if err := v.checkAlgorithm(tok); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := v.verify(ctx, tok, &claims); err != nil {
+		return nil, err
 	}
 
 	if _, ok := v.issuers[claims.Issuer]; !ok {
 		return nil, fmt.Errorf("invalid issuer: %s", claims.Issuer)
 	}
 
-	now := time.Now().Unix()
-	if claims.ExpiresAt != 0 && now > claims.ExpiresAt {
-		return nil, errors.New("token expired")
+	for _, name := range v.required {
+		if !hasClaim(&claims, name) {
+			return nil, fmt.Errorf("%w: %s", ErrMissingClaim, name)
+		}
 	}
-	if claims.NotBefore != 0 && now < claims.NotBefore {
-		return nil, errors.New("token not yet valid")
+
+	if err := v.checkTimes(&claims, time.Now().Unix()); err != nil {
+		return nil, err
 	}
 
-	if v.audience != "" && !containsAudience(claims.Audience, v.audience) {
-		return nil, errors.New("invalid audience")
+	if len(v.audiences) == 0 {
+		audienceWarning.Do(func() {
+			slog.Warn("jwt validator has no audience configured, tokens for any audience are accepted")
+		})
+	} else if !anyAudience(claims.Audience, v.audiences) {
+		return nil, ErrInvalidAudience
 	}
 
 	return &claims, nil
 }
 
-func containsAudience(audiences []string, target string) bool {
+// checkAlgorithm compares the alg header against the allow-list.
+func (v *JWTValidator) checkAlgorithm(tok *jwt.JSONWebToken) error {
+	if v.algs == nil {
+		return nil
+	}
+	if len(tok.Headers) == 0 {
+		return errors.New("missing token header")
+	}
+	for _, h := range tok.Headers {
+		if _, ok := v.algs[h.Algorithm]; !ok {
+			return fmt.Errorf("algorithm not allowed: %q", h.Algorithm)
+		}
+	}
+	return nil
+}
+
+// verify checks the signature and decodes the claims. Static keys carry
+// no key ID, so each is tried in turn.
+func (v *JWTValidator) verify(ctx context.Context, tok *jwt.JSONWebToken, claims *Claims) error {
+	if v.jwks != nil {
+		return v.jwks.verify(ctx, tok, claims)
+	}
+
+	for _, key := range v.keys {
+		if err := tok.Claims(key, claims); err == nil {
+			return nil
+		}
+	}
+	return errors.New("invalid signature")
+}
+
+// checkTimes validates exp, nbf, iat and the token age, allowing leeway
+// seconds of clock skew each way.
+func (v *JWTValidator) checkTimes(claims *Claims, now int64) error {
+	if claims.ExpiresAt != 0 && now > claims.ExpiresAt+v.leeway {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore-v.leeway {
+		return ErrTokenNotYetValid
+	}
+	if claims.IssuedAt != 0 && now < claims.IssuedAt-v.leeway {
+		return fmt.Errorf("%w: issued in the future", ErrTokenNotYetValid)
+	}
+
+	if v.maxAge > 0 {
+		if claims.IssuedAt == 0 {
+			return fmt.Errorf("%w: iat", ErrMissingClaim)
+		}
+		if now > claims.IssuedAt+v.maxAge+v.leeway {
+			return fmt.Errorf("%w: older than max age", ErrTokenExpired)
+		}
+	}
+	return nil
+}
+
+// hasClaim reports whether the registered claim name is set.
+func hasClaim(c *Claims, name string) bool {
+	switch name {
+	case "iss":
+		return c.Issuer != ""
+	case "sub":
+		return c.Subject != ""
+	case "aud":
+		return len(c.Audience) > 0
+	case "exp":
+		return c.ExpiresAt != 0
+	case "nbf":
+		return c.NotBefore != 0
+	case "iat":
+		return c.IssuedAt != 0
+	case "jti":
+		return c.ID != ""
+	default:
+		return false
+	}
+}
+
+// audienceWarning logs a missing audience config once per process.
+var audienceWarning sync.Once
+
+// anyAudience reports whether the token audiences contain any accepted one.
+func anyAudience(audiences, accepted []string) bool {
 	for _, aud := range audiences {
-		if aud == target {
-			return true
+		for _, want := range accepted {
+			if aud == want {
+				return true
+			}
 		}
 	}
 	return false
 }
 
 // JWTMiddleware validates Bearer tokens from Authorization header.
-func JWTMiddleware(validator *JWTValidator) func(http.Handler) http.Handler {
+func JWTMiddleware(validator *JWTValidator, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := newMiddlewareOptions(opts)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			auth := r.Header.Get("Authorization")
-			if auth == "" {
-				http.Error(w, `{"error":"missing authorization"}`, http.StatusUnauthorized)
+			if r.Header.Get("Authorization") == "" {
+				o.writeError(w, r, unauthorizedError(CodeTokenMissing, "missing authorization", nil))
+				return
+			}
+
+			claims, err := validateRequest(r, validator, o)
+			if err != nil {
+				o.writeError(w, r, tokenError(err))
 				return
 			}
 
-			const prefix = "Bearer "
-			if !strings.HasPrefix(auth, prefix) {
-				http.Error(w, `{"error":"invalid authorization format"}`, http.StatusUnauthorized)
+			ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// MiddlewareOption configures JWTMiddleware and OptionalJWTMiddleware.
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	lenient    bool
+	audiences  []string
+	writeError ErrorWriter
+}
+
+func newMiddlewareOptions(opts []MiddlewareOption) *middlewareOptions {
+	o := &middlewareOptions{writeError: WriteAuthError}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithAudience additionally requires one of the given audiences for the
+// routes behind this middleware, e.g. "admin-api" for the admin group,
+// so one validator can serve every route group.
+func WithAudience(audiences ...string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.audiences = audiences
+	}
+}
+
+// WithLenient treats requests with an invalid token as anonymous instead
+// of rejecting them. Use sparingly: it hides client bugs such as sending
+// expired tokens.
+func WithLenient() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.lenient = true
+	}
+}
+
+// OptionalJWTMiddleware stores claims in context when a valid Bearer token
+// is present and lets anonymous requests through, so public endpoints can
+// still recognize logged-in users. Invalid tokens get 401 unless
+// WithLenient is set.
+func OptionalJWTMiddleware(validator *JWTValidator, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := newMiddlewareOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
-			token := strings.TrimPrefix(auth, prefix)
 
-			claims, err := validator.Validate(token)
+			claims, err := validateRequest(r, validator, o)
 			if err != nil {
-				http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+				if o.lenient {
+					next.ServeHTTP(w, r)
+					return
+				}
+				o.writeError(w, r, tokenError(err))
 				return
 			}
 
@@ -158,24 +472,52 @@ func JWTMiddleware(validator *JWTValidator) func(http.Handler) http.Handler {
 	}
 }
 
+var errAuthFormat = errors.New("invalid authorization format")
+
+// validateRequest validates the Bearer token of r, then the route's
+// audience if the middleware sets one.
+func validateRequest(r *http.Request, validator *JWTValidator, o *middlewareOptions) (*Claims, error) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return nil, errAuthFormat
+	}
+
+	claims, err := validator.ValidateContext(r.Context(), strings.TrimPrefix(auth, prefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(o.audiences) > 0 && !anyAudience(claims.Audience, o.audiences) {
+		return nil, ErrInvalidAudience
+	}
+	return claims, nil
+}
+
 // ClaimsFromContext extracts JWT claims from context.
 func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
 	claims, ok := ctx.Value(claimsContextKey).(*Claims)
 	return claims, ok
 }
 
-// RequireRoles checks if user has any of the required roles.
+// IsAuthenticated reports whether an auth middleware stored claims in ctx.
+func IsAuthenticated(ctx context.Context) bool {
+	_, ok := ClaimsFromContext(ctx)
+	return ok
+}
+
+// RequireRoles checks if user has any of the required roles, directly or
+// through the role hierarchy (see SetRoleHierarchy).
 func RequireRoles(roles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims, ok := ClaimsFromContext(r.Context())
 			if !ok {
-				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				WriteAuthError(w, r, unauthorizedError(CodeUnauthorized, "unauthorized", nil))
 				return
 			}
 
 			if !hasAnyRole(claims.Roles, roles) {
-				http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+				WriteAuthError(w, r, errForbidden)
 				return
 			}
 
@@ -184,19 +526,6 @@ func RequireRoles(roles ...string) func(http.Handler) http.Handler {
 	}
 }
 
-func hasAnyRole(userRoles, requiredRoles []string) bool {
-	roleSet := make(map[string]struct{}, len(userRoles))
-	for _, r := range userRoles {
-		roleSet[r] = struct{}{}
-	}
-	for _, r := range requiredRoles {
-		if _, ok := roleSet[r]; ok {
-			return true
-		}
-	}
-	return false
-}
-
 // LoadRSAPublicKey loads an RSA public key from PEM-encoded data.
 func LoadRSAPublicKey(pemData []byte) (*rsa.PublicKey, error) {
 	block, _ := pem.Decode(pemData)
@@ -0,0 +1,128 @@
+// Package examples provides an LRU cache of token validation results, so
+// repeated requests with the same token skip signature verification.
+package examples
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// maxFailureTTL bounds how long a rejected token stays rejected from
+// cache, so transient failures (JWKS refresh, clock skew) heal quickly.
+const maxFailureTTL = 5 * time.Second
+
+// WithValidationCache caches up to size validation results for ttl each,
+// keyed by a SHA-256 of the token. Successes are never cached past the
+// token's exp, failures for at most 5 seconds. Time claims are rechecked
+// and the revocation checker consulted on every call, so expiry and
+// revocation take effect immediately. size or ttl <= 0 disables it.
+func WithValidationCache(size int, ttl time.Duration) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.cacheSize = size
+		o.cacheTTL = ttl
+	}
+}
+
+// validationCache is a fixed-size LRU of validation results, safe for
+// concurrent use.
+type validationCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front = most recently used
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type cacheEntry struct {
+	key     [sha256.Size]byte
+	claims  *Claims
+	err     error
+	expires time.Time
+}
+
+func newValidationCache(size int, ttl time.Duration) *validationCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &validationCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element, size),
+	}
+}
+
+// get returns the cached result for token, if present and not expired.
+// Entries are never modified once added.
+func (c *validationCache) get(token string, now time.Time) (*cacheEntry, bool) {
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !now.Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return e, true
+}
+
+// add stores a validation result, evicting the least recently used entry
+// when full. leeway extends a success up to the point checkTimes would
+// reject the token anyway.
+func (c *validationCache) add(token string, claims *Claims, err error, now time.Time, leeway int64) {
+	ttl := c.ttl
+	if err != nil {
+		ttl = min(ttl, maxFailureTTL)
+	} else if claims.ExpiresAt != 0 {
+		ttl = min(ttl, time.Unix(claims.ExpiresAt+leeway, 0).Sub(now))
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	key := sha256.Sum256([]byte(token))
+	entry := &cacheEntry{key: key, claims: claims, err: err, expires: now.Add(ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// remove drops token from the cache. Safe on a nil cache.
+func (c *validationCache) remove(token string) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
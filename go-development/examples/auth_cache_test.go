@@ -0,0 +1,299 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+// revokedSet is an in-memory RevocationChecker.
+type revokedSet struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func (s *revokedSet) revoke(jti string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids == nil {
+		s.ids = make(map[string]bool)
+	}
+	s.ids[jti] = true
+}
+
+func (s *revokedSet) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ids[jti], nil
+}
+
+// ---------- validationCache Tests ----------
+
+func TestValidationCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	c := newValidationCache(2, time.Minute)
+	now := time.Now()
+
+	c.add("a", &Claims{UserID: "a"}, nil, now, 0)
+	c.add("b", &Claims{UserID: "b"}, nil, now, 0)
+	_, ok := c.get("a", now)
+	require.True(t, ok)
+
+	c.add("c", &Claims{UserID: "c"}, nil, now, 0)
+
+	_, ok = c.get("b", now)
+	assert.False(t, ok, "b was least recently used")
+	for _, token := range []string{"a", "c"} {
+		e, ok := c.get(token, now)
+		require.True(t, ok, token)
+		assert.Equal(t, token, e.claims.UserID)
+	}
+	assert.Equal(t, 2, c.order.Len())
+}
+
+func TestValidationCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	errInvalid := errors.New("invalid signature")
+
+	tests := []struct {
+		name    string
+		claims  *Claims
+		err     error
+		leeway  int64
+		wantTTL time.Duration // 0 = not stored
+	}{
+		{name: "configured ttl", claims: &Claims{ExpiresAt: now.Add(time.Hour).Unix()}, wantTTL: time.Minute},
+		{name: "no exp", claims: &Claims{}, wantTTL: time.Minute},
+		{name: "capped at exp", claims: &Claims{ExpiresAt: now.Unix() + 10}, wantTTL: 10 * time.Second},
+		{name: "capped at exp plus leeway", claims: &Claims{ExpiresAt: now.Unix() + 10}, leeway: 30,
+			wantTTL: 40 * time.Second},
+		{name: "already expired", claims: &Claims{ExpiresAt: now.Unix() - 1}},
+		{name: "failure", err: errInvalid, wantTTL: maxFailureTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newValidationCache(10, time.Minute)
+			c.add("token", tt.claims, tt.err, now, tt.leeway)
+
+			e, ok := c.get("token", now)
+			if tt.wantTTL == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.WithinDuration(t, now.Add(tt.wantTTL), e.expires, time.Second)
+
+			_, ok = c.get("token", e.expires)
+			assert.False(t, ok, "gone once expired")
+		})
+	}
+}
+
+func TestNewValidationCache_Disabled(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newValidationCache(0, time.Minute))
+	assert.Nil(t, newValidationCache(10, 0))
+}
+
+// ---------- Validator Tests ----------
+
+func TestValidate_CacheSkipsVerification(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(100, time.Minute))
+	token := key.sign(t, "k1")
+
+	_, err := v.Validate(token)
+	require.NoError(t, err)
+
+	// Without keys only a cache hit can succeed.
+	v.keys = nil
+	claims, err := v.Validate(token)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.UserID)
+
+	_, err = v.Validate(key.sign(t, "k1") + "x")
+	assert.Error(t, err, "other tokens still verified")
+}
+
+func TestValidate_CacheReturnsCopies(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(100, time.Minute))
+	token := key.sign(t, "k1")
+
+	first, err := v.Validate(token)
+	require.NoError(t, err)
+	first.UserID = "1"
+
+	second, err := v.Validate(token)
+	require.NoError(t, err)
+	assert.Equal(t, "42", second.UserID)
+}
+
+func TestValidate_CacheFailures(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(100, time.Minute))
+	claims := validClaims()
+	claims.Audience = []string{"other-api"}
+	token := key.signClaims(t, "k1", claims)
+
+	_, err := v.Validate(token)
+	require.ErrorIs(t, err, ErrInvalidAudience)
+
+	e, ok := v.cache.get(token, time.Now())
+	require.True(t, ok)
+	assert.ErrorIs(t, e.err, ErrInvalidAudience)
+	assert.WithinDuration(t, time.Now().Add(maxFailureTTL), e.expires, time.Second)
+
+	_, err = v.Validate(token)
+	assert.ErrorIs(t, err, ErrInvalidAudience, "served from cache")
+}
+
+func TestValidate_CacheSkipsCancelled(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(100, time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = v.ValidateContext(ctx, key.sign(t, "k1"))
+
+	assert.Zero(t, v.cache.order.Len())
+}
+
+func TestValidate_CacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(100, time.Hour))
+
+	claims := validClaims()
+	claims.ExpiresAt = time.Now().Unix() + 1
+	token := key.signClaims(t, "k1", claims)
+
+	_, err := v.Validate(token)
+	require.NoError(t, err)
+
+	time.Sleep(time.Until(time.Unix(claims.ExpiresAt+1, 0)))
+
+	_, err = v.Validate(token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestValidate_CacheRevocation(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	revoked := &revokedSet{}
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(100, time.Minute), WithRevocationChecker(revoked))
+
+	claims := validClaims()
+	claims.ID = "token-1"
+	token := key.signClaims(t, "k1", claims)
+
+	_, err := v.Validate(token)
+	require.NoError(t, err)
+	_, ok := v.cache.get(token, time.Now())
+	require.True(t, ok)
+
+	revoked.revoke("token-1")
+
+	_, err = v.Validate(token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, ok = v.cache.get(token, time.Now())
+	assert.False(t, ok, "revoked token evicted")
+}
+
+func TestValidate_CacheConcurrent(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithValidationCache(4, time.Minute))
+
+	tokens := make([]string, 8)
+	for i := range tokens {
+		claims := validClaims()
+		claims.UserID = fmt.Sprint(i)
+		tokens[i] = key.signClaims(t, "k1", claims)
+	}
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				n := (g + i) % len(tokens)
+				claims, err := v.Validate(tokens[n])
+				if assert.NoError(t, err) {
+					assert.Equal(t, fmt.Sprint(n), claims.UserID)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, v.cache.order.Len(), 4)
+}
+
+// ---------- Benchmarks ----------
+
+// BenchmarkValidate compares RS256 validation with and without the cache:
+//
+//	go test -bench=Validate -benchmem
+func BenchmarkValidate(b *testing.B) {
+	rsaKey := newRSAKey(b)
+	issuer, err := NewIssuer(rsaKey, AlgRS256, testIssuer)
+	require.NoError(b, err)
+	token, err := issuer.Issue(Claims{Audience: []string{"my-api"}, UserID: "42"}, time.Hour)
+	require.NoError(b, err)
+
+	for _, bc := range []struct {
+		name string
+		opts []ValidatorOption
+	}{
+		{"uncached", nil},
+		{"cached", []ValidatorOption{WithValidationCache(1000, time.Minute)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			v := NewJWTValidator([]string{testIssuer}, []any{&rsaKey.PublicKey}, "my-api", bc.opts...)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := v.Validate(token); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Package examples provides auth failures in the API error response format.
+package examples
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"myapp/internal/errs"
+)
+
+// Auth error codes, so clients can tell a token to refresh from one to drop.
+const (
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeTokenMissing   = "token_missing"
+	CodeTokenMalformed = "token_malformed"
+	CodeTokenExpired   = "token_expired"
+	CodeTokenRevoked   = "token_revoked"
+	CodeTokenInvalid   = "token_invalid"
+	CodeCertMissing    = "cert_missing"
+	CodeCertRejected   = "cert_rejected"
+	CodeSessionMissing = "session_missing"
+	CodeSessionExpired = "session_expired"
+	CodeSessionInvalid = "session_invalid"
+)
+
+// ErrMalformedToken is returned for tokens that can't be parsed as JWTs.
+var ErrMalformedToken = errors.New("malformed token")
+
+// AuthError is an authentication or authorization failure with a
+// client-facing code. It wraps errs.ErrUnauthorized or errs.ErrForbidden,
+// so errs.HTTPStatus maps it to 401 or 403.
+type AuthError struct {
+	Code    string
+	Message string // client-safe
+	Err     error
+}
+
+func (e *AuthError) Error() string { return e.Code + ": " + e.Err.Error() }
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// ErrorWriter writes an auth failure. err is an *AuthError;
+// handler.ErrorHandler.Handle has this signature.
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, err error)
+
+// WithErrorWriter replaces WriteAuthError in the JWT middlewares.
+func WithErrorWriter(ew ErrorWriter) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.writeError = ew
+	}
+}
+
+// errorResponse matches handler.ErrorResponse.
+type errorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WriteAuthError writes err as the standard JSON error response, with the
+// request ID from chi's middleware.RequestID.
+func WriteAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	resp := errorResponse{
+		Error:     errs.Message(err),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		resp.Error = authErr.Message
+		resp.Code = authErr.Code
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errs.HTTPStatus(err))
+	json.NewEncoder(w).Encode(resp)
+}
+
+func unauthorizedError(code, message string, cause error) *AuthError {
+	err := errs.ErrUnauthorized
+	if cause != nil {
+		err = fmt.Errorf("%w: %w", errs.ErrUnauthorized, cause)
+	}
+	return &AuthError{Code: code, Message: message, Err: err}
+}
+
+var errForbidden = &AuthError{Code: CodeForbidden, Message: "forbidden", Err: errs.ErrForbidden}
+
+// tokenError classifies a token validation error.
+func tokenError(err error) *AuthError {
+	switch {
+	case errors.Is(err, errAuthFormat):
+		return unauthorizedError(CodeTokenMalformed, "invalid authorization format", err)
+	case errors.Is(err, ErrMalformedToken):
+		return unauthorizedError(CodeTokenMalformed, "malformed token", err)
+	case errors.Is(err, ErrTokenExpired):
+		return unauthorizedError(CodeTokenExpired, "token expired", err)
+	case errors.Is(err, ErrTokenRevoked):
+		return unauthorizedError(CodeTokenRevoked, "token revoked", err)
+	default:
+		return unauthorizedError(CodeTokenInvalid, "invalid token", err)
+	}
+}
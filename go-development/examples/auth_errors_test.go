@@ -0,0 +1,175 @@
+package examples
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/errs"
+)
+
+// ---------- Test Helpers ----------
+
+func decodeAuthError(t *testing.T, rec *httptest.ResponseRecorder) errorResponse {
+	t.Helper()
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var resp errorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	return resp
+}
+
+func withRequestID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, id))
+}
+
+// ---------- Error Classification Tests ----------
+
+func TestTokenError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{"bad header", errAuthFormat, CodeTokenMalformed, "invalid authorization format"},
+		{"unparseable", ErrMalformedToken, CodeTokenMalformed, "malformed token"},
+		{"expired", ErrTokenExpired, CodeTokenExpired, "token expired"},
+		{"revoked", ErrTokenRevoked, CodeTokenRevoked, "token revoked"},
+		{"wrong audience", ErrInvalidAudience, CodeTokenInvalid, "invalid token"},
+		{"not yet valid", ErrTokenNotYetValid, CodeTokenInvalid, "invalid token"},
+		{"bad signature", errors.New("invalid signature"), CodeTokenInvalid, "invalid token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tokenError(tt.err)
+
+			assert.Equal(t, tt.wantCode, err.Code)
+			assert.Equal(t, tt.wantMessage, err.Message)
+			assert.ErrorIs(t, err, errs.ErrUnauthorized)
+			assert.ErrorIs(t, err, tt.err, "cause kept for logging")
+		})
+	}
+}
+
+// ---------- WriteAuthError Tests ----------
+
+func TestWriteAuthError(t *testing.T) {
+	t.Parallel()
+
+	req := withRequestID(httptest.NewRequest(http.MethodGet, "/profile", nil), "req-1")
+	rec := httptest.NewRecorder()
+
+	WriteAuthError(rec, req, tokenError(ErrTokenExpired))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, errorResponse{Error: "token expired", Code: CodeTokenExpired, RequestID: "req-1"},
+		decodeAuthError(t, rec))
+}
+
+func TestWriteAuthError_Forbidden(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	WriteAuthError(rec, httptest.NewRequest(http.MethodGet, "/admin", nil), errForbidden)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, errorResponse{Error: "forbidden", Code: CodeForbidden}, decodeAuthError(t, rec))
+}
+
+// ---------- Middleware Tests ----------
+
+func TestAuthMiddleware_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := withRequestID(httptest.NewRequest(http.MethodGet, "/profile", nil), "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, errorResponse{Error: "unauthorized", Code: CodeUnauthorized, RequestID: "req-1"},
+		decodeAuthError(t, rec))
+}
+
+func TestRequireRoles_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		claims     *Claims
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no claims", wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{name: "missing role", claims: &Claims{Roles: []string{"viewer"}}, wantStatus: http.StatusForbidden,
+			wantCode: CodeForbidden},
+		{name: "has role", claims: &Claims{Roles: []string{"admin"}}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := RequireRoles("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/users/1", nil)
+			if tt.claims != nil {
+				req = req.WithContext(context.WithValue(req.Context(), claimsContextKey, tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeAuthError(t, rec).Code)
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_ErrorWriter(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api")
+
+	var got error
+	ew := func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(errs.HTTPStatus(err))
+	}
+
+	for _, mw := range []func(http.Handler) http.Handler{
+		JWTMiddleware(v, WithErrorWriter(ew)),
+		OptionalJWTMiddleware(v, WithErrorWriter(ew)),
+	} {
+		got = nil
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		var authErr *AuthError
+		require.ErrorAs(t, got, &authErr)
+		assert.Equal(t, CodeTokenMalformed, authErr.Code)
+		assert.ErrorIs(t, got, ErrMalformedToken)
+	}
+}
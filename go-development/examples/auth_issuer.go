@@ -0,0 +1,195 @@
+// Package examples provides JWT issuance with the Claims validated by JWTValidator.
+package examples
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/cryptosigner"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+// Supported signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgHS256 = "HS256"
+)
+
+// minHMACSecret is the HS256 key size recommended by RFC 7518.
+const minHMACSecret = 32
+
+// TokenIssuer signs access tokens that JWTValidator accepts.
+type TokenIssuer struct {
+	signer jose.Signer
+	issuer string
+	now    func() time.Time
+}
+
+// IssuerOption configures a TokenIssuer.
+type IssuerOption func(*issuerOptions)
+
+type issuerOptions struct {
+	keyID string
+}
+
+// WithKeyID sets the kid header, which NewJWTValidatorFromJWKS uses to
+// pick the verification key. Use the kid the key is published under.
+func WithKeyID(kid string) IssuerOption {
+	return func(o *issuerOptions) {
+		o.keyID = kid
+	}
+}
+
+// NewIssuer creates an issuer signing with an asymmetric key, either
+// RS256 (RSA) or ES256 (ECDSA P-256). Any crypto.Signer works, so the key
+// may live in a KMS or HSM. For HS256 use NewHMACIssuer.
+func NewIssuer(key crypto.Signer, alg string, issuer string, opts ...IssuerOption) (*TokenIssuer, error) {
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		if alg != AlgRS256 {
+			return nil, fmt.Errorf("issuer: RSA key cannot sign %s", alg)
+		}
+	case *ecdsa.PublicKey:
+		if alg != AlgES256 {
+			return nil, fmt.Errorf("issuer: ECDSA key cannot sign %s", alg)
+		}
+		if pub.Curve != elliptic.P256() {
+			return nil, errors.New("issuer: ES256 requires a P-256 key")
+		}
+	default:
+		return nil, fmt.Errorf("issuer: unsupported key type %T", pub)
+	}
+
+	// Plain keys are signed directly; other signers (KMS, HSM) go
+	// through go-jose's opaque signer adapter.
+	var signingKey any = cryptosigner.Opaque(key)
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		signingKey = k
+	}
+
+	return newIssuer(jose.SignatureAlgorithm(alg), signingKey, issuer, opts)
+}
+
+// NewHMACIssuer creates an issuer signing with HS256. The validator must
+// be given the same secret as its key. Secrets shorter than 32 bytes are
+// rejected.
+func NewHMACIssuer(secret []byte, issuer string, opts ...IssuerOption) (*TokenIssuer, error) {
+	if len(secret) < minHMACSecret {
+		return nil, fmt.Errorf("issuer: HS256 secret must be at least %d bytes", minHMACSecret)
+	}
+	return newIssuer(jose.HS256, secret, issuer, opts)
+}
+
+func newIssuer(alg jose.SignatureAlgorithm, key any, issuer string, opts []IssuerOption) (*TokenIssuer, error) {
+	o := &issuerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	signerOpts := (&jose.SignerOptions{}).WithType("JWT")
+	if o.keyID != "" {
+		signerOpts = signerOpts.WithHeader("kid", o.keyID)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, signerOpts)
+	if err != nil {
+		return nil, fmt.Errorf("issuer: create signer: %w", err)
+	}
+
+	return &TokenIssuer{
+		signer: signer,
+		issuer: issuer,
+		now:    time.Now,
+	}, nil
+}
+
+// Issue signs claims as a token valid for ttl. It sets iss to the
+// issuer, iat to now, exp to now+ttl and jti to a new UUID unless set.
+func (i *TokenIssuer) Issue(claims Claims, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", errors.New("issuer: ttl must be positive")
+	}
+
+	now := i.now()
+	claims.Issuer = i.issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	if claims.ID == "" {
+		claims.ID = uuid.NewString()
+	}
+
+	token, err := jwt.Signed(i.signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		return "", fmt.Errorf("issuer: sign: %w", err)
+	}
+	return token, nil
+}
+
+// LoadRSAPrivateKey loads an RSA private key from PEM-encoded PKCS#1
+// ("RSA PRIVATE KEY") or PKCS#8 ("PRIVATE KEY") data.
+func LoadRSAPrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM")
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+
+	return rsaKey, nil
+}
+
+// LoadECDSAPrivateKey loads an ECDSA private key from PEM-encoded SEC 1
+// ("EC PRIVATE KEY") or PKCS#8 ("PRIVATE KEY") data.
+func LoadECDSAPrivateKey(pemData []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM")
+	}
+
+	if block.Type == "EC PRIVATE KEY" {
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse private key: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an ECDSA private key")
+	}
+
+	return ecKey, nil
+}
@@ -0,0 +1,218 @@
+package examples
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+func newRSAKey(t testing.TB) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func pemBlock(typ string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+// ---------- TokenIssuer Tests ----------
+
+func TestTokenIssuer_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, ecKey := newRSAKey(t), newECKey(t)
+	secret := []byte(strings.Repeat("s", 32))
+
+	rsaIssuer, err := NewIssuer(rsaKey, AlgRS256, testIssuer)
+	require.NoError(t, err)
+	ecIssuer, err := NewIssuer(ecKey, AlgES256, testIssuer)
+	require.NoError(t, err)
+	hmacIssuer, err := NewHMACIssuer(secret, testIssuer)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		issuer *TokenIssuer
+		key    any
+	}{
+		{"RS256", rsaIssuer, &rsaKey.PublicKey},
+		{"ES256", ecIssuer, &ecKey.PublicKey},
+		{"HS256", hmacIssuer, secret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			token, err := tt.issuer.Issue(Claims{
+				Subject:  "user-42",
+				Audience: []string{"my-api"},
+				UserID:   "42",
+				Roles:    []string{"admin"},
+			}, 15*time.Minute)
+			require.NoError(t, err)
+
+			v := NewJWTValidator([]string{testIssuer}, []any{tt.key}, "my-api",
+				WithRequiredClaims("exp", "iat", "jti"))
+			claims, err := v.Validate(token)
+			require.NoError(t, err)
+
+			assert.Equal(t, testIssuer, claims.Issuer)
+			assert.Equal(t, "42", claims.UserID)
+			assert.Equal(t, []string{"admin"}, claims.Roles)
+			assert.NotEmpty(t, claims.ID)
+			assert.Equal(t, claims.IssuedAt+int64((15*time.Minute).Seconds()), claims.ExpiresAt)
+		})
+	}
+}
+
+func TestTokenIssuer_Rejected(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, ecKey := newRSAKey(t), newECKey(t)
+	issuer, err := NewIssuer(ecKey, AlgES256, testIssuer)
+	require.NoError(t, err)
+
+	token, err := issuer.Issue(Claims{Subject: "user-42", UserID: "42"}, time.Minute)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	forged, err := issuer.Issue(Claims{Subject: "user-42", UserID: "1", Roles: []string{"admin"}}, time.Minute)
+	require.NoError(t, err)
+	tampered := parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+
+	hmacIssuer, err := NewHMACIssuer([]byte(strings.Repeat("s", 32)), testIssuer)
+	require.NoError(t, err)
+	hmacToken, err := hmacIssuer.Issue(Claims{Subject: "user-42"}, time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		key   any
+	}{
+		{"tampered payload", tampered, &ecKey.PublicKey},
+		{"wrong algorithm key", token, &rsaKey.PublicKey},
+		{"HS256 token for ES256 key", hmacToken, &ecKey.PublicKey},
+		{"other ECDSA key", token, &newECKey(t).PublicKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := NewJWTValidator([]string{testIssuer}, []any{tt.key}, "")
+			_, err := v.Validate(tt.token)
+			assert.ErrorContains(t, err, "invalid signature")
+		})
+	}
+}
+
+func TestTokenIssuer_KeepsJTI(t *testing.T) {
+	t.Parallel()
+
+	ecKey := newECKey(t)
+	issuer, err := NewIssuer(ecKey, AlgES256, testIssuer, WithKeyID("k1"))
+	require.NoError(t, err)
+
+	token, err := issuer.Issue(Claims{ID: "token-1"}, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := NewJWTValidator([]string{testIssuer}, []any{&ecKey.PublicKey}, "").Validate(token)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", claims.ID)
+
+	_, err = issuer.Issue(Claims{}, 0)
+	assert.ErrorContains(t, err, "ttl must be positive")
+}
+
+func TestNewIssuer_Errors(t *testing.T) {
+	t.Parallel()
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewIssuer(newECKey(t), AlgRS256, testIssuer)
+	assert.ErrorContains(t, err, "ECDSA key cannot sign RS256")
+
+	_, err = NewIssuer(newRSAKey(t), AlgHS256, testIssuer)
+	assert.ErrorContains(t, err, "RSA key cannot sign HS256")
+
+	_, err = NewIssuer(p384, AlgES256, testIssuer)
+	assert.ErrorContains(t, err, "P-256")
+
+	_, err = NewHMACIssuer([]byte("short"), testIssuer)
+	assert.ErrorContains(t, err, "at least 32 bytes")
+}
+
+// ---------- Key Loading Tests ----------
+
+func TestLoadRSAPrivateKey(t *testing.T) {
+	t.Parallel()
+
+	key := newRSAKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(newECKey(t))
+	require.NoError(t, err)
+
+	got, err := LoadRSAPrivateKey(pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)))
+	require.NoError(t, err, "PKCS#1")
+	assert.True(t, key.Equal(got))
+
+	got, err = LoadRSAPrivateKey(pemBlock("PRIVATE KEY", pkcs8))
+	require.NoError(t, err, "PKCS#8")
+	assert.True(t, key.Equal(got))
+
+	_, err = LoadRSAPrivateKey(pemBlock("PRIVATE KEY", ecPKCS8))
+	assert.ErrorContains(t, err, "not an RSA private key")
+
+	_, err = LoadRSAPrivateKey([]byte("not pem"))
+	assert.ErrorContains(t, err, "failed to decode PEM")
+}
+
+func TestLoadECDSAPrivateKey(t *testing.T) {
+	t.Parallel()
+
+	key := newECKey(t)
+	sec1, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	rsaPKCS8, err := x509.MarshalPKCS8PrivateKey(newRSAKey(t))
+	require.NoError(t, err)
+
+	got, err := LoadECDSAPrivateKey(pemBlock("EC PRIVATE KEY", sec1))
+	require.NoError(t, err, "SEC 1")
+	assert.True(t, key.Equal(got))
+
+	got, err = LoadECDSAPrivateKey(pemBlock("PRIVATE KEY", pkcs8))
+	require.NoError(t, err, "PKCS#8")
+	assert.True(t, key.Equal(got))
+
+	_, err = LoadECDSAPrivateKey(pemBlock("PRIVATE KEY", rsaPKCS8))
+	assert.ErrorContains(t, err, "not an ECDSA private key")
+}
@@ -0,0 +1,214 @@
+// Package examples provides JWKS key fetching and rotation for JWTValidator.
+package examples
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+const (
+	defaultJWKSRefresh    = time.Hour
+	defaultJWKSMinRefresh = time.Minute
+	jwksFetchTimeout      = 10 * time.Second
+	maxJWKSBytes          = 1 << 20
+)
+
+// WithRefreshInterval sets how long fetched JWKS keys are used before they
+// are fetched again. Default: 1 hour.
+func WithRefreshInterval(d time.Duration) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.refresh = d
+	}
+}
+
+// WithMinRefreshInterval limits how often an unknown key ID or a failed
+// signature can trigger a JWKS fetch, so forged tokens can't make the
+// validator hammer the IdP. Default: 1 minute.
+func WithMinRefreshInterval(d time.Duration) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.minRefresh = d
+	}
+}
+
+// WithHTTPClient sets the client used to fetch the JWKS.
+func WithHTTPClient(client *http.Client) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.client = client
+	}
+}
+
+// NewJWTValidatorFromJWKS creates a validator whose keys are fetched from
+// the IdP's JWKS endpoint and selected by the token's kid header. Keys are
+// refetched after the refresh interval and when a token has an unknown kid;
+// if a fetch fails, the cached keys stay in use. Only the initial fetch
+// must succeed.
+func NewJWTValidatorFromJWKS(ctx context.Context, jwksURL string, opts ...ValidatorOption) (*JWTValidator, error) {
+	o := &validatorOptions{
+		refresh:    defaultJWKSRefresh,
+		minRefresh: defaultJWKSMinRefresh,
+		client:     &http.Client{Timeout: jwksFetchTimeout},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if len(o.issuers) == 0 {
+		return nil, errors.New("jwks: at least one issuer is required")
+	}
+
+	cache := &jwksCache{
+		url:        jwksURL,
+		client:     o.client,
+		refresh:    o.refresh,
+		minRefresh: o.minRefresh,
+	}
+	if err := cache.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	v := newValidator(o)
+	v.jwks = cache
+	return v, nil
+}
+
+// jwksCache holds the verification keys of a JWKS endpoint by key ID.
+type jwksCache struct {
+	url        string
+	client     *http.Client
+	refresh    time.Duration
+	minRefresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]any
+	fetchedAt time.Time
+
+	fetchMu     sync.Mutex // serializes fetches
+	lastAttempt time.Time
+}
+
+// verify selects the key by the token's kid. An unknown kid or a failed
+// signature triggers one rate-limited refetch, since the IdP may have
+// rotated its keys.
+func (c *jwksCache) verify(ctx context.Context, tok *jwt.JSONWebToken, claims *Claims) error {
+	if len(tok.Headers) == 0 || tok.Headers[0].KeyID == "" {
+		return errors.New("missing key id")
+	}
+	kid := tok.Headers[0].KeyID
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	if c.stale() {
+		c.refreshStale(ctx)
+	}
+
+	if key, ok := c.key(kid); ok && tok.Claims(key, claims) == nil {
+		return nil
+	}
+
+	if !c.refetch(ctx) {
+		return errors.New("invalid signature")
+	}
+	if key, ok := c.key(kid); ok && tok.Claims(key, claims) == nil {
+		return nil
+	}
+	return errors.New("invalid signature")
+}
+
+func (c *jwksCache) key(kid string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func (c *jwksCache) stale() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return time.Since(c.fetchedAt) > c.refresh
+}
+
+// refetch fetches the JWKS unless a fetch was attempted within the minimum
+// refresh interval. It reports whether new keys were loaded.
+func (c *jwksCache) refetch(ctx context.Context) bool {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	if time.Since(c.lastAttempt) < c.minRefresh {
+		return false
+	}
+	return c.fetchLocked(ctx) == nil
+}
+
+// refreshStale refetches expired keys unless another request already is.
+// On failure the cached keys stay in use.
+func (c *jwksCache) refreshStale(ctx context.Context) {
+	if !c.fetchMu.TryLock() {
+		return
+	}
+	defer c.fetchMu.Unlock()
+
+	if time.Since(c.lastAttempt) >= c.minRefresh {
+		c.fetchLocked(ctx)
+	}
+}
+
+func (c *jwksCache) fetch(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	return c.fetchLocked(ctx)
+}
+
+func (c *jwksCache) fetchLocked(ctx context.Context) error {
+	c.lastAttempt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("jwks: create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jwks: fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: fetch: status %d", resp.StatusCode)
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+		return fmt.Errorf("jwks: decode: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.KeyID == "" || (k.Use != "" && k.Use != "sig") || !k.IsPublic() {
+			continue
+		}
+		keys[k.KeyID] = k.Key
+	}
+	if len(keys) == 0 {
+		return errors.New("jwks: no signing keys")
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
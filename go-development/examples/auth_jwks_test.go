@@ -0,0 +1,180 @@
+package examples
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/go-jose/go-jose.v2"
+)
+
+// ---------- Test Helpers ----------
+
+// jwksServer serves a key set that tests can rotate and break.
+type jwksServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    []jose.JSONWebKey
+	failing bool
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...*signingKey) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{}
+	s.setKeys(keys...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: s.keys})
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...*signingKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = s.keys[:0]
+	for _, k := range keys {
+		s.keys = append(s.keys, jose.JSONWebKey{Key: &k.priv.PublicKey, KeyID: k.kid, Algorithm: "ES256", Use: "sig"})
+	}
+}
+
+func (s *jwksServer) setFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+func newJWKSValidator(t *testing.T, url string, opts ...ValidatorOption) *JWTValidator {
+	t.Helper()
+
+	opts = append([]ValidatorOption{WithIssuers(testIssuer), WithAudiences("my-api")}, opts...)
+	v, err := NewJWTValidatorFromJWKS(context.Background(), url, opts...)
+	require.NoError(t, err)
+	return v
+}
+
+// ---------- JWKS Tests ----------
+
+func TestJWKS_SelectsKeyByKid(t *testing.T) {
+	t.Parallel()
+
+	k1, k2 := newSigningKey(t, "k1"), newSigningKey(t, "k2")
+	srv := newJWKSServer(t, k1, k2)
+	v := newJWKSValidator(t, srv.URL)
+
+	for _, k := range []*signingKey{k1, k2} {
+		claims, err := v.Validate(k.sign(t, k.kid))
+		require.NoError(t, err, k.kid)
+		assert.Equal(t, "42", claims.UserID)
+	}
+
+	_, err := v.Validate(k1.sign(t, "k2"))
+	assert.Error(t, err, "key is chosen by kid, not by trying every key")
+
+	_, err = v.Validate(k1.sign(t, ""))
+	assert.ErrorContains(t, err, "missing key id")
+}
+
+func TestJWKS_RotationRefetchesOnUnknownKid(t *testing.T) {
+	t.Parallel()
+
+	k1, k2 := newSigningKey(t, "k1"), newSigningKey(t, "k2")
+	srv := newJWKSServer(t, k1)
+	v := newJWKSValidator(t, srv.URL, WithMinRefreshInterval(0))
+
+	_, err := v.Validate(k1.sign(t, "k1"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, srv.fetches.Load())
+
+	srv.setKeys(k2) // IdP rotates, retiring k1
+
+	_, err = v.Validate(k2.sign(t, "k2"))
+	require.NoError(t, err, "unknown kid triggers a refetch")
+	assert.EqualValues(t, 2, srv.fetches.Load())
+
+	_, err = v.Validate(k2.sign(t, "k2"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, srv.fetches.Load(), "known kid served from cache")
+
+	_, err = v.Validate(k1.sign(t, "k1"))
+	assert.ErrorContains(t, err, "invalid signature", "retired key rejected")
+}
+
+func TestJWKS_RateLimitsRefetch(t *testing.T) {
+	t.Parallel()
+
+	k1, forged := newSigningKey(t, "k1"), newSigningKey(t, "unknown")
+	srv := newJWKSServer(t, k1)
+	v := newJWKSValidator(t, srv.URL, WithMinRefreshInterval(time.Hour))
+
+	for range 10 {
+		_, err := v.Validate(forged.sign(t, "unknown"))
+		assert.ErrorContains(t, err, "invalid signature")
+	}
+
+	assert.EqualValues(t, 1, srv.fetches.Load(), "only the initial fetch")
+}
+
+func TestJWKS_RefreshesAfterInterval(t *testing.T) {
+	t.Parallel()
+
+	k1 := newSigningKey(t, "k1")
+	srv := newJWKSServer(t, k1)
+	v := newJWKSValidator(t, srv.URL, WithRefreshInterval(time.Nanosecond), WithMinRefreshInterval(0))
+
+	_, err := v.Validate(k1.sign(t, "k1"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, srv.fetches.Load(), "expired keys refetched")
+}
+
+func TestJWKS_FetchFailureKeepsCachedKeys(t *testing.T) {
+	t.Parallel()
+
+	k1 := newSigningKey(t, "k1")
+	srv := newJWKSServer(t, k1)
+	v := newJWKSValidator(t, srv.URL, WithRefreshInterval(time.Nanosecond), WithMinRefreshInterval(0))
+
+	srv.setFailing(true)
+
+	_, err := v.Validate(k1.sign(t, "k1"))
+	require.NoError(t, err)
+	assert.Greater(t, srv.fetches.Load(), int32(1), "refetch attempted")
+}
+
+func TestNewJWTValidatorFromJWKS_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := newJWKSServer(t, newSigningKey(t, "k1"))
+
+	_, err := NewJWTValidatorFromJWKS(context.Background(), srv.URL)
+	assert.ErrorContains(t, err, "issuer is required")
+
+	srv.setFailing(true)
+	_, err = NewJWTValidatorFromJWKS(context.Background(), srv.URL, WithIssuers(testIssuer))
+	assert.ErrorContains(t, err, "status 503")
+
+	srv.setFailing(false)
+	srv.setKeys()
+	_, err = NewJWTValidatorFromJWKS(context.Background(), srv.URL, WithIssuers(testIssuer))
+	assert.ErrorContains(t, err, "no signing keys")
+}
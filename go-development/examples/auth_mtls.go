@@ -0,0 +1,120 @@
+// Package examples provides client-certificate authentication for services
+// talking over mutual TLS.
+package examples
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// CertClaimsFunc maps a verified client certificate to claims, so
+// RequireRoles works the same for certificates and tokens.
+type CertClaimsFunc func(cert *x509.Certificate) (*Claims, error)
+
+// CertOption configures CertAuthMiddleware.
+type CertOption func(*certOptions)
+
+type certOptions struct {
+	claims     CertClaimsFunc
+	writeError ErrorWriter
+}
+
+// WithCertClaims replaces DefaultCertClaims, typically to assign roles
+// per service identity.
+func WithCertClaims(fn CertClaimsFunc) CertOption {
+	return func(o *certOptions) {
+		o.claims = fn
+	}
+}
+
+// WithCertErrorWriter replaces WriteAuthError in CertAuthMiddleware.
+func WithCertErrorWriter(ew ErrorWriter) CertOption {
+	return func(o *certOptions) {
+		o.writeError = ew
+	}
+}
+
+// CertAuthMiddleware authenticates requests by client certificate and
+// stores the mapped claims in context. The server must verify client
+// certificates (see NewMTLSConfig); requests over plain HTTP or without a
+// verified chain get 401.
+func CertAuthMiddleware(opts ...CertOption) func(http.Handler) http.Handler {
+	o := &certOptions{claims: DefaultCertClaims, writeError: WriteAuthError}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// VerifiedChains is empty unless the TLS server checked the
+			// chain, so certificates sent under RequestClientCert don't count.
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				o.writeError(w, r, unauthorizedError(CodeCertMissing, "client certificate required", nil))
+				return
+			}
+
+			claims, err := o.claims(r.TLS.VerifiedChains[0][0])
+			if err != nil {
+				o.writeError(w, r, unauthorizedError(CodeCertRejected, "client certificate rejected", err))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// DefaultCertClaims uses the SPIFFE ID (spiffe:// URI SAN) as subject,
+// falling back to the common name. Roles are left empty.
+func DefaultCertClaims(cert *x509.Certificate) (*Claims, error) {
+	subject, ok := SPIFFEID(cert)
+	if !ok {
+		subject = cert.Subject.CommonName
+	}
+	if subject == "" {
+		return nil, errors.New("certificate has neither SPIFFE ID nor common name")
+	}
+	return &Claims{Subject: subject, UserID: subject}, nil
+}
+
+// SPIFFEID returns the first spiffe:// URI SAN of cert.
+func SPIFFEID(cert *x509.Certificate) (string, bool) {
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+// NewMTLSConfig builds a server TLS config that requires client
+// certificates signed by one of the CAs in the given PEM files. Set
+// Certificates (or GetCertificate) for the server's own certificate.
+func NewMTLSConfig(caFiles ...string) (*tls.Config, error) {
+	if len(caFiles) == 0 {
+		return nil, errors.New("mtls: no client CA files")
+	}
+
+	pool := x509.NewCertPool()
+	for _, path := range caFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("mtls: read client CA: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("mtls: no certificates in %s", path)
+		}
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,285 @@
+package examples
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string // PEM file for NewMTLSConfig
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key := newECKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(nil, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(file, pemBlock("CERTIFICATE", der), 0o600))
+
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// clientCert issues a client certificate with the given CN and URI SANs.
+func (ca *testCA) clientCert(t *testing.T, cn string, uris ...string) tls.Certificate {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+
+	key := newECKey(t)
+	der, err := x509.CreateCertificate(nil, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newMTLSServer starts a TLS server requiring client certificates from ca.
+func newMTLSServer(t *testing.T, ca *testCA, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	cfg, err := NewMTLSConfig(ca.file)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = cfg
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// clientWithCert returns a client trusting srv and presenting cert.
+func clientWithCert(srv *httptest.Server, cert *tls.Certificate) *http.Client {
+	client := srv.Client()
+	transport := client.Transport.(*http.Transport).Clone()
+	if cert != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+	}
+	client.Transport = transport
+	return client
+}
+
+func subjectHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := ClaimsFromContext(r.Context())
+	json.NewEncoder(w).Encode(claims)
+}
+
+// ---------- CertAuthMiddleware Tests ----------
+
+func TestCertAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	srv := newMTLSServer(t, ca, CertAuthMiddleware()(http.HandlerFunc(subjectHandler)))
+
+	tests := []struct {
+		name        string
+		cert        tls.Certificate
+		wantSubject string
+	}{
+		{name: "SPIFFE ID", cert: ca.clientCert(t, "billing", "spiffe://example.org/ns/prod/sa/billing"),
+			wantSubject: "spiffe://example.org/ns/prod/sa/billing"},
+		{name: "common name fallback", cert: ca.clientCert(t, "billing", "https://billing.example.org"),
+			wantSubject: "billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := clientWithCert(srv, &tt.cert).Get(srv.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var claims Claims
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&claims))
+			assert.Equal(t, tt.wantSubject, claims.Subject)
+			assert.Equal(t, tt.wantSubject, claims.UserID)
+		})
+	}
+}
+
+func TestCertAuthMiddleware_HandshakeRejected(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	srv := newMTLSServer(t, ca, CertAuthMiddleware()(http.HandlerFunc(subjectHandler)))
+
+	foreign := newTestCA(t).clientCert(t, "intruder")
+
+	tests := []struct {
+		name string
+		cert *tls.Certificate
+	}{
+		{"no certificate", nil},
+		{"certificate from other CA", &foreign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := clientWithCert(srv, tt.cert).Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			assert.Error(t, err, "server verifies the chain before any handler runs")
+		})
+	}
+}
+
+func TestCertAuthMiddleware_Unverified(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	cert := ca.clientCert(t, "billing")
+	handler := CertAuthMiddleware()(http.HandlerFunc(subjectHandler))
+
+	plain := httptest.NewServer(handler)
+	t.Cleanup(plain.Close)
+
+	// Certificate requested but not verified: VerifiedChains stays empty.
+	unverified := httptest.NewUnstartedServer(handler)
+	unverified.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	unverified.StartTLS()
+	t.Cleanup(unverified.Close)
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		url    string
+	}{
+		{"plain HTTP", plain.Client(), plain.URL},
+		{"unverified certificate", clientWithCert(unverified, &cert), unverified.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := tt.client.Get(tt.url)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			var body errorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, CodeCertMissing, body.Code)
+		})
+	}
+}
+
+func TestCertAuthMiddleware_RolesMapping(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+
+	adminServices := map[string]bool{"spiffe://example.org/sa/ops": true}
+	mapping := func(cert *x509.Certificate) (*Claims, error) {
+		id, ok := SPIFFEID(cert)
+		if !ok {
+			return nil, errors.New("SPIFFE ID required")
+		}
+		claims := &Claims{Subject: id}
+		if adminServices[id] {
+			claims.Roles = []string{"admin"}
+		}
+		return claims, nil
+	}
+
+	handler := CertAuthMiddleware(WithCertClaims(mapping))(
+		RequireRoles("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	srv := newMTLSServer(t, ca, handler)
+
+	tests := []struct {
+		name       string
+		cert       tls.Certificate
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "admin service", cert: ca.clientCert(t, "ops", "spiffe://example.org/sa/ops"), wantStatus: http.StatusOK},
+		{name: "other service", cert: ca.clientCert(t, "billing", "spiffe://example.org/sa/billing"),
+			wantStatus: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "mapping error", cert: ca.clientCert(t, "legacy"),
+			wantStatus: http.StatusUnauthorized, wantCode: CodeCertRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := clientWithCert(srv, &tt.cert).Get(srv.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantCode != "" {
+				var body errorResponse
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, tt.wantCode, body.Code)
+			}
+		})
+	}
+}
+
+// ---------- NewMTLSConfig Tests ----------
+
+func TestNewMTLSConfig_Errors(t *testing.T) {
+	t.Parallel()
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not pem"), 0o600))
+
+	_, err := NewMTLSConfig()
+	assert.ErrorContains(t, err, "no client CA files")
+
+	_, err = NewMTLSConfig(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewMTLSConfig(notPEM)
+	assert.ErrorContains(t, err, "no certificates in")
+}
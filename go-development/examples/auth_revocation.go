@@ -0,0 +1,96 @@
+// Package examples provides token revocation by jti, backed by the cache client.
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapp/internal/cache"
+)
+
+// RevocationChecker reports whether a token, identified by its jti, was
+// revoked before it expired (logout, compromised account).
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// WithRevocationChecker makes Validate reject revoked tokens with
+// ErrTokenRevoked. Tokens without jti are rejected, since they can't be
+// revoked. Checker errors fail validation.
+func WithRevocationChecker(rc RevocationChecker) ValidatorOption {
+	return func(o *validatorOptions) {
+		o.revocation = rc
+	}
+}
+
+func (v *JWTValidator) checkRevoked(ctx context.Context, claims *Claims) error {
+	if v.revocation == nil {
+		return nil
+	}
+	if claims.ID == "" {
+		return fmt.Errorf("%w: jti", ErrMissingClaim)
+	}
+
+	revoked, err := v.revocation.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return fmt.Errorf("check revocation: %w", err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+const (
+	revokedKeyPrefix = "revoked_token:"
+	// revokeMargin keeps entries past exp, covering validator leeway.
+	revokeMargin = time.Minute
+)
+
+// CacheRevocations stores revoked token IDs in the cache until the token
+// would have expired anyway, so the list never outgrows the live tokens.
+type CacheRevocations struct {
+	client cache.Client
+}
+
+// NewCacheRevocations creates a revocation list on the cache client.
+func NewCacheRevocations(client cache.Client) *CacheRevocations {
+	return &CacheRevocations{client: client}
+}
+
+// IsRevoked implements RevocationChecker.
+func (r *CacheRevocations) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	res, err := r.client.ExecBatch(ctx, "token_revoked", cache.GetObj(revokedKeyPrefix+jti, &revoked))
+	if err != nil {
+		return false, err
+	}
+	if err := res[0].Err(); err != nil {
+		return false, err
+	}
+	return res[0].Val() != nil, nil
+}
+
+// Revoke marks the token as revoked for its remaining lifetime plus a
+// minute. Expired tokens are already rejected, so nothing is stored for them.
+func (r *CacheRevocations) Revoke(ctx context.Context, claims *Claims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("%w: jti", ErrMissingClaim)
+	}
+	if claims.ExpiresAt == 0 {
+		return errors.New("revoke: token without exp would stay revoked forever")
+	}
+
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0)) + revokeMargin
+	if ttl <= 0 {
+		return nil
+	}
+
+	res, err := r.client.ExecBatch(ctx, "token_revoke", cache.SetObjWithTTL(revokedKeyPrefix+claims.ID, true, ttl))
+	if err != nil {
+		return err
+	}
+	return res[0].Err()
+}
@@ -0,0 +1,180 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/cache"
+)
+
+// ---------- Test Helpers ----------
+
+func newRevocations(t *testing.T) (*CacheRevocations, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client, err := cache.NewRedisClient(context.Background(), &cache.RedisConfig{Server: mr.Addr()})
+	require.NoError(t, err)
+
+	return NewCacheRevocations(client), mr
+}
+
+type failingChecker struct{}
+
+func (failingChecker) IsRevoked(context.Context, string) (bool, error) {
+	return false, errors.New("cache unavailable")
+}
+
+// ---------- CacheRevocations Tests ----------
+
+func TestCacheRevocations_Revoke(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	revocations, mr := newRevocations(t)
+
+	claims := validClaims()
+	claims.ID = "token-1"
+	claims.ExpiresAt = time.Now().Add(10 * time.Minute).Unix()
+	require.NoError(t, revocations.Revoke(ctx, &claims))
+
+	revoked, err := revocations.IsRevoked(ctx, "token-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = revocations.IsRevoked(ctx, "token-2")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	ttl := mr.TTL(revokedKeyPrefix + "token-1")
+	assert.InDelta(t, (11 * time.Minute).Seconds(), ttl.Seconds(), 2, "remaining lifetime plus margin")
+
+	mr.FastForward(ttl)
+	revoked, err = revocations.IsRevoked(ctx, "token-1")
+	require.NoError(t, err)
+	assert.False(t, revoked, "entry dropped once the token expired")
+}
+
+func TestCacheRevocations_RevokeEdgeCases(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	revocations, mr := newRevocations(t)
+
+	expired := validClaims()
+	expired.ID = "expired"
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	require.NoError(t, revocations.Revoke(ctx, &expired))
+	assert.False(t, mr.Exists(revokedKeyPrefix+"expired"), "nothing stored for expired tokens")
+
+	noJTI := validClaims()
+	assert.ErrorIs(t, revocations.Revoke(ctx, &noJTI), ErrMissingClaim)
+
+	noExp := validClaims()
+	noExp.ID = "forever"
+	noExp.ExpiresAt = 0
+	assert.ErrorContains(t, revocations.Revoke(ctx, &noExp), "without exp")
+}
+
+// ---------- Validator Tests ----------
+
+func TestValidateContext_Revocation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	key := newSigningKey(t, "k1")
+	revocations, _ := newRevocations(t)
+
+	revoked := validClaims()
+	revoked.ID = "revoked"
+	require.NoError(t, revocations.Revoke(ctx, &revoked))
+
+	live := validClaims()
+	live.ID = "live"
+
+	tests := []struct {
+		name    string
+		checker RevocationChecker
+		claims  Claims
+		wantErr error
+	}{
+		{name: "live token", checker: revocations, claims: live},
+		{name: "revoked token", checker: revocations, claims: revoked, wantErr: ErrTokenRevoked},
+		{name: "token without jti", checker: revocations, claims: validClaims(), wantErr: ErrMissingClaim},
+		{name: "checker disabled", checker: nil, claims: revoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []ValidatorOption
+			if tt.checker != nil {
+				opts = append(opts, WithRevocationChecker(tt.checker))
+			}
+			v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api", opts...)
+
+			_, err := v.ValidateContext(ctx, key.signClaims(t, "k1", tt.claims))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateContext_CheckerErrorFailsClosed(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithRevocationChecker(failingChecker{}))
+
+	claims := validClaims()
+	claims.ID = "token-1"
+
+	_, err := v.Validate(key.signClaims(t, "k1", claims))
+	assert.ErrorContains(t, err, "cache unavailable")
+}
+
+// ---------- Middleware Tests ----------
+
+func TestJWTMiddleware_Revoked(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	revocations, _ := newRevocations(t)
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithRevocationChecker(revocations))
+	handler := JWTMiddleware(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	claims := validClaims()
+	claims.ID = "token-1"
+	token := key.signClaims(t, "k1", claims)
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+
+	require.NoError(t, revocations.Revoke(context.Background(), &claims))
+
+	rec := serve()
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"token_revoked"`)
+}
@@ -0,0 +1,135 @@
+// Package examples provides role hierarchy and custom policy checks on top
+// of the auth middlewares.
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+
+	"myapp/internal/errs"
+)
+
+// roleHierarchy maps each role to every role it implies, transitively.
+type roleHierarchy map[string]map[string]struct{}
+
+var currentRoles atomic.Pointer[roleHierarchy]
+
+// SetRoleHierarchy sets which roles imply others, e.g.
+// {"admin": {"user", "support"}} makes admins pass RequireRoles("user").
+// Implication is transitive. Cycles are rejected and leave the current
+// hierarchy in place; nil clears it. Call it at startup, before serving.
+func SetRoleHierarchy(h map[string][]string) error {
+	rh, err := newRoleHierarchy(h)
+	if err != nil {
+		return err
+	}
+	currentRoles.Store(&rh)
+	return nil
+}
+
+func newRoleHierarchy(h map[string][]string) (roleHierarchy, error) {
+	// Sorted, so a hierarchy with several cycles always reports the same one.
+	roles := make([]string, 0, len(h))
+	for role := range h {
+		roles = append(roles, role)
+	}
+	slices.Sort(roles)
+
+	rh := make(roleHierarchy, len(h))
+	for _, role := range roles {
+		implied := make(map[string]struct{})
+		if err := expandRole(h, role, implied, []string{role}); err != nil {
+			return nil, err
+		}
+		rh[role] = implied
+	}
+	return rh, nil
+}
+
+// expandRole adds every role reachable from the last role of path.
+func expandRole(h map[string][]string, role string, implied map[string]struct{}, path []string) error {
+	for _, child := range h[role] {
+		if slices.Contains(path, child) {
+			return fmt.Errorf("role hierarchy: cycle %v -> %s", path, child)
+		}
+		implied[child] = struct{}{}
+		if err := expandRole(h, child, implied, append(path, child)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasAnyRole checks userRoles against the hierarchy set by SetRoleHierarchy.
+func hasAnyRole(userRoles, requiredRoles []string) bool {
+	var rh roleHierarchy
+	if p := currentRoles.Load(); p != nil {
+		rh = *p
+	}
+	return rh.hasAnyRole(userRoles, requiredRoles)
+}
+
+// hasAnyRole reports whether userRoles, or roles they imply, include any of
+// requiredRoles. Roles missing from the hierarchy imply only themselves.
+func (rh roleHierarchy) hasAnyRole(userRoles, requiredRoles []string) bool {
+	roleSet := make(map[string]struct{}, len(userRoles))
+	for _, r := range userRoles {
+		roleSet[r] = struct{}{}
+		for implied := range rh[r] {
+			roleSet[implied] = struct{}{}
+		}
+	}
+	for _, r := range requiredRoles {
+		if _, ok := roleSet[r]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyFunc decides whether the caller may proceed. Return an error
+// wrapping errs.ErrForbidden (403) or errs.ErrUnauthorized (401) to deny;
+// other errors are written as they map in errs.HTTPStatus.
+type PolicyFunc func(ctx context.Context, claims *Claims) error
+
+// RequireFunc checks claims against an arbitrary policy, for rules roles
+// can't express (resource ownership, tenant, time of day). Requests
+// without claims get 401.
+func RequireFunc(policy PolicyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				WriteAuthError(w, r, unauthorizedError(CodeUnauthorized, "unauthorized", nil))
+				return
+			}
+
+			if err := policy(r.Context(), claims); err != nil {
+				WriteAuthError(w, r, policyError(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// policyError gives errs sentinels the auth error codes. Messages stay
+// generic: policy errors may describe the resource.
+func policyError(err error) error {
+	var authErr *AuthError
+	switch {
+	case errors.As(err, &authErr):
+		return err
+	case errors.Is(err, errs.ErrForbidden):
+		return &AuthError{Code: CodeForbidden, Message: "forbidden", Err: err}
+	case errors.Is(err, errs.ErrUnauthorized):
+		return &AuthError{Code: CodeUnauthorized, Message: "unauthorized", Err: err}
+	default:
+		return err
+	}
+}
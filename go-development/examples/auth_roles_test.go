@@ -0,0 +1,160 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/errs"
+)
+
+// ---------- Role Hierarchy Tests ----------
+
+func TestRoleHierarchy_HasAnyRole(t *testing.T) {
+	t.Parallel()
+
+	rh, err := newRoleHierarchy(map[string][]string{
+		"admin":   {"support", "user"},
+		"support": {"viewer"},
+		"billing": {"user", "viewer"}, // diamond through user and support
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		userRoles []string
+		required  []string
+		want      bool
+	}{
+		{name: "exact match", userRoles: []string{"user"}, required: []string{"user"}, want: true},
+		{name: "direct implication", userRoles: []string{"admin"}, required: []string{"user"}, want: true},
+		{name: "transitive implication", userRoles: []string{"admin"}, required: []string{"viewer"}, want: true},
+		{name: "no upward implication", userRoles: []string{"user"}, required: []string{"admin"}},
+		{name: "siblings unrelated", userRoles: []string{"support"}, required: []string{"billing"}},
+		{name: "any of several", userRoles: []string{"viewer", "billing"}, required: []string{"admin", "user"}, want: true},
+		{name: "unknown user role", userRoles: []string{"contractor"}, required: []string{"user"}},
+		{name: "unknown user role matches itself", userRoles: []string{"contractor"}, required: []string{"contractor"},
+			want: true},
+		{name: "unknown required role", userRoles: []string{"admin"}, required: []string{"superuser"}},
+		{name: "no roles", required: []string{"user"}},
+		{name: "nothing required", userRoles: []string{"admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, rh.hasAnyRole(tt.userRoles, tt.required))
+		})
+	}
+}
+
+func TestRoleHierarchy_Cycles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		h       map[string][]string
+		wantErr string
+	}{
+		{name: "self", h: map[string][]string{"admin": {"admin"}}, wantErr: "cycle [admin] -> admin"},
+		{name: "two roles", h: map[string][]string{"admin": {"user"}, "user": {"admin"}},
+			wantErr: "cycle [admin user] -> admin"},
+		{name: "three roles", h: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			wantErr: "cycle [a b c] -> a"},
+		{name: "behind acyclic root", h: map[string][]string{"admin": {"ops"}, "ops": {"oncall"}, "oncall": {"ops"}},
+			wantErr: "cycle [admin ops oncall] -> ops"},
+		{name: "diamond is not a cycle", h: map[string][]string{"admin": {"a", "b"}, "a": {"user"}, "b": {"user"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Map iteration is random; the reported cycle must not be.
+			for range 20 {
+				_, err := newRoleHierarchy(tt.h)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					continue
+				}
+				require.EqualError(t, err, "role hierarchy: "+tt.wantErr)
+			}
+		})
+	}
+}
+
+// Not parallel: SetRoleHierarchy changes package state.
+func TestSetRoleHierarchy(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetRoleHierarchy(nil)) })
+
+	require.NoError(t, SetRoleHierarchy(map[string][]string{"admin": {"user"}}))
+	assert.True(t, hasAnyRole([]string{"admin"}, []string{"user"}))
+
+	err := SetRoleHierarchy(map[string][]string{"user": {"user"}})
+	assert.ErrorContains(t, err, "cycle")
+	assert.True(t, hasAnyRole([]string{"admin"}, []string{"user"}), "previous hierarchy kept")
+
+	require.NoError(t, SetRoleHierarchy(nil))
+	assert.False(t, hasAnyRole([]string{"admin"}, []string{"user"}))
+}
+
+// ---------- RequireFunc Tests ----------
+
+func TestRequireFunc(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("policy store down")
+
+	tests := []struct {
+		name       string
+		policy     PolicyFunc
+		noClaims   bool
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "allowed", policy: func(context.Context, *Claims) error { return nil }, wantStatus: http.StatusOK},
+		{name: "forbidden", policy: func(_ context.Context, c *Claims) error {
+			return fmt.Errorf("%w: user %s does not own order", errs.ErrForbidden, c.UserID)
+		}, wantStatus: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "unauthorized", policy: func(context.Context, *Claims) error {
+			return errs.Unauthorizedf("policy", "step-up required")
+		}, wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{name: "auth error kept", policy: func(context.Context, *Claims) error {
+			return tokenError(ErrTokenExpired)
+		}, wantStatus: http.StatusUnauthorized, wantCode: CodeTokenExpired},
+		{name: "other error", policy: func(context.Context, *Claims) error { return errBoom },
+			wantStatus: http.StatusInternalServerError},
+		{name: "no claims", policy: func(context.Context, *Claims) error { return nil }, noClaims: true,
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := RequireFunc(tt.policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+			if !tt.noClaims {
+				req = req.WithContext(context.WithValue(req.Context(), claimsContextKey, &Claims{UserID: "42"}))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			resp := decodeAuthError(t, rec)
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.NotContains(t, resp.Error, "order", "policy details not leaked")
+		})
+	}
+}
@@ -0,0 +1,384 @@
+// Package examples provides cookie sessions for browser clients, sharing
+// the Claims context of the JWT middlewares.
+package examples
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Session errors.
+var (
+	ErrSessionInvalid = errors.New("invalid session")
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// SessionManager issues and validates HttpOnly session cookies holding
+// Claims. Cookies are signed with HMAC-SHA256, or encrypted with
+// AES-256-GCM when WithEncryptedCookie is set.
+type SessionManager struct {
+	opts   sessionOptions
+	macKey []byte
+	aead   cipher.AEAD // nil unless encrypted
+	now    func() time.Time
+}
+
+// SessionOption configures a SessionManager.
+type SessionOption func(*sessionOptions)
+
+type sessionOptions struct {
+	name        string
+	path        string
+	domain      string
+	secure      bool
+	sameSite    http.SameSite
+	encrypted   bool
+	rolling     time.Duration
+	maxAge      time.Duration
+	cookieFirst bool
+	writeError  ErrorWriter
+}
+
+// WithCookieName sets the cookie name. Default "session".
+func WithCookieName(name string) SessionOption {
+	return func(o *sessionOptions) {
+		o.name = name
+	}
+}
+
+// WithCookiePath sets the cookie Path. Default "/".
+func WithCookiePath(path string) SessionOption {
+	return func(o *sessionOptions) {
+		o.path = path
+	}
+}
+
+// WithCookieDomain sets the cookie Domain. By default the cookie is sent
+// to the issuing host only.
+func WithCookieDomain(domain string) SessionOption {
+	return func(o *sessionOptions) {
+		o.domain = domain
+	}
+}
+
+// WithInsecureCookie drops the Secure attribute, for local development
+// over plain HTTP.
+func WithInsecureCookie() SessionOption {
+	return func(o *sessionOptions) {
+		o.secure = false
+	}
+}
+
+// WithSameSite sets the SameSite attribute. Default Lax.
+func WithSameSite(mode http.SameSite) SessionOption {
+	return func(o *sessionOptions) {
+		o.sameSite = mode
+	}
+}
+
+// WithEncryptedCookie encrypts the cookie instead of only signing it, so
+// claims such as email aren't readable by the client.
+func WithEncryptedCookie() SessionOption {
+	return func(o *sessionOptions) {
+		o.encrypted = true
+	}
+}
+
+// DefaultMaxSessionAge caps rolling sessions unless WithMaxSessionAge
+// sets another limit.
+const DefaultMaxSessionAge = 7 * 24 * time.Hour
+
+// WithRollingExpiration makes sessions expire after idle time without
+// requests instead of at a fixed time. The cookie is re-issued once less
+// than half of idle remains, not on every request. Rolling never extends
+// a session past its maximum age (DefaultMaxSessionAge), so a stolen
+// cookie can't be kept alive forever.
+func WithRollingExpiration(idle time.Duration) SessionOption {
+	return func(o *sessionOptions) {
+		o.rolling = idle
+	}
+}
+
+// WithMaxSessionAge caps a session's lifetime from login (iat), however
+// often it is used: Issue and rolling never set exp later than iat plus
+// maxAge, and older sessions are rejected as expired.
+func WithMaxSessionAge(maxAge time.Duration) SessionOption {
+	return func(o *sessionOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithCookiePrecedence makes the session cookie win over claims a token
+// middleware already stored in context. By default the token wins.
+func WithCookiePrecedence() SessionOption {
+	return func(o *sessionOptions) {
+		o.cookieFirst = true
+	}
+}
+
+// WithSessionErrorWriter replaces WriteAuthError in Middleware.
+func WithSessionErrorWriter(ew ErrorWriter) SessionOption {
+	return func(o *sessionOptions) {
+		o.writeError = ew
+	}
+}
+
+// NewSessionManager creates a session manager. The secret must be at
+// least 32 bytes; sessions issued with one secret fail with another.
+func NewSessionManager(secret []byte, opts ...SessionOption) (*SessionManager, error) {
+	if len(secret) < minHMACSecret {
+		return nil, fmt.Errorf("session: secret must be at least %d bytes", minHMACSecret)
+	}
+
+	o := sessionOptions{
+		name:       "session",
+		path:       "/",
+		secure:     true,
+		sameSite:   http.SameSiteLaxMode,
+		writeError: WriteAuthError,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.rolling > 0 && o.maxAge <= 0 {
+		o.maxAge = DefaultMaxSessionAge
+	}
+
+	m := &SessionManager{
+		opts:   o,
+		macKey: deriveKey(secret, "session-mac"),
+		now:    time.Now,
+	}
+
+	if o.encrypted {
+		block, err := aes.NewCipher(deriveKey(secret, "session-enc"))
+		if err != nil {
+			return nil, fmt.Errorf("session: create cipher: %w", err)
+		}
+		if m.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("session: create cipher: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// deriveKey gives signing and encryption separate keys from one secret.
+func deriveKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// Issue writes a session cookie for claims, valid for ttl but no longer
+// than the maximum session age. It sets iat and exp on the stored claims;
+// call it after login.
+func (m *SessionManager) Issue(w http.ResponseWriter, claims Claims, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("session: ttl must be positive")
+	}
+	if m.opts.maxAge > 0 {
+		ttl = min(ttl, m.opts.maxAge)
+	}
+
+	now := m.now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	return m.write(w, &claims)
+}
+
+// Destroy expires the session cookie; call it on logout. The cookie is
+// only as revocable as the browser honours this: a copied cookie stays
+// valid until exp.
+func (m *SessionManager) Destroy(w http.ResponseWriter) {
+	http.SetCookie(w, m.cookie("", -1, time.Unix(0, 0)))
+}
+
+// Middleware authenticates requests by session cookie and stores the
+// claims in context, like JWTMiddleware. Requests whose context already
+// holds claims from a token middleware keep them unless
+// WithCookiePrecedence is set; requests with neither get 401.
+func (m *SessionManager) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hasToken := IsAuthenticated(r.Context())
+			if hasToken && !m.opts.cookieFirst {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := m.read(r)
+			if err != nil {
+				if hasToken {
+					next.ServeHTTP(w, r)
+					return
+				}
+				m.opts.writeError(w, r, sessionError(err))
+				return
+			}
+
+			if err := m.roll(w, claims); err != nil {
+				m.opts.writeError(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// roll extends a rolling session once less than half its idle time
+// remains, up to its maximum age.
+func (m *SessionManager) roll(w http.ResponseWriter, claims *Claims) error {
+	if m.opts.rolling <= 0 {
+		return nil
+	}
+
+	now := m.now()
+	if time.Unix(claims.ExpiresAt, 0).Sub(now) >= m.opts.rolling/2 {
+		return nil
+	}
+
+	exp := min(now.Add(m.opts.rolling).Unix(), m.maxExpiry(claims))
+	if exp <= claims.ExpiresAt {
+		return nil // at the cap already
+	}
+	claims.ExpiresAt = exp
+	return m.write(w, claims)
+}
+
+// maxExpiry returns the latest exp claims may have: iat plus the maximum
+// session age, or no limit without one.
+func (m *SessionManager) maxExpiry(claims *Claims) int64 {
+	if m.opts.maxAge <= 0 {
+		return math.MaxInt64
+	}
+	return time.Unix(claims.IssuedAt, 0).Add(m.opts.maxAge).Unix()
+}
+
+func (m *SessionManager) write(w http.ResponseWriter, claims *Claims) error {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return fmt.Errorf("session: encode claims: %w", err)
+	}
+
+	value, err := m.seal(payload)
+	if err != nil {
+		return err
+	}
+
+	expires := time.Unix(claims.ExpiresAt, 0)
+	http.SetCookie(w, m.cookie(value, int(claims.ExpiresAt-m.now().Unix()), expires))
+	return nil
+}
+
+func (m *SessionManager) read(r *http.Request) (*Claims, error) {
+	c, err := r.Cookie(m.opts.name)
+	if err != nil {
+		return nil, http.ErrNoCookie
+	}
+
+	payload, err := m.open(c.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSessionInvalid, err)
+	}
+	now := m.now().Unix()
+	if now >= claims.ExpiresAt || now >= m.maxExpiry(&claims) {
+		return nil, ErrSessionExpired
+	}
+	return &claims, nil
+}
+
+func (m *SessionManager) cookie(value string, maxAge int, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.opts.name,
+		Value:    value,
+		Path:     m.opts.path,
+		Domain:   m.opts.domain,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   m.opts.secure,
+		HttpOnly: true,
+		SameSite: m.opts.sameSite,
+	}
+}
+
+// seal signs or encrypts payload. The cookie name is bound in as well, so
+// a value can't be replayed under another cookie.
+func (m *SessionManager) seal(payload []byte) (string, error) {
+	if m.aead != nil {
+		nonce := make([]byte, m.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("session: nonce: %w", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(m.aead.Seal(nonce, nonce, payload, []byte(m.opts.name))), nil
+	}
+
+	data := base64.RawURLEncoding.EncodeToString(payload)
+	return data + "." + base64.RawURLEncoding.EncodeToString(m.sign(data)), nil
+}
+
+func (m *SessionManager) open(value string) ([]byte, error) {
+	if m.aead != nil {
+		raw, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(raw) < m.aead.NonceSize() {
+			return nil, ErrSessionInvalid
+		}
+		nonce, sealed := raw[:m.aead.NonceSize()], raw[m.aead.NonceSize():]
+		payload, err := m.aead.Open(nil, nonce, sealed, []byte(m.opts.name))
+		if err != nil {
+			return nil, ErrSessionInvalid
+		}
+		return payload, nil
+	}
+
+	data, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, ErrSessionInvalid
+	}
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, m.sign(data)) {
+		return nil, ErrSessionInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrSessionInvalid
+	}
+	return payload, nil
+}
+
+func (m *SessionManager) sign(data string) []byte {
+	mac := hmac.New(sha256.New, m.macKey)
+	mac.Write([]byte(m.opts.name + "|" + data))
+	return mac.Sum(nil)
+}
+
+// sessionError classifies a session read error.
+func sessionError(err error) *AuthError {
+	switch {
+	case errors.Is(err, http.ErrNoCookie):
+		return unauthorizedError(CodeSessionMissing, "missing session", nil)
+	case errors.Is(err, ErrSessionExpired):
+		return unauthorizedError(CodeSessionExpired, "session expired", err)
+	default:
+		return unauthorizedError(CodeSessionInvalid, "invalid session", err)
+	}
+}
@@ -0,0 +1,398 @@
+package examples
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+var sessionSecret = []byte(strings.Repeat("k", 32))
+
+// testClock is a settable clock for SessionManager.now.
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newSessions(t *testing.T, opts ...SessionOption) (*SessionManager, *testClock) {
+	t.Helper()
+
+	m, err := NewSessionManager(sessionSecret, opts...)
+	require.NoError(t, err)
+
+	clock := &testClock{t: time.Now()}
+	m.now = clock.now
+	return m, clock
+}
+
+// issueCookie issues a session and returns its cookie.
+func issueCookie(t *testing.T, m *SessionManager, claims Claims, ttl time.Duration) *http.Cookie {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, m.Issue(rec, claims, ttl))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+// serveSession runs req through the session middleware, recording the
+// claims the handler saw.
+func serveSession(m *SessionManager, req *http.Request) (*httptest.ResponseRecorder, *Claims) {
+	var got *Claims
+	handler := m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ClaimsFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, got
+}
+
+func requestWithCookie(c *http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/account", nil)
+	if c != nil {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+// ---------- SessionManager Tests ----------
+
+func TestSessionManager_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []SessionOption
+	}{
+		{"signed", nil},
+		{"encrypted", []SessionOption{WithEncryptedCookie()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, clock := newSessions(t, tt.opts...)
+			cookie := issueCookie(t, m, Claims{UserID: "42", Email: "ada@example.com", Roles: []string{"user"}}, time.Hour)
+
+			rec, claims := serveSession(m, requestWithCookie(cookie))
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.NotNil(t, claims)
+			assert.Equal(t, "42", claims.UserID)
+			assert.Equal(t, []string{"user"}, claims.Roles)
+			assert.Equal(t, clock.t.Unix(), claims.IssuedAt)
+			assert.Equal(t, clock.t.Add(time.Hour).Unix(), claims.ExpiresAt)
+			assert.Empty(t, rec.Result().Cookies(), "not re-issued without rolling expiration")
+		})
+	}
+}
+
+func TestSessionManager_EncryptedHidesClaims(t *testing.T) {
+	t.Parallel()
+
+	signed, _ := newSessions(t)
+	encrypted, _ := newSessions(t, WithEncryptedCookie())
+	claims := Claims{Email: "ada@example.com"}
+
+	decoded := func(c *http.Cookie) string {
+		payload, _, _ := strings.Cut(c.Value, ".")
+		b, _ := base64.RawURLEncoding.DecodeString(payload)
+		return string(b)
+	}
+
+	assert.Contains(t, decoded(issueCookie(t, signed, claims, time.Hour)), "ada@example.com")
+	assert.NotContains(t, decoded(issueCookie(t, encrypted, claims, time.Hour)), "ada@example.com")
+}
+
+func TestSessionManager_CookieAttributes(t *testing.T) {
+	t.Parallel()
+
+	defaults, _ := newSessions(t)
+	c := issueCookie(t, defaults, Claims{UserID: "42"}, time.Hour)
+
+	assert.Equal(t, "session", c.Name)
+	assert.Equal(t, "/", c.Path)
+	assert.True(t, c.HttpOnly)
+	assert.True(t, c.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+	assert.Equal(t, int(time.Hour.Seconds()), c.MaxAge)
+
+	custom, _ := newSessions(t, WithCookieName("__Host-sid"), WithCookiePath("/app"),
+		WithCookieDomain("example.com"), WithSameSite(http.SameSiteStrictMode), WithInsecureCookie())
+	c = issueCookie(t, custom, Claims{UserID: "42"}, time.Hour)
+
+	assert.Equal(t, "__Host-sid", c.Name)
+	assert.Equal(t, "/app", c.Path)
+	assert.Equal(t, "example.com", c.Domain)
+	assert.True(t, c.HttpOnly, "always HttpOnly")
+	assert.False(t, c.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, c.SameSite)
+}
+
+func TestSessionManager_Tampered(t *testing.T) {
+	t.Parallel()
+
+	m, _ := newSessions(t)
+	enc, _ := newSessions(t, WithEncryptedCookie())
+	otherName, _ := newSessions(t, WithCookieName("admin_session"))
+	otherSecret, err := NewSessionManager([]byte(strings.Repeat("x", 32)))
+	require.NoError(t, err)
+
+	user := Claims{UserID: "42", Roles: []string{"user"}}
+	cookie := issueCookie(t, m, user, time.Hour)
+	payload, sig, _ := strings.Cut(cookie.Value, ".")
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	escalated := strings.Replace(string(raw), `"user"`, `"admin"`, 1)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(escalated)) + "." + sig
+
+	encCookie := issueCookie(t, enc, user, time.Hour)
+	encRaw, err := base64.RawURLEncoding.DecodeString(encCookie.Value)
+	require.NoError(t, err)
+	encRaw[len(encRaw)-1] ^= 1
+
+	renamed := issueCookie(t, m, user, time.Hour)
+	renamed.Name = "admin_session"
+
+	tests := []struct {
+		name   string
+		m      *SessionManager
+		cookie *http.Cookie
+	}{
+		{name: "payload changed", m: m, cookie: &http.Cookie{Name: "session", Value: forged}},
+		{name: "signature changed", m: m, cookie: &http.Cookie{Name: "session", Value: payload + ".AAAA"}},
+		{name: "signature missing", m: m, cookie: &http.Cookie{Name: "session", Value: payload}},
+		{name: "garbage", m: m, cookie: &http.Cookie{Name: "session", Value: "%%%"}},
+		{name: "other secret", m: otherSecret, cookie: cookie},
+		{name: "replayed under other name", m: otherName, cookie: renamed},
+		{name: "ciphertext changed", m: enc,
+			cookie: &http.Cookie{Name: "session", Value: base64.RawURLEncoding.EncodeToString(encRaw)}},
+		{name: "signed cookie to encrypted manager", m: enc, cookie: cookie},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec, claims := serveSession(tt.m, requestWithCookie(tt.cookie))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Nil(t, claims)
+			assert.Equal(t, CodeSessionInvalid, decodeAuthError(t, rec).Code)
+		})
+	}
+}
+
+func TestSessionManager_Expired(t *testing.T) {
+	t.Parallel()
+
+	m, clock := newSessions(t)
+	cookie := issueCookie(t, m, Claims{UserID: "42"}, time.Hour)
+
+	clock.advance(time.Hour - time.Second)
+	rec, _ := serveSession(m, requestWithCookie(cookie))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	clock.advance(time.Second)
+	rec, claims := serveSession(m, requestWithCookie(cookie))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, claims)
+	assert.Equal(t, CodeSessionExpired, decodeAuthError(t, rec).Code)
+}
+
+func TestSessionManager_Missing(t *testing.T) {
+	t.Parallel()
+
+	m, _ := newSessions(t)
+	rec, _ := serveSession(m, requestWithCookie(nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, CodeSessionMissing, decodeAuthError(t, rec).Code)
+}
+
+func TestSessionManager_Destroy(t *testing.T) {
+	t.Parallel()
+
+	m, _ := newSessions(t, WithCookieName("sid"), WithCookiePath("/app"))
+	rec := httptest.NewRecorder()
+	m.Destroy(rec)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "sid", cookies[0].Name)
+	assert.Equal(t, "/app", cookies[0].Path, "same path, or the browser keeps the original")
+	assert.Empty(t, cookies[0].Value)
+	assert.Less(t, cookies[0].MaxAge, 0)
+}
+
+func TestSessionManager_Issue_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSessionManager([]byte("short"))
+	assert.ErrorContains(t, err, "at least 32 bytes")
+
+	m, _ := newSessions(t)
+	assert.ErrorContains(t, m.Issue(httptest.NewRecorder(), Claims{}, 0), "ttl must be positive")
+}
+
+func TestSessionManager_Rolling(t *testing.T) {
+	t.Parallel()
+
+	m, clock := newSessions(t, WithRollingExpiration(30*time.Minute))
+	cookie := issueCookie(t, m, Claims{UserID: "42"}, 30*time.Minute)
+
+	clock.advance(10 * time.Minute)
+	rec, _ := serveSession(m, requestWithCookie(cookie))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Result().Cookies(), "more than half the idle time left")
+
+	clock.advance(10 * time.Minute)
+	rec, claims := serveSession(m, requestWithCookie(cookie))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, rec.Result().Cookies(), 1, "re-issued")
+	assert.Equal(t, clock.t.Add(30*time.Minute).Unix(), claims.ExpiresAt)
+	cookie = rec.Result().Cookies()[0]
+
+	clock.advance(25 * time.Minute) // past the original expiry
+	rec, _ = serveSession(m, requestWithCookie(cookie))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	clock.advance(31 * time.Minute) // idle too long
+	rec, _ = serveSession(m, requestWithCookie(cookie))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSessionManager_RollingMaxAge(t *testing.T) {
+	t.Parallel()
+
+	m, clock := newSessions(t, WithRollingExpiration(30*time.Minute), WithMaxSessionAge(time.Hour))
+	issuedAt := clock.t
+	cookie := issueCookie(t, m, Claims{UserID: "42"}, 30*time.Minute)
+
+	clock.advance(20 * time.Minute)
+	rec, claims := serveSession(m, requestWithCookie(cookie))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, rec.Result().Cookies(), 1, "re-issued")
+	assert.Equal(t, clock.t.Add(30*time.Minute).Unix(), claims.ExpiresAt)
+	cookie = rec.Result().Cookies()[0]
+
+	clock.advance(20 * time.Minute)
+	rec, claims = serveSession(m, requestWithCookie(cookie))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, rec.Result().Cookies(), 1, "re-issued")
+	assert.Equal(t, issuedAt.Add(time.Hour).Unix(), claims.ExpiresAt, "capped at iat plus max age")
+	cookie = rec.Result().Cookies()[0]
+
+	clock.advance(20 * time.Minute) // active, but an hour after login
+	rec, _ = serveSession(m, requestWithCookie(cookie))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, CodeSessionExpired, decodeAuthError(t, rec).Code)
+}
+
+func TestSessionManager_MaxAge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Issue caps ttl", func(t *testing.T) {
+		t.Parallel()
+
+		m, clock := newSessions(t, WithMaxSessionAge(time.Hour))
+		cookie := issueCookie(t, m, Claims{UserID: "42"}, 24*time.Hour)
+		assert.Equal(t, clock.t.Add(time.Hour).Unix(), cookie.Expires.Unix())
+	})
+
+	t.Run("rolling defaults to DefaultMaxSessionAge", func(t *testing.T) {
+		t.Parallel()
+
+		m, _ := newSessions(t, WithRollingExpiration(time.Hour))
+		assert.Equal(t, DefaultMaxSessionAge, m.opts.maxAge)
+	})
+
+	t.Run("rejects a cookie past the cap", func(t *testing.T) {
+		t.Parallel()
+
+		// A cookie sealed with an exp beyond iat plus the max age
+		m, clock := newSessions(t, WithMaxSessionAge(time.Hour))
+		rec := httptest.NewRecorder()
+		claims := Claims{UserID: "42", IssuedAt: clock.t.Unix(), ExpiresAt: clock.t.Add(48 * time.Hour).Unix()}
+		require.NoError(t, m.write(rec, &claims))
+
+		clock.advance(2 * time.Hour)
+		got, _ := serveSession(m, requestWithCookie(rec.Result().Cookies()[0]))
+		assert.Equal(t, http.StatusUnauthorized, got.Code)
+	})
+}
+
+// ---------- Token Coexistence Tests ----------
+
+func TestSessionManager_WithToken(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api")
+	token := "Bearer " + key.sign(t, "k1") // user 42
+
+	tokenFirst, _ := newSessions(t)
+	cookieFirst, _ := newSessions(t, WithCookiePrecedence())
+	browser := Claims{UserID: "7"}
+
+	tests := []struct {
+		name       string
+		m          *SessionManager
+		auth       string
+		cookie     bool
+		badCookie  bool
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "token only", m: tokenFirst, auth: token, wantStatus: http.StatusOK, wantUser: "42"},
+		{name: "cookie only", m: tokenFirst, cookie: true, wantStatus: http.StatusOK, wantUser: "7"},
+		{name: "both, token wins", m: tokenFirst, auth: token, cookie: true, wantStatus: http.StatusOK, wantUser: "42"},
+		{name: "both, cookie wins", m: cookieFirst, auth: token, cookie: true, wantStatus: http.StatusOK,
+			wantUser: "7"},
+		{name: "cookie wins but invalid", m: cookieFirst, auth: token, badCookie: true, wantStatus: http.StatusOK,
+			wantUser: "42"},
+		{name: "neither", m: tokenFirst, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotUser string
+			handler := OptionalJWTMiddleware(v)(tt.m.Middleware()(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					claims, _ := ClaimsFromContext(r.Context())
+					gotUser = claims.UserID
+				})))
+
+			req := httptest.NewRequest(http.MethodGet, "/account", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if tt.cookie {
+				req.AddCookie(issueCookie(t, tt.m, browser, time.Hour))
+			}
+			if tt.badCookie {
+				req.AddCookie(&http.Cookie{Name: "session", Value: "forged.sig"})
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantUser, gotUser)
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package examples
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+const testIssuer = "https://auth.example.com"
+
+// ---------- Test Helpers ----------
+
+type signingKey struct {
+	kid  string
+	priv *ecdsa.PrivateKey
+}
+
+func newSigningKey(t *testing.T, kid string) *signingKey {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, priv: priv}
+}
+
+// validClaims returns claims accepted by a validator for testIssuer.
+func validClaims() Claims {
+	return Claims{
+		Issuer:    testIssuer,
+		Subject:   "user-42",
+		Audience:  []string{"my-api"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		IssuedAt:  time.Now().Unix(),
+		UserID:    "42",
+	}
+}
+
+// sign issues a valid token for testIssuer, with kid in the header.
+func (k *signingKey) sign(t *testing.T, kid string) string {
+	t.Helper()
+	return k.signClaims(t, kid, validClaims())
+}
+
+func (k *signingKey) signClaims(t *testing.T, kid string, claims Claims) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: k.priv},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid),
+	)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+// withHeader replaces the token header, keeping payload and signature.
+func withHeader(t *testing.T, token string, header map[string]string) string {
+	t.Helper()
+
+	data, err := json.Marshal(header)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	return base64.RawURLEncoding.EncodeToString(data) + "." + parts[1] + "." + parts[2]
+}
+
+// ---------- Validate Tests ----------
+
+func TestValidate_TimeClaims(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	now := time.Now()
+	ago := func(d time.Duration) int64 { return now.Add(-d).Unix() }
+
+	tests := []struct {
+		name    string
+		opts    []ValidatorOption
+		claims  func(c *Claims)
+		wantErr error
+	}{
+		{name: "valid", claims: func(c *Claims) {}},
+		{name: "expired", claims: func(c *Claims) { c.ExpiresAt = ago(10 * time.Second) }, wantErr: ErrTokenExpired},
+		{name: "expired within leeway", opts: []ValidatorOption{WithLeeway(30 * time.Second)},
+			claims: func(c *Claims) { c.ExpiresAt = ago(10 * time.Second) }},
+		{name: "expired beyond leeway", opts: []ValidatorOption{WithLeeway(30 * time.Second)},
+			claims: func(c *Claims) { c.ExpiresAt = ago(time.Minute) }, wantErr: ErrTokenExpired},
+		{name: "not yet valid", claims: func(c *Claims) { c.NotBefore = ago(-10 * time.Second) }, wantErr: ErrTokenNotYetValid},
+		{name: "nbf within leeway", opts: []ValidatorOption{WithLeeway(30 * time.Second)},
+			claims: func(c *Claims) { c.NotBefore = ago(-10 * time.Second) }},
+		{name: "issued in the future", claims: func(c *Claims) { c.IssuedAt = ago(-10 * time.Second) }, wantErr: ErrTokenNotYetValid},
+		{name: "iat within leeway", opts: []ValidatorOption{WithLeeway(30 * time.Second)},
+			claims: func(c *Claims) { c.IssuedAt = ago(-10 * time.Second) }},
+		{name: "no exp accepted by default", claims: func(c *Claims) { c.ExpiresAt = 0 }},
+		{name: "younger than max age", opts: []ValidatorOption{WithMaxTokenAge(time.Hour)},
+			claims: func(c *Claims) { c.IssuedAt = ago(30 * time.Minute) }},
+		{name: "older than max age", opts: []ValidatorOption{WithMaxTokenAge(time.Hour)},
+			claims: func(c *Claims) { c.IssuedAt = ago(2 * time.Hour) }, wantErr: ErrTokenExpired},
+		{name: "max age within leeway", opts: []ValidatorOption{WithMaxTokenAge(time.Hour), WithLeeway(30 * time.Second)},
+			claims: func(c *Claims) { c.IssuedAt = ago(time.Hour + 10*time.Second) }},
+		{name: "max age without iat", opts: []ValidatorOption{WithMaxTokenAge(time.Hour)},
+			claims: func(c *Claims) { c.IssuedAt = 0 }, wantErr: ErrMissingClaim},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims := validClaims()
+			tt.claims(&claims)
+			v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api", tt.opts...)
+
+			got, err := v.Validate(key.signClaims(t, "k1", claims))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "42", got.UserID)
+		})
+	}
+}
+
+func TestValidate_RequiredClaims(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api",
+		WithRequiredClaims("exp", "iat", "sub", "jti"))
+
+	tests := []struct {
+		name    string
+		claims  func(c *Claims)
+		missing string
+	}{
+		{name: "all present", claims: func(c *Claims) {}},
+		{name: "no exp", claims: func(c *Claims) { c.ExpiresAt = 0 }, missing: "exp"},
+		{name: "no iat", claims: func(c *Claims) { c.IssuedAt = 0 }, missing: "iat"},
+		{name: "no sub", claims: func(c *Claims) { c.Subject = "" }, missing: "sub"},
+		{name: "no jti", claims: func(c *Claims) { c.ID = "" }, missing: "jti"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims := validClaims()
+			claims.ID = "token-1"
+			tt.claims(&claims)
+
+			_, err := v.Validate(key.signClaims(t, "k1", claims))
+
+			if tt.missing == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrMissingClaim)
+			assert.ErrorContains(t, err, tt.missing)
+		})
+	}
+}
+
+func TestValidate_UnknownRequiredClaimRejects(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api", WithRequiredClaims("email"))
+
+	_, err := v.Validate(key.sign(t, "k1"))
+	assert.ErrorIs(t, err, ErrMissingClaim, "only registered claims are supported")
+}
+
+// ---------- Algorithm Tests ----------
+
+func TestValidate_HMACSecret(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte(strings.Repeat("s", 32))
+	ecKey := newSigningKey(t, "k1")
+	issuer, err := NewHMACIssuer(secret, testIssuer)
+	require.NoError(t, err)
+
+	token, err := issuer.Issue(validClaims(), time.Minute)
+	require.NoError(t, err)
+
+	v := NewJWTValidator([]string{testIssuer}, []any{&ecKey.priv.PublicKey, secret}, "my-api")
+
+	claims, err := v.Validate(token)
+	require.NoError(t, err, "HS256 token verified with the secret")
+	assert.Equal(t, "42", claims.UserID)
+
+	_, err = v.Validate(ecKey.sign(t, "k1"))
+	assert.NoError(t, err, "asymmetric keys still work alongside secrets")
+
+	other := NewJWTValidator([]string{testIssuer}, []any{[]byte(strings.Repeat("x", 32))}, "my-api")
+	_, err = other.Validate(token)
+	assert.ErrorContains(t, err, "invalid signature")
+}
+
+func TestValidate_AllowedAlgorithms(t *testing.T) {
+	t.Parallel()
+
+	ecKey := newSigningKey(t, "k1")
+	rsaKey := newRSAKey(t)
+	secret := []byte(strings.Repeat("s", 32))
+
+	// Algorithm confusion: HS256 "signed" with the public key, which an
+	// attacker knows, hoping the validator treats it as an HMAC secret.
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	confused, err := NewHMACIssuer(pemBlock("PUBLIC KEY", pubDER), testIssuer)
+	require.NoError(t, err)
+	confusedToken, err := confused.Issue(validClaims(), time.Minute)
+	require.NoError(t, err)
+
+	hmacIssuer, err := NewHMACIssuer(secret, testIssuer)
+	require.NoError(t, err)
+	hmacToken, err := hmacIssuer.Issue(validClaims(), time.Minute)
+	require.NoError(t, err)
+
+	esToken := ecKey.sign(t, "k1")
+	payload := strings.Split(esToken, ".")[1]
+	noneHeader, err := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	require.NoError(t, err)
+	noneToken := base64.RawURLEncoding.EncodeToString(noneHeader) + "." + payload + "."
+
+	keys := []any{&ecKey.priv.PublicKey, &rsaKey.PublicKey, secret}
+
+	tests := []struct {
+		name    string
+		token   string
+		algs    []string
+		wantErr string
+	}{
+		{name: "allowed algorithm", token: esToken, algs: []string{"RS256", "ES256"}},
+		{name: "HS256 not allowed", token: hmacToken, algs: []string{"RS256", "ES256"}, wantErr: `algorithm not allowed: "HS256"`},
+		{name: "none not allowed", token: noneToken, algs: []string{"RS256", "ES256"}, wantErr: `algorithm not allowed: "none"`},
+		{name: "confusion with public key", token: confusedToken, algs: []string{"RS256", "ES256"}, wantErr: "algorithm not allowed"},
+		{name: "header claims other allowed alg", token: withHeader(t, esToken, map[string]string{"alg": "RS256", "kid": "k1"}),
+			algs: []string{"RS256", "ES256"}, wantErr: "invalid signature"},
+		{name: "none rejected without allow-list", token: noneToken, wantErr: "invalid signature"},
+		{name: "confusion rejected without allow-list", token: confusedToken, wantErr: "invalid signature"},
+		{name: "HS256 accepted without allow-list", token: hmacToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []ValidatorOption
+			if tt.algs != nil {
+				opts = append(opts, WithAllowedAlgorithms(tt.algs...))
+			}
+			v := NewJWTValidator([]string{testIssuer}, keys, "my-api", opts...)
+
+			_, err := v.Validate(tt.token)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// ---------- Middleware Tests ----------
+
+func TestOptionalJWTMiddleware(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api")
+
+	expired := validClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name       string
+		auth       string
+		opts       []MiddlewareOption
+		wantStatus int
+		wantUser   string // "" = anonymous
+	}{
+		{name: "absent", wantStatus: http.StatusOK},
+		{name: "valid", auth: "Bearer " + key.sign(t, "k1"), wantStatus: http.StatusOK, wantUser: "42"},
+		{name: "invalid", auth: "Bearer " + key.signClaims(t, "k1", expired), wantStatus: http.StatusUnauthorized},
+		{name: "bad format", auth: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+		{name: "lenient absent", opts: []MiddlewareOption{WithLenient()}, wantStatus: http.StatusOK},
+		{name: "lenient valid", auth: "Bearer " + key.sign(t, "k1"), opts: []MiddlewareOption{WithLenient()},
+			wantStatus: http.StatusOK, wantUser: "42"},
+		{name: "lenient invalid", auth: "Bearer " + key.signClaims(t, "k1", expired), opts: []MiddlewareOption{WithLenient()},
+			wantStatus: http.StatusOK},
+		{name: "lenient bad format", auth: "Basic dXNlcjpwYXNz", opts: []MiddlewareOption{WithLenient()},
+			wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			called := false
+			var gotUser string
+			handler := OptionalJWTMiddleware(v, tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if IsAuthenticated(r.Context()) {
+					claims, _ := ClaimsFromContext(r.Context())
+					gotUser = claims.UserID
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantStatus == http.StatusOK, called)
+			assert.Equal(t, tt.wantUser, gotUser)
+		})
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	other := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "my-api")
+
+	expired := validClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name       string
+		auth       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid", auth: "Bearer " + key.sign(t, "k1"), wantStatus: http.StatusOK},
+		{name: "absent", wantStatus: http.StatusUnauthorized, wantCode: CodeTokenMissing},
+		{name: "bad format", auth: "Token abc", wantStatus: http.StatusUnauthorized, wantCode: CodeTokenMalformed},
+		{name: "malformed", auth: "Bearer abc", wantStatus: http.StatusUnauthorized, wantCode: CodeTokenMalformed},
+		{name: "expired", auth: "Bearer " + key.signClaims(t, "k1", expired), wantStatus: http.StatusUnauthorized,
+			wantCode: CodeTokenExpired},
+		{name: "bad signature", auth: "Bearer " + other.sign(t, "k1"), wantStatus: http.StatusUnauthorized,
+			wantCode: CodeTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := JWTMiddleware(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.True(t, IsAuthenticated(r.Context()))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeAuthError(t, rec).Code)
+			}
+		})
+	}
+}
+
+// ---------- Audience Tests ----------
+
+func TestValidate_Audiences(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+
+	tests := []struct {
+		name     string
+		audience string
+		opts     []ValidatorOption
+		tokenAud []string
+		wantErr  bool
+	}{
+		{name: "single match", audience: "my-api", tokenAud: []string{"my-api"}},
+		{name: "single mismatch", audience: "my-api", tokenAud: []string{"other-api"}, wantErr: true},
+		{name: "token with several audiences", audience: "my-api", tokenAud: []string{"other-api", "my-api"}},
+		{name: "any of several accepted", opts: []ValidatorOption{WithAudiences("public-api", "admin-api")},
+			tokenAud: []string{"admin-api"}},
+		{name: "none of several accepted", opts: []ValidatorOption{WithAudiences("public-api", "admin-api")},
+			tokenAud: []string{"billing-api"}, wantErr: true},
+		{name: "positional and option combined", audience: "my-api", opts: []ValidatorOption{WithAudiences("admin-api")},
+			tokenAud: []string{"admin-api"}},
+		{name: "no audience configured", tokenAud: []string{"anything"}},
+		{name: "token without audience", audience: "my-api", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims := validClaims()
+			claims.Audience = tt.tokenAud
+			v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, tt.audience, tt.opts...)
+
+			_, err := v.Validate(key.signClaims(t, "k1", claims))
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAudience)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestJWTMiddleware_RouteAudience(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "k1")
+	v := NewJWTValidator([]string{testIssuer}, []any{&key.priv.PublicKey}, "", WithAudiences("public-api", "admin-api"))
+
+	tests := []struct {
+		name       string
+		opts       []MiddlewareOption
+		tokenAud   []string
+		wantStatus int
+	}{
+		{name: "no override", tokenAud: []string{"public-api"}, wantStatus: http.StatusOK},
+		{name: "override matches", opts: []MiddlewareOption{WithAudience("admin-api")},
+			tokenAud: []string{"public-api", "admin-api"}, wantStatus: http.StatusOK},
+		{name: "override rejects token for other group", opts: []MiddlewareOption{WithAudience("admin-api")},
+			tokenAud: []string{"public-api"}, wantStatus: http.StatusUnauthorized},
+		{name: "validator still applies", opts: []MiddlewareOption{WithAudience("billing-api")},
+			tokenAud: []string{"billing-api"}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			claims := validClaims()
+			claims.Audience = tt.tokenAud
+			handler := JWTMiddleware(v, tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+			req.Header.Set("Authorization", "Bearer "+key.signClaims(t, "k1", claims))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -14,8 +14,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+
+	httpmw "myapp/internal/middleware"
+	"myapp/internal/tracing"
 )
 
+// preStopDelay is how long stop keeps serving after readiness starts
+// failing, so the load balancer stops routing here before the listeners
+// close. Keep it above the readiness probe's periodSeconds.
+const preStopDelay = 5 * time.Second
+
 // BackgroundJob is the interface for background workers.
 type BackgroundJob interface {
 	Run(ctx context.Context) error
@@ -29,6 +37,7 @@ type backend struct {
 	// Infrastructure
 	pool     *pgxpool.Pool
 	registry *prometheus.Registry
+	tracer   *tracing.Provider
 
 	// Services (add your services here)
 	// userService    services.UserService
@@ -37,6 +46,7 @@ type backend struct {
 	// Servers
 	apiServer     *http.Server
 	monitorServer *http.Server
+	drainer       *httpmw.Drainer
 
 	// Background jobs
 	jobs []BackgroundJob
@@ -48,12 +58,17 @@ func newBackend(cfg *Config, logger *zap.Logger) *backend {
 		cfg:      cfg,
 		logger:   logger,
 		registry: prometheus.NewRegistry(),
+		drainer:  httpmw.NewDrainer(),
 		jobs:     make([]BackgroundJob, 0),
 	}
 }
 
 // init initializes all dependencies in order.
 func (be *backend) init(ctx context.Context) error {
+	if err := be.initTracing(ctx); err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+
 	if err := be.initDatabase(ctx); err != nil {
 		return fmt.Errorf("init database: %w", err)
 	}
@@ -66,6 +81,27 @@ func (be *backend) init(ctx context.Context) error {
 	return nil
 }
 
+// initTracing sets up the global tracer provider before anything that
+// creates spans (database pool, HTTP handlers).
+func (be *backend) initTracing(ctx context.Context) error {
+	cfg := be.cfg.Tracing // tracing.Config: protocol, endpoint, sample rate
+	cfg.ServiceName = be.cfg.AppName
+	cfg.ServiceVersion = Version
+	cfg.OnError = func(err error) {
+		be.logger.Warn("export traces", zap.Error(err))
+	}
+
+	tracer, err := tracing.InitTracer(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	be.tracer = tracer
+	be.logger.Info("tracing initialized", zap.String("endpoint", cfg.OTLPEndpoint))
+
+	return nil
+}
+
 // initDatabase establishes database connection.
 func (be *backend) initDatabase(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -115,8 +151,10 @@ func (be *backend) initServers() {
 	apiRouter := chi.NewRouter()
 	apiRouter.Use(middleware.RequestID)
 	apiRouter.Use(middleware.RealIP)
+	apiRouter.Use(httpmw.Drain(be.drainer, httpmw.WithDrainReject(time.Second)))
+	apiRouter.Use(httpmw.Metrics(be.registry)) // before Recoverer, so panics count as 500s
 	apiRouter.Use(middleware.Recoverer)
-	apiRouter.Use(middleware.Timeout(30 * time.Second))
+	apiRouter.Use(httpmw.TimeoutJSON(30*time.Second, httpmw.WithTimeoutCounter(httpmw.NewTimeoutCounter(be.registry))))
 
 	// Mount your handlers here
 	// userHandler := handlers.NewUserHandler(be.userService)
@@ -138,9 +176,12 @@ func (be *backend) initServers() {
 
 	// Monitor server (metrics + health)
 	monitorRouter := chi.NewRouter()
+	monitorRouter.Use(httpmw.Drain(be.drainer)) // fails /ready once stop starts
 	monitorRouter.Get("/health", be.healthHandler)
 	monitorRouter.Get("/ready", be.readyHandler)
 	monitorRouter.Handle("/metrics", promhttp.HandlerFor(be.registry, promhttp.HandlerOpts{}))
+	// monitorRouter.Mount(worker.AdminHandlerPathPrefix, worker.NewAdminHandler(be.emailPool))
+	// monitorRouter.With(adminAuth).Mount(httpmw.MaintenanceAdminPathPrefix, httpmw.NewMaintenanceAdminHandler(be.maintenance))
 
 	be.monitorServer = &http.Server{
 		Addr:         be.cfg.Monitor.Address,
@@ -195,7 +236,16 @@ func (be *backend) startJobs(ctx context.Context, eg *errgroup.Group) {
 
 // stop gracefully shuts down all components.
 func (be *backend) stop(ctx context.Context) {
-	// Stop API server
+	// Fail readiness and turn new requests away, then give the load
+	// balancer preStopDelay to notice before the listeners close
+	be.drainer.StartDraining()
+	be.logger.Info("draining", zap.Duration("pre_stop_delay", preStopDelay))
+	select {
+	case <-time.After(preStopDelay):
+	case <-ctx.Done():
+	}
+
+	// Stop API server, waiting for in-flight requests
 	if err := be.apiServer.Shutdown(ctx); err != nil {
 		be.logger.Error("shutdown API server", zap.Error(err))
 	}
@@ -210,6 +260,13 @@ func (be *backend) stop(ctx context.Context) {
 		be.pool.Close()
 	}
 
+	// Flush spans last, so spans of drained requests are exported too
+	if be.tracer != nil {
+		if err := be.tracer.Shutdown(ctx); err != nil {
+			be.logger.Error("shutdown tracer", zap.Error(err))
+		}
+	}
+
 	be.logger.Info("backend stopped")
 }
 
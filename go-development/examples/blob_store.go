@@ -0,0 +1,98 @@
+// Package storage provides a BlobStore on the local filesystem.
+// Place in: internal/storage/blob_store.go
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+)
+
+// LocalBlobStore writes blobs under a directory, for development and
+// single-node deployments, until uploads move to object storage. It
+// satisfies handler.BlobStore:
+//
+//	blobs, err := storage.NewLocalBlobStore("/var/lib/myapp/uploads", "https://cdn.example.com/uploads")
+//	if err != nil {
+//	    return err
+//	}
+//	defer blobs.Close()
+//	userHandler := handler.NewUserHandler(userService, handler.WithBlobStore(blobs))
+//
+// Serve the directory (or its CDN) at baseURL; the store only writes.
+type LocalBlobStore struct {
+	root    *os.Root
+	baseURL string
+}
+
+// NewLocalBlobStore creates dir if needed and opens it as the store's
+// root. Keys can't reach outside it, symlinks included.
+func NewLocalBlobStore(dir, baseURL string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open blob dir: %w", err)
+	}
+	return &LocalBlobStore{root: root, baseURL: baseURL}, nil
+}
+
+// Put writes r to key, a slash-separated relative path such as
+// "avatars/u1.png", and returns its URL under baseURL. The blob is
+// written to a temporary file and renamed into place, so readers see
+// the old blob or the new one, never part of it; on error nothing
+// changes. contentType is implied by the key's extension when served
+// from disk.
+func (s *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("put %q: invalid key", key)
+	}
+	if dir := path.Dir(key); dir != "." {
+		if err := s.root.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("put %s: %w", key, err)
+		}
+	}
+
+	tmp := key + ".tmp-" + rand.Text()
+	f, err := s.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("put %s: %w", key, err)
+	}
+	_, err = io.Copy(f, &ctxReader{ctx: ctx, r: r})
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = s.root.Rename(tmp, key)
+	}
+	if err != nil {
+		_ = s.root.Remove(tmp)
+		return "", fmt.Errorf("put %s: %w", key, err)
+	}
+
+	return url.JoinPath(s.baseURL, key)
+}
+
+// Close closes the root directory.
+func (s *LocalBlobStore) Close() error {
+	return s.root.Close()
+}
+
+// ctxReader stops a copy once ctx is done, e.g. when the client that is
+// uploading goes away.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,126 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/storage"
+)
+
+// LocalBlobStore tests use a real temporary directory.
+
+func newBlobStore(t *testing.T) (*storage.LocalBlobStore, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	store, err := storage.NewLocalBlobStore(filepath.Join(dir, "uploads"), "https://cdn.example.com/uploads/")
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store, filepath.Join(dir, "uploads")
+}
+
+func TestLocalBlobStore_Put(t *testing.T) {
+	t.Parallel()
+
+	store, dir := newBlobStore(t)
+	ctx := context.Background()
+
+	url, err := store.Put(ctx, "avatars/u1.png", strings.NewReader("first"), "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/uploads/avatars/u1.png", url)
+
+	_, err = store.Put(ctx, "avatars/u1.png", strings.NewReader("second"), "image/png")
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(filepath.Join(dir, "avatars", "u1.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(got), "replaced")
+
+	entries, err := os.ReadDir(filepath.Join(dir, "avatars"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files left")
+}
+
+func TestLocalBlobStore_InvalidKeys(t *testing.T) {
+	t.Parallel()
+
+	store, _ := newBlobStore(t)
+
+	for _, key := range []string{"", ".", "../escape.png", "/abs.png", "avatars/../../escape.png", "a//b.png"} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"), "image/png")
+		assert.Error(t, err, key)
+	}
+}
+
+func TestLocalBlobStore_SymlinkEscape(t *testing.T) {
+	t.Parallel()
+
+	store, dir := newBlobStore(t)
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+
+	_, err := store.Put(context.Background(), "link/escape.png", strings.NewReader("x"), "image/png")
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// failingReader returns some bytes, then err.
+type failingReader struct {
+	err  error
+	done bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, r.err
+	}
+	r.done = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalBlobStore_FailedWrite(t *testing.T) {
+	t.Parallel()
+
+	store, dir := newBlobStore(t)
+	ctx := context.Background()
+
+	_, err := store.Put(ctx, "avatars/u1.png", strings.NewReader("old"), "image/png")
+	require.NoError(t, err)
+
+	tooLarge := errors.New("file too large")
+	_, err = store.Put(ctx, "avatars/u1.png", &failingReader{err: tooLarge}, "image/png")
+	require.ErrorIs(t, err, tooLarge, "the reader's error is kept for the handler")
+
+	got, err := os.ReadFile(filepath.Join(dir, "avatars", "u1.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(got), "unchanged")
+
+	entries, err := os.ReadDir(filepath.Join(dir, "avatars"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file removed")
+}
+
+func TestLocalBlobStore_Canceled(t *testing.T) {
+	t.Parallel()
+
+	store, dir := newBlobStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := store.Put(ctx, "avatars/u1.png", io.LimitReader(strings.NewReader("data"), 4), "image/png")
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = os.Stat(filepath.Join(dir, "avatars", "u1.png"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
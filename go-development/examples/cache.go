@@ -202,6 +202,70 @@ func (r *delReq) handleCmdr(cmdr redis.Cmder) Res {
 	return &result{id: r.id, val: r.cmd.Val(), err: r.cmd.Err()}
 }
 
+// SetNXObjWithTTL creates a SET NX request with TTL.
+// Val() is true when the key was set, false when it already existed.
+func SetNXObjWithTTL(key string, obj any, ttl time.Duration) Req {
+	return &setNXReq{id: generateID(), key: key, obj: obj, ttl: ttl}
+}
+
+type setNXReq struct {
+	id   string
+	key  string
+	obj  any
+	ttl  time.Duration
+	data []byte
+	cmd  *redis.BoolCmd
+}
+
+func (r *setNXReq) getID() string { return r.id }
+func (r *setNXReq) prepareCmd() error {
+	data, err := json.Marshal(r.obj)
+	if err != nil {
+		return fmt.Errorf("marshal object: %w", err)
+	}
+	r.data = data
+	return nil
+}
+func (r *setNXReq) handlePipe(ctx context.Context, pipe redis.Pipeliner) {
+	r.cmd = pipe.SetNX(ctx, r.key, r.data, r.ttl)
+}
+func (r *setNXReq) handleCmdr(cmdr redis.Cmder) Res {
+	return &result{id: r.id, val: r.cmd.Val(), err: r.cmd.Err()}
+}
+
+// IncrWithTTL creates an INCR request that sets ttl when it creates the
+// key, so counters expire without resetting on every increment.
+// Val() is the new count as int64.
+func IncrWithTTL(key string, ttl time.Duration) Req {
+	return &incrReq{id: generateID(), key: key, ttl: ttl}
+}
+
+// incrScript runs INCR and PEXPIRE as one command, keeping one pipeline
+// command per request.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`)
+
+type incrReq struct {
+	id  string
+	key string
+	ttl time.Duration
+	cmd *redis.Cmd
+}
+
+func (r *incrReq) getID() string     { return r.id }
+func (r *incrReq) prepareCmd() error { return nil }
+func (r *incrReq) handlePipe(ctx context.Context, pipe redis.Pipeliner) {
+	r.cmd = incrScript.Eval(ctx, pipe, []string{r.key}, r.ttl.Milliseconds())
+}
+func (r *incrReq) handleCmdr(cmdr redis.Cmder) Res {
+	n, err := r.cmd.Int64()
+	return &result{id: r.id, val: n, err: err}
+}
+
 func generateID() string {
 	// Use UUID or similar in production
 	return fmt.Sprintf("%d", time.Now().UnixNano())
@@ -2,6 +2,7 @@
 package errs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -51,7 +52,12 @@ func Unauthorizedf(op, format string, args ...any) error {
 	return fmt.Errorf("%s: %w: %s", op, ErrUnauthorized, fmt.Sprintf(format, args...))
 }
 
-// HTTPStatus maps error to HTTP status code.
+// StatusClientClosedRequest is nginx's non-standard 499, for requests
+// the client canceled. Write no body: nobody reads it.
+const StatusClientClosedRequest = 499
+
+// HTTPStatus maps error to HTTP status code. Context errors count too:
+// context.DeadlineExceeded is a 504, context.Canceled a 499.
 func HTTPStatus(err error) int {
 	switch {
 	case errors.Is(err, ErrNotFound):
@@ -64,10 +70,12 @@ func HTTPStatus(err error) int {
 		return http.StatusForbidden
 	case errors.Is(err, ErrUnauthorized):
 		return http.StatusUnauthorized
-	case errors.Is(err, ErrTimeout):
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
 		return http.StatusGatewayTimeout
 	case errors.Is(err, ErrUnavailable):
 		return http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
 	default:
 		return http.StatusInternalServerError
 	}
@@ -86,15 +94,115 @@ func Message(err error) string {
 		return "forbidden"
 	case errors.Is(err, ErrUnauthorized):
 		return "unauthorized"
-	case errors.Is(err, ErrTimeout):
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
 		return "request timeout"
 	case errors.Is(err, ErrUnavailable):
 		return "service unavailable"
+	case errors.Is(err, context.Canceled):
+		return "request canceled"
 	default:
 		return "internal error"
 	}
 }
 
+// ---------- Coded Errors ----------
+
+// sentinels lists the categories FromCoded looks for, most specific
+// first, like HTTPStatus.
+var sentinels = []error{
+	ErrNotFound, ErrConflict, ErrValidation, ErrForbidden,
+	ErrUnauthorized, ErrTimeout, ErrUnavailable,
+}
+
+// codedError keeps a coded error's text and chain and adds its sentinel.
+type codedError struct {
+	err      error
+	sentinel error
+}
+
+func (e *codedError) Error() string   { return e.err.Error() }
+func (e *codedError) Unwrap() []error { return []error{e.err, e.sentinel} }
+
+// FromCoded makes a coded error from the errors package usable where
+// sentinels are compared directly: the result still matches errors.As
+// for the coded type, and its chain contains the sentinel of its code,
+// e.g. ErrNotFound for CodeNotFound. Coded errors already satisfy
+// errors.Is and HTTPStatus. Other errors, and coded ones with
+// CodeInternal, are returned as is.
+//
+// Coded errors are found by their Is method, so errs doesn't import the
+// errors package, which imports errs.
+func FromCoded(err error) error {
+	var coded interface{ Is(error) bool }
+	if !errors.As(err, &coded) {
+		return err
+	}
+	for _, s := range sentinels {
+		if coded.Is(s) {
+			return &codedError{err: err, sentinel: s}
+		}
+	}
+	return err
+}
+
+// ---------- Retry Classification ----------
+
+// retryMark overrides the classification of the error it wraps. The
+// coded errors package has the same marks; both satisfy
+// interface{ Retryable() bool }, which Retryable in either package and
+// the worker honor.
+type retryMark struct {
+	err       error
+	retryable bool
+}
+
+func (m *retryMark) Error() string   { return m.err.Error() }
+func (m *retryMark) Unwrap() error   { return m.err }
+func (m *retryMark) Retryable() bool { return m.retryable }
+
+// MarkRetryable marks err as transient: retrying may succeed.
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: true}
+}
+
+// MarkPermanent marks err as permanent: retrying never helps.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: false}
+}
+
+// Retryable reports whether retrying may succeed. On each branch of err,
+// the outermost mark decides; without one, ErrTimeout and ErrUnavailable
+// are retryable. A joined error is retryable only if every branch is.
+func Retryable(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if m, ok := e.(interface{ Retryable() bool }); ok {
+			return m.Retryable()
+		}
+		if e == ErrTimeout || e == ErrUnavailable { //nolint:errorlint // walking the chain by hand, so marks above win
+			return true
+		}
+		if coded, ok := e.(interface{ Is(error) bool }); ok && (coded.Is(ErrTimeout) || coded.Is(ErrUnavailable)) {
+			return true // a coded error with CodeTimeout or CodeUnavailable
+		}
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			branches := joined.Unwrap()
+			for _, branch := range branches {
+				if !Retryable(branch) {
+					return false
+				}
+			}
+			return len(branches) > 0
+		}
+	}
+	return false
+}
+
 // Usage:
 //
 //	// Repository: create sentinel
@@ -122,6 +230,10 @@ func Message(err error) string {
 //	// Handler: map to HTTP
 //	func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
 //	    status := errs.HTTPStatus(err)
+//	    if status == errs.StatusClientClosedRequest {
+//	        w.WriteHeader(status) // the client is gone
+//	        return
+//	    }
 //	    message := errs.Message(err)
 //	    if status == http.StatusInternalServerError {
 //	        h.logger.Error("internal error", slog.String("error", err.Error()))
@@ -0,0 +1,282 @@
+// Package errors provides coded application errors with HTTP mapping.
+//
+// It is the alternative to the sentinel errs package for services whose
+// API exposes a stable error code per failure. http_errors.go renders it.
+package errors
+
+import (
+	"net/http"
+	"strings"
+
+	"myapp/internal/errs"
+)
+
+// ErrorCode is a stable, client-visible error code.
+type ErrorCode string
+
+// Error codes.
+const (
+	CodeInvalid      ErrorCode = "invalid"
+	CodeNotFound     ErrorCode = "not_found"
+	CodeConflict     ErrorCode = "conflict"
+	CodeUnauthorized ErrorCode = "unauthorized"
+	CodeForbidden    ErrorCode = "forbidden"
+	CodeUnavailable  ErrorCode = "unavailable"
+	CodeTimeout      ErrorCode = "timeout"
+	CodeCanceled     ErrorCode = "canceled" // the client went away; see IsCanceled
+	CodeInternal     ErrorCode = "internal"
+)
+
+// Error is an application error with a code and a client-safe message.
+type Error struct {
+	Code    ErrorCode
+	Message string // client-safe; ignored for CodeInternal
+	Op      string // operation, e.g. "UserService.Create"
+	Err     error  // underlying error, for logs only
+
+	// Details is client-visible metadata, e.g. {"field": "email", "max": 100}.
+	// See WithDetail.
+	Details map[string]any
+
+	// Key is the catalog key of Message, e.g. "user.not_found"; without
+	// one, Localize uses the code's. See RegisterCatalog.
+	Key string
+
+	stack []uintptr // see Internal and E
+}
+
+// New creates an error with code and a client-safe message.
+func New(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Op != "" {
+		b.WriteString(e.Op)
+		b.WriteString(": ")
+	}
+	if e.Message != "" {
+		b.WriteString(e.Message)
+	} else {
+		b.WriteString(string(e.Code))
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ---------- Validation Errors ----------
+
+// FieldError is one failed validation rule.
+type FieldError struct {
+	Field   string // e.g. "email"
+	Rule    string // e.g. "required", "max"
+	Message string // client-safe, e.g. "email is required"
+}
+
+// ValidationErrors collects every failed rule of a request, so clients
+// can fix them all at once. It carries CodeInvalid.
+//
+//	var verrs errors.ValidationErrors
+//	if req.Name == "" {
+//	    verrs.Add("name", "required", "name is required")
+//	}
+//	if len(req.Tags) > 10 {
+//	    verrs.Add("tags", "max", "at most 10 tags")
+//	}
+//	return verrs.Err()
+type ValidationErrors []FieldError
+
+// Add records a failed rule.
+func (v *ValidationErrors) Add(field, rule, message string) {
+	*v = append(*v, FieldError{Field: field, Rule: rule, Message: message})
+}
+
+// Err returns v as an error, or nil if nothing failed. Return it instead
+// of v, which as an empty error value is still non-nil.
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, fe := range v {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// ToDetails maps field to message, for WriteValidationErrors. Messages
+// for the same field are joined with "; ".
+func (v ValidationErrors) ToDetails() map[string]string {
+	details := make(map[string]string, len(v))
+	for _, fe := range v {
+		if msg, ok := details[fe.Field]; ok {
+			details[fe.Field] = msg + "; " + fe.Message
+			continue
+		}
+		details[fe.Field] = fe.Message
+	}
+	return details
+}
+
+// ---------- HTTP Mapping ----------
+
+// severity ranks codes for joined errors; higher wins. Unknown codes
+// rank lowest.
+var severity = map[ErrorCode]int{
+	CodeNotFound:     1,
+	CodeInvalid:      2,
+	CodeUnauthorized: 3,
+	CodeForbidden:    4,
+	CodeConflict:     5,
+	CodeTimeout:      6,
+	CodeUnavailable:  7,
+	CodeCanceled:     8, // nobody reads the response
+	CodeInternal:     9,
+}
+
+// GetErrorCode returns err's code, or CodeInternal if it has none.
+//
+// A joined error (errors.Join, or fmt.Errorf with several %w) reports
+// its most severe branch:
+//
+//	Internal > Canceled > Unavailable > Timeout > Conflict > Forbidden > Unauthorized > Invalid > NotFound
+//
+// so a batch where one item is missing and another hit a dead database
+// is a 500, not a 404. errs sentinels, context.DeadlineExceeded
+// (CodeTimeout) and context.Canceled (CodeCanceled) are recognized; any
+// other branch without a code counts as CodeInternal.
+func GetErrorCode(err error) ErrorCode {
+	code, _ := classify(err)
+	return code
+}
+
+// Codes returns the code of every branch of err, in the order
+// errors.Join received them; one entry for a plain error, none for nil.
+func Codes(err error) []ErrorCode {
+	var codes []ErrorCode
+	walk(err, func(code ErrorCode, _ error) {
+		codes = append(codes, code)
+	})
+	return codes
+}
+
+// classify returns the most severe code in err and the first error
+// carrying it.
+func classify(err error) (ErrorCode, error) {
+	code, carrier := CodeInternal, error(nil)
+	walk(err, func(c ErrorCode, e error) {
+		if carrier == nil || severity[c] > severity[code] {
+			code, carrier = c, e
+		}
+	})
+	return code, carrier
+}
+
+// walk calls fn for each branch of err: with the first *Error or
+// ValidationErrors on it, else the code of the errs sentinel or context
+// error it ends in, else CodeInternal. Like errors.As, it follows Unwrap() error and
+// Unwrap() []error.
+func walk(err error, fn func(ErrorCode, error)) {
+	switch e := err.(type) {
+	case nil:
+		return
+	case *Error:
+		fn(e.Code, e)
+	case ValidationErrors:
+		fn(CodeInvalid, e)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			walk(inner, fn)
+		}
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			walk(inner, fn)
+			return
+		}
+		fn(leafCode(err), err)
+	default:
+		fn(leafCode(err), err)
+	}
+}
+
+func leafCode(err error) ErrorCode {
+	if code, ok := sentinelCode(err); ok {
+		return code
+	}
+	if code, ok := contextCode(err); ok {
+		return code
+	}
+	return CodeInternal
+}
+
+// HTTPStatusCode maps err to an HTTP status code.
+func HTTPStatusCode(err error) int {
+	switch GetErrorCode(err) {
+	case CodeInvalid:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	case CodeCanceled:
+		return StatusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorMessage returns a client-safe message: that of the error
+// GetErrorCode picked, errs.Message for an errs sentinel. Internal
+// errors and errors without a code get a generic one, so causes never
+// leak.
+func ErrorMessage(err error) string {
+	code, carrier := classify(err)
+	if code == CodeInternal {
+		return "an internal error has occurred"
+	}
+	switch e := carrier.(type) {
+	case *Error:
+		if e.Message != "" {
+			return e.Message
+		}
+	case ValidationErrors:
+		return "validation failed"
+	default:
+		if _, ok := sentinelCode(e); ok {
+			return errs.Message(e)
+		}
+	}
+
+	switch code {
+	case CodeTimeout:
+		return "request timed out"
+	case CodeCanceled:
+		return "request canceled"
+	}
+	return string(code)
+}
+
+// IsInternal reports whether err maps to CodeInternal and must be logged.
+func IsInternal(err error) bool {
+	return GetErrorCode(err) == CodeInternal
+}
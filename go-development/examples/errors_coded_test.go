@@ -0,0 +1,199 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Error Tests ----------
+
+func TestError_Error(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("connection refused")
+	err := &Error{Code: CodeUnavailable, Message: "database unavailable", Op: "UserRepo.Get", Err: cause}
+
+	assert.Equal(t, "UserRepo.Get: database unavailable: connection refused", err.Error())
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "not_found", (&Error{Code: CodeNotFound}).Error())
+}
+
+func TestHTTPStatusCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    ErrorCode
+		wantMessage string
+	}{
+		{name: "invalid", err: &Error{Code: CodeInvalid, Message: "bad email"},
+			wantStatus: http.StatusBadRequest, wantCode: CodeInvalid, wantMessage: "bad email"},
+		{name: "not found", err: &Error{Code: CodeNotFound, Message: "user not found"},
+			wantStatus: http.StatusNotFound, wantCode: CodeNotFound, wantMessage: "user not found"},
+		{name: "conflict", err: &Error{Code: CodeConflict, Message: "user exists"},
+			wantStatus: http.StatusConflict, wantCode: CodeConflict, wantMessage: "user exists"},
+		{name: "unauthorized", err: &Error{Code: CodeUnauthorized},
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthorized, wantMessage: "unauthorized"},
+		{name: "forbidden", err: &Error{Code: CodeForbidden, Message: "admins only"},
+			wantStatus: http.StatusForbidden, wantCode: CodeForbidden, wantMessage: "admins only"},
+		{name: "unavailable", err: &Error{Code: CodeUnavailable, Message: "try later"},
+			wantStatus: http.StatusServiceUnavailable, wantCode: CodeUnavailable, wantMessage: "try later"},
+		{name: "timeout", err: &Error{Code: CodeTimeout, Message: "request timed out"},
+			wantStatus: http.StatusGatewayTimeout, wantCode: CodeTimeout, wantMessage: "request timed out"},
+		{name: "canceled", err: &Error{Code: CodeCanceled},
+			wantStatus: StatusClientClosedRequest, wantCode: CodeCanceled, wantMessage: "request canceled"},
+		{name: "internal hides message", err: &Error{Code: CodeInternal, Message: "pq: syntax error"},
+			wantStatus: http.StatusInternalServerError, wantCode: CodeInternal, wantMessage: "an internal error has occurred"},
+		{name: "wrapped", err: fmt.Errorf("UserService.Get: %w", &Error{Code: CodeNotFound, Message: "user not found"}),
+			wantStatus: http.StatusNotFound, wantCode: CodeNotFound, wantMessage: "user not found"},
+		{name: "uncoded", err: errors.New("boom"),
+			wantStatus: http.StatusInternalServerError, wantCode: CodeInternal, wantMessage: "an internal error has occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(tt.err))
+			assert.Equal(t, tt.wantCode, GetErrorCode(tt.err))
+			assert.Equal(t, tt.wantMessage, ErrorMessage(tt.err))
+			assert.Equal(t, tt.wantCode == CodeInternal, IsInternal(tt.err))
+		})
+	}
+}
+
+// ---------- ValidationErrors Tests ----------
+
+func TestValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	var verrs ValidationErrors
+	verrs.Add("name", "required", "name is required")
+	verrs.Add("email", "email", "invalid email format")
+	verrs.Add("email", "max", "email is too long")
+
+	require.Len(t, verrs, 3)
+	assert.Equal(t, FieldError{Field: "name", Rule: "required", Message: "name is required"}, verrs[0])
+	assert.Equal(t, "validation failed: name: name is required; email: invalid email format; email: email is too long",
+		verrs.Error())
+	assert.Equal(t, map[string]string{
+		"name":  "name is required",
+		"email": "invalid email format; email is too long",
+	}, verrs.ToDetails())
+}
+
+func TestValidationErrors_Wrapped(t *testing.T) {
+	t.Parallel()
+
+	var verrs ValidationErrors
+	verrs.Add("name", "required", "name is required")
+	err := fmt.Errorf("UserService.Create: %w", verrs.Err())
+
+	var got ValidationErrors
+	require.ErrorAs(t, err, &got)
+	assert.Equal(t, verrs, got)
+
+	assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+	assert.Equal(t, CodeInvalid, GetErrorCode(err))
+	assert.Equal(t, "validation failed", ErrorMessage(err))
+	assert.False(t, IsInternal(err))
+}
+
+func TestValidationErrors_Err(t *testing.T) {
+	t.Parallel()
+
+	var verrs ValidationErrors
+	assert.NoError(t, verrs.Err(), "nothing failed")
+
+	verrs.Add("name", "required", "name is required")
+	assert.Error(t, verrs.Err())
+}
+
+// ---------- Joined Error Tests ----------
+
+func TestGetErrorCode_Joined(t *testing.T) {
+	t.Parallel()
+
+	notFound := &Error{Code: CodeNotFound, Message: "user not found"}
+	invalid := &Error{Code: CodeInvalid, Message: "bad email"}
+	conflict := &Error{Code: CodeConflict, Message: "user exists"}
+	forbidden := &Error{Code: CodeForbidden, Message: "admins only"}
+	unavailable := &Error{Code: CodeUnavailable, Message: "try later"}
+	var verrs ValidationErrors
+	verrs.Add("name", "required", "name is required")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    ErrorCode
+		wantMessage string
+		wantCodes   []ErrorCode
+	}{
+		{name: "internal beats not found", err: errors.Join(notFound, &Error{Code: CodeInternal}),
+			wantCode: CodeInternal, wantMessage: "an internal error has occurred",
+			wantCodes: []ErrorCode{CodeNotFound, CodeInternal}},
+		{name: "uncoded branch is internal", err: errors.Join(errors.New("dial tcp: refused"), notFound),
+			wantCode: CodeInternal, wantMessage: "an internal error has occurred",
+			wantCodes: []ErrorCode{CodeInternal, CodeNotFound}},
+		{name: "order doesn't matter", err: errors.Join(conflict, forbidden),
+			wantCode: CodeConflict, wantMessage: "user exists",
+			wantCodes: []ErrorCode{CodeConflict, CodeForbidden}},
+		{name: "invalid beats not found", err: errors.Join(notFound, invalid),
+			wantCode: CodeInvalid, wantMessage: "bad email",
+			wantCodes: []ErrorCode{CodeNotFound, CodeInvalid}},
+		{name: "validation errors count as invalid", err: errors.Join(notFound, verrs),
+			wantCode: CodeInvalid, wantMessage: "validation failed",
+			wantCodes: []ErrorCode{CodeNotFound, CodeInvalid}},
+		{name: "first of equal severity", err: errors.Join(invalid, verrs),
+			wantCode: CodeInvalid, wantMessage: "bad email",
+			wantCodes: []ErrorCode{CodeInvalid, CodeInvalid}},
+		{name: "nested and wrapped",
+			err: fmt.Errorf("batch: %w", errors.Join(
+				fmt.Errorf("item 1: %w", notFound),
+				errors.Join(forbidden, fmt.Errorf("item 3: %w", unavailable)),
+			)),
+			wantCode: CodeUnavailable, wantMessage: "try later",
+			wantCodes: []ErrorCode{CodeNotFound, CodeForbidden, CodeUnavailable}},
+		{name: "multiple %w", err: fmt.Errorf("%w; %w", notFound, forbidden),
+			wantCode: CodeForbidden, wantMessage: "admins only",
+			wantCodes: []ErrorCode{CodeNotFound, CodeForbidden}},
+		{name: "coded error wrapping uncoded cause", err: &Error{Code: CodeNotFound, Message: "gone", Err: errors.New("no rows")},
+			wantCode: CodeNotFound, wantMessage: "gone",
+			wantCodes: []ErrorCode{CodeNotFound}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantCode, GetErrorCode(tt.err))
+			assert.Equal(t, tt.wantMessage, ErrorMessage(tt.err))
+			assert.Equal(t, tt.wantCodes, Codes(tt.err))
+		})
+	}
+}
+
+func TestHTTPStatusCode_Joined(t *testing.T) {
+	t.Parallel()
+
+	err := errors.Join(
+		&Error{Code: CodeNotFound, Message: "user not found"},
+		&Error{Code: CodeUnauthorized, Message: "token expired"},
+	)
+	assert.Equal(t, http.StatusUnauthorized, HTTPStatusCode(err))
+	assert.False(t, IsInternal(err))
+}
+
+func TestCodes_Nil(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Codes(nil))
+	assert.Equal(t, []ErrorCode{CodeInternal}, Codes(errors.New("boom")))
+}
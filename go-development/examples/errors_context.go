@@ -0,0 +1,37 @@
+// Package errors provides mapping of context deadline and cancellation
+// errors.
+package errors
+
+import (
+	"context"
+	"errors"
+)
+
+// StatusClientClosedRequest is nginx's non-standard 499: the client
+// closed the connection before the response was written.
+const StatusClientClosedRequest = 499
+
+// contextCode returns the code of err if it is a context error itself,
+// not one wrapped.
+func contextCode(err error) (ErrorCode, bool) {
+	switch err { //nolint:errorlint // identity; walk does the unwrapping
+	case context.DeadlineExceeded:
+		return CodeTimeout, true
+	case context.Canceled:
+		return CodeCanceled, true
+	}
+	return "", false
+}
+
+// IsTimeout reports whether err is CodeTimeout or wraps
+// context.DeadlineExceeded, even under an internal error.
+func IsTimeout(err error) bool {
+	return GetErrorCode(err) == CodeTimeout || errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsCanceled reports whether err is CodeCanceled or wraps
+// context.Canceled: the client went away, so there is no one to answer
+// and nothing to alert on. The HTTP writers send a bare 499 for it.
+func IsCanceled(err error) bool {
+	return GetErrorCode(err) == CodeCanceled || errors.Is(err, context.Canceled)
+}
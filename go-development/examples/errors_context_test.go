@@ -0,0 +1,107 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/errs"
+)
+
+// ---------- Context Error Tests ----------
+
+func TestContextErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		ctx          func() (context.Context, context.CancelFunc)
+		wantCode     ErrorCode
+		wantStatus   int
+		wantMessage  string
+		wantTimeout  bool
+		wantCanceled bool
+	}{
+		{name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Millisecond)
+			},
+			wantCode: CodeTimeout, wantStatus: http.StatusGatewayTimeout, wantMessage: "request timed out",
+			wantTimeout: true},
+		{name: "canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			wantCode: CodeCanceled, wantStatus: StatusClientClosedRequest, wantMessage: "request canceled",
+			wantCanceled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			_, repoErr := fakeRepo{}.Get(ctx, "42")
+			require.Error(t, repoErr)
+			err := fmt.Errorf("UserService.Get: %w", repoErr)
+
+			assert.Equal(t, tt.wantCode, GetErrorCode(err))
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(err))
+			assert.Equal(t, tt.wantMessage, ErrorMessage(err))
+			assert.False(t, IsInternal(err))
+			assert.Equal(t, tt.wantTimeout, IsTimeout(err))
+			assert.Equal(t, tt.wantCanceled, IsCanceled(err))
+
+			assert.Equal(t, tt.wantStatus, errs.HTTPStatus(err), "errs agrees")
+		})
+	}
+}
+
+func TestIsCanceled_UnderInternal(t *testing.T) {
+	t.Parallel()
+
+	err := Internal("load user", fmt.Errorf("UserRepo.Get: %w", context.Canceled))
+
+	assert.Equal(t, CodeInternal, GetErrorCode(err), "an explicit code stays")
+	assert.True(t, IsCanceled(err))
+	assert.False(t, IsTimeout(err))
+}
+
+func TestContextErrors_Joined(t *testing.T) {
+	t.Parallel()
+
+	err := errors.Join(
+		New(CodeNotFound, "user not found"),
+		fmt.Errorf("orders: %w", context.Canceled),
+	)
+	assert.Equal(t, CodeCanceled, GetErrorCode(err))
+
+	err = errors.Join(fmt.Errorf("orders: %w", context.Canceled), errors.New("boom"))
+	assert.Equal(t, CodeInternal, GetErrorCode(err), "internal still wins")
+	assert.True(t, IsCanceled(err))
+}
+
+// ---------- Test Helpers ----------
+
+// fakeRepo waits for the query like a driver would and returns the
+// context's error, wrapped the way repositories do.
+type fakeRepo struct{}
+
+func (fakeRepo) Get(ctx context.Context, id string) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("UserRepo.Get id=%s: %w", id, ctx.Err())
+	case <-time.After(time.Second):
+		return "user " + id, nil
+	}
+}
@@ -0,0 +1,59 @@
+// Package errors provides client-visible details on coded errors.
+package errors
+
+import (
+	"errors"
+	"maps"
+)
+
+// WithDetail returns a copy of e with key set to value in Details, so
+// package-level errors can be decorated without mutating them:
+//
+//	return errors.New(errors.CodeInvalid, "name too long").
+//	    WithDetail("field", "name").
+//	    WithDetail("max", 100)
+//
+// Values must marshal to JSON. Details are never shown for internal
+// errors.
+func (e *Error) WithDetail(key string, value any) *Error {
+	c := *e
+	c.Details = make(map[string]any, len(e.Details)+1)
+	maps.Copy(c.Details, e.Details)
+	c.Details[key] = value
+	return &c
+}
+
+// Details merges the Details of every *Error in err, including all
+// branches of joined errors; on a key set more than once the outermost,
+// then first, error wins. It returns nil for internal errors, so
+// metadata of an unexpected failure never reaches clients.
+func Details(err error) map[string]any {
+	if GetErrorCode(err) == CodeInternal {
+		return nil
+	}
+
+	var merged map[string]any
+	var collect func(error)
+	collect = func(err error) {
+		for e := err; e != nil; e = errors.Unwrap(e) {
+			if coded, ok := e.(*Error); ok {
+				for k, v := range coded.Details {
+					if _, seen := merged[k]; !seen {
+						if merged == nil {
+							merged = make(map[string]any)
+						}
+						merged[k] = v
+					}
+				}
+			}
+			if joined, ok := e.(interface{ Unwrap() []error }); ok {
+				for _, branch := range joined.Unwrap() {
+					collect(branch)
+				}
+				return
+			}
+		}
+	}
+	collect(err)
+	return merged
+}
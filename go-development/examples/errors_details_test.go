@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Details Tests ----------
+
+func TestWithDetail(t *testing.T) {
+	t.Parallel()
+
+	base := New(CodeInvalid, "name too long")
+	err := base.WithDetail("field", "name").WithDetail("max", 100)
+
+	assert.Equal(t, map[string]any{"field": "name", "max": 100}, err.Details)
+	assert.Nil(t, base.Details, "the original is untouched")
+	assert.Equal(t, CodeInvalid, err.Code)
+	assert.Equal(t, "name too long", err.Message)
+}
+
+func TestDetails(t *testing.T) {
+	t.Parallel()
+
+	inner := New(CodeNotFound, "user not found").WithDetail("resource", "user").WithDetail("id", "42")
+	outer := &Error{Code: CodeNotFound, Message: "user not found", Err: fmt.Errorf("repo: %w", inner)}
+	outer = outer.WithDetail("id", "u-42")
+
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+	}{
+		{name: "none", err: New(CodeNotFound, "user not found"), want: nil},
+		{name: "own", err: inner, want: map[string]any{"resource": "user", "id": "42"}},
+		{name: "through wrapping", err: fmt.Errorf("service: %w", inner), want: map[string]any{"resource": "user", "id": "42"}},
+		{name: "merged, outermost wins", err: outer, want: map[string]any{"resource": "user", "id": "u-42"}},
+		{name: "joined branches",
+			err: errors.Join(
+				New(CodeInvalid, "bad email").WithDetail("field", "email"),
+				New(CodeInvalid, "bad name").WithDetail("field", "name").WithDetail("max", 100),
+			),
+			want: map[string]any{"field": "email", "max": 100}},
+		{name: "internal suppressed", err: Internal("query users", errors.New("boom")).WithDetail("sql", "SELECT 1"), want: nil},
+		{name: "internal wrapping details suppressed", err: Internal("load", inner), want: nil},
+		{name: "joined with internal suppressed", err: errors.Join(inner, errors.New("boom")), want: nil},
+		{name: "uncoded", err: errors.New("boom"), want: nil},
+		{name: "nil", err: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Details(tt.err))
+		})
+	}
+}
+
+func TestDetails_JSONDeterministic(t *testing.T) {
+	t.Parallel()
+
+	err := New(CodeInvalid, "bad range").
+		WithDetail("min", 1).
+		WithDetail("max", 10).
+		WithDetail("field", "limit")
+
+	for range 20 {
+		data, jsonErr := json.Marshal(Details(err))
+		require.NoError(t, jsonErr)
+		assert.Equal(t, `{"field":"limit","max":10,"min":1}`, string(data))
+	}
+}
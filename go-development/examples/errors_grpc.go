@@ -0,0 +1,140 @@
+// Package errors provides gRPC status mapping for coded errors.
+package errors
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// GRPCCode maps err to a gRPC status code, like HTTPStatusCode does for
+// HTTP.
+func GRPCCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	switch GetErrorCode(err) {
+	case CodeInvalid:
+		return codes.InvalidArgument
+	case CodeNotFound:
+		return codes.NotFound
+	case CodeConflict:
+		return codes.AlreadyExists
+	case CodeUnauthorized:
+		return codes.Unauthenticated
+	case CodeForbidden:
+		return codes.PermissionDenied
+	case CodeUnavailable:
+		return codes.Unavailable
+	case CodeTimeout:
+		return codes.DeadlineExceeded
+	case CodeCanceled:
+		return codes.Canceled
+	default:
+		return codes.Internal
+	}
+}
+
+// ToGRPCStatus converts err into a status for a gRPC handler to return.
+// The message is ErrorMessage's, so internal causes never reach the
+// client. The ErrorCode travels as an ErrorInfo reason, and
+// ValidationErrors as BadRequest field violations.
+//
+//	func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+//	    user, err := s.users.Get(ctx, req.GetId())
+//	    if err != nil {
+//	        return nil, errors.ToGRPCStatus(err).Err()
+//	    }
+//	    return toProto(user), nil
+//	}
+func ToGRPCStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	code := GetErrorCode(err)
+	st := status.New(GRPCCode(err), ErrorMessage(err))
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(code)}}
+
+	var verrs ValidationErrors
+	if code == CodeInvalid && errors.As(err, &verrs) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(verrs))
+		for i, fe := range verrs {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromGRPCStatus converts a status received by a gRPC client back into an
+// *Error, or ValidationErrors if it carries field violations, so callers
+// handle remote failures like local ones. It returns nil for OK.
+//
+// The code comes from the ErrorInfo reason set by ToGRPCStatus, or from
+// the gRPC code for servers that don't set one.
+func FromGRPCStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	code := codeFromGRPC(st.Code())
+	var verrs ValidationErrors
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if c := ErrorCode(d.GetReason()); isKnownCode(c) {
+				code = c
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				verrs.Add(v.GetField(), "", v.GetDescription())
+			}
+		}
+	}
+
+	if len(verrs) > 0 {
+		return verrs
+	}
+	return &Error{Code: code, Message: st.Message(), Err: st.Err()}
+}
+
+func codeFromGRPC(c codes.Code) ErrorCode {
+	switch c {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return CodeInvalid
+	case codes.NotFound:
+		return CodeNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return CodeConflict
+	case codes.Unauthenticated:
+		return CodeUnauthorized
+	case codes.PermissionDenied:
+		return CodeForbidden
+	case codes.Unavailable:
+		return CodeUnavailable
+	case codes.DeadlineExceeded:
+		return CodeTimeout
+	case codes.Canceled:
+		return CodeCanceled
+	default:
+		return CodeInternal
+	}
+}
+
+func isKnownCode(c ErrorCode) bool {
+	switch c {
+	case CodeInvalid, CodeNotFound, CodeConflict, CodeUnauthorized,
+		CodeForbidden, CodeUnavailable, CodeTimeout, CodeCanceled, CodeInternal:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ---------- gRPC Mapping Tests ----------
+
+func TestGRPCCode(t *testing.T) {
+	t.Parallel()
+
+	var verrs ValidationErrors
+	verrs.Add("email", "email", "invalid email format")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{name: "invalid", err: &Error{Code: CodeInvalid, Message: "bad email"},
+			wantCode: codes.InvalidArgument, wantMessage: "bad email"},
+		{name: "validation errors", err: verrs,
+			wantCode: codes.InvalidArgument, wantMessage: "validation failed"},
+		{name: "not found", err: &Error{Code: CodeNotFound, Message: "user not found"},
+			wantCode: codes.NotFound, wantMessage: "user not found"},
+		{name: "conflict", err: &Error{Code: CodeConflict, Message: "user exists"},
+			wantCode: codes.AlreadyExists, wantMessage: "user exists"},
+		{name: "unauthorized", err: &Error{Code: CodeUnauthorized, Message: "token expired"},
+			wantCode: codes.Unauthenticated, wantMessage: "token expired"},
+		{name: "forbidden", err: &Error{Code: CodeForbidden, Message: "admins only"},
+			wantCode: codes.PermissionDenied, wantMessage: "admins only"},
+		{name: "unavailable", err: &Error{Code: CodeUnavailable, Message: "try later"},
+			wantCode: codes.Unavailable, wantMessage: "try later"},
+		{name: "timeout", err: &Error{Code: CodeTimeout, Message: "request timed out"},
+			wantCode: codes.DeadlineExceeded, wantMessage: "request timed out"},
+		{name: "canceled", err: fmt.Errorf("UserRepo.Get: %w", context.Canceled),
+			wantCode: codes.Canceled, wantMessage: "request canceled"},
+		{name: "internal hides message", err: &Error{Code: CodeInternal, Message: "pq: syntax error"},
+			wantCode: codes.Internal, wantMessage: "an internal error has occurred"},
+		{name: "uncoded hides message", err: errors.New("dial tcp 10.0.0.5:5432: refused"),
+			wantCode: codes.Internal, wantMessage: "an internal error has occurred"},
+		{name: "wrapped", err: fmt.Errorf("UserService.Get: %w", &Error{Code: CodeNotFound, Message: "user not found"}),
+			wantCode: codes.NotFound, wantMessage: "user not found"},
+		{name: "wrapped validation errors", err: fmt.Errorf("UserService.Create: %w", verrs),
+			wantCode: codes.InvalidArgument, wantMessage: "validation failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantCode, GRPCCode(tt.err))
+
+			st := ToGRPCStatus(tt.err)
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantMessage, st.Message())
+
+			var info *errdetails.ErrorInfo
+			for _, d := range st.Details() {
+				if d, ok := d.(*errdetails.ErrorInfo); ok {
+					info = d
+				}
+			}
+			require.NotNil(t, info)
+			assert.Equal(t, string(GetErrorCode(tt.err)), info.GetReason())
+		})
+	}
+}
+
+func TestToGRPCStatus_Nil(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, codes.OK, GRPCCode(nil))
+	assert.Equal(t, codes.OK, ToGRPCStatus(nil).Code())
+	assert.NoError(t, ToGRPCStatus(nil).Err())
+}
+
+func TestFromGRPCStatus_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, code := range []ErrorCode{CodeInvalid, CodeNotFound, CodeConflict, CodeUnauthorized, CodeForbidden, CodeUnavailable, CodeTimeout, CodeCanceled} {
+		t.Run(string(code), func(t *testing.T) {
+			t.Parallel()
+
+			sent := fmt.Errorf("op: %w", &Error{Code: code, Message: "message for " + string(code)})
+			got := FromGRPCStatus(ToGRPCStatus(sent))
+
+			assert.Equal(t, code, GetErrorCode(got))
+			assert.Equal(t, ErrorMessage(sent), ErrorMessage(got))
+			assert.Equal(t, HTTPStatusCode(sent), HTTPStatusCode(got))
+
+			st, ok := status.FromError(got)
+			require.True(t, ok, "the status stays reachable")
+			assert.Equal(t, GRPCCode(sent), st.Code())
+		})
+	}
+}
+
+func TestFromGRPCStatus_ValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	var sent ValidationErrors
+	sent.Add("name", "required", "name is required")
+	sent.Add("email", "email", "invalid email format")
+
+	got := FromGRPCStatus(ToGRPCStatus(sent))
+
+	var verrs ValidationErrors
+	require.ErrorAs(t, got, &verrs)
+	assert.Equal(t, sent.ToDetails(), verrs.ToDetails())
+}
+
+func TestFromGRPCStatus_ForeignServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code codes.Code
+		want ErrorCode
+	}{
+		{codes.InvalidArgument, CodeInvalid},
+		{codes.FailedPrecondition, CodeInvalid},
+		{codes.NotFound, CodeNotFound},
+		{codes.AlreadyExists, CodeConflict},
+		{codes.Aborted, CodeConflict},
+		{codes.Unauthenticated, CodeUnauthorized},
+		{codes.PermissionDenied, CodeForbidden},
+		{codes.Unavailable, CodeUnavailable},
+		{codes.DeadlineExceeded, CodeTimeout},
+		{codes.Canceled, CodeCanceled},
+		{codes.Unknown, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			t.Parallel()
+
+			err := FromGRPCStatus(status.New(tt.code, "remote says no"))
+			assert.Equal(t, tt.want, GetErrorCode(err))
+		})
+	}
+
+	assert.NoError(t, FromGRPCStatus(status.New(codes.OK, "")))
+	assert.NoError(t, FromGRPCStatus(nil))
+}
+
+func TestFromGRPCStatus_UnknownReason(t *testing.T) {
+	t.Parallel()
+
+	st, err := status.New(codes.NotFound, "gone").WithDetails(&errdetails.ErrorInfo{Reason: "QUOTA_EXCEEDED"})
+	require.NoError(t, err)
+
+	assert.Equal(t, CodeNotFound, GetErrorCode(FromGRPCStatus(st)), "falls back to the gRPC code")
+}
+
+func TestToGRPCStatus_JoinedHidesViolations(t *testing.T) {
+	t.Parallel()
+
+	var verrs ValidationErrors
+	verrs.Add("name", "required", "name is required")
+
+	st := ToGRPCStatus(errors.Join(verrs, errors.New("db down")))
+	assert.Equal(t, codes.Internal, st.Code())
+	for _, d := range st.Details() {
+		assert.NotEqual(t, "*errdetails.BadRequest", fmt.Sprintf("%T", d))
+	}
+}
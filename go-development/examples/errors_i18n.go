@@ -0,0 +1,105 @@
+// Package errors provides localized client-safe error messages.
+package errors
+
+import (
+	"maps"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the last locale Localize tries. Without a catalog for
+// it, the English text of ErrorMessage is used.
+const DefaultLocale = "en"
+
+// KeyValidationFailed is the catalog key of ValidationErrors' message.
+const KeyValidationFailed = "errors.validation_failed"
+
+var (
+	catalogsMu sync.RWMutex
+	catalogs   = map[string]map[string]string{} // locale -> key -> message
+)
+
+// MessageKey returns the catalog key of the code's messages, e.g.
+// "errors.not_found".
+func (c ErrorCode) MessageKey() string {
+	return "errors." + string(c)
+}
+
+// RegisterCatalog adds messages for locale, e.g. "ru" or "ru-RU", to the
+// ones already registered; a key registered twice keeps the last value.
+// Call it at startup:
+//
+//	errors.RegisterCatalog("ru", map[string]string{
+//	    errors.CodeNotFound.MessageKey(): "не найдено",
+//	    errors.KeyValidationFailed:       "ошибка валидации",
+//	    "user.not_found":                 "пользователь не найден",
+//	})
+func RegisterCatalog(locale string, messages map[string]string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	catalog, ok := catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		catalogs[locale] = catalog
+	}
+	maps.Copy(catalog, messages)
+}
+
+// Localize returns ErrorMessage(err) in locale. It looks up the key of
+// the error ErrorMessage picks: its Key, else its code's MessageKey, in
+// locale, then its parents and DefaultLocale ("ru-RU", "ru", "en").
+// Untranslated keys fall back to ErrorMessage, so an error with its own
+// Message needs a Key to be translated as specifically.
+func Localize(err error, locale string) string {
+	key := messageKey(err)
+	for _, l := range fallbackChain(locale) {
+		if msg, ok := lookup(l, key); ok {
+			return msg
+		}
+	}
+	return ErrorMessage(err)
+}
+
+// messageKey returns the catalog key of ErrorMessage(err).
+func messageKey(err error) string {
+	code, carrier := classify(err)
+	if code == CodeInternal {
+		return CodeInternal.MessageKey()
+	}
+	switch e := carrier.(type) {
+	case *Error:
+		if e.Key != "" {
+			return e.Key
+		}
+	case ValidationErrors:
+		return KeyValidationFailed
+	}
+	return code.MessageKey()
+}
+
+// fallbackChain returns locale and its parents, then DefaultLocale:
+// "ru-RU" gives ["ru-RU", "ru", "en"].
+func fallbackChain(locale string) []string {
+	var chain []string
+	for l := locale; l != ""; {
+		chain = append(chain, l)
+		i := strings.LastIndexAny(l, "-_")
+		if i < 0 {
+			break
+		}
+		l = l[:i]
+	}
+	if len(chain) == 0 || chain[len(chain)-1] != DefaultLocale {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
+
+func lookup(locale, key string) (string, bool) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	msg, ok := catalogs[locale][key]
+	return msg, ok
+}
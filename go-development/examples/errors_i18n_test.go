@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Localize Tests ----------
+
+func TestLocalize(t *testing.T) {
+	t.Parallel()
+	registerTestCatalogs()
+
+	var verrs ValidationErrors
+	verrs.Add("email", "email", "invalid email format")
+	userNotFound := &Error{Code: CodeNotFound, Message: "user not found", Key: "user.not_found"}
+
+	tests := []struct {
+		name   string
+		err    error
+		locale string
+		want   string
+	}{
+		{name: "code key", err: New(CodeNotFound, "order not found"), locale: "ru", want: "не найдено"},
+		{name: "own key", err: userNotFound, locale: "ru", want: "пользователь не найден"},
+		{name: "own key, wrapped", err: fmt.Errorf("UserService.Get: %w", userNotFound), locale: "de", want: "Benutzer nicht gefunden"},
+		{name: "validation errors", err: fmt.Errorf("create: %w", verrs), locale: "ru", want: "ошибка валидации"},
+		{name: "internal", err: Internal("query users", errors.New("boom")), locale: "de", want: "ein interner Fehler ist aufgetreten"},
+		{name: "uncoded is internal", err: errors.New("dial tcp: refused"), locale: "ru", want: "произошла внутренняя ошибка"},
+		{name: "joined picks the severest", err: errors.Join(userNotFound, New(CodeConflict, "user exists")), locale: "ru", want: "конфликт"},
+		{name: "region falls back to language", err: New(CodeNotFound, "order not found"), locale: "ru-RU", want: "не найдено"},
+		{name: "region overrides language", err: New(CodeForbidden, "admins only"), locale: "ru-RU", want: "доступ запрещён"},
+		{name: "underscore region", err: New(CodeNotFound, "order not found"), locale: "de_AT", want: "nicht gefunden"},
+		{name: "missing key falls back to English", err: New(CodeTimeout, "request timed out"), locale: "ru", want: "request timed out"},
+		{name: "region without a catalog", err: userNotFound.WithDetail("id", "42"), locale: "de-CH", want: "Benutzer nicht gefunden"},
+		{name: "unknown locale", err: userNotFound, locale: "fr", want: "user not found"},
+		{name: "no locale", err: New(CodeConflict, "user exists"), locale: "", want: "user exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Localize(tt.err, tt.locale))
+		})
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"ru-RU", "ru", "en"}, fallbackChain("ru-RU"))
+	assert.Equal(t, []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}, fallbackChain("zh-Hant-TW"))
+	assert.Equal(t, []string{"en-GB", "en"}, fallbackChain("en-GB"))
+	assert.Equal(t, []string{"en"}, fallbackChain(""))
+}
+
+func TestRegisterCatalog_Merges(t *testing.T) {
+	t.Parallel()
+
+	RegisterCatalog("x-merge", map[string]string{"a": "1", "b": "2"})
+	RegisterCatalog("x-merge", map[string]string{"b": "3"})
+
+	msg, _ := lookup("x-merge", "a")
+	assert.Equal(t, "1", msg)
+	msg, _ = lookup("x-merge", "b")
+	assert.Equal(t, "3", msg)
+}
+
+// ---------- Test Helpers ----------
+
+var testCatalogsOnce sync.Once
+
+// registerTestCatalogs registers ru, ru-RU and de. There is no en
+// catalog, so untranslated keys fall back to the error's own text.
+func registerTestCatalogs() {
+	testCatalogsOnce.Do(func() {
+		RegisterCatalog("ru", map[string]string{
+			CodeNotFound.MessageKey():  "не найдено",
+			CodeConflict.MessageKey():  "конфликт",
+			CodeForbidden.MessageKey(): "доступ запрещён всем",
+			CodeInternal.MessageKey():  "произошла внутренняя ошибка",
+			KeyValidationFailed:        "ошибка валидации",
+			"user.not_found":           "пользователь не найден",
+		})
+		RegisterCatalog("ru-RU", map[string]string{
+			CodeForbidden.MessageKey(): "доступ запрещён",
+		})
+		RegisterCatalog("de", map[string]string{
+			CodeNotFound.MessageKey(): "nicht gefunden",
+			CodeInternal.MessageKey(): "ein interner Fehler ist aufgetreten",
+			"user.not_found":          "Benutzer nicht gefunden",
+		})
+	})
+}
@@ -0,0 +1,100 @@
+// Package errors provides error observation for metrics by code and
+// operation.
+package errors
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer is told about each error a service returns to a client.
+type Observer func(code ErrorCode, op string)
+
+var observer atomic.Pointer[Observer]
+
+// SetObserver installs fn, called by Observe; nil turns observation off,
+// which is the default. Call it at startup:
+//
+//	errors.SetObserver(errors.PrometheusObserver(registry))
+func SetObserver(fn Observer) {
+	if fn == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&fn)
+}
+
+// Observe reports err with its code to the observer, if any. An empty op
+// is taken from err, see OpOf. ErrorHandler and the HTTP writers call it
+// for every error response; call it yourself where errors end elsewhere,
+// e.g. in a worker. It does nothing for nil.
+func Observe(op string, err error) {
+	fn := observer.Load()
+	if fn == nil || err == nil {
+		return
+	}
+	if op == "" {
+		op = OpOf(err)
+	}
+	(*fn)(GetErrorCode(err), op)
+}
+
+// opError names the operation that returned err; see WithOp.
+type opError struct {
+	op  string
+	err error
+}
+
+func (e *opError) Error() string { return e.op + ": " + e.err.Error() }
+func (e *opError) Unwrap() error { return e.err }
+
+// WithOp wraps err with the operation that returned it. The text is the
+// same as errs.Wrap's, "op: err", and the op labels metrics:
+//
+//	if err != nil {
+//	    return errors.WithOp(err, "UserService.Create")
+//	}
+//
+// Use constant names: each distinct op is a metric series. It returns
+// nil for nil.
+func WithOp(err error, op string) error {
+	if err == nil {
+		return nil
+	}
+	return &opError{op: op, err: err}
+}
+
+// OpOf returns the outermost operation on err's chain, from WithOp or
+// Error.Op, or "" if there is none.
+func OpOf(err error) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case *opError:
+			return e.op
+		case *Error:
+			if e.Op != "" {
+				return e.Op
+			}
+		}
+	}
+	return ""
+}
+
+// PrometheusObserver returns an Observer counting
+//
+//	app_errors_total{code,op}
+//
+// It registers the counter with reg, so call it once per registry.
+func PrometheusObserver(reg prometheus.Registerer) Observer {
+	errorsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "app_errors_total",
+		Help: "Errors returned to clients by error code and operation.",
+	}, []string{"code", "op"})
+	reg.MustRegister(errorsTotal)
+
+	return func(code ErrorCode, op string) {
+		errorsTotal.WithLabelValues(string(code), op).Inc()
+	}
+}
@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that call SetObserver don't run in parallel: the observer is
+// global.
+
+// ---------- Observe Tests ----------
+
+func TestObserve(t *testing.T) {
+	rec := recordObservations(t)
+
+	Observe("", WithOp(New(CodeNotFound, "user not found"), "UserService.Get"))
+	Observe("", fmt.Errorf("handler: %w", WithOp(New(CodeConflict, "user exists"), "UserService.Create")))
+	Observe("", E(Op("UserRepo.Update"), CodeInvalid, "bad email"))
+	Observe("Worker.SendEmail", errors.New("smtp: refused"))
+	Observe("", errors.Join(New(CodeNotFound, "gone"), New(CodeUnavailable, "try later")))
+	Observe("", nil)
+
+	assert.Equal(t, []observation{
+		{CodeNotFound, "UserService.Get"},
+		{CodeConflict, "UserService.Create"},
+		{CodeInvalid, "UserRepo.Update"},
+		{CodeInternal, "Worker.SendEmail"},
+		{CodeUnavailable, ""},
+	}, rec.all())
+}
+
+func TestObserve_NoObserver(t *testing.T) {
+	SetObserver(nil)
+
+	assert.NotPanics(t, func() {
+		Observe("UserService.Get", New(CodeNotFound, "user not found"))
+	})
+}
+
+func TestPrometheusObserver(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	SetObserver(PrometheusObserver(reg))
+	t.Cleanup(func() { SetObserver(nil) })
+
+	Observe("", WithOp(New(CodeNotFound, "user not found"), "UserService.Get"))
+	Observe("", WithOp(New(CodeNotFound, "user not found"), "UserService.Get"))
+	Observe("", WithOp(errors.New("boom"), "UserService.Create"))
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP app_errors_total Errors returned to clients by error code and operation.
+# TYPE app_errors_total counter
+app_errors_total{code="internal",op="UserService.Create"} 1
+app_errors_total{code="not_found",op="UserService.Get"} 2
+`), "app_errors_total"))
+}
+
+// ---------- WithOp Tests ----------
+
+func TestWithOp(t *testing.T) {
+	t.Parallel()
+
+	cause := New(CodeNotFound, "user not found")
+	err := WithOp(cause, "UserService.Get")
+
+	assert.Equal(t, "UserService.Get: user not found", err.Error(), "same text as errs.Wrap")
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, CodeNotFound, GetErrorCode(err))
+	assert.Equal(t, "user not found", ErrorMessage(err))
+	assert.NoError(t, WithOp(nil, "UserService.Get"))
+}
+
+func TestOpOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "WithOp", err: WithOp(errors.New("boom"), "UserService.Get"), want: "UserService.Get"},
+		{name: "outermost wins",
+			err:  WithOp(WithOp(errors.New("boom"), "UserRepo.Get"), "UserService.Get"),
+			want: "UserService.Get"},
+		{name: "Error.Op", err: fmt.Errorf("x: %w", &Error{Code: CodeNotFound, Op: "UserRepo.Get"}), want: "UserRepo.Get"},
+		{name: "Error without Op is skipped",
+			err:  &Error{Code: CodeNotFound, Err: WithOp(errors.New("no rows"), "UserRepo.Get")},
+			want: "UserRepo.Get"},
+		{name: "none", err: errors.New("boom"), want: ""},
+		{name: "nil", err: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, OpOf(tt.err))
+		})
+	}
+}
+
+// ---------- Test Helpers ----------
+
+type observation struct {
+	code ErrorCode
+	op   string
+}
+
+type observations struct {
+	mu  sync.Mutex
+	obs []observation
+}
+
+func (o *observations) all() []observation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.obs
+}
+
+// recordObservations installs a recording observer until t ends.
+func recordObservations(t *testing.T) *observations {
+	t.Helper()
+
+	rec := &observations{}
+	SetObserver(func(code ErrorCode, op string) {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.obs = append(rec.obs, observation{code, op})
+	})
+	t.Cleanup(func() { SetObserver(nil) })
+	return rec
+}
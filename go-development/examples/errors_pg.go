@@ -0,0 +1,121 @@
+// Package errors provides Postgres error translation into coded errors.
+package errors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PGOption configures FromPG.
+type PGOption func(*pgOptions)
+
+type pgOptions struct {
+	fields map[string]string
+}
+
+// WithConstraintFields names the field behind each constraint, e.g.
+// {"users_email_key": "email"}, so violations say which input to fix.
+func WithConstraintFields(fields map[string]string) PGOption {
+	return func(o *pgOptions) {
+		o.fields = fields
+	}
+}
+
+// FromPG translates a pgx error into a coded *Error with a client-safe
+// message naming resource, wrapping err for logs. Call it once, in the
+// repository, where resource is known:
+//
+//	pgx.ErrNoRows                    NotFound     "user not found"
+//	23505 unique_violation           Conflict     "user already exists"
+//	23503 foreign_key_violation      Invalid      "user references a missing record"
+//	23502 not_null_violation         Invalid      "email is required"
+//	23514 check_violation            Invalid      "user is invalid"
+//	22001 string_data_right_trunc.   Invalid      "value too long"
+//	classes 08, 40, 53, 57, 58       Unavailable  "service unavailable"
+//	connection failures              Unavailable  "service unavailable"
+//	network and deadline timeouts    Timeout      "request timed out"
+//	context.Canceled                 Canceled     "request canceled"
+//	anything else                    Internal
+//
+// Class 40 covers serialization failures and deadlocks, where retrying
+// the transaction usually succeeds; Retryable is true for Unavailable
+// and Timeout. It returns nil for nil.
+func FromPG(err error, resource string, opts ...PGOption) error {
+	if err == nil {
+		return nil
+	}
+	cfg := &pgOptions{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var coded *Error
+	if errors.As(err, &coded) {
+		return err // already translated
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Code: CodeNotFound, Message: resource + " not found", Err: err}
+	}
+
+	var netErr net.Error
+	var connectErr *pgconn.ConnectError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &Error{Code: CodeCanceled, Message: "request canceled", Err: err}
+	case pgconn.Timeout(err), errors.As(err, &netErr) && netErr.Timeout():
+		return &Error{Code: CodeTimeout, Message: "request timed out", Err: err}
+	case errors.As(err, &connectErr), pgconn.SafeToRetry(err),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNREFUSED):
+		return &Error{Code: CodeUnavailable, Message: "service unavailable", Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return &Error{Code: CodeInternal, Err: err}
+	}
+
+	field := cfg.fields[pgErr.ConstraintName]
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		if field != "" {
+			return &Error{Code: CodeConflict, Message: resource + " with this " + field + " already exists", Err: err}
+		}
+		return &Error{Code: CodeConflict, Message: resource + " already exists", Err: err}
+	case "23503": // foreign_key_violation
+		if field != "" {
+			return &Error{Code: CodeInvalid, Message: field + " references a missing record", Err: err}
+		}
+		return &Error{Code: CodeInvalid, Message: resource + " references a missing record", Err: err}
+	case "23502": // not_null_violation
+		if pgErr.ColumnName != "" {
+			return &Error{Code: CodeInvalid, Message: pgErr.ColumnName + " is required", Err: err}
+		}
+		return &Error{Code: CodeInvalid, Message: resource + " is missing a required value", Err: err}
+	case "23514": // check_violation
+		if field != "" {
+			return &Error{Code: CodeInvalid, Message: field + " is invalid", Err: err}
+		}
+		return &Error{Code: CodeInvalid, Message: resource + " is invalid", Err: err}
+	case "22001": // string_data_right_truncation
+		return &Error{Code: CodeInvalid, Message: "value too long", Err: err}
+	}
+
+	switch {
+	case strings.HasPrefix(pgErr.Code, "08"), // connection exception
+		strings.HasPrefix(pgErr.Code, "40"), // transaction rollback
+		strings.HasPrefix(pgErr.Code, "53"), // insufficient resources
+		strings.HasPrefix(pgErr.Code, "57"), // operator intervention
+		strings.HasPrefix(pgErr.Code, "58"): // system error
+		return &Error{Code: CodeUnavailable, Message: "service unavailable", Err: err}
+	default:
+		return &Error{Code: CodeInternal, Err: err}
+	}
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Test Helpers ----------
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// safeToRetryError is what pgconn returns when nothing reached the server.
+type safeToRetryError struct{}
+
+func (safeToRetryError) Error() string     { return "conn busy" }
+func (safeToRetryError) SafeToRetry() bool { return true }
+
+// ---------- FromPG Tests ----------
+
+func TestFromPG(t *testing.T) {
+	t.Parallel()
+
+	fields := WithConstraintFields(map[string]string{
+		"users_email_key":    "email",
+		"users_team_id_fkey": "team_id",
+		"users_age_check":    "age",
+	})
+
+	tests := []struct {
+		name        string
+		err         error
+		opts        []PGOption
+		wantCode    ErrorCode
+		wantMessage string
+	}{
+		{name: "no rows", err: pgx.ErrNoRows,
+			wantCode: CodeNotFound, wantMessage: "user not found"},
+		{name: "no rows wrapped", err: fmt.Errorf("scan: %w", pgx.ErrNoRows),
+			wantCode: CodeNotFound, wantMessage: "user not found"},
+		{name: "unique", err: &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			wantCode: CodeConflict, wantMessage: "user already exists"},
+		{name: "unique with field", err: &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, opts: []PGOption{fields},
+			wantCode: CodeConflict, wantMessage: "user with this email already exists"},
+		{name: "unique with unmapped constraint", err: &pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"}, opts: []PGOption{fields},
+			wantCode: CodeConflict, wantMessage: "user already exists"},
+		{name: "foreign key", err: &pgconn.PgError{Code: "23503", ConstraintName: "users_team_id_fkey"},
+			wantCode: CodeInvalid, wantMessage: "user references a missing record"},
+		{name: "foreign key with field", err: &pgconn.PgError{Code: "23503", ConstraintName: "users_team_id_fkey"}, opts: []PGOption{fields},
+			wantCode: CodeInvalid, wantMessage: "team_id references a missing record"},
+		{name: "not null", err: &pgconn.PgError{Code: "23502", ColumnName: "email"},
+			wantCode: CodeInvalid, wantMessage: "email is required"},
+		{name: "check with field", err: &pgconn.PgError{Code: "23514", ConstraintName: "users_age_check"}, opts: []PGOption{fields},
+			wantCode: CodeInvalid, wantMessage: "age is invalid"},
+		{name: "too long", err: &pgconn.PgError{Code: "22001"},
+			wantCode: CodeInvalid, wantMessage: "value too long"},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "too many connections", err: &pgconn.PgError{Code: "53300"},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: "57P01"},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "network timeout", err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+			wantCode: CodeTimeout, wantMessage: "request timed out"},
+		{name: "query deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantCode: CodeTimeout, wantMessage: "request timed out"},
+		{name: "query canceled", err: fmt.Errorf("query: %w", context.Canceled),
+			wantCode: CodeCanceled, wantMessage: "request canceled"},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "connection dropped", err: io.ErrUnexpectedEOF,
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "safe to retry", err: safeToRetryError{},
+			wantCode: CodeUnavailable, wantMessage: "service unavailable"},
+		{name: "syntax error hidden", err: &pgconn.PgError{Code: "42601", Message: `syntax error at or near "SELEC"`},
+			wantCode: CodeInternal, wantMessage: "an internal error has occurred"},
+		{name: "not a pg error", err: errors.New("dial tcp: connection refused"),
+			wantCode: CodeInternal, wantMessage: "an internal error has occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := FromPG(fmt.Errorf("UserRepo.Create: %w", tt.err), "user", tt.opts...)
+
+			var coded *Error
+			assert.ErrorAs(t, err, &coded)
+			assert.Equal(t, tt.wantCode, GetErrorCode(err))
+			assert.Equal(t, tt.wantMessage, ErrorMessage(err))
+			assert.ErrorIs(t, err, tt.err, "the original is kept for logs")
+		})
+	}
+}
+
+func TestFromPG_Nil(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, FromPG(nil, "user"))
+}
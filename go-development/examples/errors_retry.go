@@ -0,0 +1,68 @@
+// Package errors provides retry classification for coded errors.
+package errors
+
+import "errors"
+
+// retryMark overrides the classification of the error it wraps. errs
+// has the same marks; both satisfy interface{ Retryable() bool }, which
+// Retryable in either package and the worker honor.
+type retryMark struct {
+	err       error
+	retryable bool
+}
+
+func (m *retryMark) Error() string   { return m.err.Error() }
+func (m *retryMark) Unwrap() error   { return m.err }
+func (m *retryMark) Retryable() bool { return m.retryable }
+
+// MarkRetryable marks err as transient: retrying may succeed.
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: true}
+}
+
+// MarkPermanent marks err as permanent: retrying never helps, e.g. a
+// malformed job payload.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: false}
+}
+
+// Retryable reports whether retrying the operation that returned err may
+// succeed. On each branch of err, the outermost mark decides; without
+// one, CodeUnavailable and CodeTimeout (or errs.ErrUnavailable and
+// errs.ErrTimeout) are retryable and everything else isn't. A joined error is retryable only if every branch is.
+//
+// Translate driver errors first, e.g. Retryable(FromPG(err, "order")).
+func Retryable(err error) bool {
+	code := CodeInternal
+	found := false
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if m, ok := e.(interface{ Retryable() bool }); ok {
+			return m.Retryable()
+		}
+		if coded, ok := e.(*Error); ok && !found {
+			code, found = coded.Code, true
+		}
+		if c, ok := sentinelCode(e); ok && !found {
+			code, found = c, true // an errs sentinel
+		}
+		if joined, ok := e.(interface{ Unwrap() []error }); ok && !found {
+			return allRetryable(joined.Unwrap())
+		}
+	}
+	return code == CodeUnavailable || code == CodeTimeout
+}
+
+func allRetryable(branches []error) bool {
+	for _, branch := range branches {
+		if !Retryable(branch) {
+			return false
+		}
+	}
+	return len(branches) > 0
+}
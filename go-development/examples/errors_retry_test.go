@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Retryable Tests ----------
+
+func TestRetryable(t *testing.T) {
+	t.Parallel()
+
+	unavailable := &Error{Code: CodeUnavailable, Message: "try later"}
+	timeout := &Error{Code: CodeTimeout, Message: "request timed out"}
+	notFound := &Error{Code: CodeNotFound, Message: "user not found"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "uncoded", err: errors.New("boom"), want: false},
+		{name: "unavailable", err: unavailable, want: true},
+		{name: "timeout wrapped", err: fmt.Errorf("UserRepo.Get: %w", timeout), want: true},
+		{name: "not found", err: notFound, want: false},
+		{name: "internal", err: Internal("commit", errors.New("boom")), want: false},
+		{name: "outermost code decides", err: &Error{Code: CodeInternal, Err: unavailable}, want: false},
+		{name: "marked retryable", err: fmt.Errorf("op: %w", MarkRetryable(errors.New("rate limited"))), want: true},
+		{name: "mark under a code", err: Internal("send", MarkRetryable(errors.New("smtp 421"))), want: true},
+		{name: "marked permanent", err: MarkPermanent(fmt.Errorf("op: %w", unavailable)), want: false},
+		{name: "joined all transient", err: errors.Join(unavailable, timeout), want: true},
+		{name: "joined with permanent", err: errors.Join(unavailable, notFound), want: false},
+		{name: "joined with uncoded", err: errors.Join(unavailable, errors.New("boom")), want: false},
+		{name: "joined nested and wrapped",
+			err: fmt.Errorf("batch: %w", errors.Join(timeout, errors.Join(MarkRetryable(errors.New("x")), unavailable))), want: true},
+		{name: "serialization failure via FromPG", err: FromPG(&pgconn.PgError{Code: "40001"}, "order"), want: true},
+		{name: "unique violation via FromPG", err: FromPG(&pgconn.PgError{Code: "23505"}, "order"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Retryable(tt.err))
+		})
+	}
+}
+
+func TestMark(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, MarkRetryable(nil))
+	assert.NoError(t, MarkPermanent(nil))
+
+	cause := &Error{Code: CodeNotFound, Message: "user not found"}
+	err := MarkRetryable(cause)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, CodeNotFound, GetErrorCode(err), "marks don't change the code")
+	assert.Equal(t, "user not found", err.Error())
+}
+
+func TestFromPG_PassesThroughCoded(t *testing.T) {
+	t.Parallel()
+
+	coded := fmt.Errorf("callback: %w", &Error{Code: CodeUnavailable, Message: "smtp down"})
+	assert.Same(t, coded, FromPG(coded, "order"))
+	assert.True(t, Retryable(FromPG(coded, "order")))
+}
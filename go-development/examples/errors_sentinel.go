@@ -0,0 +1,90 @@
+// Package errors provides adapters between coded errors and errs
+// sentinels.
+package errors
+
+import (
+	"fmt"
+
+	"myapp/internal/errs"
+)
+
+// sentinels pairs each errs sentinel with its code.
+var sentinels = []struct {
+	err  error
+	code ErrorCode
+}{
+	{errs.ErrNotFound, CodeNotFound},
+	{errs.ErrConflict, CodeConflict},
+	{errs.ErrValidation, CodeInvalid},
+	{errs.ErrForbidden, CodeForbidden},
+	{errs.ErrUnauthorized, CodeUnauthorized},
+	{errs.ErrTimeout, CodeTimeout},
+	{errs.ErrUnavailable, CodeUnavailable},
+}
+
+// sentinelCode returns the code of err if it is an errs sentinel itself,
+// not one wrapped.
+func sentinelCode(err error) (ErrorCode, bool) {
+	for _, s := range sentinels {
+		if err == s.err { //nolint:errorlint // identity; walk does the unwrapping
+			return s.code, true
+		}
+	}
+	return "", false
+}
+
+// Is reports whether target is the errs sentinel of e's code, so
+// errors.Is(err, errs.ErrNotFound) and errs.HTTPStatus see coded errors.
+func (e *Error) Is(target error) bool {
+	code, ok := sentinelCode(target)
+	return ok && code == e.Code
+}
+
+// Is reports whether target is errs.ErrValidation.
+func (v ValidationErrors) Is(target error) bool {
+	return target == errs.ErrValidation //nolint:errorlint // Is compares one level
+}
+
+// FromSentinel converts an errs error into an *Error: errs.ErrNotFound
+// becomes CodeNotFound and so on, with errs.Message as the client-safe
+// message and err wrapped, so its text stays in logs. err itself is
+// returned if it is an *Error; errors with neither are CodeInternal. It
+// returns nil for nil.
+//
+// GetErrorCode and HTTPStatusCode already recognize sentinels; convert
+// where a caller needs an *Error, e.g. for errors.As or WithDetail.
+func FromSentinel(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*Error); ok { //nolint:errorlint // only err itself; wrapped ones keep their context
+		return e
+	}
+
+	code := GetErrorCode(err)
+	if code == CodeInternal {
+		return &Error{Code: CodeInternal, Err: err}
+	}
+	return &Error{Code: code, Message: ErrorMessage(err), Err: err}
+}
+
+// ---------- Constructors ----------
+
+// NotFoundf creates a CodeNotFound error; the formatted message is shown
+// to clients. Like errs.NotFoundf, it takes the operation first:
+//
+//	return errors.NotFoundf("UserRepo.Get", "user %s not found", id)
+func NotFoundf(op, format string, args ...any) *Error {
+	return &Error{Code: CodeNotFound, Op: op, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflictf creates a CodeConflict error with a client-safe message.
+func Conflictf(op, format string, args ...any) *Error {
+	return &Error{Code: CodeConflict, Op: op, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalidf creates a CodeInvalid error with a client-safe message. Use
+// ValidationErrors for per-field failures.
+func Invalidf(op, format string, args ...any) *Error {
+	return &Error{Code: CodeInvalid, Op: op, Message: fmt.Sprintf(format, args...)}
+}
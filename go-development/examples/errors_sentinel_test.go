@@ -0,0 +1,184 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/errs"
+)
+
+// ---------- Sentinel Interop Tests ----------
+
+func TestHTTPStatusCode_Sentinels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    ErrorCode
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "not found", err: errs.NotFoundf("UserRepo.Get", "userID=%s", "42"),
+			wantCode: CodeNotFound, wantStatus: http.StatusNotFound, wantMessage: "resource not found"},
+		{name: "conflict", err: errs.Conflictf("UserRepo.Create", "email taken"),
+			wantCode: CodeConflict, wantStatus: http.StatusConflict, wantMessage: "resource conflict"},
+		{name: "validation", err: errs.Validationf("UserService.Create", "name empty"),
+			wantCode: CodeInvalid, wantStatus: http.StatusBadRequest, wantMessage: "validation failed"},
+		{name: "forbidden", err: errs.Forbiddenf("OrderService.Cancel", "not owner"),
+			wantCode: CodeForbidden, wantStatus: http.StatusForbidden, wantMessage: "forbidden"},
+		{name: "unauthorized", err: errs.Unauthorizedf("Auth.Verify", "expired"),
+			wantCode: CodeUnauthorized, wantStatus: http.StatusUnauthorized, wantMessage: "unauthorized"},
+		{name: "timeout", err: errs.Wrap("Client.Call", errs.ErrTimeout),
+			wantCode: CodeTimeout, wantStatus: http.StatusGatewayTimeout, wantMessage: "request timeout"},
+		{name: "unavailable", err: errs.ErrUnavailable,
+			wantCode: CodeUnavailable, wantStatus: http.StatusServiceUnavailable, wantMessage: "service unavailable"},
+		{name: "plain error stays internal", err: errs.Wrap("UserRepo.Get", errors.New("boom")),
+			wantCode: CodeInternal, wantStatus: http.StatusInternalServerError, wantMessage: "an internal error has occurred"},
+		{name: "joined with coded", err: errors.Join(errs.NotFoundf("a", "b"), New(CodeForbidden, "admins only")),
+			wantCode: CodeForbidden, wantStatus: http.StatusForbidden, wantMessage: "admins only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantCode, GetErrorCode(tt.err))
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(tt.err))
+			assert.Equal(t, tt.wantMessage, ErrorMessage(tt.err))
+
+			converted := FromSentinel(tt.err)
+			assert.Equal(t, tt.wantCode, converted.Code)
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(converted))
+			assert.Equal(t, tt.wantMessage, ErrorMessage(converted))
+			assert.ErrorIs(t, converted, tt.err, "the original stays wrapped")
+			assert.Contains(t, converted.Error(), tt.err.Error())
+		})
+	}
+}
+
+func TestErrsHTTPStatus_Coded(t *testing.T) {
+	t.Parallel()
+
+	var verrs ValidationErrors
+	verrs.Add("email", "email", "invalid email format")
+
+	tests := []struct {
+		name        string
+		err         error
+		sentinel    error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "not found", err: NotFoundf("UserRepo.Get", "user %s not found", "42"),
+			sentinel: errs.ErrNotFound, wantStatus: http.StatusNotFound, wantMessage: "resource not found"},
+		{name: "conflict", err: Conflictf("UserRepo.Create", "user exists"),
+			sentinel: errs.ErrConflict, wantStatus: http.StatusConflict, wantMessage: "resource conflict"},
+		{name: "invalid", err: Invalidf("UserService.Create", "bad email"),
+			sentinel: errs.ErrValidation, wantStatus: http.StatusBadRequest, wantMessage: "validation failed"},
+		{name: "validation errors", err: fmt.Errorf("create: %w", verrs),
+			sentinel: errs.ErrValidation, wantStatus: http.StatusBadRequest, wantMessage: "validation failed"},
+		{name: "forbidden", err: New(CodeForbidden, "admins only"),
+			sentinel: errs.ErrForbidden, wantStatus: http.StatusForbidden, wantMessage: "forbidden"},
+		{name: "unauthorized", err: New(CodeUnauthorized, "token expired"),
+			sentinel: errs.ErrUnauthorized, wantStatus: http.StatusUnauthorized, wantMessage: "unauthorized"},
+		{name: "timeout", err: errs.Wrap("OrderService.Pay", New(CodeTimeout, "request timed out")),
+			sentinel: errs.ErrTimeout, wantStatus: http.StatusGatewayTimeout, wantMessage: "request timeout"},
+		{name: "unavailable", err: New(CodeUnavailable, "try later"),
+			sentinel: errs.ErrUnavailable, wantStatus: http.StatusServiceUnavailable, wantMessage: "service unavailable"},
+		{name: "internal", err: Internal("query", errors.New("boom")),
+			sentinel: nil, wantStatus: http.StatusInternalServerError, wantMessage: "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantStatus, errs.HTTPStatus(tt.err))
+			assert.Equal(t, tt.wantMessage, errs.Message(tt.err))
+			if tt.sentinel != nil {
+				assert.ErrorIs(t, tt.err, tt.sentinel)
+			}
+
+			converted := errs.FromCoded(tt.err)
+			assert.Equal(t, tt.wantStatus, errs.HTTPStatus(converted))
+			assert.Equal(t, tt.err.Error(), converted.Error(), "same text")
+			assert.Equal(t, GetErrorCode(tt.err), GetErrorCode(converted), "round trip keeps the code")
+			assert.Equal(t, ErrorMessage(tt.err), ErrorMessage(converted))
+		})
+	}
+}
+
+func TestFromCoded_KeepsCodedError(t *testing.T) {
+	t.Parallel()
+
+	err := errs.FromCoded(NotFoundf("UserRepo.Get", "user not found").WithDetail("id", "42"))
+
+	var coded *Error
+	require.ErrorAs(t, err, &coded)
+	assert.Equal(t, "UserRepo.Get", coded.Op)
+	assert.Equal(t, map[string]any{"id": "42"}, Details(err))
+	assert.Same(t, errs.ErrNotFound, findSentinel(err), "the sentinel itself is in the chain")
+}
+
+func TestFromSentinel(t *testing.T) {
+	t.Parallel()
+
+	coded := New(CodeConflict, "user exists")
+	assert.Same(t, coded, FromSentinel(coded), "already coded")
+	assert.Nil(t, FromSentinel(nil))
+
+	internal := FromSentinel(errors.New("boom"))
+	assert.Equal(t, CodeInternal, internal.Code)
+	assert.Empty(t, internal.Message)
+}
+
+func TestRetryable_AcrossPackages(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Retryable(errs.Wrap("Client.Call", errs.ErrUnavailable)), "coded Retryable sees sentinels")
+	assert.False(t, Retryable(errs.NotFoundf("a", "b")))
+	assert.True(t, errs.Retryable(New(CodeTimeout, "request timed out")), "errs.Retryable sees codes")
+	assert.True(t, errs.Retryable(fmt.Errorf("x: %w", New(CodeUnavailable, "try later"))))
+	assert.False(t, errs.Retryable(errs.MarkPermanent(New(CodeUnavailable, "try later"))))
+	assert.False(t, errs.Retryable(New(CodeNotFound, "gone")))
+}
+
+func TestConstructors(t *testing.T) {
+	t.Parallel()
+
+	err := Conflictf("UserRepo.Create", "user %q already exists", "ann@example.com")
+	assert.Equal(t, CodeConflict, err.Code)
+	assert.Equal(t, "UserRepo.Create", err.Op)
+	assert.Equal(t, `user "ann@example.com" already exists`, ErrorMessage(err))
+	assert.Equal(t, `UserRepo.Create: user "ann@example.com" already exists`, err.Error())
+
+	assert.Equal(t, CodeNotFound, NotFoundf("UserRepo.Get", "user %d not found", 42).Code)
+	assert.Equal(t, CodeInvalid, Invalidf("UserService.Create", "age must be positive").Code)
+}
+
+// ---------- Test Helpers ----------
+
+// findSentinel returns the errs sentinel reached by identity in err's
+// tree, the way errs.Retryable looks for one.
+func findSentinel(err error) error {
+	if _, ok := sentinelCode(err); ok {
+		return err
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if s := findSentinel(inner); s != nil {
+				return s
+			}
+		}
+	case interface{ Unwrap() error }:
+		return findSentinel(e.Unwrap())
+	}
+	return nil
+}
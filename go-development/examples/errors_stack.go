@@ -0,0 +1,170 @@
+// Package errors provides stack capture for internal errors.
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+const maxStackDepth = 32
+
+var stacksDisabled atomic.Bool
+
+// SetStackCapture turns stack capture by Internal and E on or off. It is
+// on by default; turn it off if profiling shows runtime.Callers.
+func SetStackCapture(enabled bool) {
+	stacksDisabled.Store(!enabled)
+}
+
+// Frame is one call in a captured stack.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f Frame) String() string {
+	return f.Function + " " + f.File + ":" + strconv.Itoa(f.Line)
+}
+
+// Op names the operation in E, e.g. errors.Op("UserService.Create").
+type Op string
+
+// Internal returns a CodeInternal error wrapping err, with the stack of
+// its caller. msg is for logs; clients get ErrorMessage's generic text.
+//
+//	if err := tx.Commit(ctx); err != nil {
+//	    return errors.Internal("commit order", err)
+//	}
+func Internal(msg string, err error) *Error {
+	e := &Error{Code: CodeInternal, Message: msg, Err: err}
+	e.captureStack(err)
+	return e
+}
+
+// E builds an *Error from its arguments by type, in any order:
+//
+//	ErrorCode  the code; defaults to the wrapped *Error's, else CodeInternal
+//	Op         the operation
+//	string     the message
+//	error      the wrapped error
+//
+// Internal errors get the caller's stack, like Internal.
+//
+//	return errors.E(errors.Op("UserRepo.Get"), errors.CodeNotFound, "user not found", err)
+func E(args ...any) *Error {
+	e := &Error{}
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case ErrorCode:
+			e.Code = a
+		case Op:
+			e.Op = string(a)
+		case string:
+			e.Message = a
+		case error:
+			e.Err = a
+		default:
+			panic(fmt.Sprintf("errors.E: unsupported argument %T", arg))
+		}
+	}
+
+	if e.Code == "" {
+		e.Code = CodeInternal
+		var inner *Error
+		if errors.As(e.Err, &inner) {
+			e.Code = inner.Code
+		}
+	}
+	if e.Code == CodeInternal {
+		e.captureStack(e.Err)
+	}
+	return e
+}
+
+// captureStack records the stack of the constructor's caller, unless
+// capture is off or cause already carries a stack.
+func (e *Error) captureStack(cause error) {
+	if stacksDisabled.Load() || hasStack(cause) {
+		return
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(3, pcs) // runtime.Callers, captureStack, constructor
+	e.stack = pcs[:n]
+}
+
+func hasStack(err error) bool {
+	var inner *Error
+	for errors.As(err, &inner) {
+		if inner.stack != nil {
+			return true
+		}
+		err = inner.Err
+	}
+	return false
+}
+
+// StackTrace returns where the error was created, innermost caller
+// first: e's own stack, or the first one captured further down the
+// chain. It is nil if none was captured.
+func (e *Error) StackTrace() []Frame {
+	pcs := e.stack
+	if pcs == nil {
+		var inner *Error
+		for err := e.Err; errors.As(err, &inner); err = inner.Err {
+			if inner.stack != nil {
+				pcs = inner.stack
+				break
+			}
+		}
+	}
+	if pcs == nil {
+		return nil
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	var trace []Frame
+	for {
+		f, more := frames.Next()
+		trace = append(trace, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			return trace
+		}
+	}
+}
+
+// StackTrace returns the stack of the first *Error in err's chain, for
+// logging:
+//
+//	logger.Error("internal error", slog.Any("stack", errors.StackTrace(err)))
+func StackTrace(err error) []Frame {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StackTrace()
+	}
+	return nil
+}
+
+// Format prints the stack after the message for %+v, for logs and
+// debugging; %v and %s print Error().
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, e.Error())
+			for _, f := range e.StackTrace() {
+				fmt.Fprintf(s, "\n%s\n\t%s:%d", f.Function, f.File, f.Line)
+			}
+			return
+		}
+		io.WriteString(s, e.Error())
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+// failingRepo creates errors one call below the test, like a repository.
+func failingRepo(cause error) *Error {
+	return Internal("load user", cause)
+}
+
+// ---------- Stack Tests ----------
+
+func TestInternal_CapturesCaller(t *testing.T) {
+	t.Parallel()
+
+	err := failingRepo(errors.New("connection reset"))
+
+	trace := err.StackTrace()
+	require.NotEmpty(t, trace)
+	assert.True(t, strings.HasSuffix(trace[0].Function, ".failingRepo"), trace[0].Function)
+	assert.True(t, strings.HasSuffix(trace[0].File, "errors_stack_test.go"), trace[0].File)
+	assert.True(t, strings.HasSuffix(trace[1].Function, ".TestInternal_CapturesCaller"), trace[1].Function)
+
+	assert.Equal(t, CodeInternal, GetErrorCode(err))
+	assert.Equal(t, "an internal error has occurred", ErrorMessage(err), "no stack or cause for clients")
+}
+
+func TestE(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("no rows")
+	err := E(Op("UserRepo.Get"), CodeNotFound, "user not found", cause)
+
+	assert.Equal(t, &Error{Code: CodeNotFound, Message: "user not found", Op: "UserRepo.Get", Err: cause}, err)
+	assert.Nil(t, err.StackTrace(), "only internal errors capture")
+
+	internal := E(Op("UserRepo.Get"), cause)
+	assert.Equal(t, CodeInternal, internal.Code)
+	require.NotEmpty(t, internal.StackTrace())
+	assert.True(t, strings.HasSuffix(internal.StackTrace()[0].Function, ".TestE"))
+
+	inherited := E(Op("UserService.Get"), err)
+	assert.Equal(t, CodeNotFound, inherited.Code, "code comes from the wrapped *Error")
+
+	assert.Panics(t, func() { E(42) })
+}
+
+func TestInternal_NoRecapture(t *testing.T) {
+	t.Parallel()
+
+	inner := failingRepo(errors.New("connection reset"))
+	outer := Internal("get user", fmt.Errorf("UserService.Get: %w", inner))
+
+	assert.Nil(t, outer.stack, "the inner stack is kept")
+	assert.Equal(t, inner.StackTrace(), outer.StackTrace())
+	assert.Nil(t, E(Op("Handler.Get"), outer).stack)
+}
+
+func TestError_Format(t *testing.T) {
+	t.Parallel()
+
+	err := failingRepo(errors.New("connection reset"))
+
+	assert.Equal(t, "load user: connection reset", fmt.Sprintf("%v", err))
+	assert.Equal(t, "load user: connection reset", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"load user: connection reset"`, fmt.Sprintf("%q", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	lines := strings.Split(verbose, "\n")
+	assert.Equal(t, "load user: connection reset", lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], ".failingRepo"), lines[1])
+	assert.Contains(t, lines[2], "errors_stack_test.go:")
+
+	assert.Equal(t, "not_found", fmt.Sprintf("%+v", &Error{Code: CodeNotFound}), "no stack, no frames")
+}
+
+func TestFrame_JSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(failingRepo(nil).StackTrace()[0])
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Contains(t, got["function"], "failingRepo")
+	assert.Contains(t, got["file"], "errors_stack_test.go")
+	assert.NotZero(t, got["line"])
+}
+
+// Not parallel: toggles package state.
+func TestSetStackCapture(t *testing.T) {
+	SetStackCapture(false)
+	t.Cleanup(func() { SetStackCapture(true) })
+
+	assert.Nil(t, failingRepo(nil).StackTrace())
+	assert.Nil(t, E("boom").StackTrace())
+}
+
+func TestStackTrace(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("UserService.Get: %w", failingRepo(nil))
+	require.NotEmpty(t, StackTrace(err))
+	assert.True(t, strings.HasSuffix(StackTrace(err)[0].Function, ".failingRepo"))
+
+	assert.Nil(t, StackTrace(errors.New("plain")))
+	assert.Nil(t, StackTrace(&Error{Code: CodeNotFound}))
+}
@@ -0,0 +1,95 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Retryable Tests ----------
+
+func TestRetryable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain", err: errors.New("boom"), want: false},
+		{name: "unavailable", err: ErrUnavailable, want: true},
+		{name: "timeout wrapped", err: Wrap("UserRepo.Get", fmt.Errorf("query: %w", ErrTimeout)), want: true},
+		{name: "not found", err: NotFoundf("UserRepo.Get", "userID=%s", "42"), want: false},
+		{name: "marked retryable", err: Wrap("Job.Run", MarkRetryable(errors.New("rate limited"))), want: true},
+		{name: "marked permanent", err: MarkPermanent(Wrap("Job.Run", ErrUnavailable)), want: false},
+		{name: "outermost mark wins", err: MarkRetryable(MarkPermanent(errors.New("x"))), want: true},
+		{name: "joined all transient", err: errors.Join(ErrTimeout, Wrap("op", ErrUnavailable)), want: true},
+		{name: "joined with permanent", err: errors.Join(ErrTimeout, ErrNotFound), want: false},
+		{name: "joined nested", err: Wrap("batch", errors.Join(ErrTimeout, errors.Join(MarkRetryable(errors.New("x"))))), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Retryable(tt.err))
+		})
+	}
+}
+
+func TestMark(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, MarkRetryable(nil))
+	assert.NoError(t, MarkPermanent(nil))
+
+	err := MarkPermanent(NotFoundf("UserRepo.Get", "userID=%s", "42"))
+	assert.ErrorIs(t, err, ErrNotFound, "marks keep the chain")
+	assert.Equal(t, "UserRepo.Get: not found: userID=42", err.Error())
+	assert.Equal(t, 404, HTTPStatus(err))
+}
+
+// ---------- FromCoded Tests ----------
+
+// stubCoded stands in for the errors package's *Error, which errs tests
+// can't import.
+type stubCoded struct{ sentinel error }
+
+func (e *stubCoded) Error() string        { return "user not found" }
+func (e *stubCoded) Is(target error) bool { return target == e.sentinel }
+
+func TestFromCoded(t *testing.T) {
+	t.Parallel()
+
+	coded := &stubCoded{sentinel: ErrNotFound}
+	err := FromCoded(fmt.Errorf("UserService.Get: %w", coded))
+
+	assert.Equal(t, "UserService.Get: user not found", err.Error())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, coded)
+	assert.Equal(t, 404, HTTPStatus(err))
+
+	plain := errors.New("boom")
+	assert.Same(t, plain, FromCoded(plain))
+	assert.Same(t, ErrConflict, FromCoded(ErrConflict))
+	assert.NoError(t, FromCoded(nil))
+}
+
+// ---------- HTTPStatus Tests ----------
+
+func TestHTTPStatus_Context(t *testing.T) {
+	t.Parallel()
+
+	deadline := fmt.Errorf("UserRepo.Get: %w", context.DeadlineExceeded)
+	assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(deadline))
+	assert.Equal(t, "request timeout", Message(deadline))
+
+	canceled := Wrap("UserService.Get", fmt.Errorf("UserRepo.Get: %w", context.Canceled))
+	assert.Equal(t, StatusClientClosedRequest, HTTPStatus(canceled))
+	assert.Equal(t, "request canceled", Message(canceled))
+}
@@ -3,6 +3,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 )
 
@@ -19,13 +20,20 @@ type User struct {
 	IsActive  bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int64 `db:"version"` // bumped by every write; the ETag
 }
 
-// UserColumns returns column names for SELECT queries.
+// ErrVersionConflict is returned by a write whose expected version is no
+// longer the stored one.
+var ErrVersionConflict = errors.New("version conflict")
+
+// UserColumns returns column names for SELECT queries. version needs:
+//
+//	ALTER TABLE users ADD COLUMN version bigint NOT NULL DEFAULT 1;
 func UserColumns() []string {
 	return []string{
 		"id", "email", "name", "role",
-		"is_active", "created_at", "updated_at",
+		"is_active", "created_at", "updated_at", "version",
 	}
 }
 
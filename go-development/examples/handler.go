@@ -14,21 +14,29 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
+
+	apperrors "myapp/internal/errors"
+	"myapp/internal/pagination"
 )
 
 // Path constants define API endpoints as single source of truth.
 const (
 	PathPrefix = "/api/v1"
 
-	UsersPath    = "/users"
-	UserByIDPath = "/users/{userID}"
+	UsersPath           = "/users"
+	UsersBulkPath       = "/users/bulk"
+	UsersBulkDeletePath = "/users/bulk/delete"
+	UserByIDPath        = "/users/{userID}"
+	UserAvatarPath      = "/users/{userID}/avatar"
 
 	OrdersPath    = "/orders"
 	OrderByIDPath = "/orders/{orderID}"
@@ -43,6 +51,10 @@ func NewRouter(userHandler *UserHandler) http.Handler {
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(middleware.GetHead) // HEAD runs the GET handler
+
+	r.NotFound(notFound)                 // JSON envelope, not plain text
+	r.MethodNotAllowed(methodNotAllowed) // JSON envelope with Allow
 
 	r.Get("/health", healthHandler)
 	r.Get("/ready", readyHandler)
@@ -50,9 +62,13 @@ func NewRouter(userHandler *UserHandler) http.Handler {
 	r.Route(PathPrefix, func(r chi.Router) {
 		r.Post(UsersPath, userHandler.Create)
 		r.Get(UsersPath, userHandler.List)
+		r.Post(UsersBulkPath, userHandler.BulkCreate)
+		r.Post(UsersBulkDeletePath, userHandler.BulkDelete)
 		r.Get(UserByIDPath, userHandler.GetByID)
 		r.Put(UserByIDPath, userHandler.Update)
+		r.Patch(UserByIDPath, userHandler.Patch)
 		r.Delete(UserByIDPath, userHandler.Delete)
+		r.Post(UserAvatarPath, userHandler.UploadAvatar)
 	})
 
 	return r
@@ -73,37 +89,118 @@ type User struct {
 	ID        string
 	Name      string
 	Email     string
+	Status    UserStatus
+	Version   int64 // bumped by every write; see ETag
 	CreatedAt time.Time
 }
 
+// ErrVersionConflict is returned by a write whose expected version is no
+// longer the stored one (normally in internal/models, next to User).
+var ErrVersionConflict = errors.New("version conflict")
+
+// UserStatus is a user's account state.
+type UserStatus string
+
+const (
+	UserStatusActive    UserStatus = "active"
+	UserStatusPending   UserStatus = "pending"
+	UserStatusSuspended UserStatus = "suspended"
+)
+
+// UserPatch holds the fields a partial update writes; unset fields are
+// left unchanged.
+type UserPatch struct {
+	Name  Optional[string]
+	Email Optional[string]
+}
+
+// NewUser holds the fields of a user to create.
+type NewUser struct {
+	Name  string
+	Email string
+}
+
+// UserFilter selects a page of users.
+type UserFilter struct {
+	Statuses []UserStatus        // any of; empty means all
+	Sort     []pagination.Column // allow-listed, ending with a unique column
+	Limit    int
+	Offset   int
+}
+
 // UserService defines the interface for user business logic.
 type UserService interface {
 	Create(ctx context.Context, name, email string) (*User, error)
 	GetByID(ctx context.Context, id string) (*User, error)
-	List(ctx context.Context, limit, offset int) ([]*User, int64, error)
-	Update(ctx context.Context, id, name, email string) (*User, error)
-	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filter UserFilter) ([]*User, int64, error)
+	// Update, UpdatePartial and Delete write only if the user is still at
+	// version, and return ErrVersionConflict otherwise. AnyVersion skips
+	// the check.
+	Update(ctx context.Context, id string, version int64, name, email string) (*User, error)
+	UpdatePartial(ctx context.Context, id string, version int64, patch UserPatch) (*User, error)
+	Delete(ctx context.Context, id string, version int64) error
+
+	// CreateBatch and DeleteBatch run in one transaction: every user is
+	// created (deleted) or none is.
+	CreateBatch(ctx context.Context, users []NewUser) ([]*User, error)
+	DeleteBatch(ctx context.Context, ids []string) error
 }
 
 // UserHandler handles user HTTP endpoints.
 type UserHandler struct {
 	userService UserService
 	validate    *validator.Validate
+	decode      []DecodeOption
+	maxBulk     int
+	blobs       BlobStore
+
+	requireIfMatch bool
+	exportFormats  bool
+}
+
+// HandlerOption configures a UserHandler.
+type HandlerOption func(*UserHandler)
+
+// WithDisallowUnknownFields rejects request bodies with fields the request
+// type doesn't declare, so a typo like "emial" fails instead of being
+// silently ignored.
+func WithDisallowUnknownFields() HandlerOption {
+	return func(h *UserHandler) {
+		h.decode = append(h.decode, DisallowUnknownFields())
+	}
 }
 
 // NewUserHandler creates a new user handler.
-func NewUserHandler(svc UserService) *UserHandler {
-	return &UserHandler{
+func NewUserHandler(svc UserService, opts ...HandlerOption) *UserHandler {
+	h := &UserHandler{
 		userService: svc,
-		validate:    validator.New(),
+		validate:    newValidator(),
+		maxBulk:     DefaultMaxBulkItems,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// newValidator reports fields by their JSON name, the one clients send.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
 }
 
 // Create handles POST /users.
 func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	req, err := decodeCreateUserRequest(r, h.validate)
+	req, err := DecodeJSON[CreateUserRequest](r, h.validate, h.decode...)
 	if err != nil {
 		encodeErrorResponse(w, err)
 		return
@@ -115,7 +212,9 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encodeJSONResponse(w, http.StatusCreated, toUserResponse(user))
+	setETag(w, user)
+	w.Header().Set("Location", UserByIDURL(user.ID))
+	encodeCommittedResponse(w, http.StatusCreated, toUserResponse(user))
 }
 
 // GetByID handles GET /users/{userID}.
@@ -134,31 +233,58 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encodeJSONResponse(w, http.StatusOK, toUserResponse(user))
+	setETag(w, user)
+	encodeMaskedResponse(w, r, http.StatusOK, toUserResponse(user)) // ?fields=id,name
+}
+
+// userSortFields maps the ?sort= names of List to columns.
+var userSortFields = map[string]string{
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
 }
 
-// List handles GET /users.
+// List handles GET /users?status=active,pending&sort=-created_at,name&limit=20&offset=0.
 func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	limit := getIntQuery(r, "limit", 20)
-	offset := getIntQuery(r, "offset", 0)
+	query, err := BindQuery[ListUsersQuery](r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	sort, err := ParseSort(r, userSortFields, SortField{Name: "created_at", Desc: true})
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
 
-	users, total, err := h.userService.List(ctx, limit, offset)
+	users, total, err := h.userService.List(ctx, UserFilter{
+		Statuses: query.Status,
+		Sort:     SortColumns(sort, "id"),
+		Limit:    query.Limit,
+		Offset:   query.Offset,
+	})
 	if err != nil {
 		encodeErrorResponse(w, err)
 		return
 	}
 
-	encodeJSONResponse(w, http.StatusOK, ListResponse[UserResponse]{
+	resp := ListResponse[UserResponse]{
 		Items:      toUserResponses(users),
 		TotalCount: total,
-		Limit:      limit,
-		Offset:     offset,
-	})
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+	}
+	if h.exportFormats {
+		encodeResponse(w, r, http.StatusOK, resp) // also ?format=csv, ndjson
+		return
+	}
+	encodeMaskedResponse(w, r, http.StatusOK, resp)
 }
 
-// Update handles PUT /users/{userID}.
+// Update handles PUT /users/{userID}. With If-Match, the user is only
+// written if its ETag still matches.
 func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -168,22 +294,95 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req, err := decodeUpdateUserRequest(r, h.validate)
+	version, err := h.ifMatch(r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	req, err := DecodeJSON[UpdateUserRequest](r, h.validate, h.decode...)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	user, err := h.userService.Update(ctx, userID, version, deref(req.Name), deref(req.Email))
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	setETag(w, user)
+	encodeCommittedResponse(w, http.StatusOK, toUserResponse(user))
+}
+
+// Patch handles PATCH /users/{userID} with a JSON Merge Patch (RFC 7386):
+// fields left out are unchanged and null clears a field. The patched user
+// must pass CreateUserRequest's rules, so PATCH can't produce a user
+// Create would reject, but only the fields in the patch are written.
+// With If-Match, a stale ETag fails before the patch is read. Either way
+// the write is conditional on the version that was validated: a
+// concurrent write makes it fail with 412, or 409 without If-Match.
+func (h *UserHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		encodeErrorResponse(w, NewBadRequestError("user ID is required"))
+		return
+	}
+
+	version, err := h.ifMatch(r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	user, err := h.userService.GetByID(ctx, userID)
 	if err != nil {
 		encodeErrorResponse(w, err)
 		return
 	}
+	if version != AnyVersion && version != user.Version {
+		encodeErrorResponse(w, NewVersionConflictError())
+		return
+	}
 
-	user, err := h.userService.Update(ctx, userID, deref(req.Name), deref(req.Email))
+	patched, patch, err := DecodeMergePatch(r, CreateUserRequest{Name: user.Name, Email: user.Email}, h.decode...)
 	if err != nil {
 		encodeErrorResponse(w, err)
 		return
 	}
+	if err := h.validate.StructCtx(ctx, &patched); err != nil {
+		encodeErrorResponse(w, NewValidationError(err))
+		return
+	}
 
-	encodeJSONResponse(w, http.StatusOK, toUserResponse(user))
+	var req PatchUserRequest
+	if err := json.Unmarshal(patch, &req); err != nil {
+		encodeErrorResponse(w, fmt.Errorf("UserHandler.Patch: %w", err)) // checked by DecodeMergePatch
+		return
+	}
+
+	// Write only over the version the patch was validated against, even
+	// without If-Match: a write in between would otherwise be replaced by
+	// a result nobody validated.
+	user, err = h.userService.UpdatePartial(ctx, userID, user.Version, UserPatch{Name: req.Name, Email: req.Email})
+	if err != nil {
+		if version == AnyVersion && errors.Is(err, ErrVersionConflict) {
+			// No precondition was sent, so not a 412
+			err = apperrors.New(apperrors.CodeConflict, "user changed while the patch was applied; retry")
+		}
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	setETag(w, user)
+	encodeCommittedResponse(w, http.StatusOK, toUserResponse(user))
 }
 
-// Delete handles DELETE /users/{userID}.
+// Delete handles DELETE /users/{userID}. With If-Match, the user is only
+// deleted if its ETag still matches.
 func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -193,12 +392,18 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.Delete(ctx, userID); err != nil {
+	version, err := h.ifMatch(r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	if err := h.userService.Delete(ctx, userID, version); err != nil {
 		encodeErrorResponse(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(http.StatusNoContent) // even past the deadline: the user is gone
 }
 
 // CreateUserRequest represents the request body for creating a user.
@@ -207,18 +412,32 @@ type CreateUserRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+// PatchUserRequest represents a merge patch body for a user.
+type PatchUserRequest struct {
+	Name  Optional[string] `json:"name"`
+	Email Optional[string] `json:"email"`
+}
+
 // UpdateUserRequest represents the request body for updating a user.
 type UpdateUserRequest struct {
 	Name  *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
 	Email *string `json:"email,omitempty" validate:"omitempty,email"`
 }
 
+// ListUsersQuery represents the query string for listing users.
+type ListUsersQuery struct {
+	Status []UserStatus `query:"status" enum:"active,pending,suspended"`
+	Limit  int          `query:"limit" default:"20" min:"1" max:"100"`
+	Offset int          `query:"offset" default:"0" min:"0"`
+}
+
 // UserResponse represents the response body for a user.
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id" csv:"id"`
+	Name      string    `json:"name" csv:"name"`
+	Email     string    `json:"email" csv:"email"`
+	Status    string    `json:"status,omitempty" csv:"status"`
+	CreatedAt time.Time `json:"created_at" csv:"created_at"`
 }
 
 func toUserResponse(u *User) UserResponse {
@@ -226,6 +445,7 @@ func toUserResponse(u *User) UserResponse {
 		ID:        u.ID,
 		Name:      u.Name,
 		Email:     u.Email,
+		Status:    string(u.Status),
 		CreatedAt: u.CreatedAt,
 	}
 }
@@ -248,34 +468,26 @@ type ListResponse[T any] struct {
 
 // ErrorResponse represents an API error response.
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code,omitempty"`
-	Details any    `json:"details,omitempty"`
-}
-
-func decodeCreateUserRequest(r *http.Request, v *validator.Validate) (*CreateUserRequest, error) {
-	var req CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		return nil, NewBadRequestError("invalid JSON")
-	}
-	if err := v.StructCtx(r.Context(), &req); err != nil {
-		return nil, NewValidationError(err)
-	}
-	return &req, nil
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
-func decodeUpdateUserRequest(r *http.Request, v *validator.Validate) (*UpdateUserRequest, error) {
-	var req UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		return nil, NewBadRequestError("invalid JSON")
-	}
-	if err := v.StructCtx(r.Context(), &req); err != nil {
-		return nil, NewValidationError(err)
+// encodeJSONResponse writes data as JSON with status, unless r's context
+// is done (see requestDone). Responses to writes that have happened go
+// through encodeCommittedResponse instead.
+func encodeJSONResponse(w http.ResponseWriter, r *http.Request, status int, data any) {
+	if requestDone(w, r) {
+		return
 	}
-	return &req, nil
+	encodeCommittedResponse(w, status, data)
 }
 
-func encodeJSONResponse(w http.ResponseWriter, status int, data any) {
+// encodeCommittedResponse writes data as JSON with status whatever the
+// request's context says. Handlers use it once a write has committed: a
+// 504 for a create that happened makes the client retry it.
+func encodeCommittedResponse(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if data != nil {
@@ -284,28 +496,35 @@ func encodeJSONResponse(w http.ResponseWriter, status int, data any) {
 }
 
 func encodeErrorResponse(w http.ResponseWriter, err error) {
-	status := HTTPStatusCode(err)
-	message := ErrorMessage(err)
-	code := GetErrorCode(err)
+	if apperrors.IsCanceled(err) {
+		w.WriteHeader(apperrors.StatusClientClosedRequest) // the client is gone
+		return
+	}
 
+	status, resp := newErrorResponse(err)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{
-		Error: message,
-		Code:  code,
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
-func getIntQuery(r *http.Request, key string, defaultVal int) int {
-	val := r.URL.Query().Get(key)
-	if val == "" {
-		return defaultVal
+// newErrorResponse returns the status and body encodeErrorResponse
+// writes for err. ErrVersionConflict from a service becomes 412.
+func newErrorResponse(err error) (int, ErrorResponse) {
+	if errors.Is(err, ErrVersionConflict) {
+		err = NewVersionConflictError()
 	}
-	i, err := strconv.Atoi(val)
-	if err != nil {
-		return defaultVal
+	status := HTTPStatusCode(err)
+	resp := ErrorResponse{
+		Error: ErrorMessage(err),
+		Code:  GetErrorCode(err),
 	}
-	return i
+	var verrs apperrors.ValidationErrors
+	if errors.As(err, &verrs) {
+		resp.Details = verrs.ToDetails()
+	} else if details := apperrors.Details(err); len(details) > 0 && status != http.StatusInternalServerError {
+		resp.Details = details
+	}
+	return status, resp
 }
 
 func deref(s *string) string {
@@ -315,7 +534,15 @@ func deref(s *string) string {
 	return *s
 }
 
-// HandlerError represents HTTP layer errors.
+// HandlerError is an HTTP error with an explicit status, for statuses
+// that only make sense over HTTP and have no ErrorCode: 405, 406, 412,
+// 413, 415, 428, 501. Handlers return one through the New*Error
+// constructors; services return an *apperrors.Error or a sentinel such
+// as ErrVersionConflict, which encodeErrorResponse maps to its
+// HandlerError.
+//
+// It is only consulted for errors the errors package maps to
+// CodeInternal, so a coded error wrapping a HandlerError keeps its code.
 type HandlerError struct {
 	Status  int
 	Code    string
@@ -326,46 +553,64 @@ func (e *HandlerError) Error() string {
 	return e.Message
 }
 
-// NewBadRequestError creates a 400 Bad Request error.
-func NewBadRequestError(msg string) error {
+// NewBadRequestError creates a CodeInvalid (400) error.
+func NewBadRequestError(msg string) *apperrors.Error {
+	return apperrors.New(apperrors.CodeInvalid, msg)
+}
+
+// NewNotFoundError creates a CodeNotFound (404) error.
+func NewNotFoundError(msg string) *apperrors.Error {
+	return apperrors.New(apperrors.CodeNotFound, msg)
+}
+
+// NewRequestTooLargeError creates a 413 Request Entity Too Large error.
+func NewRequestTooLargeError(limit int64) error {
 	return &HandlerError{
-		Status:  http.StatusBadRequest,
-		Code:    "bad_request",
-		Message: msg,
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "request_too_large",
+		Message: fmt.Sprintf("request body exceeds %d bytes", limit),
+	}
+}
+
+// NewUnsupportedMediaTypeError creates a 415 Unsupported Media Type
+// error naming the Content-Type the endpoint accepts.
+func NewUnsupportedMediaTypeError(want string) error {
+	return &HandlerError{
+		Status:  http.StatusUnsupportedMediaType,
+		Code:    "unsupported_media_type",
+		Message: "Content-Type must be " + want,
 	}
 }
 
-// NewNotFoundError creates a 404 Not Found error.
-func NewNotFoundError(msg string) error {
+// NewNotImplementedError creates a 501 Not Implemented error for an
+// endpoint whose backing service isn't configured.
+func NewNotImplementedError(msg string) error {
 	return &HandlerError{
-		Status:  http.StatusNotFound,
-		Code:    "not_found",
+		Status:  http.StatusNotImplemented,
+		Code:    "not_implemented",
 		Message: msg,
 	}
 }
 
-// NewValidationError creates a validation error from validator package errors.
+// NewValidationError converts validator errors into
+// apperrors.ValidationErrors, reporting every failed field.
 func NewValidationError(err error) error {
 	var validationErrors validator.ValidationErrors
 	if errors.As(err, &validationErrors) {
-		return &HandlerError{
-			Status:  http.StatusBadRequest,
-			Code:    "validation_error",
-			Message: formatValidationErrors(validationErrors),
-		}
-	}
-	return &HandlerError{
-		Status:  http.StatusBadRequest,
-		Code:    "validation_error",
-		Message: "validation failed",
+		return formatValidationErrors(validationErrors)
 	}
+	return apperrors.New(apperrors.CodeInvalid, "validation failed")
 }
 
-func formatValidationErrors(errs validator.ValidationErrors) string {
-	if len(errs) == 0 {
-		return "validation failed"
+func formatValidationErrors(errs validator.ValidationErrors) apperrors.ValidationErrors {
+	verrs := make(apperrors.ValidationErrors, 0, len(errs))
+	for _, e := range errs {
+		verrs.Add(e.Field(), e.Tag(), formatFieldError(e))
 	}
-	e := errs[0]
+	return verrs
+}
+
+func formatFieldError(e validator.FieldError) string {
 	switch e.Tag() {
 	case "required":
 		return e.Field() + " is required"
@@ -380,29 +625,42 @@ func formatValidationErrors(errs validator.ValidationErrors) string {
 	}
 }
 
-// HTTPStatusCode extracts HTTP status from error.
+// HTTPStatusCode returns apperrors.HTTPStatusCode(err), or the status
+// of a HandlerError where that is 500.
 func HTTPStatusCode(err error) int {
-	var he *HandlerError
-	if errors.As(err, &he) {
+	if he, ok := handlerError(err); ok {
 		return he.Status
 	}
-	return http.StatusInternalServerError
+	return apperrors.HTTPStatusCode(err)
 }
 
-// ErrorMessage returns client-safe error message.
+// ErrorMessage returns apperrors.ErrorMessage(err), or the message of a
+// HandlerError where that is generic.
 func ErrorMessage(err error) string {
-	var he *HandlerError
-	if errors.As(err, &he) {
+	if he, ok := handlerError(err); ok {
 		return he.Message
 	}
-	return "internal error"
+	return apperrors.ErrorMessage(err)
 }
 
-// GetErrorCode returns error code string.
+// GetErrorCode returns apperrors.GetErrorCode(err), or the code of a
+// HandlerError where that is CodeInternal.
 func GetErrorCode(err error) string {
+	if he, ok := handlerError(err); ok {
+		return he.Code
+	}
+	return string(apperrors.GetErrorCode(err))
+}
+
+// handlerError returns the HandlerError in err if the errors package
+// can't classify err itself.
+func handlerError(err error) (*HandlerError, bool) {
+	if !apperrors.IsInternal(err) {
+		return nil, false
+	}
 	var he *HandlerError
 	if errors.As(err, &he) {
-		return he.Code
+		return he, true
 	}
-	return "internal"
+	return nil, false
 }
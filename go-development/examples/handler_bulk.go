@@ -0,0 +1,261 @@
+// Package handler provides bulk endpoints that report an outcome per item
+// instead of failing the whole batch on the first bad one.
+package handler
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DefaultMaxBulkItems caps the items of one bulk request.
+const DefaultMaxBulkItems = 100
+
+// WithMaxBulkItems replaces DefaultMaxBulkItems.
+func WithMaxBulkItems(n int) HandlerOption {
+	return func(h *UserHandler) {
+		h.maxBulk = n
+	}
+}
+
+// ---------- DTOs ----------
+
+// BulkCreateUsersRequest represents the request body for creating users
+// in bulk. With Atomic, every user is created or none is.
+type BulkCreateUsersRequest struct {
+	Items  []CreateUserRequest `json:"items"`
+	Atomic bool                `json:"atomic,omitempty"`
+}
+
+// BulkDeleteUsersRequest represents the request body for deleting users
+// in bulk. With Atomic, every user is deleted or none is.
+type BulkDeleteUsersRequest struct {
+	IDs    []string `json:"ids"`
+	Atomic bool     `json:"atomic,omitempty"`
+}
+
+// BulkItemResult is the outcome of one item. Status is the HTTP status
+// the item would get on its own; failed items carry the code, message
+// and details of an ErrorResponse.
+type BulkItemResult struct {
+	Index   int    `json:"index"`
+	Status  int    `json:"status"`
+	ID      string `json:"id,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// BulkResponse represents the response body of a bulk request. It is
+// sent with 200 whatever the items' outcomes, like 207 Multi-Status.
+type BulkResponse struct {
+	Items     []BulkItemResult `json:"items"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+// ---------- Handlers ----------
+
+// BulkCreate handles POST /users/bulk:
+//
+//	{"items": [{"name": "Ann", "email": "ann@example.com"}, {"name": ""}]}
+//
+//	200 {"items": [{"index": 0, "status": 201, "id": "u1"},
+//	               {"index": 1, "status": 400, "code": "invalid", ...}],
+//	     "succeeded": 1, "failed": 1}
+//
+// Each item is validated and created on its own. With "atomic": true,
+// nothing is created if any item is invalid, and valid items report 424;
+// a failing CreateBatch fails the whole request. If the request's context
+// ends partway, items not yet attempted report 504 (or 499) and the
+// response still carries the IDs of those created.
+func (h *UserHandler) BulkCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := DecodeJSON[BulkCreateUsersRequest](r, nil, h.decode...)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	if err := h.checkBatchSize("items", len(req.Items)); err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	results := make([]BulkItemResult, len(req.Items))
+	invalid := false
+	for i := range req.Items {
+		results[i] = BulkItemResult{Index: i}
+		if err := h.validate.StructCtx(ctx, &req.Items[i]); err != nil {
+			results[i] = bulkItemError(i, NewValidationError(err))
+			invalid = true
+		}
+	}
+
+	if req.Atomic {
+		if invalid {
+			encodeJSONResponse(w, r, http.StatusOK, newBulkResponse(notAttempted(results)))
+			return
+		}
+		users := make([]NewUser, len(req.Items))
+		for i, item := range req.Items {
+			users[i] = NewUser{Name: item.Name, Email: item.Email}
+		}
+		created, err := h.userService.CreateBatch(ctx, users)
+		if err != nil {
+			encodeErrorResponse(w, err)
+			return
+		}
+		for i, u := range created {
+			results[i] = BulkItemResult{Index: i, Status: http.StatusCreated, ID: u.ID}
+		}
+		encodeCommittedResponse(w, http.StatusOK, newBulkResponse(results))
+		return
+	}
+
+	for i, item := range req.Items {
+		if results[i].Status != 0 {
+			continue // invalid
+		}
+		if err := ctx.Err(); err != nil {
+			results[i] = bulkItemError(i, err) // out of time: report what was created
+			continue
+		}
+		u, err := h.userService.Create(ctx, item.Name, item.Email)
+		if err != nil {
+			results[i] = bulkItemError(i, err)
+			continue
+		}
+		results[i] = BulkItemResult{Index: i, Status: http.StatusCreated, ID: u.ID}
+	}
+	encodeCommittedResponse(w, http.StatusOK, newBulkResponse(results))
+}
+
+// BulkDelete handles POST /users/bulk/delete:
+//
+//	{"ids": ["u1", "u2"]}
+//
+//	200 {"items": [{"index": 0, "status": 204, "id": "u1"},
+//	               {"index": 1, "status": 404, "id": "u2", "code": "not_found", ...}],
+//	     "succeeded": 1, "failed": 1}
+//
+// With "atomic": true, a failing DeleteBatch fails the whole request.
+// As with BulkCreate, a context that ends partway leaves the remaining
+// items reported as 504 (or 499) rather than failing the request.
+func (h *UserHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := DecodeJSON[BulkDeleteUsersRequest](r, nil, h.decode...)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	if err := h.checkBatchSize("ids", len(req.IDs)); err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	results := make([]BulkItemResult, len(req.IDs))
+	invalid := false
+	for i, id := range req.IDs {
+		results[i] = BulkItemResult{Index: i, ID: id}
+		if id == "" {
+			results[i] = bulkItemError(i, NewBadRequestError("user ID is required"))
+			invalid = true
+		}
+	}
+
+	if req.Atomic {
+		if invalid {
+			encodeJSONResponse(w, r, http.StatusOK, newBulkResponse(notAttempted(results)))
+			return
+		}
+		if err := h.userService.DeleteBatch(ctx, req.IDs); err != nil {
+			encodeErrorResponse(w, err)
+			return
+		}
+		for i, id := range req.IDs {
+			results[i] = BulkItemResult{Index: i, Status: http.StatusNoContent, ID: id}
+		}
+		encodeCommittedResponse(w, http.StatusOK, newBulkResponse(results))
+		return
+	}
+
+	for i, id := range req.IDs {
+		if results[i].Status != 0 {
+			continue // invalid
+		}
+		if err := ctx.Err(); err != nil {
+			results[i] = bulkItemError(i, err) // out of time: report what was deleted
+			results[i].ID = id
+			continue
+		}
+		if err := h.userService.Delete(ctx, id, AnyVersion); err != nil {
+			results[i] = bulkItemError(i, err)
+			results[i].ID = id
+			continue
+		}
+		results[i] = BulkItemResult{Index: i, Status: http.StatusNoContent, ID: id}
+	}
+	encodeCommittedResponse(w, http.StatusOK, newBulkResponse(results))
+}
+
+// ---------- Helpers ----------
+
+// checkBatchSize rejects empty batches with 400 and batches over
+// h.maxBulk with 413.
+func (h *UserHandler) checkBatchSize(field string, n int) error {
+	switch {
+	case n == 0:
+		return NewBadRequestError(field + " is required")
+	case n > h.maxBulk:
+		return NewBatchTooLargeError(h.maxBulk)
+	}
+	return nil
+}
+
+// NewBatchTooLargeError creates a 413 error for a bulk request over max
+// items.
+func NewBatchTooLargeError(max int) error {
+	return &HandlerError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "batch_too_large",
+		Message: fmt.Sprintf("batch exceeds %d items", max),
+	}
+}
+
+// bulkItemError reports err for item i as encodeErrorResponse would.
+func bulkItemError(i int, err error) BulkItemResult {
+	status, resp := newErrorResponse(err)
+	return BulkItemResult{
+		Index:   i,
+		Status:  status,
+		Code:    resp.Code,
+		Error:   resp.Error,
+		Details: resp.Details,
+	}
+}
+
+// notAttempted marks the valid items of an atomic batch that failed
+// validation: none was attempted because of the others.
+func notAttempted(results []BulkItemResult) []BulkItemResult {
+	for i := range results {
+		if results[i].Status == 0 {
+			results[i].Status = http.StatusFailedDependency
+			results[i].Code = "not_attempted"
+			results[i].Error = "not attempted: another item is invalid"
+		}
+	}
+	return results
+}
+
+func newBulkResponse(results []BulkItemResult) BulkResponse {
+	resp := BulkResponse{Items: results}
+	for _, r := range results {
+		if r.Status < http.StatusBadRequest {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp
+}
@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- Test Helpers ----------
+
+// bulkUserService keeps users in memory. Emails are unique; Create
+// fails with an internal error for "boom@example.com".
+type bulkUserService struct {
+	UserService
+
+	mu      sync.Mutex
+	users   map[string]*User
+	nextID  int
+	batches int // CreateBatch and DeleteBatch calls
+
+	afterCreate func() // called after each successful Create, if set
+}
+
+func newBulkUserService(emails ...string) *bulkUserService {
+	s := &bulkUserService{users: map[string]*User{}}
+	for _, email := range emails {
+		s.create(email)
+	}
+	return s
+}
+
+func (s *bulkUserService) create(email string) (*User, error) {
+	if email == "boom@example.com" {
+		return nil, errors.New("pq: connection reset")
+	}
+	for _, u := range s.users {
+		if u.Email == email {
+			return nil, apperrors.New(apperrors.CodeConflict, "user with this email already exists")
+		}
+	}
+	s.nextID++
+	u := &User{ID: fmt.Sprintf("u%d", s.nextID), Email: email, CreatedAt: time.Unix(0, 0)}
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *bulkUserService) Create(_ context.Context, _, email string) (*User, error) {
+	s.mu.Lock()
+	u, err := s.create(email)
+	s.mu.Unlock()
+	if err == nil && s.afterCreate != nil {
+		s.afterCreate()
+	}
+	return u, err
+}
+
+func (s *bulkUserService) Delete(_ context.Context, id string, _ int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return apperrors.New(apperrors.CodeNotFound, "user not found")
+	}
+	delete(s.users, id)
+	return nil
+}
+
+// CreateBatch rolls back by restoring a snapshot.
+func (s *bulkUserService) CreateBatch(_ context.Context, users []NewUser) ([]*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches++
+
+	snapshot, nextID := make(map[string]*User, len(s.users)), s.nextID
+	for id, u := range s.users {
+		snapshot[id] = u
+	}
+	created := make([]*User, 0, len(users))
+	for _, nu := range users {
+		u, err := s.create(nu.Email)
+		if err != nil {
+			s.users, s.nextID = snapshot, nextID
+			return nil, err
+		}
+		created = append(created, u)
+	}
+	return created, nil
+}
+
+func (s *bulkUserService) DeleteBatch(_ context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches++
+
+	for _, id := range ids {
+		if _, ok := s.users[id]; !ok {
+			return apperrors.New(apperrors.CodeNotFound, "user "+id+" not found")
+		}
+	}
+	for _, id := range ids {
+		delete(s.users, id)
+	}
+	return nil
+}
+
+func (s *bulkUserService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.users)
+}
+
+func postBulk(t *testing.T, h *UserHandler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, PathPrefix+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	NewRouter(h).ServeHTTP(rec, req)
+	return rec
+}
+
+// ---------- Bulk Create Tests ----------
+
+func TestUserHandler_BulkCreate(t *testing.T) {
+	t.Parallel()
+
+	svc := newBulkUserService("taken@example.com")
+	rec := postBulk(t, NewUserHandler(svc), UsersBulkPath, `{"items":[
+		{"name":"Ann","email":"ann@example.com"},
+		{"name":"","email":"not-an-email"},
+		{"name":"Bob","email":"taken@example.com"},
+		{"name":"Eve","email":"boom@example.com"},
+		{"name":"Joe","email":"joe@example.com"}
+	]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"items":[
+		{"index":0,"status":201,"id":"u2"},
+		{"index":1,"status":400,"code":"invalid","error":"validation failed",
+		 "details":{"name":"name is required","email":"invalid email format"}},
+		{"index":2,"status":409,"code":"conflict","error":"user with this email already exists"},
+		{"index":3,"status":500,"code":"internal","error":"an internal error has occurred"},
+		{"index":4,"status":201,"id":"u3"}
+	],"succeeded":2,"failed":3}`, rec.Body.String())
+	assert.Equal(t, 3, svc.count())
+}
+
+func TestUserHandler_BulkCreate_DeadlinePartway(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	svc := newBulkUserService()
+	svc.afterCreate = func() { <-ctx.Done() } // the first create uses up the time
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, PathPrefix+UsersBulkPath, strings.NewReader(`{"items":[
+		{"name":"Ann","email":"ann@example.com"},
+		{"name":"Joe","email":"joe@example.com"}
+	]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	NewRouter(NewUserHandler(svc)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, "not a 504 that hides the created user")
+	assert.JSONEq(t, `{"items":[
+		{"index":0,"status":201,"id":"u1"},
+		{"index":1,"status":504,"code":"timeout","error":"request timed out"}
+	],"succeeded":1,"failed":1}`, rec.Body.String())
+	assert.Equal(t, 1, svc.count())
+}
+
+func TestUserHandler_BulkCreate_Atomic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantUsers  int
+		wantBatch  int
+	}{
+		{name: "all valid", body: `{"atomic":true,"items":[
+				{"name":"Ann","email":"ann@example.com"},{"name":"Joe","email":"joe@example.com"}]}`,
+			wantStatus: http.StatusOK, wantUsers: 3, wantBatch: 1,
+			wantBody: `{"items":[{"index":0,"status":201,"id":"u2"},{"index":1,"status":201,"id":"u3"}],
+				"succeeded":2,"failed":0}`},
+		{name: "invalid item stops the batch", body: `{"atomic":true,"items":[
+				{"name":"Ann","email":"ann@example.com"},{"name":"Joe","email":"joe"}]}`,
+			wantStatus: http.StatusOK, wantUsers: 1, wantBatch: 0,
+			wantBody: `{"items":[
+				{"index":0,"status":424,"code":"not_attempted","error":"not attempted: another item is invalid"},
+				{"index":1,"status":400,"code":"invalid","error":"validation failed","details":{"email":"invalid email format"}}
+			],"succeeded":0,"failed":2}`},
+		{name: "service failure rolls back", body: `{"atomic":true,"items":[
+				{"name":"Ann","email":"ann@example.com"},{"name":"Bob","email":"taken@example.com"}]}`,
+			wantStatus: http.StatusConflict, wantUsers: 1, wantBatch: 1,
+			wantBody: `{"error":"user with this email already exists","code":"conflict"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newBulkUserService("taken@example.com")
+			rec := postBulk(t, NewUserHandler(svc), UsersBulkPath, tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, tt.wantUsers, svc.count())
+			assert.Equal(t, tt.wantBatch, svc.batches)
+		})
+	}
+}
+
+func TestUserHandler_BulkRequestErrors(t *testing.T) {
+	t.Parallel()
+
+	threeItems := `{"items":[` + strings.Repeat(`{"name":"Ann","email":"a@example.com"},`, 2) +
+		`{"name":"Ann","email":"a@example.com"}]}`
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "create over max", path: UsersBulkPath, body: threeItems,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":"batch exceeds 2 items","code":"batch_too_large"}`},
+		{name: "delete over max", path: UsersBulkDeletePath, body: `{"ids":["u1","u2","u3"]}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":"batch exceeds 2 items","code":"batch_too_large"}`},
+		{name: "create empty", path: UsersBulkPath, body: `{"items":[]}`,
+			wantStatus: http.StatusBadRequest, wantBody: `{"error":"items is required","code":"invalid"}`},
+		{name: "delete missing", path: UsersBulkDeletePath, body: `{}`,
+			wantStatus: http.StatusBadRequest, wantBody: `{"error":"ids is required","code":"invalid"}`},
+		{name: "malformed", path: UsersBulkPath, body: `{"items":{}}`,
+			wantStatus: http.StatusBadRequest, wantBody: `{"error":"items has the wrong type","code":"invalid"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newBulkUserService()
+			rec := postBulk(t, NewUserHandler(svc, WithMaxBulkItems(2)), tt.path, tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			assert.Zero(t, svc.count(), "nothing created")
+		})
+	}
+}
+
+// ---------- Bulk Delete Tests ----------
+
+func TestUserHandler_BulkDelete(t *testing.T) {
+	t.Parallel()
+
+	svc := newBulkUserService("ann@example.com", "joe@example.com")
+	rec := postBulk(t, NewUserHandler(svc), UsersBulkDeletePath, `{"ids":["u1","missing","","u2"]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"items":[
+		{"index":0,"status":204,"id":"u1"},
+		{"index":1,"status":404,"id":"missing","code":"not_found","error":"user not found"},
+		{"index":2,"status":400,"code":"invalid","error":"user ID is required"},
+		{"index":3,"status":204,"id":"u2"}
+	],"succeeded":2,"failed":2}`, rec.Body.String())
+	assert.Zero(t, svc.count())
+}
+
+func TestUserHandler_BulkDelete_Atomic(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all found", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newBulkUserService("ann@example.com", "joe@example.com")
+		rec := postBulk(t, NewUserHandler(svc), UsersBulkDeletePath, `{"atomic":true,"ids":["u1","u2"]}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"items":[{"index":0,"status":204,"id":"u1"},{"index":1,"status":204,"id":"u2"}],
+			"succeeded":2,"failed":0}`, rec.Body.String())
+		assert.Zero(t, svc.count())
+	})
+
+	t.Run("missing user deletes nothing", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newBulkUserService("ann@example.com")
+		rec := postBulk(t, NewUserHandler(svc), UsersBulkDeletePath, `{"atomic":true,"ids":["u1","u9"]}`)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.JSONEq(t, `{"error":"user u9 not found","code":"not_found"}`, rec.Body.String())
+		assert.Equal(t, 1, svc.count())
+	})
+
+	t.Run("invalid ID stops the batch", func(t *testing.T) {
+		t.Parallel()
+
+		svc := newBulkUserService("ann@example.com")
+		rec := postBulk(t, NewUserHandler(svc), UsersBulkDeletePath, `{"atomic":true,"ids":["u1",""]}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp BulkResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, http.StatusFailedDependency, resp.Items[0].Status)
+		assert.Equal(t, "u1", resp.Items[0].ID)
+		assert.Equal(t, http.StatusBadRequest, resp.Items[1].Status)
+		assert.Equal(t, 1, svc.count())
+		assert.Zero(t, svc.batches)
+	})
+}
@@ -0,0 +1,49 @@
+// Package handler provides the request's time budget and the checks that
+// stop handlers answering clients that are gone.
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestDeadline returns when r's context expires, as set by
+// middleware.TimeoutJSON or the server, and whether it has a deadline.
+// Handlers use it to size work to what is left after middleware:
+//
+//	if deadline, ok := RequestDeadline(r); ok && time.Until(deadline) < minExportBudget {
+//	    encodeErrorResponse(w, apperrors.New(apperrors.CodeUnavailable, "not enough time left"))
+//	    return
+//	}
+//
+// Calls made with r.Context() are canceled at the deadline regardless.
+func RequestDeadline(r *http.Request) (time.Time, bool) {
+	return r.Context().Deadline()
+}
+
+// requestDone reports whether r's context is done and, if it is, answers
+// as encodeErrorResponse does for the context's error: a bare 499 if the
+// client went away (recorded by the logger and metrics, never seen by the
+// client), 504 if the deadline passed. Encoders of reads call it before
+// writing, so a handler that finished after a disconnect doesn't report
+// a failed write. Never call it once a write has committed: the client
+// would retry a create that happened, or get 404 for a delete that did.
+// Those responses go through encodeCommittedResponse.
+func requestDone(w http.ResponseWriter, r *http.Request) bool {
+	err := r.Context().Err()
+	if err == nil {
+		return false
+	}
+	encodeErrorResponse(w, err)
+	return true
+}
+
+// readError returns r's context error in place of err if the context is
+// done: a body cut short by a disconnect is the client leaving, a 499,
+// not a malformed body.
+func readError(r *http.Request, err error) error {
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- Test Helpers ----------
+
+// lateUserService finishes its work after done is called, as a query
+// that ignores cancellation does.
+type lateUserService struct {
+	UserService
+	done func()
+}
+
+func (s lateUserService) Create(_ context.Context, name, email string) (*User, error) {
+	s.done()
+	return &User{ID: "u1", Name: name, Email: email, CreatedAt: time.Unix(0, 0)}, nil
+}
+
+func (s lateUserService) GetByID(_ context.Context, id string) (*User, error) {
+	s.done()
+	return &User{ID: id, Name: "Ann", CreatedAt: time.Unix(0, 0)}, nil
+}
+
+func (s lateUserService) List(context.Context, UserFilter) ([]*User, int64, error) {
+	s.done()
+	return []*User{{ID: "u1", Name: "Ann", CreatedAt: time.Unix(0, 0)}}, 1, nil
+}
+
+func (s lateUserService) Delete(context.Context, string, int64) error {
+	s.done()
+	return nil
+}
+
+// cancelingBody cancels the request mid-read, as a client disconnecting
+// halfway through its body does.
+type cancelingBody struct {
+	cancel context.CancelFunc
+	sent   bool
+}
+
+func (b *cancelingBody) Read(p []byte) (int, error) {
+	if b.sent {
+		b.cancel()
+		return 0, io.ErrUnexpectedEOF
+	}
+	b.sent = true
+	return copy(p, `{"name":"An`), nil
+}
+
+// ---------- RequestDeadline Tests ----------
+
+func TestRequestDeadline(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, ok := RequestDeadline(req)
+	assert.False(t, ok)
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(req.Context(), deadline)
+	defer cancel()
+
+	got, ok := RequestDeadline(req.WithContext(ctx))
+	assert.True(t, ok)
+	assert.Equal(t, deadline, got)
+}
+
+// ---------- Client Disconnect Tests ----------
+
+func TestUserHandler_ClientGone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "get", method: http.MethodGet, target: UserByIDURL("u1"), wantStatus: apperrors.StatusClientClosedRequest},
+		{name: "list", method: http.MethodGet, target: UsersURL(nil), wantStatus: apperrors.StatusClientClosedRequest},
+		// The write happened: report it, in case anyone is still reading
+		{name: "create", method: http.MethodPost, target: UsersURL(nil), body: `{"name":"Ann","email":"ann@example.com"}`, wantStatus: http.StatusCreated},
+		{name: "delete", method: http.MethodDelete, target: UserByIDURL("u1"), wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			router := NewRouter(NewUserHandler(lateUserService{done: cancel}))
+
+			req := httptest.NewRequestWithContext(ctx, tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == apperrors.StatusClientClosedRequest {
+				assert.Empty(t, rec.Body.String(), "nobody to read it")
+			}
+		})
+	}
+}
+
+func TestUserHandler_DeadlinePassedAfterWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "create", method: http.MethodPost, target: UsersURL(nil), body: `{"name":"Ann","email":"ann@example.com"}`, wantStatus: http.StatusCreated},
+		{name: "delete", method: http.MethodDelete, target: UserByIDURL("u1"), wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The deadline passes while the service commits the write
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			router := NewRouter(NewUserHandler(lateUserService{done: func() { <-ctx.Done() }}))
+
+			req := httptest.NewRequestWithContext(ctx, tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, "not a 504 the client would retry")
+		})
+	}
+}
+
+func TestUserHandler_DeadlinePassed(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, UsersURL(nil), nil)
+	rec := httptest.NewRecorder()
+	NewUserHandler(lateUserService{done: func() {}}).List(rec, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	var resp ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.NotEmpty(t, resp.Error)
+}
+
+func TestDecodeJSON_ClientGoneMidBody(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, UsersURL(nil), &cancelingBody{cancel: cancel})
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := DecodeJSON[CreateUserRequest](req, nil)
+	require.ErrorIs(t, err, context.Canceled, "not a 400 for a truncated body")
+
+	rec := httptest.NewRecorder()
+	NewUserHandler(stubUserService{}).Create(rec, req)
+	assert.Equal(t, apperrors.StatusClientClosedRequest, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestDecodeJSON_TruncatedBody(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodPost, UsersURL(nil), strings.NewReader(`{"name":"An`))
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := DecodeJSON[CreateUserRequest](req, nil)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, context.Canceled), "still a 400 while the client waits")
+}
+
+func TestEncodeErrorResponse_CanceledRecorded(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	ww := middleware.NewWrapResponseWriter(rec, 1)
+	encodeErrorResponse(ww, context.Canceled)
+
+	assert.Equal(t, apperrors.StatusClientClosedRequest, ww.Status(), "what Logger and Metrics see")
+	assert.Zero(t, ww.BytesWritten())
+}
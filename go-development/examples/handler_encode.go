@@ -0,0 +1,401 @@
+// Package handler provides response content negotiation: JSON, CSV and
+// NDJSON from one handler.
+package handler
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Format is a response encoding, as named by the format query parameter.
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// formats maps each Format to the media type it is sent as and those
+// that request it in Accept, in order of preference for ties.
+var formats = []struct {
+	format      Format
+	contentType string
+	accepts     []string
+}{
+	{FormatJSON, "application/json", []string{"application/json"}},
+	{FormatCSV, "text/csv; charset=utf-8; header=present", []string{"text/csv"}},
+	{FormatNDJSON, "application/x-ndjson", []string{"application/x-ndjson", "application/ndjson"}},
+}
+
+// flushEvery is how many CSV or NDJSON rows are written between flushes,
+// so large exports reach the client as they are encoded.
+const flushEvery = 100
+
+// WithExportFormats makes List negotiate CSV and NDJSON as well as JSON;
+// see encodeResponse.
+func WithExportFormats() HandlerOption {
+	return func(h *UserHandler) {
+		h.exportFormats = true
+	}
+}
+
+// CSVMarshaler is implemented by row types that map their own CSV
+// columns instead of using csv tags.
+type CSVMarshaler interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+// lister is implemented by ListResponse: CSV and NDJSON encode its
+// items and send the total as X-Total-Count.
+type lister interface {
+	items() any
+	total() int64
+}
+
+func (l ListResponse[T]) items() any   { return l.Items }
+func (l ListResponse[T]) total() int64 { return l.TotalCount }
+
+// encodeResponse writes data in the format r asks for:
+//
+//	?format=csv or Accept: text/csv                CSV, one row per item
+//	?format=ndjson or Accept: application/x-ndjson one JSON object per line
+//	anything else                                  JSON, as encodeMaskedResponse
+//
+// CSV and NDJSON need a slice, or a ListResponse, whose items they encode
+// row by row without buffering the whole response. CSV columns are the
+// fields with a csv tag, in order, unless the row type is a
+// CSVMarshaler:
+//
+//	type UserResponse struct {
+//	    ID    string `json:"id" csv:"id"`
+//	    Notes string `json:"notes" csv:"-"` // not exported
+//	}
+//
+// ?fields= masks JSON and NDJSON rows (see FieldMask.Project); with CSV,
+// whose columns come from csv tags, it gives 400. An unknown format
+// gives 400 and an Accept none can satisfy gives 406.
+func encodeResponse(w http.ResponseWriter, r *http.Request, status int, data any) {
+	w.Header().Add("Vary", "Accept")
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	if format == FormatJSON {
+		encodeMaskedResponse(w, r, status, data)
+		return
+	}
+	mask, err := ParseFields(r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	if mask != nil && format == FormatCSV {
+		encodeErrorResponse(w, queryError("fields", "excluded_with", "fields can't be combined with CSV"))
+		return
+	}
+
+	if l, ok := data.(lister); ok {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(l.total(), 10))
+		data = l.items()
+	}
+	rows := reflect.ValueOf(data)
+	if rows.Kind() != reflect.Slice {
+		encodeErrorResponse(w, NewNotAcceptableError(FormatJSON)) // only lists export
+		return
+	}
+	if mask != nil {
+		if err := mask.check(rows.Type().Elem(), ""); err != nil {
+			encodeErrorResponse(w, err)
+			return
+		}
+		projected, err := mask.projectRows(rows)
+		if err != nil {
+			encodeErrorResponse(w, err)
+			return
+		}
+		rows = reflect.ValueOf(projected)
+	}
+
+	if requestDone(w, r) {
+		return
+	}
+	switch format {
+	case FormatCSV:
+		columns, err := csvColumnsOf(rows.Type().Elem())
+		if err != nil {
+			encodeErrorResponse(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeOf(format))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment",
+			map[string]string{"filename": exportName(r) + ".csv"}))
+		w.WriteHeader(status)
+		writeCSV(w, rows, columns)
+	case FormatNDJSON:
+		w.Header().Set("Content-Type", contentTypeOf(format))
+		w.WriteHeader(status)
+		writeNDJSON(w, rows)
+	}
+}
+
+// negotiateFormat returns the format query parameter if set, or the
+// Accept media type with the highest q; ties go to JSON, then CSV.
+func negotiateFormat(r *http.Request) (Format, error) {
+	format, err := QueryEnum(r, "format", FormatJSON, FormatCSV, FormatNDJSON)
+	if err != nil || format != "" {
+		return format, err
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, nil
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQ := Format(""), 0.0
+	for _, f := range formats {
+		for _, mt := range f.accepts {
+			if q := rangeQuality(ranges, mt); q > bestQ {
+				best, bestQ = f.format, q
+			}
+		}
+	}
+	if best == "" {
+		return "", NewNotAcceptableError(FormatJSON, FormatCSV, FormatNDJSON)
+	}
+	return best, nil
+}
+
+// mediaRange is one element of an Accept header.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue // RFC 9110 lets us ignore what we can't parse
+		}
+		typ, subtype, _ := strings.Cut(mt, "/")
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// rangeQuality returns the q of the most specific range matching
+// mediaType: text/csv beats text/* beats */*.
+func rangeQuality(ranges []mediaRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, mr := range ranges {
+		s := -1
+		switch {
+		case mr.typ == typ && mr.subtype == subtype:
+			s = 2
+		case mr.typ == typ && mr.subtype == "*":
+			s = 1
+		case mr.typ == "*" && mr.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+	return q
+}
+
+func contentTypeOf(format Format) string {
+	for _, f := range formats {
+		if f.format == format {
+			return f.contentType
+		}
+	}
+	return "application/json"
+}
+
+// exportName names a download after the last path segment, e.g. users
+// for /api/v1/users.
+func exportName(r *http.Request) string {
+	name := path.Base(r.URL.Path)
+	if name == "/" || name == "." {
+		return "export"
+	}
+	return name
+}
+
+// NewNotAcceptableError creates a 406 Not Acceptable error naming the
+// formats the endpoint offers.
+func NewNotAcceptableError(offered ...Format) error {
+	names := make([]string, len(offered))
+	for i, f := range offered {
+		names[i] = string(f)
+	}
+	return &HandlerError{
+		Status:  http.StatusNotAcceptable,
+		Code:    "not_acceptable",
+		Message: "cannot produce the requested format; available: " + strings.Join(names, ", "),
+	}
+}
+
+// ---------- CSV ----------
+
+// csvColumns is either a CSVMarshaler's header or the csv-tagged fields
+// of a struct.
+type csvColumns struct {
+	header    []string
+	fields    [][]int // field indexes; nil with marshaler
+	marshaler bool
+}
+
+var csvMarshalerType = reflect.TypeFor[CSVMarshaler]()
+
+// csvColumnsOf maps a row type to columns. A type with neither csv tags
+// nor CSVHeader is a programming error (500).
+func csvColumnsOf(elem reflect.Type) (csvColumns, error) {
+	if elem.Implements(csvMarshalerType) {
+		row := reflect.New(elem).Elem()
+		if elem.Kind() == reflect.Pointer {
+			row = reflect.New(elem.Elem())
+		}
+		return csvColumns{header: row.Interface().(CSVMarshaler).CSVHeader(), marshaler: true}, nil
+	}
+
+	t := elem
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var cols csvColumns
+	if t.Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(t) {
+			name, _, _ := strings.Cut(f.Tag.Get("csv"), ",")
+			if name == "" || name == "-" || !f.IsExported() {
+				continue
+			}
+			cols.header = append(cols.header, name)
+			cols.fields = append(cols.fields, f.Index)
+		}
+	}
+	if len(cols.header) == 0 {
+		return csvColumns{}, fmt.Errorf("encodeResponse: %s has no csv tags and isn't a CSVMarshaler", elem)
+	}
+	return cols, nil
+}
+
+// writeCSV writes rows with a header line. encoding/csv quotes cells with
+// separators, quotes or newlines; a write error means the client is gone.
+func writeCSV(w http.ResponseWriter, rows reflect.Value, cols csvColumns) {
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cols.header); err != nil {
+		return
+	}
+
+	record := make([]string, len(cols.header))
+	for i := range rows.Len() {
+		row := rows.Index(i)
+		switch {
+		case row.Kind() == reflect.Pointer && row.IsNil():
+			record = make([]string, len(cols.header))
+		case cols.marshaler:
+			record = row.Interface().(CSVMarshaler).CSVRecord()
+		default:
+			row = reflect.Indirect(row)
+			for j, index := range cols.fields {
+				record[j] = csvCell(row.FieldByIndex(index))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return
+		}
+		if (i+1)%flushEvery == 0 {
+			cw.Flush()
+			_ = rc.Flush() // best effort; buffered writers flush at the end
+		}
+	}
+	cw.Flush()
+}
+
+// csvCell formats one value: TextMarshalers (time.Time is RFC 3339) and
+// Stringers as such, nil as empty. Output is valid UTF-8, as the
+// Content-Type says, and text starting like a spreadsheet formula, from
+// a string or a marshaler alike, is prefixed with ' so Excel shows it
+// instead of evaluating it. Numbers are left alone, so -2 stays -2.
+func csvCell(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	var s string
+	text := true
+	switch x := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		b, err := x.MarshalText()
+		if err != nil {
+			return ""
+		}
+		s = string(b)
+	case fmt.Stringer:
+		s = x.String()
+	default:
+		text = false
+		switch v.Kind() {
+		case reflect.String:
+			s, text = v.String(), true
+		case reflect.Bool:
+			s = strconv.FormatBool(v.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s = strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = strconv.FormatUint(v.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			s = strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+		default:
+			s, text = fmt.Sprint(v.Interface()), true
+		}
+	}
+	if text && s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		s = "'" + s
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
+
+// ---------- NDJSON ----------
+
+// writeNDJSON writes one JSON value per line, encoding each row as it
+// goes; a write error means the client is gone.
+func writeNDJSON(w http.ResponseWriter, rows reflect.Value) {
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w) // Encode ends every value with "\n"
+	for i := range rows.Len() {
+		if err := enc.Encode(rows.Index(i).Interface()); err != nil {
+			return
+		}
+		if (i+1)%flushEvery == 0 {
+			_ = rc.Flush()
+		}
+	}
+}
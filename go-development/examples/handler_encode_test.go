@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+type exportRow struct {
+	ID    int64      `json:"id" csv:"id"`
+	Note  string     `json:"note" csv:"note"`
+	At    *time.Time `json:"at,omitempty" csv:"at"`
+	Score float64    `json:"score" csv:"score"`
+	Admin bool       `json:"-" csv:"-"`
+}
+
+// pointRow maps its own columns.
+type pointRow struct{ X, Y int }
+
+func (pointRow) CSVHeader() []string { return []string{"x", "y"} }
+func (p pointRow) CSVRecord() []string {
+	return []string{string(rune('0' + p.X)), string(rune('0' + p.Y))}
+}
+
+// exportUserService lists users whose names need CSV quoting.
+type exportUserService struct {
+	UserService
+}
+
+func (exportUserService) List(context.Context, UserFilter) ([]*User, int64, error) {
+	return []*User{
+		{ID: "u1", Name: "Smith, Ann", Email: "ann@example.com", Status: UserStatusActive, CreatedAt: time.Unix(0, 0).UTC()},
+		{ID: "u2", Name: "Bob \"The\nBuilder\"", Email: "bob@example.com", CreatedAt: time.Unix(60, 0).UTC()},
+	}, 42, nil
+}
+
+func encode(t *testing.T, target, accept string, data any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	encodeResponse(rec, req, http.StatusOK, data)
+	return rec
+}
+
+func readCSV(t *testing.T, body string) [][]string {
+	t.Helper()
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+// ---------- Negotiation Tests ----------
+
+func TestNegotiateFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		query      string
+		accept     string
+		want       Format
+		wantStatus int
+	}{
+		{name: "default", want: FormatJSON},
+		{name: "any", accept: "*/*", want: FormatJSON},
+		{name: "query", query: "?format=csv", accept: "application/json", want: FormatCSV},
+		{name: "query ndjson", query: "?format=ndjson", want: FormatNDJSON},
+		{name: "accept csv", accept: "text/csv", want: FormatCSV},
+		{name: "accept with params", accept: "text/csv; charset=utf-8", want: FormatCSV},
+		{name: "accept ndjson", accept: "application/x-ndjson", want: FormatNDJSON},
+		{name: "accept ndjson alias", accept: "application/ndjson", want: FormatNDJSON},
+		{name: "q wins", accept: "application/json;q=0.5, text/csv", want: FormatCSV},
+		{name: "tie goes to json", accept: "text/csv, application/json", want: FormatJSON},
+		{name: "specific beats wildcard", accept: "text/*;q=0.9, text/csv;q=0, */*;q=0.1", want: FormatJSON},
+		{name: "type wildcard", accept: "text/*", want: FormatCSV},
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: FormatJSON},
+		{name: "bad range ignored", accept: "garbage/, text/csv", want: FormatCSV},
+		{name: "unknown format", query: "?format=xml", wantStatus: http.StatusBadRequest},
+		{name: "nothing acceptable", accept: "text/html", wantStatus: http.StatusNotAcceptable},
+		{name: "all refused", accept: "*/*;q=0", wantStatus: http.StatusNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			got, err := negotiateFormat(req)
+			if tt.wantStatus != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantStatus, HTTPStatusCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// ---------- Encoding Tests ----------
+
+func TestEncodeResponse_CSV(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []*exportRow{
+		{ID: 1, Note: "plain", At: &at, Score: 1.5},
+		{ID: 2, Note: "comma, \"quote\"\nand newline", Score: -2},
+		{ID: 3, Note: "=HYPERLINK(\"http://evil\")"},
+		{ID: 4, Note: "bad \xff byte"},
+		nil,
+	}
+
+	rec := encode(t, "/api/v1/users?format=csv", "", rows)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8; header=present", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=users.csv`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+	assert.Equal(t, [][]string{
+		{"id", "note", "at", "score"},
+		{"1", "plain", "2026-01-02T03:04:05Z", "1.5"},
+		{"2", "comma, \"quote\"\nand newline", "", "-2"},
+		{"3", "'=HYPERLINK(\"http://evil\")", "", "0"},
+		{"4", "bad \uFFFD byte", "", "0"},
+		{"", "", "", ""},
+	}, readCSV(t, rec.Body.String()))
+	assert.Contains(t, rec.Body.String(), `"comma, ""quote""`+"\nand newline\"", "quoted, quotes doubled")
+}
+
+// formulaStringer and formulaText render as text that starts like a
+// spreadsheet formula.
+type formulaStringer string
+
+func (f formulaStringer) String() string { return string(f) }
+
+type formulaText string
+
+func (f formulaText) MarshalText() ([]byte, error) { return []byte(f), nil }
+
+func TestCSVCell_FormulaGuard(t *testing.T) {
+	t.Parallel()
+
+	negative := formulaText("-2+3")
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{name: "string", v: "=1+1", want: "'=1+1"},
+		{name: "Stringer", v: formulaStringer("@SUM(A1)"), want: "'@SUM(A1)"},
+		{name: "TextMarshaler", v: formulaText("+cmd"), want: "'+cmd"},
+		{name: "pointer to TextMarshaler", v: &negative, want: "'-2+3"},
+		{name: "negative int", v: -2, want: "-2"},
+		{name: "negative float", v: -1.5, want: "-1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, csvCell(reflect.ValueOf(tt.v)))
+		})
+	}
+}
+
+func TestEncodeResponse_CSVMarshaler(t *testing.T) {
+	t.Parallel()
+
+	rec := encode(t, "/points", "text/csv", []pointRow{{1, 2}, {3, 4}})
+	assert.Equal(t, [][]string{{"x", "y"}, {"1", "2"}, {"3", "4"}}, readCSV(t, rec.Body.String()))
+	assert.Equal(t, `attachment; filename=points.csv`, rec.Header().Get("Content-Disposition"))
+}
+
+func TestEncodeResponse_NDJSON(t *testing.T) {
+	t.Parallel()
+
+	rows := []exportRow{{ID: 1, Note: "a\nb"}, {ID: 2, Note: "c, d"}}
+	rec := encode(t, "/rows", "application/x-ndjson", ListResponse[exportRow]{Items: rows, TotalCount: 9})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "9", rec.Header().Get("X-Total-Count"))
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+
+	var got []exportRow
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var row exportRow
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row), "one value per line")
+		got = append(got, row)
+	}
+	assert.Equal(t, rows, got)
+}
+
+func TestEncodeResponse_JSON(t *testing.T) {
+	t.Parallel()
+
+	resp := ListResponse[exportRow]{Items: []exportRow{{ID: 1, Note: "a, b"}}, TotalCount: 1, Limit: 20}
+	rec := encode(t, "/rows", "", resp)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("X-Total-Count"), "the envelope carries it")
+	assert.JSONEq(t, `{"items":[{"id":1,"note":"a, b","score":0}],"total_count":1,"limit":20,"offset":0}`,
+		rec.Body.String())
+}
+
+func TestEncodeResponse_Errors(t *testing.T) {
+	t.Parallel()
+
+	type untagged struct{ Name string }
+
+	tests := []struct {
+		name       string
+		target     string
+		accept     string
+		data       any
+		wantStatus int
+		wantCode   string
+		wantError  string
+	}{
+		{name: "not a slice", target: "/users/u1?format=csv", data: UserResponse{ID: "u1"},
+			wantStatus: http.StatusNotAcceptable, wantCode: "not_acceptable"},
+		{name: "no csv columns", target: "/rows?format=csv", data: []untagged{{Name: "x"}},
+			wantStatus: http.StatusInternalServerError, wantCode: "internal"},
+		{name: "unknown format", target: "/rows?format=xml", data: []exportRow{},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid"},
+		{name: "unacceptable", target: "/rows", accept: "image/png", data: []exportRow{},
+			wantStatus: http.StatusNotAcceptable, wantCode: "not_acceptable",
+			wantError: "cannot produce the requested format; available: json, csv, ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := encode(t, tt.target, tt.accept, tt.data)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+			if tt.wantError != "" {
+				assert.Contains(t, rec.Body.String(), `"error":"`+tt.wantError+`"`)
+			}
+		})
+	}
+}
+
+// ---------- UserHandler Tests ----------
+
+func TestUserHandler_ListFormats(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(NewUserHandler(exportUserService{}, WithExportFormats()))
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, PathPrefix+target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		rec := get("/users", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp ListResponse[UserResponse]
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "Smith, Ann", resp.Items[0].Name)
+		assert.EqualValues(t, 42, resp.TotalCount)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		rec := get("/users?format=csv", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "attachment; filename=users.csv", rec.Header().Get("Content-Disposition"))
+		assert.Equal(t, "42", rec.Header().Get("X-Total-Count"))
+		assert.Equal(t, [][]string{
+			{"id", "name", "email", "status", "created_at"},
+			{"u1", "Smith, Ann", "ann@example.com", "active", "1970-01-01T00:00:00Z"},
+			{"u2", "Bob \"The\nBuilder\"", "bob@example.com", "", "1970-01-01T00:01:00Z"},
+		}, readCSV(t, rec.Body.String()))
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		t.Parallel()
+
+		rec := get("/users", "application/x-ndjson")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+		require.Len(t, lines, 2, "the newline in a name stays escaped")
+		assert.JSONEq(t, `{"id":"u2","name":"Bob \"The\nBuilder\"","email":"bob@example.com",
+			"created_at":"1970-01-01T00:01:00Z"}`, lines[1])
+	})
+
+	t.Run("flag off", func(t *testing.T) {
+		t.Parallel()
+
+		router := NewRouter(NewUserHandler(exportUserService{}))
+		req := httptest.NewRequest(http.MethodGet, PathPrefix+"/users?format=csv", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	})
+}
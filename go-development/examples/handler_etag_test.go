@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- Test Helpers ----------
+
+// versionUserService keeps one user in memory and compares-and-sets its
+// version on every write, as the repository's WHERE version = $n does.
+type versionUserService struct {
+	UserService
+
+	mu   sync.Mutex
+	user *User // nil once deleted
+}
+
+func newVersionUserService() *versionUserService {
+	return &versionUserService{user: &User{
+		ID: "u1", Name: "Ann", Email: "ann@example.com", Version: 1, CreatedAt: time.Unix(0, 0),
+	}}
+}
+
+func (s *versionUserService) GetByID(_ context.Context, id string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user == nil || s.user.ID != id {
+		return nil, apperrors.New(apperrors.CodeNotFound, "user not found")
+	}
+	u := *s.user
+	return &u, nil
+}
+
+// check returns the stored user if it is at version.
+func (s *versionUserService) check(id string, version int64) (*User, error) {
+	if s.user == nil || s.user.ID != id {
+		return nil, apperrors.New(apperrors.CodeNotFound, "user not found")
+	}
+	if version != AnyVersion && version != s.user.Version {
+		return nil, fmt.Errorf("update user %s: %w", id, ErrVersionConflict)
+	}
+	return s.user, nil
+}
+
+func (s *versionUserService) Update(_ context.Context, id string, version int64, name, email string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, err := s.check(id, version)
+	if err != nil {
+		return nil, err
+	}
+	u.Name, u.Email = name, email
+	u.Version++
+	updated := *u
+	return &updated, nil
+}
+
+func (s *versionUserService) UpdatePartial(_ context.Context, id string, version int64, patch UserPatch) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, err := s.check(id, version)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name.Set {
+		u.Name = patch.Name.Value
+	}
+	if patch.Email.Set {
+		u.Email = patch.Email.Value
+	}
+	u.Version++
+	updated := *u
+	return &updated, nil
+}
+
+func (s *versionUserService) Delete(_ context.Context, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.check(id, version); err != nil {
+		return err
+	}
+	s.user = nil
+	return nil
+}
+
+func (s *versionUserService) exists() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.user != nil
+}
+
+func serve(t *testing.T, h http.Handler, method, ifMatch, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, UserByIDURL("u1"), strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// ---------- ETag Tests ----------
+
+func TestUserHandler_ETag(t *testing.T) {
+	t.Parallel()
+
+	svc := newVersionUserService()
+	router := NewRouter(NewUserHandler(svc))
+
+	rec := serve(t, router, http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	// The first writer with the current ETag wins and gets the next one.
+	rec = serve(t, router, http.MethodPut, etag, `{"name":"Eve","email":"eve@example.com"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	// A second writer holding the same, now stale, ETag is rejected.
+	for _, tt := range []struct{ method, body string }{
+		{http.MethodPut, `{"name":"Bob","email":"bob@example.com"}`},
+		{http.MethodPatch, `{"name":"Bob"}`},
+		{http.MethodDelete, ``},
+	} {
+		rec = serve(t, router, tt.method, etag, tt.body)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code, tt.method)
+		assert.JSONEq(t, `{"error":"resource has changed since it was read","code":"version_conflict"}`,
+			rec.Body.String(), tt.method)
+	}
+
+	rec = serve(t, router, http.MethodGet, "", "")
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), `"name":"Eve"`, "stale writes changed nothing")
+
+	rec = serve(t, router, http.MethodPatch, `"2"`, `{"name":"Bob"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	rec = serve(t, router, http.MethodDelete, `"3"`, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.False(t, svc.exists())
+}
+
+func TestUserHandler_IfMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []HandlerOption
+		ifMatch    string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "absent", ifMatch: "", wantStatus: http.StatusNoContent},
+		{name: "any", ifMatch: "*", wantStatus: http.StatusNoContent},
+		{name: "current", ifMatch: `"1"`, wantStatus: http.StatusNoContent},
+		{name: "stale", ifMatch: `"7"`, wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict"},
+		{name: "weak never matches", ifMatch: `W/"1"`, wantStatus: http.StatusPreconditionFailed,
+			wantCode: "version_conflict"},
+		{name: "foreign tag", ifMatch: `"abc"`, wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict"},
+		{name: "unquoted", ifMatch: `1`, wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict"},
+		{name: "list", ifMatch: `"1", "2"`, wantStatus: http.StatusBadRequest, wantCode: "invalid"},
+		{name: "required and absent", opts: []HandlerOption{WithRequireIfMatch()}, ifMatch: "",
+			wantStatus: http.StatusPreconditionRequired, wantCode: "precondition_required"},
+		{name: "required and any", opts: []HandlerOption{WithRequireIfMatch()}, ifMatch: "*",
+			wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newVersionUserService()
+			rec := serve(t, NewRouter(NewUserHandler(svc, tt.opts...)), http.MethodDelete, tt.ifMatch, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+			}
+			assert.Equal(t, tt.wantStatus == http.StatusNoContent, !svc.exists())
+		})
+	}
+}
+
+// racingUserService lets another client write the user right after a
+// handler reads it.
+type racingUserService struct {
+	*versionUserService
+}
+
+func (s racingUserService) GetByID(ctx context.Context, id string) (*User, error) {
+	u, err := s.versionUserService.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.versionUserService.Update(ctx, id, AnyVersion, "Bob", "")
+	return u, err
+}
+
+func TestUserHandler_PatchConcurrentWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "without If-Match", ifMatch: "", wantStatus: http.StatusConflict, wantCode: "conflict"},
+		{name: "with If-Match", ifMatch: `"1"`, wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newVersionUserService()
+			rec := serve(t, NewRouter(NewUserHandler(racingUserService{svc})), http.MethodPatch, tt.ifMatch, `{"name":"Eve"}`)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+
+			u, err := svc.GetByID(context.Background(), "u1")
+			require.NoError(t, err)
+			assert.Equal(t, "Bob", u.Name, "the concurrent write isn't replaced by an unvalidated patch")
+		})
+	}
+}
+
+func TestUserHandler_IfMatchRequired(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(NewUserHandler(newVersionUserService(), WithRequireIfMatch()))
+
+	rec := serve(t, router, http.MethodPut, "", `{"name":"Eve","email":"eve@example.com"}`)
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	rec = serve(t, router, http.MethodPatch, "", `{"name":"Eve"}`)
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = serve(t, router, http.MethodGet, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code, "reads need no precondition")
+}
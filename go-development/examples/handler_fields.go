@@ -0,0 +1,341 @@
+// Package handler provides field masks: ?fields=id,name responses that
+// carry only the requested JSON fields.
+package handler
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FieldMask is a parsed ?fields= selection: JSON field names, each mapped
+// to the subfields selected below it, or to nil for the whole field.
+type FieldMask map[string]FieldMask
+
+// ParseFields parses ?fields=id,name,balance.currency: a comma-separated
+// list (or repeated key) of JSON field names, with one level of nesting
+// through ".". Selecting a field whole ("balance") wins over selecting
+// some of its subfields. It returns nil without the parameter, which
+// Project treats as "everything".
+//
+// Names are checked against the response type by Project, since only it
+// knows the type.
+func ParseFields(r *http.Request) (FieldMask, error) {
+	items, err := splitValues("fields", r.URL.Query()["fields"])
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	mask := make(FieldMask, len(items))
+	for _, item := range items {
+		name, sub, nested := strings.Cut(item, ".")
+		switch {
+		case name == "" || (nested && sub == ""):
+			return nil, queryError("fields", "required", fmt.Sprintf("fields item %q has an empty name", item))
+		case strings.Contains(sub, "."):
+			return nil, queryError("fields", "max", fmt.Sprintf("fields nest one level deep, got %q", item))
+		}
+
+		selected, seen := mask[name]
+		switch {
+		case !nested:
+			mask[name] = nil
+		case seen && selected == nil:
+			// the whole field is already selected
+		case seen:
+			selected[sub] = nil
+		default:
+			mask[name] = FieldMask{sub: nil}
+		}
+	}
+	return mask, nil
+}
+
+// maskable is implemented by ListResponse, so masks apply to its items
+// and leave the envelope alone.
+type maskable interface {
+	project(m FieldMask) (any, error)
+}
+
+func (l ListResponse[T]) project(m FieldMask) (any, error) {
+	if err := m.check(reflect.TypeFor[T](), ""); err != nil {
+		return nil, err
+	}
+	items, err := m.projectRows(reflect.ValueOf(l.Items))
+	if err != nil {
+		return nil, err
+	}
+	return ListResponse[json.RawMessage]{
+		Items:      items,
+		TotalCount: l.TotalCount,
+		Limit:      l.Limit,
+		Offset:     l.Offset,
+	}, nil
+}
+
+// Project returns data, a struct, a slice of structs or a ListResponse,
+// reduced to the fields in m, in the order the type declares them:
+//
+//	mask, err := ParseFields(r)
+//	...
+//	resp, err := mask.Project(toUserResponse(user))
+//
+// Fields are named by their json tags and keep their omitempty. A name
+// the type doesn't have, or a subfield of something that isn't a struct,
+// gives a 400 ValidationErrors entry for "fields", even when the slice is
+// empty. A nil mask returns data as is.
+func (m FieldMask) Project(data any) (any, error) {
+	if m == nil || data == nil {
+		return data, nil
+	}
+	if p, ok := data.(maskable); ok {
+		return p.project(m)
+	}
+
+	v := reflect.ValueOf(data)
+	if err := m.check(v.Type(), ""); err != nil {
+		return nil, err
+	}
+	return m.projectValue(v)
+}
+
+// projectRows projects each element of rows, for NDJSON and list items.
+func (m FieldMask) projectRows(rows reflect.Value) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, rows.Len())
+	for i := range rows.Len() {
+		raw, err := m.projectValue(rows.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		out[i] = raw
+	}
+	return out, nil
+}
+
+// check validates m against t, a struct type possibly behind pointers and
+// slices; prefix is the parent field for nested masks.
+func (m FieldMask) check(t reflect.Type, prefix string) error {
+	st, ok := maskStruct(t)
+	if !ok {
+		if prefix == "" {
+			return queryError("fields", "excluded_with", "fields can't select from this response")
+		}
+		return queryError("fields", "oneof", fmt.Sprintf("%s has no subfields", strings.TrimSuffix(prefix, ".")))
+	}
+	fields := jsonFieldsOf(st)
+
+	var unknown []string
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		i, ok := fields.byName[name]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("%q", prefix+name))
+			continue
+		}
+		if sub := m[name]; sub != nil {
+			if err := sub.check(fields.list[i].typ, prefix+name+"."); err != nil {
+				return err
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		names := make([]string, len(fields.list))
+		for i, f := range fields.list {
+			names[i] = prefix + f.name
+		}
+		return queryError("fields", "oneof", fmt.Sprintf("unknown field %s; select from %s",
+			strings.Join(unknown, ", "), strings.Join(names, ", ")))
+	}
+	return nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// maskStruct returns the struct type under t's pointers, slices and
+// arrays. Types that marshal themselves, like time.Time, have no fields
+// to select.
+func maskStruct(t reflect.Type) (reflect.Type, bool) {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+			marshals := t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+				reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+			return t, !marshals
+		}
+		return nil, false
+	}
+}
+
+// projectValue encodes v, which check has accepted for m.
+func (m FieldMask) projectValue(v reflect.Value) (json.RawMessage, error) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return json.RawMessage("null"), nil
+		}
+		return m.projectValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return json.RawMessage("null"), nil
+		}
+		var b bytes.Buffer
+		b.WriteByte('[')
+		for i := range v.Len() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			raw, err := m.projectValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			b.Write(raw)
+		}
+		b.WriteByte(']')
+		return b.Bytes(), nil
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for _, f := range jsonFieldsOf(v.Type()).list {
+		sub, selected := m[f.name]
+		if !selected {
+			continue
+		}
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil || !fv.CanInterface() || (f.omitEmpty && isEmptyValue(fv)) {
+			continue // behind a nil embedded pointer, or omitted as encoding/json would
+		}
+
+		var raw []byte
+		if sub != nil {
+			raw, err = sub.projectValue(fv)
+		} else {
+			raw, err = json.Marshal(fv.Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", f.name, err)
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.name)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(raw)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// ---------- Field Metadata ----------
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields lists a struct's JSON fields in declaration order.
+type jsonFields struct {
+	list   []jsonField
+	byName map[string]int
+}
+
+// jsonFieldCache holds *jsonFields per reflect.Type, so tags are parsed
+// once per type rather than per response.
+var jsonFieldCache sync.Map
+
+// jsonFieldsOf returns the JSON fields of struct type t: exported fields
+// named by their json tag (or Go name), "-" left out, and embedded
+// structs' fields promoted, the shallowest winning a name.
+func jsonFieldsOf(t reflect.Type) *jsonFields {
+	if cached, ok := jsonFieldCache.Load(t); ok {
+		return cached.(*jsonFields)
+	}
+
+	fields := &jsonFields{byName: make(map[string]int)}
+	for _, f := range reflect.VisibleFields(t) {
+		tag, hasTag := f.Tag.Lookup("json")
+		name, opts, _ := strings.Cut(tag, ",")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && !hasTag {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				continue // its fields are promoted
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		field := jsonField{
+			name:      name,
+			index:     f.Index,
+			typ:       f.Type,
+			omitEmpty: slices.Contains(strings.Split(opts, ","), "omitempty"),
+		}
+		if i, dup := fields.byName[name]; dup {
+			if len(fields.list[i].index) > len(f.Index) {
+				fields.list[i] = field
+			}
+			continue
+		}
+		fields.byName[name] = len(fields.list)
+		fields.list = append(fields.list, field)
+	}
+
+	cached, _ := jsonFieldCache.LoadOrStore(t, fields)
+	return cached.(*jsonFields)
+}
+
+// isEmptyValue is encoding/json's omitempty test.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// ---------- Encoding ----------
+
+// encodeMaskedResponse writes data as encodeJSONResponse does, reduced to
+// the fields ?fields= selects, if any.
+func encodeMaskedResponse(w http.ResponseWriter, r *http.Request, status int, data any) {
+	mask, err := ParseFields(r)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	if data, err = mask.Project(data); err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+	encodeJSONResponse(w, r, status, data)
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+type maskBalance struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type maskAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type maskAccount struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name,omitempty"`
+	Balance maskBalance   `json:"balance"`
+	Limits  []maskBalance `json:"limits"`
+	Parent  *maskBalance  `json:"parent,omitempty"`
+	Opened  time.Time     `json:"opened"`
+	Secret  string        `json:"-"`
+	Legacy  string
+	*maskAudit
+}
+
+func newMaskAccount() maskAccount {
+	return maskAccount{
+		ID:        "a1",
+		Name:      "Main",
+		Balance:   maskBalance{Amount: 1250, Currency: "EUR"},
+		Limits:    []maskBalance{{Amount: 100, Currency: "EUR"}, {Amount: 5, Currency: "USD"}},
+		Opened:    time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Secret:    "s3cr3t",
+		Legacy:    "old",
+		maskAudit: &maskAudit{CreatedBy: "u9"},
+	}
+}
+
+func fieldsRequest(query string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/accounts?"+query, nil)
+}
+
+func project(t *testing.T, query string, data any) string {
+	t.Helper()
+
+	mask, err := ParseFields(fieldsRequest(query))
+	require.NoError(t, err)
+	projected, err := mask.Project(data)
+	require.NoError(t, err)
+	b, err := json.Marshal(projected)
+	require.NoError(t, err)
+	return string(b)
+}
+
+// ---------- ParseFields Tests ----------
+
+func TestParseFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		want    FieldMask
+		wantErr string
+	}{
+		{name: "absent", query: "", want: nil},
+		{name: "empty", query: "fields=", want: nil},
+		{name: "list", query: "fields=id,name", want: FieldMask{"id": nil, "name": nil}},
+		{name: "repeated", query: "fields=id&fields=name", want: FieldMask{"id": nil, "name": nil}},
+		{name: "nested", query: "fields=id,balance.currency,balance.amount",
+			want: FieldMask{"id": nil, "balance": {"currency": nil, "amount": nil}}},
+		{name: "whole wins", query: "fields=balance.currency,balance",
+			want: FieldMask{"balance": nil}},
+		{name: "whole wins first", query: "fields=balance,balance.currency",
+			want: FieldMask{"balance": nil}},
+		{name: "too deep", query: "fields=a.b.c", wantErr: `fields nest one level deep, got "a.b.c"`},
+		{name: "empty subfield", query: "fields=balance.", wantErr: `fields item "balance." has an empty name`},
+		{name: "empty parent", query: "fields=.currency", wantErr: `fields item ".currency" has an empty name`},
+		{name: "empty item", query: "fields=id,,name", wantErr: "fields has an empty item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFields(fieldsRequest(tt.query))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// ---------- Project Tests ----------
+
+func TestFieldMask_Project(t *testing.T) {
+	t.Parallel()
+
+	account := newMaskAccount()
+
+	tests := []struct {
+		name  string
+		query string
+		data  any
+		want  string
+	}{
+		{name: "no mask", query: "", data: maskBalance{Amount: 1, Currency: "EUR"},
+			want: `{"amount":1,"currency":"EUR"}`},
+		{name: "declaration order", query: "fields=opened,id", data: account,
+			want: `{"id":"a1","opened":"2026-01-02T00:00:00Z"}`},
+		{name: "pointer", query: "fields=id", data: &account, want: `{"id":"a1"}`},
+		{name: "whole nested", query: "fields=balance", data: account,
+			want: `{"balance":{"amount":1250,"currency":"EUR"}}`},
+		{name: "nested", query: "fields=id,balance.currency", data: account,
+			want: `{"id":"a1","balance":{"currency":"EUR"}}`},
+		{name: "nested slice", query: "fields=limits.currency", data: account,
+			want: `{"limits":[{"currency":"EUR"},{"currency":"USD"}]}`},
+		{name: "nil nested pointer omitted", query: "fields=id,parent.amount", data: account,
+			want: `{"id":"a1"}`},
+		{name: "omitempty kept", query: "fields=id,name", data: maskAccount{ID: "a2"},
+			want: `{"id":"a2"}`},
+		{name: "untagged and promoted", query: "fields=Legacy,created_by", data: account,
+			want: `{"Legacy":"old","created_by":"u9"}`},
+		{name: "nil embedded pointer", query: "fields=id,created_by", data: maskAccount{ID: "a3"},
+			want: `{"id":"a3"}`},
+		{name: "slice", query: "fields=currency", data: []maskBalance{{1, "EUR"}, {2, "USD"}},
+			want: `[{"currency":"EUR"},{"currency":"USD"}]`},
+		{name: "list envelope kept", query: "fields=id",
+			data: ListResponse[maskAccount]{Items: []maskAccount{account}, TotalCount: 7, Limit: 1, Offset: 2},
+			want: `{"items":[{"id":"a1"}],"total_count":7,"limit":1,"offset":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.JSONEq(t, tt.want, project(t, tt.query, tt.data))
+		})
+	}
+
+	t.Run("keeps field order", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, `{"id":"a1","balance":{"amount":1250,"currency":"EUR"},"opened":"2026-01-02T00:00:00Z"}`,
+			project(t, "fields=opened,balance.currency,balance.amount,id", account))
+	})
+}
+
+func TestFieldMask_ProjectInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		data    any
+		wantErr string
+	}{
+		{name: "unknown", query: "fields=id,nope", data: newMaskAccount(),
+			wantErr: `unknown field "nope"; select from id, name, balance, limits, parent, opened, Legacy, created_by`},
+		{name: "hidden by dash", query: "fields=Secret", data: newMaskAccount(), wantErr: `unknown field "Secret"`},
+		{name: "unknown nested", query: "fields=balance.rate", data: newMaskAccount(),
+			wantErr: `unknown field "balance.rate"; select from balance.amount, balance.currency`},
+		{name: "subfield of scalar", query: "fields=id.x", data: newMaskAccount(), wantErr: "id has no subfields"},
+		{name: "subfield of time", query: "fields=opened.wall", data: newMaskAccount(),
+			wantErr: "opened has no subfields"},
+		{name: "empty list", query: "fields=nope", data: ListResponse[maskAccount]{}, wantErr: `unknown field "nope"`},
+		{name: "empty slice", query: "fields=nope", data: []maskBalance{}, wantErr: `unknown field "nope"`},
+		{name: "not a struct", query: "fields=id", data: map[string]string{"id": "a1"},
+			wantErr: "fields can't select from this response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mask, err := ParseFields(fieldsRequest(tt.query))
+			require.NoError(t, err)
+			_, err = mask.Project(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+		})
+	}
+}
+
+func TestJSONFieldsOf_Cached(t *testing.T) {
+	t.Parallel()
+
+	typ := reflect.TypeFor[maskAccount]()
+	first := jsonFieldsOf(typ)
+	assert.Same(t, first, jsonFieldsOf(typ), "parsed once per type")
+
+	_, ok := jsonFieldCache.Load(typ)
+	assert.True(t, ok)
+	assert.NotSame(t, first, jsonFieldsOf(reflect.TypeFor[maskBalance]()))
+}
+
+func BenchmarkFieldMask_Project(b *testing.B) {
+	mask := FieldMask{"id": nil, "balance": {"currency": nil}}
+	items := make([]maskAccount, 100)
+	for i := range items {
+		items[i] = newMaskAccount()
+	}
+	resp := ListResponse[maskAccount]{Items: items, TotalCount: 100}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := mask.Project(resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// ---------- UserHandler Tests ----------
+
+type fieldsUserService struct {
+	UserService
+}
+
+func (fieldsUserService) GetByID(_ context.Context, id string) (*User, error) {
+	return &User{ID: id, Name: "Ann", Email: "ann@example.com", Version: 3}, nil
+}
+
+func (fieldsUserService) List(context.Context, UserFilter) ([]*User, int64, error) {
+	return []*User{{ID: "u1", Name: "Ann", Email: "ann@example.com"}}, 1, nil
+}
+
+func TestUserHandler_Fields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []HandlerOption
+		target     string
+		accept     string
+		wantStatus int
+		want       string
+	}{
+		{name: "get", target: "/users/u1?fields=id,name", wantStatus: http.StatusOK,
+			want: `{"id":"u1","name":"Ann"}` + "\n"},
+		{name: "get all", target: "/users/u1", wantStatus: http.StatusOK,
+			want: `{"id":"u1","name":"Ann","email":"ann@example.com","created_at":"0001-01-01T00:00:00Z"}` + "\n"},
+		{name: "list", target: "/users?fields=email", wantStatus: http.StatusOK,
+			want: `{"items":[{"email":"ann@example.com"}],"total_count":1,"limit":20,"offset":0}` + "\n"},
+		{name: "list export json", opts: []HandlerOption{WithExportFormats()}, target: "/users?fields=id",
+			wantStatus: http.StatusOK, want: `{"items":[{"id":"u1"}],"total_count":1,"limit":20,"offset":0}` + "\n"},
+		{name: "list ndjson", opts: []HandlerOption{WithExportFormats()}, target: "/users?fields=id,name",
+			accept: "application/x-ndjson", wantStatus: http.StatusOK, want: `{"id":"u1","name":"Ann"}` + "\n"},
+		{name: "list csv", opts: []HandlerOption{WithExportFormats()}, target: "/users?format=csv&fields=id",
+			wantStatus: http.StatusBadRequest},
+		{name: "unknown", target: "/users/u1?fields=id,password", wantStatus: http.StatusBadRequest},
+		{name: "too deep", target: "/users?fields=a.b.c", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := NewRouter(NewUserHandler(fieldsUserService{}, tt.opts...))
+			req := httptest.NewRequest(http.MethodGet, PathPrefix+tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"details":{"fields":`)
+				return
+			}
+			assert.Equal(t, tt.want, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Package handler provides request decoding helpers shared by entity
+// handlers.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// DefaultMaxBodyBytes caps bodies read by DecodeJSON.
+const DefaultMaxBodyBytes = 1 << 20 // 1 MB
+
+// DecodeOption configures DecodeJSON.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	disallowUnknownFields bool
+	maxBytes              int64
+	useNumber             bool // decode numbers in any as json.Number
+}
+
+// DisallowUnknownFields rejects bodies with fields the target type
+// doesn't declare, so a typo like "emial" fails instead of being
+// silently ignored.
+func DisallowUnknownFields() DecodeOption {
+	return func(o *decodeOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// WithMaxBodyBytes replaces DefaultMaxBodyBytes. The MaxBodyBytes
+// middleware's cap still applies if it is lower.
+func WithMaxBodyBytes(n int64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.maxBytes = n
+	}
+}
+
+// DecodeJSON decodes and validates a JSON request body into a new T,
+// which must be a struct:
+//
+//	req, err := DecodeJSON[CreateUserRequest](r, h.validate)
+//	if err != nil {
+//	    encodeErrorResponse(w, err)
+//	    return
+//	}
+//
+// Errors are ready for encodeErrorResponse:
+//
+//	Content-Type not application/json   415
+//	body over the cap                   413
+//	empty, malformed or mistyped body   400, saying which and where
+//	failed validation                   400, apperrors.ValidationErrors with every field
+//	client gone mid-body                context.Canceled, a bare 499
+//
+// A nil v skips validation.
+func DecodeJSON[T any](r *http.Request, v *validator.Validate, opts ...DecodeOption) (*T, error) {
+	cfg := decodeOptions{maxBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := r.Context().Err(); err != nil {
+		return nil, err // don't read a body nobody is waiting on
+	}
+	if err := requireMediaType(r, "application/json"); err != nil {
+		return nil, err
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, cfg.maxBytes)
+
+	var dst T
+	if err := decodeJSON(r.Body, &dst, cfg); err != nil {
+		return nil, readError(r, err)
+	}
+	if v != nil {
+		if err := v.StructCtx(r.Context(), &dst); err != nil {
+			return nil, NewValidationError(err)
+		}
+	}
+	return &dst, nil
+}
+
+// requireMediaType accepts any of the JSON mediaTypes with no charset or
+// UTF-8, the only encoding JSON allows. The error names the first.
+func requireMediaType(r *http.Request, mediaTypes ...string) error {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !slices.Contains(mediaTypes, mediaType) {
+		return NewUnsupportedMediaTypeError(mediaTypes[0])
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return NewUnsupportedMediaTypeError(mediaTypes[0])
+	}
+	return nil
+}
+
+// decodeJSON decodes a body holding a single JSON value into dst. A body
+// cut off by http.MaxBytesReader (see middleware.MaxBodyBytes) gives 413,
+// any other malformed body 400.
+func decodeJSON(body io.Reader, dst any, opts decodeOptions) error {
+	dec := json.NewDecoder(body)
+	if opts.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.useNumber {
+		dec.UseNumber()
+	}
+
+	err := dec.Decode(dst)
+	if err == nil {
+		if dec.More() {
+			return NewBadRequestError("request body must contain a single JSON value")
+		}
+		return nil
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return NewRequestTooLargeError(maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return NewBadRequestError("request body is empty")
+	case errors.As(err, &syntaxErr):
+		return NewBadRequestError(fmt.Sprintf("invalid JSON at byte %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return NewBadRequestError(typeErr.Field + " has the wrong type")
+	case errors.As(err, &typeErr):
+		return NewBadRequestError("request body has the wrong type")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields.
+		return NewBadRequestError("unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return NewBadRequestError("invalid JSON")
+	}
+}
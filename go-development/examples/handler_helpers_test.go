@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- DecodeJSON Tests ----------
+
+func TestDecodeJSON_Generic(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name  string `json:"name" validate:"required,min=2"`
+		Email string `json:"email" validate:"required,email"`
+		Bio   string `json:"bio" validate:"max=10"`
+		Age   int    `json:"age"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []DecodeOption
+		wantStatus  int // 0 = no error
+		wantMsg     string
+		wantFields  map[string]string
+	}{
+		{name: "valid", contentType: "application/json",
+			body: `{"name":"Ann","email":"ann@example.com","age":30}`},
+		{name: "charset", contentType: "application/json; charset=UTF-8",
+			body: `{"name":"Ann","email":"ann@example.com"}`},
+		{name: "missing content type", contentType: "",
+			body:       `{"name":"Ann","email":"ann@example.com"}`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMsg: "Content-Type must be application/json"},
+		{name: "wrong content type", contentType: "text/plain",
+			body:       `{"name":"Ann","email":"ann@example.com"}`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMsg: "Content-Type must be application/json"},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: `name=Ann`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMsg: "Content-Type must be application/json"},
+		{name: "non UTF-8 charset", contentType: "application/json; charset=latin1",
+			body:       `{"name":"Ann","email":"ann@example.com"}`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMsg: "Content-Type must be application/json"},
+		{name: "syntax error with offset", contentType: "application/json", body: `{"name": Ann}`,
+			wantStatus: http.StatusBadRequest, wantMsg: "invalid JSON at byte 10"},
+		{name: "truncated", contentType: "application/json", body: `{"name":"Ann"`,
+			wantStatus: http.StatusBadRequest, wantMsg: "invalid JSON"},
+		{name: "field type mismatch", contentType: "application/json", body: `{"name":"Ann","age":"thirty"}`,
+			wantStatus: http.StatusBadRequest, wantMsg: "age has the wrong type"},
+		{name: "body type mismatch", contentType: "application/json", body: `["Ann"]`,
+			wantStatus: http.StatusBadRequest, wantMsg: "request body has the wrong type"},
+		{name: "empty", contentType: "application/json", body: ``,
+			wantStatus: http.StatusBadRequest, wantMsg: "request body is empty"},
+		{name: "unknown field ignored by default", contentType: "application/json",
+			body: `{"name":"Ann","email":"ann@example.com","admin":true}`},
+		{name: "unknown field rejected", contentType: "application/json",
+			body: `{"name":"Ann","email":"ann@example.com","admin":true}`, opts: []DecodeOption{DisallowUnknownFields()},
+			wantStatus: http.StatusBadRequest, wantMsg: `unknown field "admin"`},
+		{name: "too large", contentType: "application/json",
+			body: `{"name":"` + strings.Repeat("a", 100) + `"}`, opts: []DecodeOption{WithMaxBodyBytes(64)},
+			wantStatus: http.StatusRequestEntityTooLarge, wantMsg: "request body exceeds 64 bytes"},
+		{name: "every failed field", contentType: "application/json", body: `{"name":"A","email":"nope","bio":"far too long a bio"}`,
+			wantStatus: http.StatusBadRequest, wantMsg: "validation failed",
+			wantFields: map[string]string{
+				"name":  "name is too short",
+				"email": "invalid email format",
+				"bio":   "bio is too long",
+			}},
+		{name: "required fields", contentType: "application/json", body: `{}`,
+			wantStatus: http.StatusBadRequest, wantMsg: "validation failed",
+			wantFields: map[string]string{
+				"name":  "name is required",
+				"email": "email is required",
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			got, err := DecodeJSON[request](req, newValidator(), tt.opts...)
+
+			if tt.wantStatus == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, "Ann", got.Name)
+				return
+			}
+			require.Error(t, err)
+			assert.Nil(t, got)
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(err))
+			assert.Equal(t, tt.wantMsg, ErrorMessage(err))
+
+			if tt.wantFields != nil {
+				var verrs apperrors.ValidationErrors
+				require.ErrorAs(t, err, &verrs)
+				assert.Equal(t, tt.wantFields, verrs.ToDetails())
+			}
+		})
+	}
+}
+
+func TestDecodeJSON_NilValidator(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":""}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	got, err := DecodeJSON[CreateUserRequest](req, nil)
+	require.NoError(t, err)
+	assert.Empty(t, got.Name)
+}
@@ -0,0 +1,169 @@
+// Package handler provides JSON Merge Patch (RFC 7386) support for PATCH
+// endpoints.
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MergePatchMediaType is the RFC 7386 media type. PATCH endpoints accept
+// it and plain application/json.
+const MergePatchMediaType = "application/merge-patch+json"
+
+// ---------- Optional ----------
+
+// Optional is a tri-state PATCH field. A *string can't tell a field left
+// out of the patch from one set to null; Optional can:
+//
+//	{}               Set: false                  leave unchanged
+//	{"bio": null}    Set: true, Null: true       clear
+//	{"bio": "hi"}    Set: true, Value: "hi"      replace
+//
+// Use it in DTOs decoded from a patch DecodeMergePatch has checked:
+// encoding/json doesn't reliably name the field when a custom
+// UnmarshalJSON fails. Documents passed to ApplyMergePatch use plain and
+// pointer fields, since an unset Optional marshals as null and would
+// come back set.
+type Optional[T any] struct {
+	Value T
+	Set   bool // the field was in the patch
+	Null  bool // the field was null
+}
+
+// Some returns a set, non-null Optional.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Null returns a set Optional holding null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+// UnmarshalJSON is only called for fields present in the input, which
+// is what marks o as Set.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	*o = Optional[T]{Set: true}
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// MarshalJSON writes null for unset and null Optionals.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// ---------- Merge Patch ----------
+
+// ApplyMergePatch applies an RFC 7386 merge patch to original's JSON form
+// and decodes the result into a new T:
+//
+//	{"name": "Ann"}               replaces name, keeps every other field
+//	{"email": null}               removes email, leaving T's zero value
+//	{"address": {"city": "X"}}    merges into the nested object
+//
+// Arrays are replaced, not merged. Patch members T doesn't declare are
+// dropped, as encoding/json does. A malformed patch or a member of the
+// wrong type gives a 400 error, as DecodeJSON does.
+func ApplyMergePatch[T any](original T, patch []byte) (T, error) {
+	var zero T
+
+	doc, err := json.Marshal(original)
+	if err != nil {
+		return zero, fmt.Errorf("ApplyMergePatch: %w", err)
+	}
+	// Numbers stay json.Number, so int64 IDs survive the round trip that
+	// float64 would round.
+	var target any
+	if err := decodeJSON(bytes.NewReader(doc), &target, decodeOptions{useNumber: true}); err != nil {
+		return zero, fmt.Errorf("ApplyMergePatch: %w", err)
+	}
+	var p any
+	if err := decodeJSON(bytes.NewReader(patch), &p, decodeOptions{useNumber: true}); err != nil {
+		return zero, err
+	}
+
+	merged, err := json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return zero, fmt.Errorf("ApplyMergePatch: %w", err)
+	}
+	var result T
+	if err := decodeJSON(bytes.NewReader(merged), &result, decodeOptions{}); err != nil {
+		return zero, err
+	}
+	return result, nil
+}
+
+// mergePatch is the MergePatch function of RFC 7386, section 2.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
+
+// ---------- Decoding ----------
+
+// DecodeMergePatch reads a merge patch body and applies it to original.
+// It also returns the raw patch, to decode into a DTO with Optional
+// fields that tells the service which fields to write:
+//
+//	patched, patch, err := DecodeMergePatch(r, CreateUserRequest{Name: u.Name, Email: u.Email})
+//
+// It accepts MergePatchMediaType and application/json, with the limits
+// and errors of DecodeJSON. Members are checked against T, the plain
+// document type, so a wrong type names its field and
+// DisallowUnknownFields rejects members T doesn't declare. It doesn't
+// validate: validate patched.
+func DecodeMergePatch[T any](r *http.Request, original T, opts ...DecodeOption) (T, []byte, error) {
+	var zero T
+	cfg := decodeOptions{maxBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := requireMediaType(r, MergePatchMediaType, "application/json"); err != nil {
+		return zero, nil, err
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, cfg.maxBytes))
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return zero, nil, NewRequestTooLargeError(maxBytesErr.Limit)
+	case err != nil:
+		return zero, nil, fmt.Errorf("read patch: %w", err)
+	}
+
+	if err := decodeJSON(bytes.NewReader(patch), new(T), cfg); err != nil {
+		return zero, nil, err
+	}
+	patched, err := ApplyMergePatch(original, patch)
+	if err != nil {
+		return zero, nil, err
+	}
+	return patched, patch, nil
+}
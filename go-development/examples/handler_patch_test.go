@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Optional Tests ----------
+
+func TestOptional_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	type patch struct {
+		Bio   Optional[string] `json:"bio"`
+		Count Optional[int]    `json:"count"`
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		wantBio   Optional[string]
+		wantCount Optional[int]
+	}{
+		{name: "absent", body: `{}`},
+		{name: "null", body: `{"bio":null}`, wantBio: Null[string]()},
+		{name: "value", body: `{"bio":"hi","count":3}`, wantBio: Some("hi"), wantCount: Some(3)},
+		{name: "zero value is set", body: `{"bio":"","count":0}`, wantBio: Some(""), wantCount: Some(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got patch
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &got))
+			assert.Equal(t, tt.wantBio, got.Bio)
+			assert.Equal(t, tt.wantCount, got.Count)
+		})
+	}
+}
+
+func TestOptional_UnmarshalJSON_WrongType(t *testing.T) {
+	t.Parallel()
+
+	var got struct {
+		Count Optional[int] `json:"count"`
+	}
+	var typeErr *json.UnmarshalTypeError
+	assert.ErrorAs(t, json.Unmarshal([]byte(`{"count":"three"}`), &got), &typeErr)
+}
+
+func TestOptional_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		opt  Optional[string]
+		want string
+	}{
+		{Optional[string]{}, `null`},
+		{Null[string](), `null`},
+		{Some("hi"), `"hi"`},
+		{Some(""), `""`},
+	} {
+		got, err := json.Marshal(tt.opt)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, string(got))
+	}
+}
+
+// ---------- ApplyMergePatch Tests ----------
+
+// TestApplyMergePatch_RFC7386 runs the examples of RFC 7386, appendix A.
+func TestApplyMergePatch_RFC7386(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		original, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.original+" + "+tt.patch, func(t *testing.T) {
+			t.Parallel()
+
+			var original any
+			require.NoError(t, json.Unmarshal([]byte(tt.original), &original))
+
+			got, err := ApplyMergePatch(original, []byte(tt.patch))
+			require.NoError(t, err)
+
+			gotJSON, err := json.Marshal(got)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(gotJSON))
+		})
+	}
+}
+
+type patchDoc struct {
+	ID    int64    `json:"id"`
+	Name  string   `json:"name"`
+	Bio   *string  `json:"bio"`
+	Tags  []string `json:"tags"`
+	Owner struct {
+		Name string `json:"name"`
+		City string `json:"city"`
+	} `json:"owner"`
+}
+
+func TestApplyMergePatch_Struct(t *testing.T) {
+	t.Parallel()
+
+	bio := "hello"
+	original := patchDoc{ID: 1<<62 + 1, Name: "Ann", Bio: &bio, Tags: []string{"a", "b"}}
+	original.Owner.Name = "Bob"
+	original.Owner.City = "Oslo"
+
+	tests := []struct {
+		name  string
+		patch string
+		want  func(d *patchDoc)
+	}{
+		{name: "empty patch changes nothing", patch: `{}`,
+			want: func(*patchDoc) {}},
+		{name: "absent fields are kept", patch: `{"name":"Eve"}`,
+			want: func(d *patchDoc) { d.Name = "Eve" }},
+		{name: "null clears a pointer", patch: `{"bio":null}`,
+			want: func(d *patchDoc) { d.Bio = nil }},
+		{name: "null zeroes a value", patch: `{"name":null}`,
+			want: func(d *patchDoc) { d.Name = "" }},
+		{name: "arrays are replaced", patch: `{"tags":["c"]}`,
+			want: func(d *patchDoc) { d.Tags = []string{"c"} }},
+		{name: "objects are merged", patch: `{"owner":{"city":"Rome"}}`,
+			want: func(d *patchDoc) { d.Owner.City = "Rome" }},
+		{name: "unknown members are dropped", patch: `{"nickname":"x"}`,
+			want: func(*patchDoc) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := original
+			want.Tags = []string{"a", "b"}
+			tt.want(&want)
+
+			got, err := ApplyMergePatch(original, []byte(tt.patch))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+			assert.Equal(t, []string{"a", "b"}, original.Tags, "original untouched")
+		})
+	}
+}
+
+func TestApplyMergePatch_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		patch   string
+		wantMsg string
+	}{
+		{name: "invalid type", patch: `{"name":42}`, wantMsg: "name has the wrong type"},
+		{name: "invalid nested type", patch: `{"owner":{"city":[]}}`, wantMsg: "owner.city has the wrong type"},
+		{name: "not an object", patch: `["a"]`, wantMsg: "request body has the wrong type"},
+		{name: "syntax error", patch: `{"name":}`, wantMsg: "invalid JSON at byte 9"},
+		{name: "empty", patch: ``, wantMsg: "request body is empty"},
+		{name: "trailing value", patch: `{} {}`, wantMsg: "request body must contain a single JSON value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ApplyMergePatch(patchDoc{Name: "Ann"}, []byte(tt.patch))
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+			assert.Equal(t, tt.wantMsg, ErrorMessage(err))
+		})
+	}
+}
+
+// ---------- DecodeMergePatch Tests ----------
+
+func TestDecodeMergePatch(t *testing.T) {
+	t.Parallel()
+
+	original := CreateUserRequest{Name: "Ann", Email: "ann@example.com"}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []DecodeOption
+		want        CreateUserRequest
+		wantStatus  int
+		wantMsg     string
+	}{
+		{name: "merge patch", contentType: MergePatchMediaType, body: `{"name":"Eve","email":null}`,
+			want: CreateUserRequest{Name: "Eve"}},
+		{name: "plain json", contentType: "application/json", body: `{"email":"eve@example.com"}`,
+			want: CreateUserRequest{Name: "Ann", Email: "eve@example.com"}},
+		{name: "unknown field dropped", contentType: MergePatchMediaType, body: `{"emial":"x"}`,
+			want: original},
+		{name: "wrong content type", contentType: "application/json-patch+json", body: `[]`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMsg: "Content-Type must be application/merge-patch+json"},
+		{name: "invalid type", contentType: MergePatchMediaType, body: `{"name":42}`,
+			wantStatus: http.StatusBadRequest, wantMsg: "name has the wrong type"},
+		{name: "not an object", contentType: MergePatchMediaType, body: `"Eve"`,
+			wantStatus: http.StatusBadRequest, wantMsg: "request body has the wrong type"},
+		{name: "syntax error", contentType: MergePatchMediaType, body: `{"name":"Eve",}`,
+			wantStatus: http.StatusBadRequest, wantMsg: "invalid JSON at byte 15"},
+		{name: "empty", contentType: MergePatchMediaType, body: ``,
+			wantStatus: http.StatusBadRequest, wantMsg: "request body is empty"},
+		{name: "unknown field rejected", contentType: MergePatchMediaType, body: `{"emial":"x"}`,
+			opts:       []DecodeOption{DisallowUnknownFields()},
+			wantStatus: http.StatusBadRequest, wantMsg: `unknown field "emial"`},
+		{name: "too large", contentType: MergePatchMediaType, body: `{"name":"` + strings.Repeat("a", 64) + `"}`,
+			opts:       []DecodeOption{WithMaxBodyBytes(32)},
+			wantStatus: http.StatusRequestEntityTooLarge, wantMsg: "request body exceeds 32 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/u1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			got, patch, err := DecodeMergePatch(req, original, tt.opts...)
+			if tt.wantStatus != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantStatus, HTTPStatusCode(err))
+				assert.Equal(t, tt.wantMsg, ErrorMessage(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.body, string(patch))
+		})
+	}
+}
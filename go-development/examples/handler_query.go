@@ -0,0 +1,335 @@
+// Package handler provides typed query parameter helpers shared by entity
+// handlers.
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- Single Parameters ----------
+
+// Each helper parses one parameter and returns its zero value (or def)
+// when the parameter is absent or empty. A malformed value returns
+// apperrors.ValidationErrors naming the parameter, so encodeErrorResponse
+// writes 400:
+//
+//	status, err := QueryEnum(r, "status", UserStatusActive, UserStatusPending)
+//	if err != nil {
+//	    encodeErrorResponse(w, err) // {"details": {"status": "status must be one of active, pending"}}
+//	    return
+//	}
+//
+// Use BindQuery to parse several at once and report every bad one.
+
+// QueryTime parses key with layout, e.g. time.DateOnly for
+// ?created_from=2024-01-01.
+func QueryTime(r *http.Request, key, layout string) (time.Time, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return parseTime(key, raw, layout)
+}
+
+// QueryBool parses key as a boolean: 1, t, true, 0, f, false and their
+// upper-case forms. Use r.URL.Query().Has(key) to tell false from absent.
+func QueryBool(r *http.Request, key string) (bool, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return false, nil
+	}
+	return parseBool(key, raw)
+}
+
+// QueryStringSlice returns every value of key, accepting both repeated
+// keys and comma-separated lists: ?status=a,b&status=c gives [a b c].
+// Values are trimmed; an empty item such as the middle of "a,,b" fails.
+func QueryStringSlice(r *http.Request, key string) ([]string, error) {
+	return splitValues(key, r.URL.Query()[key])
+}
+
+// QueryEnum returns key if it is one of allowed.
+func QueryEnum[T ~string](r *http.Request, key string, allowed ...T) (T, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return "", nil
+	}
+	if err := checkEnum(key, raw, enumNames(allowed)); err != nil {
+		return "", err
+	}
+	return T(raw), nil
+}
+
+// QueryEnumSlice is QueryStringSlice with every item checked against
+// allowed, for filters like ?status=active,pending.
+func QueryEnumSlice[T ~string](r *http.Request, key string, allowed ...T) ([]T, error) {
+	items, err := splitValues(key, r.URL.Query()[key])
+	if err != nil || items == nil {
+		return nil, err
+	}
+	names := enumNames(allowed)
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if err := checkEnum(key, item, names); err != nil {
+			return nil, err
+		}
+		result = append(result, T(item))
+	}
+	return result, nil
+}
+
+// QueryIntRange parses key as an integer in [min, max], returning def
+// when it is absent:
+//
+//	limit, err := QueryIntRange(r, "limit", 20, 1, 100)
+func QueryIntRange(r *http.Request, key string, def, min, max int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := parseInt(key, raw, int64(min), int64(max))
+	return int(n), err
+}
+
+// ---------- Struct Binding ----------
+
+// BindQuery fills a new T, which must be a struct, from r's query string.
+// Fields are bound by their query tag; untagged fields are left alone:
+//
+//	type ListUsersQuery struct {
+//	    Status []UserStatus `query:"status" enum:"active,pending,suspended"`
+//	    From   time.Time    `query:"created_from" layout:"2006-01-02"`
+//	    Limit  int          `query:"limit" default:"20" min:"1" max:"100"`
+//	}
+//
+// Supported field types and their options:
+//
+//	string kinds           enum: comma-separated allowed values
+//	slices of string kinds as QueryStringSlice, plus enum
+//	bool                   as QueryBool
+//	int kinds              min, max
+//	time.Time              layout, time.RFC3339 by default
+//
+// default applies when the parameter is absent. Every bad parameter is
+// reported in one apperrors.ValidationErrors; a field of any other type
+// is a programming error and returned as a plain (500) error.
+func BindQuery[T any](r *http.Request) (*T, error) {
+	var dst T
+	rv := reflect.ValueOf(&dst).Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("BindQuery: %T is not a struct", dst)
+	}
+
+	q := r.URL.Query()
+	var verrs apperrors.ValidationErrors
+	for i := range rv.NumField() {
+		f := rv.Type().Field(i)
+		key := f.Tag.Get("query")
+		if key == "" {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("BindQuery: field %s is unexported", f.Name)
+		}
+
+		err := bindField(rv.Field(i), f, key, q)
+		if fieldErrs, ok := err.(apperrors.ValidationErrors); ok {
+			verrs = append(verrs, fieldErrs...)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err := verrs.Err(); err != nil {
+		return nil, err
+	}
+	return &dst, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// bindField sets v from the values of key.
+func bindField(v reflect.Value, f reflect.StructField, key string, q url.Values) error {
+	values := q[key]
+	if !slices.ContainsFunc(values, func(s string) bool { return s != "" }) {
+		values = nil
+		if def, ok := f.Tag.Lookup("default"); ok {
+			values = []string{def}
+		}
+	}
+	var raw string
+	if len(values) > 0 {
+		raw = values[0]
+	}
+
+	var allowed []string
+	if enum := f.Tag.Get("enum"); enum != "" {
+		allowed = strings.Split(enum, ",")
+	}
+
+	switch {
+	case f.Type == timeType:
+		if raw == "" {
+			return nil
+		}
+		layout := f.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := parseTime(key, raw, layout)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+
+	case f.Type.Kind() == reflect.String:
+		if raw == "" {
+			return nil
+		}
+		if allowed != nil {
+			if err := checkEnum(key, raw, allowed); err != nil {
+				return err
+			}
+		}
+		v.SetString(raw)
+
+	case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.String:
+		items, err := splitValues(key, values)
+		if err != nil || items == nil {
+			return err
+		}
+		s := reflect.MakeSlice(f.Type, len(items), len(items))
+		for i, item := range items {
+			if allowed != nil {
+				if err := checkEnum(key, item, allowed); err != nil {
+					return err
+				}
+			}
+			s.Index(i).SetString(item)
+		}
+		v.Set(s)
+
+	case f.Type.Kind() == reflect.Bool:
+		if raw == "" {
+			return nil
+		}
+		b, err := parseBool(key, raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case f.Type.Kind() >= reflect.Int && f.Type.Kind() <= reflect.Int64:
+		if raw == "" {
+			return nil
+		}
+		lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+		for tag, bound := range map[string]*int64{"min": &lo, "max": &hi} {
+			if s, ok := f.Tag.Lookup(tag); ok {
+				n, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					return fmt.Errorf("BindQuery: field %s has invalid %s %q", f.Name, tag, s)
+				}
+				*bound = n
+			}
+		}
+		n, err := parseInt(key, raw, lo, hi)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return queryError(key, "number", key+" is out of range")
+		}
+		v.SetInt(n)
+
+	default:
+		return fmt.Errorf("BindQuery: field %s has unsupported type %s", f.Name, f.Type)
+	}
+	return nil
+}
+
+// ---------- Parsing ----------
+
+// queryError reports one bad parameter as ValidationErrors.
+func queryError(key, rule, message string) error {
+	var verrs apperrors.ValidationErrors
+	verrs.Add(key, rule, message)
+	return verrs
+}
+
+func parseTime(key, raw, layout string) (time.Time, error) {
+	t, err := time.Parse(layout, raw)
+	if err != nil {
+		return time.Time{}, queryError(key, "datetime", key+" must be a time formatted as "+layout)
+	}
+	return t, nil
+}
+
+func parseBool(key, raw string) (bool, error) {
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, queryError(key, "boolean", key+" must be true or false")
+	}
+	return b, nil
+}
+
+func parseInt(key, raw string, min, max int64) (int64, error) {
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, queryError(key, "number", key+" must be an integer")
+	}
+	switch {
+	case n < min && max == math.MaxInt64:
+		return 0, queryError(key, "min", fmt.Sprintf("%s must be at least %d", key, min))
+	case n > max && min == math.MinInt64:
+		return 0, queryError(key, "max", fmt.Sprintf("%s must be at most %d", key, max))
+	case n < min:
+		return 0, queryError(key, "min", fmt.Sprintf("%s must be between %d and %d", key, min, max))
+	case n > max:
+		return 0, queryError(key, "max", fmt.Sprintf("%s must be between %d and %d", key, min, max))
+	}
+	return n, nil
+}
+
+// splitValues flattens repeated and comma-separated values, skipping
+// empty ones (?status=) but not empty items within a list.
+func splitValues(key string, values []string) ([]string, error) {
+	var items []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				return nil, queryError(key, "required", key+" has an empty item")
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func checkEnum(key, value string, allowed []string) error {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return queryError(key, "oneof", key+" must be one of "+strings.Join(allowed, ", "))
+}
+
+func enumNames[T ~string](allowed []T) []string {
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = string(a)
+	}
+	return names
+}
@@ -0,0 +1,364 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- Test Helpers ----------
+
+// requireQueryError asserts err is ValidationErrors with one entry for key.
+func requireQueryError(t *testing.T, err error, key, rule, message string) {
+	t.Helper()
+
+	var verrs apperrors.ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, apperrors.ValidationErrors{{Field: key, Rule: rule, Message: message}}, verrs)
+	assert.Equal(t, 400, apperrors.HTTPStatusCode(err))
+}
+
+// ---------- Single Parameter Tests ----------
+
+func TestQueryTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		layout  string
+		want    time.Time
+		wantErr string
+	}{
+		{name: "date", query: "created_from=2024-01-02", layout: time.DateOnly,
+			want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "rfc3339", query: "created_from=2024-01-02T03:04:05Z", layout: time.RFC3339,
+			want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "absent", query: "", layout: time.DateOnly},
+		{name: "empty", query: "created_from=", layout: time.DateOnly},
+		{name: "wrong layout", query: "created_from=02/01/2024", layout: time.DateOnly,
+			wantErr: "created_from must be a time formatted as 2006-01-02"},
+		{name: "not a date", query: "created_from=2024-13-01", layout: time.DateOnly,
+			wantErr: "created_from must be a time formatted as 2006-01-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := QueryTime(httptest.NewRequest("GET", "/?"+tt.query, nil), "created_from", tt.layout)
+			if tt.wantErr != "" {
+				requireQueryError(t, err, "created_from", "datetime", tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v", got)
+		})
+	}
+}
+
+func TestQueryBool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{query: "archived=true", want: true},
+		{query: "archived=1", want: true},
+		{query: "archived=TRUE", want: true},
+		{query: "archived=false", want: false},
+		{query: "archived=0", want: false},
+		{query: "", want: false},
+		{query: "archived=", want: false},
+		{query: "archived=yes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := QueryBool(httptest.NewRequest("GET", "/?"+tt.query, nil), "archived")
+			if tt.wantErr {
+				requireQueryError(t, err, "archived", "boolean", "archived must be true or false")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryStringSlice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single", query: "tag=a", want: []string{"a"}},
+		{name: "comma separated", query: "tag=a,b,c", want: []string{"a", "b", "c"}},
+		{name: "repeated", query: "tag=a&tag=b", want: []string{"a", "b"}},
+		{name: "both", query: "tag=a,b&tag=c", want: []string{"a", "b", "c"}},
+		{name: "trimmed", query: "tag=a,%20b", want: []string{"a", "b"}},
+		{name: "absent", query: ""},
+		{name: "empty value skipped", query: "tag=&tag=a", want: []string{"a"}},
+		{name: "empty item", query: "tag=a,,b", wantErr: true},
+		{name: "trailing comma", query: "tag=a,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := QueryStringSlice(httptest.NewRequest("GET", "/?"+tt.query, nil), "tag")
+			if tt.wantErr {
+				requireQueryError(t, err, "tag", "required", "tag has an empty item")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryEnum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query   string
+		want    UserStatus
+		wantErr bool
+	}{
+		{query: "status=active", want: UserStatusActive},
+		{query: "status=pending", want: UserStatusPending},
+		{query: "", want: ""},
+		{query: "status=deleted", wantErr: true},
+		{query: "status=Active", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			got, err := QueryEnum(r, "status", UserStatusActive, UserStatusPending)
+			if tt.wantErr {
+				requireQueryError(t, err, "status", "oneof", "status must be one of active, pending")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryEnumSlice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query    string
+		want     []UserStatus
+		wantRule string
+		wantMsg  string
+	}{
+		{query: "status=active,pending", want: []UserStatus{UserStatusActive, UserStatusPending}},
+		{query: "status=active&status=suspended", want: []UserStatus{UserStatusActive, UserStatusSuspended}},
+		{query: "", want: nil},
+		{query: "status=active,deleted", wantRule: "oneof",
+			wantMsg: "status must be one of active, pending, suspended"},
+		{query: "status=active,", wantRule: "required", wantMsg: "status has an empty item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			got, err := QueryEnumSlice(r, "status", UserStatusActive, UserStatusPending, UserStatusSuspended)
+			if tt.wantRule != "" {
+				requireQueryError(t, err, "status", tt.wantRule, tt.wantMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryIntRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query    string
+		want     int
+		wantRule string
+		wantMsg  string
+	}{
+		{query: "limit=50", want: 50},
+		{query: "limit=1", want: 1},
+		{query: "limit=100", want: 100},
+		{query: "", want: 20},
+		{query: "limit=", want: 20},
+		{query: "limit=0", wantRule: "min", wantMsg: "limit must be between 1 and 100"},
+		{query: "limit=101", wantRule: "max", wantMsg: "limit must be between 1 and 100"},
+		{query: "limit=-5", wantRule: "min", wantMsg: "limit must be between 1 and 100"},
+		{query: "limit=ten", wantRule: "number", wantMsg: "limit must be an integer"},
+		{query: "limit=1.5", wantRule: "number", wantMsg: "limit must be an integer"},
+		{query: "limit=99999999999999999999", wantRule: "number", wantMsg: "limit must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := QueryIntRange(httptest.NewRequest("GET", "/?"+tt.query, nil), "limit", 20, 1, 100)
+			if tt.wantRule != "" {
+				requireQueryError(t, err, "limit", tt.wantRule, tt.wantMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// ---------- BindQuery Tests ----------
+
+type searchQuery struct {
+	Status   []UserStatus `query:"status" enum:"active,pending,suspended"`
+	Sort     string       `query:"sort" enum:"name,created_at" default:"created_at"`
+	Search   string       `query:"q"`
+	Tags     []string     `query:"tag"`
+	Archived bool         `query:"archived"`
+	From     time.Time    `query:"created_from" layout:"2006-01-02"`
+	Until    time.Time    `query:"created_until"`
+	Limit    int          `query:"limit" default:"20" min:"1" max:"100"`
+	Offset   int64        `query:"offset" min:"0"`
+	Page     int8         `query:"page" max:"10"`
+	Ignored  string
+}
+
+func TestBindQuery(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/?status=active,pending&status=suspended&sort=name&q=ann"+
+		"&tag=a,b&archived=true&created_from=2024-01-02&created_until=2024-02-03T04:05:06Z"+
+		"&limit=50&offset=10&page=3&Ignored=x", nil)
+
+	got, err := BindQuery[searchQuery](r)
+	require.NoError(t, err)
+
+	assert.Equal(t, &searchQuery{
+		Status:   []UserStatus{UserStatusActive, UserStatusPending, UserStatusSuspended},
+		Sort:     "name",
+		Search:   "ann",
+		Tags:     []string{"a", "b"},
+		Archived: true,
+		From:     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+		Limit:    50,
+		Offset:   10,
+		Page:     3,
+	}, got)
+}
+
+func TestBindQuery_Defaults(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []string{"/", "/?sort=&limit="} {
+		t.Run(target, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := BindQuery[searchQuery](httptest.NewRequest("GET", target, nil))
+			require.NoError(t, err)
+			assert.Equal(t, &searchQuery{Sort: "created_at", Limit: 20}, got)
+		})
+	}
+}
+
+func TestBindQuery_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		want  apperrors.ValidationErrors
+	}{
+		{name: "enum slice", query: "status=active,deleted",
+			want: apperrors.ValidationErrors{{Field: "status", Rule: "oneof", Message: "status must be one of active, pending, suspended"}}},
+		{name: "enum", query: "sort=email",
+			want: apperrors.ValidationErrors{{Field: "sort", Rule: "oneof", Message: "sort must be one of name, created_at"}}},
+		{name: "empty item", query: "tag=a,,b",
+			want: apperrors.ValidationErrors{{Field: "tag", Rule: "required", Message: "tag has an empty item"}}},
+		{name: "bool", query: "archived=maybe",
+			want: apperrors.ValidationErrors{{Field: "archived", Rule: "boolean", Message: "archived must be true or false"}}},
+		{name: "time layout", query: "created_from=2024-01-02T00:00:00Z",
+			want: apperrors.ValidationErrors{{Field: "created_from", Rule: "datetime", Message: "created_from must be a time formatted as 2006-01-02"}}},
+		{name: "default time layout", query: "created_until=2024-01-02",
+			want: apperrors.ValidationErrors{{Field: "created_until", Rule: "datetime", Message: "created_until must be a time formatted as " + time.RFC3339}}},
+		{name: "int", query: "limit=all",
+			want: apperrors.ValidationErrors{{Field: "limit", Rule: "number", Message: "limit must be an integer"}}},
+		{name: "range", query: "limit=500",
+			want: apperrors.ValidationErrors{{Field: "limit", Rule: "max", Message: "limit must be between 1 and 100"}}},
+		{name: "min only", query: "offset=-1",
+			want: apperrors.ValidationErrors{{Field: "offset", Rule: "min", Message: "offset must be at least 0"}}},
+		{name: "max only", query: "page=11",
+			want: apperrors.ValidationErrors{{Field: "page", Rule: "max", Message: "page must be at most 10"}}},
+		{name: "overflows field", query: "page=-200",
+			want: apperrors.ValidationErrors{{Field: "page", Rule: "number", Message: "page is out of range"}}},
+		{name: "every bad parameter", query: "status=gone&limit=0&archived=x",
+			want: apperrors.ValidationErrors{
+				{Field: "status", Rule: "oneof", Message: "status must be one of active, pending, suspended"},
+				{Field: "archived", Rule: "boolean", Message: "archived must be true or false"},
+				{Field: "limit", Rule: "min", Message: "limit must be between 1 and 100"},
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := BindQuery[searchQuery](httptest.NewRequest("GET", "/?"+tt.query, nil))
+			assert.Nil(t, got)
+
+			var verrs apperrors.ValidationErrors
+			require.ErrorAs(t, err, &verrs)
+			assert.Equal(t, tt.want, verrs)
+			assert.Equal(t, apperrors.CodeInvalid, apperrors.GetErrorCode(err))
+		})
+	}
+}
+
+func TestBindQuery_ProgrammingErrors(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/?n=1", nil)
+
+	_, err := BindQuery[struct {
+		N float64 `query:"n"`
+	}](r)
+	assert.EqualError(t, err, "BindQuery: field N has unsupported type float64")
+	assert.True(t, apperrors.IsInternal(err))
+
+	_, err = BindQuery[struct {
+		N int `query:"n" min:"one"`
+	}](r)
+	assert.EqualError(t, err, `BindQuery: field N has invalid min "one"`)
+
+	_, err = BindQuery[struct {
+		n string `query:"n"`
+	}](r)
+	assert.EqualError(t, err, "BindQuery: field n is unexported")
+
+	_, err = BindQuery[string](r)
+	assert.EqualError(t, err, "BindQuery: string is not a struct")
+}
@@ -0,0 +1,92 @@
+// Package handler provides the router's 404, 405 and OPTIONS responses,
+// in the same JSON envelope as handler errors.
+package handler
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// routeMethods are the methods tried when computing Allow, in the order
+// they are listed.
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// notFound replaces chi's plain-text "404 page not found".
+func notFound(w http.ResponseWriter, r *http.Request) {
+	encodeRouteError(w, r, NewNotFoundError("no route for "+r.URL.Path))
+}
+
+// methodNotAllowed replaces chi's empty 405 with the envelope and an
+// Allow header listing the methods the path has. OPTIONS, having no route
+// of its own, gets 204 with Allow instead (RFC 9110, section 9.3.7).
+//
+// CORS preflights never get here: the CORS middleware, in router.Use,
+// answers them before routing.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	allow := strings.Join(allowedMethods(r), ", ")
+	w.Header().Set("Allow", allow)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	encodeRouteError(w, r, NewMethodNotAllowedError(r.Method, allow))
+}
+
+// allowedMethods returns the methods routed for r's path. HEAD comes with
+// GET (see middleware.GetHead) and OPTIONS with anything.
+//
+// rctx.Routes is the top-level router even inside r.Route, and its Match
+// descends into subrouters, so the full path is matched, not RoutePath.
+func allowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+	path := cmp.Or(r.URL.RawPath, r.URL.Path)
+
+	var allowed []string
+	get := false
+	for _, m := range routeMethods {
+		matched := rctx.Routes.Match(chi.NewRouteContext(), m, path)
+		switch m {
+		case http.MethodGet:
+			get = matched
+		case http.MethodHead:
+			matched = matched || get
+		case http.MethodOptions:
+			matched = len(allowed) > 0
+		}
+		if matched {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+// encodeRouteError writes err as encodeErrorResponse does, with the
+// request ID, since these responses come from no handler a client could
+// name.
+func encodeRouteError(w http.ResponseWriter, r *http.Request, err error) {
+	status, resp := newErrorResponse(err)
+	resp.RequestID = middleware.GetReqID(r.Context())
+	encodeJSONResponse(w, r, status, resp)
+}
+
+// NewMethodNotAllowedError creates a 405 Method Not Allowed error naming
+// the methods the path accepts.
+func NewMethodNotAllowedError(method, allow string) error {
+	return &HandlerError{
+		Status:  http.StatusMethodNotAllowed,
+		Code:    "method_not_allowed",
+		Message: fmt.Sprintf("method %s not allowed; use %s", method, allow),
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Router Error Tests ----------
+
+func TestNewRouter_RoutingErrors(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(NewUserHandler(stubUserService{}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantAllow  string
+	}{
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			path:       "/nope",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			name:       "unknown path under prefix",
+			method:     http.MethodGet,
+			path:       PathPrefix + "/nope",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			name:       "wrong method on users",
+			method:     http.MethodDelete,
+			path:       PathPrefix + UsersPath,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "method_not_allowed",
+			wantAllow:  "GET, HEAD, POST, OPTIONS",
+		},
+		{
+			name:       "wrong method on user",
+			method:     http.MethodPost,
+			path:       PathPrefix + "/users/u1",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "method_not_allowed",
+			wantAllow:  "GET, HEAD, PUT, PATCH, DELETE, OPTIONS",
+		},
+		{
+			name:       "wrong method on top-level route",
+			method:     http.MethodPost,
+			path:       "/health",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "method_not_allowed",
+			wantAllow:  "GET, HEAD, OPTIONS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantAllow, rec.Header().Get("Allow"))
+
+			var resp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.NotEmpty(t, resp.Error)
+			assert.NotEmpty(t, resp.RequestID, "set by middleware.RequestID")
+		})
+	}
+}
+
+func TestNewRouter_Options(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(NewUserHandler(stubUserService{}))
+
+	req := httptest.NewRequest(http.MethodOptions, PathPrefix+UsersPath, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, HEAD, POST, OPTIONS", rec.Header().Get("Allow"))
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestNewRouter_Head(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(NewUserHandler(stubUserService{}))
+
+	req := httptest.NewRequest(http.MethodHead, "/health", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, "served by the GET handler")
+	assert.Empty(t, rec.Header().Get("Allow"))
+}
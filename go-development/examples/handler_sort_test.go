@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+	"myapp/internal/pagination"
+)
+
+var testSortFields = map[string]string{
+	"name":       "u.name",
+	"created_at": "u.created_at",
+	"id":         "u.id",
+}
+
+// ---------- ParseSort Tests ----------
+
+func TestParseSort(t *testing.T) {
+	t.Parallel()
+
+	newest := SortField{Name: "created_at", Desc: true}
+
+	tests := []struct {
+		name     string
+		query    string
+		defaults []SortField
+		want     []SortField
+		wantMsg  string // "" = no error
+	}{
+		{name: "default ordering", query: "", defaults: []SortField{newest},
+			want: []SortField{{Name: "created_at", Column: "u.created_at", Desc: true}}},
+		{name: "no defaults", query: "", want: nil},
+		{name: "ascending", query: "?sort=name", defaults: []SortField{newest},
+			want: []SortField{{Name: "name", Column: "u.name"}}},
+		{name: "multi-field", query: "?sort=-created_at,name",
+			want: []SortField{
+				{Name: "created_at", Column: "u.created_at", Desc: true},
+				{Name: "name", Column: "u.name"},
+			}},
+		{name: "repeated key", query: "?sort=name&sort=-id",
+			want: []SortField{{Name: "name", Column: "u.name"}, {Name: "id", Column: "u.id", Desc: true}}},
+		{name: "invalid field", query: "?sort=password",
+			wantMsg: `unknown sort field "password"; sort by created_at, id, name`},
+		{name: "too many fields", query: "?sort=-password,name,secret",
+			wantMsg: `sort accepts at most 2 fields`},
+		{name: "two invalid fields", query: "?sort=-password,secret",
+			wantMsg: `unknown sort field "password", "secret"; sort by created_at, id, name`},
+		{name: "column expression is not a field", query: "?sort=u.name",
+			wantMsg: `unknown sort field "u.name"; sort by created_at, id, name`},
+		{name: "injection", query: "?sort=name%3BDROP%20TABLE%20users",
+			wantMsg: `unknown sort field "name;DROP TABLE users"; sort by created_at, id, name`},
+		{name: "duplicate", query: "?sort=name,-name", wantMsg: "sort field name given twice"},
+		{name: "bare minus", query: "?sort=-", wantMsg: `unknown sort field ""; sort by created_at, id, name`},
+		{name: "empty item", query: "?sort=name,,id", wantMsg: "sort has an empty item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil)
+			got, err := ParseSort(r, testSortFields, tt.defaults...)
+			if tt.wantMsg != "" {
+				var verrs apperrors.ValidationErrors
+				require.ErrorAs(t, err, &verrs)
+				assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+				assert.Equal(t, map[string]string{"sort": tt.wantMsg}, verrs.ToDetails())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSort_DefaultNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	_, err := ParseSort(r, testSortFields, SortField{Name: "score"})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatusCode(err), "a programming error")
+}
+
+// ---------- Conversion Tests ----------
+
+func TestSortColumns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		fields []SortField
+		want   []pagination.Column
+	}{
+		{name: "tie-breaker follows the last order",
+			fields: []SortField{{Column: "u.created_at", Desc: true}},
+			want:   []pagination.Column{pagination.Col("u.created_at", pagination.Desc), pagination.Col("u.id", pagination.Desc)}},
+		{name: "mixed orders",
+			fields: []SortField{{Column: "u.created_at", Desc: true}, {Column: "u.name"}},
+			want: []pagination.Column{pagination.Col("u.created_at", pagination.Desc),
+				pagination.Col("u.name", pagination.Asc), pagination.Col("u.id", pagination.Asc)}},
+		{name: "already sorted on the tie-breaker",
+			fields: []SortField{{Column: "u.id", Desc: true}},
+			want:   []pagination.Column{pagination.Col("u.id", pagination.Desc)}},
+		{name: "no fields", fields: nil,
+			want: []pagination.Column{pagination.Col("u.id", pagination.Asc)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, SortColumns(tt.fields, "u.id"))
+		})
+	}
+}
+
+func TestSortOrderBy_MatchesKeyset(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/users?sort=-created_at,name", nil)
+	fields, err := ParseSort(r, testSortFields)
+	require.NoError(t, err)
+
+	orderBy := SortOrderBy(fields, "u.id")
+	assert.Equal(t, []string{"u.created_at DESC", "u.name ASC", "u.id ASC"}, orderBy)
+
+	ks := pagination.NewKeyset(SortColumns(fields, "u.id")...)
+	sql, args, err := sq.Select("*").From("users u").
+		Where(ks.Where("2026-01-01", "Ann", "u1")).
+		OrderBy(orderBy...).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users u WHERE (u.created_at < $1 OR (u.created_at = $2 AND u.name > $3) OR "+
+		"(u.created_at = $4 AND u.name = $5 AND u.id > $6)) ORDER BY u.created_at DESC, u.name ASC, u.id ASC", sql)
+	assert.Len(t, args, 6)
+}
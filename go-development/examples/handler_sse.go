@@ -0,0 +1,229 @@
+// Package handler provides a Server-Sent Events writer for streaming
+// endpoints.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myapp/internal/worker"
+)
+
+// DefaultSSEKeepAlive is a keep-alive interval below the idle timeouts
+// of common proxies and load balancers (often 30-60s).
+const DefaultSSEKeepAlive = 15 * time.Second
+
+var (
+	// ErrSSEUnsupported is returned by NewSSEWriter when no writer in the
+	// chain can flush, e.g. behind a middleware that buffers responses.
+	ErrSSEUnsupported = errors.New("sse: response writer does not support flushing")
+
+	// ErrSSEClosed is returned by Send after Close.
+	ErrSSEClosed = errors.New("sse: writer closed")
+)
+
+// SSEWriter writes a text/event-stream response:
+//
+//	sse, err := NewSSEWriter(w)
+//	if err != nil {
+//	    encodeErrorResponse(w, err)
+//	    return
+//	}
+//	defer sse.Close()
+//	sse.KeepAlive(r.Context(), DefaultSSEKeepAlive)
+//
+//	for {
+//	    select {
+//	    case <-r.Context().Done():
+//	        return // the client is gone
+//	    case p := <-progress:
+//	        if err := sse.Send("progress", "", p); err != nil {
+//	            return
+//	        }
+//	    }
+//	}
+//
+// Every event is flushed as it is sent. It is safe for concurrent use.
+type SSEWriter struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	closed bool
+
+	stop chan struct{} // closed by Close
+	done sync.WaitGroup
+}
+
+// NewSSEWriter starts an event stream on w: it sets the SSE headers,
+// writes 200 and flushes, so clients see the stream open at once. It
+// returns ErrSSEUnsupported, having written nothing, if w can't flush;
+// middleware wrappers are unwrapped as http.ResponseController does.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	if !canFlush(w) {
+		return nil, ErrSSEUnsupported
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // nginx: don't buffer the stream
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	s := &SSEWriter{w: w, rc: http.NewResponseController(w), stop: make(chan struct{})}
+	if err := s.rc.Flush(); err != nil {
+		return nil, fmt.Errorf("sse: flush: %w", err)
+	}
+	return s, nil
+}
+
+// canFlush reports whether w, or a writer it unwraps to, is an
+// http.Flusher.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		if _, ok := w.(http.Flusher); ok {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Send writes one event with data as JSON and flushes it:
+//
+//	event: progress
+//	id: 42
+//	data: {"done":3,"total":10}
+//
+// An empty event or id leaves its line out; browsers then dispatch a
+// "message" event. The error is the write's once the client is gone.
+func (s *SSEWriter) Send(event, id string, data any) error {
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n") {
+		return errors.New("sse: event and id must be a single line")
+	}
+	payload, err := json.Marshal(data) // escapes newlines, so one data line
+	if err != nil {
+		return fmt.Errorf("sse: encode %s: %w", event, err)
+	}
+
+	var b strings.Builder
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("data: ")
+	b.Write(payload)
+	b.WriteString("\n\n")
+	return s.write(b.String())
+}
+
+// Comment writes a comment line, which clients ignore.
+func (s *SSEWriter) Comment(text string) error {
+	if strings.ContainsAny(text, "\r\n") {
+		return errors.New("sse: comment must be a single line")
+	}
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *SSEWriter) write(chunk string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSSEClosed
+	}
+	if _, err := s.w.Write([]byte(chunk)); err != nil {
+		return fmt.Errorf("sse: write: %w", err)
+	}
+	if err := s.rc.Flush(); err != nil {
+		return fmt.Errorf("sse: flush: %w", err)
+	}
+	return nil
+}
+
+// KeepAlive writes a ": keep-alive" comment every interval in the
+// background, so proxies don't close a quiet stream, until ctx is done,
+// Close is called or a write fails. Pass r.Context() as ctx.
+func (s *SSEWriter) KeepAlive(ctx context.Context, interval time.Duration) {
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := s.Comment("keep-alive"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close stops KeepAlive and waits for it, so nothing writes to the
+// response after the handler returns. Send fails after Close.
+func (s *SSEWriter) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	s.done.Wait()
+}
+
+// ---------- Example: Worker Pool Status ----------
+
+// WorkerStatusStream streams the pool's status as a "status" event every
+// interval until the client disconnects:
+//
+//	router.Get("/admin/workers/stream", WorkerStatusStream(pool, time.Second))
+//
+// Mount it next to worker.AdminHandler, and outside the TimeoutJSON
+// middleware, whose buffered writer can't stream.
+func WorkerStatusStream(pool worker.Controller, interval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		sse, err := NewSSEWriter(w)
+		if err != nil {
+			encodeErrorResponse(w, err)
+			return
+		}
+		defer sse.Close()
+		sse.KeepAlive(ctx, DefaultSSEKeepAlive)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for seq := 1; ; seq++ {
+			if err := sse.Send("status", strconv.Itoa(seq), pool.Status()); err != nil {
+				return // the client is gone
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
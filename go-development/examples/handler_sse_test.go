@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/worker"
+)
+
+// ---------- Test Helpers ----------
+
+// sseEvent is one parsed event, or a comment if Comment is set.
+type sseEvent struct {
+	Event   string
+	ID      string
+	Data    string
+	Comment string
+}
+
+// readEvent reads the next event or comment from an event stream, as a
+// browser's EventSource would, ignoring fields it doesn't know.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return ev // blank line: dispatch
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			ev.Comment = value
+		case "event":
+			ev.Event = value
+		case "id":
+			ev.ID = value
+		case "data":
+			ev.Data = value
+		}
+	}
+}
+
+// noFlushWriter implements http.ResponseWriter and nothing else, like a
+// buffering middleware's writer.
+type noFlushWriter struct {
+	header http.Header
+}
+
+func (w *noFlushWriter) Header() http.Header         { return w.header }
+func (w *noFlushWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *noFlushWriter) WriteHeader(int)             {}
+
+// unwrapWriter hides the recorder's Flush behind Unwrap.
+type unwrapWriter struct {
+	noFlushWriter
+	rec *httptest.ResponseRecorder
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter { return w.rec }
+
+type stubPool struct {
+	worker.Controller
+}
+
+func (stubPool) Status() []worker.WorkerStatus {
+	return []worker.WorkerStatus{{Name: "w1", State: worker.StateIdle, Processed: 7}}
+}
+
+// ---------- SSEWriter Tests ----------
+
+func TestNewSSEWriter(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Length", "10")
+
+	sse, err := NewSSEWriter(rec)
+	require.NoError(t, err)
+	defer sse.Close()
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Empty(t, rec.Header().Get("Content-Length"))
+	assert.True(t, rec.Flushed, "headers are flushed at once")
+}
+
+func TestNewSSEWriter_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	w := &noFlushWriter{header: http.Header{}}
+	_, err := NewSSEWriter(w)
+	require.ErrorIs(t, err, ErrSSEUnsupported)
+	assert.Empty(t, w.Header().Get("Content-Type"), "nothing written")
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatusCode(err))
+}
+
+func TestNewSSEWriter_Unwrap(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	sse, err := NewSSEWriter(&unwrapWriter{noFlushWriter: noFlushWriter{header: rec.Header()}, rec: rec})
+	require.NoError(t, err)
+	defer sse.Close()
+
+	assert.True(t, rec.Flushed)
+}
+
+func TestSSEWriter_Send(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	sse, err := NewSSEWriter(rec)
+	require.NoError(t, err)
+
+	require.NoError(t, sse.Send("progress", "1", map[string]any{"done": 3, "note": "a\nb"}))
+	require.NoError(t, sse.Send("", "", "hi"))
+	require.NoError(t, sse.Comment("ping"))
+
+	assert.Error(t, sse.Send("bad\nevent", "", nil))
+	assert.Error(t, sse.Send("progress", "1\r", nil))
+	assert.Error(t, sse.Send("progress", "", make(chan int)))
+	assert.Error(t, sse.Comment("two\nlines"))
+
+	sse.Close()
+	assert.ErrorIs(t, sse.Send("progress", "", nil), ErrSSEClosed)
+	sse.Close() // idempotent
+
+	r := bufio.NewReader(rec.Body)
+	assert.Equal(t, sseEvent{Event: "progress", ID: "1", Data: `{"done":3,"note":"a\nb"}`}, readEvent(t, r))
+	assert.Equal(t, sseEvent{Data: `"hi"`}, readEvent(t, r))
+	assert.Equal(t, sseEvent{Comment: "ping"}, readEvent(t, r))
+	_, err = r.ReadByte()
+	assert.ErrorIs(t, err, io.EOF, "failed sends wrote nothing")
+}
+
+func TestSSEWriter_KeepAlive(t *testing.T) {
+	t.Parallel()
+
+	returned := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(returned)
+
+		sse, err := NewSSEWriter(w)
+		if err != nil {
+			return
+		}
+		defer sse.Close()
+		sse.KeepAlive(r.Context(), 10*time.Millisecond)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	assert.Equal(t, sseEvent{Comment: "keep-alive"}, readEvent(t, r))
+	assert.Equal(t, sseEvent{Comment: "keep-alive"}, readEvent(t, r))
+
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still running after the client disconnected")
+	}
+}
+
+// ---------- WorkerStatusStream Tests ----------
+
+func TestWorkerStatusStream(t *testing.T) {
+	t.Parallel()
+
+	returned := make(chan struct{})
+	stream := WorkerStatusStream(stubPool{}, 10*time.Millisecond)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(returned)
+		stream(w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(resp.Body)
+	for _, wantID := range []string{"1", "2"} {
+		ev := readEvent(t, r)
+		assert.Equal(t, "status", ev.Event)
+		assert.Equal(t, wantID, ev.ID)
+
+		var statuses []worker.WorkerStatus
+		require.NoError(t, json.Unmarshal([]byte(ev.Data), &statuses))
+		require.Len(t, statuses, 1)
+		assert.Equal(t, "w1", statuses[0].Name)
+		assert.EqualValues(t, 7, statuses[0].Processed)
+	}
+
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still running after the client disconnected")
+	}
+}
+
+func TestWorkerStatusStream_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	w := &noFlushWriter{header: http.Header{}}
+	WorkerStatusStream(stubPool{}, time.Second)(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"), "error response instead of a stream")
+}
@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+	"myapp/internal/errs"
+	"myapp/internal/pagination"
+)
+
+// ---------- Test Helpers ----------
+
+// stubUserService echoes its input; only Create is used.
+type stubUserService struct {
+	UserService
+}
+
+func (stubUserService) Create(_ context.Context, name, email string) (*User, error) {
+	return &User{ID: "u1", Name: name, Email: email, CreatedAt: time.Unix(0, 0)}, nil
+}
+
+// errUserService fails GetByID with err.
+type errUserService struct {
+	UserService
+	err error
+}
+
+func (s errUserService) GetByID(context.Context, string) (*User, error) {
+	return nil, s.err
+}
+
+// listUserService records the filter List was called with.
+type listUserService struct {
+	UserService
+	filter *UserFilter
+}
+
+func (s listUserService) List(_ context.Context, filter UserFilter) ([]*User, int64, error) {
+	*s.filter = filter
+	return []*User{{ID: "u1", Name: "Ann", Status: UserStatusActive, CreatedAt: time.Unix(0, 0)}}, 1, nil
+}
+
+// ---------- decodeJSON Tests ----------
+
+func TestDecodeJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		opts       decodeOptions
+		limit      int64
+		wantStatus int // 0 = no error
+		wantMsg    string
+	}{
+		{name: "valid", body: `{"name":"Ann","email":"ann@example.com"}`},
+		{name: "trailing whitespace", body: `{"name":"Ann"}` + "\n"},
+		{name: "unknown field ignored", body: `{"name":"Ann","emial":"x"}`},
+		{name: "unknown field rejected", body: `{"name":"Ann","emial":"x"}`,
+			opts: decodeOptions{disallowUnknownFields: true}, wantStatus: 400, wantMsg: `unknown field "emial"`},
+		{name: "empty", body: ``, wantStatus: 400, wantMsg: "request body is empty"},
+		{name: "malformed", body: `{"name":`, wantStatus: 400, wantMsg: "invalid JSON"},
+		{name: "syntax error", body: `{"name":"Ann",}`, wantStatus: 400, wantMsg: "invalid JSON at byte 15"},
+		{name: "wrong type", body: `{"name":42}`, wantStatus: 400, wantMsg: "name has the wrong type"},
+		{name: "two values", body: `{"name":"Ann"}{"name":"Bob"}`, wantStatus: 400,
+			wantMsg: "request body must contain a single JSON value"},
+		{name: "over limit", body: `{"name":"` + strings.Repeat("a", 100) + `"}`, limit: 64,
+			wantStatus: 413, wantMsg: "request body exceeds 64 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			if tt.limit > 0 {
+				req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, tt.limit)
+			}
+
+			var dst CreateUserRequest
+			err := decodeJSON(req.Body, &dst, tt.opts)
+
+			if tt.wantStatus == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, "Ann", dst.Name)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(err))
+			assert.Equal(t, tt.wantMsg, ErrorMessage(err))
+		})
+	}
+}
+
+// ---------- UserHandler Tests ----------
+
+func TestUserHandler_CreateDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []HandlerOption
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "created", body: `{"name":"Ann","email":"ann@example.com"}`, wantStatus: http.StatusCreated},
+		{name: "unknown field allowed by default", body: `{"name":"Ann","email":"ann@example.com","admin":true}`,
+			wantStatus: http.StatusCreated},
+		{name: "unknown field rejected", opts: []HandlerOption{WithDisallowUnknownFields()},
+			body: `{"name":"Ann","email":"ann@example.com","admin":true}`, wantStatus: http.StatusBadRequest,
+			wantCode: "invalid"},
+		{name: "too large", body: `{"name":"` + strings.Repeat("a", 2048) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge, wantCode: "request_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := NewUserHandler(stubUserService{}, tt.opts...)
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			req.Body = http.MaxBytesReader(rec, req.Body, 1024)
+			h.Create(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode == "" {
+				return
+			}
+			var resp ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
+
+func TestUserHandler_CreateValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	h := NewUserHandler(stubUserService{})
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"A","email":"not-an-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.Create(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp struct {
+		Error   string            `json:"error"`
+		Code    string            `json:"code"`
+		Details map[string]string `json:"details"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "validation failed", resp.Error)
+	assert.Equal(t, "invalid", resp.Code)
+	assert.Equal(t, map[string]string{
+		"name":  "name is too short",
+		"email": "invalid email format",
+	}, resp.Details, "every failed field, by JSON name")
+}
+
+func TestUserHandler_GetByIDErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "errs sentinel", err: errs.NotFoundf("UserRepo.FindByID", "userID=%s", "42"),
+			wantStatus: http.StatusNotFound, wantBody: `{"error":"resource not found","code":"not_found"}`},
+		{name: "coded error", err: fmt.Errorf("UserService.GetByID: %w", apperrors.NotFoundf("UserRepo.FindByID", "user not found")),
+			wantStatus: http.StatusNotFound, wantBody: `{"error":"user not found","code":"not_found"}`},
+		{name: "coded forbidden", err: apperrors.New(apperrors.CodeForbidden, "admins only"),
+			wantStatus: http.StatusForbidden, wantBody: `{"error":"admins only","code":"forbidden"}`},
+		{name: "constructor", err: NewNotFoundError("user not found"),
+			wantStatus: http.StatusNotFound, wantBody: `{"error":"user not found","code":"not_found"}`},
+		{name: "HandlerError", err: &HandlerError{Status: http.StatusTeapot, Code: "teapot", Message: "short and stout"},
+			wantStatus: http.StatusTeapot, wantBody: `{"error":"short and stout","code":"teapot"}`},
+		{name: "HandlerError wrapped", err: fmt.Errorf("UserHandler.GetByID: %w", NewVersionConflictError()),
+			wantStatus: http.StatusPreconditionFailed, wantBody: `{"error":"resource has changed since it was read","code":"version_conflict"}`},
+		{name: "coded error wrapping HandlerError", err: &apperrors.Error{Code: apperrors.CodeConflict, Message: "user changed", Err: NewVersionConflictError()},
+			wantStatus: http.StatusConflict, wantBody: `{"error":"user changed","code":"conflict"}`},
+		{name: "deadline", err: fmt.Errorf("UserRepo.FindByID: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout, wantBody: `{"error":"request timed out","code":"timeout"}`},
+		{name: "canceled", err: fmt.Errorf("UserRepo.FindByID: %w", context.Canceled),
+			wantStatus: apperrors.StatusClientClosedRequest, wantBody: ``},
+		{name: "uncoded", err: errors.New("pq: connection reset"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"an internal error has occurred","code":"internal"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := chi.NewRouter()
+			r.Get(UserByIDPath, NewUserHandler(errUserService{err: tt.err}).GetByID)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody == "" {
+				assert.Empty(t, rec.Body.String())
+				return
+			}
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_List(t *testing.T) {
+	t.Parallel()
+
+	newestFirst := []pagination.Column{pagination.Col("created_at", pagination.Desc), pagination.Col("id", pagination.Desc)}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFilter UserFilter
+		wantBody   string
+	}{
+		{name: "defaults", query: "",
+			wantStatus: http.StatusOK, wantFilter: UserFilter{Sort: newestFirst, Limit: 20}},
+		{name: "status filter", query: "?status=active,pending&limit=5&offset=10",
+			wantStatus: http.StatusOK,
+			wantFilter: UserFilter{Statuses: []UserStatus{UserStatusActive, UserStatusPending}, Sort: newestFirst,
+				Limit: 5, Offset: 10}},
+		{name: "repeated status", query: "?status=active&status=suspended",
+			wantStatus: http.StatusOK,
+			wantFilter: UserFilter{Statuses: []UserStatus{UserStatusActive, UserStatusSuspended}, Sort: newestFirst,
+				Limit: 20}},
+		{name: "sort", query: "?sort=-created_at,name",
+			wantStatus: http.StatusOK,
+			wantFilter: UserFilter{Sort: []pagination.Column{pagination.Col("created_at", pagination.Desc),
+				pagination.Col("name", pagination.Asc), pagination.Col("id", pagination.Asc)}, Limit: 20}},
+		{name: "unknown sort field", query: "?sort=password",
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"validation failed","code":"invalid",` +
+				`"details":{"sort":"unknown sort field \"password\"; sort by created_at, email, name"}}`},
+		{name: "unknown status", query: "?status=deleted",
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"validation failed","code":"invalid",` +
+				`"details":{"status":"status must be one of active, pending, suspended"}}`},
+		{name: "every bad parameter", query: "?status=deleted&limit=abc&offset=-1",
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"validation failed","code":"invalid","details":{` +
+				`"status":"status must be one of active, pending, suspended",` +
+				`"limit":"limit must be an integer","offset":"offset must be at least 0"}}`},
+		{name: "limit over max", query: "?limit=1000",
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"validation failed","code":"invalid",` +
+				`"details":{"limit":"limit must be between 1 and 100"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var filter UserFilter
+			h := NewUserHandler(listUserService{filter: &filter})
+			rec := httptest.NewRecorder()
+			h.List(rec, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+				return
+			}
+			assert.Equal(t, tt.wantFilter, filter)
+
+			var resp ListResponse[UserResponse]
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.wantFilter.Limit, resp.Limit)
+			assert.Equal(t, tt.wantFilter.Offset, resp.Offset)
+			require.Len(t, resp.Items, 1)
+			assert.Equal(t, "active", resp.Items[0].Status)
+		})
+	}
+}
+
+// patchUserService serves Ann and records the patch UpdatePartial got.
+type patchUserService struct {
+	UserService
+	patch *UserPatch
+}
+
+func (patchUserService) GetByID(_ context.Context, id string) (*User, error) {
+	return &User{ID: id, Name: "Ann", Email: "ann@example.com", CreatedAt: time.Unix(0, 0)}, nil
+}
+
+func (s patchUserService) UpdatePartial(_ context.Context, id string, _ int64, patch UserPatch) (*User, error) {
+	*s.patch = patch
+	u := &User{ID: id, Name: "Ann", Email: "ann@example.com", CreatedAt: time.Unix(0, 0)}
+	if patch.Name.Set {
+		u.Name = patch.Name.Value
+	}
+	if patch.Email.Set {
+		u.Email = patch.Email.Value
+	}
+	return u, nil
+}
+
+func TestUserHandler_Patch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantPatch   UserPatch
+		wantBody    string
+	}{
+		{name: "absent field unchanged", contentType: MergePatchMediaType, body: `{"name":"Eve"}`,
+			wantStatus: http.StatusOK, wantPatch: UserPatch{Name: Some("Eve")},
+			wantBody: `{"id":"u1","name":"Eve","email":"ann@example.com","created_at":"1970-01-01T00:00:00Z"}`},
+		{name: "empty patch", contentType: MergePatchMediaType, body: `{}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":"u1","name":"Ann","email":"ann@example.com","created_at":"1970-01-01T00:00:00Z"}`},
+		{name: "plain json", contentType: "application/json", body: `{"email":"eve@example.com"}`,
+			wantStatus: http.StatusOK, wantPatch: UserPatch{Email: Some("eve@example.com")},
+			wantBody: `{"id":"u1","name":"Ann","email":"eve@example.com","created_at":"1970-01-01T00:00:00Z"}`},
+		{name: "null field clears and fails validation", contentType: MergePatchMediaType, body: `{"email":null}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"validation failed","code":"invalid","details":{"email":"email is required"}}`},
+		{name: "invalid value", contentType: MergePatchMediaType, body: `{"name":"E","email":"nope"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"validation failed","code":"invalid",` +
+				`"details":{"name":"name is too short","email":"invalid email format"}}`},
+		{name: "invalid type", contentType: MergePatchMediaType, body: `{"name":42}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"name has the wrong type","code":"invalid"}`},
+		{name: "wrong content type", contentType: "text/plain", body: `{"name":"Eve"}`,
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantBody:   `{"error":"Content-Type must be application/merge-patch+json","code":"unsupported_media_type"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var patch UserPatch
+			router := NewRouter(NewUserHandler(patchUserService{patch: &patch}))
+
+			req := httptest.NewRequest(http.MethodPatch, UserByIDURL("u1"), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, tt.wantPatch, patch)
+		})
+	}
+}
+
+// ---------- Validation Error Tests ----------
+
+func TestNewValidationError(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name  string `json:"name" validate:"required"`
+		Email string `json:"email" validate:"required,email"`
+		Bio   string `json:"bio,omitempty" validate:"max=3"`
+		Extra string `json:"-" validate:"required"`
+	}
+
+	err := NewValidationError(newValidator().Struct(request{Email: "x", Bio: "long"}))
+
+	var verrs apperrors.ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, apperrors.ValidationErrors{
+		{Field: "name", Rule: "required", Message: "name is required"},
+		{Field: "email", Rule: "email", Message: "invalid email format"},
+		{Field: "bio", Rule: "max", Message: "bio is too long"},
+		{Field: "Extra", Rule: "required", Message: "Extra is required"},
+	}, verrs)
+	assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+	assert.Equal(t, "invalid", GetErrorCode(err))
+	assert.Equal(t, "validation failed", ErrorMessage(err))
+
+	wrapped := fmt.Errorf("decode: %w", err)
+	assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(wrapped))
+}
+
+func TestNewValidationError_NotValidatorError(t *testing.T) {
+	t.Parallel()
+
+	err := NewValidationError(errors.New("unexpected"))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatusCode(err))
+	assert.Equal(t, "validation failed", ErrorMessage(err))
+}
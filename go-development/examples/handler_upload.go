@@ -0,0 +1,329 @@
+// Package handler provides multipart file uploads, checked for size and
+// type while they stream, and the BlobStore they are put in.
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DefaultMaxUploadBytes caps a file read by DecodeFileUpload.
+const DefaultMaxUploadBytes = 5 << 20 // 5 MB
+
+const (
+	// maxUploadOverhead is what the rest of a multipart body (boundaries,
+	// part headers, small fields) may add to the file.
+	maxUploadOverhead = 64 << 10
+
+	// sniffLen is how much http.DetectContentType looks at.
+	sniffLen = 512
+
+	maxFilenameBytes = 255
+)
+
+// UploadOpts configures DecodeFileUpload.
+type UploadOpts struct {
+	MaxBytes     int64    // file size cap; DefaultMaxUploadBytes if 0
+	AllowedTypes []string // sniffed media types accepted, e.g. "image/png"; required
+}
+
+// Upload is a file being read from a multipart request. It is an
+// io.Reader over the file's content, failing with a 413 error once the
+// content passes UploadOpts.MaxBytes.
+type Upload struct {
+	Filename    string // the client's name, made safe to show; never a storage key
+	ContentType string // sniffed from the content, not the part's header
+
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+// Read reads the file. Reading past the cap returns
+// NewFileTooLargeError; callers must not keep what they read.
+func (u *Upload) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	u.n += int64(n)
+	if u.n > u.max {
+		n -= int(u.n - u.max)
+		u.n = u.max
+		return n, NewFileTooLargeError(u.max)
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return n, NewFileTooLargeError(u.max)
+	}
+	return n, err
+}
+
+// Size returns the bytes read so far: the file's size once Read has
+// returned io.EOF.
+func (u *Upload) Size() int64 {
+	return u.n
+}
+
+// DecodeFileUpload finds the file in form field field of a
+// multipart/form-data request and checks its type:
+//
+//	upload, err := DecodeFileUpload(r, "avatar", UploadOpts{
+//	    MaxBytes:     2 << 20,
+//	    AllowedTypes: []string{"image/png", "image/jpeg"},
+//	})
+//	if err != nil {
+//	    encodeErrorResponse(w, err)
+//	    return
+//	}
+//	url, err := store.Put(ctx, key, upload, upload.ContentType)
+//
+// Nothing is buffered beyond the first 512 bytes, which are sniffed with
+// http.DetectContentType; the client's Content-Type for the part is
+// ignored, since it is whatever the client says. Parts before field are
+// skipped and parts after it are never read, so send the file last.
+//
+// Errors are ready for encodeErrorResponse:
+//
+//	request not multipart/form-data        415
+//	no such field, not a file, empty file  400
+//	sniffed type not allowed               415 unsupported_file_type
+//	file over MaxBytes                     413 file_too_large, from Read
+//	client gone mid-body                   context.Canceled, a bare 499
+func DecodeFileUpload(r *http.Request, field string, opts UploadOpts) (*Upload, error) {
+	if len(opts.AllowedTypes) == 0 {
+		return nil, errors.New("DecodeFileUpload: no AllowedTypes")
+	}
+	maxBytes := cmp.Or(opts.MaxBytes, DefaultMaxUploadBytes)
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "multipart/form-data" {
+		return nil, NewUnsupportedMediaTypeError("multipart/form-data")
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBytes+maxUploadOverhead)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, NewBadRequestError("invalid multipart body")
+	}
+
+	part, err := nextFilePart(mr, field, maxBytes)
+	if err != nil {
+		return nil, readError(r, err)
+	}
+
+	head, err := readHead(part)
+	switch {
+	case err != nil:
+		return nil, readError(r, multipartError(err, maxBytes))
+	case len(head) == 0:
+		return nil, NewBadRequestError(field + " is empty")
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !slices.Contains(opts.AllowedTypes, contentType) {
+		return nil, NewUnsupportedFileTypeError(contentType, opts.AllowedTypes)
+	}
+
+	return &Upload{
+		Filename:    safeFilename(part.FileName()),
+		ContentType: contentType,
+		r:           io.MultiReader(bytes.NewReader(head), part),
+		max:         maxBytes,
+	}, nil
+}
+
+// nextFilePart skips to the part for field, which must be a file.
+func nextFilePart(mr *multipart.Reader, field string, maxBytes int64) (*multipart.Part, error) {
+	for {
+		part, err := mr.NextPart() // discards the rest of the previous part
+		if errors.Is(err, io.EOF) {
+			return nil, NewBadRequestError(field + " is required")
+		}
+		if err != nil {
+			return nil, multipartError(err, maxBytes)
+		}
+		if part.FormName() != field {
+			continue
+		}
+		if part.FileName() == "" {
+			return nil, NewBadRequestError(field + " must be a file")
+		}
+		return part, nil
+	}
+}
+
+// readHead reads up to sniffLen bytes of a part. Unlike io.ReadFull, it
+// tells a short file (io.EOF) from a truncated body, which a part reports
+// as io.ErrUnexpectedEOF.
+func readHead(part io.Reader) ([]byte, error) {
+	head := make([]byte, sniffLen)
+	n := 0
+	for n < len(head) {
+		m, err := part.Read(head[n:])
+		n += m
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return head[:n], nil
+}
+
+// multipartError maps a read error: the body cap gives 413, anything
+// else a malformed body.
+func multipartError(err error, maxBytes int64) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewFileTooLargeError(maxBytes)
+	}
+	return NewBadRequestError("invalid multipart body")
+}
+
+// safeFilename keeps the last element of a client's file name, with
+// either slash, and drops what doesn't belong in a header or a listing:
+// control characters, quotes, leading dots and invalid UTF-8. It is capped
+// at 255 bytes, keeping the extension, and is "upload" if nothing is left.
+func safeFilename(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r), r == '"', r == '\'', r == '`':
+			return -1
+		case strings.ContainsRune(`<>:|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+
+	if len(name) > maxFilenameBytes {
+		ext := path.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:maxFilenameBytes-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1] // don't split a rune
+		}
+		name = base + ext
+	}
+	if name == "" {
+		return "upload"
+	}
+	return name
+}
+
+// NewFileTooLargeError creates a 413 error for an uploaded file over limit
+// bytes.
+func NewFileTooLargeError(limit int64) error {
+	return &HandlerError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "file_too_large",
+		Message: fmt.Sprintf("file exceeds %d bytes", limit),
+	}
+}
+
+// NewUnsupportedFileTypeError creates a 415 error for an uploaded file
+// whose content is got, naming the allowed types.
+func NewUnsupportedFileTypeError(got string, allowed []string) error {
+	return &HandlerError{
+		Status:  http.StatusUnsupportedMediaType,
+		Code:    "unsupported_file_type",
+		Message: fmt.Sprintf("file is %s; upload %s", got, strings.Join(allowed, ", ")),
+	}
+}
+
+// ---------- Blob Storage ----------
+
+// BlobStore stores uploaded files under a key and returns the URL they
+// are served from. storage.LocalBlobStore writes to disk; an S3 store
+// can replace it without touching handlers.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (url string, err error)
+}
+
+// WithBlobStore sets the store UploadAvatar puts files in.
+func WithBlobStore(store BlobStore) HandlerOption {
+	return func(h *UserHandler) {
+		h.blobs = store
+	}
+}
+
+// ---------- Avatar Upload ----------
+
+// avatarUpload is what UploadAvatar accepts.
+var avatarUpload = UploadOpts{
+	MaxBytes:     2 << 20,
+	AllowedTypes: []string{"image/png", "image/jpeg", "image/webp"},
+}
+
+// uploadExtensions names stored files after their sniffed type, never the
+// client's file name.
+var uploadExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// AvatarResponse represents the response body for an uploaded avatar.
+type AvatarResponse struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Filename    string `json:"filename"`
+}
+
+// UploadAvatar handles POST /users/{userID}/avatar with the image in the
+// "avatar" field of a multipart/form-data body. It is stored as
+// avatars/{userID}.{ext}, replacing the previous one.
+func (h *UserHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.blobs == nil {
+		encodeErrorResponse(w, NewNotImplementedError("avatar uploads are not configured"))
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		encodeErrorResponse(w, NewBadRequestError("user ID is required"))
+		return
+	}
+	if _, err := h.userService.GetByID(ctx, userID); err != nil {
+		encodeErrorResponse(w, err) // 404 before reading the body
+		return
+	}
+
+	upload, err := DecodeFileUpload(r, "avatar", avatarUpload)
+	if err != nil {
+		encodeErrorResponse(w, err)
+		return
+	}
+
+	url, err := h.blobs.Put(ctx, "avatars/"+userID+uploadExtensions[upload.ContentType], upload, upload.ContentType)
+	if err != nil {
+		encodeErrorResponse(w, err) // 413 if the file ran over the cap
+		return
+	}
+
+	encodeCommittedResponse(w, http.StatusCreated, AvatarResponse{
+		URL:         url,
+		ContentType: upload.ContentType,
+		Size:        upload.Size(),
+		Filename:    upload.Filename,
+	})
+}
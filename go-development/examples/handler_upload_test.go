@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+var (
+	pngBytes  = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	jpegBytes = append([]byte("\xff\xd8\xff\xe0"), bytes.Repeat([]byte{1}, 100)...)
+)
+
+// formPart is one part of a crafted multipart body. Without a filename,
+// it is a plain field.
+type formPart struct {
+	field, filename, contentType string
+	content                      []byte
+}
+
+// multipartBody encodes parts and returns the body and its Content-Type.
+func multipartBody(t *testing.T, parts ...formPart) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		h := textproto.MIMEHeader{}
+		disposition := fmt.Sprintf(`form-data; name=%q`, p.field)
+		if p.filename != "" {
+			disposition += fmt.Sprintf(`; filename=%q`, p.filename)
+		}
+		h.Set("Content-Disposition", disposition)
+		if p.contentType != "" {
+			h.Set("Content-Type", p.contentType)
+		}
+		w, err := mw.CreatePart(h)
+		require.NoError(t, err)
+		_, err = w.Write(p.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	return &body, mw.FormDataContentType()
+}
+
+// memBlobStore keeps blobs in memory.
+type memBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+	types map[string]string
+}
+
+func newMemBlobStore() *memBlobStore {
+	return &memBlobStore{blobs: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (s *memBlobStore) Put(_ context.Context, key string, r io.Reader, contentType string) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("put %s: %w", key, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = b
+	s.types[key] = contentType
+	return "https://cdn.example.com/" + key, nil
+}
+
+// avatarUserService finds only u1.
+type avatarUserService struct {
+	UserService
+}
+
+func (avatarUserService) GetByID(_ context.Context, id string) (*User, error) {
+	if id != "u1" {
+		return nil, NewNotFoundError("user not found")
+	}
+	return &User{ID: id}, nil
+}
+
+func uploadAvatar(h *UserHandler, userID string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, UserAvatarURL(userID), body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	NewRouter(h).ServeHTTP(rec, req)
+	return rec
+}
+
+// ---------- DecodeFileUpload Tests ----------
+
+func TestDecodeFileUpload(t *testing.T) {
+	t.Parallel()
+
+	body, contentType := multipartBody(t,
+		formPart{field: "note", content: []byte("skipped")},
+		formPart{field: "file", filename: "../../etc/photo.png", contentType: "text/plain", content: pngBytes},
+	)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+
+	upload, err := DecodeFileUpload(req, "file", UploadOpts{AllowedTypes: []string{"image/png"}})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", upload.ContentType, "sniffed, not the part's header")
+	assert.Equal(t, "photo.png", upload.Filename)
+	assert.Zero(t, upload.Size(), "nothing read yet")
+
+	got, err := io.ReadAll(upload)
+	require.NoError(t, err)
+	assert.Equal(t, pngBytes, got, "sniffed bytes are not lost")
+	assert.EqualValues(t, len(pngBytes), upload.Size())
+}
+
+func TestDecodeFileUpload_Rejections(t *testing.T) {
+	t.Parallel()
+
+	png := []string{"image/png"}
+
+	tests := []struct {
+		name        string
+		parts       []formPart
+		body        string // raw body instead of parts
+		contentType string // overrides the multipart one
+		opts        UploadOpts
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{name: "json body", body: `{"avatar":"x"}`, contentType: "application/json", opts: UploadOpts{AllowedTypes: png},
+			wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_media_type"},
+		{name: "no boundary", body: "x", contentType: "multipart/form-data", opts: UploadOpts{AllowedTypes: png},
+			wantStatus: http.StatusBadRequest, wantMessage: "invalid multipart body"},
+		{name: "missing field", parts: []formPart{{field: "other", filename: "a.png", content: pngBytes}},
+			opts: UploadOpts{AllowedTypes: png}, wantStatus: http.StatusBadRequest, wantMessage: "file is required"},
+		{name: "not a file", parts: []formPart{{field: "file", content: pngBytes}},
+			opts: UploadOpts{AllowedTypes: png}, wantStatus: http.StatusBadRequest, wantMessage: "file must be a file"},
+		{name: "empty file", parts: []formPart{{field: "file", filename: "a.png"}},
+			opts: UploadOpts{AllowedTypes: png}, wantStatus: http.StatusBadRequest, wantMessage: "file is empty"},
+		{name: "type by content", parts: []formPart{{field: "file", filename: "a.png", contentType: "image/png",
+			content: []byte("<html><script>alert(1)</script>")}},
+			opts: UploadOpts{AllowedTypes: png}, wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_file_type",
+			wantMessage: "file is text/html; upload image/png"},
+		{name: "type not allowed", parts: []formPart{{field: "file", filename: "a.png", content: jpegBytes}},
+			opts: UploadOpts{AllowedTypes: png}, wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_file_type",
+			wantMessage: "file is image/jpeg; upload image/png"},
+		{name: "earlier part over the body cap",
+			parts: []formPart{{field: "note", content: bytes.Repeat([]byte("x"), maxUploadOverhead+200)},
+				{field: "file", filename: "a.png", content: pngBytes}},
+			opts: UploadOpts{MaxBytes: 100, AllowedTypes: png}, wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode: "file_too_large"},
+		{name: "truncated", body: "--b\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.png\"\r\n\r\n\x89PNG",
+			contentType: "multipart/form-data; boundary=b", opts: UploadOpts{AllowedTypes: png},
+			wantStatus: http.StatusBadRequest, wantMessage: "invalid multipart body"},
+		{name: "no allowed types", parts: []formPart{{field: "file", filename: "a.png", content: pngBytes}},
+			wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body io.Reader = strings.NewReader(tt.body)
+			contentType := tt.contentType
+			if tt.parts != nil {
+				var multipartType string
+				body, multipartType = multipartBody(t, tt.parts...)
+				contentType = cmp.Or(contentType, multipartType)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/upload", body)
+			req.Header.Set("Content-Type", contentType)
+
+			_, err := DecodeFileUpload(req, "file", tt.opts)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantStatus, HTTPStatusCode(err))
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, GetErrorCode(err))
+			}
+			if tt.wantMessage != "" {
+				assert.Equal(t, tt.wantMessage, err.Error())
+			}
+		})
+	}
+}
+
+func TestUpload_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	big := append(bytes.Clone(pngBytes), bytes.Repeat([]byte{0}, 1000)...)
+	body, contentType := multipartBody(t, formPart{field: "file", filename: "a.png", content: big})
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+
+	upload, err := DecodeFileUpload(req, "file", UploadOpts{MaxBytes: 600, AllowedTypes: []string{"image/png"}})
+	require.NoError(t, err, "the size is only known while reading")
+
+	got, err := io.ReadAll(upload)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, HTTPStatusCode(err))
+	assert.Equal(t, "file exceeds 600 bytes", err.Error())
+	assert.Len(t, got, 600, "never more than the cap")
+	assert.EqualValues(t, 600, upload.Size())
+}
+
+func TestSafeFilename(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("é", 200) + ".png" // 404 bytes
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "photo.png", want: "photo.png"},
+		{name: "unix path", in: "../../etc/passwd", want: "passwd"},
+		{name: "windows path", in: `C:\Users\ann\photo.png`, want: "photo.png"},
+		{name: "hidden", in: ".htaccess", want: "htaccess"},
+		{name: "dots only", in: "..", want: "upload"},
+		{name: "empty", in: "", want: "upload"},
+		{name: "control and quotes", in: "a\r\n\"b'`\x00.png", want: "ab.png"},
+		{name: "reserved", in: "a<b>:c|d?*.png", want: "a_b__c_d__.png"},
+		{name: "invalid utf-8", in: "a\xffb.png", want: "ab.png"},
+		{name: "unicode kept", in: "фото 1.png", want: "фото 1.png"},
+		{name: "long keeps extension", in: long, want: strings.Repeat("é", 125) + ".png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := safeFilename(tt.in)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len(got), 255)
+		})
+	}
+}
+
+// ---------- UploadAvatar Tests ----------
+
+func TestUserHandler_UploadAvatar(t *testing.T) {
+	t.Parallel()
+
+	store := newMemBlobStore()
+	h := NewUserHandler(avatarUserService{}, WithBlobStore(store))
+
+	body, contentType := multipartBody(t, formPart{field: "avatar", filename: "me.jpeg", content: jpegBytes})
+	rec := uploadAvatar(h, "u1", body, contentType)
+
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var resp AvatarResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, AvatarResponse{
+		URL:         "https://cdn.example.com/avatars/u1.jpg",
+		ContentType: "image/jpeg",
+		Size:        int64(len(jpegBytes)),
+		Filename:    "me.jpeg",
+	}, resp)
+	assert.Equal(t, jpegBytes, store.blobs["avatars/u1.jpg"])
+	assert.Equal(t, "image/jpeg", store.types["avatars/u1.jpg"])
+}
+
+func TestUserHandler_UploadAvatarErrors(t *testing.T) {
+	t.Parallel()
+
+	tooBig := append(bytes.Clone(pngBytes), bytes.Repeat([]byte{0}, int(avatarUpload.MaxBytes))...)
+
+	tests := []struct {
+		name       string
+		store      BlobStore
+		userID     string
+		parts      []formPart
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no store", userID: "u1", parts: []formPart{{field: "avatar", filename: "a.png", content: pngBytes}},
+			wantStatus: http.StatusNotImplemented, wantCode: "not_implemented"},
+		{name: "unknown user", store: newMemBlobStore(), userID: "u2",
+			parts:      []formPart{{field: "avatar", filename: "a.png", content: pngBytes}},
+			wantStatus: http.StatusNotFound, wantCode: "not_found"},
+		{name: "wrong field", store: newMemBlobStore(), userID: "u1",
+			parts:      []formPart{{field: "photo", filename: "a.png", content: pngBytes}},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid"},
+		{name: "gif", store: newMemBlobStore(), userID: "u1",
+			parts:      []formPart{{field: "avatar", filename: "a.png", content: []byte("GIF89a......")}},
+			wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_file_type"},
+		{name: "too large", store: newMemBlobStore(), userID: "u1",
+			parts:      []formPart{{field: "avatar", filename: "a.png", content: tooBig}},
+			wantStatus: http.StatusRequestEntityTooLarge, wantCode: "file_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []HandlerOption
+			if tt.store != nil {
+				opts = append(opts, WithBlobStore(tt.store))
+			}
+			body, contentType := multipartBody(t, tt.parts...)
+			rec := uploadAvatar(NewUserHandler(avatarUserService{}, opts...), tt.userID, body, contentType)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+			if store, ok := tt.store.(*memBlobStore); ok {
+				assert.Empty(t, store.blobs, "nothing stored")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package handler provides URL builders for the path constants, for
+// Location headers, links and tests.
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// UsersURL returns the URL of the user list, e.g.
+// "/api/v1/users?status=active"; query may be nil.
+func UsersURL(query url.Values) string {
+	return withQuery(expandPath(PathPrefix+UsersPath), query)
+}
+
+// UserByIDURL returns the URL of a user, e.g. "/api/v1/users/u1".
+func UserByIDURL(userID string) string {
+	return expandPath(PathPrefix+UserByIDPath, userID)
+}
+
+// UserAvatarURL returns the URL avatars for a user are uploaded to.
+func UserAvatarURL(userID string) string {
+	return expandPath(PathPrefix+UserAvatarPath, userID)
+}
+
+// OrderByIDURL returns the URL of an order.
+func OrderByIDURL(orderID string) string {
+	return expandPath(PathPrefix+OrderByIDPath, orderID)
+}
+
+// expandPath replaces the {params} of a chi pattern, in order, with
+// params escaped as one path segment each: "a/b" becomes "a%2Fb", never
+// two segments. It panics if the counts differ; the builders above are
+// the only callers, so that is a bug caught by their tests.
+func expandPath(pattern string, params ...string) string {
+	var b strings.Builder
+	rest := pattern
+	n := 0
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			panic(fmt.Sprintf("expandPath %q: unclosed {", pattern))
+		}
+		if n == len(params) {
+			panic(fmt.Sprintf("expandPath %q: too few params", pattern))
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(params[n]))
+		rest = rest[start+end+1:]
+		n++
+	}
+	if n != len(params) {
+		panic(fmt.Sprintf("expandPath %q: %d params for %d placeholders", pattern, len(params), n))
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// withQuery appends query, if any, in url.Values.Encode's sorted order.
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- URL Builder Tests ----------
+
+func TestUserByIDURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		userID string
+		want   string
+	}{
+		{name: "plain", userID: "u1", want: "/api/v1/users/u1"},
+		{name: "slash stays in one segment", userID: "a/b", want: "/api/v1/users/a%2Fb"},
+		{name: "query and fragment", userID: "a?b#c", want: "/api/v1/users/a%3Fb%23c"},
+		{name: "percent", userID: "100%", want: "/api/v1/users/100%25"},
+		{name: "space", userID: "a b", want: "/api/v1/users/a%20b"},
+		{name: "dot segment", userID: "..", want: "/api/v1/users/.."},
+		{name: "unicode", userID: "ü", want: "/api/v1/users/%C3%BC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := UserByIDURL(tt.userID)
+			assert.Equal(t, tt.want, got)
+
+			u, err := url.Parse(got)
+			require.NoError(t, err)
+			assert.Equal(t, "/api/v1/users/"+tt.userID, u.Path, "decodes back")
+		})
+	}
+}
+
+func TestUserByIDURL_RoutesToGetByID(t *testing.T) {
+	t.Parallel()
+
+	var gotID string
+	r := chi.NewRouter()
+	r.Get(PathPrefix+UserByIDPath, func(w http.ResponseWriter, r *http.Request) {
+		gotID = chi.URLParam(r, "userID")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, UserByIDURL("a b"), nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "a b", gotID)
+}
+
+func TestUsersURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/api/v1/users", UsersURL(nil))
+	assert.Equal(t, "/api/v1/users?q=a%26b&status=active", UsersURL(url.Values{
+		"status": {"active"},
+		"q":      {"a&b"},
+	}), "sorted by key, values escaped")
+}
+
+func TestExpandPath_ParamCount(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/users/u1/orders/o1", expandPath("/users/{userID}/orders/{orderID}", "u1", "o1"))
+	assert.Equal(t, "/orders/7", expandPath("/orders/{orderID:[0-9]+}", "7"), "regexp placeholders")
+	assert.Panics(t, func() { expandPath(UserByIDPath) })
+	assert.Panics(t, func() { expandPath(UserByIDPath, "u1", "u2") })
+}
+
+// idUserService creates users with a fixed ID.
+type idUserService struct {
+	UserService
+	id string
+}
+
+func (s idUserService) Create(_ context.Context, name, email string) (*User, error) {
+	return &User{ID: s.id, Name: name, Email: email, CreatedAt: time.Unix(0, 0)}, nil
+}
+
+func TestUserHandler_CreateLocation(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(NewUserHandler(idUserService{id: "team/42"}))
+
+	req := httptest.NewRequest(http.MethodPost, UsersURL(nil), strings.NewReader(`{"name":"Ann","email":"ann@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "/api/v1/users/team%2F42", rec.Header().Get("Location"))
+}
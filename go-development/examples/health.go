@@ -2,27 +2,47 @@
 //
 // This example shows:
 // - HealthzHandler for liveness probes
-// - ReadyzHandler for readiness probes with parallel checks
+// - StartupzHandler for startup probes, gated on programmatic warm-up
+// - ReadyzHandler for readiness probes with parallel, time-bounded checks
+// - Bounded check concurrency, an overall budget and panic recovery
+// - Per-check results by name in the readiness response
+// - Required and optional checks with a degraded status
+// - Short-lived result cache so probes don't hammer dependencies
+// - Background Monitor so probes read a snapshot (health_monitor.go)
 // - ReadyChecker interface for dependency checks
 // - Database and HTTP service checkers
+// - Schema version discovered from embedded goose migrations
+// - gRPC health-protocol checker and server (health_grpc.go)
 package health
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
 )
 
 // ---------- Path Constants ----------
 
 const (
-	HealthzHandlerPathPrefix = "/check/healthz"
-	ReadyzHandlerPathPrefix  = "/check/readyz"
+	HealthzHandlerPathPrefix  = "/check/healthz"
+	StartupzHandlerPathPrefix = "/check/startupz"
+	ReadyzHandlerPathPrefix   = "/check/readyz"
 )
 
 // ---------- ReadyChecker Interface ----------
@@ -60,13 +80,306 @@ func (HealthzHandler) handleHealthz(w http.ResponseWriter, _ *http.Request) {
 	json.NewEncoder(w).Encode(healthzResponse{Status: "healthy"})
 }
 
+// ---------- Startupz Handler (Startup) ----------
+
+// Component states reported by StartupzHandler.
+const (
+	componentPending = "pending"
+	componentReady   = "ready"
+)
+
+// StartupzHandler handles startup probe requests.
+// Returns 503 until every registered component is marked ready, or until
+// MarkReady is called. Once started it stays started.
+type StartupzHandler struct {
+	http.Handler
+
+	mu         sync.Mutex
+	components map[string]bool
+	started    bool
+}
+
+// NewStartupzHandler creates a startup handler waiting for components.
+// With no components it waits for MarkReady.
+func NewStartupzHandler(components ...string) *StartupzHandler {
+	router := chi.NewRouter()
+	handler := &StartupzHandler{
+		Handler:    router,
+		components: make(map[string]bool, len(components)),
+	}
+	for _, name := range components {
+		handler.components[name] = false
+	}
+
+	router.Get("/", handler.handleStartupz)
+
+	return handler
+}
+
+// MarkComponentReady marks one component as warmed up. Safe for concurrent
+// use; marking twice or marking an unregistered name has no effect.
+func (h *StartupzHandler) MarkComponentReady(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.components[name]; !ok {
+		return
+	}
+	h.components[name] = true
+
+	for _, ready := range h.components {
+		if !ready {
+			return
+		}
+	}
+	h.started = true
+}
+
+// MarkReady marks the service as started, including any pending components.
+func (h *StartupzHandler) MarkReady() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for name := range h.components {
+		h.components[name] = true
+	}
+	h.started = true
+}
+
+// Started reports whether startup has completed.
+func (h *StartupzHandler) Started() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.started
+}
+
+type startupzResponse struct {
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+func (h *StartupzHandler) handleStartupz(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	resp := startupzResponse{Status: "starting"}
+	status := http.StatusServiceUnavailable
+	if h.started {
+		resp.Status = "started"
+		status = http.StatusOK
+	}
+	if len(h.components) > 0 {
+		resp.Components = make(map[string]string, len(h.components))
+		for name, ready := range h.components {
+			resp.Components[name] = componentPending
+			if ready {
+				resp.Components[name] = componentReady
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ---------- Named Checkers ----------
+
+// NamedChecker is a ReadyChecker that reports under its own name.
+// Unnamed checkers are reported as check_1, check_2, ...
+type NamedChecker interface {
+	ReadyChecker
+	Name() string
+}
+
+type namedChecker struct {
+	ReadyChecker
+	name string
+}
+
+func (c namedChecker) Name() string {
+	return c.name
+}
+
+// Severity reports the wrapped checker's severity.
+func (c namedChecker) Severity() Severity {
+	return severityOf(c.ReadyChecker)
+}
+
+// Named reports checker under name in readiness responses.
+func Named(name string, checker ReadyChecker) NamedChecker {
+	return namedChecker{ReadyChecker: checker, name: name}
+}
+
+// checkerNames returns a unique name per checker, suffixing duplicates.
+func checkerNames(checkers []ReadyChecker) []string {
+	names := make([]string, len(checkers))
+	seen := make(map[string]int, len(checkers))
+
+	for i, c := range checkers {
+		name := fmt.Sprintf("check_%d", i+1)
+		if n, ok := c.(NamedChecker); ok && n.Name() != "" {
+			name = n.Name()
+		}
+
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		names[i] = name
+	}
+
+	return names
+}
+
+// ---------- Check Severity ----------
+
+// Severity decides how a failing check affects readiness.
+type Severity int
+
+const (
+	// SeverityRequired failures make the service unavailable (503).
+	SeverityRequired Severity = iota
+	// SeverityOptional failures degrade the service but keep it serving (200).
+	SeverityOptional
+)
+
+type severityChecker struct {
+	ReadyChecker
+	severity Severity
+}
+
+// Name keeps the wrapped checker's name, if any.
+func (c severityChecker) Name() string {
+	if n, ok := c.ReadyChecker.(NamedChecker); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+func (c severityChecker) Severity() Severity {
+	return c.severity
+}
+
+// Required marks checker as required. Checkers are required by default;
+// use it to make intent explicit next to Optional ones.
+func Required(checker ReadyChecker) ReadyChecker {
+	return severityChecker{ReadyChecker: checker, severity: SeverityRequired}
+}
+
+// Optional marks checker as optional: when it fails, readiness reports
+// "degraded" but still returns 200, e.g. for a cache with a database fallback.
+func Optional(checker ReadyChecker) ReadyChecker {
+	return severityChecker{ReadyChecker: checker, severity: SeverityOptional}
+}
+
+// severityOf returns the checker's severity, required unless marked otherwise.
+func severityOf(checker ReadyChecker) Severity {
+	if s, ok := checker.(interface{ Severity() Severity }); ok {
+		return s.Severity()
+	}
+	return SeverityRequired
+}
+
+// ---------- Metrics ----------
+
+// Metrics holds Prometheus collectors for health checks.
+// A nil *Metrics disables metrics, so WithMetrics is optional.
+type Metrics struct {
+	readiness   *prometheus.GaugeVec
+	checkStatus *prometheus.GaugeVec
+	duration    *prometheus.HistogramVec
+	failures    *prometheus.CounterVec
+}
+
+// NewMetrics creates health metrics and registers them with reg.
+// Pass backend.registry so they show up on the monitor server.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		readiness: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "health_readiness_status",
+			Help: "Current readiness status: 1 for the active status, 0 otherwise.",
+		}, []string{"status"}),
+		checkStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "health_check_status",
+			Help: "Result of the last health check: 1 passed, 0 failed.",
+		}, []string{"check"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "health_check_duration_seconds",
+			Help:    "Health check duration, including timed out checks.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"check"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "health_check_failures_total",
+			Help: "Failed health checks, including timeouts.",
+		}, []string{"check"}),
+	}
+
+	reg.MustRegister(m.readiness, m.checkStatus, m.duration, m.failures)
+
+	return m
+}
+
+func (m *Metrics) status(current string) {
+	if m == nil {
+		return
+	}
+	for _, s := range []string{statusReady, statusDegraded, statusUnavailable} {
+		value := 0.0
+		if s == current {
+			value = 1
+		}
+		m.readiness.WithLabelValues(s).Set(value)
+	}
+}
+
+func (m *Metrics) checked(check string, passed bool, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.duration.WithLabelValues(check).Observe(elapsed.Seconds())
+	if passed {
+		m.checkStatus.WithLabelValues(check).Set(1)
+		return
+	}
+	m.checkStatus.WithLabelValues(check).Set(0)
+	m.failures.WithLabelValues(check).Inc()
+}
+
 // ---------- Readyz Handler (Readiness) ----------
 
+// Check results reported per checker; failures report the error message.
+const (
+	checkOK      = "ok"
+	checkTimeout = "timeout"
+)
+
+// Aggregate readiness statuses.
+const (
+	statusReady       = "ready"
+	statusDegraded    = "degraded"
+	statusUnavailable = "unavailable"
+)
+
 // ReadyzHandler handles readiness probe requests.
-// Returns 200 OK only if all checkers pass.
+// Returns 200 OK unless a required checker fails; failing optional
+// checkers report "degraded".
 type ReadyzHandler struct {
 	http.Handler
-	checkers []ReadyChecker
+	checkers     []ReadyChecker
+	names        []string
+	optional     map[string]bool
+	checkTimeout time.Duration
+	budget       time.Duration
+	sem          chan struct{}
+	cacheTTL     time.Duration
+	metrics      *Metrics
+	monitor      *Monitor
+
+	mu       sync.Mutex
+	cached   map[string]string
+	cachedAt time.Time
+	refresh  singleflight.Group
 }
 
 // NewReadyzHandler creates a new readiness handler with checkers.
@@ -75,6 +388,15 @@ func NewReadyzHandler(checkers ...ReadyChecker) *ReadyzHandler {
 	handler := &ReadyzHandler{
 		Handler:  router,
 		checkers: checkers,
+		names:    checkerNames(checkers),
+		optional: make(map[string]bool),
+		budget:   4 * time.Second,
+		sem:      make(chan struct{}, 8),
+	}
+	for i, checker := range checkers {
+		if severityOf(checker) == SeverityOptional {
+			handler.optional[handler.names[i]] = true
+		}
 	}
 
 	router.Get("/", handler.handleReadyz)
@@ -82,44 +404,224 @@ func NewReadyzHandler(checkers ...ReadyChecker) *ReadyzHandler {
 	return handler
 }
 
-type readyzResponse struct {
-	Status string `json:"status"`
+// WithCheckTimeout bounds each checker to d, so one slow dependency
+// reports "timeout" instead of holding the probe. Zero means no limit.
+func (h *ReadyzHandler) WithCheckTimeout(d time.Duration) *ReadyzHandler {
+	h.checkTimeout = d
+	return h
 }
 
-type errorResponse struct {
-	Error string `json:"error"`
+// WithBudget bounds a whole round of checks to d (default 4s), keeping
+// probes under the kubelet timeout however many checkers queue up.
+// Zero means no limit.
+func (h *ReadyzHandler) WithBudget(d time.Duration) *ReadyzHandler {
+	h.budget = d
+	return h
+}
+
+// WithConcurrency runs at most n checks at once across all probes
+// (default 8), so many checkers and frequent probes don't pile up
+// goroutines. Set it before serving.
+func (h *ReadyzHandler) WithConcurrency(n int) *ReadyzHandler {
+	h.sem = make(chan struct{}, max(n, 1))
+	return h
+}
+
+// WithCacheTTL serves the last results for d instead of checking dependencies
+// on every probe. Concurrent probes after expiry share one refresh;
+// ?force=1 bypasses the cache. Zero disables caching.
+func (h *ReadyzHandler) WithCacheTTL(d time.Duration) *ReadyzHandler {
+	h.cacheTTL = d
+	return h
+}
+
+// WithMetrics records the readiness status and per-check results in m,
+// whether checks run inline or in a Monitor.
+func (h *ReadyzHandler) WithMetrics(m *Metrics) *ReadyzHandler {
+	h.metrics = m
+	return h
+}
+
+type readyzResponse struct {
+	Status     string            `json:"status"`
+	Checks     map[string]string `json:"checks,omitempty"`
+	DurationMS int64             `json:"duration_ms"`
 }
 
 func (h *ReadyzHandler) handleReadyz(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	start := time.Now()
+	checks := h.results(r.Context(), r.URL.Query().Get("force") == "1")
 
-	// Run all checkers in parallel
-	errCh := make(chan error, len(h.checkers))
+	resp := readyzResponse{
+		Status:     h.evaluate(checks),
+		Checks:     checks,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	status := http.StatusOK
+	if resp.Status == statusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
 
-	for _, checker := range h.checkers {
-		go func(checker ReadyChecker) {
-			errCh <- checker.CheckReady(ctx)
-		}(checker)
+// evaluate aggregates check results and records the status: a failed
+// required check makes the service unavailable, failed optional checks
+// only degrade it.
+func (h *ReadyzHandler) evaluate(checks map[string]string) string {
+	status := statusReady
+	for name, result := range checks {
+		if result == checkOK {
+			continue
+		}
+		if !h.optional[name] {
+			status = statusUnavailable
+			break
+		}
+		status = statusDegraded
+	}
+
+	h.metrics.status(status)
+	return status
+}
+
+// results returns the monitor's snapshot if there is one, otherwise cached
+// results while fresh, refreshing at most once at a time.
+func (h *ReadyzHandler) results(ctx context.Context, force bool) map[string]string {
+	if h.monitor != nil && !force {
+		return h.monitor.snapshot()
+	}
+
+	if h.cacheTTL <= 0 {
+		return h.runChecks(ctx)
 	}
 
-	// Collect errors
-	var errs []error
-	for i := 0; i < len(h.checkers); i++ {
-		if err := <-errCh; err != nil {
-			errs = append(errs, err)
+	if !force {
+		if checks := h.fresh(); checks != nil {
+			return checks
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	v, _, _ := h.refresh.Do("readyz", func() (any, error) {
+		// A refresh may have finished between the check above and Do
+		if !force {
+			if checks := h.fresh(); checks != nil {
+				return checks, nil
+			}
+		}
 
-	if len(errs) > 0 {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(errorResponse{Error: errs[0].Error()})
-		return
+		// Shared by every waiting probe, so one probe's cancellation must not fail it
+		checks := h.runChecks(context.WithoutCancel(ctx))
+
+		h.mu.Lock()
+		h.cached, h.cachedAt = checks, time.Now()
+		h.mu.Unlock()
+
+		return checks, nil
+	})
+
+	return v.(map[string]string)
+}
+
+// fresh returns the cached results, or nil once they are older than the TTL.
+func (h *ReadyzHandler) fresh() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if time.Since(h.cachedAt) >= h.cacheTTL {
+		return nil
 	}
+	return h.cached
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(readyzResponse{Status: "ready"})
+// runChecks runs all checkers in parallel, at most sem at a time and within
+// the budget, and returns each result by name. Checks still waiting for a
+// slot when the budget runs out report "timeout".
+func (h *ReadyzHandler) runChecks(ctx context.Context) map[string]string {
+	if h.budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.budget)
+		defer cancel()
+	}
+
+	type result struct {
+		name   string
+		status string
+	}
+
+	resultCh := make(chan result, len(h.checkers))
+	for i, checker := range h.checkers {
+		select {
+		case h.sem <- struct{}{}:
+		case <-ctx.Done():
+			resultCh <- result{name: h.names[i], status: checkResult(ctx.Err())}
+			continue
+		}
+
+		go func() {
+			defer func() { <-h.sem }()
+			resultCh <- result{name: h.names[i], status: h.check(ctx, h.names[i], checker)}
+		}()
+	}
+
+	checks := make(map[string]string, len(h.checkers))
+	for range h.checkers {
+		r := <-resultCh
+		checks[r.name] = r.status
+	}
+
+	return checks
+}
+
+// check runs one checker, giving up at the check timeout even if the
+// checker ignores its context, and records it under name. A panicking
+// checker fails its check instead of crashing the process.
+func (h *ReadyzHandler) check(ctx context.Context, name string, checker ReadyChecker) string {
+	start := time.Now()
+
+	if h.checkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.checkTimeout)
+		defer cancel()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- checker.CheckReady(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	// Cancelled means the probe or monitor went away, not the dependency
+	if !errors.Is(err, context.Canceled) {
+		h.metrics.checked(name, err == nil, time.Since(start))
+	}
+
+	return checkResult(err)
+}
+
+// checkResult converts a checker error into its reported result.
+func checkResult(err error) string {
+	switch {
+	case err == nil:
+		return checkOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return checkTimeout
+	default:
+		return err.Error()
+	}
 }
 
 // ---------- PostgreSQL Checker ----------
@@ -140,6 +642,61 @@ func NewPostgresChecker(pool *pgxpool.Pool, schemaVersion int64) *PostgresChecke
 	}
 }
 
+// NewPostgresCheckerFromMigrations creates a PostgreSQL checker expecting
+// the newest migration in migrations, so the version never drifts from
+// the code. Pass the FS given to goose, e.g. fs.Sub(embedMigrations, "migrations").
+func NewPostgresCheckerFromMigrations(pool *pgxpool.Pool, migrations fs.FS) (*PostgresChecker, error) {
+	version, err := latestMigration(migrations)
+	if err != nil {
+		return nil, fmt.Errorf("postgres checker: %w", err)
+	}
+
+	return NewPostgresChecker(pool, version), nil
+}
+
+// gooseMigration matches goose migration files: 20240115120000_add_users.sql.
+var gooseMigration = regexp.MustCompile(`^(\d+)_.+\.(sql|go)$`)
+
+// latestMigration returns the highest goose version in the root of fsys.
+// Other files, such as README.md or _test.go files, are ignored.
+func latestMigration(fsys fs.FS) (int64, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+
+	var latest int64
+	for _, e := range entries {
+		name := e.Name()
+		m := gooseMigration.FindStringSubmatch(name)
+		if e.IsDir() || m == nil || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", name, err)
+		}
+		latest = max(latest, version)
+	}
+
+	if latest == 0 {
+		return 0, errors.New("no migrations found")
+	}
+
+	return latest, nil
+}
+
+// SchemaVersion returns the expected migration version; zero skips the check.
+func (c *PostgresChecker) SchemaVersion() int64 {
+	return c.schemaVersion
+}
+
+// Name implements NamedChecker.
+func (c *PostgresChecker) Name() string {
+	return "postgres"
+}
+
 // CheckReady checks database connectivity and schema version.
 func (c *PostgresChecker) CheckReady(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
@@ -162,7 +719,13 @@ func (c *PostgresChecker) CheckReady(ctx context.Context) error {
 			LIMIT 1
 		`).Scan(&versionID, &dirty)
 
-		if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "42P01": // undefined_table
+			return fmt.Errorf("postgres schema check: goose_db_version table missing, migrations not applied: %w", err)
+		case errors.Is(err, pgx.ErrNoRows):
+			return fmt.Errorf("postgres schema check: no migrations applied, want version %d", c.schemaVersion)
+		case err != nil:
 			return fmt.Errorf("postgres schema check: %w", err)
 		}
 
@@ -197,6 +760,15 @@ func NewHTTPChecker(url string) *HTTPChecker {
 	}
 }
 
+// Name implements NamedChecker. It is the service host, e.g. "auth-service".
+func (c *HTTPChecker) Name() string {
+	u, err := url.Parse(c.url)
+	if err != nil || u.Hostname() == "" {
+		return "http"
+	}
+	return u.Hostname()
+}
+
 // CheckReady checks if the HTTP service is available.
 func (c *HTTPChecker) CheckReady(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
@@ -224,25 +796,51 @@ func (c *HTTPChecker) CheckReady(ctx context.Context) error {
 
 // Example setup:
 //
-//	func setupHealthChecks(pool *pgxpool.Pool) (http.Handler, http.Handler) {
+//	//go:embed migrations/*.sql
+//	var embedMigrations embed.FS
+//
+//	func setupHealthChecks(pool *pgxpool.Pool) (http.Handler, *health.StartupzHandler, http.Handler, error) {
 //	    // Liveness - always healthy if process runs
 //	    healthz := health.NewHealthzHandler()
 //
+//	    // Startup - fails until warm-up code calls MarkComponentReady for each
+//	    startupz := health.NewStartupzHandler("cache", "search-index")
+//
 //	    // Readiness - check dependencies
+//	    // Expected version comes from the embedded migrations, no constant to bump
+//	    migrations, _ := fs.Sub(embedMigrations, "migrations")
+//	    postgres, err := health.NewPostgresCheckerFromMigrations(pool, migrations)
+//	    if err != nil {
+//	        return nil, nil, nil, err
+//	    }
+//
 //	    readyz := health.NewReadyzHandler(
-//	        health.NewPostgresChecker(pool, 20240115120000),
+//	        health.Required(postgres),
 //	        // health.NewHTTPChecker("http://auth-service/check/healthz/"),
-//	    )
+//	        // health.Optional(health.Named("redis", redisChecker)),
+//	    ).WithCheckTimeout(2 * time.Second).WithCacheTTL(5 * time.Second).
+//	        WithMetrics(health.NewMetrics(registry))
 //
-//	    return healthz, readyz
+//	    return healthz, startupz, readyz, nil
 //	}
 //
 //	// Router setup:
 //	router := chi.NewRouter()
 //	router.Mount(health.HealthzHandlerPathPrefix, healthz)
+//	router.Mount(health.StartupzHandlerPathPrefix, startupz)
 //	router.Mount(health.ReadyzHandlerPathPrefix, readyz)
 //
+//	// Warm-up, e.g. in a BackgroundJob:
+//	go func() {
+//	    warmCache(ctx)
+//	    startupz.MarkComponentReady("cache")
+//	}()
+//
 //	// Kubernetes probes:
+//	// startupProbe:
+//	//   httpGet:
+//	//     path: /check/startupz/
+//	//     port: 8081
 //	// livenessProbe:
 //	//   httpGet:
 //	//     path: /check/healthz/
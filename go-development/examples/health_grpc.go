@@ -0,0 +1,193 @@
+// Package health provides the gRPC health-protocol checker and server.
+// Place in: internal/health/grpc.go
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ---------- gRPC Checker ----------
+
+// GRPCChecker checks a gRPC service via the grpc.health.v1 Check RPC.
+// The connection is created on the first check and reused after that.
+type GRPCChecker struct {
+	target   string
+	service  string
+	dialOpts []grpc.DialOption
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+}
+
+// NewGRPCChecker creates a checker for service at target, e.g.
+// "dns:///billing:9090". An empty service checks the server as a whole.
+// Connections are plaintext unless dialOpts set transport credentials.
+func NewGRPCChecker(target, service string, dialOpts ...grpc.DialOption) *GRPCChecker {
+	opts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, dialOpts...)
+
+	return &GRPCChecker{
+		target:   target,
+		service:  service,
+		dialOpts: opts,
+		timeout:  5 * time.Second,
+	}
+}
+
+// Name implements NamedChecker. It is the checked service, or the target
+// host when checking the whole server.
+func (c *GRPCChecker) Name() string {
+	if c.service != "" {
+		return c.service
+	}
+
+	host := c.target
+	if i := strings.Index(host, ":///"); i >= 0 {
+		host = host[i+len(":///"):]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "" {
+		return "grpc"
+	}
+	return host
+}
+
+// CheckReady checks that the service reports SERVING.
+func (c *GRPCChecker) CheckReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.client()
+	if err != nil {
+		return fmt.Errorf("grpc checker: %w", err)
+	}
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: c.service,
+	})
+	if err != nil {
+		return fmt.Errorf("grpc checker: %w", err)
+	}
+
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc checker: status %s", resp.GetStatus())
+	}
+
+	return nil
+}
+
+// Close releases the connection. A later check reconnects.
+func (c *GRPCChecker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// client returns the shared connection, creating it on first use.
+// grpc.NewClient does not dial; the first RPC connects.
+func (c *GRPCChecker) client() (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := grpc.NewClient(c.target, c.dialOpts...)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+
+	return c.conn, nil
+}
+
+// ---------- gRPC Health Server ----------
+
+// GRPCServer serves grpc.health.v1 from a ReadyzHandler's checkers.
+// The overall ("") service mirrors /readyz, serving while ready or
+// degraded; each check is also exposed as a service under its name,
+// e.g. "postgres".
+type GRPCServer struct {
+	readyz *ReadyzHandler
+	server *grpchealth.Server
+}
+
+// NewGRPCServer registers the health service on s. Everything reports
+// NOT_SERVING until the first Update.
+func NewGRPCServer(s *grpc.Server, readyz *ReadyzHandler) *GRPCServer {
+	server := grpchealth.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, server)
+
+	return &GRPCServer{readyz: readyz, server: server}
+}
+
+// Update runs the readiness checks (honouring the handler's cache) and
+// publishes the results. Watch streams see the change immediately.
+func (g *GRPCServer) Update(ctx context.Context) {
+	checks := g.readyz.results(ctx, false)
+
+	for name, result := range checks {
+		g.server.SetServingStatus(name, servingStatus(result == checkOK))
+	}
+	g.server.SetServingStatus("", servingStatus(g.readyz.evaluate(checks) != statusUnavailable))
+}
+
+// Run updates every interval until ctx is cancelled, then reports
+// NOT_SERVING for everything so clients drain before the server stops.
+func (g *GRPCServer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	g.Update(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			g.server.Shutdown()
+			return
+		case <-ticker.C:
+			g.Update(ctx)
+		}
+	}
+}
+
+func servingStatus(ok bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ok {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// ---------- Usage Example ----------
+
+// Example setup:
+//
+//	readyz := health.NewReadyzHandler(
+//	    health.NewPostgresChecker(pool, 20240115120000),
+//	    health.NewGRPCChecker("dns:///billing:9090", "billing.v1.BillingService"),
+//	).WithCheckTimeout(2 * time.Second).WithCacheTTL(5 * time.Second)
+//
+//	// Expose the same state over gRPC (grpc_health_probe, Kubernetes grpc probes)
+//	grpcServer := grpc.NewServer()
+//	grpcHealth := health.NewGRPCServer(grpcServer, readyz)
+//	go grpcHealth.Run(ctx, 5*time.Second)
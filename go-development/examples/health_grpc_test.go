@@ -0,0 +1,226 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"myapp/internal/health"
+)
+
+// ---------- Test Helpers ----------
+
+const bufTarget = "passthrough:///bufnet"
+
+// serveBufconn serves s in-process and returns a dial option that connects
+// to it, counting dials.
+func serveBufconn(t *testing.T, s *grpc.Server) (grpc.DialOption, *atomic.Int32) {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	var dials atomic.Int32
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		dials.Add(1)
+		return lis.DialContext(ctx)
+	})
+
+	return dialer, &dials
+}
+
+// healthServer runs a plain grpc.health.v1 server, as a dependency would.
+func healthServer(t *testing.T) (*grpchealth.Server, grpc.DialOption, *atomic.Int32) {
+	t.Helper()
+
+	s := grpc.NewServer()
+	hs := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+
+	dialer, dials := serveBufconn(t, s)
+	return hs, dialer, dials
+}
+
+func grpcChecker(t *testing.T, service string, dialer grpc.DialOption) *health.GRPCChecker {
+	t.Helper()
+
+	c := health.NewGRPCChecker(bufTarget, service, dialer)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func healthClient(t *testing.T, dialer grpc.DialOption) healthpb.HealthClient {
+	t.Helper()
+
+	conn, err := grpc.NewClient(bufTarget, dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func checkStatus(t *testing.T, client healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+// ---------- gRPC Checker Tests ----------
+
+func TestGRPCChecker_Serving(t *testing.T) {
+	t.Parallel()
+
+	hs, dialer, _ := healthServer(t)
+	hs.SetServingStatus("billing.v1.BillingService", healthpb.HealthCheckResponse_SERVING)
+
+	c := grpcChecker(t, "billing.v1.BillingService", dialer)
+
+	require.NoError(t, c.CheckReady(context.Background()))
+}
+
+func TestGRPCChecker_NotServing(t *testing.T) {
+	t.Parallel()
+
+	hs, dialer, _ := healthServer(t)
+	hs.SetServingStatus("billing.v1.BillingService", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	c := grpcChecker(t, "billing.v1.BillingService", dialer)
+
+	assert.ErrorContains(t, c.CheckReady(context.Background()), "status NOT_SERVING")
+}
+
+func TestGRPCChecker_UnknownService(t *testing.T) {
+	t.Parallel()
+
+	_, dialer, _ := healthServer(t)
+	c := grpcChecker(t, "missing.v1.Service", dialer)
+
+	assert.ErrorContains(t, c.CheckReady(context.Background()), "NotFound")
+}
+
+func TestGRPCChecker_ReusesConnection(t *testing.T) {
+	t.Parallel()
+
+	_, dialer, dials := healthServer(t)
+	c := grpcChecker(t, "", dialer)
+
+	for range 3 {
+		require.NoError(t, c.CheckReady(context.Background()))
+	}
+	assert.Equal(t, int32(1), dials.Load())
+
+	require.NoError(t, c.Close())
+	require.NoError(t, c.CheckReady(context.Background()))
+	assert.Equal(t, int32(2), dials.Load(), "reconnects after Close")
+}
+
+func TestGRPCChecker_Name(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		target  string
+		service string
+		want    string
+	}{
+		{"dns:///billing:9090", "", "billing"},
+		{"billing:9090", "", "billing"},
+		{"billing:9090", "billing.v1.BillingService", "billing.v1.BillingService"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, health.NewGRPCChecker(tt.target, tt.service).Name())
+	}
+}
+
+// ---------- gRPC Server Tests ----------
+
+func TestGRPCServer_MirrorsReadyz(t *testing.T) {
+	t.Parallel()
+
+	var redisDown atomic.Bool
+	readyz := health.NewReadyzHandler(
+		health.Named("postgres", ok()),
+		health.Named("redis", checkerFunc(func(context.Context) error {
+			if redisDown.Load() {
+				return errors.New("redis down")
+			}
+			return nil
+		})),
+	)
+
+	s := grpc.NewServer()
+	g := health.NewGRPCServer(s, readyz)
+	dialer, _ := serveBufconn(t, s)
+	client := healthClient(t, dialer)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkStatus(t, client, ""), "not serving before first update")
+
+	g.Update(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkStatus(t, client, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkStatus(t, client, "redis"))
+
+	redisDown.Store(true)
+	g.Update(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkStatus(t, client, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkStatus(t, client, "redis"))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkStatus(t, client, "postgres"))
+}
+
+func TestGRPCServer_RunShutsDown(t *testing.T) {
+	t.Parallel()
+
+	s := grpc.NewServer()
+	g := health.NewGRPCServer(s, health.NewReadyzHandler(health.Named("postgres", ok())))
+	dialer, _ := serveBufconn(t, s)
+	client := healthClient(t, dialer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		g.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return checkStatus(t, client, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkStatus(t, client, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkStatus(t, client, "postgres"))
+}
+
+func TestGRPCServer_DegradedServes(t *testing.T) {
+	t.Parallel()
+
+	readyz := health.NewReadyzHandler(
+		health.Required(health.Named("postgres", ok())),
+		health.Optional(health.Named("redis", failing("redis down"))),
+	)
+
+	s := grpc.NewServer()
+	g := health.NewGRPCServer(s, readyz)
+	dialer, _ := serveBufconn(t, s)
+	client := healthClient(t, dialer)
+
+	g.Update(context.Background())
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, checkStatus(t, client, ""), "optional failures keep serving")
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, checkStatus(t, client, "redis"))
+}
@@ -0,0 +1,202 @@
+// Package health provides background checking so probes read a snapshot.
+// Place in: internal/health/monitor.go
+package health
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Results reported by the monitor when a check has no usable result.
+const (
+	checkPending = "pending"
+	checkStale   = "stale"
+)
+
+// ---------- Clock ----------
+
+// Clock abstracts time so tests can drive the monitor's schedule.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the real wall clock.
+var SystemClock Clock = systemClock{}
+
+// ---------- Monitor Options ----------
+
+type monitorOptions struct {
+	interval   time.Duration
+	jitter     time.Duration
+	staleAfter int
+}
+
+// MonitorOption configures a Monitor.
+type MonitorOption func(*monitorOptions)
+
+func defaultMonitorOptions() *monitorOptions {
+	return &monitorOptions{
+		interval:   10 * time.Second,
+		jitter:     time.Second,
+		staleAfter: 3,
+	}
+}
+
+// WithInterval sets how often each check runs. Default 10s.
+func WithInterval(d time.Duration) MonitorOption {
+	return func(o *monitorOptions) {
+		o.interval = d
+	}
+}
+
+// WithJitter adds up to d of random delay per check and round, so checks
+// across checkers and pods don't hit dependencies in lockstep. Default 1s.
+func WithJitter(d time.Duration) MonitorOption {
+	return func(o *monitorOptions) {
+		o.jitter = d
+	}
+}
+
+// WithStaleAfter fails a check whose last result is older than n intervals
+// (jitter included), e.g. because the checker hangs. Zero disables it. Default 3.
+func WithStaleAfter(n int) MonitorOption {
+	return func(o *monitorOptions) {
+		o.staleAfter = n
+	}
+}
+
+// ---------- Monitor ----------
+
+type monitorResult struct {
+	status string
+	at     time.Time
+}
+
+// Monitor runs a ReadyzHandler's checkers in the background and keeps the
+// latest result per check, so probes answer from memory instead of
+// waiting on dependencies. Implements BackgroundJob.
+type Monitor struct {
+	readyz *ReadyzHandler
+	opts   *monitorOptions
+	clock  Clock
+
+	mu      sync.Mutex
+	results map[string]monitorResult
+}
+
+// NewMonitor creates a monitor for readyz's checkers. From then on readyz
+// serves the monitor's snapshot; ?force=1 still checks inline.
+// Checks report "pending" until Run has checked them once.
+func NewMonitor(readyz *ReadyzHandler, opts ...MonitorOption) *Monitor {
+	o := defaultMonitorOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	m := &Monitor{
+		readyz:  readyz,
+		opts:    o,
+		clock:   SystemClock,
+		results: make(map[string]monitorResult, len(readyz.checkers)),
+	}
+	readyz.monitor = m
+
+	return m
+}
+
+// WithClock sets the clock used for scheduling and staleness.
+func (m *Monitor) WithClock(clock Clock) *Monitor {
+	m.clock = clock
+	return m
+}
+
+// Run checks every checker on its own schedule until ctx is cancelled.
+// Returns nil on clean shutdown.
+func (m *Monitor) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i, checker := range m.readyz.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.loop(ctx, m.readyz.names[i], checker)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// loop checks one checker immediately, then every interval plus jitter.
+func (m *Monitor) loop(ctx context.Context, name string, checker ReadyChecker) {
+	for {
+		status := m.readyz.check(ctx, name, checker)
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.mu.Lock()
+		m.results[name] = monitorResult{status: status, at: m.clock.Now()}
+		m.mu.Unlock()
+
+		wait := m.opts.interval
+		if m.opts.jitter > 0 {
+			wait += rand.N(m.opts.jitter)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.clock.After(wait):
+		}
+	}
+}
+
+// snapshot returns the latest result per check, failing missing and
+// stale ones.
+func (m *Monitor) snapshot() map[string]string {
+	now := m.clock.Now()
+	maxAge := time.Duration(m.opts.staleAfter) * (m.opts.interval + m.opts.jitter)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checks := make(map[string]string, len(m.readyz.names))
+	for _, name := range m.readyz.names {
+		r, ok := m.results[name]
+		switch {
+		case !ok:
+			checks[name] = checkPending
+		case m.opts.staleAfter > 0 && now.Sub(r.at) > maxAge:
+			checks[name] = checkStale
+		default:
+			checks[name] = r.status
+		}
+	}
+
+	return checks
+}
+
+// ---------- Usage Example ----------
+
+// Example setup:
+//
+//	readyz := health.NewReadyzHandler(
+//	    health.Required(health.NewPostgresChecker(pool, 20240115120000)),
+//	    health.Optional(health.Named("redis", redisChecker)),
+//	).WithCheckTimeout(2 * time.Second)
+//
+//	// Probes now read the monitor's snapshot instead of checking inline
+//	monitor := health.NewMonitor(readyz,
+//	    health.WithInterval(10*time.Second),
+//	    health.WithJitter(time.Second),
+//	    health.WithStaleAfter(3),
+//	)
+//	be.jobs = append(be.jobs, monitor) // started by backend.startJobs
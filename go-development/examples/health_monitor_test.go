@@ -0,0 +1,306 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/health"
+)
+
+// ---------- Test Helpers ----------
+
+// fakeClock fires timers only when the test advances it.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	waits  []time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+	c.waits = append(c.waits, d)
+	return timer.c
+}
+
+// Advance moves time forward and fires every timer that is due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.c <- c.now
+	}
+	c.timers = pending
+}
+
+// WaitTimers waits until n timers are scheduled, i.e. every check loop has
+// stored its result and is sleeping.
+func (c *fakeClock) WaitTimers(t *testing.T, n int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.timers) == n
+	}, time.Second, time.Millisecond)
+}
+
+func (c *fakeClock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+// toggle is a checker whose health the test flips.
+type toggle struct {
+	down atomic.Bool
+}
+
+func (c *toggle) CheckReady(context.Context) error {
+	if c.down.Load() {
+		return errors.New("down")
+	}
+	return nil
+}
+
+// runMonitor starts m and stops it when the test ends.
+func runMonitor(t *testing.T, m *health.Monitor) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
+
+// ---------- Monitor Tests ----------
+
+func TestMonitor_PendingBeforeFirstCheck(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(health.Named("postgres", ok()))
+	health.NewMonitor(h)
+
+	code, body := probe(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"postgres": "pending"}, body.Checks)
+}
+
+func TestMonitor_ServesSnapshot(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	var calls atomic.Int32
+	h := health.NewReadyzHandler(health.Named("postgres", checkerFunc(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})))
+	m := health.NewMonitor(h, health.WithInterval(10*time.Second), health.WithJitter(0)).WithClock(clock)
+
+	runMonitor(t, m)
+	clock.WaitTimers(t, 1)
+
+	for range 5 {
+		code, body := probe(t, h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", body.Checks["postgres"])
+	}
+	assert.Equal(t, int32(1), calls.Load(), "probes don't run checks")
+}
+
+func TestMonitor_TracksToggles(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	postgres, redis := &toggle{}, &toggle{}
+	h := health.NewReadyzHandler(
+		health.Required(health.Named("postgres", postgres)),
+		health.Optional(health.Named("redis", redis)),
+	)
+	m := health.NewMonitor(h, health.WithInterval(10*time.Second), health.WithJitter(0)).WithClock(clock)
+
+	runMonitor(t, m)
+	clock.WaitTimers(t, 2)
+
+	_, body := probe(t, h)
+	assert.Equal(t, "ready", body.Status)
+
+	redis.down.Store(true)
+	_, body = probe(t, h)
+	assert.Equal(t, "ready", body.Status, "unchanged until the next round")
+
+	clock.Advance(10 * time.Second)
+	clock.WaitTimers(t, 2)
+
+	code, body := probe(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", body.Status)
+	assert.Equal(t, "down", body.Checks["redis"])
+
+	postgres.down.Store(true)
+	redis.down.Store(false)
+	clock.Advance(10 * time.Second)
+	clock.WaitTimers(t, 2)
+
+	code, body = probe(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"postgres": "down", "redis": "ok"}, body.Checks)
+}
+
+func TestMonitor_StaleResultsFail(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	var hang atomic.Bool
+	h := health.NewReadyzHandler(
+		health.Named("postgres", ok()),
+		health.Named("search", checkerFunc(func(ctx context.Context) error {
+			if hang.Load() {
+				<-release
+			}
+			return nil
+		})),
+	)
+	m := health.NewMonitor(h,
+		health.WithInterval(10*time.Second),
+		health.WithJitter(0),
+		health.WithStaleAfter(3),
+	).WithClock(clock)
+
+	runMonitor(t, m)
+	clock.WaitTimers(t, 2)
+
+	// search hangs from the next round on; postgres keeps reporting
+	hang.Store(true)
+	for range 3 {
+		clock.Advance(10 * time.Second)
+		clock.WaitTimers(t, 1)
+	}
+
+	_, body := probe(t, h)
+	assert.Equal(t, "ok", body.Checks["search"], "30s old is not stale yet")
+
+	clock.Advance(10 * time.Second)
+	clock.WaitTimers(t, 1)
+
+	code, body := probe(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"postgres": "ok", "search": "stale"}, body.Checks)
+}
+
+func TestMonitor_Jitter(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	h := health.NewReadyzHandler(ok(), ok(), ok())
+	m := health.NewMonitor(h,
+		health.WithInterval(10*time.Second),
+		health.WithJitter(2*time.Second),
+	).WithClock(clock)
+
+	runMonitor(t, m)
+	clock.WaitTimers(t, 3)
+	for range 3 {
+		clock.Advance(12 * time.Second)
+		clock.WaitTimers(t, 3)
+	}
+
+	waits := clock.Waits()
+	require.Len(t, waits, 12)
+	for _, d := range waits {
+		assert.GreaterOrEqual(t, d, 10*time.Second)
+		assert.Less(t, d, 12*time.Second)
+	}
+}
+
+func TestMonitor_ForceChecksInline(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	redis := &toggle{}
+	h := health.NewReadyzHandler(health.Named("redis", redis))
+	m := health.NewMonitor(h, health.WithJitter(0)).WithClock(clock)
+
+	runMonitor(t, m)
+	clock.WaitTimers(t, 1)
+
+	redis.down.Store(true)
+
+	_, body := probe(t, h)
+	assert.Equal(t, "ok", body.Checks["redis"])
+
+	_, body = probePath(t, h, "/?force=1")
+	assert.Equal(t, "down", body.Checks["redis"])
+}
+
+func TestMonitor_RecordsMetrics(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	redis := &toggle{}
+	redis.down.Store(true)
+
+	reg := prometheus.NewRegistry()
+	h := health.NewReadyzHandler(health.Named("redis", redis)).WithMetrics(health.NewMetrics(reg))
+	m := health.NewMonitor(h, health.WithInterval(10*time.Second), health.WithJitter(0)).WithClock(clock)
+
+	runMonitor(t, m)
+	clock.WaitTimers(t, 1)
+	clock.Advance(10 * time.Second)
+	clock.WaitTimers(t, 1)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP health_check_failures_total Failed health checks, including timeouts.
+# TYPE health_check_failures_total counter
+health_check_failures_total{check="redis"} 2
+# HELP health_check_status Result of the last health check: 1 passed, 0 failed.
+# TYPE health_check_status gauge
+health_check_status{check="redis"} 0
+`), "health_check_status", "health_check_failures_total"), "recorded without any probe")
+}
@@ -0,0 +1,180 @@
+//go:build linux || darwin
+
+// Package health provides disk space and memory pressure checkers.
+// Place in: internal/health/system.go
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime/metrics"
+	"syscall"
+)
+
+// ---------- Disk Checker ----------
+
+// DiskUsage is the capacity and space available to the process on a filesystem.
+type DiskUsage struct {
+	Total uint64
+	Free  uint64
+}
+
+// DiskChecker fails when a filesystem runs low on free space, before
+// writes start failing and the pod goes read-only.
+type DiskChecker struct {
+	path           string
+	minFreeBytes   uint64
+	minFreePercent float64
+	stat           func(path string) (DiskUsage, error)
+}
+
+// NewDiskChecker creates a checker for the filesystem holding path.
+// Zero disables a threshold.
+func NewDiskChecker(path string, minFreeBytes uint64, minFreePercent float64) *DiskChecker {
+	return &DiskChecker{
+		path:           path,
+		minFreeBytes:   minFreeBytes,
+		minFreePercent: minFreePercent,
+		stat:           statfs,
+	}
+}
+
+// WithStatFunc replaces statfs, e.g. with fixed numbers in tests.
+func (c *DiskChecker) WithStatFunc(fn func(path string) (DiskUsage, error)) *DiskChecker {
+	c.stat = fn
+	return c
+}
+
+// Name implements NamedChecker, e.g. "disk:/data".
+func (c *DiskChecker) Name() string {
+	return "disk:" + c.path
+}
+
+// CheckReady checks free space against both thresholds. A single statfs
+// call, cheap enough for inline checks.
+func (c *DiskChecker) CheckReady(_ context.Context) error {
+	usage, err := c.stat(c.path)
+	if err != nil {
+		return fmt.Errorf("disk: %w", err)
+	}
+
+	if c.minFreeBytes > 0 && usage.Free < c.minFreeBytes {
+		return fmt.Errorf("disk: %s free < %s required", formatBytes(usage.Free), formatBytes(c.minFreeBytes))
+	}
+
+	if c.minFreePercent > 0 && usage.Total > 0 {
+		freePercent := float64(usage.Free) / float64(usage.Total) * 100
+		if freePercent < c.minFreePercent {
+			return fmt.Errorf("disk: %.1f%% free < %g%% required", freePercent, c.minFreePercent)
+		}
+	}
+
+	return nil
+}
+
+// statfs reports space available to unprivileged users, which is what
+// the service can actually write.
+func statfs(path string) (DiskUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return DiskUsage{}, fmt.Errorf("statfs %s: %w", path, err)
+	}
+
+	return DiskUsage{
+		Total: st.Blocks * uint64(st.Bsize),
+		Free:  st.Bavail * uint64(st.Bsize),
+	}, nil
+}
+
+// ---------- Memory Checker ----------
+
+// MemoryChecker fails when the process uses more memory than allowed,
+// so the pod leaves the load balancer before the OOM killer strikes.
+type MemoryChecker struct {
+	maxBytes uint64
+	usage    func() (uint64, error)
+}
+
+// NewMemoryChecker creates a checker with a limit, typically ~90% of
+// the container memory limit.
+func NewMemoryChecker(maxRSSBytes uint64) *MemoryChecker {
+	return &MemoryChecker{
+		maxBytes: maxRSSBytes,
+		usage:    runtimeMemory,
+	}
+}
+
+// WithUsageFunc replaces the memory reader, e.g. with fixed numbers in tests.
+func (c *MemoryChecker) WithUsageFunc(fn func() (uint64, error)) *MemoryChecker {
+	c.usage = fn
+	return c
+}
+
+// Name implements NamedChecker.
+func (c *MemoryChecker) Name() string {
+	return "memory"
+}
+
+// CheckReady checks memory in use against the limit.
+func (c *MemoryChecker) CheckReady(_ context.Context) error {
+	used, err := c.usage()
+	if err != nil {
+		return fmt.Errorf("memory: %w", err)
+	}
+
+	if used > c.maxBytes {
+		return fmt.Errorf("memory: %s in use > %s limit", formatBytes(used), formatBytes(c.maxBytes))
+	}
+
+	return nil
+}
+
+// Go runtime memory, mapped minus returned to the OS. Close to RSS for
+// pure-Go programs; cgo allocations are not included.
+var memorySamples = []string{
+	"/memory/classes/total:bytes",
+	"/memory/classes/heap/released:bytes",
+}
+
+// runtimeMemory reads memory in use from runtime/metrics, which does not
+// stop the world, unlike runtime.ReadMemStats.
+func runtimeMemory() (uint64, error) {
+	samples := make([]metrics.Sample, len(memorySamples))
+	for i, name := range memorySamples {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	for _, s := range samples {
+		if s.Value.Kind() != metrics.KindUint64 {
+			return 0, fmt.Errorf("runtime metric %s unsupported", s.Name)
+		}
+	}
+
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64(), nil
+}
+
+// formatBytes formats n with binary units, e.g. "1.5 GiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// ---------- Usage Example ----------
+
+// Example setup:
+//
+//	readyz := health.NewReadyzHandler(
+//	    health.Required(health.NewPostgresChecker(pool, 20240115120000)),
+//	    health.Required(health.NewDiskChecker("/data", 1<<30, 5)), // 1 GiB and 5% free
+//	    health.NewMemoryChecker(1800<<20),                         // 90% of a 2 GiB limit
+//	)
@@ -0,0 +1,134 @@
+//go:build linux || darwin
+
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/health"
+)
+
+const gib = 1 << 30
+
+// ---------- Test Helpers ----------
+
+func diskUsage(total, free uint64) func(string) (health.DiskUsage, error) {
+	return func(string) (health.DiskUsage, error) {
+		return health.DiskUsage{Total: total, Free: free}, nil
+	}
+}
+
+func memoryUsage(used uint64) func() (uint64, error) {
+	return func() (uint64, error) { return used, nil }
+}
+
+// ---------- Disk Checker Tests ----------
+
+func TestDiskChecker_TempDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	assert.NoError(t, health.NewDiskChecker(dir, 1, 0).CheckReady(context.Background()))
+	assert.ErrorContains(t,
+		health.NewDiskChecker(dir, 1<<62, 0).CheckReady(context.Background()),
+		"required", "no filesystem has 4 EiB free")
+}
+
+func TestDiskChecker_Thresholds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		total, free    uint64
+		minFreeBytes   uint64
+		minFreePercent float64
+		wantErr        string
+	}{
+		{"enough space", 100 * gib, 50 * gib, gib, 5, ""},
+		{"below percent", 100 * gib, 21 * gib / 10, gib, 5, "disk: 2.1% free < 5% required"},
+		{"below bytes", 100 * gib, gib / 2, gib, 0, "disk: 512.0 MiB free < 1.0 GiB required"},
+		{"bytes only", 100 * gib, 2 * gib, gib, 0, ""},
+		{"percent only", 100 * gib, 10 * gib, 0, 5, ""},
+		{"thresholds disabled", 100 * gib, 0, 0, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := health.NewDiskChecker("/data", tt.minFreeBytes, tt.minFreePercent).
+				WithStatFunc(diskUsage(tt.total, tt.free))
+
+			err := c.CheckReady(context.Background())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDiskChecker_StatError(t *testing.T) {
+	t.Parallel()
+
+	statErr := errors.New("statfs /data: no such file or directory")
+	c := health.NewDiskChecker("/data", gib, 5).WithStatFunc(func(string) (health.DiskUsage, error) {
+		return health.DiskUsage{}, statErr
+	})
+
+	err := c.CheckReady(context.Background())
+
+	require.ErrorIs(t, err, statErr)
+	assert.EqualError(t, err, "disk: statfs /data: no such file or directory")
+}
+
+func TestDiskChecker_Name(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "disk:/data", health.NewDiskChecker("/data", gib, 5).Name())
+}
+
+// ---------- Memory Checker Tests ----------
+
+func TestMemoryChecker_Limit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		used    uint64
+		wantErr string
+	}{
+		{"under limit", gib, ""},
+		{"at limit", 1.5 * gib, ""},
+		{"over limit", 19 * gib / 10, "memory: 1.9 GiB in use > 1.5 GiB limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := health.NewMemoryChecker(1.5 * gib).WithUsageFunc(memoryUsage(tt.used))
+
+			err := c.CheckReady(context.Background())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMemoryChecker_Runtime(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, health.NewMemoryChecker(1<<62).CheckReady(context.Background()))
+	assert.ErrorContains(t, health.NewMemoryChecker(1).CheckReady(context.Background()), "in use > 1 B limit")
+}
@@ -0,0 +1,667 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/health"
+)
+
+// ---------- Test Helpers ----------
+
+// checkerFunc adapts a function to health.ReadyChecker.
+type checkerFunc func(ctx context.Context) error
+
+func (f checkerFunc) CheckReady(ctx context.Context) error { return f(ctx) }
+
+func ok() health.ReadyChecker {
+	return checkerFunc(func(context.Context) error { return nil })
+}
+
+func failing(msg string) health.ReadyChecker {
+	return checkerFunc(func(context.Context) error { return errors.New(msg) })
+}
+
+// sleeping ignores its context, like a checker stuck in a blocking call.
+func sleeping(d time.Duration) health.ReadyChecker {
+	return checkerFunc(func(context.Context) error {
+		time.Sleep(d)
+		return nil
+	})
+}
+
+// counting counts calls and takes a little time, so parallel probes overlap.
+func counting(calls *atomic.Int32) health.ReadyChecker {
+	return checkerFunc(func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+}
+
+type readyzBody struct {
+	Status     string            `json:"status"`
+	Checks     map[string]string `json:"checks"`
+	DurationMS int64             `json:"duration_ms"`
+}
+
+func probe(t *testing.T, h http.Handler) (int, readyzBody) {
+	t.Helper()
+	return probePath(t, h, "/")
+}
+
+func probePath(t *testing.T, h http.Handler, path string) (int, readyzBody) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body readyzBody
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	return rec.Code, body
+}
+
+type startupzBody struct {
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components"`
+}
+
+func probeStartupz(t *testing.T, h http.Handler) (int, startupzBody) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body startupzBody
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	return rec.Code, body
+}
+
+// ---------- Startupz Tests ----------
+
+func TestStartupz_PartialComponents(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewStartupzHandler("cache", "search-index")
+	h.MarkComponentReady("cache")
+
+	code, body := probeStartupz(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "starting", body.Status)
+	assert.Equal(t, map[string]string{"cache": "ready", "search-index": "pending"}, body.Components)
+	assert.False(t, h.Started())
+}
+
+func TestStartupz_AllComponents(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewStartupzHandler("cache", "search-index")
+	h.MarkComponentReady("cache")
+	h.MarkComponentReady("search-index")
+
+	code, body := probeStartupz(t, h)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "started", body.Status)
+	assert.Equal(t, map[string]string{"cache": "ready", "search-index": "ready"}, body.Components)
+	assert.True(t, h.Started())
+}
+
+func TestStartupz_UnknownComponentIgnored(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewStartupzHandler("cache")
+	h.MarkComponentReady("typo")
+
+	code, body := probeStartupz(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"cache": "pending"}, body.Components)
+}
+
+func TestStartupz_MarkReady(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no components", func(t *testing.T) {
+		t.Parallel()
+
+		h := health.NewStartupzHandler()
+		code, _ := probeStartupz(t, h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+
+		h.MarkReady()
+		code, body := probeStartupz(t, h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, body.Components)
+	})
+
+	t.Run("marks pending components", func(t *testing.T) {
+		t.Parallel()
+
+		h := health.NewStartupzHandler("cache", "search-index")
+		h.MarkReady()
+
+		code, body := probeStartupz(t, h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]string{"cache": "ready", "search-index": "ready"}, body.Components)
+	})
+}
+
+func TestStartupz_ConcurrentMarking(t *testing.T) {
+	t.Parallel()
+
+	names := make([]string, 50)
+	for i := range names {
+		names[i] = fmt.Sprintf("component-%d", i)
+	}
+	h := health.NewStartupzHandler(names...)
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h.MarkComponentReady(name)
+		}()
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	wg.Wait()
+
+	code, body := probeStartupz(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body.Components, len(names))
+	assert.True(t, h.Started())
+}
+
+// ---------- Readyz Tests ----------
+
+func TestReadyz_AllPass(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(health.Named("postgres", ok()), health.Named("redis", ok()))
+
+	code, body := probe(t, h)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body.Status)
+	assert.Equal(t, map[string]string{"postgres": "ok", "redis": "ok"}, body.Checks)
+}
+
+func TestReadyz_ReportsAllFailures(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		health.Named("postgres", ok()),
+		health.Named("redis", failing("redis: connection refused")),
+		health.Named("auth-service", failing("http checker: status 502")),
+	)
+
+	code, body := probe(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, map[string]string{
+		"postgres":     "ok",
+		"redis":        "redis: connection refused",
+		"auth-service": "http checker: status 502",
+	}, body.Checks)
+}
+
+func TestReadyz_CheckTimeout(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		health.Named("postgres", ok()),
+		health.Named("auth-service", sleeping(2*time.Second)),
+	).WithCheckTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	code, body := probe(t, h)
+
+	assert.Less(t, time.Since(start), time.Second, "probe waits for the timeout, not the checker")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"postgres": "ok", "auth-service": "timeout"}, body.Checks)
+}
+
+func TestReadyz_CheckerNames(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		ok(),
+		health.Named("cache", ok()),
+		health.Named("cache", ok()),
+		health.NewHTTPChecker("http://auth-service:8081/check/healthz/"),
+	).WithCheckTimeout(time.Millisecond)
+
+	_, body := probe(t, h)
+
+	assert.Contains(t, body.Checks, "check_1")
+	assert.Contains(t, body.Checks, "cache")
+	assert.Contains(t, body.Checks, "cache_2")
+	assert.Contains(t, body.Checks, "auth-service")
+}
+
+func TestReadyz_NoCheckers(t *testing.T) {
+	t.Parallel()
+
+	code, body := probe(t, health.NewReadyzHandler())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body.Status)
+}
+
+// ---------- Readyz Safety Tests ----------
+
+func TestReadyz_PanickingChecker(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		health.Named("postgres", ok()),
+		health.Named("plugin", checkerFunc(func(context.Context) error {
+			panic("nil map write")
+		})),
+	)
+
+	code, body := probe(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"postgres": "ok", "plugin": "panic: nil map write"}, body.Checks)
+
+	code, _ = probe(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "handler keeps serving after a panic")
+}
+
+func TestReadyz_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	var inflight, peak atomic.Int32
+	tracking := checkerFunc(func(context.Context) error {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	checkers := make([]health.ReadyChecker, 8)
+	for i := range checkers {
+		checkers[i] = tracking
+	}
+	h := health.NewReadyzHandler(checkers...).WithConcurrency(2)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(2), "bounded across concurrent probes")
+
+	code, body := probe(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body.Checks, 8)
+}
+
+func TestReadyz_Budget(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		health.Named("a", sleeping(2*time.Second)),
+		health.Named("b", sleeping(2*time.Second)),
+		health.Named("c", sleeping(2*time.Second)),
+	).WithConcurrency(1).WithBudget(50 * time.Millisecond)
+
+	start := time.Now()
+	code, body := probe(t, h)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"a": "timeout", "b": "timeout", "c": "timeout"}, body.Checks,
+		"queued checks time out with the budget too")
+	assert.GreaterOrEqual(t, body.DurationMS, int64(50))
+}
+
+// ---------- Readyz Severity Tests ----------
+
+func TestReadyz_Severity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		checkers   []health.ReadyChecker
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name: "all pass",
+			checkers: []health.ReadyChecker{
+				health.Required(health.Named("postgres", ok())),
+				health.Optional(health.Named("redis", ok())),
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: "ready",
+		},
+		{
+			name: "optional fails",
+			checkers: []health.ReadyChecker{
+				health.Required(health.Named("postgres", ok())),
+				health.Optional(health.Named("redis", failing("redis down"))),
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: "degraded",
+		},
+		{
+			name: "required fails",
+			checkers: []health.ReadyChecker{
+				health.Required(health.Named("postgres", failing("postgres down"))),
+				health.Optional(health.Named("redis", failing("redis down"))),
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "unavailable",
+		},
+		{
+			name: "unmarked checkers are required",
+			checkers: []health.ReadyChecker{
+				health.Named("postgres", failing("postgres down")),
+				health.Optional(health.Named("redis", ok())),
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, body := probe(t, health.NewReadyzHandler(tt.checkers...))
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantStatus, body.Status)
+		})
+	}
+}
+
+func TestReadyz_DegradedJSON(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		health.Required(health.Named("postgres", ok())),
+		health.Optional(health.Named("redis", failing("redis: connection refused"))),
+	)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Contains(t, body, "duration_ms")
+	delete(body, "duration_ms")
+
+	got, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"status": "degraded",
+		"checks": {"postgres": "ok", "redis": "redis: connection refused"}
+	}`, string(got))
+}
+
+func TestReadyz_SeverityKeepsNames(t *testing.T) {
+	t.Parallel()
+
+	h := health.NewReadyzHandler(
+		health.Optional(health.Named("redis", failing("redis down"))),
+		health.Named("search", health.Optional(failing("search down"))),
+		health.Optional(failing("unnamed down")),
+	)
+
+	code, body := probe(t, h)
+
+	assert.Equal(t, http.StatusOK, code, "all failures are optional")
+	assert.Equal(t, "degraded", body.Status)
+	assert.Equal(t, map[string]string{
+		"redis":   "redis down",
+		"search":  "search down",
+		"check_3": "unnamed down",
+	}, body.Checks)
+}
+
+func TestReadyz_StatusMetric(t *testing.T) {
+	t.Parallel()
+
+	var redisDown atomic.Bool
+	reg := prometheus.NewRegistry()
+	h := health.NewReadyzHandler(
+		health.Required(health.Named("postgres", ok())),
+		health.Optional(health.Named("redis", checkerFunc(func(context.Context) error {
+			if redisDown.Load() {
+				return errors.New("redis down")
+			}
+			return nil
+		}))),
+	).WithMetrics(health.NewMetrics(reg))
+
+	probe(t, h)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP health_readiness_status Current readiness status: 1 for the active status, 0 otherwise.
+# TYPE health_readiness_status gauge
+health_readiness_status{status="degraded"} 0
+health_readiness_status{status="ready"} 1
+health_readiness_status{status="unavailable"} 0
+`), "health_readiness_status"))
+
+	redisDown.Store(true)
+	probe(t, h)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP health_readiness_status Current readiness status: 1 for the active status, 0 otherwise.
+# TYPE health_readiness_status gauge
+health_readiness_status{status="degraded"} 1
+health_readiness_status{status="ready"} 0
+health_readiness_status{status="unavailable"} 0
+`), "health_readiness_status"))
+}
+
+// ---------- Metrics Tests ----------
+
+func TestMetrics_InlineChecks(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	h := health.NewReadyzHandler(
+		health.Named("postgres", ok()),
+		health.Named("redis", failing("redis down")),
+		health.Named("search", sleeping(time.Second)),
+	).WithCheckTimeout(10 * time.Millisecond).WithMetrics(health.NewMetrics(reg))
+
+	probe(t, h)
+	probe(t, h)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP health_check_failures_total Failed health checks, including timeouts.
+# TYPE health_check_failures_total counter
+health_check_failures_total{check="redis"} 2
+health_check_failures_total{check="search"} 2
+# HELP health_check_status Result of the last health check: 1 passed, 0 failed.
+# TYPE health_check_status gauge
+health_check_status{check="postgres"} 1
+health_check_status{check="redis"} 0
+health_check_status{check="search"} 0
+`), "health_check_status", "health_check_failures_total"))
+
+	series, err := testutil.GatherAndCount(reg, "health_check_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 3, series, "one histogram per check")
+}
+
+func TestMetrics_Recovery(t *testing.T) {
+	t.Parallel()
+
+	var down atomic.Bool
+	down.Store(true)
+
+	reg := prometheus.NewRegistry()
+	h := health.NewReadyzHandler(health.Named("redis", checkerFunc(func(context.Context) error {
+		if down.Load() {
+			return errors.New("redis down")
+		}
+		return nil
+	}))).WithMetrics(health.NewMetrics(reg))
+
+	probe(t, h)
+	down.Store(false)
+	probe(t, h)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP health_check_failures_total Failed health checks, including timeouts.
+# TYPE health_check_failures_total counter
+health_check_failures_total{check="redis"} 1
+# HELP health_check_status Result of the last health check: 1 passed, 0 failed.
+# TYPE health_check_status gauge
+health_check_status{check="redis"} 1
+`), "health_check_status", "health_check_failures_total"))
+}
+
+// ---------- Readyz Cache Tests ----------
+
+func TestReadyz_CacheServesWithinTTL(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	h := health.NewReadyzHandler(health.Named("postgres", counting(&calls))).WithCacheTTL(time.Minute)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "parallel probes share one refresh")
+
+	code, body := probe(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body.Checks["postgres"])
+	assert.Equal(t, int32(1), calls.Load(), "served from cache")
+}
+
+func TestReadyz_CacheForceBypasses(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	h := health.NewReadyzHandler(counting(&calls)).WithCacheTTL(time.Minute)
+
+	probe(t, h)
+	probePath(t, h, "/?force=1")
+	probe(t, h)
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestReadyz_CacheExpires(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	h := health.NewReadyzHandler(counting(&calls)).WithCacheTTL(50 * time.Millisecond)
+
+	probe(t, h)
+	time.Sleep(100 * time.Millisecond)
+	probe(t, h)
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestReadyz_CacheKeepsFailures(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	h := health.NewReadyzHandler(health.Named("redis", checkerFunc(func(context.Context) error {
+		calls.Add(1)
+		return errors.New("redis down")
+	}))).WithCacheTTL(time.Minute)
+
+	for range 3 {
+		code, body := probe(t, h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "redis down", body.Checks["redis"])
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// ---------- Postgres Checker Tests ----------
+
+func TestPostgresCheckerFromMigrations(t *testing.T) {
+	t.Parallel()
+
+	migrations := fstest.MapFS{
+		"00001_init.sql":                   {},
+		"20240115120000_add_users.sql":     {},
+		"20240201090000_backfill_users.go": {},
+		"29990101000000_helpers_test.go":   {},
+		"README.md":                        {},
+		"embed.go":                         {},
+		"99999999999999_nested/up.sql":     {},
+	}
+
+	c, err := health.NewPostgresCheckerFromMigrations(nil, migrations)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(20240201090000), c.SchemaVersion())
+}
+
+func TestPostgresCheckerFromMigrations_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		migrations fstest.MapFS
+		wantErr    string
+	}{
+		{"empty", fstest.MapFS{}, "postgres checker: no migrations found"},
+		{"no migration files", fstest.MapFS{"README.md": {}}, "postgres checker: no migrations found"},
+		{"version overflow", fstest.MapFS{"99999999999999999999_huge.sql": {}}, "value out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := health.NewPostgresCheckerFromMigrations(nil, tt.migrations)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -5,6 +5,8 @@
 // - API error response format
 // - Request ID for tracing
 // - Logging integration with internal error protection
+// - Messages localized to the request's locale
+// - RFC 7807 problem+json responses, negotiated via Accept (http_problem.go)
 package handler
 
 import (
@@ -14,76 +16,129 @@ import (
 
 	"github.com/go-chi/chi/v5/middleware"
 
-	"project/internal/errors"
+	"myapp/internal/errors"
+	httpmw "myapp/internal/middleware"
 )
 
 // ---------- API Error Response ----------
 
 // ErrorResponse is the standard API error response format.
 type ErrorResponse struct {
-	Error     string            `json:"error"`
-	Code      string            `json:"code,omitempty"`
-	Details   map[string]string `json:"details,omitempty"`
-	RequestID string            `json:"request_id,omitempty"`
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ---------- Error Handler ----------
 
 // ErrorHandler handles errors and writes appropriate HTTP responses.
 type ErrorHandler struct {
-	logger *slog.Logger
+	logger          *slog.Logger
+	formats         []ErrorFormat // see WithFormats
+	problemTypeBase string
 }
 
-// NewErrorHandler creates a new ErrorHandler.
-func NewErrorHandler(logger *slog.Logger) *ErrorHandler {
-	return &ErrorHandler{logger: logger}
+// NewErrorHandler creates a new ErrorHandler. It writes ErrorResponse
+// bodies unless WithFormats says otherwise.
+func NewErrorHandler(logger *slog.Logger, opts ...ErrorHandlerOption) *ErrorHandler {
+	h := &ErrorHandler{
+		logger:          logger,
+		formats:         []ErrorFormat{FormatLegacy},
+		problemTypeBase: DefaultProblemTypeBase,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// Handle converts an error to an HTTP response.
+// Handle converts an error to an HTTP response, with the message in the
+// request's locale (see errors.Localize and httpmw.Locale). Canceled
+// requests get a bare 499, see errors.IsCanceled.
 func (h *ErrorHandler) Handle(w http.ResponseWriter, r *http.Request, err error) {
 	reqID := middleware.GetReqID(r.Context())
+	if errors.IsCanceled(err) {
+		h.canceled(w, r, reqID, err)
+		return
+	}
 	status := errors.HTTPStatusCode(err)
-	message := errors.ErrorMessage(err)
+	message := errors.Localize(err, httpmw.LocaleFromContext(r.Context()))
 
 	// Log internal errors with full details
 	if status == http.StatusInternalServerError {
-		h.logger.Error("internal error",
-			slog.String("request_id", reqID),
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.String("error", err.Error()),
-		)
+		h.logInternal(r, reqID, err)
 	}
+	errors.Observe("", err)
 
-	h.writeError(w, status, message, string(errors.GetErrorCode(err)), reqID)
+	h.respond(w, r, status, message, string(errors.GetErrorCode(err)), reqID, err)
 }
 
 // HandleWithCode handles error with a custom error code.
 func (h *ErrorHandler) HandleWithCode(w http.ResponseWriter, r *http.Request, err error, code string) {
 	reqID := middleware.GetReqID(r.Context())
+	if errors.IsCanceled(err) {
+		h.canceled(w, r, reqID, err)
+		return
+	}
 	status := errors.HTTPStatusCode(err)
-	message := errors.ErrorMessage(err)
+	message := errors.Localize(err, httpmw.LocaleFromContext(r.Context()))
 
 	if status == http.StatusInternalServerError {
-		h.logger.Error("internal error",
-			slog.String("request_id", reqID),
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.String("error", err.Error()),
-		)
+		h.logInternal(r, reqID, err)
 	}
+	errors.Observe("", err)
+
+	h.respond(w, r, status, message, code, reqID, err)
+}
 
-	h.writeError(w, status, message, code, reqID)
+// logInternal logs err with its stack, if one was captured.
+func (h *ErrorHandler) logInternal(r *http.Request, reqID string, err error) {
+	attrs := []slog.Attr{
+		slog.String("request_id", reqID),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("error", err.Error()),
+	}
+	if stack := errors.StackTrace(err); stack != nil {
+		attrs = append(attrs, slog.Any("stack", stack))
+	}
+	h.logger.LogAttrs(r.Context(), slog.LevelError, "internal error", attrs...)
+}
+
+// canceled answers a request the client gave up on. It logs at debug,
+// since nothing is wrong with the service, and writes no body, since
+// nobody reads it.
+func (h *ErrorHandler) canceled(w http.ResponseWriter, r *http.Request, reqID string, err error) {
+	h.logger.LogAttrs(r.Context(), slog.LevelDebug, "request canceled",
+		slog.String("request_id", reqID),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("error", err.Error()),
+	)
+	errors.Observe("", err)
+	w.WriteHeader(errors.StatusClientClosedRequest)
 }
 
 // ---------- Response Writers ----------
 
-func (h *ErrorHandler) writeError(w http.ResponseWriter, status int, message, code, requestID string) {
+func (h *ErrorHandler) respond(w http.ResponseWriter, r *http.Request, status int, message, code, requestID string, err error) {
+	if h.format(r) == FormatProblem {
+		writeProblem(w, r, err, code, h.problemTypeBase)
+		return
+	}
+	h.writeError(w, status, message, code, requestID, err)
+}
+
+func (h *ErrorHandler) writeError(w http.ResponseWriter, status int, message, code, requestID string, err error) {
 	resp := ErrorResponse{
 		Error:     message,
 		Code:      code,
 		RequestID: requestID,
 	}
+	if details := errors.Details(err); len(details) > 0 {
+		resp.Details = details
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -99,8 +154,16 @@ func WriteJSON(w http.ResponseWriter, status int, v any) {
 	json.NewEncoder(w).Encode(v)
 }
 
-// WriteError writes an error response.
+// WriteError writes an error response, with the error's details unless
+// it is internal, or a bare 499 if the request was canceled. The message
+// is not localized; ErrorHandler.Handle is.
 func WriteError(w http.ResponseWriter, err error, requestID string) {
+	errors.Observe("", err)
+	if errors.IsCanceled(err) {
+		w.WriteHeader(errors.StatusClientClosedRequest) // the client is gone
+		return
+	}
+
 	status := errors.HTTPStatusCode(err)
 	message := errors.ErrorMessage(err)
 	code := errors.GetErrorCode(err)
@@ -110,6 +173,9 @@ func WriteError(w http.ResponseWriter, err error, requestID string) {
 		Code:      string(code),
 		RequestID: requestID,
 	}
+	if details := errors.Details(err); len(details) > 0 {
+		resp.Details = details
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+	httpmw "myapp/internal/middleware"
+)
+
+// ---------- WriteError Tests ----------
+
+func TestWriteError_Details(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "details in sorted order",
+			err: fmt.Errorf("UserService.Update: %w", apperrors.New(apperrors.CodeInvalid, "name too long").
+				WithDetail("max", 100).
+				WithDetail("field", "name")),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"name too long","code":"invalid","details":{"field":"name","max":100},"request_id":"req-1"}`},
+		{name: "no details",
+			err:        apperrors.New(apperrors.CodeNotFound, "user not found"),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"user not found","code":"not_found","request_id":"req-1"}`},
+		{name: "internal details suppressed",
+			err:        apperrors.Internal("query", errors.New("boom")).WithDetail("sql", "SELECT secret"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"an internal error has occurred","code":"internal","request_id":"req-1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			WriteError(rec, tt.err, "req-1")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, tt.wantBody+"\n", rec.Body.String(), "stable field and key order")
+		})
+	}
+}
+
+// ---------- ErrorHandler Tests ----------
+
+func TestErrorHandler_Handle_Localized(t *testing.T) {
+	t.Parallel()
+
+	apperrors.RegisterCatalog("ru", map[string]string{
+		apperrors.CodeNotFound.MessageKey(): "не найдено",
+		apperrors.KeyValidationFailed:       "ошибка валидации",
+	})
+	var verrs apperrors.ValidationErrors
+	verrs.Add("email", "email", "invalid email format")
+
+	tests := []struct {
+		name     string
+		err      error
+		locale   string
+		problem  bool
+		wantBody string
+	}{
+		{name: "translated", err: apperrors.New(apperrors.CodeNotFound, "user not found"), locale: "ru-RU",
+			wantBody: `{"error":"не найдено","code":"not_found"}`},
+		{name: "untranslated falls back to English", err: apperrors.New(apperrors.CodeConflict, "user exists"), locale: "ru",
+			wantBody: `{"error":"user exists","code":"conflict"}`},
+		{name: "no Locale middleware", err: apperrors.New(apperrors.CodeNotFound, "user not found"), locale: "",
+			wantBody: `{"error":"user not found","code":"not_found"}`},
+		{name: "problem detail", err: verrs, locale: "ru", problem: true,
+			wantBody: `{"type":"https://errors.example.com/invalid","title":"Bad Request","status":400,` +
+				`"detail":"ошибка валидации","instance":"/users","errors":{"email":"invalid email format"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			format := FormatLegacy
+			if tt.problem {
+				format = FormatProblem
+			}
+			h := NewErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), WithFormats(format))
+
+			r := httptest.NewRequest(http.MethodPost, "/users", nil)
+			if tt.locale != "" {
+				r = r.WithContext(httpmw.WithLocale(r.Context(), tt.locale))
+			}
+			rec := httptest.NewRecorder()
+			h.Handle(rec, r, tt.err)
+
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+// Observer tests don't run in parallel: the observer is global.
+
+func TestErrorWriters_Observe(t *testing.T) {
+	var got []string
+	apperrors.SetObserver(func(code apperrors.ErrorCode, op string) {
+		got = append(got, string(code)+" "+op)
+	})
+	t.Cleanup(func() { apperrors.SetObserver(nil) })
+
+	h := NewErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+
+	h.Handle(httptest.NewRecorder(), r,
+		apperrors.WithOp(apperrors.New(apperrors.CodeNotFound, "user not found"), "UserService.Get"))
+	h.HandleWithCode(httptest.NewRecorder(), r,
+		apperrors.WithOp(apperrors.New(apperrors.CodeUnauthorized, "token expired"), "Auth.Verify"), "token_expired")
+	WriteError(httptest.NewRecorder(), apperrors.WithOp(errors.New("boom"), "UserService.Create"), "req-1")
+	WriteProblem(httptest.NewRecorder(), r, apperrors.New(apperrors.CodeConflict, "user exists"))
+
+	assert.Equal(t, []string{
+		"not_found UserService.Get",
+		"unauthorized Auth.Verify",
+		"internal UserService.Create",
+		"conflict ",
+	}, got)
+}
+
+func TestErrorHandler_Handle_Context(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		problem    bool
+		wantStatus int
+		wantBody   string
+		wantLevel  string
+	}{
+		{name: "canceled, no body", err: fmt.Errorf("UserRepo.Get: %w", context.Canceled),
+			wantStatus: apperrors.StatusClientClosedRequest, wantBody: "", wantLevel: "DEBUG"},
+		{name: "canceled problem, no body", err: fmt.Errorf("UserRepo.Get: %w", context.Canceled), problem: true,
+			wantStatus: apperrors.StatusClientClosedRequest, wantBody: "", wantLevel: "DEBUG"},
+		{name: "canceled under internal", err: apperrors.Internal("load", context.Canceled),
+			wantStatus: apperrors.StatusClientClosedRequest, wantBody: "", wantLevel: "DEBUG"},
+		{name: "deadline", err: fmt.Errorf("UserRepo.Get: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantBody:   `{"error":"request timed out","code":"timeout"}` + "\n", wantLevel: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			format := FormatLegacy
+			if tt.problem {
+				format = FormatProblem
+			}
+			h := NewErrorHandler(logger, WithFormats(format))
+
+			rec := httptest.NewRecorder()
+			h.Handle(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil), tt.err)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			if tt.wantLevel == "" {
+				assert.Empty(t, logs.String())
+				return
+			}
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+			assert.Equal(t, tt.wantLevel, entry["level"])
+			assert.Equal(t, "request canceled", entry["msg"])
+		})
+	}
+}
+
+func TestWriteError_Canceled(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	WriteError(rec, fmt.Errorf("UserRepo.Get: %w", context.Canceled), "req-1")
+	assert.Equal(t, apperrors.StatusClientClosedRequest, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	WriteProblem(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil), context.Canceled)
+	assert.Equal(t, apperrors.StatusClientClosedRequest, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
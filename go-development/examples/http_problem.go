@@ -0,0 +1,171 @@
+// Package handler provides RFC 7807 problem details error responses.
+package handler
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"myapp/internal/errors"
+	httpmw "myapp/internal/middleware"
+)
+
+// DefaultProblemTypeBase prefixes the error code in a problem's type URI.
+const DefaultProblemTypeBase = "https://errors.example.com/"
+
+// ---------- Problem Response ----------
+
+// Problem is an RFC 7807 application/problem+json response. Errors and
+// Details are extension members carrying the same data as
+// ErrorResponse.Details.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`  // per-field validation messages
+	Details   map[string]any    `json:"details,omitempty"` // see errors.Details
+}
+
+// WriteProblem writes err as application/problem+json:
+//
+//	{"type": "https://errors.example.com/not_found", "title": "Not Found",
+//	 "status": 404, "detail": "user not found", "instance": "/users/42",
+//	 "request_id": "..."}
+//
+// The detail is in the request's locale, see errors.Localize. Internal
+// errors get the generic detail and no extension members; canceled
+// requests get a bare 499.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	errors.Observe("", err)
+	if errors.IsCanceled(err) {
+		w.WriteHeader(errors.StatusClientClosedRequest) // the client is gone
+		return
+	}
+	writeProblem(w, r, err, string(errors.GetErrorCode(err)), DefaultProblemTypeBase)
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, err error, code, typeBase string) {
+	status := errors.HTTPStatusCode(err)
+	p := Problem{
+		Type:      typeBase + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    errors.Localize(err, httpmw.LocaleFromContext(r.Context())),
+		Instance:  r.URL.Path,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	if !errors.IsInternal(err) {
+		var verrs errors.ValidationErrors
+		if stderrors.As(err, &verrs) {
+			p.Errors = verrs.ToDetails()
+		}
+		p.Details = errors.Details(err)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// ---------- Format Negotiation ----------
+
+// ErrorFormat is an error response body format.
+type ErrorFormat string
+
+const (
+	FormatLegacy  ErrorFormat = "legacy"  // ErrorResponse, application/json
+	FormatProblem ErrorFormat = "problem" // Problem, application/problem+json
+)
+
+// ErrorHandlerOption configures an ErrorHandler.
+type ErrorHandlerOption func(*ErrorHandler)
+
+// WithFormats sets the formats ErrorHandler may write; the default is
+// FormatLegacy only. With both, the request's Accept header picks one
+// and the first is used when it has no preference:
+//
+//	// legacy for existing clients, problem+json for those asking for it
+//	handler.NewErrorHandler(logger, handler.WithFormats(handler.FormatLegacy, handler.FormatProblem))
+func WithFormats(formats ...ErrorFormat) ErrorHandlerOption {
+	return func(h *ErrorHandler) {
+		if len(formats) > 0 {
+			h.formats = formats
+		}
+	}
+}
+
+// WithProblemTypeBase replaces DefaultProblemTypeBase in problem type
+// URIs, e.g. "https://api.example.com/problems/".
+func WithProblemTypeBase(base string) ErrorHandlerOption {
+	return func(h *ErrorHandler) {
+		h.problemTypeBase = base
+	}
+}
+
+// format picks the response format for r.
+func (h *ErrorHandler) format(r *http.Request) ErrorFormat {
+	if len(h.formats) == 1 {
+		return h.formats[0]
+	}
+
+	accept := r.Header.Get("Accept")
+	problemQ := acceptQuality(accept, "application/problem+json")
+	jsonQ := acceptQuality(accept, "application/json")
+	switch {
+	case problemQ > jsonQ && slices.Contains(h.formats, FormatProblem):
+		return FormatProblem
+	case jsonQ > problemQ && slices.Contains(h.formats, FormatLegacy):
+		return FormatLegacy
+	default:
+		return h.formats[0]
+	}
+}
+
+// acceptQuality returns the q-value the Accept header gives mediaType,
+// from its most specific matching range. An empty header accepts
+// everything.
+func acceptQuality(accept, mediaType string) float64 {
+	if accept == "" {
+		return 1
+	}
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		s := -1
+		switch mt {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "myapp/internal/errors"
+)
+
+// ---------- WriteProblem Tests ----------
+
+func TestWriteProblem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantType   string
+		wantTitle  string
+		wantStatus int
+		wantDetail string
+	}{
+		{name: "invalid", err: apperrors.New(apperrors.CodeInvalid, "bad email"),
+			wantType: "https://errors.example.com/invalid", wantTitle: "Bad Request",
+			wantStatus: http.StatusBadRequest, wantDetail: "bad email"},
+		{name: "not found", err: apperrors.New(apperrors.CodeNotFound, "user not found"),
+			wantType: "https://errors.example.com/not_found", wantTitle: "Not Found",
+			wantStatus: http.StatusNotFound, wantDetail: "user not found"},
+		{name: "conflict", err: apperrors.New(apperrors.CodeConflict, "user exists"),
+			wantType: "https://errors.example.com/conflict", wantTitle: "Conflict",
+			wantStatus: http.StatusConflict, wantDetail: "user exists"},
+		{name: "unauthorized", err: apperrors.New(apperrors.CodeUnauthorized, "token expired"),
+			wantType: "https://errors.example.com/unauthorized", wantTitle: "Unauthorized",
+			wantStatus: http.StatusUnauthorized, wantDetail: "token expired"},
+		{name: "forbidden", err: apperrors.New(apperrors.CodeForbidden, "admins only"),
+			wantType: "https://errors.example.com/forbidden", wantTitle: "Forbidden",
+			wantStatus: http.StatusForbidden, wantDetail: "admins only"},
+		{name: "unavailable", err: apperrors.New(apperrors.CodeUnavailable, "try later"),
+			wantType: "https://errors.example.com/unavailable", wantTitle: "Service Unavailable",
+			wantStatus: http.StatusServiceUnavailable, wantDetail: "try later"},
+		{name: "timeout", err: apperrors.New(apperrors.CodeTimeout, "request timed out"),
+			wantType: "https://errors.example.com/timeout", wantTitle: "Gateway Timeout",
+			wantStatus: http.StatusGatewayTimeout, wantDetail: "request timed out"},
+		{name: "internal hides message", err: apperrors.Internal("pq: syntax error", errors.New("boom")),
+			wantType: "https://errors.example.com/internal", wantTitle: "Internal Server Error",
+			wantStatus: http.StatusInternalServerError, wantDetail: "an internal error has occurred"},
+		{name: "uncoded", err: errors.New("dial tcp: refused"),
+			wantType: "https://errors.example.com/internal", wantTitle: "Internal Server Error",
+			wantStatus: http.StatusInternalServerError, wantDetail: "an internal error has occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			WriteProblem(rec, newProblemRequest(""), tt.err)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+			p := decodeProblem(t, rec.Body)
+			assert.Equal(t, Problem{
+				Type:      tt.wantType,
+				Title:     tt.wantTitle,
+				Status:    tt.wantStatus,
+				Detail:    tt.wantDetail,
+				Instance:  "/users/42",
+				RequestID: "req-1",
+			}, p)
+		})
+	}
+}
+
+func TestWriteProblem_ValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	var verrs apperrors.ValidationErrors
+	verrs.Add("name", "required", "name is required")
+	verrs.Add("email", "email", "invalid email format")
+
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, newProblemRequest(""), verrs)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{
+		"type": "https://errors.example.com/invalid",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "validation failed",
+		"instance": "/users/42",
+		"request_id": "req-1",
+		"errors": {"name": "name is required", "email": "invalid email format"}
+	}`, rec.Body.String())
+}
+
+func TestWriteProblem_Details(t *testing.T) {
+	t.Parallel()
+
+	err := apperrors.New(apperrors.CodeInvalid, "name too long").WithDetail("field", "name")
+
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, newProblemRequest(""), err)
+
+	p := decodeProblem(t, rec.Body)
+	assert.Equal(t, map[string]any{"field": "name"}, p.Details)
+}
+
+func TestWriteProblem_JoinedWithInternalHidesErrors(t *testing.T) {
+	t.Parallel()
+
+	var verrs apperrors.ValidationErrors
+	verrs.Add("name", "required", "name is required")
+
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, newProblemRequest(""), errors.Join(verrs, errors.New("db down")))
+
+	p := decodeProblem(t, rec.Body)
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Nil(t, p.Errors)
+}
+
+// ---------- Negotiation Tests ----------
+
+func TestErrorHandler_Format(t *testing.T) {
+	t.Parallel()
+
+	both := []ErrorFormat{FormatLegacy, FormatProblem}
+	tests := []struct {
+		name    string
+		formats []ErrorFormat
+		accept  string
+		want    ErrorFormat
+	}{
+		{name: "legacy by default", formats: nil, accept: "application/problem+json", want: FormatLegacy},
+		{name: "problem only", formats: []ErrorFormat{FormatProblem}, accept: "application/json", want: FormatProblem},
+		{name: "both, no Accept", formats: both, accept: "", want: FormatLegacy},
+		{name: "both, wildcard", formats: both, accept: "*/*", want: FormatLegacy},
+		{name: "both, asks for problem", formats: both, accept: "application/problem+json", want: FormatProblem},
+		{name: "both, asks for json", formats: both, accept: "application/json", want: FormatLegacy},
+		{name: "both, prefers problem",
+			formats: both, accept: "application/json;q=0.5, application/problem+json", want: FormatProblem},
+		{name: "both, prefers json",
+			formats: both, accept: "application/problem+json;q=0.1, application/*", want: FormatLegacy},
+		{name: "both, problem refused", formats: both, accept: "*/*, application/problem+json;q=0", want: FormatLegacy},
+		{name: "problem first, no preference",
+			formats: []ErrorFormat{FormatProblem, FormatLegacy}, accept: "*/*", want: FormatProblem},
+		{name: "problem first, asks for json",
+			formats: []ErrorFormat{FormatProblem, FormatLegacy}, accept: "application/json", want: FormatLegacy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := NewErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), WithFormats(tt.formats...))
+			assert.Equal(t, tt.want, h.format(newProblemRequest(tt.accept)))
+		})
+	}
+}
+
+func TestErrorHandler_Handle_Negotiated(t *testing.T) {
+	t.Parallel()
+
+	h := NewErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithFormats(FormatLegacy, FormatProblem),
+		WithProblemTypeBase("https://api.example.com/problems/"))
+	err := apperrors.New(apperrors.CodeNotFound, "user not found")
+
+	rec := httptest.NewRecorder()
+	h.Handle(rec, newProblemRequest("application/problem+json"), err)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "https://api.example.com/problems/not_found", decodeProblem(t, rec.Body).Type)
+
+	rec = httptest.NewRecorder()
+	h.Handle(rec, newProblemRequest("application/json"), err)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"user not found","code":"not_found","request_id":"req-1"}`, rec.Body.String())
+}
+
+func TestErrorHandler_HandleWithCode_Problem(t *testing.T) {
+	t.Parallel()
+
+	h := NewErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), WithFormats(FormatProblem))
+
+	rec := httptest.NewRecorder()
+	h.HandleWithCode(rec, newProblemRequest(""), apperrors.New(apperrors.CodeUnauthorized, "token expired"), "token_expired")
+
+	p := decodeProblem(t, rec.Body)
+	assert.Equal(t, "https://errors.example.com/token_expired", p.Type)
+	assert.Equal(t, http.StatusUnauthorized, p.Status)
+}
+
+// ---------- Test Helpers ----------
+
+func newProblemRequest(accept string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/users/42?expand=roles", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	return r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "req-1"))
+}
+
+func decodeProblem(t *testing.T, body io.Reader) Problem {
+	t.Helper()
+
+	var p Problem
+	require.NoError(t, json.NewDecoder(body).Decode(&p))
+	return p
+}
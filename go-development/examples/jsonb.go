@@ -7,7 +7,8 @@ package examples
 import (
 	"database/sql/driver"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"reflect"
 
 	"github.com/lib/pq"
 )
@@ -30,9 +31,9 @@ func (s *Settings) Scan(src any) error {
 	if src == nil {
 		return nil
 	}
-	data, ok := src.([]byte)
-	if !ok {
-		return errors.New("expected []byte for JSONB")
+	data, err := jsonbSource(src)
+	if err != nil {
+		return err
 	}
 	return json.Unmarshal(data, s)
 }
@@ -57,13 +58,29 @@ func (f *GameFilter) Scan(src any) error {
 	if src == nil {
 		return nil
 	}
-	data, ok := src.([]byte)
-	if !ok {
-		return errors.New("expected []byte for JSONB")
+	data, err := jsonbSource(src)
+	if err != nil {
+		return err
 	}
 	return json.Unmarshal(data, f)
 }
 
+// jsonbSource returns the JSON in a non-NULL JSONB column value. pgx
+// hands it to a Scanner as []byte, but lib/pq and some proxies use
+// string, and wrappers json.RawMessage.
+func jsonbSource(src any) ([]byte, error) {
+	switch v := src.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case json.RawMessage:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("scan JSONB: got %T, want []byte, string or json.RawMessage", src)
+	}
+}
+
 // List is a generic slice type for PostgreSQL arrays.
 type List[T ~string] []T
 
@@ -115,9 +132,9 @@ func (m *Metadata) Scan(src any) error {
 		*m = nil
 		return nil
 	}
-	data, ok := src.([]byte)
-	if !ok {
-		return errors.New("expected []byte for JSONB")
+	data, err := jsonbSource(src)
+	if err != nil {
+		return err
 	}
 	return json.Unmarshal(data, m)
 }
@@ -158,7 +175,8 @@ func (m Metadata) GetInt(key string) int {
 	}
 }
 
-// NullableSettings handles NULL JSONB values.
+// NullableSettings handles NULL JSONB values. New code can use
+// NullableJSONB[Settings], which needs no methods per type.
 type NullableSettings struct {
 	Settings
 	Valid bool
@@ -178,19 +196,127 @@ func (n *NullableSettings) Scan(src any) error {
 		n.Valid = false
 		return nil
 	}
+	if err := n.Settings.Scan(src); err != nil {
+		return err
+	}
 	n.Valid = true
-	return n.Settings.Scan(src)
+	return nil
+}
+
+// JSONB stores any JSON-encodable T in a JSONB column, so a struct needs
+// no Value/Scan methods of its own. It never writes SQL NULL: a nil map
+// is stored as '{}' and a nil slice as '[]'. Use NullableJSONB for
+// nullable columns.
+//
+// It marshals to JSON as Data, so API DTOs can use it directly.
+type JSONB[T any] struct {
+	Data T
+}
+
+// Value implements driver.Valuer.
+func (j JSONB[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		switch reflect.TypeFor[T]().Kind() {
+		case reflect.Map:
+			return []byte("{}"), nil
+		case reflect.Slice:
+			return []byte("[]"), nil
+		}
+	}
+	return data, nil
+}
+
+// Scan implements sql.Scanner. Data is replaced, not merged into: a
+// reused JSONB[map[string]any] keeps no keys from the previous row. SQL
+// NULL and JSON null leave Data zero.
+func (j *JSONB[T]) Scan(src any) error {
+	if src == nil {
+		j.Data = *new(T)
+		return nil
+	}
+	data, err := jsonbSource(src)
+	if err != nil {
+		return err
+	}
+
+	var t T
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	j.Data = t
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j JSONB[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *JSONB[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.Data)
+}
+
+// NullableJSONB is JSONB for nullable columns: Valid false is SQL NULL
+// in the database and null in JSON.
+type NullableJSONB[T any] struct {
+	Data  T
+	Valid bool
+}
+
+// Value implements driver.Valuer.
+func (n NullableJSONB[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return JSONB[T]{Data: n.Data}.Value()
+}
+
+// Scan implements sql.Scanner.
+func (n *NullableJSONB[T]) Scan(src any) error {
+	var j JSONB[T]
+	if err := j.Scan(src); err != nil {
+		return err
+	}
+	n.Data, n.Valid = j.Data, src != nil
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n NullableJSONB[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *NullableJSONB[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = NullableJSONB[T]{}
+		return nil
+	}
+	var t T
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	n.Data, n.Valid = t, true
+	return nil
 }
 
 // UserWithJSONB demonstrates a model with various JSONB and array fields.
 type UserWithJSONB struct {
-	ID        string           `db:"id"`
-	Name      string           `db:"name"`
-	Settings  Settings         `db:"settings"`
-	Metadata  Metadata         `db:"metadata"`
-	Roles     List[string]     `db:"roles"`
-	Tags      List[string]     `db:"tags"`
-	Prefs     NullableSettings `db:"prefs"`
+	ID       string                  `db:"id"`
+	Name     string                  `db:"name"`
+	Settings JSONB[Settings]         `db:"settings"`
+	Metadata Metadata                `db:"metadata"`
+	Roles    List[string]            `db:"roles"`
+	Tags     List[string]            `db:"tags"`
+	Prefs    NullableJSONB[Settings] `db:"prefs"`
 }
 
 // Usage:
@@ -198,11 +324,11 @@ type UserWithJSONB struct {
 //	user := &UserWithJSONB{
 //	    ID:   uuid.NewString(),
 //	    Name: "John Doe",
-//	    Settings: Settings{
+//	    Settings: JSONB[Settings]{Data: Settings{
 //	        Theme:    "dark",
 //	        Language: "en",
 //	        Timezone: "UTC",
-//	    },
+//	    }},
 //	    Metadata: Metadata{
 //	        "source":   "signup",
 //	        "campaign": "summer2024",
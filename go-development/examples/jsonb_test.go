@@ -0,0 +1,272 @@
+package examples
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- JSONB Tests ----------
+
+// roundTrip stores v as the driver would and scans it back.
+func roundTrip[T any](t *testing.T, v JSONB[T]) (stored string, scanned JSONB[T]) {
+	t.Helper()
+
+	value, err := v.Value()
+	require.NoError(t, err)
+	data, ok := value.([]byte)
+	require.True(t, ok, "JSONB never stores NULL")
+
+	require.NoError(t, scanned.Scan(data))
+	return string(data), scanned
+}
+
+func TestJSONB_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	t.Run("struct", func(t *testing.T) {
+		t.Parallel()
+
+		in := JSONB[Settings]{Data: Settings{Theme: "dark", Preferences: []string{"a", "b"}}}
+		stored, out := roundTrip(t, in)
+		assert.JSONEq(t, `{"theme":"dark","preferences":["a","b"]}`, stored)
+		assert.Equal(t, in, out)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		t.Parallel()
+
+		in := JSONB[map[string]int]{Data: map[string]int{"a": 1}}
+		stored, out := roundTrip(t, in)
+		assert.JSONEq(t, `{"a":1}`, stored)
+		assert.Equal(t, in, out)
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		t.Parallel()
+
+		in := JSONB[[]string]{Data: []string{"x", "y"}}
+		stored, out := roundTrip(t, in)
+		assert.Equal(t, `["x","y"]`, stored)
+		assert.Equal(t, in, out)
+	})
+
+	t.Run("nil map", func(t *testing.T) {
+		t.Parallel()
+
+		stored, out := roundTrip(t, JSONB[map[string]int]{})
+		assert.Equal(t, `{}`, stored)
+		assert.Empty(t, out.Data)
+	})
+
+	t.Run("nil slice", func(t *testing.T) {
+		t.Parallel()
+
+		stored, out := roundTrip(t, JSONB[[]string]{})
+		assert.Equal(t, `[]`, stored)
+		assert.Empty(t, out.Data)
+	})
+
+	t.Run("nil pointer", func(t *testing.T) {
+		t.Parallel()
+
+		stored, out := roundTrip(t, JSONB[*Settings]{})
+		assert.Equal(t, `null`, stored, "JSON null, not SQL NULL")
+		assert.Nil(t, out.Data)
+	})
+}
+
+func TestJSONB_Scan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("string source", func(t *testing.T) {
+		t.Parallel()
+
+		var j JSONB[Settings]
+		require.NoError(t, j.Scan(`{"theme":"dark"}`))
+		assert.Equal(t, "dark", j.Data.Theme)
+	})
+
+	t.Run("NULL resets to zero", func(t *testing.T) {
+		t.Parallel()
+
+		j := JSONB[Settings]{Data: Settings{Theme: "dark"}}
+		require.NoError(t, j.Scan(nil))
+		assert.Zero(t, j.Data)
+	})
+
+	t.Run("replaces, not merges", func(t *testing.T) {
+		t.Parallel()
+
+		j := JSONB[map[string]int]{Data: map[string]int{"old": 1}}
+		require.NoError(t, j.Scan([]byte(`{"new":2}`)))
+		assert.Equal(t, map[string]int{"new": 2}, j.Data)
+	})
+
+	t.Run("invalid JSON keeps data", func(t *testing.T) {
+		t.Parallel()
+
+		j := JSONB[Settings]{Data: Settings{Theme: "dark"}}
+		require.Error(t, j.Scan([]byte(`{`)))
+		assert.Equal(t, "dark", j.Data.Theme)
+	})
+
+	t.Run("unsupported source", func(t *testing.T) {
+		t.Parallel()
+
+		var j JSONB[Settings]
+		assert.Error(t, j.Scan(42))
+	})
+}
+
+func TestNullableJSONB(t *testing.T) {
+	t.Parallel()
+
+	var null NullableJSONB[Settings]
+	value, err := null.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value, "SQL NULL")
+
+	valid := NullableJSONB[map[string]int]{Valid: true}
+	value, err = valid.Value()
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{}`), value, "valid and nil is '{}'")
+
+	n := NullableJSONB[Settings]{Data: Settings{Theme: "dark"}, Valid: true}
+	require.NoError(t, n.Scan(nil))
+	assert.Equal(t, NullableJSONB[Settings]{}, n)
+
+	require.NoError(t, n.Scan([]byte(`{"theme":"light"}`)))
+	assert.Equal(t, NullableJSONB[Settings]{Data: Settings{Theme: "light"}, Valid: true}, n)
+}
+
+// userDTO is an API body using the JSONB types directly.
+type userDTO struct {
+	Settings JSONB[Settings]         `json:"settings"`
+	Prefs    NullableJSONB[Settings] `json:"prefs"`
+}
+
+func TestJSONB_JSON(t *testing.T) {
+	t.Parallel()
+
+	in := userDTO{Settings: JSONB[Settings]{Data: Settings{Theme: "dark"}}}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"settings":{"theme":"dark"},"prefs":null}`, string(data), "no Data or Valid wrapper")
+
+	var out userDTO
+	require.NoError(t, json.Unmarshal([]byte(`{"settings":{"theme":"light"},"prefs":{"language":"en"}}`), &out))
+	assert.Equal(t, "light", out.Settings.Data.Theme)
+	assert.Equal(t, NullableJSONB[Settings]{Data: Settings{Language: "en"}, Valid: true}, out.Prefs)
+
+	out.Prefs = NullableJSONB[Settings]{Data: Settings{Theme: "x"}, Valid: true}
+	require.NoError(t, json.Unmarshal([]byte(`{"prefs":null}`), &out))
+	assert.False(t, out.Prefs.Valid)
+}
+
+// ---------- Scan Source Tests ----------
+
+func TestScan_Sources(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"theme":"dark","ids":["g1"],"k":"v"}`
+	sources := []struct {
+		name string
+		src  any
+	}{
+		{name: "[]byte", src: []byte(doc)},
+		{name: "string", src: doc},
+		{name: "json.RawMessage", src: json.RawMessage(doc)},
+	}
+
+	// scanners makes a fresh value of each JSONB type and checks
+	// what it scanned from doc.
+	scanners := []struct {
+		name  string
+		new   func() sql.Scanner
+		check func(t *testing.T, s sql.Scanner)
+	}{
+		{
+			name: "Settings",
+			new:  func() sql.Scanner { return &Settings{} },
+			check: func(t *testing.T, s sql.Scanner) {
+				assert.Equal(t, "dark", s.(*Settings).Theme)
+			},
+		},
+		{
+			name: "GameFilter",
+			new:  func() sql.Scanner { return &GameFilter{} },
+			check: func(t *testing.T, s sql.Scanner) {
+				assert.Equal(t, []string{"g1"}, s.(*GameFilter).IDs)
+			},
+		},
+		{
+			name: "Metadata",
+			new:  func() sql.Scanner { return &Metadata{} },
+			check: func(t *testing.T, s sql.Scanner) {
+				assert.Equal(t, "v", s.(*Metadata).GetString("k"))
+			},
+		},
+		{
+			name: "NullableSettings",
+			new:  func() sql.Scanner { return &NullableSettings{} },
+			check: func(t *testing.T, s sql.Scanner) {
+				n := s.(*NullableSettings)
+				assert.True(t, n.Valid)
+				assert.Equal(t, "dark", n.Theme)
+			},
+		},
+		{
+			name: "JSONB",
+			new:  func() sql.Scanner { return &JSONB[Settings]{} },
+			check: func(t *testing.T, s sql.Scanner) {
+				assert.Equal(t, "dark", s.(*JSONB[Settings]).Data.Theme)
+			},
+		},
+		{
+			name: "NullableJSONB",
+			new:  func() sql.Scanner { return &NullableJSONB[Settings]{} },
+			check: func(t *testing.T, s sql.Scanner) {
+				n := s.(*NullableJSONB[Settings])
+				assert.True(t, n.Valid)
+				assert.Equal(t, "dark", n.Data.Theme)
+			},
+		},
+	}
+
+	for _, sc := range scanners {
+		t.Run(sc.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, src := range sources {
+				s := sc.new()
+				require.NoError(t, s.Scan(src.src), src.name)
+				sc.check(t, s)
+			}
+
+			require.NoError(t, sc.new().Scan(nil), "NULL")
+
+			err := sc.new().Scan(42)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "got int", "names the type received")
+		})
+	}
+}
+
+func TestScan_NullLeavesZero(t *testing.T) {
+	t.Parallel()
+
+	var m Metadata
+	require.NoError(t, m.Scan(nil))
+	assert.Nil(t, m)
+
+	var n NullableSettings
+	require.NoError(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+
+	require.Error(t, n.Scan(3.5))
+	assert.False(t, n.Valid, "not valid after a failed scan")
+}
@@ -67,8 +67,8 @@ func main() {
 
 	logger.Info("stopping application")
 
-	// Graceful shutdown with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Graceful shutdown with timeout, on top of the pre-stop drain
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), preStopDelay+5*time.Second)
 	defer shutdownCancel()
 
 	be.stop(shutdownCtx)
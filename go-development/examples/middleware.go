@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"runtime/debug"
 	"strings"
@@ -16,8 +17,19 @@ import (
 type ctxKey string
 
 const (
-	UserCtxKey    ctxKey = "user"
-	RequestCtxKey ctxKey = "request_context"
+	UserCtxKey       ctxKey = "user"
+	RequestCtxKey    ctxKey = "request_context"
+	AuthMethodCtxKey ctxKey = "auth_method"
+)
+
+// AuthMethod is how a request authenticated. CSRF skips requests whose
+// credentials a browser can't attach on its own.
+type AuthMethod string
+
+const (
+	AuthMethodBearer AuthMethod = "bearer"
+	AuthMethodAPIKey AuthMethod = "api_key"
+	AuthMethodCookie AuthMethod = "cookie"
 )
 
 // User represents an authenticated user.
@@ -42,7 +54,7 @@ func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
 				if rec := recover(); rec != nil {
 					reqID := middleware.GetReqID(r.Context())
 
-					logger.Error("panic recovered",
+					logger.ErrorContext(r.Context(), "panic recovered",
 						slog.String("request_id", reqID),
 						slog.Any("panic", rec),
 						slog.String("stack", string(debug.Stack())),
@@ -62,37 +74,136 @@ func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-// RequestLogger logs requests with timing.
-func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+// sensitiveHeaders are never logged, even if allowlisted.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// RequestLoggerOption configures RequestLogger.
+type RequestLoggerOption func(*requestLoggerOptions)
+
+type requestLoggerOptions struct {
+	ignorePaths   map[string]struct{}
+	sampleRate    float64
+	slowThreshold time.Duration
+	headers       []string
+	random        func() float64 // [0, 1); replaced in tests
+}
+
+// WithIgnorePaths sets paths not logged at all, e.g. /metrics and probes.
+func WithIgnorePaths(paths ...string) RequestLoggerOption {
+	return func(o *requestLoggerOptions) {
+		o.ignorePaths = make(map[string]struct{}, len(paths))
+		for _, p := range paths {
+			o.ignorePaths[p] = struct{}{}
+		}
+	}
+}
+
+// WithSampling logs only this fraction (0 to 1) of 2xx responses. Other
+// statuses are always logged. Sampled lines carry sample_rate, so counts
+// can be scaled back up.
+func WithSampling(rate float64) RequestLoggerOption {
+	return func(o *requestLoggerOptions) {
+		o.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithSlowThreshold always logs requests taking at least d, marked
+// slow=true, whatever the sampling.
+func WithSlowThreshold(d time.Duration) RequestLoggerOption {
+	return func(o *requestLoggerOptions) {
+		o.slowThreshold = d
+	}
+}
+
+// WithHeaderLogging logs the listed request headers under "headers".
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie are dropped
+// even if listed.
+func WithHeaderLogging(allowlist ...string) RequestLoggerOption {
+	return func(o *requestLoggerOptions) {
+		o.headers = o.headers[:0]
+		for _, h := range allowlist {
+			h = http.CanonicalHeaderKey(h)
+			if !sensitiveHeaders[h] {
+				o.headers = append(o.headers, h)
+			}
+		}
+	}
+}
+
+// RequestLogger logs requests with timing and response size.
+// Logs with the request context, so a tracing.SlogHandler-wrapped logger
+// adds trace_id/span_id when tracing.Handler runs earlier in the chain.
+// Without options every request is logged.
+func RequestLogger(logger *slog.Logger, opts ...RequestLoggerOption) func(http.Handler) http.Handler {
+	cfg := &requestLoggerOptions{
+		sampleRate: 1,
+		random:     rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ignored := cfg.ignorePaths[r.URL.Path]; ignored {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			start := time.Now()
 			reqID := middleware.GetReqID(r.Context())
 
-			ww := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			// Keeps Flusher (SSE), Hijacker (websockets), io.ReaderFrom and
+			// Pusher when w has them; a plain embedding wrapper hides them.
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 			next.ServeHTTP(ww, r)
 
-			logger.Info("request",
+			duration := time.Since(start)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // nothing written, or hijacked
+			}
+
+			attrs := []slog.Attr{
 				slog.String("request_id", reqID),
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
-				slog.Int("status", ww.status),
-				slog.Duration("duration", time.Since(start)),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", duration),
 				slog.String("ip", r.RemoteAddr),
-			)
-		})
-	}
-}
+			}
 
-type responseWriter struct {
-	http.ResponseWriter
-	status int
-}
+			slow := cfg.slowThreshold > 0 && duration >= cfg.slowThreshold
+			switch {
+			case slow:
+				attrs = append(attrs, slog.Bool("slow", true))
+			case status >= 200 && status < 300 && cfg.sampleRate < 1:
+				if cfg.random() >= cfg.sampleRate {
+					return
+				}
+				attrs = append(attrs, slog.Float64("sample_rate", cfg.sampleRate))
+			}
+
+			if len(cfg.headers) > 0 {
+				headers := make([]any, 0, len(cfg.headers))
+				for _, h := range cfg.headers {
+					if v := r.Header.Values(h); len(v) > 0 {
+						headers = append(headers, slog.String(h, strings.Join(v, ", ")))
+					}
+				}
+				attrs = append(attrs, slog.Group("headers", headers...))
+			}
 
-func (w *responseWriter) WriteHeader(status int) {
-	w.status = status
-	w.ResponseWriter.WriteHeader(status)
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+		})
+	}
 }
 
 // AuthService defines the interface for authentication.
@@ -104,7 +215,7 @@ type AuthService interface {
 func Auth(authSvc AuthService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := extractToken(r)
+			token, method := extractToken(r)
 			if token == "" {
 				unauthorized(w)
 				return
@@ -117,22 +228,23 @@ func Auth(authSvc AuthService) func(http.Handler) http.Handler {
 			}
 
 			ctx := context.WithValue(r.Context(), UserCtxKey, user)
+			ctx = WithAuthMethod(ctx, method)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func extractToken(r *http.Request) string {
+func extractToken(r *http.Request) (string, AuthMethod) {
 	auth := r.Header.Get("Authorization")
 	if strings.HasPrefix(auth, "Bearer ") {
-		return strings.TrimPrefix(auth, "Bearer ")
+		return strings.TrimPrefix(auth, "Bearer "), AuthMethodBearer
 	}
 
 	if cookie, err := r.Cookie("token"); err == nil {
-		return cookie.Value
+		return cookie.Value, AuthMethodCookie
 	}
 
-	return ""
+	return "", ""
 }
 
 func unauthorized(w http.ResponseWriter) {
@@ -188,6 +300,18 @@ func UserFromContext(ctx context.Context) (*User, bool) {
 	return user, ok
 }
 
+// WithAuthMethod records how the request authenticated. Auth calls it;
+// other auth middlewares (API keys, sessions) should too.
+func WithAuthMethod(ctx context.Context, method AuthMethod) context.Context {
+	return context.WithValue(ctx, AuthMethodCtxKey, method)
+}
+
+// AuthMethodFromContext returns how the request authenticated.
+func AuthMethodFromContext(ctx context.Context) (AuthMethod, bool) {
+	method, ok := ctx.Value(AuthMethodCtxKey).(AuthMethod)
+	return method, ok
+}
+
 // RequestFromContext returns the request context.
 func RequestFromContext(ctx context.Context) (RequestContext, bool) {
 	reqCtx, ok := ctx.Value(RequestCtxKey).(RequestContext)
@@ -0,0 +1,254 @@
+// Package middleware provides an audit trail for access to sensitive
+// endpoints.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"myapp/internal/tracing"
+)
+
+// auditWriteTimeout bounds a sink write, which happens after the response
+// and outlives a client that has gone away.
+const auditWriteTimeout = 5 * time.Second
+
+// AuditEvent records one audited request.
+type AuditEvent struct {
+	Time      time.Time
+	RequestID string
+	UserID    string // empty if unauthenticated
+	Role      string
+	Method    string
+	Route     string // chi pattern, e.g. /admin/users/{userID}
+	Path      string // actual path, naming the resource acted on
+	Status    int
+	Duration  time.Duration
+	IP        string // RemoteAddr without the port; run RealIP first behind a proxy
+}
+
+// AuditSink stores audit events. Keep it apart from application logs:
+// a dedicated logger, file or table.
+type AuditSink interface {
+	Write(ctx context.Context, event AuditEvent) error
+}
+
+// AuditOption configures Audit.
+type AuditOption func(*auditOptions)
+
+type auditOptions struct {
+	actor    func(*http.Request) (id, role string)
+	failures prometheus.Counter
+	logger   *slog.Logger
+}
+
+// WithAuditActor sets how the user is read from the request. Defaults to
+// UserFromContext; use it to read auth.ClaimsFromContext instead.
+func WithAuditActor(actor func(*http.Request) (id, role string)) AuditOption {
+	return func(o *auditOptions) {
+		o.actor = actor
+	}
+}
+
+// WithAuditFailureCounter counts sink write failures, e.g. on the counter
+// from NewAuditFailureCounter.
+func WithAuditFailureCounter(counter prometheus.Counter) AuditOption {
+	return func(o *auditOptions) {
+		o.failures = counter
+	}
+}
+
+// WithAuditLogger sets the logger for sink write failures. Defaults to
+// slog.Default().
+func WithAuditLogger(logger *slog.Logger) AuditOption {
+	return func(o *auditOptions) {
+		o.logger = logger
+	}
+}
+
+// NewAuditFailureCounter registers audit_write_failures_total with reg.
+// Alert on it: every increment is a gap in the audit trail.
+func NewAuditFailureCounter(reg prometheus.Registerer) prometheus.Counter {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_write_failures_total",
+		Help: "Audit events the sink failed to store.",
+	})
+	reg.MustRegister(counter)
+	return counter
+}
+
+// Audit writes an AuditEvent to sink for each request matcher accepts
+// (nil matches all), once the handler has returned, including for panics.
+// Mount it after the auth middleware so the user is in context.
+//
+// A sink error is logged and counted but never fails the request: the
+// response has already been written.
+func Audit(sink AuditSink, matcher func(*http.Request) bool, opts ...AuditOption) func(http.Handler) http.Handler {
+	cfg := &auditOptions{
+		actor: func(r *http.Request) (string, string) {
+			if user, ok := UserFromContext(r.Context()); ok {
+				return user.ID, user.Role
+			}
+			return "", ""
+		},
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matcher != nil && !matcher(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				status := ww.Status()
+				rec := recover()
+				if rec != nil {
+					status = http.StatusInternalServerError
+				}
+				if status == 0 {
+					status = http.StatusOK // nothing written
+				}
+
+				cfg.write(r, sink, status, start)
+
+				if rec != nil {
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+func (o *auditOptions) write(r *http.Request, sink AuditSink, status int, start time.Time) {
+	userID, role := o.actor(r)
+	event := AuditEvent{
+		Time:      start.UTC(),
+		RequestID: middleware.GetReqID(r.Context()),
+		UserID:    userID,
+		Role:      role,
+		Method:    r.Method,
+		Route:     tracing.RouteLabel(r),
+		Path:      r.URL.Path,
+		Status:    status,
+		Duration:  time.Since(start),
+		IP:        r.RemoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		event.IP = host
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), auditWriteTimeout)
+	defer cancel()
+
+	if err := sink.Write(ctx, event); err != nil {
+		if o.failures != nil {
+			o.failures.Inc()
+		}
+		o.logger.ErrorContext(ctx, "audit write failed",
+			slog.String("request_id", event.RequestID),
+			slog.String("user_id", event.UserID),
+			slog.String("method", event.Method),
+			slog.String("path", event.Path),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// ---------- Slog Sink ----------
+
+// SlogAuditSink writes events as "audit" lines to a dedicated logger,
+// e.g. a JSON handler on its own file or stream.
+type SlogAuditSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogAuditSink creates a sink writing to logger.
+func NewSlogAuditSink(logger *slog.Logger) *SlogAuditSink {
+	return &SlogAuditSink{logger: logger}
+}
+
+// Write logs the event. It never fails.
+func (s *SlogAuditSink) Write(ctx context.Context, event AuditEvent) error {
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "audit",
+		slog.Time("time", event.Time),
+		slog.String("request_id", event.RequestID),
+		slog.String("user_id", event.UserID),
+		slog.String("role", event.Role),
+		slog.String("method", event.Method),
+		slog.String("route", event.Route),
+		slog.String("path", event.Path),
+		slog.Int("status", event.Status),
+		slog.Duration("duration", event.Duration),
+		slog.String("ip", event.IP),
+	)
+	return nil
+}
+
+// ---------- Postgres Sink ----------
+
+// AuditExecer runs a statement; pg.Client satisfies it.
+type AuditExecer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// PostgresAuditSink inserts events into the audit_log table:
+//
+//	CREATE TABLE audit_log (
+//	    id          bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+//	    occurred_at timestamptz NOT NULL,
+//	    request_id  text        NOT NULL,
+//	    user_id     text        NOT NULL,
+//	    role        text        NOT NULL,
+//	    method      text        NOT NULL,
+//	    route       text        NOT NULL,
+//	    path        text        NOT NULL,
+//	    status      smallint    NOT NULL,
+//	    duration_ms integer     NOT NULL,
+//	    ip          text        NOT NULL
+//	);
+//
+// Grant the application role INSERT only, so the trail can't be edited
+// through it.
+type PostgresAuditSink struct {
+	db AuditExecer
+}
+
+// NewPostgresAuditSink creates a sink writing through db.
+func NewPostgresAuditSink(db AuditExecer) *PostgresAuditSink {
+	return &PostgresAuditSink{db: db}
+}
+
+const insertAuditEvent = `INSERT INTO audit_log
+	(occurred_at, request_id, user_id, role, method, route, path, status, duration_ms, ip)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+// Write inserts the event.
+func (s *PostgresAuditSink) Write(ctx context.Context, event AuditEvent) error {
+	_, err := s.db.Exec(ctx, insertAuditEvent,
+		event.Time, event.RequestID, event.UserID, event.Role,
+		event.Method, event.Route, event.Path, event.Status,
+		event.Duration.Milliseconds(), event.IP,
+	)
+	if err != nil {
+		return fmt.Errorf("insert audit event: %w", err)
+	}
+	return nil
+}
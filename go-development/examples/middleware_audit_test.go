@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []AuditEvent
+	err    error
+}
+
+func (s *recordingSink) Write(ctx context.Context, event AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) Events() []AuditEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditEvent(nil), s.events...)
+}
+
+func adminOnly(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/admin/")
+}
+
+// auditRouter authenticates X-Test-User as an admin and audits /admin/.
+func auditRouter(sink AuditSink, opts ...AuditOption) http.Handler {
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Recoverer)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := r.Header.Get("X-Test-User"); id != "" {
+				r = r.WithContext(context.WithValue(r.Context(), UserCtxKey, &User{ID: id, Role: "admin"}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(Audit(sink, adminOnly, opts...))
+
+	router.Delete("/admin/users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Post("/admin/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	router.Get("/users", okHandler)
+
+	return router
+}
+
+func serveAudited(h http.Handler, method, path, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+type execCall struct {
+	sql  string
+	args []any
+}
+
+type fakeExecer struct {
+	calls []execCall
+	err   error
+}
+
+func (f *fakeExecer) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	f.calls = append(f.calls, execCall{sql: sql, args: args})
+	return pgconn.NewCommandTag("INSERT 0 1"), f.err
+}
+
+// ---------- Audit Tests ----------
+
+func TestAudit_RecordsEvent(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	rec := serveAudited(auditRouter(sink), http.MethodDelete, "/admin/users/42", "u-1")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	events := sink.Events()
+	require.Len(t, events, 1)
+	event := events[0]
+
+	assert.Equal(t, "u-1", event.UserID)
+	assert.Equal(t, "admin", event.Role)
+	assert.Equal(t, http.MethodDelete, event.Method)
+	assert.Equal(t, "/admin/users/{userID}", event.Route)
+	assert.Equal(t, "/admin/users/42", event.Path)
+	assert.Equal(t, http.StatusNoContent, event.Status)
+	assert.Equal(t, "10.0.0.7", event.IP)
+	assert.NotEmpty(t, event.RequestID)
+	assert.False(t, event.Time.IsZero())
+	assert.Positive(t, event.Duration)
+}
+
+func TestAudit_Matcher(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	h := auditRouter(sink)
+
+	serveAudited(h, http.MethodGet, "/users", "u-1")
+	assert.Empty(t, sink.Events(), "unmatched requests aren't audited")
+
+	serveAudited(h, http.MethodDelete, "/admin/users/42", "")
+	events := sink.Events()
+	require.Len(t, events, 1, "anonymous requests are audited too")
+	assert.Empty(t, events[0].UserID)
+}
+
+func TestAudit_Panic(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	rec := serveAudited(auditRouter(sink), http.MethodPost, "/admin/panic", "u-1")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "panic still reaches Recoverer")
+	events := sink.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, http.StatusInternalServerError, events[0].Status)
+}
+
+func TestAudit_SinkFailure(t *testing.T) {
+	t.Parallel()
+
+	counter := NewAuditFailureCounter(prometheus.NewRegistry())
+	var logs bytes.Buffer
+	sink := &recordingSink{err: errors.New("connection refused")}
+
+	h := auditRouter(sink,
+		WithAuditFailureCounter(counter),
+		WithAuditLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+
+	rec := serveAudited(h, http.MethodDelete, "/admin/users/42", "u-1")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "the request doesn't fail")
+	serveAudited(h, http.MethodDelete, "/admin/users/43", "u-1")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(counter))
+	assert.Contains(t, logs.String(), "audit write failed")
+	assert.Contains(t, logs.String(), "connection refused")
+}
+
+func TestAudit_CustomActor(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	h := auditRouter(sink, WithAuditActor(func(r *http.Request) (string, string) {
+		return "svc-" + r.Header.Get("X-Test-User"), "service"
+	}))
+
+	serveAudited(h, http.MethodDelete, "/admin/users/42", "billing")
+
+	events := sink.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "svc-billing", events[0].UserID)
+	assert.Equal(t, "service", events[0].Role)
+}
+
+func TestAudit_SinkOutlivesClient(t *testing.T) {
+	t.Parallel()
+
+	var sinkErr error
+	sink := sinkFunc(func(ctx context.Context, _ AuditEvent) error {
+		sinkErr = ctx.Err()
+		return nil
+	})
+
+	h := Audit(sink, nil)(http.HandlerFunc(okHandler))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // client went away
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/admin/x", nil).WithContext(ctx))
+
+	assert.NoError(t, sinkErr)
+}
+
+type sinkFunc func(ctx context.Context, event AuditEvent) error
+
+func (f sinkFunc) Write(ctx context.Context, event AuditEvent) error {
+	return f(ctx, event)
+}
+
+// ---------- Audit Sink Tests ----------
+
+func TestSlogAuditSink(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	sink := NewSlogAuditSink(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	h := auditRouter(sink)
+	serveAudited(h, http.MethodDelete, "/admin/users/42", "u-1")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "audit", line["msg"])
+	assert.Equal(t, "u-1", line["user_id"])
+	assert.Equal(t, "/admin/users/{userID}", line["route"])
+	assert.Equal(t, float64(http.StatusNoContent), line["status"])
+}
+
+func TestPostgresAuditSink(t *testing.T) {
+	t.Parallel()
+
+	db := &fakeExecer{}
+	sink := NewPostgresAuditSink(db)
+
+	serveAudited(auditRouter(sink), http.MethodDelete, "/admin/users/42", "u-1")
+
+	require.Len(t, db.calls, 1)
+	call := db.calls[0]
+	assert.Contains(t, call.sql, "INSERT INTO audit_log")
+	require.Len(t, call.args, 10)
+	assert.Equal(t, "u-1", call.args[2])
+	assert.Equal(t, "/admin/users/{userID}", call.args[5])
+	assert.Equal(t, "/admin/users/42", call.args[6])
+	assert.Equal(t, http.StatusNoContent, call.args[7])
+	assert.Equal(t, "10.0.0.7", call.args[9])
+
+	db.err = errors.New("relation \"audit_log\" does not exist")
+	err := sink.Write(context.Background(), AuditEvent{})
+	assert.ErrorContains(t, err, "insert audit event")
+}
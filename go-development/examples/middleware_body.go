@@ -0,0 +1,64 @@
+// Package middleware provides request body size limits.
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// BodyLimitOption configures MaxBodyBytes.
+type BodyLimitOption func(*bodyLimits)
+
+type bodyLimits struct {
+	multipart int64
+}
+
+// WithMultipartLimit sets the cap for multipart/form-data bodies, which
+// carry file uploads and usually need more than JSON. Defaults to the
+// MaxBodyBytes cap.
+func WithMultipartLimit(n int64) BodyLimitOption {
+	return func(l *bodyLimits) {
+		l.multipart = n
+	}
+}
+
+// MaxBodyBytes caps request bodies at n bytes. A body whose Content-Length
+// exceeds the cap is rejected with 413 before the handler runs. Other
+// bodies (chunked, or lying about their length) are wrapped in
+// http.MaxBytesReader, so reads past the cap fail with *http.MaxBytesError
+// for the handler's decode helper to turn into 413. Either way the server
+// closes the connection instead of reading the rest of the body.
+func MaxBodyBytes(n int64, opts ...BodyLimitOption) func(http.Handler) http.Handler {
+	if n <= 0 {
+		panic("maxbody: limit must be positive")
+	}
+	limits := bodyLimits{multipart: n}
+	for _, opt := range opts {
+		opt(&limits)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := n
+			if isMultipart(r) {
+				limit = limits.multipart
+			}
+
+			if r.ContentLength > limit {
+				w.Header().Set("Connection", "close")
+				writeError(w, r, http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("request body exceeds %d bytes", limit), "request_too_large")
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+// readBody reads the whole body and answers 413 on *http.MaxBytesError,
+// as the handler decode helpers do.
+func readBody(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large", "request_too_large")
+		return
+	}
+	w.Write(data)
+}
+
+func multipartBody(t *testing.T, size int) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", "upload.bin")
+	require.NoError(t, err)
+	_, err = part.Write(bytes.Repeat([]byte("a"), size))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	return &buf, mw.FormDataContentType()
+}
+
+func decodeError(t *testing.T, body io.Reader) errorResponse {
+	t.Helper()
+
+	var resp errorResponse
+	require.NoError(t, json.NewDecoder(body).Decode(&resp))
+	return resp
+}
+
+// ---------- MaxBodyBytes Tests ----------
+
+func TestMaxBodyBytes_ContentLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+	}{
+		{name: "under limit", size: 512, wantStatus: http.StatusOK},
+		{name: "at limit", size: 1024, wantStatus: http.StatusOK},
+		{name: "over limit", size: 1025, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			called := false
+			handler := MaxBodyBytes(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				readBody(w, r)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader(strings.Repeat("a", tt.size)))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.size, rec.Body.Len(), "body passed through intact")
+				return
+			}
+			assert.False(t, called, "rejected before the handler")
+			assert.Equal(t, "close", rec.Header().Get("Connection"))
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			resp := decodeError(t, rec.Body)
+			assert.Equal(t, "request body exceeds 1024 bytes", resp.Error)
+			assert.Equal(t, "request_too_large", resp.Code)
+		})
+	}
+}
+
+func TestMaxBodyBytes_Chunked(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(MaxBodyBytes(1024)(http.HandlerFunc(readBody)))
+	t.Cleanup(srv.Close)
+
+	// An io.Reader of unknown length is sent chunked, without Content-Length.
+	body := io.MultiReader(strings.NewReader(strings.Repeat("a", 64<<10)))
+	resp, err := http.Post(srv.URL, "application/json", body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.True(t, resp.Close, "server closes the connection after hitting the limit")
+	assert.Equal(t, "request_too_large", decodeError(t, resp.Body).Code)
+}
+
+func TestMaxBodyBytes_ClosesHalfReadConnection(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(MaxBodyBytes(1024)(http.HandlerFunc(readBody)))
+	t.Cleanup(srv.Close)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	// Announce 10 MB, send only the headers and 1 KB of it.
+	_, err = io.WriteString(conn, "POST /upload HTTP/1.1\r\nHost: example.com\r\n"+
+		"Content-Type: application/json\r\nContent-Length: 10485760\r\n\r\n"+strings.Repeat("a", 1024))
+	require.NoError(t, err)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.True(t, resp.Close)
+	assert.Equal(t, "request_too_large", decodeError(t, resp.Body).Code)
+	resp.Body.Close()
+
+	// The server must hang up rather than wait for the remaining 10 MB.
+	_, err = io.ReadAll(br)
+	assert.NoError(t, err, "connection closed by the server, not timed out")
+}
+
+func TestMaxBodyBytes_MultipartLimit(t *testing.T) {
+	t.Parallel()
+
+	handler := MaxBodyBytes(1024, WithMultipartLimit(8192))(http.HandlerFunc(readBody))
+
+	tests := []struct {
+		name       string
+		multipart  bool
+		size       int
+		wantStatus int
+	}{
+		{name: "json under default", size: 1000, wantStatus: http.StatusOK},
+		{name: "json over default", size: 4096, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "multipart under upload limit", multipart: true, size: 4096, wantStatus: http.StatusOK},
+		{name: "multipart over upload limit", multipart: true, size: 16384, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body, contentType := bytes.NewBufferString(strings.Repeat("a", tt.size)), "application/json"
+			if tt.multipart {
+				body, contentType = multipartBody(t, tt.size)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/upload", body)
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestMaxBodyBytes_InvalidLimit(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { MaxBodyBytes(0) })
+}
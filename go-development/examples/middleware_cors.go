@@ -0,0 +1,248 @@
+// Package middleware provides CORS handling with per-route configuration.
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures CORS. Mount one CORS middleware per route group to
+// give groups different policies.
+type CORSConfig struct {
+	// AllowedOrigins lists origins allowed to call the API: exact
+	// ("https://app.example.com"), with one wildcard
+	// ("https://*.example.com", "http://localhost:*") or "*" for any.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are request headers clients may send; "*" allows any.
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by scripts.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization.
+	// It can't be combined with the "*" origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight. Browsers cap it
+	// (Chrome at 2h); 0 sends no Max-Age.
+	MaxAge time.Duration
+}
+
+// DevCORS allows any localhost origin with credentials, for local
+// frontends on any port. Don't use it in production.
+func DevCORS() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"X-Request-Id"},
+		AllowCredentials: true,
+	}
+}
+
+// ProdCORS allows only the given exact origins, common methods and
+// headers, without credentials. Set AllowCredentials on the result when
+// the frontend uses cookies.
+func ProdCORS(origins ...string) CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Request-Id"},
+		ExposedHeaders: []string{"X-Request-Id"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// CORS answers preflight requests itself, without calling the next
+// handler, and adds CORS headers to requests from allowed origins.
+// Disallowed origins get no CORS headers, so the browser blocks the
+// response; the request itself isn't rejected. It panics on "*" with
+// AllowCredentials, which would let any site act as the user.
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	c := newCORS(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				c.preflight(w, r, origin)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			c.actual(w, origin)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type cors struct {
+	anyOrigin   bool
+	origins     map[string]struct{}
+	patterns    []originPattern
+	anyHeader   bool
+	headers     map[string]struct{} // lower case
+	methods     []string
+	exposed     string
+	maxAge      string
+	credentials bool
+}
+
+// originPattern matches origins with one wildcard, as prefix + host part + suffix.
+type originPattern struct {
+	prefix, suffix string
+}
+
+func newCORS(cfg CORSConfig) *cors {
+	c := &cors{
+		origins:     make(map[string]struct{}),
+		headers:     make(map[string]struct{}),
+		credentials: cfg.AllowCredentials,
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(o)
+		switch {
+		case o == "*":
+			c.anyOrigin = true
+		case strings.Contains(o, "*"):
+			prefix, suffix, _ := strings.Cut(o, "*")
+			c.patterns = append(c.patterns, originPattern{prefix: prefix, suffix: suffix})
+		default:
+			c.origins[o] = struct{}{}
+		}
+	}
+	if c.anyOrigin && c.credentials {
+		panic(`cors: AllowCredentials can't be used with the "*" origin`)
+	}
+
+	for _, h := range cfg.AllowedHeaders {
+		if h == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers[strings.ToLower(h)] = struct{}{}
+	}
+
+	c.methods = cfg.AllowedMethods
+	if len(c.methods) == 0 {
+		c.methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	c.exposed = strings.Join(cfg.ExposedHeaders, ", ")
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+
+	return c
+}
+
+func (c *cors) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := c.origins[origin]; ok {
+		return true
+	}
+	for _, p := range c.patterns {
+		if p.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// match requires a non-empty host part made of letters, digits, dots and
+// hyphens, so "https://*.example.com" never matches "https://evil.com/.example.com".
+func (p originPattern) match(origin string) bool {
+	if len(origin) <= len(p.prefix)+len(p.suffix) ||
+		!strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	host := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	for _, r := range host {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	if !c.allowOrigin(origin) {
+		return
+	}
+	if !slices.Contains(c.methods, r.Header.Get("Access-Control-Request-Method")) {
+		return
+	}
+	reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if !c.allowHeaders(reqHeaders) {
+		return
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	if reqHeaders != "" {
+		h.Set("Access-Control-Allow-Headers", reqHeaders)
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+}
+
+func (c *cors) actual(w http.ResponseWriter, origin string) {
+	h := w.Header()
+	if !c.anyOrigin {
+		h.Add("Vary", "Origin")
+	}
+	if !c.allowOrigin(origin) {
+		return
+	}
+
+	c.setOrigin(h, origin)
+	if c.exposed != "" {
+		h.Set("Access-Control-Expose-Headers", c.exposed)
+	}
+}
+
+// setOrigin echoes the origin, except for "*" without credentials, where
+// the response is the same for every origin.
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowHeaders checks a comma-separated Access-Control-Request-Headers value.
+func (c *cors) allowHeaders(requested string) bool {
+	if c.anyHeader || requested == "" {
+		return true
+	}
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := c.headers[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
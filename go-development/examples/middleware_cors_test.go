@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+// serveCORS runs req through CORS(cfg) and reports whether the next
+// handler was called.
+func serveCORS(cfg CORSConfig, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	called := false
+	handler := CORS(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("X-Request-Id", "req-1")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, called
+}
+
+func preflightRequest(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/items", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+func originRequest(method, origin string) *http.Request {
+	req := httptest.NewRequest(method, "/api/items", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	return req
+}
+
+var testCORS = CORSConfig{
+	AllowedOrigins: []string{"https://app.example.com", "https://*.preview.example.com"},
+	AllowedMethods: []string{"GET", "POST", "DELETE"},
+	AllowedHeaders: []string{"Authorization", "Content-Type"},
+	ExposedHeaders: []string{"X-Request-Id", "Link"},
+	MaxAge:         10 * time.Minute,
+}
+
+// ---------- Preflight Tests ----------
+
+func TestCORS_Preflight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		origin      string
+		method      string
+		headers     string
+		wantAllowed bool
+	}{
+		{name: "exact origin", origin: "https://app.example.com", method: "DELETE",
+			headers: "authorization, content-type", wantAllowed: true},
+		{name: "wildcard subdomain", origin: "https://pr-42.preview.example.com", method: "POST", wantAllowed: true},
+		{name: "nested subdomain", origin: "https://a.pr-42.preview.example.com", method: "GET", wantAllowed: true},
+		{name: "origin case-insensitive", origin: "https://APP.example.com", method: "GET", wantAllowed: true},
+		{name: "unknown origin", origin: "https://evil.com", method: "GET"},
+		{name: "wildcard needs subdomain", origin: "https://preview.example.com", method: "GET"},
+		{name: "wildcard suffix trick", origin: "https://evil.com/.preview.example.com", method: "GET"},
+		{name: "wildcard lookalike", origin: "https://evilpreview.example.com", method: "GET"},
+		{name: "other scheme", origin: "http://app.example.com", method: "GET"},
+		{name: "method not allowed", origin: "https://app.example.com", method: "PUT"},
+		{name: "header not allowed", origin: "https://app.example.com", method: "GET", headers: "x-debug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec, called := serveCORS(testCORS, preflightRequest(tt.origin, tt.method, tt.headers))
+
+			assert.False(t, called, "preflight answered by the middleware")
+			assert.Equal(t, http.StatusNoContent, rec.Code, "no error either way")
+			assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+				rec.Header().Values("Vary"))
+
+			h := rec.Header()
+			if !tt.wantAllowed {
+				assert.Empty(t, h.Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, h.Get("Access-Control-Allow-Methods"))
+				return
+			}
+			assert.Equal(t, tt.origin, h.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "GET, POST, DELETE", h.Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, tt.headers, h.Get("Access-Control-Allow-Headers"))
+			assert.Equal(t, "600", h.Get("Access-Control-Max-Age"))
+			assert.Empty(t, h.Get("Access-Control-Allow-Credentials"))
+		})
+	}
+}
+
+func TestCORS_PlainOptionsPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	req := originRequest(http.MethodOptions, "https://app.example.com")
+	_, called := serveCORS(testCORS, req)
+
+	assert.True(t, called, "OPTIONS without Access-Control-Request-Method is not a preflight")
+}
+
+// ---------- Simple Request Tests ----------
+
+func TestCORS_SimpleRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		origin      string
+		wantAllowed bool
+	}{
+		{name: "allowed origin", origin: "https://app.example.com", wantAllowed: true},
+		{name: "disallowed origin", origin: "https://evil.com"},
+		{name: "same-origin request", origin: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec, called := serveCORS(testCORS, originRequest(http.MethodGet, tt.origin))
+
+			assert.True(t, called, "disallowed origins aren't rejected, only not granted access")
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			h := rec.Header()
+			if tt.origin == "" {
+				assert.Empty(t, h.Values("Vary"))
+			} else {
+				assert.Equal(t, []string{"Origin"}, h.Values("Vary"), "caches must key on Origin")
+			}
+			if !tt.wantAllowed {
+				assert.Empty(t, h.Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, h.Get("Access-Control-Expose-Headers"))
+				return
+			}
+			assert.Equal(t, tt.origin, h.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "X-Request-Id, Link", h.Get("Access-Control-Expose-Headers"))
+		})
+	}
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	t.Parallel()
+
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}}
+
+	rec, _ := serveCORS(cfg, originRequest(http.MethodGet, "https://anywhere.com"))
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Values("Vary"), "same response for every origin")
+
+	rec, _ = serveCORS(cfg, preflightRequest("https://anywhere.com", "POST", ""))
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, HEAD, POST", rec.Header().Get("Access-Control-Allow-Methods"), "default methods")
+}
+
+// ---------- Credentials Tests ----------
+
+func TestCORS_Credentials(t *testing.T) {
+	t.Parallel()
+
+	cfg := testCORS
+	cfg.AllowCredentials = true
+
+	rec, _ := serveCORS(cfg, originRequest(http.MethodGet, "https://pr-1.preview.example.com"))
+	h := rec.Header()
+	assert.Equal(t, "https://pr-1.preview.example.com", h.Get("Access-Control-Allow-Origin"),
+		"wildcard pattern echoes the concrete origin, never *")
+	assert.Equal(t, "true", h.Get("Access-Control-Allow-Credentials"))
+
+	rec, _ = serveCORS(cfg, preflightRequest("https://app.example.com", "POST", "content-type"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec, _ = serveCORS(cfg, originRequest(http.MethodGet, "https://evil.com"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_CredentialsWithAnyOriginRejected(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, `cors: AllowCredentials can't be used with the "*" origin`, func() {
+		CORS(CORSConfig{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true})
+	})
+
+	// Without credentials, "*" never grants credentialed access: browsers
+	// drop responses to credentialed requests without Allow-Credentials.
+	req := originRequest(http.MethodGet, "https://evil.com")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	rec, _ := serveCORS(CORSConfig{AllowedOrigins: []string{"*"}}, req)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+// ---------- Presets Tests ----------
+
+func TestCORS_Presets(t *testing.T) {
+	t.Parallel()
+
+	rec, _ := serveCORS(DevCORS(), preflightRequest("http://localhost:5173", "PATCH", "x-anything"))
+	assert.Equal(t, "http://localhost:5173", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "x-anything", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec, _ = serveCORS(DevCORS(), preflightRequest("http://localhost.evil.com", "GET", ""))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	prod := ProdCORS("https://app.example.com")
+	rec, _ = serveCORS(prod, preflightRequest("https://app.example.com", "PUT", "Authorization"))
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+
+	rec, _ = serveCORS(prod, preflightRequest("http://localhost:3000", "GET", ""))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// ---------- Routing Tests ----------
+
+func TestCORS_PerRoute(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	r.Route("/public", func(r chi.Router) {
+		r.Use(CORS(CORSConfig{AllowedOrigins: []string{"*"}}))
+		r.Get("/items", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(CORS(ProdCORS("https://admin.example.com")))
+		r.Delete("/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		origin     string
+		method     string
+		wantOrigin string
+	}{
+		{"public any origin", "/public/items", "https://blog.example.org", "GET", "*"},
+		{"admin from admin app", "/admin/items/1", "https://admin.example.com", "DELETE", "https://admin.example.com"},
+		{"admin from other origin", "/admin/items/1", "https://blog.example.org", "DELETE", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusNoContent, rec.Code, "preflight reaches the route group's middleware")
+			assert.Equal(t, tt.wantOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package middleware provides CSRF protection for cookie-authenticated
+// browser clients.
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const csrfNonceSize = 32
+
+type csrfCtxKey struct{}
+
+// CSRFConfig configures CSRF.
+type CSRFConfig struct {
+	// Secret signs tokens, so a cookie planted by a sibling subdomain
+	// isn't accepted. At least 32 bytes.
+	Secret []byte
+	// CookieName defaults to "csrf_token".
+	CookieName string
+	// HeaderName defaults to "X-CSRF-Token".
+	HeaderName string
+	// CookiePath defaults to "/".
+	CookiePath   string
+	CookieDomain string
+	// MaxAge of the cookie. Defaults to 12h; tokens are also rotated on
+	// login and logout with RotateCSRFToken.
+	MaxAge time.Duration
+	// SameSite defaults to Lax, like the session cookie.
+	SameSite http.SameSite
+	// Insecure drops the Secure attribute, for local development over
+	// plain HTTP.
+	Insecure bool
+	// ExemptPaths are path prefixes never checked, e.g. webhooks
+	// authenticated by signature.
+	ExemptPaths []string
+}
+
+type csrf struct {
+	cfg CSRFConfig
+}
+
+// csrfState is the request's token, replaced by RotateCSRFToken.
+type csrfState struct {
+	c     *csrf
+	token string
+}
+
+// CSRF implements the signed double-submit cookie pattern: it sets a token
+// cookie readable by the frontend, which echoes it in the X-CSRF-Token
+// header on POST, PUT, PATCH and DELETE. A cross-site attacker can make
+// the browser send the cookie, but can't read it to set the header.
+//
+// Failures get 403 with code "csrf_failed". Requests that authenticated by
+// bearer token or API key (AuthMethodFromContext) are skipped, since
+// browsers never attach those on their own; mount CSRF after the auth
+// middleware to get that. It panics on a Secret shorter than 32 bytes.
+func CSRF(cfg CSRFConfig) func(http.Handler) http.Handler {
+	if len(cfg.Secret) < 32 {
+		panic("csrf: Secret must be at least 32 bytes")
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = "csrf_token"
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = "X-CSRF-Token"
+	}
+	if cfg.CookiePath == "" {
+		cfg.CookiePath = "/"
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 12 * time.Hour
+	}
+	if cfg.SameSite == 0 {
+		cfg.SameSite = http.SameSiteLaxMode
+	}
+	c := &csrf{cfg: cfg}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state := &csrfState{c: c}
+			if cookie, err := r.Cookie(c.cfg.CookieName); err == nil && c.valid(cookie.Value) {
+				state.token = cookie.Value
+			}
+			r = r.WithContext(context.WithValue(r.Context(), csrfCtxKey{}, state))
+
+			if !c.checked(r) {
+				if state.token == "" {
+					state.rotate(w)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sent := r.Header.Get(c.cfg.HeaderName)
+			switch {
+			case sent == "":
+				writeError(w, r, http.StatusForbidden, "missing CSRF token", "csrf_failed")
+				return
+			case state.token == "":
+				// No cookie, or one we didn't sign: issue one for the retry
+				state.rotate(w)
+				writeError(w, r, http.StatusForbidden, "missing or invalid CSRF cookie", "csrf_failed")
+				return
+			case subtle.ConstantTimeCompare([]byte(sent), []byte(state.token)) != 1:
+				writeError(w, r, http.StatusForbidden, "invalid CSRF token", "csrf_failed")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checked reports whether r must carry a valid token.
+func (c *csrf) checked(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	for _, p := range c.cfg.ExemptPaths {
+		if hasPathPrefix(r.URL.Path, p) {
+			return false
+		}
+	}
+	if method, ok := AuthMethodFromContext(r.Context()); ok {
+		return method != AuthMethodBearer && method != AuthMethodAPIKey
+	}
+	return true
+}
+
+// newToken returns nonce.signature, both base64url.
+func (c *csrf) newToken() string {
+	nonce := make([]byte, csrfNonceSize)
+	rand.Read(nonce)
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(nonce) + "." + enc.EncodeToString(c.sign(nonce))
+}
+
+func (c *csrf) valid(token string) bool {
+	nonceStr, sigStr, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	enc := base64.RawURLEncoding
+	nonce, err := enc.DecodeString(nonceStr)
+	if err != nil || len(nonce) != csrfNonceSize {
+		return false
+	}
+	sig, err := enc.DecodeString(sigStr)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sig, c.sign(nonce))
+}
+
+func (c *csrf) sign(nonce []byte) []byte {
+	mac := hmac.New(sha256.New, c.cfg.Secret)
+	mac.Write(nonce)
+	return mac.Sum(nil)
+}
+
+func (s *csrfState) rotate(w http.ResponseWriter) string {
+	cfg := s.c.cfg
+	s.token = s.c.newToken()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   cfg.CookieName,
+		Value:  s.token,
+		Path:   cfg.CookiePath,
+		Domain: cfg.CookieDomain,
+		MaxAge: int(cfg.MaxAge / time.Second),
+		Secure: !cfg.Insecure,
+		// Not HttpOnly: the frontend reads it to fill the header
+		HttpOnly: false,
+		SameSite: cfg.SameSite,
+	})
+	return s.token
+}
+
+// CSRFToken returns the request's CSRF token, for server-rendered forms
+// or a token endpoint. It is empty outside CSRF.
+func CSRFToken(ctx context.Context) string {
+	state, ok := ctx.Value(csrfCtxKey{}).(*csrfState)
+	if !ok {
+		return ""
+	}
+	return state.token
+}
+
+// RotateCSRFToken replaces the CSRF cookie and returns the new token. Call
+// it on login and logout, so a token planted or read before the session
+// changed is useless after. It returns "" outside CSRF.
+func RotateCSRFToken(w http.ResponseWriter, r *http.Request) string {
+	state, ok := r.Context().Value(csrfCtxKey{}).(*csrfState)
+	if !ok {
+		return ""
+	}
+	return state.rotate(w)
+}
+
+// CSRFTokenHandler returns the request's token as {"csrf_token": "..."},
+// for clients that can't read the cookie (another origin, or HttpOnly
+// set by a proxy). Mount it behind CSRF.
+func CSRFTokenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"csrf_token": CSRFToken(r.Context())})
+}
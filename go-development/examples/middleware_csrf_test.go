@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+var csrfSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// csrfRouter marks requests with the auth method in X-Test-Auth, as an
+// auth middleware mounted before CSRF would.
+func csrfRouter(cfg CSRFConfig) http.Handler {
+	if cfg.Secret == nil {
+		cfg.Secret = csrfSecret
+	}
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if method := r.Header.Get("X-Test-Auth"); method != "" {
+				r = r.WithContext(WithAuthMethod(r.Context(), AuthMethod(method)))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(CSRF(cfg))
+
+	router.Get("/form", okHandler)
+	router.Get("/csrf", CSRFTokenHandler)
+	router.Post("/orders", okHandler)
+	router.Delete("/orders/{id}", okHandler)
+	router.Post("/webhooks/stripe", okHandler)
+	router.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		old := CSRFToken(r.Context())
+		token := RotateCSRFToken(w, r)
+		json.NewEncoder(w).Encode(map[string]string{"old": old, "new": token, "ctx": CSRFToken(r.Context())})
+	})
+
+	return router
+}
+
+func serveCSRF(h http.Handler, method, path string, cookie *http.Cookie, header string, mutate ...func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	if header != "" {
+		req.Header.Set("X-CSRF-Token", header)
+	}
+	for _, m := range mutate {
+		m(req)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func csrfCookie(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "csrf_token" {
+			return c
+		}
+	}
+	t.Fatal("no csrf_token cookie set")
+	return nil
+}
+
+func authAs(method AuthMethod) func(*http.Request) {
+	return func(r *http.Request) {
+		r.Header.Set("X-Test-Auth", string(method))
+	}
+}
+
+// ---------- CSRF Tests ----------
+
+func TestCSRF_IssuesCookie(t *testing.T) {
+	t.Parallel()
+
+	h := csrfRouter(CSRFConfig{})
+
+	rec := serveCSRF(h, http.MethodGet, "/form", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	cookie := csrfCookie(t, rec)
+	assert.NotEmpty(t, cookie.Value)
+	assert.Equal(t, "/", cookie.Path)
+	assert.True(t, cookie.Secure)
+	assert.False(t, cookie.HttpOnly, "the frontend must read it")
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	assert.Equal(t, 12*60*60, cookie.MaxAge)
+
+	rec = serveCSRF(h, http.MethodGet, "/form", cookie, "")
+	assert.Empty(t, rec.Result().Cookies(), "a valid cookie is kept")
+}
+
+func TestCSRF_Validation(t *testing.T) {
+	t.Parallel()
+
+	h := csrfRouter(CSRFConfig{})
+	cookie := csrfCookie(t, serveCSRF(h, http.MethodGet, "/form", nil, ""))
+
+	other := csrfCookie(t, serveCSRF(h, http.MethodGet, "/form", nil, ""))
+	unsigned := &http.Cookie{Name: "csrf_token", Value: "attacker-chosen"}
+	wrongKey := csrfCookie(t, serveCSRF(csrfRouter(CSRFConfig{
+		Secret: []byte("fedcba9876543210fedcba9876543210"),
+	}), http.MethodGet, "/form", nil, ""))
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		cookie    *http.Cookie
+		header    string
+		wantCode  int
+		wantError string
+	}{
+		{name: "valid", method: http.MethodPost, path: "/orders", cookie: cookie, header: cookie.Value, wantCode: http.StatusOK},
+		{name: "valid delete", method: http.MethodDelete, path: "/orders/1", cookie: cookie, header: cookie.Value, wantCode: http.StatusOK},
+		{name: "missing header", method: http.MethodPost, path: "/orders", cookie: cookie,
+			wantCode: http.StatusForbidden, wantError: "missing CSRF token"},
+		{name: "missing cookie", method: http.MethodPost, path: "/orders", header: cookie.Value,
+			wantCode: http.StatusForbidden, wantError: "missing or invalid CSRF cookie"},
+		{name: "mismatch", method: http.MethodPost, path: "/orders", cookie: cookie, header: other.Value,
+			wantCode: http.StatusForbidden, wantError: "invalid CSRF token"},
+		{name: "planted unsigned cookie", method: http.MethodPost, path: "/orders", cookie: unsigned, header: unsigned.Value,
+			wantCode: http.StatusForbidden, wantError: "missing or invalid CSRF cookie"},
+		{name: "signed with another secret", method: http.MethodPost, path: "/orders", cookie: wrongKey, header: wrongKey.Value,
+			wantCode: http.StatusForbidden, wantError: "missing or invalid CSRF cookie"},
+		{name: "safe method", method: http.MethodGet, path: "/form", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveCSRF(h, tt.method, tt.path, tt.cookie, tt.header)
+			require.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantError != "" {
+				body := decodeError(t, rec.Body)
+				assert.Equal(t, "csrf_failed", body.Code)
+				assert.Equal(t, tt.wantError, body.Error)
+			}
+		})
+	}
+}
+
+func TestCSRF_Exemptions(t *testing.T) {
+	t.Parallel()
+
+	h := csrfRouter(CSRFConfig{ExemptPaths: []string{"/webhooks/"}})
+
+	tests := []struct {
+		name     string
+		path     string
+		mutate   []func(*http.Request)
+		wantCode int
+	}{
+		{name: "bearer token", path: "/orders", mutate: []func(*http.Request){authAs(AuthMethodBearer)}, wantCode: http.StatusOK},
+		{name: "API key", path: "/orders", mutate: []func(*http.Request){authAs(AuthMethodAPIKey)}, wantCode: http.StatusOK},
+		{name: "cookie auth", path: "/orders", mutate: []func(*http.Request){authAs(AuthMethodCookie)}, wantCode: http.StatusForbidden},
+		{name: "unauthenticated", path: "/orders", wantCode: http.StatusForbidden},
+		{name: "exempt path", path: "/webhooks/stripe", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveCSRF(h, http.MethodPost, tt.path, nil, "", tt.mutate...)
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestCSRF_RotateOnLogin(t *testing.T) {
+	t.Parallel()
+
+	h := csrfRouter(CSRFConfig{})
+	cookie := csrfCookie(t, serveCSRF(h, http.MethodGet, "/form", nil, ""))
+
+	rec := serveCSRF(h, http.MethodPost, "/login", cookie, cookie.Value)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tokens map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&tokens))
+	assert.Equal(t, cookie.Value, tokens["old"])
+	assert.NotEqual(t, tokens["old"], tokens["new"])
+	assert.Equal(t, tokens["new"], tokens["ctx"], "CSRFToken sees the rotated token")
+
+	rotated := csrfCookie(t, rec)
+	assert.Equal(t, tokens["new"], rotated.Value)
+	assert.Equal(t, http.StatusOK, serveCSRF(h, http.MethodPost, "/orders", rotated, rotated.Value).Code)
+}
+
+func TestCSRF_TokenHandler(t *testing.T) {
+	t.Parallel()
+
+	h := csrfRouter(CSRFConfig{})
+
+	rec := serveCSRF(h, http.MethodGet, "/csrf", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, csrfCookie(t, rec).Value, body["csrf_token"], "same token as the new cookie")
+}
+
+func TestCSRF_Config(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { CSRF(CSRFConfig{Secret: []byte("short")}) })
+
+	h := csrfRouter(CSRFConfig{
+		CookieName: "xsrf",
+		HeaderName: "X-XSRF-Token",
+		SameSite:   http.SameSiteStrictMode,
+		Insecure:   true,
+	})
+
+	rec := serveCSRF(h, http.MethodGet, "/form", nil, "")
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "xsrf", cookies[0].Name)
+	assert.False(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+
+	rec = serveCSRF(h, http.MethodPost, "/orders", cookies[0], "", func(r *http.Request) {
+		r.Header.Set("X-XSRF-Token", cookies[0].Value)
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,146 @@
+// Package middleware provides a shutdown drain: failing readiness and
+// turning new requests away before the server stops.
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDrainRetryAfter is the Retry-After sent with rejected requests:
+// by then the load balancer routes to another pod.
+const DefaultDrainRetryAfter = time.Second
+
+// ---------- Drainer ----------
+
+// Drainer is the drain state shared by the routers and backend.stop. It is
+// safe for concurrent use; requests see StartDraining at once.
+type Drainer struct {
+	since atomic.Pointer[time.Time]
+}
+
+// NewDrainer creates a Drainer that is not draining.
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// StartDraining marks the process as shutting down. It can't be undone;
+// calls after the first are no-ops.
+func (d *Drainer) StartDraining() {
+	now := time.Now()
+	d.since.CompareAndSwap(nil, &now)
+}
+
+// Draining reports whether StartDraining has been called.
+func (d *Drainer) Draining() bool {
+	return d.since.Load() != nil
+}
+
+// Since returns when draining started, or the zero time.
+func (d *Drainer) Since() time.Time {
+	if t := d.since.Load(); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
+// ---------- Middleware ----------
+
+// DrainOption configures the Drain middleware.
+type DrainOption func(*drainConfig)
+
+type drainConfig struct {
+	readyPaths []string
+	exempt     []string
+	reject     bool
+	retryAfter time.Duration
+}
+
+// WithDrainReadyPaths replaces the readiness paths answered with 503
+// while draining. Defaults to /ready.
+func WithDrainReadyPaths(paths ...string) DrainOption {
+	return func(c *drainConfig) {
+		c.readyPaths = paths
+	}
+}
+
+// WithDrainReject rejects new requests while draining with 503 and a
+// Retry-After of retryAfter (DefaultDrainRetryAfter if not positive),
+// instead of serving them until the listener closes.
+func WithDrainReject(retryAfter time.Duration) DrainOption {
+	return func(c *drainConfig) {
+		c.reject = true
+		c.retryAfter = retryAfter
+		if c.retryAfter <= 0 {
+			c.retryAfter = DefaultDrainRetryAfter
+		}
+	}
+}
+
+// WithDrainExempt replaces the path prefixes WithDrainReject never
+// rejects. Defaults to /health and /check/, so liveness probes keep
+// passing and the pod isn't restarted mid-drain.
+func WithDrainExempt(prefixes ...string) DrainOption {
+	return func(c *drainConfig) {
+		c.exempt = prefixes
+	}
+}
+
+// Drain lets d fail readiness and, with WithDrainReject, turn new
+// requests away during shutdown:
+//
+//	drainer := NewDrainer()
+//	apiRouter.Use(Drain(drainer, WithDrainReject(time.Second)))
+//	monitorRouter.Use(Drain(drainer))
+//
+// Once d is draining, readiness paths get 503 with code "draining"
+// without reaching the handler, and every response carries
+// Connection: close, so keep-alive clients reconnect, through the load
+// balancer, elsewhere. Requests already in the handler are untouched;
+// http.Server.Shutdown waits for them.
+func Drain(d *Drainer, opts ...DrainOption) func(http.Handler) http.Handler {
+	cfg := &drainConfig{
+		readyPaths: []string{"/ready"},
+		exempt:     []string{"/health", "/check/"},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !d.Draining() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Connection", "close")
+			for _, p := range cfg.readyPaths {
+				if r.URL.Path == p {
+					writeError(w, r, http.StatusServiceUnavailable, "shutting down", "draining")
+					return
+				}
+			}
+			if !cfg.reject || cfg.exempts(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			secs := int(math.Ceil(cfg.retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			writeError(w, r, http.StatusServiceUnavailable, "shutting down", "draining")
+		})
+	}
+}
+
+func (c *drainConfig) exempts(path string) bool {
+	for _, p := range c.exempt {
+		if hasPathPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
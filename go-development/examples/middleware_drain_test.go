@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+func serveDrain(d *Drainer, opts []DrainOption, path string) *httptest.ResponseRecorder {
+	h := Drain(d, opts...)(http.HandlerFunc(okHandler))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// ---------- Drainer Tests ----------
+
+func TestDrainer(t *testing.T) {
+	t.Parallel()
+
+	d := NewDrainer()
+	assert.False(t, d.Draining())
+	assert.True(t, d.Since().IsZero())
+
+	d.StartDraining()
+	assert.True(t, d.Draining())
+	since := d.Since()
+	assert.False(t, since.IsZero())
+
+	d.StartDraining()
+	assert.Equal(t, since, d.Since(), "the first call wins")
+}
+
+// ---------- Drain Middleware Tests ----------
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	reject := []DrainOption{WithDrainReject(1500 * time.Millisecond)}
+
+	tests := []struct {
+		name           string
+		opts           []DrainOption
+		draining       bool
+		path           string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "ready before drain", path: "/ready", wantStatus: http.StatusOK},
+		{name: "request before drain", opts: reject, path: "/orders", wantStatus: http.StatusOK},
+		{name: "ready fails", draining: true, path: "/ready", wantStatus: http.StatusServiceUnavailable},
+		{name: "ready fails with reject", opts: reject, draining: true, path: "/ready",
+			wantStatus: http.StatusServiceUnavailable},
+		{name: "served without reject", draining: true, path: "/orders", wantStatus: http.StatusOK},
+		{name: "rejected", opts: reject, draining: true, path: "/orders",
+			wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "2"},
+		{name: "health exempt", opts: reject, draining: true, path: "/health", wantStatus: http.StatusOK},
+		{name: "probe exempt", opts: reject, draining: true, path: "/check/livez", wantStatus: http.StatusOK},
+		{name: "default retry after", opts: []DrainOption{WithDrainReject(0)}, draining: true, path: "/orders",
+			wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "1"},
+		{name: "custom ready path", opts: []DrainOption{WithDrainReadyPaths("/readyz")}, draining: true,
+			path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{name: "old ready path", opts: []DrainOption{WithDrainReadyPaths("/readyz")}, draining: true,
+			path: "/ready", wantStatus: http.StatusOK},
+		{name: "replaced exemptions", opts: append([]DrainOption{WithDrainExempt()}, reject...), draining: true,
+			path: "/health", wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := NewDrainer()
+			if tt.draining {
+				d.StartDraining()
+			}
+
+			rec := serveDrain(d, tt.opts, tt.path)
+			require.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantRetryAfter, rec.Header().Get("Retry-After"))
+			if tt.draining {
+				assert.Equal(t, "close", rec.Header().Get("Connection"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Connection"))
+			}
+			if rec.Code == http.StatusServiceUnavailable {
+				assert.Equal(t, "draining", decodeError(t, rec.Body).Code)
+			}
+		})
+	}
+}
+
+// TestDrain_ShutdownSequence runs backend.stop's order against a real
+// server: start draining, check what the load balancer would see during
+// the pre-stop delay, then Shutdown while a request is still in flight.
+func TestDrain_ShutdownSequence(t *testing.T) {
+	t.Parallel()
+
+	drainer := NewDrainer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := chi.NewRouter()
+	router.Use(Drain(drainer, WithDrainReject(time.Second)))
+	router.Get("/ready", okHandler)
+	router.Get("/health", okHandler)
+	router.Get("/orders", okHandler)
+	router.Get("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	get := func(path string) *http.Response {
+		resp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusOK, get("/ready").StatusCode)
+
+	slow := make(chan *http.Response, 1)
+	go func() {
+		resp, err := srv.Client().Get(srv.URL + "/slow")
+		if err != nil {
+			slow <- nil
+			return
+		}
+		slow <- resp
+	}()
+	<-started
+
+	// SIGTERM: mark draining
+	drainer.StartDraining()
+
+	// Pre-stop delay: readiness fails, new requests are turned away
+	ready := get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, ready.StatusCode)
+	assert.True(t, ready.Close, "Connection: close")
+
+	orders := get("/orders")
+	assert.Equal(t, http.StatusServiceUnavailable, orders.StatusCode)
+	assert.Equal(t, "1", orders.Header.Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, get("/health").StatusCode, "liveness keeps passing")
+
+	// Shutdown: waits for the in-flight request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Config.Shutdown(ctx) }()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned with a request in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	resp := <-slow
+	require.NotNil(t, resp, "in-flight request completed")
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "done", string(body))
+
+	require.NoError(t, <-shutdown)
+}
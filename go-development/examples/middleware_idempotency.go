@@ -0,0 +1,425 @@
+// Package middleware provides Idempotency-Key handling, so retried unsafe
+// requests replay the first response instead of executing twice.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"myapp/internal/cache"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	maxIdempotencyKeyLen = 255
+
+	// idempotencyLockTTL bounds how long an in-flight record blocks
+	// retries if the pod dies before completing it.
+	idempotencyLockTTL = time.Minute
+)
+
+// replayedHeaders are the response headers stored and replayed.
+var replayedHeaders = []string{"Content-Type", "Content-Location", "Location", "ETag", "Last-Modified"}
+
+// IdempotencyRecord is the stored state of one key: in flight until
+// Done, then the response to replay.
+type IdempotencyRecord struct {
+	RequestHash string      `json:"request_hash"` // SHA-256 of the request body
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// IdempotencyStore keeps records per key. Implementations must be safe for
+// concurrent use.
+type IdempotencyStore interface {
+	// Claim stores rec under key unless the key exists. It reports whether
+	// rec was stored and, if not, returns the existing record (nil if it
+	// expired in between).
+	Claim(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Save replaces the record under key.
+	Save(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error
+	// Release deletes key, so the next request with it executes.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyOption configures Idempotency.
+type IdempotencyOption func(*idempotency)
+
+// WithMaxReplayBody sets the largest response body stored for replay.
+// Larger responses aren't stored: the key is released and a retry
+// executes again. Defaults to 64 KB.
+func WithMaxReplayBody(n int) IdempotencyOption {
+	return func(m *idempotency) {
+		m.maxBody = n
+	}
+}
+
+// WithMaxRequestBody sets the largest request body read for hashing;
+// larger ones get 413 before the handler runs. Defaults to 1 MB. A
+// MaxBodyBytes limit mounted earlier still applies if it is lower.
+func WithMaxRequestBody(n int64) IdempotencyOption {
+	return func(m *idempotency) {
+		m.maxRequest = n
+	}
+}
+
+// WithIdempotencyCaller sets how callers are told apart, so two clients
+// choosing the same key don't see each other's responses. Defaults to
+// UserOrIP.
+func WithIdempotencyCaller(fn func(*http.Request) string) IdempotencyOption {
+	return func(m *idempotency) {
+		m.caller = fn
+	}
+}
+
+// WithIdempotencyLogger sets the logger for store errors. Defaults to
+// slog.Default().
+func WithIdempotencyLogger(logger *slog.Logger) IdempotencyOption {
+	return func(m *idempotency) {
+		m.logger = logger
+	}
+}
+
+type idempotency struct {
+	store      IdempotencyStore
+	ttl        time.Duration
+	maxBody    int
+	maxRequest int64
+	caller     func(*http.Request) string
+	logger     *slog.Logger
+}
+
+// Idempotency makes POST and PATCH requests carrying an Idempotency-Key
+// header execute at most once per key, caller and path within ttl:
+//
+//   - A duplicate of a completed request gets the stored status, headers
+//     and body, with Idempotent-Replayed: true; the handler doesn't run.
+//   - A duplicate arriving while the first is in flight gets 409.
+//   - Reusing a key with a different body gets 422.
+//
+// The request body is buffered to hash it, up to WithMaxRequestBody.
+// Flushes pass through, so streaming handlers work, but a streamed
+// response over WithMaxReplayBody isn't stored for replay.
+// 5xx responses aren't stored, so clients can retry server failures.
+// Store errors fail closed with 503: running without the guard is what
+// double-charges. Mount it after Auth so callers are keyed by user.
+func Idempotency(store IdempotencyStore, ttl time.Duration, opts ...IdempotencyOption) func(http.Handler) http.Handler {
+	m := &idempotency{
+		store:      store,
+		ttl:        ttl,
+		maxBody:    64 << 10,
+		maxRequest: 1 << 20,
+		caller:     UserOrIP,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				writeError(w, r, http.StatusBadRequest,
+					fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLen),
+					"bad_request")
+				return
+			}
+
+			m.serve(w, r, next, key)
+		})
+	}
+}
+
+func (m *idempotency) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.maxRequest))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, r, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit), "request_too_large")
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, "failed to read request body", "bad_request")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	storeKey := m.storeKey(r, key)
+	sum := sha256.Sum256(body)
+	pending := &IdempotencyRecord{RequestHash: hex.EncodeToString(sum[:])}
+
+	existing, claimed, err := m.store.Claim(ctx, storeKey, pending, min(m.ttl, idempotencyLockTTL))
+	if err != nil {
+		m.storeFailed(w, r, err)
+		return
+	}
+	if !claimed {
+		m.duplicate(w, r, pending, existing)
+		return
+	}
+
+	rec := &captureWriter{ResponseWriter: w, status: http.StatusOK, max: m.maxBody}
+	completed := false
+	defer func() {
+		// A panicking handler completed nothing; let a retry run it.
+		if !completed {
+			m.release(r, storeKey)
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+	completed = true
+
+	if rec.status >= http.StatusInternalServerError || rec.overflow {
+		m.release(r, storeKey)
+		return
+	}
+
+	done := &IdempotencyRecord{
+		RequestHash: pending.RequestHash,
+		Done:        true,
+		Status:      rec.status,
+		Header:      make(http.Header),
+		Body:        rec.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if v := rec.Header().Values(name); len(v) > 0 {
+			done.Header[name] = v
+		}
+	}
+	// The response is already sent; use a context that outlives the request.
+	if err := m.store.Save(context.WithoutCancel(ctx), storeKey, done, m.ttl); err != nil {
+		m.logger.ErrorContext(ctx, "idempotency store save failed",
+			slog.String("request_id", middleware.GetReqID(ctx)),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// storeKey scopes the client's key to the caller and the request target.
+func (m *idempotency) storeKey(r *http.Request, key string) string {
+	sum := sha256.Sum256([]byte(m.caller(r) + "\x00" + r.Method + " " + r.URL.Path + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *idempotency) duplicate(w http.ResponseWriter, r *http.Request, pending, existing *IdempotencyRecord) {
+	switch {
+	case existing != nil && existing.RequestHash != pending.RequestHash:
+		writeError(w, r, http.StatusUnprocessableEntity,
+			"idempotency key reused with a different request body", "idempotency_key_reused")
+	case existing == nil || !existing.Done:
+		writeError(w, r, http.StatusConflict, "request in flight", "request_in_flight")
+	default:
+		for name, values := range existing.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(existing.Status)
+		w.Write(existing.Body)
+	}
+}
+
+func (m *idempotency) release(r *http.Request, key string) {
+	if err := m.store.Release(context.WithoutCancel(r.Context()), key); err != nil {
+		m.logger.ErrorContext(r.Context(), "idempotency store release failed",
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func (m *idempotency) storeFailed(w http.ResponseWriter, r *http.Request, err error) {
+	m.logger.ErrorContext(r.Context(), "idempotency store failed",
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+		slog.Any("error", err),
+	)
+	writeError(w, r, http.StatusServiceUnavailable, "service unavailable", "unavailable")
+}
+
+// captureWriter passes the response through and keeps a copy of up to max
+// body bytes.
+type captureWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (w *captureWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(b) > w.max {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// ---------- In-Memory Store ----------
+
+// MemoryIdempotencyStore keeps records in process memory. Each pod has
+// its own, so retries routed to another pod execute again; use
+// CacheIdempotencyStore with more than one replica.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryRecord
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryRecord struct {
+	rec     *IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]memoryRecord),
+		now:     time.Now,
+	}
+}
+
+// Claim implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Claim(_ context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if r, ok := s.records[key]; ok && now.Before(r.expires) {
+		return r.rec, false, nil
+	}
+	s.sweep(now)
+	s.records[key] = memoryRecord{rec: rec, expires: now.Add(ttl)}
+	return nil, true, nil
+}
+
+// Save implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryRecord{rec: rec, expires: s.now().Add(ttl)}
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops expired records, at most once a minute.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, r := range s.records {
+		if !now.Before(r.expires) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// ---------- Cache Store ----------
+
+const idempotencyKeyPrefix = "idempotency:"
+
+// CacheIdempotencyStore keeps records in the cache, shared by all pods.
+type CacheIdempotencyStore struct {
+	client cache.Client
+}
+
+// NewCacheIdempotencyStore creates a store on the cache client.
+func NewCacheIdempotencyStore(client cache.Client) *CacheIdempotencyStore {
+	return &CacheIdempotencyStore{client: client}
+}
+
+// Claim implements IdempotencyStore with SET NX, reading the existing
+// record in the same pipeline.
+func (s *CacheIdempotencyStore) Claim(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	var existing IdempotencyRecord
+	res, err := s.client.ExecBatch(ctx, "idempotency_claim",
+		cache.SetNXObjWithTTL(idempotencyKeyPrefix+key, rec, ttl),
+		cache.GetObj(idempotencyKeyPrefix+key, &existing),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, r := range res {
+		if err := r.Err(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if res[0].Val().(bool) {
+		return nil, true, nil
+	}
+	if res[1].Val() == nil {
+		return nil, false, nil
+	}
+	return &existing, false, nil
+}
+
+// Save implements IdempotencyStore.
+func (s *CacheIdempotencyStore) Save(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	return s.exec(ctx, "idempotency_save", cache.SetObjWithTTL(idempotencyKeyPrefix+key, rec, ttl))
+}
+
+// Release implements IdempotencyStore.
+func (s *CacheIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.exec(ctx, "idempotency_release", cache.DelObj(idempotencyKeyPrefix+key))
+}
+
+func (s *CacheIdempotencyStore) exec(ctx context.Context, name string, req cache.Req) error {
+	res, err := s.client.ExecBatch(ctx, name, req)
+	if err != nil {
+		return err
+	}
+	return res[0].Err()
+}
@@ -0,0 +1,369 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/cache"
+)
+
+// ---------- Test Helpers ----------
+
+// paymentHandler counts executions and answers 201 with a new payment ID.
+type paymentHandler struct {
+	calls atomic.Int64
+	block chan struct{} // if set, handlers wait on it
+}
+
+func (h *paymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	if h.block != nil {
+		<-h.block
+	}
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/payments/%d", n))
+	w.Header().Set("X-Debug", "not replayed")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"id":%d,"request":%s}`, n, body)
+}
+
+func paymentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.1:1234"
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return req
+}
+
+func serveIdempotent(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type failingIdempotencyStore struct{ MemoryIdempotencyStore }
+
+func (*failingIdempotencyStore) Claim(context.Context, string, *IdempotencyRecord, time.Duration) (*IdempotencyRecord, bool, error) {
+	return nil, false, errors.New("cache unavailable")
+}
+
+// ---------- Idempotency Tests ----------
+
+func TestIdempotency_Replay(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	first := serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	second := serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+
+	assert.Equal(t, int64(1), next.calls.Load(), "charged once")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "/payments/1", second.Header().Get("Location"))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, second.Header().Get("X-Debug"), "only allow-listed headers stored")
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_DifferentPayload(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+	rec := serveIdempotent(h, paymentRequest("key-1", `{"amount":500}`))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "idempotency_key_reused", decodeError(t, rec.Body).Code)
+	assert.Equal(t, int64(1), next.calls.Load())
+}
+
+func TestIdempotency_Scope(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	serveIdempotent(h, paymentRequest("key-1", `{}`))
+
+	otherKey := paymentRequest("key-2", `{}`)
+	otherCaller := paymentRequest("key-1", `{}`)
+	otherCaller.RemoteAddr = "203.0.113.2:1234"
+	otherPath := httptest.NewRequest(http.MethodPost, "/refunds", strings.NewReader(`{}`))
+	otherPath.RemoteAddr = "203.0.113.1:1234"
+	otherPath.Header.Set(IdempotencyKeyHeader, "key-1")
+
+	for _, req := range []*http.Request{otherKey, otherCaller, otherPath} {
+		rec := serveIdempotent(h, req)
+		assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	}
+	assert.Equal(t, int64(4), next.calls.Load())
+}
+
+func TestIdempotency_PassThrough(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	serveIdempotent(h, paymentRequest("", `{}`))
+	serveIdempotent(h, paymentRequest("", `{}`))
+
+	put := httptest.NewRequest(http.MethodPut, "/payments/1", strings.NewReader(`{}`))
+	put.Header.Set(IdempotencyKeyHeader, "key-1")
+	serveIdempotent(h, put)
+	serveIdempotent(h, put)
+
+	assert.Equal(t, int64(4), next.calls.Load(), "no key, or a method other than POST/PATCH")
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{block: make(chan struct{})}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var first *httptest.ResponseRecorder
+	go func() {
+		defer wg.Done()
+		first = serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+	}()
+	require.Eventually(t, func() bool { return next.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	dup := serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+	assert.Equal(t, http.StatusConflict, dup.Code)
+	assert.Equal(t, "request_in_flight", decodeError(t, dup.Body).Code)
+
+	close(next.block)
+	wg.Wait()
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, int64(1), next.calls.Load())
+}
+
+func TestIdempotency_ConcurrentDuplicates(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+			assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, rec.Code)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), next.calls.Load(), "executed exactly once")
+}
+
+func TestIdempotency_Expiry(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock(time.Unix(1000, 0))
+	store := NewMemoryIdempotencyStore()
+	store.now = clock.Now
+	next := &paymentHandler{}
+	h := Idempotency(store, time.Hour)(next)
+
+	serveIdempotent(h, paymentRequest("key-1", `{}`))
+	clock.Advance(59 * time.Minute)
+	rec := serveIdempotent(h, paymentRequest("key-1", `{}`))
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+
+	clock.Advance(time.Minute)
+	rec = serveIdempotent(h, paymentRequest("key-1", `{}`))
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"), "expired after the TTL")
+	assert.Equal(t, int64(2), next.calls.Load())
+
+	clock.Advance(2 * time.Hour)
+	serveIdempotent(h, paymentRequest("key-2", `{}`))
+	assert.Len(t, store.records, 1, "expired records swept")
+}
+
+func TestIdempotency_NotStored(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{name: "server error", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{name: "body over the cap", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strings.Repeat("a", 600)))
+			w.Write([]byte(strings.Repeat("a", 600)))
+		}},
+		{name: "panic", handler: func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int64
+			h := Idempotency(NewMemoryIdempotencyStore(), time.Hour, WithMaxReplayBody(1024))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls.Add(1)
+					tt.handler(w, r)
+				}))
+			serve := func() {
+				defer func() { _ = recover() }()
+				serveIdempotent(h, paymentRequest("key-1", `{}`))
+			}
+
+			serve()
+			serve()
+			assert.Equal(t, int64(2), calls.Load(), "key released, retry executes")
+		})
+	}
+}
+
+func TestIdempotency_RequestTooLarge(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour, WithMaxRequestBody(16))(next)
+
+	req := paymentRequest("key-1", `{"amount":100,"currency":"EUR"}`)
+	req.ContentLength = -1 // chunked: only the reader can tell
+	rec := serveIdempotent(h, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Zero(t, next.calls.Load())
+
+	rec = serveIdempotent(h, paymentRequest("key-1", `{}`))
+	assert.Equal(t, http.StatusCreated, rec.Code, "nothing claimed for the rejected request")
+}
+
+func TestIdempotency_Flush(t *testing.T) {
+	t.Parallel()
+
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flusher, ok := w.(http.Flusher)
+			require.True(t, ok, "streaming handlers can flush")
+			w.Write([]byte("chunk"))
+			flusher.Flush()
+			require.NoError(t, http.NewResponseController(w).Flush())
+		}))
+
+	rec := serveIdempotent(h, paymentRequest("key-1", `{}`))
+	assert.True(t, rec.Flushed)
+
+	rec = serveIdempotent(h, paymentRequest("key-1", `{}`))
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "chunk", rec.Body.String())
+}
+
+func TestIdempotency_InvalidKey(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(NewMemoryIdempotencyStore(), time.Hour)(next)
+
+	rec := serveIdempotent(h, paymentRequest(strings.Repeat("k", 256), `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Zero(t, next.calls.Load())
+}
+
+func TestIdempotency_StoreErrorFailsClosed(t *testing.T) {
+	t.Parallel()
+
+	next := &paymentHandler{}
+	h := Idempotency(&failingIdempotencyStore{}, time.Hour, WithIdempotencyLogger(discardLogger))(next)
+
+	rec := serveIdempotent(h, paymentRequest("key-1", `{}`))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Zero(t, next.calls.Load(), "not executed without the guard")
+}
+
+// ---------- CacheIdempotencyStore Tests ----------
+
+func TestCacheIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+	client, err := cache.NewRedisClient(context.Background(), &cache.RedisConfig{Server: mr.Addr()})
+	require.NoError(t, err)
+
+	next := &paymentHandler{}
+	h := Idempotency(NewCacheIdempotencyStore(client), time.Hour)(next)
+
+	first := serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	second := serveIdempotent(h, paymentRequest("key-1", `{"amount":100}`))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "/payments/1", second.Header().Get("Location"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+
+	rec := serveIdempotent(h, paymentRequest("key-1", `{"amount":500}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, int64(1), next.calls.Load())
+
+	keys := mr.Keys()
+	require.Len(t, keys, 1)
+	assert.True(t, strings.HasPrefix(keys[0], "idempotency:"))
+	assert.Equal(t, time.Hour, mr.TTL(keys[0]))
+
+	mr.FastForward(time.Hour)
+	rec = serveIdempotent(h, paymentRequest("key-1", `{"amount":500}`))
+	assert.Equal(t, http.StatusCreated, rec.Code, "expired")
+	assert.Equal(t, int64(2), next.calls.Load())
+}
+
+func TestCacheIdempotencyStore_InFlight(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+	client, err := cache.NewRedisClient(context.Background(), &cache.RedisConfig{Server: mr.Addr()})
+	require.NoError(t, err)
+	store := NewCacheIdempotencyStore(client)
+	ctx := context.Background()
+
+	_, claimed, err := store.Claim(ctx, "k", &IdempotencyRecord{RequestHash: "h"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	existing, claimed, err := store.Claim(ctx, "k", &IdempotencyRecord{RequestHash: "h"}, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, &IdempotencyRecord{RequestHash: "h"}, existing)
+
+	require.NoError(t, store.Release(ctx, "k"))
+	_, claimed, err = store.Claim(ctx, "k", &IdempotencyRecord{RequestHash: "h"}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed, "claimable again once released")
+}
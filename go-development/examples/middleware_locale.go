@@ -0,0 +1,134 @@
+// Package middleware provides locale negotiation from Accept-Language.
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+type localeCtxKey struct{}
+
+// LocaleOption configures Locale.
+type LocaleOption func(*localeOptions)
+
+type localeOptions struct {
+	queryParam string
+	cookieName string
+}
+
+// WithLocaleQuery lets a query parameter, e.g. "lang" for ?lang=de,
+// override everything else. Useful for links and testing.
+func WithLocaleQuery(param string) LocaleOption {
+	return func(o *localeOptions) {
+		o.queryParam = param
+	}
+}
+
+// WithLocaleCookie reads the user's saved choice from a cookie, which
+// overrides Accept-Language.
+func WithLocaleCookie(name string) LocaleOption {
+	return func(o *localeOptions) {
+		o.cookieName = name
+	}
+}
+
+// Locale stores the best supported locale for each request in context,
+// read by LocaleFromContext. Sources, first supported match wins:
+//
+//  1. the WithLocaleQuery parameter
+//  2. the WithLocaleCookie cookie
+//  3. Accept-Language, by q-value ("de-AT" matches a supported "de")
+//  4. fallback
+//
+// Values are returned as written in supported. It panics on an invalid
+// tag, at startup rather than per request.
+func Locale(supported []string, fallback string, opts ...LocaleOption) func(http.Handler) http.Handler {
+	cfg := &localeOptions{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// The matcher falls back to its first tag
+	names := append([]string{fallback}, supported...)
+	tags := make([]language.Tag, len(names))
+	for i, name := range names {
+		tags[i] = language.MustParse(name)
+	}
+	matcher := language.NewMatcher(tags)
+
+	match := func(desired ...language.Tag) (string, bool) {
+		if len(desired) == 0 {
+			return "", false
+		}
+		_, i, confidence := matcher.Match(desired...)
+		if confidence == language.No {
+			return "", false
+		}
+		return names[i], true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+
+			locale := fallback
+			if l, ok := cfg.fromQuery(r, match); ok {
+				locale = l
+			} else if l, ok := cfg.fromCookie(r, match); ok {
+				locale = l
+			} else if desired, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil {
+				if l, ok := match(desired...); ok {
+					locale = l
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), locale)))
+		})
+	}
+}
+
+func (o *localeOptions) fromQuery(r *http.Request, match func(...language.Tag) (string, bool)) (string, bool) {
+	if o.queryParam == "" {
+		return "", false
+	}
+	return matchTag(r.URL.Query().Get(o.queryParam), match)
+}
+
+func (o *localeOptions) fromCookie(r *http.Request, match func(...language.Tag) (string, bool)) (string, bool) {
+	if o.cookieName == "" {
+		return "", false
+	}
+	cookie, err := r.Cookie(o.cookieName)
+	if err != nil {
+		return "", false
+	}
+	return matchTag(cookie.Value, match)
+}
+
+// matchTag matches a single tag; unparsable or unsupported values are
+// skipped, so a stale cookie doesn't hide Accept-Language.
+func matchTag(value string, match func(...language.Tag) (string, bool)) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	tag, err := language.Parse(value)
+	if err != nil {
+		return "", false
+	}
+	return match(tag)
+}
+
+// WithLocale returns ctx carrying locale, for tests and for work started
+// outside an HTTP request.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeCtxKey{}, locale)
+}
+
+// LocaleFromContext returns the request's locale, or "" if Locale didn't
+// run.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeCtxKey{}).(string)
+	return locale
+}
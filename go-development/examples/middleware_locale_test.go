@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Test Helpers ----------
+
+func serveLocale(h func(http.Handler) http.Handler, target, acceptLanguage, cookie string) (string, http.Header) {
+	var got string
+	handler := h(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = LocaleFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: "lang", Value: cookie})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return got, rec.Header()
+}
+
+// ---------- Locale Tests ----------
+
+func TestLocale_AcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	mw := Locale([]string{"en", "de", "fr", "pt-BR"}, "en")
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "exact", header: "de", want: "de"},
+		{name: "q-values order, not position", header: "fr;q=0.5, de;q=0.9, en;q=0.1", want: "de"},
+		{name: "unsupported preferred skipped", header: "ja, fr;q=0.8", want: "fr"},
+		{name: "region matches base", header: "de-AT", want: "de"},
+		{name: "base matches region", header: "pt", want: "pt-BR"},
+		{name: "only unsupported falls back", header: "ja, zh;q=0.9", want: "en"},
+		{name: "malformed falls back", header: "!!;q=x", want: "en"},
+		{name: "missing falls back", header: "", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, header := serveLocale(mw, "/", tt.header, "")
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "Accept-Language", header.Get("Vary"))
+		})
+	}
+}
+
+func TestLocale_Precedence(t *testing.T) {
+	t.Parallel()
+
+	mw := Locale([]string{"en", "de", "fr"}, "en", WithLocaleQuery("lang"), WithLocaleCookie("lang"))
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		cookie string
+		want   string
+	}{
+		{name: "query over cookie and header", target: "/?lang=fr", header: "de", cookie: "de", want: "fr"},
+		{name: "cookie over header", target: "/", header: "de", cookie: "fr", want: "fr"},
+		{name: "header without overrides", target: "/", header: "de", want: "de"},
+		{name: "unsupported query skipped", target: "/?lang=ja", header: "de", cookie: "fr", want: "fr"},
+		{name: "invalid cookie skipped", target: "/", header: "de", cookie: "not a tag", want: "de"},
+		{name: "nothing supported", target: "/?lang=ja", header: "zh", cookie: "ko", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, _ := serveLocale(mw, tt.target, tt.header, tt.cookie)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLocale_OverridesOffByDefault(t *testing.T) {
+	t.Parallel()
+
+	got, _ := serveLocale(Locale([]string{"en", "de"}, "en"), "/?lang=de", "", "de")
+	assert.Equal(t, "en", got)
+}
+
+func TestLocale_FallbackOutsideSupported(t *testing.T) {
+	t.Parallel()
+
+	mw := Locale([]string{"de", "fr"}, "en")
+
+	got, _ := serveLocale(mw, "/", "ja", "")
+	assert.Equal(t, "en", got)
+	got, _ = serveLocale(mw, "/", "fr", "")
+	assert.Equal(t, "fr", got)
+}
+
+func TestLocale_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { Locale([]string{"en", "not a tag"}, "en") })
+}
+
+func TestLocaleFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, LocaleFromContext(context.Background()))
+	assert.Equal(t, "de", LocaleFromContext(WithLocale(context.Background(), "de")))
+}
@@ -0,0 +1,220 @@
+// Package middleware provides a maintenance mode togglable at runtime.
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultMaintenanceMessage = "service is under maintenance"
+
+// ---------- Controller ----------
+
+// MaintenanceStatus is a snapshot of the maintenance mode. Only Enabled
+// is set while it is disabled.
+type MaintenanceStatus struct {
+	Enabled    bool          `json:"enabled"`
+	Message    string        `json:"message,omitempty"`
+	RetryAfter time.Duration `json:"retry_after_ns,omitempty"`
+	Since      time.Time     `json:"since"`
+}
+
+// MaintenanceOption configures a MaintenanceController.
+type MaintenanceOption func(*MaintenanceController)
+
+// WithMaintenanceMethods replaces the methods blocked during maintenance.
+// Defaults to POST, PUT, PATCH and DELETE, so reads keep working.
+func WithMaintenanceMethods(methods ...string) MaintenanceOption {
+	return func(c *MaintenanceController) {
+		c.methods = make(map[string]bool, len(methods))
+		for _, m := range methods {
+			c.methods[strings.ToUpper(m)] = true
+		}
+	}
+}
+
+// WithMaintenancePaths limits blocking to these path prefixes. Defaults
+// to every path.
+func WithMaintenancePaths(prefixes ...string) MaintenanceOption {
+	return func(c *MaintenanceController) {
+		c.paths = prefixes
+	}
+}
+
+// WithMaintenanceExempt replaces the path prefixes never blocked. Defaults
+// to the health endpoints, so orchestrators don't restart pods mid-migration.
+func WithMaintenanceExempt(prefixes ...string) MaintenanceOption {
+	return func(c *MaintenanceController) {
+		c.exempt = prefixes
+	}
+}
+
+// MaintenanceController switches maintenance mode on and off. It is safe
+// for concurrent use; requests see a change as soon as it is made.
+type MaintenanceController struct {
+	state   atomic.Pointer[MaintenanceStatus]
+	methods map[string]bool
+	paths   []string
+	exempt  []string
+}
+
+// NewMaintenanceController creates a controller with maintenance disabled.
+func NewMaintenanceController(opts ...MaintenanceOption) *MaintenanceController {
+	c := &MaintenanceController{
+		methods: map[string]bool{
+			http.MethodPost: true, http.MethodPut: true,
+			http.MethodPatch: true, http.MethodDelete: true,
+		},
+		exempt: []string{"/health", "/ready", "/check/"},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.state.Store(&MaintenanceStatus{})
+	return c
+}
+
+// Enable blocks matching requests with message (a default if empty) and a
+// Retry-After of retryAfter, if positive.
+func (c *MaintenanceController) Enable(message string, retryAfter time.Duration) {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	c.state.Store(&MaintenanceStatus{
+		Enabled:    true,
+		Message:    message,
+		RetryAfter: max(retryAfter, 0),
+		Since:      time.Now(),
+	})
+}
+
+// Disable lets every request through again.
+func (c *MaintenanceController) Disable() {
+	c.state.Store(&MaintenanceStatus{})
+}
+
+// Status returns the current maintenance mode.
+func (c *MaintenanceController) Status() MaintenanceStatus {
+	return *c.state.Load()
+}
+
+// Blocks reports whether r is blocked while maintenance is enabled,
+// regardless of whether it currently is.
+func (c *MaintenanceController) Blocks(r *http.Request) bool {
+	if !c.methods[r.Method] {
+		return false
+	}
+	for _, p := range c.exempt {
+		if hasPathPrefix(r.URL.Path, p) {
+			return false
+		}
+	}
+	if len(c.paths) == 0 {
+		return true
+	}
+	for _, p := range c.paths {
+		if hasPathPrefix(r.URL.Path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix matches whole segments: "/orders" matches "/orders" and
+// "/orders/1" but not "/orders-archive". A trailing slash matches below it.
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// ---------- Middleware ----------
+
+// Maintenance rejects the requests ctrl blocks with 503 and code
+// "maintenance" while maintenance is enabled.
+func Maintenance(ctrl *MaintenanceController) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := ctrl.Status()
+			if !status.Enabled || !ctrl.Blocks(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if status.RetryAfter > 0 {
+				secs := int(math.Ceil(status.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+			}
+			writeError(w, r, http.StatusServiceUnavailable, status.Message, "maintenance")
+		})
+	}
+}
+
+// ---------- Admin Handler ----------
+
+const MaintenanceAdminPathPrefix = "/admin/maintenance"
+
+// MaintenanceAdminHandler shows and toggles maintenance mode over HTTP.
+// Mount on the monitor server behind auth, not on the public API.
+type MaintenanceAdminHandler struct {
+	http.Handler
+	ctrl *MaintenanceController
+}
+
+// NewMaintenanceAdminHandler creates an admin handler for ctrl.
+func NewMaintenanceAdminHandler(ctrl *MaintenanceController) *MaintenanceAdminHandler {
+	router := chi.NewRouter()
+	handler := &MaintenanceAdminHandler{
+		Handler: router,
+		ctrl:    ctrl,
+	}
+
+	router.Get("/", handler.handleStatus)
+	router.Post("/enable", handler.handleEnable)
+	router.Post("/disable", handler.handleDisable)
+
+	return handler
+}
+
+type enableMaintenanceRequest struct {
+	Message           string `json:"message"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
+
+func (h *MaintenanceAdminHandler) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.ctrl.Status())
+}
+
+// handleEnable takes an optional JSON body; an empty one enables
+// maintenance with the default message and no Retry-After.
+func (h *MaintenanceAdminHandler) handleEnable(w http.ResponseWriter, r *http.Request) {
+	var req enableMaintenanceRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid JSON", "bad_request")
+			return
+		}
+	}
+	if req.RetryAfterSeconds < 0 {
+		writeError(w, r, http.StatusBadRequest, "retry_after_seconds must not be negative", "bad_request")
+		return
+	}
+
+	h.ctrl.Enable(req.Message, time.Duration(req.RetryAfterSeconds)*time.Second)
+	h.handleStatus(w, r)
+}
+
+func (h *MaintenanceAdminHandler) handleDisable(w http.ResponseWriter, r *http.Request) {
+	h.ctrl.Disable()
+	h.handleStatus(w, r)
+}
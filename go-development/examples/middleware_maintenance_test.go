@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+func serveMaintenance(ctrl *MaintenanceController, method, path string) *httptest.ResponseRecorder {
+	h := Maintenance(ctrl)(http.HandlerFunc(okHandler))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+// ---------- Maintenance Tests ----------
+
+func TestMaintenance_Blocks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		opts   []MaintenanceOption
+		method string
+		path   string
+		want   bool
+	}{
+		{name: "write blocked", method: http.MethodPost, path: "/api/v1/orders", want: true},
+		{name: "delete blocked", method: http.MethodDelete, path: "/api/v1/orders/1", want: true},
+		{name: "read allowed", method: http.MethodGet, path: "/api/v1/orders", want: false},
+		{name: "head allowed", method: http.MethodHead, path: "/api/v1/orders", want: false},
+		{name: "health exempt", method: http.MethodPost, path: "/health", want: false},
+		{name: "probe exempt", method: http.MethodPost, path: "/check/readyz/", want: false},
+		{
+			name:   "custom methods",
+			opts:   []MaintenanceOption{WithMaintenanceMethods("get")},
+			method: http.MethodGet, path: "/api/v1/orders", want: true,
+		},
+		{
+			name:   "inside blocked prefix",
+			opts:   []MaintenanceOption{WithMaintenancePaths("/api/v1/orders")},
+			method: http.MethodPost, path: "/api/v1/orders/1/refund", want: true,
+		},
+		{
+			name:   "outside blocked prefix",
+			opts:   []MaintenanceOption{WithMaintenancePaths("/api/v1/orders")},
+			method: http.MethodPost, path: "/api/v1/users", want: false,
+		},
+		{
+			name:   "prefix matches whole segments",
+			opts:   []MaintenanceOption{WithMaintenancePaths("/api/v1/orders")},
+			method: http.MethodPost, path: "/api/v1/orders-archive", want: false,
+		},
+		{
+			name:   "exempt wins over blocked prefix",
+			opts:   []MaintenanceOption{WithMaintenancePaths("/api"), WithMaintenanceExempt("/api/v1/auth/")},
+			method: http.MethodPost, path: "/api/v1/auth/login", want: false,
+		},
+		{
+			name:   "replaced exemptions",
+			opts:   []MaintenanceOption{WithMaintenanceExempt()},
+			method: http.MethodPost, path: "/health", want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := NewMaintenanceController(tt.opts...)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			assert.Equal(t, tt.want, ctrl.Blocks(req))
+		})
+	}
+}
+
+func TestMaintenance_Toggle(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewMaintenanceController()
+
+	rec := serveMaintenance(ctrl, http.MethodPost, "/orders")
+	assert.Equal(t, http.StatusOK, rec.Code, "disabled by default")
+
+	ctrl.Enable("database migration in progress", 90*time.Second+time.Millisecond)
+
+	rec = serveMaintenance(ctrl, http.MethodPost, "/orders")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "91", rec.Header().Get("Retry-After"))
+	body := decodeError(t, rec.Body)
+	assert.Equal(t, "maintenance", body.Code)
+	assert.Equal(t, "database migration in progress", body.Error)
+
+	assert.Equal(t, http.StatusOK, serveMaintenance(ctrl, http.MethodGet, "/orders").Code)
+
+	ctrl.Disable()
+	assert.Equal(t, http.StatusOK, serveMaintenance(ctrl, http.MethodPost, "/orders").Code)
+}
+
+func TestMaintenance_Defaults(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewMaintenanceController()
+	ctrl.Enable("", -time.Second)
+
+	rec := serveMaintenance(ctrl, http.MethodPut, "/orders/1")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+	assert.Equal(t, defaultMaintenanceMessage, decodeError(t, rec.Body).Error)
+}
+
+func TestMaintenance_ConcurrentToggle(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewMaintenanceController()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				ctrl.Enable("migrating", time.Minute)
+			} else {
+				ctrl.Disable()
+			}
+		}
+	}()
+
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				rec := serveMaintenance(ctrl, http.MethodPost, "/orders")
+				if rec.Code == http.StatusServiceUnavailable {
+					// A blocked response is always a complete one
+					assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+					assert.Equal(t, "migrating", decodeError(t, rec.Body).Error)
+				} else {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				}
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+}
+
+// ---------- Maintenance Admin Handler Tests ----------
+
+func TestMaintenanceAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	ctrl := NewMaintenanceController()
+	handler := NewMaintenanceAdminHandler(ctrl)
+
+	call := func(method, path, body string) (int, MaintenanceStatus) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+		var status MaintenanceStatus
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&status))
+		}
+		return rec.Code, status
+	}
+
+	code, status := call(http.MethodGet, "/", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, status.Enabled)
+
+	code, status = call(http.MethodPost, "/enable", `{"message":"upgrading","retry_after_seconds":120}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, "upgrading", status.Message)
+	assert.Equal(t, 2*time.Minute, status.RetryAfter)
+	assert.True(t, ctrl.Status().Enabled)
+
+	code, _ = call(http.MethodPost, "/enable", `{"retry_after_seconds":-1}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call(http.MethodPost, "/enable", `{`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "upgrading", ctrl.Status().Message, "bad requests change nothing")
+
+	code, status = call(http.MethodPost, "/disable", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, status.Enabled)
+
+	code, status = call(http.MethodPost, "/enable", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, defaultMaintenanceMessage, status.Message, "empty body uses defaults")
+}
@@ -0,0 +1,147 @@
+// Package middleware provides Prometheus HTTP metrics for RED dashboards.
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"myapp/internal/tracing"
+)
+
+// sizeBuckets span 100 B to 100 MB.
+var sizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+
+// MetricsOption configures Metrics.
+type MetricsOption func(*metricsOptions)
+
+type metricsOptions struct {
+	skipPaths map[string]struct{}
+	buckets   []float64
+}
+
+// WithMetricsSkipPaths replaces the paths not measured. Defaults to
+// /metrics and the health endpoints.
+func WithMetricsSkipPaths(paths ...string) MetricsOption {
+	return func(o *metricsOptions) {
+		o.skipPaths = make(map[string]struct{}, len(paths))
+		for _, p := range paths {
+			o.skipPaths[p] = struct{}{}
+		}
+	}
+}
+
+// WithDurationBuckets sets the request duration histogram buckets.
+// Defaults to prometheus.DefBuckets.
+func WithDurationBuckets(buckets ...float64) MetricsOption {
+	return func(o *metricsOptions) {
+		o.buckets = buckets
+	}
+}
+
+// Metrics returns middleware recording, per method and chi route pattern
+// ("/users/{userID}", never the raw path):
+//
+//	http_requests_total{method,route,status}
+//	http_request_duration_seconds{method,route}
+//	http_request_size_bytes{method,route}
+//	http_response_size_bytes{method,route}
+//	http_requests_in_flight
+//
+// It registers its collectors with reg, so call it once per registry.
+// Labels come from tracing.MethodLabel and tracing.RouteLabel, and the
+// first two series are the ones tracing.Metrics records: this is the
+// superset backend.go mounts, so don't mount tracing.Metrics as well.
+// Mount it before Recoverer, which then writes a panic's 500 through it.
+func Metrics(reg prometheus.Registerer, opts ...MetricsOption) func(http.Handler) http.Handler {
+	cfg := &metricsOptions{
+		skipPaths: map[string]struct{}{
+			"/metrics":        {},
+			"/health":         {},
+			"/ready":          {},
+			"/check/healthz/": {},
+			"/check/readyz/":  {},
+		},
+		buckets: prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request duration by method and route pattern.",
+		Buckets: cfg.buckets,
+	}, []string{"method", "route"})
+	requestSize := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_size_bytes",
+		Help:    "HTTP request body size by method and route pattern.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "route"})
+	responseSize := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "HTTP response body size by method and route pattern.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "route"})
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+
+	reg.MustRegister(requests, duration, requestSize, responseSize, inFlight)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, skip := cfg.skipPaths[r.URL.Path]; skip {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+			// Keeps Flusher and Hijacker, unlike a plain wrapper.
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // nothing written
+			}
+
+			// The pattern is only known once chi has routed the request
+			method, route := tracing.MethodLabel(r.Method), tracing.RouteLabel(r)
+			requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+			duration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			requestSize.WithLabelValues(method, route).Observe(float64(max(r.ContentLength, body.n)))
+			responseSize.WithLabelValues(method, route).Observe(float64(ww.BytesWritten()))
+		})
+	}
+}
+
+// countingReader counts body bytes read, for chunked requests without a
+// Content-Length.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+func metricsRouter(reg prometheus.Registerer, opts ...MetricsOption) http.Handler {
+	router := chi.NewRouter()
+	router.Use(Metrics(reg, opts...))
+	router.Use(middleware.Recoverer)
+
+	router.Get("/users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "userID") == "missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("hello"))
+	})
+	router.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	router.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	return router
+}
+
+func serveMetrics(h http.Handler, method, path, body string) {
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, strings.NewReader(body)))
+}
+
+func gaugeValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+// ---------- Metrics Tests ----------
+
+func TestMetrics_Labels(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	h := metricsRouter(reg)
+
+	serveMetrics(h, http.MethodGet, "/users/3f0c9a", "")
+	serveMetrics(h, http.MethodGet, "/users/7b21e4", "")
+	serveMetrics(h, http.MethodGet, "/users/missing", "")
+	serveMetrics(h, http.MethodPost, "/users", `{"name":"Ann"}`)
+	serveMetrics(h, http.MethodGet, "/wp-admin/setup.php", "")
+	serveMetrics(h, "PROPFIND", "/users/1", "")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/users/{userID}",status="200"} 2
+http_requests_total{method="GET",route="/users/{userID}",status="404"} 1
+http_requests_total{method="GET",route="unmatched",status="404"} 1
+http_requests_total{method="OTHER",route="unmatched",status="405"} 1
+http_requests_total{method="POST",route="/users",status="201"} 1
+`), "http_requests_total"))
+
+	series, err := testutil.GatherAndCount(reg, "http_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 4, series, "one histogram per method and route")
+}
+
+func TestMetrics_Sizes(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	h := metricsRouter(reg)
+
+	serveMetrics(h, http.MethodGet, "/users/1", "")
+	serveMetrics(h, http.MethodPost, "/users", `{"name":"Ann"}`)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	sums := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if hist := m.GetHistogram(); hist != nil {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "route" {
+						sums[mf.GetName()+" "+l.GetValue()] = hist.GetSampleSum()
+					}
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, float64(len("hello")), sums["http_response_size_bytes /users/{userID}"])
+	assert.Equal(t, float64(len(`{"name":"Ann"}`)), sums["http_request_size_bytes /users"])
+	assert.Zero(t, sums["http_request_size_bytes /users/{userID}"])
+}
+
+func TestMetrics_InFlight(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	var during float64
+
+	router := chi.NewRouter()
+	router.Use(Metrics(reg))
+	router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		during = gaugeValue(t, reg, "http_requests_in_flight")
+	})
+
+	serveMetrics(router, http.MethodGet, "/slow", "")
+
+	assert.Equal(t, 1.0, during)
+	assert.Zero(t, gaugeValue(t, reg, "http_requests_in_flight"))
+}
+
+func TestMetrics_PanicCountsAs500(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	serveMetrics(metricsRouter(reg), http.MethodGet, "/panic", "")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/panic",status="500"} 1
+`), "http_requests_total"))
+}
+
+func TestMetrics_SkipPaths(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		reg := prometheus.NewRegistry()
+		h := metricsRouter(reg)
+
+		serveMetrics(h, http.MethodGet, "/metrics", "")
+		serveMetrics(h, http.MethodGet, "/health", "")
+
+		count, err := testutil.GatherAndCount(reg, "http_requests_total")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Parallel()
+
+		reg := prometheus.NewRegistry()
+		h := metricsRouter(reg, WithMetricsSkipPaths("/users/1"))
+
+		serveMetrics(h, http.MethodGet, "/users/1", "")
+		serveMetrics(h, http.MethodGet, "/health", "")
+
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/health",status="200"} 1
+`), "http_requests_total"))
+	})
+}
+
+func TestMetrics_KeepsFlusher(t *testing.T) {
+	t.Parallel()
+
+	router := chi.NewRouter()
+	router.Use(Metrics(prometheus.NewRegistry()))
+	router.Get("/stream", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("chunk"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.True(t, rec.Flushed)
+}
@@ -0,0 +1,281 @@
+// Package middleware provides per-client rate limiting with pluggable stores.
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"myapp/internal/cache"
+)
+
+// Limit allows Requests per Window for each client.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+	// Burst is how many requests may arrive at once after a quiet period.
+	// Defaults to Requests. Stores may ignore it (see CacheRateLimitStore).
+	Burst int
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// RateLimitResult is a store's decision for one request.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // set when not allowed
+}
+
+// RateLimitStore counts requests per key. Implementations must be safe
+// for concurrent use.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
+
+// RateLimitConfig configures RateLimit.
+type RateLimitConfig struct {
+	Limit Limit
+	// KeyFunc identifies the client. Defaults to ClientIP. Requests with
+	// an empty key are not limited.
+	KeyFunc func(*http.Request) string
+	// Store defaults to a new MemoryRateLimitStore, which limits per pod.
+	// Use CacheRateLimitStore to share limits across pods.
+	Store RateLimitStore
+	// Logger logs store errors. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// RateLimit rejects clients over the limit with 429, a Retry-After header
+// and the standard JSON error body. Every limited response carries
+// X-RateLimit-Limit and X-RateLimit-Remaining. Store errors fail open:
+// an unavailable Redis shouldn't take the API down with it.
+func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
+	if cfg.Limit.Requests <= 0 || cfg.Limit.Window <= 0 {
+		panic("ratelimit: Limit.Requests and Limit.Window must be positive")
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = ClientIP
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore()
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	limitHeader := strconv.Itoa(cfg.Limit.Requests)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := cfg.KeyFunc(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := cfg.Store.Allow(r.Context(), key, cfg.Limit)
+			if err != nil {
+				cfg.Logger.ErrorContext(r.Context(), "rate limit store failed",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.Any("error", err),
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", limitHeader)
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(res.Remaining, 0)))
+
+			if !res.Allowed {
+				// Whole seconds, rounded up: retrying early is rejected again.
+				retry := int(math.Ceil(res.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(retry, 1)))
+				writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded", "rate_limited")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP keys by the client IP from r.RemoteAddr. Behind a proxy, run
+// chi's middleware.RealIP first so RemoteAddr is the client, not the proxy.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// UserOrIP keys by the authenticated user when Auth ran before, so users
+// behind one NAT don't share a limit, and by ClientIP otherwise.
+func UserOrIP(r *http.Request) string {
+	if user, ok := UserFromContext(r.Context()); ok {
+		return "user:" + user.ID
+	}
+	return ClientIP(r)
+}
+
+// errorResponse matches handler.ErrorResponse.
+type errorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{
+		Error:     msg,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// ---------- In-Memory Store ----------
+
+// MemoryRateLimitStore is a token bucket per key, held in process memory.
+// Each pod limits on its own, so N pods allow up to N times the limit.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // when the bucket refills completely
+}
+
+// NewMemoryRateLimitStore creates an in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow implements RateLimitStore. Buckets hold up to Burst tokens and
+// refill at Requests per Window.
+func (s *MemoryRateLimitStore) Allow(_ context.Context, key string, limit Limit) (RateLimitResult, error) {
+	capacity := float64(limit.burst())
+	perToken := limit.Window / time.Duration(limit.Requests)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now, limit.Window)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = min(capacity, b.tokens+float64(now.Sub(b.last))/float64(perToken))
+	b.last = now
+
+	res := RateLimitResult{Allowed: b.tokens >= 1}
+	if res.Allowed {
+		b.tokens--
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	res.Remaining = int(b.tokens)
+	b.full = now.Add(time.Duration((capacity - b.tokens) * float64(perToken)))
+
+	return res, nil
+}
+
+// sweep drops full buckets once per window; a missing bucket is a full one.
+func (s *MemoryRateLimitStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// ---------- Cache Store ----------
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// CacheRateLimitStore counts requests in the cache, so all pods share one
+// limit. It uses a sliding window: the previous window's count, weighted
+// by how much of it still overlaps, plus the current window's count.
+// Rejected requests count too, and Burst is ignored.
+type CacheRateLimitStore struct {
+	client cache.Client
+	now    func() time.Time
+}
+
+// NewCacheRateLimitStore creates a store on the cache client.
+func NewCacheRateLimitStore(client cache.Client) *CacheRateLimitStore {
+	return &CacheRateLimitStore{client: client, now: time.Now}
+}
+
+// Allow implements RateLimitStore.
+func (s *CacheRateLimitStore) Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error) {
+	now := s.now()
+	window := int64(limit.Window)
+	index := now.UnixNano() / window
+	elapsed := float64(now.UnixNano()-index*window) / float64(window)
+
+	var previous int64
+	res, err := s.client.ExecBatch(ctx, "rate_limit",
+		cache.IncrWithTTL(fmt.Sprintf("%s%s:%d", rateLimitKeyPrefix, key, index), 2*limit.Window),
+		cache.GetObj(fmt.Sprintf("%s%s:%d", rateLimitKeyPrefix, key, index-1), &previous),
+	)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	for _, r := range res {
+		if err := r.Err(); err != nil {
+			return RateLimitResult{}, err
+		}
+	}
+	current := res[0].Val().(int64)
+
+	prev, cur, allowed := float64(previous), float64(current), float64(limit.Requests)
+	estimate := prev*(1-elapsed) + cur
+	if estimate <= allowed {
+		return RateLimitResult{Allowed: true, Remaining: int(allowed - math.Ceil(estimate))}, nil
+	}
+
+	// Wait until a retry, which counts too, fits under the limit: within
+	// this window while the previous one fades out, else in the next one.
+	var retry float64 // in windows from the start of this one
+	if cur < allowed && prev > 0 {
+		retry = 1 - (allowed-cur-1)/prev
+	} else {
+		retry = 2 - (allowed-1)/cur
+	}
+	return RateLimitResult{
+		RetryAfter: time.Duration((retry - elapsed) * float64(window)),
+	}, nil
+}
@@ -0,0 +1,374 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/cache"
+)
+
+// ---------- Test Helpers ----------
+
+// testClock is a settable clock for stores.
+type testClock struct{ now time.Time }
+
+func newTestClock(t time.Time) *testClock    { return &testClock{now: t} }
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newMemoryStore(clock *testClock) *MemoryRateLimitStore {
+	s := NewMemoryRateLimitStore()
+	s.now = clock.Now
+	return s
+}
+
+func allow(t *testing.T, s RateLimitStore, key string, limit Limit) RateLimitResult {
+	t.Helper()
+	res, err := s.Allow(context.Background(), key, limit)
+	require.NoError(t, err)
+	return res
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, Limit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("cache unavailable")
+}
+
+// serveLimited runs req through handler and reports whether the next handler was called.
+func serveLimited(handler func(http.Handler) http.Handler, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	called := false
+	h := handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, called
+}
+
+func requestFrom(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+// ---------- MemoryRateLimitStore Tests ----------
+
+func TestMemoryRateLimitStore_Refill(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock(time.Unix(1000, 0))
+	s := newMemoryStore(clock)
+	limit := Limit{Requests: 10, Window: time.Second}
+
+	for i := range 10 {
+		res := allow(t, s, "k", limit)
+		require.True(t, res.Allowed, "request %d", i+1)
+		assert.Equal(t, 9-i, res.Remaining)
+	}
+
+	res := allow(t, s, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 100*time.Millisecond, res.RetryAfter, "one token per 100ms")
+
+	clock.Advance(50 * time.Millisecond)
+	res = allow(t, s, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 50*time.Millisecond, res.RetryAfter)
+
+	clock.Advance(50 * time.Millisecond)
+	assert.True(t, allow(t, s, "k", limit).Allowed, "refilled one token")
+	assert.False(t, allow(t, s, "k", limit).Allowed)
+
+	clock.Advance(time.Hour)
+	for range 10 {
+		require.True(t, allow(t, s, "k", limit).Allowed)
+	}
+	assert.False(t, allow(t, s, "k", limit).Allowed, "refill capped at burst")
+}
+
+func TestMemoryRateLimitStore_Burst(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock(time.Unix(1000, 0))
+	s := newMemoryStore(clock)
+	limit := Limit{Requests: 60, Window: time.Minute, Burst: 5}
+
+	for range 5 {
+		require.True(t, allow(t, s, "k", limit).Allowed)
+	}
+	res := allow(t, s, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+}
+
+func TestMemoryRateLimitStore_KeysIndependent(t *testing.T) {
+	t.Parallel()
+
+	s := newMemoryStore(newTestClock(time.Unix(1000, 0)))
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	assert.True(t, allow(t, s, "a", limit).Allowed)
+	assert.False(t, allow(t, s, "a", limit).Allowed)
+	assert.True(t, allow(t, s, "b", limit).Allowed)
+}
+
+func TestMemoryRateLimitStore_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	s := newMemoryStore(newTestClock(time.Unix(1000, 0)))
+	limit := Limit{Requests: 100, Window: time.Hour}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				res, err := s.Allow(context.Background(), "k", limit)
+				if assert.NoError(t, err) && res.Allowed {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(100), allowed.Load(), "exactly the limit across goroutines")
+}
+
+func TestMemoryRateLimitStore_Sweep(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock(time.Unix(1000, 0))
+	s := newMemoryStore(clock)
+	limit := Limit{Requests: 10, Window: time.Second}
+
+	allow(t, s, "idle", limit)
+	clock.Advance(1500 * time.Millisecond)
+	for range 10 {
+		allow(t, s, "busy", limit)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	allow(t, s, "other", limit)
+
+	assert.NotContains(t, s.buckets, "idle", "full bucket dropped")
+	assert.Contains(t, s.buckets, "busy", "still refilling at the sweep")
+}
+
+// ---------- CacheRateLimitStore Tests ----------
+
+func newCacheStore(t *testing.T, clock *testClock) (*CacheRateLimitStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client, err := cache.NewRedisClient(context.Background(), &cache.RedisConfig{Server: mr.Addr()})
+	require.NoError(t, err)
+
+	s := NewCacheRateLimitStore(client)
+	s.now = clock.Now
+	return s, mr
+}
+
+func TestCacheRateLimitStore_SlidingWindow(t *testing.T) {
+	t.Parallel()
+
+	// Window boundary: 1000s is a multiple of 10s.
+	clock := newTestClock(time.Unix(1000, 0))
+	s, mr := newCacheStore(t, clock)
+	limit := Limit{Requests: 4, Window: 10 * time.Second}
+
+	for i := range 4 {
+		res := allow(t, s, "k", limit)
+		require.True(t, res.Allowed, "request %d", i+1)
+		assert.Equal(t, 3-i, res.Remaining)
+	}
+	assert.Equal(t, 20*time.Second, mr.TTL("ratelimit:k:100"), "kept for the next window")
+
+	// Next window: the previous 4 still weigh fully.
+	clock.Advance(10 * time.Second)
+	res := allow(t, s, "k", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 5*time.Second, res.RetryAfter, "half the previous window has faded by then")
+
+	clock.Advance(5 * time.Second)
+	res = allow(t, s, "k", limit)
+	assert.True(t, res.Allowed, "4*0.5 + 2 requests")
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestCacheRateLimitStore_RetryIntoNextWindow(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock(time.Unix(1000, 0))
+	s, _ := newCacheStore(t, clock)
+	limit := Limit{Requests: 4, Window: 10 * time.Second}
+
+	for range 4 {
+		require.True(t, allow(t, s, "k", limit).Allowed)
+	}
+	clock.Advance(2 * time.Second)
+	res := allow(t, s, "k", limit)
+	require.False(t, res.Allowed)
+
+	// 5 requests in this window: a retry fits once 5*(1-g)+1 <= 4.
+	assert.Equal(t, 12*time.Second, res.RetryAfter)
+
+	clock.Advance(res.RetryAfter)
+	assert.True(t, allow(t, s, "k", limit).Allowed)
+}
+
+func TestCacheRateLimitStore_KeysIndependent(t *testing.T) {
+	t.Parallel()
+
+	s, _ := newCacheStore(t, newTestClock(time.Unix(1000, 0)))
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	assert.True(t, allow(t, s, "a", limit).Allowed)
+	assert.False(t, allow(t, s, "a", limit).Allowed)
+	assert.True(t, allow(t, s, "b", limit).Allowed)
+}
+
+func TestCacheRateLimitStore_Error(t *testing.T) {
+	t.Parallel()
+
+	s, mr := newCacheStore(t, newTestClock(time.Unix(1000, 0)))
+	mr.Close()
+
+	_, err := s.Allow(context.Background(), "k", Limit{Requests: 1, Window: time.Minute})
+	assert.Error(t, err)
+}
+
+// ---------- Middleware Tests ----------
+
+func TestRateLimit_RejectsOverLimit(t *testing.T) {
+	t.Parallel()
+
+	clock := newTestClock(time.Unix(1000, 0))
+	mw := RateLimit(RateLimitConfig{
+		Limit: Limit{Requests: 2, Window: time.Minute},
+		Store: newMemoryStore(clock),
+	})
+
+	for i := range 2 {
+		rec, called := serveLimited(mw, requestFrom("203.0.113.1:1234"))
+		require.True(t, called, "request %d", i+1)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, []string{"1", "0"}[i], rec.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	rec, called := serveLimited(mw, requestFrom("203.0.113.1:5678"))
+	assert.False(t, called, "same IP, other port")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body errorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "rate limit exceeded", body.Error)
+	assert.Equal(t, "rate_limited", body.Code)
+
+	_, called = serveLimited(mw, requestFrom("203.0.113.2:1234"))
+	assert.True(t, called, "other clients unaffected")
+}
+
+func TestRateLimit_RetryAfterRoundsUp(t *testing.T) {
+	t.Parallel()
+
+	mw := RateLimit(RateLimitConfig{
+		Limit: Limit{Requests: 10, Window: time.Second},
+		Store: newMemoryStore(newTestClock(time.Unix(1000, 0))),
+	})
+
+	for range 10 {
+		serveLimited(mw, requestFrom("203.0.113.1:1234"))
+	}
+	rec, _ := serveLimited(mw, requestFrom("203.0.113.1:1234"))
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"), "100ms rounds up to 1s")
+}
+
+func TestRateLimit_UserOrIP(t *testing.T) {
+	t.Parallel()
+
+	mw := RateLimit(RateLimitConfig{
+		Limit:   Limit{Requests: 1, Window: time.Minute},
+		KeyFunc: UserOrIP,
+		Store:   newMemoryStore(newTestClock(time.Unix(1000, 0))),
+	})
+	asUser := func(id string) *http.Request {
+		req := requestFrom("203.0.113.1:1234")
+		return req.WithContext(context.WithValue(req.Context(), UserCtxKey, &User{ID: id}))
+	}
+
+	_, called := serveLimited(mw, asUser("alice"))
+	assert.True(t, called)
+	_, called = serveLimited(mw, asUser("bob"))
+	assert.True(t, called, "users behind one IP limited separately")
+	_, called = serveLimited(mw, asUser("alice"))
+	assert.False(t, called)
+
+	_, called = serveLimited(mw, requestFrom("203.0.113.1:1234"))
+	assert.True(t, called, "anonymous requests keyed by IP")
+}
+
+func TestRateLimit_EmptyKeyNotLimited(t *testing.T) {
+	t.Parallel()
+
+	mw := RateLimit(RateLimitConfig{
+		Limit:   Limit{Requests: 1, Window: time.Minute},
+		KeyFunc: func(*http.Request) string { return "" },
+	})
+
+	for range 3 {
+		rec, called := serveLimited(mw, requestFrom("203.0.113.1:1234"))
+		assert.True(t, called)
+		assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestRateLimit_StoreErrorFailsOpen(t *testing.T) {
+	t.Parallel()
+
+	mw := RateLimit(RateLimitConfig{
+		Limit:  Limit{Requests: 1, Window: time.Minute},
+		Store:  failingStore{},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	rec, called := serveLimited(mw, requestFrom("203.0.113.1:1234"))
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRateLimit_InvalidLimit(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { RateLimit(RateLimitConfig{Limit: Limit{Window: time.Minute}}) })
+	assert.Panics(t, func() { RateLimit(RateLimitConfig{Limit: Limit{Requests: 1}}) })
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "ip:203.0.113.1", ClientIP(requestFrom("203.0.113.1:1234")))
+	assert.Equal(t, "ip:2001:db8::1", ClientIP(requestFrom("[2001:db8::1]:1234")))
+	assert.Equal(t, "ip:203.0.113.1", ClientIP(requestFrom("203.0.113.1")), "RealIP strips the port")
+}
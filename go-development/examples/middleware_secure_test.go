@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------- Test Helpers ----------
+
+func serveSecure(h http.Handler, path string, overTLS bool) http.Header {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if overTLS {
+		req.TLS = &tls.ConnectionState{}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Header()
+}
+
+func okHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// ---------- Secure Headers Tests ----------
+
+func TestSecureHeaders(t *testing.T) {
+	t.Parallel()
+
+	withCSP := func(policy string, reportOnly bool) SecureHeadersConfig {
+		cfg := DefaultSecureHeaders()
+		cfg.ContentSecurityPolicy = policy
+		cfg.CSPReportOnly = reportOnly
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		cfg     SecureHeadersConfig
+		overTLS bool
+		want    map[string]string // "" means absent
+	}{
+		{
+			name:    "defaults over TLS",
+			cfg:     DefaultSecureHeaders(),
+			overTLS: true,
+			want: map[string]string{
+				"Strict-Transport-Security":           "max-age=31536000; includeSubDomains",
+				"X-Content-Type-Options":              "nosniff",
+				"X-Frame-Options":                     "DENY",
+				"Referrer-Policy":                     "strict-origin-when-cross-origin",
+				"Content-Security-Policy":             "default-src 'none'; frame-ancestors 'none'",
+				"Content-Security-Policy-Report-Only": "",
+			},
+		},
+		{
+			name: "defaults over plain HTTP omit HSTS",
+			cfg:  DefaultSecureHeaders(),
+			want: map[string]string{
+				"Strict-Transport-Security": "",
+				"X-Content-Type-Options":    "nosniff",
+			},
+		},
+		{
+			name: "forced HSTS with preload",
+			cfg: func() SecureHeadersConfig {
+				cfg := DefaultSecureHeaders()
+				cfg.ForceHSTS = true
+				cfg.HSTSPreload = true
+				cfg.HSTSMaxAge = 2 * 365 * 24 * time.Hour
+				return cfg
+			}(),
+			want: map[string]string{
+				"Strict-Transport-Security": "max-age=63072000; includeSubDomains; preload",
+			},
+		},
+		{
+			name: "report-only CSP",
+			cfg:  withCSP("default-src 'self'", true),
+			want: map[string]string{
+				"Content-Security-Policy":             "",
+				"Content-Security-Policy-Report-Only": "default-src 'self'; frame-ancestors 'none'",
+			},
+		},
+		{
+			name: "explicit frame-ancestors kept",
+			cfg:  withCSP("default-src 'self'; frame-ancestors https://app.example.com", false),
+			want: map[string]string{
+				"Content-Security-Policy": "default-src 'self'; frame-ancestors https://app.example.com",
+				"X-Frame-Options":         "DENY",
+			},
+		},
+		{
+			name: "same origin framing",
+			cfg: func() SecureHeadersConfig {
+				cfg := DefaultSecureHeaders()
+				cfg.FrameOptions = "SAMEORIGIN"
+				return cfg
+			}(),
+			want: map[string]string{
+				"X-Frame-Options":         "SAMEORIGIN",
+				"Content-Security-Policy": "default-src 'none'; frame-ancestors 'self'",
+			},
+		},
+		{
+			name:    "zero config sends nothing",
+			cfg:     SecureHeadersConfig{ForceHSTS: true},
+			overTLS: true,
+			want: map[string]string{
+				"Strict-Transport-Security": "",
+				"X-Content-Type-Options":    "",
+				"X-Frame-Options":           "",
+				"Referrer-Policy":           "",
+				"Content-Security-Policy":   "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := SecureHeaders(tt.cfg)(http.HandlerFunc(okHandler))
+			got := serveSecure(h, "/", tt.overTLS)
+
+			for name, want := range tt.want {
+				assert.Equal(t, want, got.Get(name), name)
+			}
+		})
+	}
+}
+
+func TestOverrideCSP(t *testing.T) {
+	t.Parallel()
+
+	const docsPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'"
+
+	newRouter := func(cfg SecureHeadersConfig) http.Handler {
+		router := chi.NewRouter()
+		router.Use(SecureHeaders(cfg))
+		router.Get("/users", okHandler)
+		router.With(OverrideCSP(docsPolicy)).Get("/docs", okHandler)
+		router.With(OverrideCSP("")).Get("/raw", okHandler)
+		return router
+	}
+
+	t.Run("enforced", func(t *testing.T) {
+		t.Parallel()
+
+		router := newRouter(DefaultSecureHeaders())
+
+		assert.Equal(t, "default-src 'none'; frame-ancestors 'none'",
+			serveSecure(router, "/users", false).Get("Content-Security-Policy"))
+
+		docs := serveSecure(router, "/docs", false)
+		assert.Equal(t, docsPolicy, docs.Get("Content-Security-Policy"))
+		assert.Equal(t, "nosniff", docs.Get("X-Content-Type-Options"), "other headers untouched")
+
+		assert.Empty(t, serveSecure(router, "/raw", false).Get("Content-Security-Policy"))
+	})
+
+	t.Run("report-only", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := DefaultSecureHeaders()
+		cfg.CSPReportOnly = true
+		docs := serveSecure(newRouter(cfg), "/docs", false)
+
+		assert.Equal(t, docsPolicy, docs.Get("Content-Security-Policy-Report-Only"))
+		assert.Empty(t, docs.Get("Content-Security-Policy"), "stays report-only")
+	})
+}
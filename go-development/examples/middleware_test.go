@@ -0,0 +1,400 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+// plainWriter implements http.ResponseWriter and nothing else.
+type plainWriter struct {
+	header http.Header
+}
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *plainWriter) WriteHeader(int)             {}
+
+// http1Writer has everything net/http's HTTP/1 writer has.
+type http1Writer struct {
+	*httptest.ResponseRecorder
+}
+
+func (w http1Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func (w http1Writer) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+// http2Writer has everything net/http's HTTP/2 writer has.
+type http2Writer struct {
+	*httptest.ResponseRecorder
+}
+
+func (w http2Writer) Push(string, *http.PushOptions) error { return nil }
+
+func newTestLogger() (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return slog.New(slog.NewJSONHandler(&buf, nil)), &buf
+}
+
+func decodeLogLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+// ---------- RequestLogger Tests ----------
+
+func TestRequestLogger_KeepsOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		w          http.ResponseWriter
+		protoMajor int
+		flusher    bool
+		hijacker   bool
+		readerFrom bool
+		pusher     bool
+	}{
+		{name: "plain", w: &plainWriter{header: http.Header{}}, protoMajor: 1},
+		{name: "recorder", w: httptest.NewRecorder(), protoMajor: 1, flusher: true},
+		{
+			name: "http1", w: http1Writer{httptest.NewRecorder()}, protoMajor: 1,
+			flusher: true, hijacker: true, readerFrom: true,
+		},
+		{
+			name: "http2", w: http2Writer{httptest.NewRecorder()}, protoMajor: 2,
+			flusher: true, pusher: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, _ := newTestLogger()
+			var got http.ResponseWriter
+			h := RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				got = w
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.ProtoMajor = tt.protoMajor
+			h.ServeHTTP(tt.w, req)
+
+			_, flusher := got.(http.Flusher)
+			_, hijacker := got.(http.Hijacker)
+			_, readerFrom := got.(io.ReaderFrom)
+			_, pusher := got.(http.Pusher)
+			assert.Equal(t, tt.flusher, flusher, "http.Flusher")
+			assert.Equal(t, tt.hijacker, hijacker, "http.Hijacker")
+			assert.Equal(t, tt.readerFrom, readerFrom, "io.ReaderFrom")
+			assert.Equal(t, tt.pusher, pusher, "http.Pusher")
+		})
+	}
+}
+
+func TestRequestLogger_LogsStatusAndBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus float64
+		wantBytes  float64
+	}{
+		{
+			name: "body",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":"1"}`))
+			},
+			wantStatus: http.StatusCreated,
+			wantBytes:  10,
+		},
+		{
+			name: "ReadFrom",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				io.Copy(w, strings.NewReader("hello"))
+			},
+			wantStatus: http.StatusOK,
+			wantBytes:  5,
+		},
+		{
+			name:       "nothing written",
+			handler:    func(http.ResponseWriter, *http.Request) {},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, buf := newTestLogger()
+			h := RequestLogger(logger)(tt.handler)
+			h.ServeHTTP(http1Writer{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			line := decodeLogLine(t, buf)
+			assert.Equal(t, tt.wantStatus, line["status"])
+			assert.Equal(t, tt.wantBytes, line["bytes"])
+			assert.Equal(t, "/users", line["path"])
+		})
+	}
+}
+
+func TestRequestLogger_ServerSentEvents(t *testing.T) {
+	t.Parallel()
+
+	logger, _ := newTestLogger()
+	received := make(chan struct{})
+
+	srv := httptest.NewServer(RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 3 {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("flush: %v", err)
+				return
+			}
+			if i == 0 {
+				// Only reachable if the first event got through unbuffered
+				select {
+				case <-received:
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}
+	})))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "data: 0\n", line)
+	close(received)
+
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "\ndata: 1\n\ndata: 2\n\n", string(rest))
+}
+
+func TestRequestLogger_Hijack(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	logged := make(chan struct{})
+
+	h := RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		close(logged)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	<-logged // Server.Close doesn't wait for hijacked connections
+	assert.Contains(t, buf.String(), `"msg":"request"`)
+}
+
+// ---------- RequestLogger Option Tests ----------
+
+func withRandom(f func() float64) RequestLoggerOption {
+	return func(o *requestLoggerOptions) {
+		o.random = f
+	}
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line map[string]any
+		require.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/missing":
+		w.WriteHeader(http.StatusNotFound)
+	case "/broken":
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func serveLogged(h http.Handler, path string) {
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+func TestRequestLogger_IgnorePaths(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	h := RequestLogger(logger, WithIgnorePaths("/metrics", "/health"))(http.HandlerFunc(statusHandler))
+
+	serveLogged(h, "/metrics")
+	serveLogged(h, "/health")
+	serveLogged(h, "/health/deep")
+	serveLogged(h, "/users")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 2, "exact paths only")
+	assert.Equal(t, "/health/deep", lines[0]["path"])
+	assert.Equal(t, "/users", lines[1]["path"])
+}
+
+func TestRequestLogger_SamplingIsDeterministicWhenSeeded(t *testing.T) {
+	t.Parallel()
+
+	sampled := func(seed uint64) []string {
+		logger, buf := newTestLogger()
+		random := rand.New(rand.NewPCG(seed, seed))
+		h := RequestLogger(logger, WithSampling(0.1), withRandom(random.Float64))(http.HandlerFunc(statusHandler))
+
+		for i := range 1000 {
+			serveLogged(h, fmt.Sprintf("/users/%d", i))
+		}
+
+		var paths []string
+		for _, line := range logLines(t, buf) {
+			assert.Equal(t, 0.1, line["sample_rate"])
+			paths = append(paths, line["path"].(string))
+		}
+		return paths
+	}
+
+	first := sampled(42)
+	assert.Equal(t, first, sampled(42), "same seed, same lines")
+	assert.NotEqual(t, first, sampled(7))
+	assert.InDelta(t, 100, len(first), 40)
+}
+
+func TestRequestLogger_SamplingKeepsErrors(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	h := RequestLogger(logger, WithSampling(0))(http.HandlerFunc(statusHandler))
+
+	for range 10 {
+		serveLogged(h, "/users")
+		serveLogged(h, "/missing")
+		serveLogged(h, "/broken")
+	}
+
+	statuses := map[float64]int{}
+	for _, line := range logLines(t, buf) {
+		statuses[line["status"].(float64)]++
+		assert.NotContains(t, line, "sample_rate", "unsampled lines")
+	}
+	assert.Equal(t, map[float64]int{404: 10, 500: 10}, statuses)
+}
+
+func TestRequestLogger_SlowThreshold(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	h := RequestLogger(logger, WithSampling(0), WithSlowThreshold(20*time.Millisecond))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(25 * time.Millisecond)
+			}
+		}))
+
+	serveLogged(h, "/fast")
+	serveLogged(h, "/slow")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "/slow", lines[0]["path"])
+	assert.Equal(t, true, lines[0]["slow"])
+}
+
+func TestRequestLogger_HeaderLogging(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	h := RequestLogger(logger, WithHeaderLogging("user-agent", "X-Request-Id", "Authorization", "cookie", "X-Missing"))(
+		http.HandlerFunc(statusHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("User-Agent", "mobile/1.2")
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "session=secret-session")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NotContains(t, buf.String(), "secret")
+	line := decodeLogLine(t, buf)
+	assert.Equal(t, map[string]any{"User-Agent": "mobile/1.2", "X-Request-Id": "req-1"}, line["headers"])
+}
+
+func TestRequestLogger_DefaultsLogEverything(t *testing.T) {
+	t.Parallel()
+
+	logger, buf := newTestLogger()
+	h := RequestLogger(logger)(http.HandlerFunc(statusHandler))
+
+	serveLogged(h, "/metrics")
+	serveLogged(h, "/users")
+	serveLogged(h, "/broken")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.NotContains(t, line, "sample_rate")
+		assert.NotContains(t, line, "headers")
+	}
+}
@@ -0,0 +1,199 @@
+// Package middleware provides request timeouts with a JSON 504 response.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"myapp/internal/tracing"
+)
+
+// TimeoutOption configures TimeoutJSON.
+type TimeoutOption func(*timeoutOptions)
+
+type timeoutOptions struct {
+	counter *prometheus.CounterVec
+}
+
+// WithTimeoutCounter counts timeouts by method and route pattern, e.g. on
+// the counter from NewTimeoutCounter. A timeout is counted once the handler
+// returns, when chi has matched the full pattern.
+func WithTimeoutCounter(counter *prometheus.CounterVec) TimeoutOption {
+	return func(o *timeoutOptions) {
+		o.counter = counter
+	}
+}
+
+// NewTimeoutCounter registers http_request_timeouts_total{method,route}
+// with reg. Create it once and pass it to every TimeoutJSON.
+func NewTimeoutCounter(reg prometheus.Registerer) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_request_timeouts_total",
+		Help: "HTTP requests answered 504 by TimeoutJSON, by method and route pattern.",
+	}, []string{"method", "route"})
+	reg.MustRegister(counter)
+	return counter
+}
+
+// TimeoutJSON runs the handler with a context cancelled after d. If the
+// handler hasn't returned by then, the client gets a 504 with code
+// "timeout" and the handler's later writes fail with http.ErrHandlerTimeout
+// instead of reaching the client.
+//
+// The response is buffered until the handler returns, like
+// http.TimeoutHandler, so don't use it on streaming routes (SSE,
+// downloads). Panics are re-raised for the Recoverer above it.
+func TimeoutJSON(d time.Duration, opts ...TimeoutOption) func(http.Handler) http.Handler {
+	cfg := &timeoutOptions{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			// Under Use, chi routes the rest of the way in the handler's
+			// goroutine, which can outlive this call after a timeout; the
+			// router recycles its route context once it returns.
+			rctx := chi.RouteContext(ctx)
+			var routed *chi.Context
+			if rctx != nil {
+				routed = copyRouteContext(rctx)
+				ctx = context.WithValue(ctx, chi.RouteCtxKey, routed)
+			}
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{ctx: ctx, header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			finished := false
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				finished = true
+			case <-ctx.Done():
+			}
+
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			if finished && !tw.timedOut {
+				if rctx != nil {
+					// Let Metrics and the tracer above see the full pattern
+					rctx.URLParams = routed.URLParams
+					rctx.RoutePatterns = routed.RoutePatterns
+				}
+				dst := w.Header()
+				clear(dst)
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK // nothing written
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+				return
+			}
+
+			tw.timedOut = true
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return // client gone, nobody to answer
+			}
+			writeError(w, r, http.StatusGatewayTimeout, "request timed out", "timeout")
+			if cfg.counter != nil {
+				// The pattern is only known once the handler has returned
+				go func() {
+					select {
+					case <-done:
+					case <-panicked:
+					}
+					cfg.counter.WithLabelValues(tracing.MethodLabel(r.Method), tracing.RouteLabel(r)).Inc()
+				}()
+			}
+		})
+	}
+}
+
+// copyRouteContext copies what chi has matched so far into a fresh route
+// context the handler can keep routing on.
+func copyRouteContext(rctx *chi.Context) *chi.Context {
+	c := chi.NewRouteContext()
+	c.Routes = rctx.Routes
+	c.RoutePath = rctx.RoutePath
+	c.RouteMethod = rctx.RouteMethod
+	c.URLParams.Keys = slices.Clone(rctx.URLParams.Keys)
+	c.URLParams.Values = slices.Clone(rctx.URLParams.Values)
+	c.RoutePatterns = slices.Clone(rctx.RoutePatterns)
+	return c
+}
+
+// timeoutWriter buffers the handler's response until TimeoutJSON copies
+// it out, or drops it after a timeout.
+type timeoutWriter struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// WriteHeader keeps the first status and ignores calls after a timeout,
+// so late handlers don't cause "superfluous WriteHeader" logs.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// expired marks the response timed out once the context is done, even if
+// the handler noticed before TimeoutJSON did. Callers hold mu.
+func (tw *timeoutWriter) expired() bool {
+	if tw.ctx.Err() != nil {
+		tw.timedOut = true
+	}
+	return tw.timedOut
+}
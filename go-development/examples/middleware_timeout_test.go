@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------- Test Helpers ----------
+
+// slowHandler blocks until its context ends, then tries to write, like a
+// handler whose DB call returned context.DeadlineExceeded.
+type slowHandler struct {
+	ctxErr   chan error
+	writeErr chan error
+}
+
+func newSlowHandler() *slowHandler {
+	return &slowHandler{ctxErr: make(chan error, 1), writeErr: make(chan error, 1)}
+}
+
+func (h *slowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	<-r.Context().Done()
+	h.ctxErr <- r.Context().Err()
+
+	w.Header().Set("X-Late", "true")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, err := w.Write([]byte("too late"))
+	h.writeErr <- err
+}
+
+// ---------- TimeoutJSON Tests ----------
+
+func TestTimeoutJSON_SlowHandler(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	counter := NewTimeoutCounter(reg)
+	slow := newSlowHandler()
+
+	router := chi.NewRouter()
+	router.With(TimeoutJSON(20*time.Millisecond, WithTimeoutCounter(counter))).Get("/reports/{id}", slow.ServeHTTP)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/1", nil))
+
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	body := decodeError(t, rec.Body)
+	assert.Equal(t, "timeout", body.Code)
+	assert.Equal(t, "request timed out", body.Error)
+	assert.Zero(t, rec.Body.Len(), "a single JSON value")
+
+	assert.ErrorIs(t, <-slow.ctxErr, context.DeadlineExceeded, "the handler sees the cancellation")
+	assert.ErrorIs(t, <-slow.writeErr, http.ErrHandlerTimeout)
+	assert.Empty(t, rec.Header().Get("X-Late"), "late writes never reach the client")
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(counter.WithLabelValues(http.MethodGet, "/reports/{id}")) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestTimeoutJSON_FastHandler(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	counter := NewTimeoutCounter(reg)
+
+	h := SecureHeaders(SecureHeadersConfig{NoSniff: true})(
+		TimeoutJSON(time.Second, WithTimeoutCounter(counter))(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, hasDeadline := r.Context().Deadline()
+				assert.True(t, hasDeadline)
+				assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"), "outer headers visible")
+
+				w.Header().Set("Location", "/orders/1")
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusOK) // ignored
+				w.Write([]byte(`{"id":"1"}`))
+			})))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/orders/1", rec.Header().Get("Location"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `{"id":"1"}`, rec.Body.String())
+
+	count, err := testutil.GatherAndCount(reg, "http_request_timeouts_total")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestTimeoutJSON_NothingWritten(t *testing.T) {
+	t.Parallel()
+
+	h := TimeoutJSON(time.Second)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestTimeoutJSON_ClientGone(t *testing.T) {
+	t.Parallel()
+
+	counter := NewTimeoutCounter(prometheus.NewRegistry())
+	slow := newSlowHandler()
+	h := TimeoutJSON(time.Minute, WithTimeoutCounter(counter))(slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	cancel()
+	h.ServeHTTP(rec, req)
+
+	assert.ErrorIs(t, <-slow.ctxErr, context.Canceled)
+	assert.False(t, rec.Code == http.StatusGatewayTimeout || rec.Body.Len() > 0, "no response for a gone client")
+	assert.Zero(t, testutil.ToFloat64(counter.WithLabelValues(http.MethodGet, "unmatched")))
+}
+
+func TestTimeoutJSON_RouteVisibleToOuterMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var pattern, param string
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			pattern = chi.RouteContext(r.Context()).RoutePattern()
+			param = chi.URLParam(r, "id")
+		})
+	})
+	router.Route("/reports", func(r chi.Router) {
+		r.Use(TimeoutJSON(time.Second))
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(chi.URLParam(r, "id")))
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/7", nil))
+
+	assert.Equal(t, "7", rec.Body.String())
+	assert.Equal(t, "/reports/{id}", pattern)
+	assert.Equal(t, "7", param)
+}
+
+func TestTimeoutJSON_PanicReachesRecoverer(t *testing.T) {
+	t.Parallel()
+
+	router := chi.NewRouter()
+	router.Use(middleware.Recoverer)
+	router.With(TimeoutJSON(time.Second)).Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestTimeoutJSON_PerRouteGroups(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	counter := NewTimeoutCounter(reg)
+	sleep := func(d time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(d):
+				w.Write([]byte("done"))
+			case <-r.Context().Done():
+			}
+		}
+	}
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(TimeoutJSON(20*time.Millisecond, WithTimeoutCounter(counter)))
+		r.Get("/users", sleep(100*time.Millisecond))
+	})
+	router.Route("/exports", func(r chi.Router) {
+		r.Use(TimeoutJSON(time.Second, WithTimeoutCounter(counter)))
+		r.Get("/", sleep(50*time.Millisecond))
+		r.Get("/huge", sleep(5*time.Second))
+	})
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, strings.NewReader("")))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusGatewayTimeout, serve("/users"), "short default")
+	assert.Equal(t, http.StatusOK, serve("/exports/"), "longer budget")
+	assert.Equal(t, http.StatusGatewayTimeout, serve("/exports/huge"))
+
+	// Counted under the pattern chi matched after TimeoutJSON, not the mount
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_request_timeouts_total HTTP requests answered 504 by TimeoutJSON, by method and route pattern.
+# TYPE http_request_timeouts_total counter
+http_request_timeouts_total{method="GET",route="/exports/huge"} 1
+http_request_timeouts_total{method="GET",route="/users"} 1
+`), "http_request_timeouts_total"))
+	}, time.Second, time.Millisecond)
+}
@@ -1,34 +1,104 @@
 // Package pagination provides cursor-based (keyset) pagination utilities.
 //
 // This example shows:
-// - Cursor encoding/decoding
+// - Cursor encoding/decoding with versioning
 // - Generic page response
 // - Multi-column keyset pagination
+// - Previous-page navigation
+// - Query param parsing and Link headers for HTTP handlers
+// - Iterating all pages in batch jobs
+// - Filter+cursor state tokens
 // - Repository integration
 package pagination
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/shopspring/decimal"
+
+	"myapp/internal/errs"
 )
 
 // Note: IDs are string type, not uuid.UUID.
 
 // ---------- Generic Page Types ----------
 
+// Direction is which way a page request walks from its cursor.
+type Direction string
+
+const (
+	Forward  Direction = "next" // Items after the cursor (default)
+	Backward Direction = "prev" // Items before the cursor
+)
+
 // PageRequest is the request for a paginated list.
 type PageRequest struct {
-	Cursor string // Base64 encoded cursor
-	Limit  int    // Max items per page
+	Cursor    string    // Base64 encoded cursor
+	Limit     int       // Max items per page
+	Direction Direction // Forward when empty
+}
+
+// Validate rejects a negative limit or unknown direction, for requests not
+// built by FromRequest (e.g. from gRPC or internal callers). A zero limit is
+// valid and means the default.
+func (r PageRequest) Validate() error {
+	if r.Limit < 0 {
+		return &RequestError{Param: "limit", Value: strconv.Itoa(r.Limit), Reason: "must not be negative"}
+	}
+	switch r.Direction {
+	case "", Forward, Backward:
+	default:
+		return &RequestError{Param: "direction", Value: string(r.Direction), Reason: `want "next" or "prev"`}
+	}
+	return nil
 }
 
 // PageResponse is a generic paginated response.
 type PageResponse[T any] struct {
-	Items      []T    `json:"items"`
-	NextCursor string `json:"next_cursor,omitempty"`
-	HasMore    bool   `json:"has_more"`
+	Items      []T        `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	PrevCursor string     `json:"prev_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
+	Links      *PageLinks `json:"links,omitempty"`
+}
+
+// PageLinks are absolute URLs of the neighbouring pages.
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Header formats the links as an RFC 5988 Link header value:
+//
+//	<https://api.example.com/users?cursor=abc>; rel="next", <...>; rel="prev"
+func (l PageLinks) Header() string {
+	var parts []string
+	if l.Next != "" {
+		parts = append(parts, fmt.Sprintf(`<%s>; rel="next"`, l.Next))
+	}
+	if l.Prev != "" {
+		parts = append(parts, fmt.Sprintf(`<%s>; rel="prev"`, l.Prev))
+	}
+	return strings.Join(parts, ", ")
 }
 
 // NewPageResponse creates a new page response.
@@ -40,6 +110,57 @@ func NewPageResponse[T any](items []T, nextCursor string) PageResponse[T] {
 	}
 }
 
+// NewBidirectionalPageResponse creates a page response with both cursors.
+func NewBidirectionalPageResponse[T any](items []T, nextCursor, prevCursor string) PageResponse[T] {
+	return PageResponse[T]{
+		Items:      items,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+// MapItems converts each item with fn, keeping cursors and links,
+// e.g. from domain models to response DTOs.
+func MapItems[T, U any](pr PageResponse[T], fn func(T) U) PageResponse[U] {
+	items := make([]U, len(pr.Items))
+	for i, item := range pr.Items {
+		items[i] = fn(item)
+	}
+
+	return PageResponse[U]{
+		Items:      items,
+		NextCursor: pr.NextCursor,
+		PrevCursor: pr.PrevCursor,
+		HasMore:    pr.HasMore,
+		Links:      pr.Links,
+	}
+}
+
+// UnknownTotal is the TotalCount of a ListResponse built from a cursor page.
+const UnknownTotal int64 = -1
+
+// ListResponse has the same fields as the handler package's ListResponse,
+// so one converts to the other without either package importing the other:
+//
+//	handler.ListResponse[UserResponse](pagination.ToListResponse(page, limit))
+type ListResponse[T any] struct {
+	Items      []T   `json:"items"`
+	TotalCount int64 `json:"total_count"`
+	Limit      int   `json:"limit"`
+	Offset     int   `json:"offset"`
+}
+
+// ToListResponse adapts a cursor page to the offset-style list envelope for
+// APIs without offsets. Offset is 0 and TotalCount is UnknownTotal.
+func ToListResponse[T any](pr PageResponse[T], limit int) ListResponse[T] {
+	return ListResponse[T]{
+		Items:      pr.Items,
+		TotalCount: UnknownTotal,
+		Limit:      limit,
+	}
+}
+
 // ---------- Cursor Types ----------
 
 // IDCursor is a simple cursor using only ID.
@@ -48,14 +169,88 @@ type IDCursor struct {
 }
 
 // TimestampCursor is a cursor using timestamp and ID (for created_at ordering).
+// Timestamp is normalized by CursorTime when encoded.
 type TimestampCursor struct {
 	Timestamp time.Time `json:"ts"`
 	ID        string    `json:"id"`
 }
 
+// MarshalJSON encodes the cursor with Timestamp normalized by CursorTime.
+func (c TimestampCursor) MarshalJSON() ([]byte, error) {
+	type plain TimestampCursor
+	c.Timestamp = CursorTime(c.Timestamp)
+	return json.Marshal(plain(c))
+}
+
+// CursorTime converts t to the value Postgres stores for it in a timestamptz
+// column: UTC, truncated to microseconds. A cursor holding nanoseconds never
+// equals the stored value, so the id tie-breaker never fires and rows sharing
+// a timestamp are skipped or repeated. Use it for every time.Time cursor field.
+func CursorTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// DecimalCursor is a cursor for numeric sort keys such as scores or prices.
+// Never use float64 for numeric(p,s) columns: it drops digits and skips rows
+// at page boundaries. int64 keys can use a plain int64 field.
+type DecimalCursor struct {
+	Value Decimal `json:"v"`
+	ID    string  `json:"id"`
+}
+
+// CursorValue is a cursor field with an exact SQL representation.
+// Keyset.Where binds SQLValue() instead of the field itself.
+type CursorValue interface {
+	SQLValue() any
+}
+
+// Decimal is an exact decimal cursor field. It encodes as a JSON string
+// and binds to SQL as its string form, so it never passes through float64.
+type Decimal struct {
+	decimal.Decimal
+}
+
+// NewDecimal wraps d for use in a cursor.
+func NewDecimal(d decimal.Decimal) Decimal {
+	return Decimal{Decimal: d}
+}
+
+// SQLValue implements CursorValue.
+func (d Decimal) SQLValue() any {
+	return d.String()
+}
+
 // ---------- Cursor Encoding ----------
 
-// EncodeCursor encodes any cursor struct to a base64 string.
+// CursorVersion is written as the first byte of every encoded cursor.
+// Bump it whenever the cursor shape or the sort order behind it changes,
+// so stale cursors fail with ErrCursorVersion instead of returning wrong pages.
+// Values must stay below '{' (123), which marks cursors issued before versioning.
+var CursorVersion byte = 1
+
+// ErrCursorVersion is returned when a cursor was issued under a different
+// CursorVersion. Handlers should respond 400 so the client restarts from page one.
+var ErrCursorVersion = errors.New("cursor version mismatch")
+
+// DecodeOption configures DecodeCursor.
+type DecodeOption[T any] func(*decodeOptions[T])
+
+type decodeOptions[T any] struct {
+	legacy func([]byte) (*T, error)
+}
+
+// WithLegacyDecoder decodes cursors from other versions instead of rejecting
+// them, e.g. to map an old cursor shape onto the new one during a rollout.
+// fn receives the JSON payload without the version byte; it may return
+// ErrCursorVersion for versions it cannot upgrade.
+func WithLegacyDecoder[T any](fn func(data []byte) (*T, error)) DecodeOption[T] {
+	return func(o *decodeOptions[T]) {
+		o.legacy = fn
+	}
+}
+
+// EncodeCursor encodes any cursor struct to a base64 string
+// prefixed with CursorVersion.
 func EncodeCursor[T any](cursor *T) string {
 	if cursor == nil {
 		return ""
@@ -64,48 +259,822 @@ func EncodeCursor[T any](cursor *T) string {
 	if err != nil {
 		return ""
 	}
-	return base64.URLEncoding.EncodeToString(data)
+	return base64.URLEncoding.EncodeToString(append([]byte{CursorVersion}, data...))
 }
 
 // DecodeCursor decodes a base64 string to a cursor struct.
-func DecodeCursor[T any](s string) (*T, error) {
+// Cursors from another version return ErrCursorVersion unless
+// WithLegacyDecoder is given.
+func DecodeCursor[T any](s string, opts ...DecodeOption[T]) (*T, error) {
 	if s == "" {
 		return nil, nil
 	}
-	data, err := base64.URLEncoding.DecodeString(s)
+	version, payload, err := splitCursor(s)
 	if err != nil {
-		return nil, fmt.Errorf("invalid cursor encoding: %w", err)
+		return nil, err
 	}
+
+	if version != CursorVersion {
+		o := &decodeOptions[T]{}
+		for _, opt := range opts {
+			opt(o)
+		}
+		if o.legacy == nil {
+			return nil, fmt.Errorf("%w: got v%d, want v%d", ErrCursorVersion, version, CursorVersion)
+		}
+
+		cursor, err := o.legacy(payload)
+		if err != nil {
+			return nil, fmt.Errorf("legacy cursor v%d: %w", version, err)
+		}
+		return cursor, nil
+	}
+
 	var cursor T
-	if err := json.Unmarshal(data, &cursor); err != nil {
+	if err := json.Unmarshal(payload, &cursor); err != nil {
 		return nil, fmt.Errorf("invalid cursor format: %w", err)
 	}
 	return &cursor, nil
 }
 
+// splitCursor decodes s into its version and JSON payload.
+func splitCursor(s string) (byte, []byte, error) {
+	data, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid cursor encoding: %w", err)
+	}
+	if len(data) == 0 {
+		return 0, nil, fmt.Errorf("invalid cursor format: empty")
+	}
+
+	if data[0] == '{' {
+		// Issued before versioning: bare JSON
+		return 0, data, nil
+	}
+	return data[0], data[1:], nil
+}
+
+// ---------- Limits ----------
+
+// Page size fallbacks when nothing else is configured.
+const (
+	defaultPageLimit = 20
+	defaultMaxLimit  = 100
+)
+
+// Limits bounds page sizes. Zero fields fall back to the global limits.
+type Limits struct {
+	Default int // Used when no limit is requested
+	Max     int // Larger limits are clamped to this
+}
+
+var globalLimits atomic.Pointer[Limits]
+
+// SetGlobalLimits sets the package-wide limits used by FromRequest and any
+// Limits with zero fields. Call once at startup. Zero fields fall back to 20
+// and 100; Default is capped at Max.
+func SetGlobalLimits(l Limits) {
+	if l.Max <= 0 {
+		l.Max = defaultMaxLimit
+	}
+	if l.Default <= 0 {
+		l.Default = defaultPageLimit
+	}
+	l.Default = min(l.Default, l.Max)
+	globalLimits.Store(&l)
+}
+
+// GlobalLimits returns the package-wide limits, 20 and 100 unless set.
+func GlobalLimits() Limits {
+	if l := globalLimits.Load(); l != nil {
+		return *l
+	}
+	return Limits{Default: defaultPageLimit, Max: defaultMaxLimit}
+}
+
+// resolve fills zero fields from the global limits.
+func (l Limits) resolve() Limits {
+	global := GlobalLimits()
+	if l.Max <= 0 {
+		l.Max = global.Max
+	}
+	if l.Default <= 0 {
+		l.Default = global.Default
+	}
+	l.Default = min(l.Default, l.Max)
+	return l
+}
+
+// Apply returns requested clamped to Max, or Default when requested
+// is zero or negative. The result is always between 1 and Max.
+func (l Limits) Apply(requested int) int {
+	l = l.resolve()
+	if requested <= 0 {
+		return l.Default
+	}
+	return min(requested, l.Max)
+}
+
+// resolvedLimit returns limit as resolved at the boundary (FromRequest,
+// Limits.Apply or a batch job's own page size), or
+// GlobalLimits().Default if it is zero or negative. It never clamps to a Max:
+// that would undo a per-endpoint Limits{Max: 500}.
+func resolvedLimit(limit int) int {
+	if limit <= 0 {
+		return GlobalLimits().Default
+	}
+	return limit
+}
+
 // ---------- Pagination Helpers ----------
 
+// FetchLimit returns how many rows a repository must query for a page of limit:
+// limit+1. The extra row is how Paginate knows another page exists.
+//
+// IMPORTANT: query FetchLimit(limit) rows but pass limit to Paginate.
+// Querying only limit rows makes Paginate report the last page on every page,
+// and passing limit+1 to Paginate returns one row too many.
+//
+// limit is taken as already bounded, e.g. by FromRequest; a zero or negative
+// limit means GlobalLimits().Default, exactly as in the Paginate helpers, so
+// it never reaches SQL.
+func FetchLimit(limit int) int {
+	return resolvedLimit(limit) + 1
+}
+
 // Paginate handles the common pagination pattern:
-// 1. Fetches limit+1 items
+// 1. Fetches limit+1 items (see FetchLimit)
 // 2. Determines if there are more items
 // 3. Creates next cursor from last item
+//
+// With limit+1 rows the last page never carries a next cursor, so clients
+// don't need a trailing empty request to learn they are done.
 func Paginate[T any, C any](
 	items []T,
 	limit int,
 	cursorFn func(T) C,
 ) ([]T, string) {
-	if len(items) <= limit {
+	limit = resolvedLimit(limit)
+	return PaginateWithHasMore(items, limit, len(items) > limit, cursorFn)
+}
+
+// PaginateWithHasMore is Paginate for repositories that already know whether
+// another page exists (e.g. from a window function or a separate EXISTS query)
+// and fetch exactly limit rows.
+func PaginateWithHasMore[T any, C any](
+	items []T,
+	limit int,
+	hasMore bool,
+	cursorFn func(T) C,
+) ([]T, string) {
+	limit = resolvedLimit(limit)
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	if !hasMore || len(items) == 0 {
 		return items, ""
 	}
 
 	// We have more items
-	items = items[:limit]
 	lastItem := items[len(items)-1]
 	nextCursor := cursorFn(lastItem)
 
 	return items, EncodeCursor(&nextCursor)
 }
 
+// PaginateWithTotal is Paginate for repositories that count matching rows,
+// e.g. with COUNT(*) OVER (). seen is how many rows earlier pages returned;
+// there are more pages while seen plus this page is below total.
+func PaginateWithTotal[T any, C any](
+	items []T,
+	limit int,
+	total, seen int64,
+	cursorFn func(T) C,
+) ([]T, string) {
+	limit = resolvedLimit(limit)
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return PaginateWithHasMore(items, limit, seen+int64(len(items)) < total, cursorFn)
+}
+
+// PaginateBidirectional is Paginate for pages that can walk both ways.
+// items must be fetched with limit+1 in the query order for direction,
+// i.e. reversed when walking Backward (see KeysetOrderBy).
+// fromCursor reports whether the request had a cursor; without one there
+// is nothing behind the first page (Forward) or after the last (Backward).
+// Returns items in display order plus next and prev cursors.
+func PaginateBidirectional[T any, C any](
+	items []T,
+	limit int,
+	cursorFn func(T) C,
+	direction Direction,
+	fromCursor bool,
+) ([]T, string, string) {
+	limit = resolvedLimit(limit)
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+
+	if direction == Backward {
+		items = slices.Clone(items)
+		slices.Reverse(items)
+	}
+
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	first := cursorFn(items[0])
+	last := cursorFn(items[len(items)-1])
+
+	var next, prev string
+	switch direction {
+	case Backward:
+		if more {
+			prev = EncodeCursor(&first)
+		}
+		if fromCursor {
+			next = EncodeCursor(&last)
+		}
+	default:
+		if more {
+			next = EncodeCursor(&last)
+		}
+		if fromCursor {
+			prev = EncodeCursor(&first)
+		}
+	}
+
+	return items, next, prev
+}
+
+// KeysetOrderBy returns ORDER BY clauses for columns sorted desc (or asc),
+// flipped when walking Backward so the rows nearest the cursor come first.
+func KeysetOrderBy(direction Direction, desc bool, columns ...string) []string {
+	return uniformKeyset(desc, columns).For(direction).OrderBy()
+}
+
+// KeysetWhere returns the condition selecting rows after the cursor in
+// direction for columns all sorted desc (or asc). See Keyset for mixed orders.
+// values are the cursor's column values in the same order as columns.
+func KeysetWhere(direction Direction, desc bool, columns []string, values ...any) sq.Sqlizer {
+	return uniformKeyset(desc, columns).For(direction).Where(values...)
+}
+
+func uniformKeyset(desc bool, columns []string) *Keyset {
+	order := Asc
+	if desc {
+		order = Desc
+	}
+
+	cols := make([]Column, len(columns))
+	for i, name := range columns {
+		cols[i] = Col(name, order)
+	}
+	return NewKeyset(cols...)
+}
+
+// ---------- Keyset Builder ----------
+
+// SortOrder is the sort direction of a keyset column.
+type SortOrder int
+
+const (
+	Asc SortOrder = iota
+	Desc
+)
+
+// Column is a keyset column and its sort order.
+type Column struct {
+	Name  string
+	Order SortOrder
+}
+
+// Col defines a keyset column.
+func Col(name string, order SortOrder) Column {
+	return Column{Name: name, Order: order}
+}
+
+// maxKeysetColumns bounds the OR/AND expansion for mixed orders.
+const maxKeysetColumns = 3
+
+// Keyset builds matching ORDER BY and WHERE clauses from ordered columns.
+// The last column must be unique (usually id) to break ties.
+//
+//	ks := pagination.NewKeyset(pagination.Col("created_at", pagination.Desc), pagination.Col("id", pagination.Desc))
+//	qb = qb.OrderBy(ks.OrderBy()...)
+//	if cursor != nil {
+//	    qb = qb.Where(ks.Where(cursor.CreatedAt, cursor.ID))
+//	}
+type Keyset struct {
+	cols []Column
+}
+
+// NewKeyset creates a keyset over 1 to 3 columns.
+func NewKeyset(cols ...Column) *Keyset {
+	return &Keyset{cols: cols}
+}
+
+// For returns the keyset to use when walking in direction.
+// Backward flips every column's order.
+func (k *Keyset) For(direction Direction) *Keyset {
+	if direction != Backward {
+		return k
+	}
+
+	cols := make([]Column, len(k.cols))
+	for i, c := range k.cols {
+		cols[i] = c
+		cols[i].Order = Asc
+		if c.Order == Asc {
+			cols[i].Order = Desc
+		}
+	}
+	return &Keyset{cols: cols}
+}
+
+// OrderBy returns ORDER BY clauses matching Where.
+func (k *Keyset) OrderBy() []string {
+	clauses := make([]string, len(k.cols))
+	for i, c := range k.cols {
+		clauses[i] = c.Name + " ASC"
+		if c.Order == Desc {
+			clauses[i] = c.Name + " DESC"
+		}
+	}
+	return clauses
+}
+
+// Where returns the condition selecting rows after the cursor values,
+// given in column order.
+//
+// When all columns share an order it emits a row comparison, which
+// Postgres can serve from a composite index:
+//
+//	(created_at, id) < ($1, $2)
+//
+// Mixed orders cannot use a tuple comparison, so it expands to:
+//
+//	(score < $1 OR (score = $2 AND id > $3))
+//
+// Values implementing CursorValue are bound as their SQLValue;
+// time.Time values are normalized by CursorTime.
+func (k *Keyset) Where(values ...any) sq.Sqlizer {
+	if len(k.cols) == 0 || len(k.cols) > maxKeysetColumns {
+		return errSqlizer{fmt.Errorf("keyset: %d columns, want 1-%d", len(k.cols), maxKeysetColumns)}
+	}
+	if len(values) != len(k.cols) {
+		return errSqlizer{fmt.Errorf("keyset: %d columns, %d values", len(k.cols), len(values))}
+	}
+
+	values = slices.Clone(values)
+	for i, v := range values {
+		switch v := v.(type) {
+		case CursorValue:
+			values[i] = v.SQLValue()
+		case time.Time:
+			values[i] = CursorTime(v)
+		}
+	}
+
+	if len(k.cols) == 1 {
+		return k.compare(0, values[0])
+	}
+
+	if k.uniform() {
+		names := make([]string, len(k.cols))
+		for i, c := range k.cols {
+			names[i] = c.Name
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+
+		return sq.Expr(
+			fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), k.op(0), placeholders),
+			values...,
+		)
+	}
+
+	// c1 op v1 OR (c1 = v1 AND c2 op v2) OR ...
+	or := make(sq.Or, 0, len(k.cols))
+	for i := range k.cols {
+		if i == 0 {
+			or = append(or, k.compare(0, values[0]))
+			continue
+		}
+
+		and := make(sq.And, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, sq.Eq{k.cols[j].Name: values[j]})
+		}
+		or = append(or, append(and, k.compare(i, values[i])))
+	}
+
+	return or
+}
+
+func (k *Keyset) uniform() bool {
+	for _, c := range k.cols[1:] {
+		if c.Order != k.cols[0].Order {
+			return false
+		}
+	}
+	return true
+}
+
+// op returns the comparison that moves past the cursor for column i.
+func (k *Keyset) op(i int) string {
+	if k.cols[i].Order == Desc {
+		return "<"
+	}
+	return ">"
+}
+
+func (k *Keyset) compare(i int, value any) sq.Sqlizer {
+	if k.cols[i].Order == Desc {
+		return sq.Lt{k.cols[i].Name: value}
+	}
+	return sq.Gt{k.cols[i].Name: value}
+}
+
+// errSqlizer defers a builder error to ToSql, where squirrel reports errors.
+type errSqlizer struct {
+	err error
+}
+
+func (e errSqlizer) ToSql() (string, []any, error) {
+	return "", nil, e.err
+}
+
+// ---------- Page State ----------
+
+// ErrInvalidSignature is returned by DecodeState when the token's HMAC does
+// not match the signing key, or when signing is expected but missing.
+var ErrInvalidSignature = errors.New("pagination: invalid state signature")
+
+// ErrFilterMismatch is returned by CheckFilter when a page token was issued
+// for different filters than the request carries.
+var ErrFilterMismatch = errors.New("pagination: filters changed")
+
+// State token header flags.
+const (
+	stateGzip   byte = 1 << 0
+	stateSigned byte = 1 << 1
+)
+
+// stateGzipThreshold is the payload size above which state is gzipped.
+const stateGzipThreshold = 200
+
+// maxStateSize caps a state payload after gunzip, so a small token can't
+// expand into a huge allocation.
+const maxStateSize = 64 << 10
+
+// StateOption configures EncodeState and DecodeState.
+type StateOption func(*stateOptions)
+
+type stateOptions struct {
+	key []byte
+}
+
+// WithSigningKey signs tokens with HMAC-SHA256 so clients cannot forge
+// filters or cursors. DecodeState with a key rejects unsigned tokens.
+func WithSigningKey(key []byte) StateOption {
+	return func(o *stateOptions) {
+		o.key = key
+	}
+}
+
+type pageState struct {
+	Filter json.RawMessage `json:"f"`
+	Cursor json.RawMessage `json:"c,omitempty"`
+}
+
+// EncodeState packs the filters a page was generated with and its cursor into
+// one opaque token. Layout before base64: version, flags, payload, [HMAC].
+// The JSON payload is gzipped when larger than ~200 bytes.
+// Like EncodeCursor, it returns "" if filter or cursor cannot be marshaled,
+// or if the payload is over 64 KiB, which DecodeState would reject.
+func EncodeState(filter, cursor any, opts ...StateOption) string {
+	o := &stateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	state := pageState{}
+	var err error
+	if state.Filter, err = json.Marshal(filter); err != nil {
+		return ""
+	}
+	if cursor != nil {
+		if state.Cursor, err = json.Marshal(cursor); err != nil {
+			return ""
+		}
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil || len(payload) > maxStateSize {
+		return ""
+	}
+
+	var flags byte
+	if len(payload) > stateGzipThreshold {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(payload) // writes to a bytes.Buffer don't fail
+		_ = zw.Close()
+		payload = buf.Bytes()
+		flags |= stateGzip
+	}
+
+	if o.key != nil {
+		flags |= stateSigned
+	}
+
+	token := append([]byte{CursorVersion, flags}, payload...)
+	if o.key != nil {
+		token = append(token, stateMAC(o.key, token)...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// DecodeState unpacks a token from EncodeState. An empty token returns nil
+// filter and cursor. Tokens from another version return ErrCursorVersion.
+func DecodeState[F, C any](s string, opts ...StateOption) (*F, *C, error) {
+	if s == "" {
+		return nil, nil, nil
+	}
+
+	o := &stateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid state encoding: %w", err)
+	}
+	if len(token) < 2 {
+		return nil, nil, fmt.Errorf("invalid state format: too short")
+	}
+
+	version, flags := token[0], token[1]
+	if version != CursorVersion {
+		return nil, nil, fmt.Errorf("%w: got v%d, want v%d", ErrCursorVersion, version, CursorVersion)
+	}
+
+	switch {
+	case o.key != nil && flags&stateSigned == 0:
+		return nil, nil, fmt.Errorf("%w: token is not signed", ErrInvalidSignature)
+	case flags&stateSigned != 0:
+		if o.key == nil {
+			return nil, nil, fmt.Errorf("%w: no signing key", ErrInvalidSignature)
+		}
+		if len(token) < 2+sha256.Size {
+			return nil, nil, fmt.Errorf("%w: token too short", ErrInvalidSignature)
+		}
+		body, mac := token[:len(token)-sha256.Size], token[len(token)-sha256.Size:]
+		if !hmac.Equal(mac, stateMAC(o.key, body)) {
+			return nil, nil, ErrInvalidSignature
+		}
+		token = body
+	}
+
+	payload := token[2:]
+	if flags&stateGzip != 0 {
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid state compression: %w", err)
+		}
+		if payload, err = io.ReadAll(io.LimitReader(zr, maxStateSize+1)); err != nil {
+			return nil, nil, fmt.Errorf("invalid state compression: %w", err)
+		}
+		if len(payload) > maxStateSize {
+			return nil, nil, fmt.Errorf("invalid state format: payload exceeds %d bytes", maxStateSize)
+		}
+	}
+
+	var state pageState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return nil, nil, fmt.Errorf("invalid state format: %w", err)
+	}
+
+	var filter F
+	if err := json.Unmarshal(state.Filter, &filter); err != nil {
+		return nil, nil, fmt.Errorf("invalid state filter: %w", err)
+	}
+	if len(state.Cursor) == 0 || string(state.Cursor) == "null" {
+		return &filter, nil, nil
+	}
+
+	var cursor C
+	if err := json.Unmarshal(state.Cursor, &cursor); err != nil {
+		return nil, nil, fmt.Errorf("invalid state cursor: %w", err)
+	}
+	return &filter, &cursor, nil
+}
+
+func stateMAC(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// FilterHash returns a short stable hash of filter's JSON form,
+// for comparing filters without storing them.
+func FilterHash(filter any) string {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// CheckFilter compares the filters stored in a page token with the ones on
+// the current request. A mismatch returns a *RequestError wrapping
+// ErrFilterMismatch, which maps to 400; the client should restart from page one.
+func CheckFilter(stored, requested any) error {
+	if FilterHash(stored) == FilterHash(requested) {
+		return nil
+	}
+	return &RequestError{
+		Param:  "cursor",
+		Value:  FilterHash(stored),
+		Reason: "filters changed since the first page",
+		Err:    ErrFilterMismatch,
+	}
+}
+
+// ---------- Iteration ----------
+
+// ErrNoProgress is returned by Iterate when fetch hands back a cursor it
+// already returned, which would otherwise loop forever.
+var ErrNoProgress = errors.New("pagination: fetch made no progress")
+
+// FetchFunc loads the page after cursor (nil for the first page) and returns
+// the cursor of the next page, or nil after the last page.
+type FetchFunc[T, C any] func(ctx context.Context, cursor *C, limit int) ([]T, *C, error)
+
+// Iterate walks every page from fetch, yielding items one at a time, for
+// batch jobs that share keyset logic with the API:
+//
+//	for user, err := range pagination.Iterate(ctx, repo.ListUsersAfter, 500) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    // ...
+//	}
+//
+// Iteration stops after a fetch error, on context cancellation (yielding
+// ctx.Err()) and with ErrNoProgress if fetch repeats a cursor.
+func Iterate[T, C any](ctx context.Context, fetch FetchFunc[T, C], limit int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var (
+			zero   T
+			cursor *C
+			seen   = make(map[string]struct{})
+		)
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			items, next, err := fetch(ctx, cursor, limit)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if err := ctx.Err(); err != nil {
+					yield(zero, err)
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if next == nil {
+				return
+			}
+
+			key := EncodeCursor(next)
+			if _, dup := seen[key]; dup {
+				yield(zero, fmt.Errorf("%w: cursor %s returned twice", ErrNoProgress, key))
+				return
+			}
+			seen[key] = struct{}{}
+			cursor = next
+		}
+	}
+}
+
+// ---------- HTTP Helpers ----------
+
+// RequestError reports an invalid pagination query parameter.
+// It wraps errs.ErrValidation, so errs.HTTPStatus maps it to 400.
+type RequestError struct {
+	Param  string
+	Value  string
+	Reason string
+	Err    error // Underlying cause, if any
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Param, e.Value, e.Reason)
+}
+
+func (e *RequestError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{errs.ErrValidation}
+	}
+	return []error{errs.ErrValidation, e.Err}
+}
+
+// FromRequest reads the cursor, limit and direction query params.
+// A missing limit uses limits.Default; a larger one is clamped to limits.Max.
+// Zero fields of limits fall back to GlobalLimits.
+// Non-numeric or non-positive limits, unknown directions and cursors that are
+// not valid encodings return a *RequestError. Cursor versions are checked
+// later by DecodeCursor, which may accept them via WithLegacyDecoder.
+func FromRequest(r *http.Request, limits Limits) (PageRequest, error) {
+	limits = limits.resolve()
+
+	q := r.URL.Query()
+	req := PageRequest{
+		Cursor:    q.Get("cursor"),
+		Limit:     limits.Default,
+		Direction: Forward,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return PageRequest{}, &RequestError{Param: "limit", Value: v, Reason: "not an integer", Err: err}
+		}
+		if limit < 1 {
+			return PageRequest{}, &RequestError{Param: "limit", Value: v, Reason: "must be positive"}
+		}
+		req.Limit = limits.Apply(limit)
+	}
+
+	switch v := Direction(q.Get("direction")); v {
+	case "", Forward:
+	case Backward:
+		req.Direction = Backward
+	default:
+		return PageRequest{}, &RequestError{Param: "direction", Value: string(v), Reason: `want "next" or "prev"`}
+	}
+
+	if req.Cursor != "" {
+		if _, _, err := splitCursor(req.Cursor); err != nil {
+			return PageRequest{}, &RequestError{Param: "cursor", Value: req.Cursor, Reason: "malformed", Err: err}
+		}
+	}
+
+	return req, nil
+}
+
+// WithLinks returns the response with next/prev URLs built from baseURL,
+// usually the request URL. Other query params such as filters and limit are
+// kept; cursor and direction are replaced. Set the Link header from
+// resp.Links.Header() for clients that follow RFC 5988 links.
+func (p PageResponse[T]) WithLinks(baseURL string) PageResponse[T] {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return p
+	}
+
+	link := func(cursor string, direction Direction) string {
+		if cursor == "" {
+			return ""
+		}
+		q := u.Query()
+		q.Set("cursor", cursor)
+		q.Del("direction")
+		if direction == Backward {
+			q.Set("direction", string(Backward))
+		}
+		target := *u
+		target.RawQuery = q.Encode()
+		return target.String()
+	}
+
+	links := PageLinks{
+		Next: link(p.NextCursor, Forward),
+		Prev: link(p.PrevCursor, Backward),
+	}
+	if links != (PageLinks{}) {
+		p.Links = &links
+	}
+	return p
+}
+
 // ---------- Example Repository Usage ----------
 
 // UserCursor is the cursor for user pagination.
@@ -114,24 +1083,20 @@ type UserCursor struct {
 	ID        string    `json:"id"`
 }
 
+// userKeyset orders users newest first, id breaking ties.
+var userKeyset = NewKeyset(Col("created_at", Desc), Col("id", Desc))
+
 // Example usage in repository:
 //
 //	func (r *userRepo) ListUsers(ctx context.Context, cursor *UserCursor, limit int) ([]*User, *UserCursor, error) {
 //	    qb := squirrel.Select("*").
 //	        From("users").
-//	        OrderBy("created_at DESC", "id DESC").
-//	        Limit(uint64(limit + 1))
+//	        OrderBy(userKeyset.OrderBy()...).
+//	        Limit(uint64(pagination.FetchLimit(limit)))
 //
 //	    if cursor != nil {
-//	        qb = qb.Where(
-//	            squirrel.Or{
-//	                squirrel.Lt{"created_at": cursor.CreatedAt},
-//	                squirrel.And{
-//	                    squirrel.Eq{"created_at": cursor.CreatedAt},
-//	                    squirrel.Lt{"id": cursor.ID},
-//	                },
-//	            },
-//	        )
+//	        // (created_at, id) < (cursor.CreatedAt, cursor.ID)
+//	        qb = qb.Where(userKeyset.Where(cursor.CreatedAt, cursor.ID))
 //	    }
 //
 //	    sql, args, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
@@ -144,7 +1109,7 @@ type UserCursor struct {
 //
 //	    // Paginate results
 //	    users, nextCursorStr := pagination.Paginate(users, limit, func(u *User) UserCursor {
-//	        return UserCursor{CreatedAt: u.CreatedAt, ID: u.ID}
+//	        return UserCursor{CreatedAt: pagination.CursorTime(u.CreatedAt), ID: u.ID}
 //	    })
 //
 //	    var nextCursor *UserCursor
@@ -155,6 +1120,32 @@ type UserCursor struct {
 //	    return users, nextCursor, nil
 //	}
 
+// Example with previous-page support:
+//
+//	func (r *userRepo) ListUsersPage(ctx context.Context, req pagination.PageRequest) (pagination.PageResponse[*User], error) {
+//	    cols := []string{"created_at", "id"}
+//	    qb := squirrel.Select("*").
+//	        From("users").
+//	        OrderBy(pagination.KeysetOrderBy(req.Direction, true, cols...)...).
+//	        Limit(uint64(pagination.FetchLimit(req.Limit)))
+//
+//	    cursor, err := pagination.DecodeCursor[UserCursor](req.Cursor)
+//	    if err != nil {
+//	        return pagination.PageResponse[*User]{}, err
+//	    }
+//	    if cursor != nil {
+//	        qb = qb.Where(pagination.KeysetWhere(req.Direction, true, cols, cursor.CreatedAt, cursor.ID))
+//	    }
+//
+//	    // ... query and scan rows into users ...
+//
+//	    users, next, prev := pagination.PaginateBidirectional(users, req.Limit, func(u *User) UserCursor {
+//	        return UserCursor{CreatedAt: pagination.CursorTime(u.CreatedAt), ID: u.ID}
+//	    }, req.Direction, cursor != nil)
+//
+//	    return pagination.NewBidirectionalPageResponse(users, next, prev), nil
+//	}
+
 // ---------- Offset Pagination (for admin panels) ----------
 
 // OffsetRequest is the request for offset-based pagination.
@@ -163,25 +1154,58 @@ type OffsetRequest struct {
 	Offset int `json:"offset" validate:"min=0"`
 }
 
+// Normalize bounds Limit by Limits{Max: maxLimit} (the default comes from
+// GlobalLimits) and floors a negative offset to 0.
+func (r *OffsetRequest) Normalize(maxLimit int) {
+	r.Limit = Limits{Max: maxLimit}.Apply(r.Limit)
+	r.Offset = max(r.Offset, 0)
+}
+
+// ToOffsetLimit converts a 1-based page number and page size to an offset
+// and limit. Pages below 1 are treated as 1. perPage is taken as already
+// bounded (see Limits.Apply); zero or negative means GlobalLimits().Default.
+func ToOffsetLimit(page, perPage int) (offset, limit int) {
+	perPage = resolvedLimit(perPage)
+	page = max(page, 1)
+	return (page - 1) * perPage, perPage
+}
+
 // OffsetResponse is an offset-based paginated response.
+// Page numbers are 1-based; TotalPages is 0 when there are no items.
 type OffsetResponse[T any] struct {
 	Items      []T   `json:"items"`
 	TotalCount int64 `json:"total_count"`
 	Limit      int   `json:"limit"`
 	Offset     int   `json:"offset"`
+	Page       int   `json:"page"`
+	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+	HasPrev    bool  `json:"has_prev"`
 }
 
-// NewOffsetResponse creates a new offset response.
+// NewOffsetResponse creates a new offset response with page numbers derived
+// from total, limit and offset.
 func NewOffsetResponse[T any](items []T, total int64, limit, offset int) OffsetResponse[T] {
-	return OffsetResponse[T]{
+	resp := OffsetResponse[T]{
 		Items:      items,
 		TotalCount: total,
 		Limit:      limit,
 		Offset:     offset,
+		Page:       1,
+		HasNext:    int64(offset+len(items)) < total,
+		HasPrev:    offset > 0,
 	}
+
+	if limit > 0 {
+		resp.Page = offset/limit + 1
+		resp.TotalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	return resp
 }
 
 // DefaultLimit returns the default limit if not specified.
+// Prefer Limits.Apply, which reads the defaults from one place.
 func DefaultLimit(limit, defaultVal, maxVal int) int {
 	if limit <= 0 {
 		return defaultVal
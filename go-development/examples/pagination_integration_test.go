@@ -0,0 +1,388 @@
+//go:build integration
+
+package pagination_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"myapp/internal/pagination"
+)
+
+// Keyset tests run against a REAL PostgreSQL database.
+// Run with: go test -tags=integration ./internal/pagination/...
+
+var pgConnURL string
+
+// TestMain uses testcontainers locally and DATABASE_URL in CI.
+func TestMain(m *testing.M) {
+	var code int
+
+	func() {
+		if os.Getenv("CI") == "true" {
+			pgConnURL = os.Getenv("DATABASE_URL")
+			if pgConnURL == "" {
+				log.Fatal("DATABASE_URL is required in CI environment")
+			}
+		} else {
+			ctx := context.Background()
+			container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+				ContainerRequest: testcontainers.ContainerRequest{
+					Image:        "postgres:16-alpine",
+					ExposedPorts: []string{"5432/tcp"},
+					Env: map[string]string{
+						"POSTGRES_USER":     "test",
+						"POSTGRES_PASSWORD": "test",
+						"POSTGRES_DB":       "test",
+					},
+					WaitingFor: wait.ForListeningPort("5432/tcp").
+						WithStartupTimeout(60 * time.Second),
+				},
+				Started: true,
+			})
+			if err != nil {
+				log.Fatalf("Failed to start PostgreSQL container: %v", err)
+			}
+			defer func() {
+				if err := container.Terminate(ctx); err != nil {
+					log.Printf("Failed to close postgres: %v", err)
+				}
+			}()
+
+			host, err := container.Host(ctx)
+			if err != nil {
+				log.Fatalf("Failed to get host: %v", err)
+			}
+			port, err := container.MappedPort(ctx, "5432")
+			if err != nil {
+				log.Fatalf("Failed to get port: %v", err)
+			}
+			pgConnURL = fmt.Sprintf("postgres://test:test@%s:%s/test?sslmode=disable", host, port.Port())
+		}
+
+		code = m.Run()
+	}()
+
+	os.Exit(code)
+}
+
+type scoredRow struct {
+	ID        string
+	Score     int
+	CreatedAt time.Time
+}
+
+// seedScoredRows creates a table with many ties on every sort column but id.
+func seedScoredRows(t *testing.T, pool *pgxpool.Pool) string {
+	t.Helper()
+
+	ctx := context.Background()
+	table := fmt.Sprintf("keyset_rows_%d", time.Now().UnixNano())
+
+	_, err := pool.Exec(ctx, fmt.Sprintf(`CREATE TABLE %s (
+		id         TEXT PRIMARY KEY,
+		score      INT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	)`, table))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), "DROP TABLE "+table)
+	})
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		_, err := pool.Exec(ctx,
+			fmt.Sprintf("INSERT INTO %s (id, score, created_at) VALUES ($1, $2, $3)", table),
+			fmt.Sprintf("row-%02d", i),
+			i%4,
+			base.Add(time.Duration(i%5)*time.Hour),
+		)
+		require.NoError(t, err)
+	}
+
+	return table
+}
+
+// walkPages paginates the whole table with ks and returns ids in page order.
+func walkPages(t *testing.T, pool *pgxpool.Pool, table string, ks *pagination.Keyset, limit int, cursorFn func(scoredRow) []any) []string {
+	t.Helper()
+
+	ctx := context.Background()
+	var (
+		ids    []string
+		cursor []any
+	)
+
+	for page := 0; page < 100; page++ {
+		qb := sq.Select("id", "score", "created_at").
+			From(table).
+			OrderBy(ks.OrderBy()...).
+			Limit(uint64(pagination.FetchLimit(limit))).
+			PlaceholderFormat(sq.Dollar)
+		if cursor != nil {
+			qb = qb.Where(ks.Where(cursor...))
+		}
+
+		query, args, err := qb.ToSql()
+		require.NoError(t, err)
+
+		rows, err := pool.Query(ctx, query, args...)
+		require.NoError(t, err)
+
+		var items []scoredRow
+		for rows.Next() {
+			var r scoredRow
+			require.NoError(t, rows.Scan(&r.ID, &r.Score, &r.CreatedAt))
+			items = append(items, r)
+		}
+		require.NoError(t, rows.Err())
+
+		items, next := pagination.Paginate(items, limit, cursorFn)
+		for _, r := range items {
+			ids = append(ids, r.ID)
+		}
+		if next == "" {
+			return ids
+		}
+		cursor = cursorFn(items[len(items)-1])
+	}
+
+	t.Fatal("pagination did not terminate")
+	return nil
+}
+
+func allIDs(t *testing.T, pool *pgxpool.Pool, table string, orderBy []string) []string {
+	t.Helper()
+
+	query, _, err := sq.Select("id").From(table).OrderBy(orderBy...).ToSql()
+	require.NoError(t, err)
+
+	rows, err := pool.Query(context.Background(), query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	return ids
+}
+
+func TestKeyset_Postgres_NoSkipsOrDuplicates(t *testing.T) {
+	t.Parallel()
+
+	pool, err := pgxpool.New(context.Background(), pgConnURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	table := seedScoredRows(t, pool)
+
+	tests := []struct {
+		name     string
+		keyset   *pagination.Keyset
+		cursorFn func(scoredRow) []any
+	}{
+		{
+			name: "uniform desc",
+			keyset: pagination.NewKeyset(
+				pagination.Col("created_at", pagination.Desc),
+				pagination.Col("id", pagination.Desc),
+			),
+			cursorFn: func(r scoredRow) []any { return []any{r.CreatedAt, r.ID} },
+		},
+		{
+			name: "mixed orders",
+			keyset: pagination.NewKeyset(
+				pagination.Col("score", pagination.Desc),
+				pagination.Col("created_at", pagination.Asc),
+				pagination.Col("id", pagination.Desc),
+			),
+			cursorFn: func(r scoredRow) []any { return []any{r.Score, r.CreatedAt, r.ID} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := allIDs(t, pool, table, tt.keyset.OrderBy())
+			require.Len(t, want, 50)
+
+			for _, limit := range []int{1, 7, 50} {
+				got := walkPages(t, pool, table, tt.keyset, limit, tt.cursorFn)
+				assert.Equal(t, want, got, "limit %d", limit)
+			}
+		})
+	}
+}
+
+func TestKeyset_Postgres_DecimalScores(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, pgConnURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	table := fmt.Sprintf("keyset_scores_%d", time.Now().UnixNano())
+	_, err = pool.Exec(ctx, fmt.Sprintf(`CREATE TABLE %s (
+		id    TEXT PRIMARY KEY,
+		score NUMERIC(20, 8) NOT NULL
+	)`, table))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), "DROP TABLE "+table)
+	})
+
+	// Scores differ only in the 8th decimal place; float64 collapses them all
+	base := decimal.RequireFromString("99999999999.99999980")
+	for i := 0; i < 20; i++ {
+		_, err := pool.Exec(ctx,
+			fmt.Sprintf("INSERT INTO %s (id, score) VALUES ($1, $2)", table),
+			fmt.Sprintf("row-%02d", i),
+			base.Add(decimal.New(int64(i), -8)).String(),
+		)
+		require.NoError(t, err)
+	}
+
+	ks := pagination.NewKeyset(
+		pagination.Col("score", pagination.Desc),
+		pagination.Col("id", pagination.Asc),
+	)
+	want := allIDs(t, pool, table, ks.OrderBy())
+	require.Len(t, want, 20)
+
+	var (
+		got    []string
+		cursor *pagination.DecimalCursor
+	)
+	for page := 0; page < 20 && (page == 0 || cursor != nil); page++ {
+		qb := sq.Select("id", "score::text").
+			From(table).
+			OrderBy(ks.OrderBy()...).
+			Limit(uint64(pagination.FetchLimit(3))).
+			PlaceholderFormat(sq.Dollar)
+		if cursor != nil {
+			qb = qb.Where(ks.Where(cursor.Value, cursor.ID))
+		}
+
+		query, args, err := qb.ToSql()
+		require.NoError(t, err)
+
+		rows, err := pool.Query(ctx, query, args...)
+		require.NoError(t, err)
+
+		var items []pagination.DecimalCursor
+		for rows.Next() {
+			var id, score string
+			require.NoError(t, rows.Scan(&id, &score))
+			items = append(items, pagination.DecimalCursor{
+				Value: pagination.NewDecimal(decimal.RequireFromString(score)),
+				ID:    id,
+			})
+		}
+		require.NoError(t, rows.Err())
+
+		items, next := pagination.Paginate(items, 3, func(c pagination.DecimalCursor) pagination.DecimalCursor { return c })
+		for _, c := range items {
+			got = append(got, c.ID)
+		}
+
+		// Round-trip through the encoded form, as a client would
+		cursor, err = pagination.DecodeCursor[pagination.DecimalCursor](next)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestKeyset_Postgres_IdenticalTimestamps(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, pgConnURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	table := fmt.Sprintf("keyset_events_%d", time.Now().UnixNano())
+	_, err = pool.Exec(ctx, fmt.Sprintf(`CREATE TABLE %s (
+		id         TEXT PRIMARY KEY,
+		created_at TIMESTAMPTZ NOT NULL
+	)`, table))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), "DROP TABLE "+table)
+	})
+
+	// Nanoseconds and a non-UTC offset, as time.Now() in the app would produce.
+	// Postgres keeps microseconds, so the stored value differs from createdAt.
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.FixedZone("UTC+2", 2*60*60))
+	for i := 0; i < 10; i++ {
+		_, err := pool.Exec(ctx,
+			fmt.Sprintf("INSERT INTO %s (id, created_at) VALUES ($1, $2)", table),
+			fmt.Sprintf("evt-%02d", i),
+			createdAt,
+		)
+		require.NoError(t, err)
+	}
+
+	ks := pagination.NewKeyset(
+		pagination.Col("created_at", pagination.Desc),
+		pagination.Col("id", pagination.Desc),
+	)
+	want := allIDs(t, pool, table, ks.OrderBy())
+	require.Len(t, want, 10)
+
+	var (
+		got    []string
+		cursor *pagination.TimestampCursor
+	)
+	for page := 0; page < 10 && (page == 0 || cursor != nil); page++ {
+		qb := sq.Select("id").
+			From(table).
+			OrderBy(ks.OrderBy()...).
+			Limit(uint64(pagination.FetchLimit(3))).
+			PlaceholderFormat(sq.Dollar)
+		if cursor != nil {
+			qb = qb.Where(ks.Where(cursor.Timestamp, cursor.ID))
+		}
+
+		query, args, err := qb.ToSql()
+		require.NoError(t, err)
+
+		rows, err := pool.Query(ctx, query, args...)
+		require.NoError(t, err)
+
+		var ids []string
+		for rows.Next() {
+			var id string
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		require.NoError(t, rows.Err())
+
+		// Cursor built from the app-side timestamp, not the stored one
+		ids, next := pagination.Paginate(ids, 3, func(id string) pagination.TimestampCursor {
+			return pagination.TimestampCursor{Timestamp: createdAt, ID: id}
+		})
+		got = append(got, ids...)
+
+		cursor, err = pagination.DecodeCursor[pagination.TimestampCursor](next)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, want, got, "no skipped or repeated rows")
+}
@@ -0,0 +1,1227 @@
+package pagination_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/errs"
+	"myapp/internal/pagination"
+)
+
+type idCursor struct {
+	ID int `json:"id"`
+}
+
+func cursorOf(id int) idCursor { return idCursor{ID: id} }
+
+func decodeID(t *testing.T, s string) int {
+	t.Helper()
+	c, err := pagination.DecodeCursor[idCursor](s)
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	return c.ID
+}
+
+// rawCursor encodes payload the way EncodeCursor would under version.
+func rawCursor(version byte, payload string) string {
+	return base64.URLEncoding.EncodeToString(append([]byte{version}, payload...))
+}
+
+// ---------- Cursor Encoding Tests ----------
+
+func TestCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	s := pagination.EncodeCursor(&idCursor{ID: 42})
+
+	data, err := base64.URLEncoding.DecodeString(s)
+	require.NoError(t, err)
+	assert.Equal(t, pagination.CursorVersion, data[0])
+	assert.Equal(t, 42, decodeID(t, s))
+}
+
+func TestDecodeCursor_Empty(t *testing.T) {
+	t.Parallel()
+
+	c, err := pagination.DecodeCursor[idCursor]("")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestDecodeCursor_VersionMismatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"unversioned", base64.URLEncoding.EncodeToString([]byte(`{"id":7}`))},
+		{"older version", rawCursor(pagination.CursorVersion-1, `{"id":7}`)},
+		{"unknown version", rawCursor(pagination.CursorVersion+9, `{"id":7}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := pagination.DecodeCursor[idCursor](tt.cursor)
+			assert.ErrorIs(t, err, pagination.ErrCursorVersion)
+			assert.Nil(t, c)
+		})
+	}
+}
+
+func TestDecodeCursor_LegacyDecoder(t *testing.T) {
+	t.Parallel()
+
+	// The old cursor stored the id as a string
+	legacy := pagination.WithLegacyDecoder(func(data []byte) (*idCursor, error) {
+		var old struct {
+			Key int `json:"key,string"`
+		}
+		if err := json.Unmarshal(data, &old); err != nil {
+			return nil, err
+		}
+		return &idCursor{ID: old.Key}, nil
+	})
+
+	t.Run("upgrades unversioned cursor", func(t *testing.T) {
+		t.Parallel()
+
+		s := base64.URLEncoding.EncodeToString([]byte(`{"key":"7"}`))
+		c, err := pagination.DecodeCursor[idCursor](s, legacy)
+		require.NoError(t, err)
+		assert.Equal(t, 7, c.ID)
+	})
+
+	t.Run("upgrades older version", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := pagination.DecodeCursor[idCursor](rawCursor(pagination.CursorVersion-1, `{"key":"8"}`), legacy)
+		require.NoError(t, err)
+		assert.Equal(t, 8, c.ID)
+	})
+
+	t.Run("skipped for current version", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := pagination.DecodeCursor[idCursor](pagination.EncodeCursor(&idCursor{ID: 9}), legacy)
+		require.NoError(t, err)
+		assert.Equal(t, 9, c.ID)
+	})
+
+	t.Run("rejection keeps sentinel", func(t *testing.T) {
+		t.Parallel()
+
+		reject := pagination.WithLegacyDecoder(func([]byte) (*idCursor, error) {
+			return nil, pagination.ErrCursorVersion
+		})
+		_, err := pagination.DecodeCursor[idCursor](rawCursor(pagination.CursorVersion+9, `{}`), reject)
+		assert.ErrorIs(t, err, pagination.ErrCursorVersion)
+	})
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := pagination.DecodeCursor[idCursor]("not base64!")
+	require.Error(t, err)
+
+	_, err = pagination.DecodeCursor[idCursor](rawCursor(pagination.CursorVersion, "not json"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, pagination.ErrCursorVersion)
+}
+
+// ---------- Limits Tests ----------
+
+// Runs first and sequentially: parallel tests start after it.
+func TestGlobalLimits_Unset(t *testing.T) {
+	assert.Equal(t, pagination.Limits{Default: 20, Max: 100}, pagination.GlobalLimits())
+}
+
+func TestSetGlobalLimits(t *testing.T) {
+	prev := pagination.GlobalLimits()
+	t.Cleanup(func() { pagination.SetGlobalLimits(prev) })
+
+	pagination.SetGlobalLimits(pagination.Limits{Default: 10, Max: 30})
+	assert.Equal(t, 10, pagination.Limits{}.Apply(0))
+	assert.Equal(t, 30, pagination.Limits{}.Apply(1000))
+	assert.Equal(t, 11, pagination.FetchLimit(0))
+
+	r := httptest.NewRequest(http.MethodGet, "/users?limit=1000", nil)
+	req, err := pagination.FromRequest(r, pagination.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, 30, req.Limit)
+
+	pagination.SetGlobalLimits(pagination.Limits{Default: 50, Max: 25})
+	assert.Equal(t, pagination.Limits{Default: 25, Max: 25}, pagination.GlobalLimits(), "default capped at max")
+
+	pagination.SetGlobalLimits(pagination.Limits{})
+	assert.Equal(t, pagination.Limits{Default: 20, Max: 100}, pagination.GlobalLimits())
+}
+
+func TestLimits_Apply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		limits    pagination.Limits
+		requested int
+		want      int
+	}{
+		{"zero uses default", pagination.Limits{Default: 10, Max: 50}, 0, 10},
+		{"negative uses default", pagination.Limits{Default: 10, Max: 50}, -5, 10},
+		{"within range", pagination.Limits{Default: 10, Max: 50}, 25, 25},
+		{"over max", pagination.Limits{Default: 10, Max: 50}, 100000, 50},
+		{"unset fields use global", pagination.Limits{}, 0, 20},
+		{"unset max uses global", pagination.Limits{Default: 10}, 500, 100},
+		{"default above max", pagination.Limits{Default: 80, Max: 50}, 0, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.limits.Apply(tt.requested))
+		})
+	}
+}
+
+func TestPageRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, pagination.PageRequest{}.Validate())
+	require.NoError(t, pagination.PageRequest{Limit: 10, Direction: pagination.Backward}.Validate())
+
+	err := pagination.PageRequest{Limit: -1}.Validate()
+	assert.ErrorIs(t, err, errs.ErrValidation)
+
+	err = pagination.PageRequest{Direction: "up"}.Validate()
+	assert.ErrorIs(t, err, errs.ErrValidation)
+}
+
+func TestFetchLimit_NeverBelowOne(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 21, pagination.FetchLimit(0))
+	assert.Equal(t, 21, pagination.FetchLimit(-10))
+}
+
+func TestPaginate_PerEndpointMaxAboveGlobal(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/export?limit=500", nil)
+	req, err := pagination.FromRequest(r, pagination.Limits{Max: 500})
+	require.NoError(t, err)
+	require.Equal(t, 500, req.Limit)
+
+	fetch := pagination.FetchLimit(req.Limit)
+	assert.Equal(t, 501, fetch, "not cut back to the global max of 100")
+
+	items := make([]int, fetch)
+	for i := range items {
+		items[i] = i + 1
+	}
+	got, next := pagination.Paginate(items, req.Limit, cursorOf)
+	assert.Len(t, got, 500)
+	require.NotEmpty(t, next)
+
+	cursor, err := pagination.DecodeCursor[idCursor](next)
+	require.NoError(t, err)
+	assert.Equal(t, 500, cursor.ID)
+
+	got, _, _ = pagination.PaginateBidirectional(items, req.Limit, cursorOf, pagination.Forward, false)
+	assert.Len(t, got, 500)
+
+	_, limit := pagination.ToOffsetLimit(1, req.Limit)
+	assert.Equal(t, 500, limit)
+}
+
+func TestPaginate_NegativeLimit(t *testing.T) {
+	t.Parallel()
+
+	items := make([]int, 25)
+	got, next := pagination.Paginate(items, -1, cursorOf)
+	assert.Len(t, got, 20, "negative limit means the default")
+	assert.NotEmpty(t, next)
+}
+
+// ---------- Pagination Helper Tests ----------
+
+func TestFetchLimit(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 21, pagination.FetchLimit(20))
+}
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		items    []int
+		wantLen  int
+		wantNext int // 0 means no next cursor
+	}{
+		{"more pages", []int{1, 2, 3, 4}, 3, 3},
+		{"exactly limit", []int{1, 2, 3}, 3, 0},
+		{"short page", []int{1}, 1, 0},
+		{"empty", nil, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			items, next := pagination.Paginate(tt.items, 3, cursorOf)
+			assert.Len(t, items, tt.wantLen)
+			if tt.wantNext == 0 {
+				assert.Empty(t, next)
+				return
+			}
+			assert.Equal(t, tt.wantNext, decodeID(t, next))
+		})
+	}
+}
+
+func TestPaginateWithHasMore(t *testing.T) {
+	t.Parallel()
+
+	items, next := pagination.PaginateWithHasMore([]int{1, 2, 3}, 3, true, cursorOf)
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Equal(t, 3, decodeID(t, next))
+
+	items, next = pagination.PaginateWithHasMore([]int{1, 2, 3}, 3, false, cursorOf)
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Empty(t, next)
+
+	_, next = pagination.PaginateWithHasMore([]int{}, 3, true, cursorOf)
+	assert.Empty(t, next, "no cursor without a last item")
+}
+
+func TestPaginateWithTotal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		items    []int
+		total    int64
+		seen     int64
+		wantNext bool
+	}{
+		{"first of two pages", []int{1, 2, 3}, 5, 0, true},
+		{"last full page", []int{4, 5, 6}, 6, 3, false},
+		{"last short page", []int{4, 5}, 5, 3, false},
+		{"extra row trimmed", []int{1, 2, 3, 4}, 4, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			items, next := pagination.PaginateWithTotal(tt.items, 3, tt.total, tt.seen, cursorOf)
+			assert.LessOrEqual(t, len(items), 3)
+			assert.Equal(t, tt.wantNext, next != "")
+		})
+	}
+}
+
+// ---------- Bidirectional Pagination Tests ----------
+
+func TestPaginateBidirectional_ForwardFirstPage(t *testing.T) {
+	t.Parallel()
+
+	items, next, prev := pagination.PaginateBidirectional([]int{1, 2, 3, 4}, 3, cursorOf, pagination.Forward, false)
+
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Equal(t, 3, decodeID(t, next))
+	assert.Empty(t, prev)
+}
+
+func TestPaginateBidirectional_ForwardMiddlePage(t *testing.T) {
+	t.Parallel()
+
+	items, next, prev := pagination.PaginateBidirectional([]int{4, 5, 6, 7}, 3, cursorOf, pagination.Forward, true)
+
+	assert.Equal(t, []int{4, 5, 6}, items)
+	assert.Equal(t, 6, decodeID(t, next))
+	assert.Equal(t, 4, decodeID(t, prev))
+}
+
+func TestPaginateBidirectional_ForwardLastPage(t *testing.T) {
+	t.Parallel()
+
+	items, next, prev := pagination.PaginateBidirectional([]int{7, 8}, 3, cursorOf, pagination.Forward, true)
+
+	assert.Equal(t, []int{7, 8}, items)
+	assert.Empty(t, next)
+	assert.Equal(t, 7, decodeID(t, prev))
+}
+
+func TestPaginateBidirectional_Backward(t *testing.T) {
+	t.Parallel()
+
+	// Walking back from 7: rows arrive nearest-first
+	items, next, prev := pagination.PaginateBidirectional([]int{6, 5, 4, 3}, 3, cursorOf, pagination.Backward, true)
+
+	assert.Equal(t, []int{4, 5, 6}, items)
+	assert.Equal(t, 6, decodeID(t, next))
+	assert.Equal(t, 4, decodeID(t, prev))
+}
+
+func TestPaginateBidirectional_BackwardToFirstPage(t *testing.T) {
+	t.Parallel()
+
+	fetched := []int{3, 2, 1}
+	items, next, prev := pagination.PaginateBidirectional(fetched, 3, cursorOf, pagination.Backward, true)
+
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Equal(t, 3, decodeID(t, next))
+	assert.Empty(t, prev)
+	assert.Equal(t, []int{3, 2, 1}, fetched, "input must not be reordered")
+}
+
+func TestPaginateBidirectional_Empty(t *testing.T) {
+	t.Parallel()
+
+	items, next, prev := pagination.PaginateBidirectional([]int{}, 3, cursorOf, pagination.Backward, true)
+
+	assert.Empty(t, items)
+	assert.Empty(t, next)
+	assert.Empty(t, prev)
+}
+
+// ---------- Keyset Condition Tests ----------
+
+func TestKeysetWhere(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		direction pagination.Direction
+		desc      bool
+		wantSQL   string
+	}{
+		{"forward desc", pagination.Forward, true, "(created_at, id) < (?, ?)"},
+		{"backward desc", pagination.Backward, true, "(created_at, id) > (?, ?)"},
+		{"forward asc", pagination.Forward, false, "(created_at, id) > (?, ?)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, args, err := pagination.KeysetWhere(tt.direction, tt.desc, []string{"created_at", "id"}, "2024-01-01", "u1").ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSQL, sql)
+			assert.Equal(t, []any{"2024-01-01", "u1"}, args)
+		})
+	}
+}
+
+func TestKeysetWhere_ValueCountMismatch(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := pagination.KeysetWhere(pagination.Forward, true, []string{"created_at", "id"}, "2024-01-01").ToSql()
+	assert.Error(t, err)
+}
+
+func TestKeysetOrderBy(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"created_at DESC", "id DESC"}, pagination.KeysetOrderBy(pagination.Forward, true, "created_at", "id"))
+	assert.Equal(t, []string{"created_at ASC", "id ASC"}, pagination.KeysetOrderBy(pagination.Backward, true, "created_at", "id"))
+}
+
+// ---------- Keyset Builder Tests ----------
+
+func TestKeyset_Where(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		keyset   *pagination.Keyset
+		values   []any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "single column",
+			keyset:   pagination.NewKeyset(pagination.Col("id", pagination.Asc)),
+			values:   []any{"u1"},
+			wantSQL:  "id > ?",
+			wantArgs: []any{"u1"},
+		},
+		{
+			name: "uniform desc uses row comparison",
+			keyset: pagination.NewKeyset(
+				pagination.Col("created_at", pagination.Desc),
+				pagination.Col("id", pagination.Desc),
+			),
+			values:   []any{"2024-01-01", "u1"},
+			wantSQL:  "(created_at, id) < (?, ?)",
+			wantArgs: []any{"2024-01-01", "u1"},
+		},
+		{
+			name: "mixed orders expand",
+			keyset: pagination.NewKeyset(
+				pagination.Col("score", pagination.Desc),
+				pagination.Col("id", pagination.Asc),
+			),
+			values:   []any{90, "u1"},
+			wantSQL:  "(score < ? OR (score = ? AND id > ?))",
+			wantArgs: []any{90, 90, "u1"},
+		},
+		{
+			name: "three mixed columns",
+			keyset: pagination.NewKeyset(
+				pagination.Col("priority", pagination.Asc),
+				pagination.Col("created_at", pagination.Desc),
+				pagination.Col("id", pagination.Desc),
+			),
+			values:   []any{1, "2024-01-01", "u1"},
+			wantSQL:  "(priority > ? OR (priority = ? AND created_at < ?) OR (priority = ? AND created_at = ? AND id < ?))",
+			wantArgs: []any{1, 1, "2024-01-01", 1, "2024-01-01", "u1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, args, err := tt.keyset.Where(tt.values...).ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSQL, sql)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestKeyset_WhereInvalid(t *testing.T) {
+	t.Parallel()
+
+	four := pagination.NewKeyset(
+		pagination.Col("a", pagination.Asc),
+		pagination.Col("b", pagination.Asc),
+		pagination.Col("c", pagination.Asc),
+		pagination.Col("d", pagination.Asc),
+	)
+	_, _, err := four.Where(1, 2, 3, 4).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = pagination.NewKeyset().Where().ToSql()
+	assert.Error(t, err)
+
+	two := pagination.NewKeyset(pagination.Col("a", pagination.Asc), pagination.Col("b", pagination.Asc))
+	_, _, err = two.Where(1).ToSql()
+	assert.Error(t, err)
+}
+
+func TestKeyset_OrderBy(t *testing.T) {
+	t.Parallel()
+
+	ks := pagination.NewKeyset(
+		pagination.Col("score", pagination.Desc),
+		pagination.Col("id", pagination.Asc),
+	)
+
+	assert.Equal(t, []string{"score DESC", "id ASC"}, ks.OrderBy())
+	assert.Equal(t, []string{"score ASC", "id DESC"}, ks.For(pagination.Backward).OrderBy())
+
+	sql, _, err := ks.For(pagination.Backward).Where(90, "u1").ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "(score > ? OR (score = ? AND id < ?))", sql)
+}
+
+// ---------- Timestamp Cursor Tests ----------
+
+func TestTimestampCursor_NormalizedOnEncode(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	ts := time.Date(2024, 5, 1, 15, 4, 5, 123456789, loc)
+
+	c, err := pagination.DecodeCursor[pagination.TimestampCursor](
+		pagination.EncodeCursor(&pagination.TimestampCursor{Timestamp: ts, ID: "a"}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.UTC, c.Timestamp.Location())
+	assert.Equal(t, 123456000, c.Timestamp.Nanosecond())
+	assert.True(t, c.Timestamp.Equal(pagination.CursorTime(ts)))
+	assert.Equal(t, "a", c.ID)
+}
+
+func TestKeyset_WhereNormalizesTime(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 5, 1, 15, 4, 5, 123456789, time.Local)
+	ks := pagination.NewKeyset(pagination.Col("created_at", pagination.Desc), pagination.Col("id", pagination.Desc))
+
+	_, args, err := ks.Where(ts, "x").ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, []any{pagination.CursorTime(ts), "x"}, args)
+}
+
+// ---------- Decimal Cursor Tests ----------
+
+func TestDecimalCursor_RoundTripsExactly(t *testing.T) {
+	t.Parallel()
+
+	score := decimal.RequireFromString("99999999999.12345678")
+	s := pagination.EncodeCursor(&pagination.DecimalCursor{Value: pagination.NewDecimal(score), ID: "a"})
+
+	c, err := pagination.DecodeCursor[pagination.DecimalCursor](s)
+	require.NoError(t, err)
+	assert.True(t, score.Equal(c.Value.Decimal), "got %s", c.Value)
+
+	// The same value through float64 does not survive
+	f, _ := score.Float64()
+	assert.False(t, score.Equal(decimal.NewFromFloat(f)))
+}
+
+func TestInt64Cursor_RoundTripsExactly(t *testing.T) {
+	t.Parallel()
+
+	type seqCursor struct {
+		Seq int64 `json:"seq"`
+	}
+	want := int64(1<<62 + 1)
+
+	c, err := pagination.DecodeCursor[seqCursor](pagination.EncodeCursor(&seqCursor{Seq: want}))
+	require.NoError(t, err)
+	assert.Equal(t, want, c.Seq)
+}
+
+func TestKeyset_WhereBindsCursorValue(t *testing.T) {
+	t.Parallel()
+
+	ks := pagination.NewKeyset(pagination.Col("score", pagination.Desc), pagination.Col("id", pagination.Desc))
+	score := pagination.NewDecimal(decimal.RequireFromString("0.00000001"))
+
+	_, args, err := ks.Where(score, "x").ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, []any{"0.00000001", "x"}, args)
+}
+
+// TestDecimalCursor_NoRowsLostAtBoundary pages through scores that differ only
+// in the 8th decimal place, applying the keyset condition in memory.
+func TestDecimalCursor_NoRowsLostAtBoundary(t *testing.T) {
+	t.Parallel()
+
+	type row struct {
+		Score decimal.Decimal
+		ID    string
+	}
+
+	// Sorted by score DESC, id DESC
+	base := decimal.RequireFromString("99999999999.99999990")
+	var rows []row
+	for i := 9; i >= 0; i-- {
+		rows = append(rows, row{Score: base.Add(decimal.New(int64(i), -8)), ID: fmt.Sprintf("r%d", i)})
+	}
+
+	after := func(r row, c *pagination.DecimalCursor) bool {
+		cmp := r.Score.Cmp(c.Value.Decimal)
+		return cmp < 0 || (cmp == 0 && r.ID < c.ID)
+	}
+
+	var (
+		seen   []string
+		cursor string
+	)
+	for page := 0; page < len(rows)+1; page++ {
+		c, err := pagination.DecodeCursor[pagination.DecimalCursor](cursor)
+		require.NoError(t, err)
+
+		var fetched []row
+		for _, r := range rows {
+			if (c == nil || after(r, c)) && len(fetched) < pagination.FetchLimit(3) {
+				fetched = append(fetched, r)
+			}
+		}
+
+		items, next := pagination.Paginate(fetched, 3, func(r row) pagination.DecimalCursor {
+			return pagination.DecimalCursor{Value: pagination.NewDecimal(r.Score), ID: r.ID}
+		})
+		for _, r := range items {
+			seen = append(seen, r.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, []string{"r9", "r8", "r7", "r6", "r5", "r4", "r3", "r2", "r1", "r0"}, seen)
+}
+
+// ---------- HTTP Helper Tests ----------
+
+func TestFromRequest(t *testing.T) {
+	t.Parallel()
+
+	cursor := pagination.EncodeCursor(&idCursor{ID: 5})
+	limits := pagination.Limits{Default: 20, Max: 50}
+
+	tests := []struct {
+		name  string
+		query string
+		want  pagination.PageRequest
+	}{
+		{"defaults", "", pagination.PageRequest{Limit: 20, Direction: pagination.Forward}},
+		{"explicit limit", "limit=10", pagination.PageRequest{Limit: 10, Direction: pagination.Forward}},
+		{"clamped to max", "limit=500", pagination.PageRequest{Limit: 50, Direction: pagination.Forward}},
+		{"cursor", "cursor=" + cursor, pagination.PageRequest{Cursor: cursor, Limit: 20, Direction: pagination.Forward}},
+		{"backward", "direction=prev&cursor=" + cursor, pagination.PageRequest{Cursor: cursor, Limit: 20, Direction: pagination.Backward}},
+		{"forward", "direction=next", pagination.PageRequest{Limit: 20, Direction: pagination.Forward}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+			got, err := pagination.FromRequest(r, limits)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromRequest_ZeroLimits(t *testing.T) {
+	t.Parallel()
+
+	got, err := pagination.FromRequest(httptest.NewRequest(http.MethodGet, "/users", nil), pagination.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, 20, got.Limit)
+
+	got, err = pagination.FromRequest(httptest.NewRequest(http.MethodGet, "/users?limit=1000", nil), pagination.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, 100, got.Limit)
+}
+
+func TestFromRequest_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     string
+		wantParam string
+	}{
+		{"non-numeric limit", "limit=abc", "limit"},
+		{"zero limit", "limit=0", "limit"},
+		{"negative limit", "limit=-5", "limit"},
+		{"unknown direction", "direction=sideways", "direction"},
+		{"malformed cursor", "cursor=%25%25%25", "cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+			_, err := pagination.FromRequest(r, pagination.Limits{})
+			require.Error(t, err)
+
+			var reqErr *pagination.RequestError
+			require.True(t, errors.As(err, &reqErr))
+			assert.Equal(t, tt.wantParam, reqErr.Param)
+			assert.ErrorIs(t, err, errs.ErrValidation)
+			assert.Equal(t, http.StatusBadRequest, errs.HTTPStatus(err))
+		})
+	}
+}
+
+func TestPageResponse_WithLinks(t *testing.T) {
+	t.Parallel()
+
+	resp := pagination.NewBidirectionalPageResponse([]int{1, 2}, "NEXT", "PREV").
+		WithLinks("https://api.example.com/users?status=active&limit=2&cursor=OLD&direction=prev")
+	require.NotNil(t, resp.Links)
+
+	next, err := url.Parse(resp.Links.Next)
+	require.NoError(t, err)
+	assert.Equal(t, "/users", next.Path)
+	assert.Equal(t, url.Values{"status": {"active"}, "limit": {"2"}, "cursor": {"NEXT"}}, next.Query())
+
+	prev, err := url.Parse(resp.Links.Prev)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"status": {"active"}, "limit": {"2"}, "cursor": {"PREV"}, "direction": {"prev"}}, prev.Query())
+
+	assert.Equal(t,
+		"<"+resp.Links.Next+`>; rel="next", <`+resp.Links.Prev+`>; rel="prev"`,
+		resp.Links.Header(),
+	)
+}
+
+func TestPageResponse_WithLinks_LastPage(t *testing.T) {
+	t.Parallel()
+
+	resp := pagination.NewPageResponse([]int{1}, "").WithLinks("https://api.example.com/users")
+	assert.Nil(t, resp.Links)
+}
+
+// ---------- Offset Pagination Tests ----------
+
+func TestNewOffsetResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		items          int
+		total          int64
+		limit, offset  int
+		wantPage       int
+		wantTotalPages int
+		wantNext       bool
+		wantPrev       bool
+	}{
+		{"empty", 0, 0, 10, 0, 1, 0, false, false},
+		{"single partial page", 3, 3, 10, 0, 1, 1, false, false},
+		{"first of exact multiple", 10, 30, 10, 0, 1, 3, true, false},
+		{"middle of exact multiple", 10, 30, 10, 10, 2, 3, true, true},
+		{"last of exact multiple", 10, 30, 10, 20, 3, 3, false, true},
+		{"last partial page", 5, 25, 10, 20, 3, 3, false, true},
+		{"past the end", 0, 25, 10, 40, 5, 3, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := pagination.NewOffsetResponse(make([]int, tt.items), tt.total, tt.limit, tt.offset)
+			assert.Equal(t, tt.wantPage, resp.Page, "page")
+			assert.Equal(t, tt.wantTotalPages, resp.TotalPages, "total pages")
+			assert.Equal(t, tt.wantNext, resp.HasNext, "has next")
+			assert.Equal(t, tt.wantPrev, resp.HasPrev, "has prev")
+		})
+	}
+}
+
+func TestOffsetRequest_Normalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   pagination.OffsetRequest
+		want pagination.OffsetRequest
+	}{
+		{"defaults", pagination.OffsetRequest{}, pagination.OffsetRequest{Limit: 20}},
+		{"kept", pagination.OffsetRequest{Limit: 30, Offset: 60}, pagination.OffsetRequest{Limit: 30, Offset: 60}},
+		{"clamped", pagination.OffsetRequest{Limit: 500}, pagination.OffsetRequest{Limit: 50}},
+		{"negative", pagination.OffsetRequest{Limit: -1, Offset: -10}, pagination.OffsetRequest{Limit: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := tt.in
+			req.Normalize(50)
+			assert.Equal(t, tt.want, req)
+		})
+	}
+
+	req := pagination.OffsetRequest{}
+	req.Normalize(10)
+	assert.Equal(t, 10, req.Limit, "default never exceeds max")
+}
+
+func TestToOffsetLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		page, perPage         int
+		wantOffset, wantLimit int
+	}{
+		{1, 10, 0, 10},
+		{3, 10, 20, 10},
+		{0, 10, 0, 10},
+		{-2, 10, 0, 10},
+		{2, 0, 20, 20},
+	}
+
+	for _, tt := range tests {
+		offset, limit := pagination.ToOffsetLimit(tt.page, tt.perPage)
+		assert.Equal(t, tt.wantOffset, offset, "page %d perPage %d", tt.page, tt.perPage)
+		assert.Equal(t, tt.wantLimit, limit, "page %d perPage %d", tt.page, tt.perPage)
+	}
+}
+
+// ---------- Iterate Tests ----------
+
+// pagedSource serves n ints in pages and counts fetches.
+type pagedSource struct {
+	n     int
+	calls int
+}
+
+func (s *pagedSource) fetch(_ context.Context, cursor *idCursor, limit int) ([]int, *idCursor, error) {
+	s.calls++
+
+	start := 0
+	if cursor != nil {
+		start = cursor.ID
+	}
+	end := min(start+limit, s.n)
+
+	items := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		items = append(items, i)
+	}
+	if end == s.n {
+		return items, nil, nil
+	}
+	return items, &idCursor{ID: end}, nil
+}
+
+func TestIterate_AllPages(t *testing.T) {
+	t.Parallel()
+
+	src := &pagedSource{n: 25}
+
+	var got []int
+	for item, err := range pagination.Iterate(context.Background(), src.fetch, 10) {
+		require.NoError(t, err)
+		got = append(got, item)
+	}
+
+	assert.Len(t, got, 25)
+	assert.Equal(t, 0, got[0])
+	assert.Equal(t, 24, got[24])
+	assert.Equal(t, 3, src.calls)
+}
+
+func TestIterate_BreakStopsFetching(t *testing.T) {
+	t.Parallel()
+
+	src := &pagedSource{n: 25}
+
+	for item, err := range pagination.Iterate(context.Background(), src.fetch, 10) {
+		require.NoError(t, err)
+		if item == 12 {
+			break
+		}
+	}
+
+	assert.Equal(t, 2, src.calls)
+}
+
+func TestIterate_FetchError(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	fetch := func(_ context.Context, cursor *idCursor, _ int) ([]int, *idCursor, error) {
+		if cursor != nil {
+			return nil, nil, boom
+		}
+		return []int{1, 2}, &idCursor{ID: 2}, nil
+	}
+
+	var (
+		got     []int
+		gotErrs []error
+	)
+	for item, err := range pagination.Iterate(context.Background(), fetch, 2) {
+		if err != nil {
+			gotErrs = append(gotErrs, err)
+			continue
+		}
+		got = append(got, item)
+	}
+
+	assert.Equal(t, []int{1, 2}, got)
+	require.Len(t, gotErrs, 1)
+	assert.ErrorIs(t, gotErrs[0], boom)
+}
+
+func TestIterate_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := &pagedSource{n: 25}
+
+	var (
+		got     []int
+		lastErr error
+	)
+	for item, err := range pagination.Iterate(ctx, src.fetch, 10) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		got = append(got, item)
+		if item == 4 {
+			cancel()
+		}
+	}
+
+	assert.Len(t, got, 5)
+	assert.ErrorIs(t, lastErr, context.Canceled)
+	assert.Equal(t, 1, src.calls)
+}
+
+func TestIterate_NoProgress(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	fetch := func(context.Context, *idCursor, int) ([]int, *idCursor, error) {
+		calls++
+		return []int{calls}, &idCursor{ID: 1}, nil
+	}
+
+	var lastErr error
+	for _, err := range pagination.Iterate(context.Background(), fetch, 1) {
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	assert.ErrorIs(t, lastErr, pagination.ErrNoProgress)
+	assert.Equal(t, 2, calls)
+}
+
+// ---------- Page State Tests ----------
+
+type userFilter struct {
+	Status string   `json:"status"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func TestState_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	filter := userFilter{Status: "active", Tags: []string{"a", "b"}}
+	token := pagination.EncodeState(filter, idCursor{ID: 42})
+	require.NotEmpty(t, token)
+
+	f, c, err := pagination.DecodeState[userFilter, idCursor](token)
+	require.NoError(t, err)
+	assert.Equal(t, filter, *f)
+	assert.Equal(t, 42, c.ID)
+}
+
+func TestState_NilCursor(t *testing.T) {
+	t.Parallel()
+
+	f, c, err := pagination.DecodeState[userFilter, idCursor](pagination.EncodeState(userFilter{Status: "new"}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "new", f.Status)
+	assert.Nil(t, c)
+}
+
+func TestState_Empty(t *testing.T) {
+	t.Parallel()
+
+	f, c, err := pagination.DecodeState[userFilter, idCursor]("")
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.Nil(t, c)
+}
+
+func TestState_LargePayloadIsCompressed(t *testing.T) {
+	t.Parallel()
+
+	tags := make([]string, 50)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("tag-%02d", i)
+	}
+	filter := userFilter{Status: "active", Tags: tags}
+
+	raw, err := json.Marshal(filter)
+	require.NoError(t, err)
+
+	token := pagination.EncodeState(filter, idCursor{ID: 1})
+	assert.Less(t, len(token), len(raw), "gzipped token smaller than the raw filter")
+
+	f, c, err := pagination.DecodeState[userFilter, idCursor](token)
+	require.NoError(t, err)
+	assert.Equal(t, filter, *f)
+	assert.Equal(t, 1, c.ID)
+}
+
+func TestState_GzipBombRejected(t *testing.T) {
+	t.Parallel()
+
+	// 16 MiB of JSON whitespace compresses to a token of a few KiB
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(bytes.Repeat([]byte(" "), 16<<20))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	token := base64.RawURLEncoding.EncodeToString(append([]byte{pagination.CursorVersion, 1}, buf.Bytes()...))
+	require.Less(t, len(token), 64<<10)
+
+	_, _, err = pagination.DecodeState[userFilter, idCursor](token)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds")
+}
+
+func TestState_OversizedNotEncoded(t *testing.T) {
+	t.Parallel()
+
+	filter := userFilter{Status: strings.Repeat("x", 64<<10)}
+	assert.Empty(t, pagination.EncodeState(filter, nil), "DecodeState would reject it")
+}
+
+func TestState_Signed(t *testing.T) {
+	t.Parallel()
+
+	key := pagination.WithSigningKey([]byte("secret"))
+	token := pagination.EncodeState(userFilter{Status: "active"}, idCursor{ID: 7}, key)
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		f, c, err := pagination.DecodeState[userFilter, idCursor](token, key)
+		require.NoError(t, err)
+		assert.Equal(t, "active", f.Status)
+		assert.Equal(t, 7, c.ID)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := pagination.DecodeState[userFilter, idCursor](token, pagination.WithSigningKey([]byte("other")))
+		assert.ErrorIs(t, err, pagination.ErrInvalidSignature)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := base64.RawURLEncoding.DecodeString(token)
+		require.NoError(t, err)
+		data[3] ^= 0xff
+
+		_, _, err = pagination.DecodeState[userFilter, idCursor](base64.RawURLEncoding.EncodeToString(data), key)
+		assert.ErrorIs(t, err, pagination.ErrInvalidSignature)
+	})
+
+	t.Run("unsigned token rejected", func(t *testing.T) {
+		t.Parallel()
+
+		unsigned := pagination.EncodeState(userFilter{Status: "active"}, idCursor{ID: 7})
+		_, _, err := pagination.DecodeState[userFilter, idCursor](unsigned, key)
+		assert.ErrorIs(t, err, pagination.ErrInvalidSignature)
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := pagination.DecodeState[userFilter, idCursor](token)
+		assert.ErrorIs(t, err, pagination.ErrInvalidSignature)
+	})
+}
+
+func TestState_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := pagination.DecodeState[userFilter, idCursor]("!!!")
+	require.Error(t, err)
+
+	other := base64.RawURLEncoding.EncodeToString([]byte{pagination.CursorVersion + 1, 0, '{', '}'})
+	_, _, err = pagination.DecodeState[userFilter, idCursor](other)
+	assert.ErrorIs(t, err, pagination.ErrCursorVersion)
+}
+
+func TestFilterHash(t *testing.T) {
+	t.Parallel()
+
+	a := pagination.FilterHash(userFilter{Status: "active"})
+	assert.Equal(t, a, pagination.FilterHash(userFilter{Status: "active"}))
+	assert.NotEqual(t, a, pagination.FilterHash(userFilter{Status: "blocked"}))
+}
+
+func TestCheckFilter(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, pagination.CheckFilter(userFilter{Status: "active"}, userFilter{Status: "active"}))
+
+	err := pagination.CheckFilter(userFilter{Status: "active"}, userFilter{Status: "blocked"})
+	assert.ErrorIs(t, err, pagination.ErrFilterMismatch)
+	assert.Equal(t, http.StatusBadRequest, errs.HTTPStatus(err))
+}
+
+// ---------- Response Mapping Tests ----------
+
+type user struct {
+	ID   string
+	Name string
+}
+
+type userResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func toUserResponse(u *user) userResponse {
+	return userResponse{ID: u.ID, Name: u.Name}
+}
+
+// handlerListResponse mirrors handler.ListResponse.
+type handlerListResponse[T any] struct {
+	Items      []T   `json:"items"`
+	TotalCount int64 `json:"total_count"`
+	Limit      int   `json:"limit"`
+	Offset     int   `json:"offset"`
+}
+
+func TestMapItems(t *testing.T) {
+	t.Parallel()
+
+	page := pagination.NewBidirectionalPageResponse(
+		[]*user{{ID: "1", Name: "Ann"}, {ID: "2", Name: "Bob"}},
+		"NEXT", "PREV",
+	).WithLinks("https://api.example.com/users")
+
+	got := pagination.MapItems(page, toUserResponse)
+
+	assert.Equal(t, []userResponse{{ID: "1", Name: "Ann"}, {ID: "2", Name: "Bob"}}, got.Items)
+	assert.Equal(t, "NEXT", got.NextCursor)
+	assert.Equal(t, "PREV", got.PrevCursor)
+	assert.True(t, got.HasMore)
+	assert.Equal(t, page.Links, got.Links)
+}
+
+func TestMapItems_Empty(t *testing.T) {
+	t.Parallel()
+
+	got := pagination.MapItems(pagination.NewPageResponse[*user](nil, ""), toUserResponse)
+	assert.NotNil(t, got.Items, "encodes as [] rather than null")
+	assert.Empty(t, got.Items)
+	assert.False(t, got.HasMore)
+}
+
+func TestToListResponse(t *testing.T) {
+	t.Parallel()
+
+	page := pagination.MapItems(
+		pagination.NewPageResponse([]*user{{ID: "1", Name: "Ann"}}, "NEXT"),
+		toUserResponse,
+	)
+
+	resp := handlerListResponse[userResponse](pagination.ToListResponse(page, 20))
+
+	assert.Equal(t, []userResponse{{ID: "1", Name: "Ann"}}, resp.Items)
+	assert.Equal(t, pagination.UnknownTotal, resp.TotalCount)
+	assert.Equal(t, 20, resp.Limit)
+	assert.Zero(t, resp.Offset)
+}
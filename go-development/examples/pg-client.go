@@ -11,12 +11,13 @@ package pg
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/avast/retry-go"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	apperrors "myapp/internal/errors"
 )
 
 // ---------- Types ----------
@@ -223,16 +224,12 @@ func (c *client) WithTx(ctx context.Context, txFunc TxFunc, isoLvl pgx.TxIsoLeve
 	)
 }
 
+// isRetryable classifies through the shared taxonomy: FromPG maps
+// SQLSTATE classes 08, 40, 53, 57, 58, connection failures and timeouts
+// to codes Retryable accepts, and callbacks can use MarkRetryable or
+// MarkPermanent to decide for themselves.
 func isRetryable(err error) bool {
-	s := err.Error()
-	return strings.Contains(s, "i/o timeout") ||
-		strings.Contains(s, "unexpected EOF") ||
-		strings.Contains(s, "SQLSTATE 08") || // Connection exception
-		strings.Contains(s, "SQLSTATE 40") || // Transaction rollback
-		strings.Contains(s, "SQLSTATE 53") || // Insufficient resources
-		strings.Contains(s, "SQLSTATE 57") || // Operator intervention
-		strings.Contains(s, "SQLSTATE 58") || // System error
-		strings.Contains(s, "connection refused")
+	return apperrors.Retryable(apperrors.FromPG(err, "transaction"))
 }
 
 // ---------- Usage Example ----------
@@ -12,19 +12,27 @@ import (
 	sq "github.com/Masterminds/squirrel"
 
 	"myapp/internal/models"
+	"myapp/internal/pagination"
 	"myapp/pkg/pg"
 )
 
 // ErrUserNotFound is returned when a user is not found.
 var ErrUserNotFound = errors.New("user not found")
 
+// AnyVersion makes Update and Delete unconditional. Real versions start
+// at 1, the column default:
+//
+//	ALTER TABLE users ADD COLUMN version bigint NOT NULL DEFAULT 1;
+const AnyVersion int64 = 0
+
 // Users defines the user repository interface.
 type Users interface {
 	FindByID(ctx context.Context, id string) (*models.User, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	Find(ctx context.Context, filter *models.UserFilter) ([]*models.User, error)
 	Save(ctx context.Context, users ...*models.User) error
-	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, user *models.User, version int64) error
+	Delete(ctx context.Context, id string, version int64) error
 }
 
 type userStorage struct {
@@ -92,7 +100,9 @@ func (s *userStorage) FindByEmail(ctx context.Context, email string) (*models.Us
 	return &user, nil
 }
 
-// Find returns users matching the filter.
+// Find returns users matching the filter, in filter.Sort order.
+// filter.Sort holds allow-listed columns (see handler.SortColumns), so
+// it is safe in ORDER BY; never build it from raw query parameters.
 func (s *userStorage) Find(ctx context.Context, filter *models.UserFilter) ([]*models.User, error) {
 	builder := sq.
 		Select(models.UserColumns()...).
@@ -106,6 +116,9 @@ func (s *userStorage) Find(ctx context.Context, filter *models.UserFilter) ([]*m
 		if filter.Email != nil {
 			builder = builder.Where(sq.Eq{"email": *filter.Email})
 		}
+		if len(filter.Sort) > 0 {
+			builder = builder.OrderBy(pagination.NewKeyset(filter.Sort...).OrderBy()...)
+		}
 		if filter.Limit > 0 {
 			builder = builder.Limit(uint64(filter.Limit))
 		}
@@ -147,11 +160,12 @@ func (s *userStorage) Save(ctx context.Context, users ...*models.User) error {
 
 	builder := sq.
 		Insert("users").
-		Columns(models.UserColumns()...).
+		Columns("id", "name", "email", "created_at", "updated_at"). // version defaults to 1
 		Suffix(`ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			email = EXCLUDED.email,
-			updated_at = EXCLUDED.updated_at`).
+			updated_at = EXCLUDED.updated_at,
+			version = users.version + 1`).
 		PlaceholderFormat(sq.Dollar)
 
 	for _, user := range users {
@@ -185,25 +199,80 @@ func (s *userStorage) Save(ctx context.Context, users ...*models.User) error {
 	return nil
 }
 
-// Delete removes a user by ID.
-func (s *userStorage) Delete(ctx context.Context, id string) error {
+// Update writes user's name and email and bumps its version, if the
+// stored user is still at version (compare-and-set):
+//
+//	UPDATE users SET ..., version = version + 1 WHERE id = $1 AND version = $2
+//
+// Zero rows affected means another write got there first, or the user
+// is gone: ErrVersionConflict. With AnyVersion, it is ErrUserNotFound.
+// On success user holds the new version and updated_at.
+func (s *userStorage) Update(ctx context.Context, user *models.User, version int64) error {
+	where := sq.Eq{"id": user.ID}
+	if version != AnyVersion {
+		where["version"] = version
+	}
+
+	sql, args, err := sq.
+		Update("users").
+		Set("name", user.Name).
+		Set("email", user.Email).
+		Set("updated_at", time.Now()).
+		Set("version", sq.Expr("version + 1")).
+		Where(where).
+		Suffix("RETURNING version, updated_at").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	err = s.client.QueryRow(ctx, sql, args...).Scan(&user.Version, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return s.noRows(version)
+		}
+		return fmt.Errorf("update user: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a user by ID if it is still at version; see Update.
+func (s *userStorage) Delete(ctx context.Context, id string, version int64) error {
+	where := sq.Eq{"id": id}
+	if version != AnyVersion {
+		where["version"] = version
+	}
+
 	sql, args, err := sq.
 		Delete("users").
-		Where(sq.Eq{"id": id}).
+		Where(where).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("build query: %w", err)
 	}
 
-	_, err = s.client.Exec(ctx, sql, args...)
+	tag, err := s.client.Exec(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("delete user: %w", err)
 	}
+	if tag.RowsAffected() == 0 && version != AnyVersion {
+		return s.noRows(version)
+	}
 
 	return nil
 }
 
+// noRows reports a conditional write that matched no row.
+func (s *userStorage) noRows(version int64) error {
+	if version == AnyVersion {
+		return ErrUserNotFound
+	}
+	return models.ErrVersionConflict
+}
+
 // Usage:
 //
 //	type UserService struct {
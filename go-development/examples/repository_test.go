@@ -171,7 +171,7 @@ func TestUserRepository_Delete(t *testing.T) {
 	user := createTestUser(t, pool)
 
 	// Delete user
-	err := repo.Delete(ctx, user.ID)
+	err := repo.Delete(ctx, user.ID, storage.AnyVersion)
 	require.NoError(t, err)
 
 	// Verify user is deleted
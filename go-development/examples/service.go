@@ -80,11 +80,17 @@ func (s *UserService) Create(ctx context.Context, name, email string) (*models.U
 	return user, nil
 }
 
-func (s *UserService) Update(ctx context.Context, id string, name, email string) (*models.User, error) {
+// Update writes the user if it is still at version (storage.AnyVersion
+// for any), and returns models.ErrVersionConflict otherwise, so two
+// clients editing the same user can't silently overwrite each other.
+func (s *UserService) Update(ctx context.Context, id string, version int64, name, email string) (*models.User, error) {
 	user, err := s.storage.Users().FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if version != storage.AnyVersion && user.Version != version {
+		return nil, models.ErrVersionConflict // stale before we even write
+	}
 
 	if name != "" {
 		user.Name = name
@@ -94,7 +100,7 @@ func (s *UserService) Update(ctx context.Context, id string, name, email string)
 	}
 
 	err = s.storage.ExecReadCommitted(ctx, func(ctx context.Context) error {
-		return s.storage.Users().Update(ctx, user)
+		return s.storage.Users().Update(ctx, user, version)
 	})
 	if err != nil {
 		return nil, err
@@ -103,8 +109,18 @@ func (s *UserService) Update(ctx context.Context, id string, name, email string)
 	return user, nil
 }
 
-func (s *UserService) Delete(ctx context.Context, id string) error {
+// Delete removes the user if it is still at version; see Update. A user
+// that doesn't exist is ErrUserNotFound, not a conflict.
+func (s *UserService) Delete(ctx context.Context, id string, version int64) error {
+	user, err := s.storage.Users().FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if version != storage.AnyVersion && user.Version != version {
+		return models.ErrVersionConflict
+	}
+
 	return s.storage.ExecReadCommitted(ctx, func(ctx context.Context) error {
-		return s.storage.Users().Delete(ctx, id)
+		return s.storage.Users().Delete(ctx, id, version)
 	})
 }
@@ -12,6 +12,7 @@ import (
 	"myapp/internal/common"
 	"myapp/internal/models"
 	"myapp/internal/services"
+	"myapp/internal/storage"
 )
 
 // Service tests use MOCKS for repositories.
@@ -54,8 +55,8 @@ func (m *MockUserRepository) Update(ctx context.Context, user *models.User) (*mo
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockUserRepository) Delete(ctx context.Context, id string, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
@@ -218,6 +219,7 @@ func TestUserService_Delete(t *testing.T) {
 	tests := []struct {
 		name      string
 		id        string
+		version   int64
 		setupMock func(*MockUserRepository)
 		wantErr   bool
 	}{
@@ -229,11 +231,22 @@ func TestUserService_Delete(t *testing.T) {
 				m.On("GetByID", mock.Anything, userID).
 					Return(&models.User{ID: userID}, nil)
 				// Then delete
-				m.On("Delete", mock.Anything, userID).
+				m.On("Delete", mock.Anything, userID, storage.AnyVersion).
 					Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:    "stale version",
+			id:      userID,
+			version: 1,
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", mock.Anything, userID).
+					Return(&models.User{ID: userID, Version: 2}, nil)
+				// No Delete: the version check fails first
+			},
+			wantErr: true,
+		},
 		{
 			name: "user not found",
 			id:   userID,
@@ -254,7 +267,7 @@ func TestUserService_Delete(t *testing.T) {
 			tt.setupMock(mockRepo)
 
 			svc := services.NewUserService(mockRepo, nil)
-			err := svc.Delete(ctx, tt.id)
+			err := svc.Delete(ctx, tt.id, tt.version)
 
 			if tt.wantErr {
 				require.Error(t, err)
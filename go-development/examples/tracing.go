@@ -1,27 +1,41 @@
 // Package tracing provides OpenTelemetry tracing setup and utilities.
 //
 // This example shows:
-// - TracerProvider setup with OTLP exporter
-// - HTTP middleware with chi router
+// - TracerProvider setup with OTLP (gRPC/HTTP) or stdout exporter
+// - HTTP middleware with chi router, spans named after route patterns
+// - RED metrics middleware labelled by chi route (tracing_metrics.go)
 // - Manual span creation
-// - Database tracing with otelpgx
+// - Traced HTTP client for outgoing calls (tracing_client.go)
+// - Database tracing with otelpgx, Redis via a go-redis hook (tracing_redis.go)
 package tracing
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/exaring/otelpgx"
+	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"myapp/internal/errs"
 )
 
 // ---------- Middleware Type ----------
@@ -31,31 +45,63 @@ type Middleware func(next http.Handler) http.Handler
 
 // ---------- Configuration ----------
 
+// Protocol selects the span exporter.
+type Protocol string
+
+const (
+	ProtocolGRPC   Protocol = "grpc"   // OTLP/gRPC, collector port 4317
+	ProtocolHTTP   Protocol = "http"   // OTLP/HTTP, collector port 4318
+	ProtocolStdout Protocol = "stdout" // pretty-printed spans, local dev without a collector
+)
+
 // Config configures the OpenTelemetry tracer.
 type Config struct {
 	ServiceName    string
 	ServiceVersion string
-	OTLPEndpoint   string  // e.g. "localhost:4317"
-	Insecure       bool    // true for local dev
-	SampleRate     float64 // 0.0 to 1.0, default 1.0 (100%)
+	Protocol       Protocol          // default grpc, or http when OTLPEndpoint has a scheme
+	OTLPEndpoint   string            // e.g. "localhost:4317" or "https://otel.example.com:4318"
+	Insecure       bool              // true for local dev; implied by an http:// endpoint
+	Headers        map[string]string // e.g. {"Authorization": "Bearer ..."} for hosted collectors
+	SampleRate     float64           // 0.0 to 1.0, default 1.0 (100%)
+	Output         io.Writer         // stdout protocol only, default os.Stdout
+
+	// Exporter replaces the one built from Protocol, e.g. an in-memory exporter in tests.
+	Exporter sdktrace.SpanExporter
+	// OnError receives errors from background exports, which are otherwise
+	// only printed by the OTel default handler. Usually a logger call.
+	OnError func(error)
 }
 
 // ---------- TracerProvider Setup ----------
 
-// InitTracer initializes OpenTelemetry tracer.
-// Returns a shutdown function to flush pending traces.
-func InitTracer(ctx context.Context, cfg Config) (func(context.Context) error, error) {
-	// Create OTLP exporter
-	opts := []otlptracegrpc.Option{
-		otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint),
-	}
-	if cfg.Insecure {
-		opts = append(opts, otlptracegrpc.WithInsecure())
-	}
+// Provider owns the tracer provider created by InitTracer.
+// Call Shutdown on exit, or the last batch of spans is lost.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
 
-	exporter, err := otlptracegrpc.New(ctx, opts...)
-	if err != nil {
-		return nil, err
+// ForceFlush exports all ended spans now, returning export errors.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	return p.tp.ForceFlush(ctx)
+}
+
+// Shutdown flushes pending spans and stops the exporter, giving up when ctx
+// is done. Export errors of the final flush are returned, not only passed
+// to OnError, so the caller can log them.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return errors.Join(p.tp.ForceFlush(ctx), p.tp.Shutdown(ctx))
+}
+
+// InitTracer initializes OpenTelemetry tracer.
+// Returns a Provider; call Shutdown with a deadline to flush pending traces.
+func InitTracer(ctx context.Context, cfg Config) (*Provider, error) {
+	// Create exporter for the configured protocol
+	exporter := cfg.Exporter
+	if exporter == nil {
+		var err error
+		if exporter, err = newExporter(ctx, cfg); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create resource with service info
@@ -70,24 +116,27 @@ func InitTracer(ctx context.Context, cfg Config) (func(context.Context) error, e
 	}
 
 	// Determine sampler
-	var sampler trace.Sampler
+	var sampler sdktrace.Sampler
 	if cfg.SampleRate <= 0 {
-		sampler = trace.NeverSample()
+		sampler = sdktrace.NeverSample()
 	} else if cfg.SampleRate >= 1.0 {
-		sampler = trace.AlwaysSample()
+		sampler = sdktrace.AlwaysSample()
 	} else {
-		sampler = trace.ParentBased(trace.TraceIDRatioBased(cfg.SampleRate))
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))
 	}
 
 	// Create TracerProvider
-	tp := trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
-		trace.WithResource(res),
-		trace.WithSampler(sampler),
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
 	)
 
 	// Register as global provider
 	otel.SetTracerProvider(tp)
+	if cfg.OnError != nil {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(cfg.OnError))
+	}
 
 	// Set up context propagation (W3C TraceContext)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
@@ -95,7 +144,63 @@ func InitTracer(ctx context.Context, cfg Config) (func(context.Context) error, e
 		propagation.Baggage{},
 	))
 
-	return tp.Shutdown, nil
+	return &Provider{tp: tp}, nil
+}
+
+// newExporter builds the span exporter for cfg.Protocol. An OTLPEndpoint
+// with an http:// or https:// scheme selects OTLP/HTTP unless Protocol is
+// set, and TLS follows the scheme.
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	endpoint, urlPath, insecure := cfg.OTLPEndpoint, "", cfg.Insecure
+	protocol := cfg.Protocol
+
+	if u, err := url.Parse(cfg.OTLPEndpoint); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		endpoint, urlPath, insecure = u.Host, strings.TrimSuffix(u.Path, "/"), u.Scheme == "http"
+		if protocol == "" {
+			protocol = ProtocolHTTP
+		}
+	}
+
+	switch protocol {
+	case "", ProtocolGRPC:
+		var opts []otlptracegrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+		}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+
+	case ProtocolHTTP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if urlPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(urlPath))
+		}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+
+	case ProtocolStdout:
+		out := cfg.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(out), stdouttrace.WithPrettyPrint())
+
+	default:
+		return nil, fmt.Errorf("tracing: unknown protocol %q", protocol)
+	}
 }
 
 // ---------- HTTP Middleware ----------
@@ -104,9 +209,13 @@ func InitTracer(ctx context.Context, cfg Config) (func(context.Context) error, e
 type Option func(*options)
 
 type options struct {
-	serverName   string
-	ignorePaths  map[string]struct{}
-	filterFunc   func(*http.Request) bool
+	serverName     string
+	ignorePaths    map[string]struct{}
+	filterFunc     func(*http.Request) bool
+	tracerProvider trace.TracerProvider
+	clientSpanName func(*http.Request) string
+	messageSizes   bool
+	baggageKeys    []string
 }
 
 func defaultOptions() *options {
@@ -145,7 +254,27 @@ func WithFilter(fn func(*http.Request) bool) Option {
 	}
 }
 
+// WithTracerProvider sets the provider for spans instead of the global one,
+// e.g. an SDK provider with a tracetest.SpanRecorder in tests.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// filter reports whether r should be traced (or measured).
+func (o *options) filter(r *http.Request) bool {
+	// Check custom filter first
+	if o.filterFunc != nil {
+		return o.filterFunc(r)
+	}
+	// Check ignore paths
+	_, ignored := o.ignorePaths[r.URL.Path]
+	return !ignored
+}
+
 // Handler returns OpenTelemetry tracing middleware.
+// Spans are named after the chi route, e.g. "GET /users/{userID}".
 // Usage: router.Use(tracing.Handler())
 func Handler(opts ...Option) Middleware {
 	cfg := defaultOptions()
@@ -153,24 +282,45 @@ func Handler(opts ...Option) Middleware {
 		opt(cfg)
 	}
 
-	// Build filter function
-	filter := func(r *http.Request) bool {
-		// Check custom filter first
-		if cfg.filterFunc != nil {
-			return cfg.filterFunc(r)
-		}
-		// Check ignore paths
-		_, ignored := cfg.ignorePaths[r.URL.Path]
-		return !ignored
+	otelOpts := []otelhttp.Option{otelhttp.WithFilter(cfg.filter)}
+	if cfg.tracerProvider != nil {
+		otelOpts = append(otelOpts, otelhttp.WithTracerProvider(cfg.tracerProvider))
 	}
 
 	return func(next http.Handler) http.Handler {
-		return otelhttp.NewHandler(next, cfg.serverName,
-			otelhttp.WithFilter(filter),
-		)
+		handler := nameFromRoute(next)
+		if len(cfg.baggageKeys) > 0 {
+			handler = baggageToAttributes(handler, cfg.baggageKeys)
+		}
+		return otelhttp.NewHandler(handler, cfg.serverName, otelOpts...)
 	}
 }
 
+// nameFromRoute renames the server span once chi has routed the request,
+// using the route pattern rather than the raw path so span names stay
+// low-cardinality. Unmatched requests are named after the method alone.
+// Requires Handler to be mounted with router.Use, inside chi's context.
+func nameFromRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		span := trace.SpanFromContext(r.Context())
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || !span.IsRecording() {
+			return
+		}
+
+		pattern := rctx.RoutePattern()
+		if pattern == "" {
+			span.SetName(r.Method)
+			return
+		}
+
+		span.SetName(r.Method + " " + pattern)
+		span.SetAttributes(semconv.HTTPRoute(pattern))
+	})
+}
+
 // ---------- Database Setup ----------
 
 // NewTracedPool creates a pgxpool with OpenTelemetry tracing.
@@ -190,17 +340,109 @@ func NewTracedPool(ctx context.Context, connString string) (*pgxpool.Pool, error
 
 // StartSpan starts a new span and returns the context and span.
 // Always defer span.End() after calling this.
-func StartSpan(ctx context.Context, tracerName, spanName string) (context.Context, interface{ End(...trace.SpanEndOption) }) {
+func StartSpan(ctx context.Context, tracerName, spanName string) (context.Context, trace.Span) {
 	return otel.Tracer(tracerName).Start(ctx, spanName)
 }
 
-// RecordError records an error on the current span from context.
-func RecordError(ctx context.Context, err error) {
+// ErrorOption configures RecordError.
+type ErrorOption func(*errorOptions)
+
+type errorOptions struct {
+	code        codes.Code
+	description string
+	override    bool
+	attrs       []attribute.KeyValue
+	expected    []error
+}
+
+// WithStatus sets the span status instead of deriving it from the error.
+func WithStatus(code codes.Code, description string) ErrorOption {
+	return func(o *errorOptions) {
+		o.code, o.description, o.override = code, description, true
+	}
+}
+
+// WithErrorAttributes attaches attributes to the recorded exception event.
+func WithErrorAttributes(attrs ...attribute.KeyValue) ErrorOption {
+	return func(o *errorOptions) {
+		o.attrs = append(o.attrs, attrs...)
+	}
+}
+
+// WithExpected marks errors matching any target (errors.Is) as expected:
+// recorded on the span, but without an error status.
+func WithExpected(targets ...error) ErrorOption {
+	return func(o *errorOptions) {
+		o.expected = append(o.expected, targets...)
+	}
+}
+
+// RecordError records err on the current span from context and sets the
+// status from StatusFromError, so client errors (errs.ErrNotFound,
+// errs.ErrValidation, ...) don't mark the span as failed.
+func RecordError(ctx context.Context, err error, opts ...ErrorOption) {
 	span := trace.SpanFromContext(ctx)
-	if span.IsRecording() {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	o := &errorOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	span.RecordError(err, trace.WithAttributes(o.attrs...))
+
+	if o.override {
+		span.SetStatus(o.code, o.description)
+		return
+	}
+	for _, target := range o.expected {
+		if errors.Is(err, target) {
+			return
+		}
 	}
+	span.SetStatus(StatusFromError(err))
+}
+
+// StatusFromError maps err to a span status using the errs package:
+// errors the caller caused (4xx: not found, validation, conflict, auth)
+// leave the status unset, everything else (5xx, unclassified) is an error.
+func StatusFromError(err error) (codes.Code, string) {
+	if err == nil || errs.HTTPStatus(err) < http.StatusInternalServerError {
+		return codes.Unset, ""
+	}
+	return codes.Error, err.Error()
+}
+
+// WithSpan runs fn inside a new span, records and classifies the returned
+// error like RecordError, and ends the span. If fn panics, the span ends
+// with an error status before the panic continues.
+// Usage: return tracing.WithSpan(ctx, "order-service", "CreateOrder", func(ctx context.Context) error { ... })
+func WithSpan(ctx context.Context, tracerName, spanName string, fn func(ctx context.Context) error, attrs ...attribute.KeyValue) error {
+	_, err := WithSpanResult(ctx, tracerName, spanName, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, attrs...)
+	return err
+}
+
+// WithSpanResult is WithSpan for functions returning a value.
+// Usage: user, err := tracing.WithSpanResult(ctx, "user-service", "GetUser", func(ctx context.Context) (*User, error) { ... })
+func WithSpanResult[T any](ctx context.Context, tracerName, spanName string, fn func(ctx context.Context) (T, error), attrs ...attribute.KeyValue) (T, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, spanName, trace.WithAttributes(attrs...))
+	defer func() {
+		if rec := recover(); rec != nil {
+			span.RecordError(fmt.Errorf("panic: %v", rec), trace.WithStackTrace(true))
+			span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", rec))
+			span.End()
+			panic(rec)
+		}
+		span.End()
+	}()
+
+	result, err := fn(ctx)
+	RecordError(ctx, err)
+	return result, err
 }
 
 // SetAttributes sets attributes on the current span from context.
@@ -220,7 +462,7 @@ func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
 //	    defer stop()
 //
 //	    // Initialize tracer
-//	    shutdown, err := tracing.InitTracer(ctx, tracing.Config{
+//	    provider, err := tracing.InitTracer(ctx, tracing.Config{
 //	        ServiceName:    "my-service",
 //	        ServiceVersion: "1.0.0",
 //	        OTLPEndpoint:   os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
@@ -230,7 +472,14 @@ func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
 //	    if err != nil {
 //	        log.Fatal(err)
 //	    }
-//	    defer shutdown(ctx)
+//	    defer func() {
+//	        // ctx is already cancelled here; flush with a fresh deadline
+//	        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	        defer cancel()
+//	        if err := provider.Shutdown(ctx); err != nil {
+//	            log.Println("shutdown tracer:", err)
+//	        }
+//	    }()
 //
 //	    // Create traced database pool
 //	    pool, err := tracing.NewTracedPool(ctx, os.Getenv("DATABASE_URL"))
@@ -0,0 +1,104 @@
+// Package tracing provides W3C baggage helpers for cross-service context.
+// Place in: internal/tracing/baggage.go
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// W3C baggage limits.
+const (
+	maxBaggageMembers = 64
+	maxBaggageBytes   = 8192
+)
+
+// SetBaggage returns a copy of ctx with the key/value pairs added to its
+// baggage, which InitTracer's propagator sends on outgoing requests.
+// Keys must be RFC 7230 tokens; values are percent-encoded on the wire.
+// On error ctx is returned unchanged.
+// Usage: ctx, err = tracing.SetBaggage(ctx, "tenant_id", tenant.ID, "user_id", user.ID)
+func SetBaggage(ctx context.Context, kv ...string) (context.Context, error) {
+	if len(kv)%2 != 0 {
+		return ctx, fmt.Errorf("tracing: baggage needs key/value pairs, got %d strings", len(kv))
+	}
+
+	b := baggage.FromContext(ctx)
+	for i := 0; i < len(kv); i += 2 {
+		key, value := kv[i], kv[i+1]
+		if !isToken(key) {
+			return ctx, fmt.Errorf("tracing: invalid baggage key %q", key)
+		}
+
+		m, err := baggage.NewMemberRaw(key, value)
+		if err != nil {
+			return ctx, fmt.Errorf("tracing: baggage %q: %w", key, err)
+		}
+		if b, err = b.SetMember(m); err != nil {
+			return ctx, fmt.Errorf("tracing: baggage %q: %w", key, err)
+		}
+	}
+
+	if n := b.Len(); n > maxBaggageMembers {
+		return ctx, fmt.Errorf("tracing: baggage has %d members, limit is %d", n, maxBaggageMembers)
+	}
+	if n := len(b.String()); n > maxBaggageBytes {
+		return ctx, fmt.Errorf("tracing: baggage is %d bytes, limit is %d", n, maxBaggageBytes)
+	}
+
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
+
+// BaggageValue returns the baggage value for key in ctx, or "" if unset.
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
+
+// isToken reports whether s is a non-empty RFC 7230 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '!', c == '#', c == '$', c == '%', c == '&', c == '\'', c == '*',
+			c == '+', c == '-', c == '.', c == '^', c == '_', c == '`', c == '|', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithBaggageToAttributes copies the given baggage entries from incoming
+// requests onto the server span as attributes of the same name, so spans
+// can be searched by tenant or user.
+// Usage: tracing.Handler(tracing.WithBaggageToAttributes("tenant_id", "user_id"))
+func WithBaggageToAttributes(keys ...string) Option {
+	return func(o *options) {
+		o.baggageKeys = keys
+	}
+}
+
+// baggageToAttributes runs inside the otelhttp handler, after it has
+// extracted the incoming baggage into the request context.
+func baggageToAttributes(next http.Handler, keys []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if span.IsRecording() {
+			b := baggage.FromContext(r.Context())
+			for _, key := range keys {
+				if m := b.Member(key); m.Key() != "" {
+					span.SetAttributes(attribute.String(key, m.Value()))
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"myapp/internal/tracing"
+)
+
+// ---------- SetBaggage Tests ----------
+
+func TestSetBaggage(t *testing.T) {
+	t.Parallel()
+
+	ctx, err := tracing.SetBaggage(context.Background(), "tenant_id", "acme", "user_id", "42")
+	require.NoError(t, err)
+
+	assert.Equal(t, "acme", tracing.BaggageValue(ctx, "tenant_id"))
+	assert.Equal(t, "42", tracing.BaggageValue(ctx, "user_id"))
+	assert.Empty(t, tracing.BaggageValue(ctx, "session_id"))
+
+	ctx, err = tracing.SetBaggage(ctx, "tenant_id", "globex")
+	require.NoError(t, err)
+	assert.Equal(t, "globex", tracing.BaggageValue(ctx, "tenant_id"), "later values win")
+	assert.Equal(t, "42", tracing.BaggageValue(ctx, "user_id"), "existing entries kept")
+}
+
+func TestSetBaggage_Validation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		kv      []string
+		wantErr string
+	}{
+		{"odd arguments", []string{"tenant_id"}, "key/value pairs"},
+		{"empty key", []string{"", "acme"}, `invalid baggage key ""`},
+		{"key with space", []string{"tenant id", "acme"}, `invalid baggage key "tenant id"`},
+		{"key with separator", []string{"tenant=id", "acme"}, "invalid baggage key"},
+		{"invalid utf-8 value", []string{"tenant_id", "\xff"}, `baggage "tenant_id"`},
+		{"too many bytes", []string{"blob", strings.Repeat("x", 8192)}, "limit is 8192"},
+		{"too many members", manyMembers(65), "limit is 64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parent, err := tracing.SetBaggage(context.Background(), "request_source", "api")
+			require.NoError(t, err)
+
+			ctx, err := tracing.SetBaggage(parent, tt.kv...)
+
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, parent, ctx, "context unchanged on error")
+		})
+	}
+}
+
+func manyMembers(n int) []string {
+	kv := make([]string, 0, 2*n)
+	for i := range n {
+		kv = append(kv, "k"+strings.Repeat("x", i), "v")
+	}
+	return kv
+}
+
+// ---------- Propagation Tests ----------
+
+func TestHandler_BaggageToAttributes(t *testing.T) {
+	t.Parallel()
+
+	tp, exporter := exportingProvider(t)
+
+	var tenant string
+	router := chi.NewRouter()
+	router.Use(tracing.Handler(
+		tracing.WithTracerProvider(tp),
+		tracing.WithBaggageToAttributes("tenant_id", "user_id"),
+	))
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		tenant = tracing.BaggageValue(r.Context(), "tenant_id")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("baggage", "tenant_id=acme,session_id=s3cr3t")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "acme", tenant)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes, attribute.String("tenant_id", "acme"))
+	for _, kv := range spans[0].Attributes {
+		assert.NotEqual(t, attribute.Key("user_id"), kv.Key, "missing entries are not added")
+		assert.NotEqual(t, attribute.Key("session_id"), kv.Key, "only selected keys are copied")
+	}
+}
+
+func TestNewHTTPClient_PropagatesBaggage(t *testing.T) {
+	t.Parallel()
+
+	got := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get("baggage")
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, err := tracing.SetBaggage(context.Background(), "tenant_id", "acme corp")
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := tracing.NewHTTPClient(srv.Client()).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "tenant_id=acme%20corp", <-got)
+}
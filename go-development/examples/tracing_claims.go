@@ -0,0 +1,103 @@
+// Package tracing provides span attributes for the authenticated user and request ID.
+// Place in: internal/tracing/claims.go
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDKey is the span attribute holding chi's request ID. It matches
+// the request_id log field, so traces and logs can be joined either way.
+const RequestIDKey = attribute.Key("request_id")
+
+// Enduser is the authenticated caller recorded on server spans.
+type Enduser struct {
+	ID    string
+	Roles []string
+	Email string
+}
+
+// EnduserFunc returns the caller the auth middleware stored in ctx.
+// It keeps this package independent of the auth package.
+type EnduserFunc func(ctx context.Context) (Enduser, bool)
+
+// ClaimsOption configures WithClaimsAttributes.
+type ClaimsOption func(*claimsOptions)
+
+type claimsOptions struct {
+	email bool
+}
+
+// WithEmail also records enduser.email. Email is PII: enable it only
+// where your data policy allows storing it in the tracing backend.
+func WithEmail() ClaimsOption {
+	return func(o *claimsOptions) {
+		o.email = true
+	}
+}
+
+// WithClaimsAttributes sets enduser.id and enduser.role (roles joined with
+// ",") on the server span. Mount it after tracing.Handler and the auth
+// middleware; requests without claims pass through untouched.
+// Usage:
+//
+//	r.Use(tracing.WithClaimsAttributes(func(ctx context.Context) (tracing.Enduser, bool) {
+//	    c, ok := auth.ClaimsFromContext(ctx)
+//	    if !ok {
+//	        return tracing.Enduser{}, false
+//	    }
+//	    return tracing.Enduser{ID: c.UserID, Roles: c.Roles, Email: c.Email}, true
+//	}))
+func WithClaimsAttributes(enduser EnduserFunc, opts ...ClaimsOption) Middleware {
+	cfg := &claimsOptions{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			span := trace.SpanFromContext(r.Context())
+			if span.IsRecording() {
+				if user, ok := enduser(r.Context()); ok {
+					span.SetAttributes(enduserAttributes(user, cfg)...)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func enduserAttributes(user Enduser, cfg *claimsOptions) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if user.ID != "" {
+		attrs = append(attrs, semconv.EnduserID(user.ID))
+	}
+	if len(user.Roles) > 0 {
+		attrs = append(attrs, semconv.EnduserRole(strings.Join(user.Roles, ",")))
+	}
+	if cfg.email && user.Email != "" {
+		attrs = append(attrs, attribute.String("enduser.email", user.Email))
+	}
+	return attrs
+}
+
+// RequestAttributes sets the request ID from chi's middleware.RequestID on
+// the current span. Call it in handlers or middleware running after both
+// middleware.RequestID and tracing.Handler; it is a no-op without either.
+// Usage: tracing.RequestAttributes(r.Context())
+func RequestAttributes(ctx context.Context) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	if id := middleware.GetReqID(ctx); id != "" {
+		span.SetAttributes(RequestIDKey.String(id))
+	}
+}
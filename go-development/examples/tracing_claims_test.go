@@ -0,0 +1,140 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"myapp/internal/tracing"
+)
+
+// ---------- Test Helpers ----------
+
+type claimsKey struct{}
+
+// fakeAuth stands in for the auth middleware, storing user in the context.
+func fakeAuth(user *tracing.Enduser) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user != nil {
+				r = r.WithContext(context.WithValue(r.Context(), claimsKey{}, *user))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func enduserFromContext(ctx context.Context) (tracing.Enduser, bool) {
+	user, ok := ctx.Value(claimsKey{}).(tracing.Enduser)
+	return user, ok
+}
+
+// ---------- WithClaimsAttributes Tests ----------
+
+func TestWithClaimsAttributes(t *testing.T) {
+	t.Parallel()
+
+	ann := &tracing.Enduser{ID: "42", Roles: []string{"admin", "billing"}, Email: "ann@example.com"}
+
+	tests := []struct {
+		name      string
+		user      *tracing.Enduser
+		opts      []tracing.ClaimsOption
+		wantID    string
+		wantRole  string
+		wantEmail string
+	}{
+		{name: "id and roles", user: ann, wantID: "42", wantRole: "admin,billing"},
+		{name: "email when allowed", user: ann, opts: []tracing.ClaimsOption{tracing.WithEmail()},
+			wantID: "42", wantRole: "admin,billing", wantEmail: "ann@example.com"},
+		{name: "no roles", user: &tracing.Enduser{ID: "7"}, wantID: "7"},
+		{name: "anonymous request", user: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router, sr := tracedRouter(t)
+			router.Use(fakeAuth(tt.user))
+			router.Use(tracing.WithClaimsAttributes(enduserFromContext, tt.opts...))
+			router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+
+			for key, want := range map[string]string{
+				"enduser.id":    tt.wantID,
+				"enduser.role":  tt.wantRole,
+				"enduser.email": tt.wantEmail,
+			} {
+				got, ok := spanAttr(spans[0], attribute.Key(key))
+				if want == "" {
+					assert.False(t, ok, "%s not set", key)
+					continue
+				}
+				assert.Equal(t, want, got.AsString(), key)
+			}
+		})
+	}
+}
+
+func TestWithClaimsAttributes_WithoutSpan(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	handler := tracing.WithClaimsAttributes(func(ctx context.Context) (tracing.Enduser, bool) {
+		called = true
+		return tracing.Enduser{}, false
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.False(t, called, "claims are not read when nothing is recorded")
+}
+
+// ---------- RequestAttributes Tests ----------
+
+func TestRequestAttributes(t *testing.T) {
+	t.Parallel()
+
+	router, sr := tracedRouter(t)
+	router.Use(middleware.RequestID)
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		tracing.RequestAttributes(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+
+	got, ok := spanAttr(spans[0], tracing.RequestIDKey)
+	require.True(t, ok)
+	assert.Equal(t, "req-123", got.AsString())
+}
+
+func TestRequestAttributes_WithoutRequestID(t *testing.T) {
+	t.Parallel()
+
+	span := recordedSpan(t, func(ctx context.Context) {
+		tracing.RequestAttributes(ctx)
+	})
+
+	_, ok := spanAttr(span, tracing.RequestIDKey)
+	assert.False(t, ok)
+}
@@ -0,0 +1,123 @@
+// Package tracing provides a traced HTTP client for outgoing calls.
+// Place in: internal/tracing/client.go
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey string
+
+const routeCtxKey ctxKey = "client_route"
+
+// ContextWithRoute sets the route template of the next outgoing request,
+// e.g. "/users/{id}", used in the client span name instead of the raw path.
+func ContextWithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeCtxKey, route)
+}
+
+// routeFromContext returns the route template set by ContextWithRoute.
+func routeFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeCtxKey).(string)
+	return route
+}
+
+// WithClientSpanName sets how NewHTTPClient names client spans.
+// The default is method, host and route template: "GET users-api/users/{id}".
+func WithClientSpanName(fn func(*http.Request) string) Option {
+	return func(o *options) {
+		o.clientSpanName = fn
+	}
+}
+
+// WithMessageSizes records request and response body sizes on client spans
+// (http.request.body.size, http.response.body.size) when Content-Length is known.
+func WithMessageSizes() Option {
+	return func(o *options) {
+		o.messageSizes = true
+	}
+}
+
+// NewHTTPClient returns a copy of base whose transport creates a client span
+// per request and injects W3C trace headers with the global propagator, so
+// traces continue in the called service. base is not modified; nil means
+// http.DefaultClient. Uses WithTracerProvider, WithClientSpanName and
+// WithMessageSizes; server options are ignored.
+// Usage: client := tracing.NewHTTPClient(&http.Client{Timeout: 10 * time.Second})
+func NewHTTPClient(base *http.Client, opts ...Option) *http.Client {
+	cfg := defaultOptions()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if base == nil {
+		base = http.DefaultClient
+	}
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if cfg.messageSizes {
+		transport = &sizeTransport{base: transport}
+	}
+
+	spanName := cfg.clientSpanName
+	if spanName == nil {
+		spanName = clientSpanName
+	}
+
+	otelOpts := []otelhttp.Option{
+		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return spanName(r)
+		}),
+	}
+	if cfg.tracerProvider != nil {
+		otelOpts = append(otelOpts, otelhttp.WithTracerProvider(cfg.tracerProvider))
+	}
+
+	client := *base
+	client.Transport = otelhttp.NewTransport(transport, otelOpts...)
+	return &client
+}
+
+// clientSpanName names a client span after method, host and route template.
+// Without a template the path is left out, since it usually holds IDs.
+func clientSpanName(r *http.Request) string {
+	return r.Method + " " + r.URL.Host + routeFromContext(r.Context())
+}
+
+// InjectHeaders writes the trace context of ctx into h using the global
+// propagator, for requests not sent through NewHTTPClient (e.g. a
+// third-party SDK that accepts extra headers).
+func InjectHeaders(ctx context.Context, h http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// ---------- Message Sizes ----------
+
+// sizeTransport runs inside the otelhttp transport, where the request
+// context already holds the client span.
+type sizeTransport struct {
+	base http.RoundTripper
+}
+
+func (t *sizeTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(r.Context())
+	if r.ContentLength > 0 {
+		span.SetAttributes(semconv.HTTPRequestBodySize(int(r.ContentLength)))
+	}
+
+	resp, err := t.base.RoundTrip(r)
+	if err == nil && resp.ContentLength >= 0 {
+		span.SetAttributes(semconv.HTTPResponseBodySize(int(resp.ContentLength)))
+	}
+	return resp, err
+}
@@ -0,0 +1,187 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"myapp/internal/tracing"
+)
+
+// TestMain installs the W3C propagators InitTracer would set globally.
+func TestMain(m *testing.M) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	os.Exit(m.Run())
+}
+
+// ---------- Test Helpers ----------
+
+// traceparentServer records the traceparent header of each request.
+func traceparentServer(t *testing.T) (*httptest.Server, <-chan string) {
+	t.Helper()
+
+	got := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get("traceparent")
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, got
+}
+
+// ---------- HTTP Client Tests ----------
+
+func TestNewHTTPClient_PropagatesTraceparent(t *testing.T) {
+	t.Parallel()
+
+	tp, exporter := exportingProvider(t)
+	srv, got := traceparentServer(t)
+	client := tracing.NewHTTPClient(srv.Client(), tracing.WithTracerProvider(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "GetUser")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/users/42", nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	traceparent := <-got
+	require.NotEmpty(t, traceparent)
+	assert.Contains(t, traceparent, parent.SpanContext().TraceID().String())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2, "client span and its parent")
+
+	clientSpan := spans[0]
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), clientSpan.Parent.SpanID())
+	assert.Contains(t, traceparent, clientSpan.SpanContext.SpanID().String(), "server sees the client span as parent")
+}
+
+func TestNewHTTPClient_SpanName(t *testing.T) {
+	t.Parallel()
+
+	srv, got := traceparentServer(t)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	tests := []struct {
+		name string
+		ctx  func(context.Context) context.Context
+		opts []tracing.Option
+		want string
+	}{
+		{
+			name: "route template",
+			ctx:  func(ctx context.Context) context.Context { return tracing.ContextWithRoute(ctx, "/users/{id}") },
+			want: "GET " + host + "/users/{id}",
+		},
+		{
+			name: "no template omits path",
+			ctx:  func(ctx context.Context) context.Context { return ctx },
+			want: "GET " + host,
+		},
+		{
+			name: "custom formatter",
+			ctx:  func(ctx context.Context) context.Context { return ctx },
+			opts: []tracing.Option{tracing.WithClientSpanName(func(r *http.Request) string {
+				return "users-api " + r.Method
+			})},
+			want: "users-api GET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, exporter := exportingProvider(t)
+			client := tracing.NewHTTPClient(srv.Client(), append(tt.opts, tracing.WithTracerProvider(tp))...)
+
+			req, err := http.NewRequestWithContext(tt.ctx(context.Background()), http.MethodGet, srv.URL+"/users/42", nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			<-got
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.want, spans[0].Name)
+		})
+	}
+}
+
+func TestNewHTTPClient_MessageSizes(t *testing.T) {
+	t.Parallel()
+
+	tp, exporter := exportingProvider(t)
+	srv, got := traceparentServer(t)
+	client := tracing.NewHTTPClient(srv.Client(), tracing.WithTracerProvider(tp), tracing.WithMessageSizes())
+
+	resp, err := client.Post(srv.URL+"/users", "application/json", strings.NewReader(`{"name":"Ann"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	<-got
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	reqSize, ok := spanAttr(spans[0].Snapshot(), "http.request.body.size")
+	require.True(t, ok)
+	assert.Equal(t, int64(len(`{"name":"Ann"}`)), reqSize.AsInt64())
+
+	respSize, ok := spanAttr(spans[0].Snapshot(), "http.response.body.size")
+	require.True(t, ok)
+	assert.Equal(t, int64(len(`{"id":"42"}`)), respSize.AsInt64())
+}
+
+func TestNewHTTPClient_KeepsBase(t *testing.T) {
+	t.Parallel()
+
+	base := &http.Client{}
+	client := tracing.NewHTTPClient(base)
+
+	assert.Nil(t, base.Transport, "base client is not modified")
+	assert.NotNil(t, client.Transport)
+	assert.NotSame(t, base, client)
+	assert.NotNil(t, tracing.NewHTTPClient(nil).Transport)
+}
+
+// ---------- InjectHeaders Tests ----------
+
+func TestInjectHeaders(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := exportingProvider(t)
+	ctx, span := tp.Tracer("test").Start(context.Background(), "CallPartner")
+	defer span.End()
+
+	h := http.Header{}
+	tracing.InjectHeaders(ctx, h)
+
+	sc := span.SpanContext()
+	assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", h.Get("traceparent"))
+}
+
+func TestInjectHeaders_WithoutSpan(t *testing.T) {
+	t.Parallel()
+
+	h := http.Header{}
+	tracing.InjectHeaders(context.Background(), h)
+
+	assert.Empty(t, h.Get("traceparent"))
+}
@@ -0,0 +1,76 @@
+// Package tracing provides trace/log correlation for slog and zap.
+// Place in: internal/tracing/log.go
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Log attribute keys, the names log backends (Loki, Elastic) are usually
+// configured to link to traces.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// ---------- slog ----------
+
+// slogHandler adds trace_id and span_id to records logged with a context
+// carrying a recording span.
+type slogHandler struct {
+	inner slog.Handler
+}
+
+// SlogHandler wraps inner so every record logged with the *Context methods
+// (InfoContext, ErrorContext, ...) carries the active span's IDs.
+// Records logged without a context, or outside a span, pass through as-is.
+// Usage: slog.New(tracing.SlogHandler(slog.NewJSONHandler(os.Stdout, nil)))
+func SlogHandler(inner slog.Handler) slog.Handler {
+	return &slogHandler{inner: inner}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		sc := span.SpanContext()
+		record.AddAttrs(
+			slog.String(TraceIDKey, sc.TraceID().String()),
+			slog.String(SpanIDKey, sc.SpanID().String()),
+		)
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &slogHandler{inner: h.inner.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the wrapper; note the IDs then land inside the group.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{inner: h.inner.WithGroup(name)}
+}
+
+// ---------- zap ----------
+
+// ZapFields returns trace_id and span_id fields for the recording span in
+// ctx, or nil, so it can always be spread into a log call.
+// Usage: logger.Info("order created", append(tracing.ZapFields(ctx), zap.String("order_id", id))...)
+func ZapFields(ctx context.Context) []zap.Field {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	sc := span.SpanContext()
+	return []zap.Field{
+		zap.String(TraceIDKey, sc.TraceID().String()),
+		zap.String(SpanIDKey, sc.SpanID().String()),
+	}
+}
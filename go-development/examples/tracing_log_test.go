@@ -0,0 +1,139 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"myapp/internal/tracing"
+)
+
+// ---------- Test Helpers ----------
+
+func exportingProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	return tp, exporter
+}
+
+func decodeLogLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+// ---------- SlogHandler Tests ----------
+
+func TestSlogHandler_RequestCarriesTraceID(t *testing.T) {
+	t.Parallel()
+
+	tp, exporter := exportingProvider(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(tracing.SlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	router := chi.NewRouter()
+	router.Use(tracing.Handler(tracing.WithTracerProvider(tp)))
+	router.Get("/users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "user loaded", slog.String("user_id", chi.URLParam(r, "userID")))
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	line := decodeLogLine(t, &buf)
+	assert.Equal(t, "user loaded", line["msg"])
+	assert.Equal(t, "42", line["user_id"])
+	assert.Equal(t, spans[0].SpanContext.TraceID().String(), line[tracing.TraceIDKey])
+	assert.Equal(t, spans[0].SpanContext.SpanID().String(), line[tracing.SpanIDKey])
+}
+
+func TestSlogHandler_WithoutSpan(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(tracing.SlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	logger.InfoContext(context.Background(), "no span")
+
+	line := decodeLogLine(t, &buf)
+	assert.NotContains(t, line, tracing.TraceIDKey)
+	assert.NotContains(t, line, tracing.SpanIDKey)
+}
+
+func TestSlogHandler_WithAttrsKeepsIDs(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := exportingProvider(t)
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	var buf bytes.Buffer
+	logger := slog.New(tracing.SlogHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("component", "billing"))
+
+	logger.InfoContext(ctx, "charged")
+
+	line := decodeLogLine(t, &buf)
+	assert.Equal(t, "billing", line["component"])
+	assert.Equal(t, span.SpanContext().TraceID().String(), line[tracing.TraceIDKey])
+}
+
+func TestSlogHandler_RespectsLevel(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(tracing.SlogHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	logger.InfoContext(context.Background(), "dropped")
+
+	assert.Zero(t, buf.Len())
+}
+
+// ---------- ZapFields Tests ----------
+
+func TestZapFields(t *testing.T) {
+	t.Parallel()
+
+	tp, exporter := exportingProvider(t)
+
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "CreateOrder")
+	logger.Info("order created", tracing.ZapFields(ctx)...)
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, 1, logs.Len())
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, spans[0].SpanContext.TraceID().String(), fields[tracing.TraceIDKey])
+	assert.Equal(t, spans[0].SpanContext.SpanID().String(), fields[tracing.SpanIDKey])
+}
+
+func TestZapFields_WithoutSpan(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, tracing.ZapFields(context.Background()))
+}
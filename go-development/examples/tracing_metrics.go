@@ -0,0 +1,119 @@
+// Package tracing provides Prometheus RED metrics for HTTP handlers.
+// Place in: internal/tracing/metrics.go
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests no route matched (404/405), so scanners
+// probing random paths don't create a series per path.
+const unmatchedRoute = "unmatched"
+
+// knownMethods bounds the method label; anything else is "OTHER".
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// Metrics returns middleware recording request rate, errors and duration
+// labelled by chi route pattern ("/users/{userID}"), never the raw path.
+// Shares WithIgnorePaths/WithFilter with Handler. Registers its collectors
+// with reg, so call it once per registry.
+//
+// middleware.Metrics registers the same two series, plus sizes and
+// in-flight requests, so dashboards work with either; mounting both on one
+// registry panics at startup. Services built on backend.go use
+// middleware.Metrics; this one is for services that take only tracing.
+// Usage: router.Use(tracing.Handler(), tracing.Metrics(be.registry))
+func Metrics(reg prometheus.Registerer, opts ...Option) Middleware {
+	cfg := defaultOptions()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request duration by method and route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	reg.MustRegister(requests, duration)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.filter(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(ww, r)
+
+			// The pattern is only known once chi has routed the request
+			method, route := MethodLabel(r.Method), RouteLabel(r)
+			requests.WithLabelValues(method, route, strconv.Itoa(ww.status)).Inc()
+			duration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// MethodLabel returns method for the standard methods and "OTHER" for
+// anything else, bounding the cardinality of a method label.
+func MethodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "OTHER"
+}
+
+// RouteLabel returns the chi route pattern that matched r, or "unmatched".
+// It is set only once chi has routed the request, so call it after
+// next.ServeHTTP returns.
+func RouteLabel(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return unmatchedRoute
+	}
+	return rctx.RoutePattern()
+}
+
+// ---------- Response Writer ----------
+
+// responseWriter captures the status code written by the handler.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach Flush and friends through
+// this wrapper, and through otelhttp's when Handler runs first.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
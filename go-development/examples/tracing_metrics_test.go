@@ -0,0 +1,136 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/tracing"
+)
+
+// ---------- Test Helpers ----------
+
+func metricsRouter(reg prometheus.Registerer, opts ...tracing.Option) http.Handler {
+	router := chi.NewRouter()
+	router.Use(tracing.Metrics(reg, opts...))
+
+	router.Get("/users/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "userID") == "missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	router.Route("/orders", func(r chi.Router) {
+		r.Post("/", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+	router.Get("/metrics", func(w http.ResponseWriter, _ *http.Request) {})
+
+	return router
+}
+
+func serve(h http.Handler, method, path string) {
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+// ---------- Metrics Tests ----------
+
+func TestMetrics_RouteLabels(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	h := metricsRouter(reg)
+
+	serve(h, http.MethodGet, "/users/3f0c9a")
+	serve(h, http.MethodGet, "/users/7b21e4")
+	serve(h, http.MethodGet, "/users/missing")
+	serve(h, http.MethodPost, "/orders/")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/users/{userID}",status="200"} 2
+http_requests_total{method="GET",route="/users/{userID}",status="404"} 1
+http_requests_total{method="POST",route="/orders",status="201"} 1
+`), "http_requests_total"))
+
+	series, err := testutil.GatherAndCount(reg, "http_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, series, "one histogram per method and route")
+}
+
+func TestMetrics_UnmatchedRoutes(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	h := metricsRouter(reg)
+
+	serve(h, http.MethodGet, "/wp-admin/setup.php")
+	serve(h, http.MethodGet, "/.env")
+	serve(h, "PROPFIND", "/users/1")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="unmatched",status="404"} 2
+http_requests_total{method="OTHER",route="unmatched",status="405"} 1
+`), "http_requests_total"))
+}
+
+func TestMetrics_IgnorePaths(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		reg := prometheus.NewRegistry()
+		h := metricsRouter(reg)
+
+		serve(h, http.MethodGet, "/metrics")
+
+		count, err := testutil.GatherAndCount(reg, "http_requests_total")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+
+		reg := prometheus.NewRegistry()
+		h := metricsRouter(reg, tracing.WithFilter(func(r *http.Request) bool {
+			return r.Method != http.MethodPost
+		}))
+
+		serve(h, http.MethodPost, "/orders/")
+		serve(h, http.MethodGet, "/users/1")
+
+		count, err := testutil.GatherAndCount(reg, "http_requests_total")
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestMetrics_KeepsFlusher(t *testing.T) {
+	t.Parallel()
+
+	router := chi.NewRouter()
+	router.Use(tracing.Metrics(prometheus.NewRegistry()))
+	router.Get("/stream", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("chunk"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.True(t, rec.Flushed)
+}
@@ -0,0 +1,129 @@
+// Package tracing provides OpenTelemetry spans for Redis commands and cache batches.
+// Place in: internal/tracing/redis.go
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentRedis adds a hook creating a client span per command and per
+// pipeline, with db.system=redis, the command names as db.statement (never
+// keys or values) and the server address. redis.Nil is not an error.
+// Only WithTracerProvider applies.
+// Usage: tracing.InstrumentRedis(rdb)
+func InstrumentRedis(client *redis.Client, opts ...Option) {
+	cfg := defaultOptions()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	tp := cfg.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	client.AddHook(&redisHook{
+		tracer: tp.Tracer("redis"),
+		attrs:  redisAttributes(client.Options()),
+	})
+}
+
+func redisAttributes(opts *redis.Options) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemRedis,
+		semconv.DBRedisDBIndex(opts.DB),
+	}
+
+	host, port, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return append(attrs, semconv.ServerAddress(opts.Addr))
+	}
+	attrs = append(attrs, semconv.ServerAddress(host))
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(p))
+	}
+	return attrs
+}
+
+// redisHook implements redis.Hook.
+type redisHook struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.start(ctx, cmd.FullName(), cmd.Name())
+		defer span.End()
+
+		err := next(ctx, cmd)
+		h.recordErr(span, err)
+		return err
+	}
+}
+
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+
+		ctx, span := h.start(ctx, "pipeline", strings.Join(names, " "),
+			attribute.Int("db.redis.num_cmd", len(cmds)))
+		defer span.End()
+
+		err := next(ctx, cmds)
+		h.recordErr(span, err)
+		return err
+	}
+}
+
+func (h *redisHook) start(ctx context.Context, name, statement string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return h.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(h.attrs...),
+		trace.WithAttributes(semconv.DBStatement(statement)),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// recordErr marks the span failed, ignoring cache misses (redis.Nil).
+func (h *redisHook) recordErr(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(StatusFromError(err))
+}
+
+// ---------- Cache Spans ----------
+
+// CacheSpan starts a span around a cache batch, e.g. ExecBatch, so its
+// pipeline span is grouped under the batch name. Always defer span.End().
+// Usage:
+//
+//	ctx, span := tracing.CacheSpan(ctx, name, len(reqs))
+//	defer span.End()
+func CacheSpan(ctx context.Context, batchName string, size int) (context.Context, trace.Span) {
+	return otel.Tracer("cache").Start(ctx, "cache "+batchName,
+		trace.WithAttributes(
+			attribute.String("cache.batch.name", batchName),
+			attribute.Int("cache.batch.size", size),
+		),
+	)
+}
@@ -0,0 +1,133 @@
+package tracing_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"myapp/internal/tracing"
+)
+
+// ---------- Test Helpers ----------
+
+// newRedis returns a client to a fresh miniredis with its connection
+// already open, so handshake commands (HELLO, CLIENT SETINFO) sent on
+// connect don't show up as spans.
+func newRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	require.NoError(t, rdb.Ping(context.Background()).Err())
+
+	return rdb, mr
+}
+
+// tracedRedis returns a client instrumented into an in-memory exporter.
+func tracedRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	rdb, mr := newRedis(t)
+	tp, exporter := exportingProvider(t)
+	tracing.InstrumentRedis(rdb, tracing.WithTracerProvider(tp))
+
+	return rdb, mr, exporter
+}
+
+// ---------- Redis Tests ----------
+
+func TestInstrumentRedis_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	rdb, mr, exporter := tracedRedis(t)
+	ctx := context.Background()
+
+	pipe := rdb.Pipeline()
+	pipe.Set(ctx, "user:42", `{"email":"ann@example.com"}`, 0)
+	pipe.Get(ctx, "user:42")
+	_, err := pipe.Exec(ctx)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "pipeline", span.Name)
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	host, _, err := net.SplitHostPort(mr.Addr())
+	require.NoError(t, err)
+
+	assert.Contains(t, span.Attributes, attribute.String("db.system", "redis"))
+	assert.Contains(t, span.Attributes, attribute.String("db.statement", "set get"), "command names only")
+	assert.Contains(t, span.Attributes, attribute.Int("db.redis.num_cmd", 2))
+	assert.Contains(t, span.Attributes, attribute.String("server.address", host))
+	for _, kv := range span.Attributes {
+		assert.NotContains(t, kv.Value.Emit(), "ann@example.com", "values never reach span attributes")
+	}
+}
+
+func TestInstrumentRedis_Command(t *testing.T) {
+	t.Parallel()
+
+	rdb, _, exporter := tracedRedis(t)
+
+	err := rdb.Get(context.Background(), "user:missing").Err()
+	require.ErrorIs(t, err, redis.Nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "get", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.String("db.statement", "get"))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code, "cache miss is not an error")
+}
+
+func TestInstrumentRedis_Error(t *testing.T) {
+	t.Parallel()
+
+	rdb, mr, exporter := tracedRedis(t)
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+
+	err := rdb.Get(context.Background(), "user:42").Err()
+	require.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+// ---------- CacheSpan Tests ----------
+
+func TestCacheSpan_WrapsPipeline(t *testing.T) {
+	sr := globalRecorder(t)
+
+	rdb, _ := newRedis(t)
+	tracing.InstrumentRedis(rdb)
+
+	ctx, span := tracing.CacheSpan(context.Background(), "get.users", 2)
+	pipe := rdb.Pipeline()
+	pipe.Get(ctx, "user:1")
+	pipe.Get(ctx, "user:2")
+	pipe.Exec(ctx)
+	span.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+
+	pipeline, batch := spans[0], spans[1]
+	assert.Equal(t, "cache get.users", batch.Name())
+	assert.Contains(t, batch.Attributes(), attribute.String("cache.batch.name", "get.users"))
+	assert.Contains(t, batch.Attributes(), attribute.Int("cache.batch.size", 2))
+	assert.Equal(t, batch.SpanContext().SpanID(), pipeline.Parent().SpanID())
+}
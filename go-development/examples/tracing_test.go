@@ -0,0 +1,491 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"myapp/internal/errs"
+	"myapp/internal/tracing"
+)
+
+// ---------- Test Helpers ----------
+
+// tracedRouter returns a router traced into a span recorder.
+func tracedRouter(t *testing.T) (*chi.Mux, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	router := chi.NewRouter()
+	router.Use(tracing.Handler(tracing.WithTracerProvider(tp)))
+
+	return router, sr
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// ---------- Handler Tests ----------
+
+func TestHandler_SpanNamedAfterRoute(t *testing.T) {
+	t.Parallel()
+
+	router, sr := tracedRouter(t)
+	router.Get("/users/{userID}", func(w http.ResponseWriter, _ *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/3f0c9a1e", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /users/{userID}", spans[0].Name())
+
+	route, ok := spanAttr(spans[0], "http.route")
+	require.True(t, ok)
+	assert.Equal(t, "/users/{userID}", route.AsString())
+}
+
+func TestHandler_SubrouterPattern(t *testing.T) {
+	t.Parallel()
+
+	router, sr := tracedRouter(t)
+	router.Route("/orgs/{orgID}", func(r chi.Router) {
+		r.Delete("/members/{userID}", func(w http.ResponseWriter, _ *http.Request) {})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/orgs/acme/members/42", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "DELETE /orgs/{orgID}/members/{userID}", spans[0].Name())
+}
+
+func TestHandler_UnmatchedRoute(t *testing.T) {
+	t.Parallel()
+
+	router, sr := tracedRouter(t)
+	router.Get("/users/{userID}", func(w http.ResponseWriter, _ *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/wp-admin/setup.php", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET", spans[0].Name(), "raw path never becomes the span name")
+
+	_, ok := spanAttr(spans[0], "http.route")
+	assert.False(t, ok)
+}
+
+func TestHandler_IgnoredPath(t *testing.T) {
+	t.Parallel()
+
+	router, sr := tracedRouter(t)
+	router.Get("/check/healthz/", func(w http.ResponseWriter, _ *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/check/healthz/", nil))
+
+	assert.Empty(t, sr.Ended())
+}
+
+// ---------- Span Helper Tests ----------
+
+func recordedSpan(t *testing.T, fn func(ctx context.Context)) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	fn(ctx)
+	span.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	return spans[0]
+}
+
+func TestStartSpan_ReturnsSpan(t *testing.T) {
+	t.Parallel()
+
+	ctx, span := tracing.StartSpan(context.Background(), "test", "op")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("order.id", "42"))
+	assert.Equal(t, span, trace.SpanFromContext(ctx))
+}
+
+func TestStatusFromError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{"nil", nil, codes.Unset},
+		{"not found", errs.NotFoundf("UserRepo.FindByID", "userID=42"), codes.Unset},
+		{"validation", errs.Validationf("UserService.Create", "email required"), codes.Unset},
+		{"conflict", errs.Conflictf("UserRepo.Create", "email taken"), codes.Unset},
+		{"unauthorized", errs.Wrap("Auth", errs.ErrUnauthorized), codes.Unset},
+		{"timeout", errs.Wrap("PaymentClient.Charge", errs.ErrTimeout), codes.Error},
+		{"unavailable", errs.Wrap("PaymentClient.Charge", errs.ErrUnavailable), codes.Error},
+		{"unclassified", errors.New("connection reset"), codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, desc := tracing.StatusFromError(tt.err)
+			assert.Equal(t, tt.wantCode, code)
+			if tt.wantCode == codes.Error {
+				assert.Equal(t, tt.err.Error(), desc)
+			} else {
+				assert.Empty(t, desc)
+			}
+		})
+	}
+}
+
+func TestRecordError_Classification(t *testing.T) {
+	t.Parallel()
+
+	t.Run("server error", func(t *testing.T) {
+		t.Parallel()
+
+		span := recordedSpan(t, func(ctx context.Context) {
+			tracing.RecordError(ctx, errors.New("connection reset"))
+		})
+
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "connection reset", span.Status().Description)
+		require.Len(t, span.Events(), 1)
+		assert.Equal(t, "exception", span.Events()[0].Name)
+	})
+
+	t.Run("not found is not a span error", func(t *testing.T) {
+		t.Parallel()
+
+		span := recordedSpan(t, func(ctx context.Context) {
+			tracing.RecordError(ctx, errs.NotFoundf("UserRepo.FindByID", "userID=42"))
+		})
+
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Len(t, span.Events(), 1, "still recorded as an event")
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		t.Parallel()
+
+		span := recordedSpan(t, func(ctx context.Context) {
+			tracing.RecordError(ctx, nil)
+		})
+
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Empty(t, span.Events())
+	})
+}
+
+func TestRecordError_Options(t *testing.T) {
+	t.Parallel()
+
+	errCacheMiss := errors.New("cache miss")
+
+	t.Run("expected", func(t *testing.T) {
+		t.Parallel()
+
+		span := recordedSpan(t, func(ctx context.Context) {
+			tracing.RecordError(ctx, fmt.Errorf("get user: %w", errCacheMiss), tracing.WithExpected(errCacheMiss))
+		})
+
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+	t.Run("status override", func(t *testing.T) {
+		t.Parallel()
+
+		span := recordedSpan(t, func(ctx context.Context) {
+			tracing.RecordError(ctx, errs.ErrNotFound, tracing.WithStatus(codes.Error, "tenant config missing"))
+		})
+
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "tenant config missing", span.Status().Description)
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		t.Parallel()
+
+		span := recordedSpan(t, func(ctx context.Context) {
+			tracing.RecordError(ctx, errors.New("declined"), tracing.WithErrorAttributes(attribute.String("payment.provider", "stripe")))
+		})
+
+		require.Len(t, span.Events(), 1)
+		assert.Contains(t, span.Events()[0].Attributes, attribute.String("payment.provider", "stripe"))
+	})
+}
+
+// ---------- InitTracer Tests ----------
+
+// restoreGlobals undoes the global provider and propagator InitTracer sets.
+// InitTracer tests don't run in parallel for the same reason.
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+
+	tp, prop := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(prop)
+	})
+}
+
+func TestInitTracer_Stdout(t *testing.T) {
+	restoreGlobals(t)
+
+	var buf bytes.Buffer
+	provider, err := tracing.InitTracer(context.Background(), tracing.Config{
+		ServiceName: "order-service",
+		Protocol:    tracing.ProtocolStdout,
+		SampleRate:  1,
+		Output:      &buf,
+	})
+	require.NoError(t, err)
+
+	_, span := tracing.StartSpan(context.Background(), "test", "CreateOrder")
+	traceID := span.SpanContext().TraceID().String()
+	span.End()
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	assert.Contains(t, buf.String(), "CreateOrder")
+	assert.Contains(t, buf.String(), traceID)
+}
+
+func TestInitTracer_Exporters(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  tracing.Config
+	}{
+		{"grpc default", tracing.Config{OTLPEndpoint: "localhost:4317", Insecure: true}},
+		{"grpc with headers", tracing.Config{
+			Protocol:     tracing.ProtocolGRPC,
+			OTLPEndpoint: "otel.example.com:4317",
+			Headers:      map[string]string{"Authorization": "Bearer token"},
+		}},
+		{"http", tracing.Config{Protocol: tracing.ProtocolHTTP, OTLPEndpoint: "localhost:4318", Insecure: true}},
+		{"http from scheme", tracing.Config{OTLPEndpoint: "http://localhost:4318"}},
+		{"https from scheme with path", tracing.Config{OTLPEndpoint: "https://otel.example.com/otlp/v1/traces"}},
+		{"grpc with scheme", tracing.Config{Protocol: tracing.ProtocolGRPC, OTLPEndpoint: "http://localhost:4317"}},
+		{"exporter defaults", tracing.Config{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreGlobals(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			tt.cfg.ServiceName = "order-service"
+			provider, err := tracing.InitTracer(ctx, tt.cfg)
+			require.NoError(t, err)
+			assert.NoError(t, provider.Shutdown(ctx), "no collector needed to shut down an idle exporter")
+		})
+	}
+}
+
+func TestInitTracer_UnknownProtocol(t *testing.T) {
+	restoreGlobals(t)
+
+	_, err := tracing.InitTracer(context.Background(), tracing.Config{Protocol: "zipkin"})
+
+	assert.EqualError(t, err, `tracing: unknown protocol "zipkin"`)
+}
+
+// ---------- WithSpan Tests ----------
+
+// globalRecorder installs a span recorder as the global provider, which
+// WithSpan and StartSpan use. Tests calling it don't run in parallel.
+func globalRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	restoreGlobals(t)
+
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	return sr
+}
+
+func TestWithSpan_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{"success", nil, codes.Unset},
+		{"expected error", errs.NotFoundf("OrderRepo.FindByID", "orderID=42"), codes.Unset},
+		{"failure", errors.New("connection reset"), codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := globalRecorder(t)
+
+			err := tracing.WithSpan(context.Background(), "order-service", "CreateOrder", func(ctx context.Context) error {
+				assert.True(t, trace.SpanFromContext(ctx).IsRecording(), "fn runs inside the span")
+				return tt.err
+			}, attribute.String("order.id", "42"))
+
+			assert.Equal(t, tt.err, err)
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "CreateOrder", spans[0].Name())
+			assert.Equal(t, tt.wantCode, spans[0].Status().Code)
+			assert.Contains(t, spans[0].Attributes(), attribute.String("order.id", "42"))
+		})
+	}
+}
+
+func TestWithSpanResult(t *testing.T) {
+	sr := globalRecorder(t)
+
+	total, err := tracing.WithSpanResult(context.Background(), "order-service", "SumOrder", func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, total)
+	require.Len(t, sr.Ended(), 1)
+	assert.Equal(t, "SumOrder", sr.Ended()[0].Name())
+}
+
+func TestWithSpan_Panic(t *testing.T) {
+	sr := globalRecorder(t)
+
+	assert.PanicsWithValue(t, "nil map", func() {
+		tracing.WithSpan(context.Background(), "order-service", "CreateOrder", func(ctx context.Context) error {
+			panic("nil map")
+		})
+	})
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1, "span ended before re-panicking")
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "panic: nil map", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+// ---------- Provider Tests ----------
+
+// keptExporter keeps exported spans after Shutdown, which the in-memory
+// exporter would otherwise clear before the test can inspect them.
+type keptExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keptExporter) Shutdown(context.Context) error { return nil }
+
+// failingExporter fails every export, or blocks until ctx is done.
+type failingExporter struct {
+	err   error
+	block bool
+}
+
+func (e failingExporter) ExportSpans(ctx context.Context, _ []sdktrace.ReadOnlySpan) error {
+	if e.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return e.err
+}
+
+func (failingExporter) Shutdown(context.Context) error { return nil }
+
+func initWithExporter(t *testing.T, exporter sdktrace.SpanExporter) *tracing.Provider {
+	t.Helper()
+	restoreGlobals(t)
+
+	provider, err := tracing.InitTracer(context.Background(), tracing.Config{
+		ServiceName: "order-service",
+		SampleRate:  1,
+		Exporter:    exporter,
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+func TestProvider_ShutdownExportsPendingSpans(t *testing.T) {
+	exporter := keptExporter{tracetest.NewInMemoryExporter()}
+	provider := initWithExporter(t, exporter)
+
+	_, span := tracing.StartSpan(context.Background(), "test", "LastRequest")
+	span.End()
+	require.Empty(t, exporter.GetSpans(), "batched, not exported yet")
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "LastRequest", spans[0].Name)
+}
+
+func TestProvider_ForceFlush(t *testing.T) {
+	exporter := keptExporter{tracetest.NewInMemoryExporter()}
+	provider := initWithExporter(t, exporter)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	_, span := tracing.StartSpan(context.Background(), "test", "Checkpoint")
+	span.End()
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	assert.Len(t, exporter.GetSpans(), 1)
+}
+
+func TestProvider_ShutdownReturnsExportError(t *testing.T) {
+	errExport := errors.New("collector unavailable")
+	provider := initWithExporter(t, failingExporter{err: errExport})
+
+	_, span := tracing.StartSpan(context.Background(), "test", "LastRequest")
+	span.End()
+
+	assert.ErrorIs(t, provider.Shutdown(context.Background()), errExport)
+}
+
+func TestProvider_ShutdownRespectsDeadline(t *testing.T) {
+	provider := initWithExporter(t, failingExporter{block: true})
+
+	_, span := tracing.StartSpan(context.Background(), "test", "LastRequest")
+	span.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := provider.Shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -5,15 +5,22 @@
 // - Panic recovery with stack trace logging
 // - Graceful shutdown
 // - In-memory queue for testing
+// - Delayed items and periodic jobs
 package worker
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // ---------- Queue Interface ----------
@@ -30,30 +37,138 @@ type Queue[T any] interface {
 	Fail(ctx context.Context, item *T, err error) error
 }
 
+// BatchQueue is a queue that can pop several items at once.
+// Workers with Concurrency > 1 use PopN when the queue implements it.
+type BatchQueue[T any] interface {
+	Queue[T]
+
+	// PopN returns up to n items, or an empty slice if none available.
+	PopN(ctx context.Context, n int) ([]*T, error)
+}
+
+// DepthQueue is a queue that can report how many items are pending.
+// AutoscalingPool samples it to size the pool.
+type DepthQueue[T any] interface {
+	Queue[T]
+
+	// Depth returns the number of pending items.
+	Depth(ctx context.Context) (int, error)
+}
+
+// RetryQueue is a queue whose Fail puts some items back for another
+// attempt. Workers ask it before Fail so that NewWithResult records only
+// an item's last attempt; Fail on any other queue is taken as final.
+type RetryQueue[T any] interface {
+	Queue[T]
+
+	// WillRetry reports whether Fail would requeue item rather than
+	// drop or dead-letter it. Call it before Fail.
+	WillRetry(item *T) bool
+}
+
+// DelayedQueue is a queue that can hold items until a given time.
+// Pop never returns an item before its time, so Worker needs no changes.
+type DelayedQueue[T any] interface {
+	Queue[T]
+
+	// PushAt schedules item to become available at the given time.
+	PushAt(ctx context.Context, item T, at time.Time) error
+}
+
+// ---------- Clock ----------
+
+// Clock abstracts time so schedules can be tested with a fake clock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the real wall clock.
+var SystemClock Clock = systemClock{}
+
 // ---------- Worker ----------
 
 // Handler processes a single work item.
 type Handler[T any] func(ctx context.Context, item T) error
 
+// Middleware wraps a Handler with cross-cutting behavior
+// (tracing, logging, metrics, rate limiting).
+type Middleware[T any] func(next Handler[T]) Handler[T]
+
 // Config configures the worker.
 type Config struct {
 	PollInterval time.Duration
+
+	// Concurrency is how many items a single worker handles in parallel.
+	// Values below 1 mean one item at a time.
+	Concurrency int
+
+	// DrainTimeout bounds how long in-flight handlers may run after shutdown
+	// is requested. Handler contexts are cancelled when it expires.
+	DrainTimeout time.Duration
+
+	// DrainQueue keeps processing after shutdown until the queue is empty
+	// or DrainTimeout expires.
+	DrainQueue bool
+
+	// StuckThreshold makes Pool report workers whose heartbeat is older
+	// than this. Must exceed PollInterval. Zero disables the watchdog.
+	StuckThreshold time.Duration
+
+	// MaxPanics dead-letters an item after it panicked this many times,
+	// bypassing remaining retries. Requires an item ID. Zero disables it.
+	MaxPanics int
+
+	// RetryIf reports whether a failed item may be retried, e.g.
+	// errs.Retryable. Items it rejects are dead-lettered at once. Nil
+	// retries everything except errors marked permanent with
+	// errs.MarkPermanent or errors.MarkPermanent.
+	RetryIf func(error) bool
+
+	// Metrics records worker metrics. Nil disables them.
+	Metrics *Metrics
 }
 
 // DefaultConfig returns default worker configuration.
 func DefaultConfig() Config {
 	return Config{
 		PollInterval: 1 * time.Second,
+		Concurrency:  1,
+		DrainTimeout: 10 * time.Second,
 	}
 }
 
 // Worker processes items from a queue.
 type Worker[T any] struct {
-	name    string
-	queue   Queue[T]
-	handler Handler[T]
-	logger  *slog.Logger
-	cfg     Config
+	name        string
+	queue       Queue[T]
+	handler     Handler[T]
+	middlewares []Middleware[T]
+	wrapped     Handler[T]
+	dedupe      *dedupe[T]
+	results     resultRecorder[T]
+	idFn        func(T) string
+	panics      *panicTracker
+	logger      *slog.Logger
+	cfg         Config
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	// Runtime state for Pause/Resume and Status
+	mu        sync.Mutex
+	resume    chan struct{} // non-nil while paused
+	state     WorkerState
+	busySince time.Time
+	heartbeat time.Time
+	processed atomic.Int64
+	failed    atomic.Int64
 }
 
 // New creates a new worker.
@@ -64,125 +179,476 @@ func New[T any](
 	logger *slog.Logger,
 	cfg Config,
 ) *Worker[T] {
-	return &Worker[T]{
+	w := &Worker[T]{
 		name:    name,
 		queue:   queue,
 		handler: handler,
+		wrapped: handler,
 		logger:  logger.With(slog.String("worker", name)),
 		cfg:     cfg,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		state:   StateIdle,
+		panics:  newPanicTracker(),
 	}
+	w.Use(Logging[T](w.logger))
+	return w
 }
 
-// Start begins processing items until context is cancelled.
+// Use appends middlewares around the handler.
+// The first middleware is the outermost; Logging is always installed first.
+// All middlewares run inside panic recovery. Call before Start.
+func (w *Worker[T]) Use(mw ...Middleware[T]) {
+	w.middlewares = append(w.middlewares, mw...)
+
+	h := w.handler
+	for i := len(w.middlewares) - 1; i >= 0; i-- {
+		h = w.middlewares[i](h)
+	}
+	w.wrapped = h
+}
+
+// UseDeduper skips items whose key was already processed within ttl.
+// The key is reserved before handling and marked after Complete. Call before Start.
+func (w *Worker[T]) UseDeduper(d Deduper, keyFn func(T) string, ttl time.Duration) {
+	w.dedupe = &dedupe[T]{deduper: d, keyFn: keyFn, ttl: ttl}
+}
+
+// Start begins processing items until context is cancelled or Stop is called.
+// Returns nil on clean shutdown.
 func (w *Worker[T]) Start(ctx context.Context) error {
 	w.logger.Info("starting worker")
+	defer close(w.done)
 
-	for {
-		select {
-		case <-ctx.Done():
-			w.logger.Info("worker stopped")
-			return ctx.Err()
-		default:
-			if err := w.processOne(ctx); err != nil {
-				// Log but don't exit on processing errors
-				w.logger.Error("processing failed",
-					slog.String("error", err.Error()),
-				)
+	// Handlers run on a context that outlives the shutdown signal
+	// and is cancelled only when the drain deadline expires.
+	runCtx, cancelRun := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRun()
+
+	go w.enforceDrainDeadline(ctx, runCtx, cancelRun)
+
+	for !w.stopping(ctx) {
+		w.beat()
+
+		if resume := w.pausedCh(); resume != nil {
+			w.setState(StatePaused)
+			select {
+			case <-ctx.Done():
+			case <-w.stop:
+			case <-resume:
+			}
+			w.setState(StateIdle)
+			continue
+		}
+
+		processed, err := w.processBatch(runCtx)
+		if err != nil {
+			// Log but don't exit on processing errors
+			w.logger.Error("processing failed",
+				slog.String("error", err.Error()),
+			)
+		}
+
+		if !processed {
+			// No items available, wait before polling again
+			select {
+			case <-ctx.Done():
+			case <-w.stop:
+			case <-time.After(w.cfg.PollInterval):
 			}
 		}
 	}
+
+	if w.cfg.DrainQueue {
+		w.drain(runCtx)
+	}
+
+	w.logger.Info("worker stopped")
+	return nil
+}
+
+// Stop stops popping new items and waits for Start to return.
+// Returns ctx.Err() if the worker does not finish in time.
+func (w *Worker[T]) Stop(ctx context.Context) error {
+	w.signalStop()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker[T]) signalStop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+func (w *Worker[T]) stopping(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// enforceDrainDeadline cancels handler contexts DrainTimeout after shutdown starts.
+func (w *Worker[T]) enforceDrainDeadline(ctx, runCtx context.Context, cancel context.CancelFunc) {
+	select {
+	case <-ctx.Done():
+	case <-w.stop:
+	case <-runCtx.Done():
+		return
+	}
+
+	timer := time.NewTimer(w.cfg.DrainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		cancel()
+	case <-runCtx.Done():
+	}
+}
+
+// drain processes remaining items until the queue is empty or the deadline hits.
+func (w *Worker[T]) drain(ctx context.Context) {
+	w.logger.Info("draining queue")
+
+	for ctx.Err() == nil {
+		processed, err := w.processBatch(ctx)
+		if err != nil {
+			w.logger.Error("processing failed",
+				slog.String("error", err.Error()),
+			)
+		}
+		if !processed {
+			return
+		}
+	}
+
+	w.logger.Warn("drain deadline exceeded")
 }
 
-func (w *Worker[T]) processOne(ctx context.Context) error {
-	item, err := w.queue.Pop(ctx)
+// processBatch pops up to Concurrency items and handles them in parallel,
+// completing or failing each independently.
+// Reports whether any item was popped.
+func (w *Worker[T]) processBatch(ctx context.Context) (bool, error) {
+	items, err := w.pop(ctx)
 	if err != nil {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return false, ctx.Err()
 		}
-		return fmt.Errorf("pop: %w", err)
+		return false, fmt.Errorf("pop: %w", err)
 	}
 
-	if item == nil {
-		// No items available, wait before polling again
-		time.Sleep(w.cfg.PollInterval)
-		return nil
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	w.setState(StateProcessing)
+	defer w.setState(StateIdle)
+
+	var g errgroup.Group
+	g.SetLimit(max(w.cfg.Concurrency, 1))
+
+	for _, item := range items {
+		g.Go(func() error {
+			w.beat()
+			defer w.beat()
+
+			if err := w.processOne(ctx, item); err != nil {
+				w.logger.Error("processing failed",
+					slog.String("error", err.Error()),
+				)
+			}
+			return nil
+		})
+	}
+
+	return true, g.Wait()
+}
+
+// pop returns up to Concurrency items, using PopN when the queue supports it.
+func (w *Worker[T]) pop(ctx context.Context) ([]*T, error) {
+	n := max(w.cfg.Concurrency, 1)
+
+	if bq, ok := w.queue.(BatchQueue[T]); ok && n > 1 {
+		return bq.PopN(ctx, n)
+	}
+
+	items := make([]*T, 0, n)
+	for len(items) < n {
+		item, err := w.queue.Pop(ctx)
+		if err != nil {
+			if len(items) > 0 {
+				// Handle what we already hold, surface the error on next pop
+				return items, nil
+			}
+			return nil, err
+		}
+		if item == nil {
+			break
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// processOne handles a single popped item and acks it.
+func (w *Worker[T]) processOne(ctx context.Context, item *T) error {
+	var dedupeKey string
+	if w.dedupe != nil {
+		dedupeKey = w.dedupe.keyFn(*item)
+
+		seen, err := w.dedupe.deduper.Seen(ctx, dedupeKey)
+		if err != nil {
+			// Fail open: a duplicate is better than a lost item
+			w.logger.Warn("dedupe check failed",
+				slog.String("key", dedupeKey),
+				slog.String("error", err.Error()),
+			)
+			dedupeKey = ""
+		}
+		if seen {
+			w.logger.Debug("skipping duplicate item", slog.String("key", dedupeKey))
+			w.cfg.Metrics.duplicate(w.name)
+			return w.queue.Complete(ctx, item)
+		}
 	}
 
 	// Process with panic recovery
-	start := time.Now()
 	handlerErr := w.safeHandle(ctx, item)
-	elapsed := time.Since(start)
 
 	if handlerErr != nil {
-		w.logger.Error("item processing failed",
-			slog.Duration("elapsed", elapsed),
-			slog.String("error", handlerErr.Error()),
-		)
-		if err := w.queue.Fail(ctx, item, handlerErr); err != nil {
+		w.failed.Add(1)
+		final, err := w.fail(ctx, item, handlerErr)
+		if err != nil {
 			w.logger.Error("failed to mark item as failed",
 				slog.String("error", err.Error()),
 			)
 		}
+		if final && err == nil {
+			// A retry may still succeed; only the last attempt is the outcome
+			w.recordResult(ctx, item, handlerErr)
+		}
+		w.releaseDedupe(ctx, dedupeKey)
 		return handlerErr
 	}
 
-	w.logger.Debug("item processed",
-		slog.Duration("elapsed", elapsed),
-	)
-
+	w.processed.Add(1)
+	if w.cfg.MaxPanics > 0 {
+		if id := w.itemID(item); id != "" {
+			w.panics.reset(id)
+		}
+	}
 	if err := w.queue.Complete(ctx, item); err != nil {
+		// The item will be redelivered; don't drop it as a duplicate
+		w.releaseDedupe(ctx, dedupeKey)
+		w.discardResult(item)
 		return fmt.Errorf("complete: %w", err)
 	}
+	w.recordResult(ctx, item, nil)
+
+	if dedupeKey != "" {
+		if err := w.dedupe.deduper.Mark(ctx, dedupeKey, w.dedupe.ttl); err != nil {
+			w.releaseDedupe(ctx, dedupeKey)
+			return fmt.Errorf("dedupe mark: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// releaseDedupe forgets a reservation taken by processOne, so every exit
+// short of Mark leaves the item free to be handled again.
+func (w *Worker[T]) releaseDedupe(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := w.dedupe.deduper.Forget(ctx, key); err != nil {
+		w.logger.Error("failed to release dedupe key",
+			slog.String("key", key),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// recordResult persists the outcome for workers created with NewWithResult.
+// It is called for final outcomes only: success, a permanent failure or
+// the last attempt.
+func (w *Worker[T]) recordResult(ctx context.Context, item *T, handlerErr error) {
+	if w.results == nil {
+		return
+	}
+	if err := w.results.record(ctx, *item, handlerErr); err != nil {
+		w.logger.Error("failed to store result",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// discardResult drops the value held for an item that wasn't acked.
+func (w *Worker[T]) discardResult(item *T) {
+	if w.results != nil {
+		w.results.discard(*item)
+	}
+}
+
 func (w *Worker[T]) safeHandle(ctx context.Context, item *T) (handlerErr error) {
 	defer func() {
 		if r := recover(); r != nil {
+			stack := debug.Stack()
 			w.logger.Error("panic in handler",
+				slog.String("item_id", w.itemID(item)),
 				slog.Any("panic", r),
-				slog.String("stack", string(debug.Stack())),
+				slog.String("stack", string(stack)),
 			)
-			handlerErr = fmt.Errorf("panic: %v", r)
+			w.cfg.Metrics.panicked(w.name)
+			handlerErr = &PanicError{Value: r, Stack: stack}
 		}
 	}()
 
-	return w.handler(ctx, *item)
+	return w.wrapped(ctx, *item)
 }
 
 // ---------- In-Memory Queue (for testing) ----------
 
+// ErrQueueFull is returned by Push on a full queue created with WithNonBlockingPush.
+var ErrQueueFull = errors.New("queue full")
+
+// ErrQueueClosed is returned by Push and PushAt after Close.
+var ErrQueueClosed = errors.New("queue closed")
+
+// MemoryQueueOption configures a MemoryQueue. The defaults keep the
+// simple behavior: Push blocks when full, Fail drops the item.
+type MemoryQueueOption func(*memoryQueueOptions)
+
+type memoryQueueOptions struct {
+	maxAttempts int
+	nonBlocking bool
+}
+
+// WithRequeueOnFail puts failed items back on the queue until they have
+// been attempted maxAttempts times, then dead-letters them.
+func WithRequeueOnFail(maxAttempts int) MemoryQueueOption {
+	return func(o *memoryQueueOptions) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// WithNonBlockingPush makes Push return ErrQueueFull instead of blocking.
+func WithNonBlockingPush() MemoryQueueOption {
+	return func(o *memoryQueueOptions) {
+		o.nonBlocking = true
+	}
+}
+
+// memoryEntry carries the attempt count alongside the item.
+type memoryEntry[T any] struct {
+	item     T
+	attempts int
+}
+
 // MemoryQueue is an in-memory queue for testing.
 type MemoryQueue[T any] struct {
-	items chan T
-	done  chan struct{}
-	mu    sync.Mutex
+	items   chan memoryEntry[T]
+	done    chan struct{}
+	opts    memoryQueueOptions
+	mu      sync.Mutex
+	delayed delayedHeap[T]
+	dead    []T
+	clock   Clock
+
+	// closeMu lets Close wait for in-flight Pushes, so no Push
+	// succeeds after Close returns.
+	closeMu sync.RWMutex
+	closed  bool
+
+	// inflight tracks attempts of popped items when requeueing is enabled.
+	inflight map[*T]int
+
+	completed atomic.Int64
+	failed    atomic.Int64
 }
 
 // NewMemoryQueue creates a new in-memory queue.
-func NewMemoryQueue[T any](size int) *MemoryQueue[T] {
-	return &MemoryQueue[T]{
-		items: make(chan T, size),
-		done:  make(chan struct{}),
+func NewMemoryQueue[T any](size int, opts ...MemoryQueueOption) *MemoryQueue[T] {
+	q := &MemoryQueue[T]{
+		items:    make(chan memoryEntry[T], size),
+		done:     make(chan struct{}),
+		clock:    SystemClock,
+		inflight: make(map[*T]int),
+	}
+	for _, opt := range opts {
+		opt(&q.opts)
 	}
+	return q
+}
+
+// WithClock sets the clock used to release delayed items.
+func (q *MemoryQueue[T]) WithClock(clock Clock) *MemoryQueue[T] {
+	q.clock = clock
+	return q
 }
 
 // Push adds an item to the queue.
 func (q *MemoryQueue[T]) Push(item T) error {
+	return q.push(memoryEntry[T]{item: item})
+}
+
+func (q *MemoryQueue[T]) push(e memoryEntry[T]) error {
+	q.closeMu.RLock()
+	defer q.closeMu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	if q.opts.nonBlocking {
+		select {
+		case q.items <- e:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+
 	select {
-	case q.items <- item:
+	case q.items <- e:
 		return nil
 	case <-q.done:
-		return fmt.Errorf("queue closed")
+		return ErrQueueClosed
+	}
+}
+
+// PushAt schedules an item to become available at the given time.
+func (q *MemoryQueue[T]) PushAt(_ context.Context, item T, at time.Time) error {
+	q.closeMu.RLock()
+	defer q.closeMu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
 	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	heap.Push(&q.delayed, delayedItem[T]{item: item, at: at})
+	return nil
 }
 
 // Pop returns the next item or nil if none available.
 func (q *MemoryQueue[T]) Pop(ctx context.Context) (*T, error) {
+	q.releaseDue()
+
 	select {
-	case item := <-q.items:
-		return &item, nil
+	case e := <-q.items:
+		return q.track(e), nil
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case <-q.done:
@@ -193,33 +659,180 @@ func (q *MemoryQueue[T]) Pop(ctx context.Context) (*T, error) {
 	}
 }
 
-// Complete marks item as processed (no-op for in-memory).
-func (q *MemoryQueue[T]) Complete(ctx context.Context, item *T) error {
+// PopN returns up to n ready items without blocking.
+func (q *MemoryQueue[T]) PopN(_ context.Context, n int) ([]*T, error) {
+	q.releaseDue()
+
+	items := make([]*T, 0, n)
+	for len(items) < n {
+		select {
+		case e := <-q.items:
+			items = append(items, q.track(e))
+		default:
+			return items, nil
+		}
+	}
+
+	return items, nil
+}
+
+// track returns the item to hand out, remembering its attempts if needed.
+func (q *MemoryQueue[T]) track(e memoryEntry[T]) *T {
+	item := &e.item
+	if q.opts.maxAttempts > 0 {
+		q.mu.Lock()
+		q.inflight[item] = e.attempts
+		q.mu.Unlock()
+	}
+	return item
+}
+
+// untrack forgets item and returns how many times it was attempted before.
+func (q *MemoryQueue[T]) untrack(item *T) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	attempts := q.inflight[item]
+	delete(q.inflight, item)
+	return attempts
+}
+
+// Complete marks item as processed.
+func (q *MemoryQueue[T]) Complete(_ context.Context, item *T) error {
+	q.untrack(item)
+	q.completed.Add(1)
 	return nil
 }
 
-// Fail marks item as failed (no-op for in-memory).
+// Fail marks item as failed. With WithRequeueOnFail the item is retried
+// until it runs out of attempts; otherwise it is dropped.
 func (q *MemoryQueue[T]) Fail(ctx context.Context, item *T, err error) error {
+	attempts := q.untrack(item) + 1
+	q.failed.Add(1)
+
+	if q.opts.maxAttempts <= 0 {
+		return nil
+	}
+	if attempts >= q.opts.maxAttempts {
+		return q.DeadLetter(ctx, item, err)
+	}
+
+	// Never block the worker that is also the consumer
+	select {
+	case q.items <- memoryEntry[T]{item: *item, attempts: attempts}:
+		return nil
+	default:
+		_ = q.DeadLetter(ctx, item, err)
+		return fmt.Errorf("requeue: %w", ErrQueueFull)
+	}
+}
+
+// WillRetry reports whether Fail would requeue item. Implements RetryQueue.
+func (q *MemoryQueue[T]) WillRetry(item *T) bool {
+	if q.opts.maxAttempts <= 0 {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.inflight[item]+1 < q.opts.maxAttempts
+}
+
+// DeadLetter parks item so tests can inspect it. Implements DeadLetterQueue.
+func (q *MemoryQueue[T]) DeadLetter(_ context.Context, item *T, _ error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inflight, item)
+	q.dead = append(q.dead, *item)
 	return nil
 }
 
-// Close closes the queue.
-func (q *MemoryQueue[T]) Close() {
+// DeadLetters returns the items parked by DeadLetter.
+func (q *MemoryQueue[T]) DeadLetters() []T {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+
+	return append([]T(nil), q.dead...)
+}
+
+// Completed returns how many items were completed.
+func (q *MemoryQueue[T]) Completed() int64 {
+	return q.completed.Load()
+}
+
+// Failed returns how many times Fail was called, including retried attempts.
+func (q *MemoryQueue[T]) Failed() int64 {
+	return q.failed.Load()
+}
+
+// Close closes the queue. Blocked Pushes return ErrQueueClosed and
+// no Push succeeds after Close returns.
+func (q *MemoryQueue[T]) Close() {
+	q.mu.Lock()
 	select {
 	case <-q.done:
 		// Already closed
 	default:
 		close(q.done)
 	}
+	q.mu.Unlock()
+
+	// Wait for in-flight Pushes to observe done
+	q.closeMu.Lock()
+	q.closed = true
+	q.closeMu.Unlock()
 }
 
-// Len returns the number of items in the queue.
+// Len returns the number of items ready to be popped.
 func (q *MemoryQueue[T]) Len() int {
 	return len(q.items)
 }
 
+// Depth returns the number of ready items. Implements DepthQueue.
+func (q *MemoryQueue[T]) Depth(context.Context) (int, error) {
+	return len(q.items), nil
+}
+
+// releaseDue moves delayed items whose time has come onto the ready channel.
+func (q *MemoryQueue[T]) releaseDue() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.clock.Now()
+	for q.delayed.Len() > 0 && !q.delayed[0].at.After(now) {
+		select {
+		case q.items <- memoryEntry[T]{item: q.delayed[0].item}:
+			heap.Pop(&q.delayed)
+		default:
+			// Ready channel is full, try again on next Pop
+			return
+		}
+	}
+}
+
+type delayedItem[T any] struct {
+	item T
+	at   time.Time
+}
+
+// delayedHeap is a min-heap of items ordered by release time.
+type delayedHeap[T any] []delayedItem[T]
+
+func (h delayedHeap[T]) Len() int           { return len(h) }
+func (h delayedHeap[T]) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h delayedHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *delayedHeap[T]) Push(x any) { *h = append(*h, x.(delayedItem[T])) }
+
+func (h *delayedHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
 // ---------- Worker Pool ----------
 
 // Pool manages multiple workers.
@@ -239,6 +852,7 @@ func NewPool[T any](
 	pool := &Pool[T]{
 		workers: make([]*Worker[T], count),
 	}
+	panics := newPanicTracker()
 
 	for i := 0; i < count; i++ {
 		pool.workers[i] = New(
@@ -248,12 +862,13 @@ func NewPool[T any](
 			logger,
 			cfg,
 		)
+		pool.workers[i].panics = panics
 	}
 
 	return pool
 }
 
-// Start begins all workers.
+// Start begins all workers, plus the watchdog when Config.StuckThreshold is set.
 func (p *Pool[T]) Start(ctx context.Context) {
 	for _, w := range p.workers {
 		p.wg.Add(1)
@@ -262,6 +877,50 @@ func (p *Pool[T]) Start(ctx context.Context) {
 			worker.Start(ctx)
 		}(w)
 	}
+
+	if len(p.workers) > 0 && p.workers[0].cfg.StuckThreshold > 0 {
+		stopped := make(chan struct{})
+		go func() {
+			p.wg.Wait()
+			close(stopped)
+		}()
+		go p.watchdog(ctx, stopped)
+	}
+}
+
+// Stop signals all workers to stop and waits for them to finish draining.
+// Returns ctx.Err() if they do not finish in time.
+func (p *Pool[T]) Stop(ctx context.Context) error {
+	for _, w := range p.workers {
+		w.signalStop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UseDeduper enables deduplication on every worker in the pool. Call before Start.
+func (p *Pool[T]) UseDeduper(d Deduper, keyFn func(T) string, ttl time.Duration) {
+	for _, w := range p.workers {
+		w.UseDeduper(d, keyFn, ttl)
+	}
+}
+
+// Use appends middlewares to every worker in the pool. Call before Start.
+func (p *Pool[T]) Use(mw ...Middleware[T]) {
+	for _, w := range p.workers {
+		w.Use(mw...)
+	}
 }
 
 // Wait blocks until all workers have stopped.
@@ -269,6 +928,64 @@ func (p *Pool[T]) Wait() {
 	p.wg.Wait()
 }
 
+// ---------- Periodic Job ----------
+
+// PeriodicJob runs a function on a fixed interval with random jitter.
+// Implements backend.BackgroundJob for periodic maintenance tasks.
+type PeriodicJob struct {
+	interval time.Duration
+	jitter   time.Duration
+	fn       func(ctx context.Context) error
+	logger   *slog.Logger
+	clock    Clock
+}
+
+// Every creates a job that calls fn every interval plus up to jitter.
+// Errors from fn are logged and do not stop the job.
+func Every(
+	interval, jitter time.Duration,
+	fn func(ctx context.Context) error,
+	logger *slog.Logger,
+) *PeriodicJob {
+	return &PeriodicJob{
+		interval: interval,
+		jitter:   jitter,
+		fn:       fn,
+		logger:   logger,
+		clock:    SystemClock,
+	}
+}
+
+// WithClock sets the clock used to wait between runs.
+func (j *PeriodicJob) WithClock(clock Clock) *PeriodicJob {
+	j.clock = clock
+	return j
+}
+
+// Run calls fn on schedule until context is cancelled.
+// Returns nil on clean shutdown.
+func (j *PeriodicJob) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		wait := j.interval
+		if j.jitter > 0 {
+			wait += rand.N(j.jitter)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-j.clock.After(wait):
+			if err := j.fn(ctx); err != nil {
+				j.logger.Error("periodic job failed",
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
 // ---------- Usage Example ----------
 
 // Example usage:
@@ -290,6 +1007,13 @@ func (p *Pool[T]) Wait() {
 //	    // Push some tasks
 //	    queue.Push(EmailTask{To: "user@example.com", Subject: "Hello", Body: "..."})
 //
+//	    // Schedule a reminder for tomorrow
+//	    queue.PushAt(ctx, EmailTask{To: "user@example.com", Subject: "Reminder"}, time.Now().Add(24*time.Hour))
+//
+//	    // Periodic maintenance (register in backend.initJobs)
+//	    cleanup := worker.Every(time.Hour, 5*time.Minute, sessionService.PurgeExpired, logger)
+//	    go cleanup.Run(ctx)
+//
 //	    // Wait for shutdown
 //	    pool.Wait()
 //	}
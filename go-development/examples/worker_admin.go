@@ -0,0 +1,168 @@
+// Package worker provides pause/resume control and runtime introspection.
+// Place in: internal/worker/admin.go
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ---------- Worker State ----------
+
+// WorkerState is what a worker is currently doing.
+type WorkerState string
+
+const (
+	StateIdle       WorkerState = "idle"
+	StateProcessing WorkerState = "processing"
+	StatePaused     WorkerState = "paused"
+)
+
+// WorkerStatus is a point-in-time snapshot of a worker.
+type WorkerStatus struct {
+	Name           string        `json:"name"`
+	State          WorkerState   `json:"state"`
+	CurrentItemAge time.Duration `json:"current_item_age_ns"`
+	Processed      int64         `json:"processed"`
+	Failed         int64         `json:"failed"`
+	LastHeartbeat  time.Time     `json:"last_heartbeat"`
+	Stuck          bool          `json:"stuck"`
+}
+
+// Pause makes the worker stop popping after the current items finish.
+func (w *Worker[T]) Pause() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.resume == nil {
+		w.resume = make(chan struct{})
+	}
+}
+
+// Resume lets a paused worker pop again.
+func (w *Worker[T]) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.resume != nil {
+		close(w.resume)
+		w.resume = nil
+	}
+}
+
+// Status returns a snapshot of the worker.
+func (w *Worker[T]) Status() WorkerStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := WorkerStatus{
+		Name:          w.name,
+		State:         w.state,
+		Processed:     w.processed.Load(),
+		Failed:        w.failed.Load(),
+		LastHeartbeat: w.heartbeat,
+	}
+	if w.state == StateProcessing {
+		status.CurrentItemAge = time.Since(w.busySince)
+	}
+	_, status.Stuck = w.stuckLocked()
+
+	return status
+}
+
+func (w *Worker[T]) pausedCh() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.resume
+}
+
+func (w *Worker[T]) setState(state WorkerState) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if state == StateProcessing {
+		w.busySince = time.Now()
+	}
+	w.state = state
+}
+
+// ---------- Pool Control ----------
+
+// Pause pauses every worker in the pool.
+func (p *Pool[T]) Pause() {
+	for _, w := range p.workers {
+		w.Pause()
+	}
+}
+
+// Resume resumes every worker in the pool.
+func (p *Pool[T]) Resume() {
+	for _, w := range p.workers {
+		w.Resume()
+	}
+}
+
+// Status returns a snapshot of every worker in the pool.
+func (p *Pool[T]) Status() []WorkerStatus {
+	statuses := make([]WorkerStatus, len(p.workers))
+	for i, w := range p.workers {
+		statuses[i] = w.Status()
+	}
+	return statuses
+}
+
+// ---------- Admin Handler ----------
+
+const AdminHandlerPathPrefix = "/admin/workers"
+
+// Controller is implemented by Pool regardless of item type.
+type Controller interface {
+	Pause()
+	Resume()
+	Status() []WorkerStatus
+}
+
+// AdminHandler exposes pool status and pause/resume over HTTP.
+// Mount on the monitor server, not the public API.
+type AdminHandler struct {
+	http.Handler
+	pool Controller
+}
+
+// NewAdminHandler creates an admin handler for the pool.
+func NewAdminHandler(pool Controller) *AdminHandler {
+	router := chi.NewRouter()
+	handler := &AdminHandler{
+		Handler: router,
+		pool:    pool,
+	}
+
+	router.Get("/", handler.handleStatus)
+	router.Post("/pause", handler.handlePause)
+	router.Post("/resume", handler.handleResume)
+
+	return handler
+}
+
+type statusResponse struct {
+	Workers []WorkerStatus `json:"workers"`
+}
+
+func (h *AdminHandler) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(statusResponse{Workers: h.pool.Status()})
+}
+
+func (h *AdminHandler) handlePause(w http.ResponseWriter, r *http.Request) {
+	h.pool.Pause()
+	h.handleStatus(w, r)
+}
+
+func (h *AdminHandler) handleResume(w http.ResponseWriter, r *http.Request) {
+	h.pool.Resume()
+	h.handleStatus(w, r)
+}
@@ -0,0 +1,256 @@
+// Package worker provides a pool that scales with queue depth.
+// Place in: internal/worker/autoscale.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ---------- Options ----------
+
+// AutoscaleOption configures the autoscaling pool.
+type AutoscaleOption func(*autoscaleOptions)
+
+type autoscaleOptions struct {
+	itemsPerWorker int
+	sampleInterval time.Duration
+	scaleDownAfter int
+}
+
+func defaultAutoscaleOptions() *autoscaleOptions {
+	return &autoscaleOptions{
+		itemsPerWorker: 100,
+		sampleInterval: 10 * time.Second,
+		scaleDownAfter: 3,
+	}
+}
+
+// WithItemsPerWorker sets the target of one worker per n pending items.
+func WithItemsPerWorker(n int) AutoscaleOption {
+	return func(o *autoscaleOptions) {
+		o.itemsPerWorker = n
+	}
+}
+
+// WithSampleInterval sets how often queue depth is sampled.
+func WithSampleInterval(d time.Duration) AutoscaleOption {
+	return func(o *autoscaleOptions) {
+		o.sampleInterval = d
+	}
+}
+
+// WithScaleDownAfter sets how many consecutive samples must call for fewer
+// workers before the pool shrinks. Scaling up is immediate.
+func WithScaleDownAfter(samples int) AutoscaleOption {
+	return func(o *autoscaleOptions) {
+		o.scaleDownAfter = samples
+	}
+}
+
+// ---------- Autoscaling Pool ----------
+
+// AutoscalingPool runs between min and max workers based on queue depth.
+type AutoscalingPool[T any] struct {
+	min, max int
+	queue    DepthQueue[T]
+	handler  Handler[T]
+	logger   *slog.Logger
+	cfg      Config
+	opts     *autoscaleOptions
+
+	mu      sync.Mutex
+	workers []*Worker[T]
+	seq     int
+	panics  *panicTracker
+	below   int // consecutive samples wanting fewer workers
+	stopped bool
+	wg      sync.WaitGroup
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAutoscalingPool creates a pool that keeps between min and max workers.
+func NewAutoscalingPool[T any](
+	min, max int,
+	queue DepthQueue[T],
+	handler Handler[T],
+	logger *slog.Logger,
+	cfg Config,
+	opts ...AutoscaleOption,
+) *AutoscalingPool[T] {
+	o := defaultAutoscaleOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &AutoscalingPool[T]{
+		min:     min,
+		max:     max,
+		queue:   queue,
+		handler: handler,
+		logger:  logger,
+		cfg:     cfg,
+		opts:    o,
+		panics:  newPanicTracker(),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start starts min workers and the autoscaler.
+func (p *AutoscalingPool[T]) Start(ctx context.Context) {
+	p.mu.Lock()
+	p.resize(ctx, p.min)
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.autoscale(ctx)
+	}()
+}
+
+// Stop stops the autoscaler and all workers, waiting for them to drain.
+// Returns ctx.Err() if they do not finish in time.
+func (p *AutoscalingPool[T]) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	p.mu.Lock()
+	p.stopped = true
+	for _, w := range p.workers {
+		w.signalStop()
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait blocks until all workers and the autoscaler have stopped.
+func (p *AutoscalingPool[T]) Wait() {
+	p.wg.Wait()
+}
+
+// Size returns the current number of workers.
+func (p *AutoscalingPool[T]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
+}
+
+// Status returns a snapshot of every running worker.
+func (p *AutoscalingPool[T]) Status() []WorkerStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]WorkerStatus, len(p.workers))
+	for i, w := range p.workers {
+		statuses[i] = w.Status()
+	}
+	return statuses
+}
+
+func (p *AutoscalingPool[T]) autoscale(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			depth, err := p.queue.Depth(ctx)
+			if err != nil {
+				p.logger.Error("sample queue depth", slog.String("error", err.Error()))
+				continue
+			}
+			p.sample(ctx, depth)
+		}
+	}
+}
+
+// sample adjusts the pool for the observed depth.
+// Grows immediately, shrinks only after scaleDownAfter consecutive low samples.
+func (p *AutoscalingPool[T]) sample(ctx context.Context, depth int) {
+	desired := (depth + p.opts.itemsPerWorker - 1) / p.opts.itemsPerWorker
+	desired = min(max(desired, p.min), p.max)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// A sample racing with Stop must not start new workers
+	if p.stopped {
+		return
+	}
+
+	current := len(p.workers)
+	switch {
+	case desired > current:
+		p.below = 0
+		p.resize(ctx, desired)
+	case desired < current:
+		p.below++
+		if p.below >= p.opts.scaleDownAfter {
+			p.below = 0
+			p.resize(ctx, desired)
+		}
+	default:
+		p.below = 0
+	}
+}
+
+// resize starts or stops workers to reach size. Caller holds p.mu.
+func (p *AutoscalingPool[T]) resize(ctx context.Context, size int) {
+	current := len(p.workers)
+	if size == current {
+		return
+	}
+
+	for len(p.workers) < size {
+		w := New(fmt.Sprintf("worker-%d", p.seq), p.queue, p.handler, p.logger, p.cfg)
+		w.panics = p.panics
+		p.seq++
+		p.workers = append(p.workers, w)
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			w.Start(ctx)
+		}()
+	}
+
+	for len(p.workers) > size {
+		last := p.workers[len(p.workers)-1]
+		p.workers = p.workers[:len(p.workers)-1]
+		// Stopped workers finish their current item before exiting
+		last.signalStop()
+	}
+
+	direction := "up"
+	if size < current {
+		direction = "down"
+	}
+
+	p.logger.Info("pool resized",
+		slog.String("direction", direction),
+		slog.Int("from", current),
+		slog.Int("to", size),
+	)
+	p.cfg.Metrics.scaled(direction, size)
+}
@@ -0,0 +1,436 @@
+// Package worker provides a cron-style scheduled job.
+// Place in: internal/worker/cron.go
+//
+// This example shows:
+// - Standard 5-field cron specs plus @hourly/@daily/... and @every shorthands
+// - Overlap policy when a run outlasts its schedule (skip or queue one)
+// - Per-run timeout, jitter and timezone
+// - Implements backend.BackgroundJob so it slots into initJobs
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Schedule ----------
+
+// Schedule returns the next activation time strictly after t.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+var cronShorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a 5-field cron spec (minute hour day-of-month month day-of-week),
+// a shorthand like @daily, or "@every <duration>".
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("parse @every: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("parse @every: duration must be positive")
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	if expanded, ok := cronShorthands[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron spec %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		s   cronSchedule
+		err error
+	)
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+
+	// 7 is an alias for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = strings.HasPrefix(fields[2], "*")
+	s.dowAny = strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+// parseCronField parses "*", "n", "a-b", lists and "/step" into a bitmask.
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var mask uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = lo, hi
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var errA, errB error
+			start, errA = strconv.Atoi(a)
+			end, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start, end = n, n
+			if hasStep {
+				// "5/15" means every 15 starting at 5
+				end = hi
+			}
+		}
+
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", rangePart, lo, hi)
+		}
+
+		for v := start; v <= end; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+
+	return mask, nil
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// Next returns the next matching minute after t, in t's location.
+// Wall times skipped by a DST transition are skipped.
+func (s cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+
+	// Impossible specs like "0 0 30 2 *" never match
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows cron semantics: when both day fields are restricted,
+// either may match.
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// ---------- Options ----------
+
+// OverlapPolicy decides what happens when a run is due while the previous
+// one is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops the due run.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs once more after the current run finishes.
+	// At most one run is queued.
+	OverlapQueue
+)
+
+// CronOption configures a CronJob.
+type CronOption func(*cronOptions)
+
+type cronOptions struct {
+	jitter      time.Duration
+	overlap     OverlapPolicy
+	location    *time.Location
+	timeout     time.Duration
+	stopOnError bool
+	metrics     *Metrics
+}
+
+func defaultCronOptions() *cronOptions {
+	return &cronOptions{
+		overlap:  OverlapSkip,
+		location: time.UTC,
+	}
+}
+
+// WithCronJitter delays each run by up to d to spread load across instances.
+func WithCronJitter(d time.Duration) CronOption {
+	return func(o *cronOptions) {
+		o.jitter = d
+	}
+}
+
+// WithOverlap sets the overlap policy. Default is OverlapSkip.
+func WithOverlap(policy OverlapPolicy) CronOption {
+	return func(o *cronOptions) {
+		o.overlap = policy
+	}
+}
+
+// WithTimezone evaluates the spec in loc. Default is UTC.
+func WithTimezone(loc *time.Location) CronOption {
+	return func(o *cronOptions) {
+		o.location = loc
+	}
+}
+
+// WithRunTimeout cancels each run's context after d.
+func WithRunTimeout(d time.Duration) CronOption {
+	return func(o *cronOptions) {
+		o.timeout = d
+	}
+}
+
+// WithStopOnError makes Run return the first run error instead of logging it.
+func WithStopOnError() CronOption {
+	return func(o *cronOptions) {
+		o.stopOnError = true
+	}
+}
+
+// WithCronMetrics counts failed runs in worker_cron_failures_total.
+func WithCronMetrics(m *Metrics) CronOption {
+	return func(o *cronOptions) {
+		o.metrics = m
+	}
+}
+
+// ---------- Cron Job ----------
+
+// CronJob runs a function on a cron schedule.
+// Implements backend.BackgroundJob.
+type CronJob struct {
+	spec     string
+	schedule Schedule
+	fn       func(ctx context.Context) error
+	logger   *slog.Logger
+	opts     *cronOptions
+	clock    Clock
+
+	running chan struct{} // holds a token while a run is in progress
+	queued  chan struct{} // holds a token while a run waits (OverlapQueue)
+}
+
+// NewCronJob creates a job that calls fn on the given cron spec.
+// Run errors are logged and counted; they stop the job only with WithStopOnError.
+func NewCronJob(
+	spec string,
+	fn func(ctx context.Context) error,
+	logger *slog.Logger,
+	opts ...CronOption,
+) (*CronJob, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	o := defaultCronOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &CronJob{
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		logger:   logger.With(slog.String("cron", spec)),
+		opts:     o,
+		clock:    SystemClock,
+		running:  make(chan struct{}, 1),
+		queued:   make(chan struct{}, 1),
+	}, nil
+}
+
+// WithClock sets the clock used to wait for the next run.
+func (j *CronJob) WithClock(clock Clock) *CronJob {
+	j.clock = clock
+	return j
+}
+
+// Run fires fn on schedule until context is cancelled, then waits for
+// in-flight runs. Returns nil on clean shutdown.
+func (j *CronJob) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+
+	for {
+		now := j.clock.Now()
+		next := j.schedule.Next(now.In(j.opts.location))
+		if next.IsZero() {
+			return fmt.Errorf("cron spec %q never fires", j.spec)
+		}
+
+		wait := next.Sub(now)
+		if j.opts.jitter > 0 {
+			wait += rand.N(j.opts.jitter)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		case <-j.clock.After(wait):
+			j.fire(runCtx, &wg, errCh)
+		}
+	}
+}
+
+// fire starts a run, or applies the overlap policy if one is in progress.
+func (j *CronJob) fire(ctx context.Context, wg *sync.WaitGroup, errCh chan<- error) {
+	select {
+	case j.running <- struct{}{}:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j.run(ctx, errCh)
+		}()
+		return
+	default:
+	}
+
+	if j.opts.overlap == OverlapQueue {
+		select {
+		case j.queued <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case j.running <- struct{}{}:
+					<-j.queued
+					j.run(ctx, errCh)
+				case <-ctx.Done():
+					<-j.queued
+				}
+			}()
+			return
+		default:
+		}
+	}
+
+	j.logger.Warn("skipping cron run, previous run still in progress")
+}
+
+// run calls fn and releases the running token.
+func (j *CronJob) run(ctx context.Context, errCh chan<- error) {
+	defer func() { <-j.running }()
+
+	if j.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.timeout)
+		defer cancel()
+	}
+
+	err := j.fn(ctx)
+	if err == nil {
+		return
+	}
+
+	j.opts.metrics.cronFailed(j.spec)
+
+	if j.opts.stopOnError {
+		select {
+		case errCh <- fmt.Errorf("cron run: %w", err):
+		default:
+		}
+		return
+	}
+
+	j.logger.Error("cron run failed", slog.String("error", err.Error()))
+}
+
+// ---------- Usage Example ----------
+
+// Example usage:
+//
+//	cleanup, err := worker.NewCronJob("0 3 * * *", sessions.DeleteExpired, logger,
+//	    worker.WithTimezone(time.Local),
+//	    worker.WithRunTimeout(10*time.Minute),
+//	    worker.WithCronJitter(time.Minute),
+//	)
+//	if err != nil {
+//	    return fmt.Errorf("cleanup job: %w", err)
+//	}
+//
+//	// backend.initJobs
+//	be.jobs = append(be.jobs, cleanup)
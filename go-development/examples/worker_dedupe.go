@@ -0,0 +1,157 @@
+// Package worker provides per-item deduplication for Worker handlers.
+// Place in: internal/worker/dedupe.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"myapp/internal/cache"
+)
+
+// Deduper remembers which items have already been processed.
+type Deduper interface {
+	// Seen reports whether key was already processed or is being processed.
+	// A false result reserves the key, so concurrent callers see true.
+	Seen(ctx context.Context, key string) (bool, error)
+
+	// Mark records key as processed for ttl.
+	Mark(ctx context.Context, key string, ttl time.Duration) error
+
+	// Forget releases a reservation so a failed item can be retried.
+	Forget(ctx context.Context, key string) error
+}
+
+type dedupe[T any] struct {
+	deduper Deduper
+	keyFn   func(T) string
+	ttl     time.Duration
+}
+
+// ---------- Memory Deduper ----------
+
+// defaultReserveTTL bounds a MemoryDeduper reservation unless
+// WithReserveTTL sets another.
+const defaultReserveTTL = 5 * time.Minute
+
+// MemoryDeduperOption configures a MemoryDeduper.
+type MemoryDeduperOption func(*MemoryDeduper)
+
+// WithReserveTTL sets how long an in-progress reservation lasts, so a
+// handler that never returns can't hold its key forever. It should
+// exceed the longest expected handler run.
+func WithReserveTTL(ttl time.Duration) MemoryDeduperOption {
+	return func(d *MemoryDeduper) {
+		d.reserveTTL = ttl
+	}
+}
+
+// MemoryDeduper is an in-process Deduper for tests and single-instance services.
+type MemoryDeduper struct {
+	reserveTTL time.Duration
+
+	mu   sync.Mutex
+	keys map[string]time.Time // reserved or marked until
+}
+
+// NewMemoryDeduper creates a new in-memory deduper.
+func NewMemoryDeduper(opts ...MemoryDeduperOption) *MemoryDeduper {
+	d := &MemoryDeduper{
+		reserveTTL: defaultReserveTTL,
+		keys:       make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Seen reports whether key is reserved or marked and not expired.
+// Otherwise it reserves key for the reservation TTL.
+func (d *MemoryDeduper) Seen(_ context.Context, key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if expiresAt, ok := d.keys[key]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	d.keys[key] = now.Add(d.reserveTTL)
+	return false, nil
+}
+
+// Mark records key as processed for ttl.
+func (d *MemoryDeduper) Mark(_ context.Context, key string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.keys[key] = time.Now().Add(ttl)
+	return nil
+}
+
+// Forget releases key.
+func (d *MemoryDeduper) Forget(_ context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.keys, key)
+	return nil
+}
+
+// ---------- Cache Deduper ----------
+
+// CacheDeduper is a Deduper backed by cache.Client, shared across instances.
+type CacheDeduper struct {
+	client     cache.Client
+	prefix     string
+	reserveTTL time.Duration
+}
+
+// NewCacheDeduper creates a deduper storing keys under prefix.
+// reserveTTL bounds how long an in-progress reservation survives a crashed worker.
+func NewCacheDeduper(client cache.Client, prefix string, reserveTTL time.Duration) *CacheDeduper {
+	return &CacheDeduper{
+		client:     client,
+		prefix:     prefix,
+		reserveTTL: reserveTTL,
+	}
+}
+
+// Seen reserves key with SET NX; an existing key means seen.
+func (d *CacheDeduper) Seen(ctx context.Context, key string) (bool, error) {
+	res, err := d.client.ExecBatch(ctx, "dedupe.seen",
+		cache.SetNXObjWithTTL(d.prefix+key, "processing", d.reserveTTL),
+	)
+	if err != nil {
+		return false, fmt.Errorf("dedupe seen: %w", err)
+	}
+	if err := res[0].Err(); err != nil {
+		return false, fmt.Errorf("dedupe seen: %w", err)
+	}
+
+	set, _ := res[0].Val().(bool)
+	return !set, nil
+}
+
+// Mark records key as processed for ttl.
+func (d *CacheDeduper) Mark(ctx context.Context, key string, ttl time.Duration) error {
+	res, err := d.client.ExecBatch(ctx, "dedupe.mark",
+		cache.SetObjWithTTL(d.prefix+key, "done", ttl),
+	)
+	if err != nil {
+		return fmt.Errorf("dedupe mark: %w", err)
+	}
+	return res[0].Err()
+}
+
+// Forget releases key.
+func (d *CacheDeduper) Forget(ctx context.Context, key string) error {
+	res, err := d.client.ExecBatch(ctx, "dedupe.forget", cache.DelObj(d.prefix+key))
+	if err != nil {
+		return fmt.Errorf("dedupe forget: %w", err)
+	}
+	return res[0].Err()
+}
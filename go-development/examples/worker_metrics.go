@@ -0,0 +1,89 @@
+// Package worker provides Prometheus metrics for workers.
+// Place in: internal/worker/metrics.go
+package worker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds Prometheus collectors for workers.
+// A nil *Metrics disables metrics, so Config.Metrics is optional.
+type Metrics struct {
+	duplicates    *prometheus.CounterVec
+	panics        *prometheus.CounterVec
+	stuckWorkers  *prometheus.CounterVec
+	poolSize      prometheus.Gauge
+	scalingEvents *prometheus.CounterVec
+	cronFailures  *prometheus.CounterVec
+}
+
+// NewMetrics creates worker metrics and registers them with reg.
+// Pass backend.registry so they show up on the monitor server.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		duplicates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "worker_duplicates_total",
+			Help: "Items skipped because they were already processed.",
+		}, []string{"worker"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "worker_panics_total",
+			Help: "Handler panics recovered by the worker.",
+		}, []string{"worker"}),
+		stuckWorkers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "worker_stuck_total",
+			Help: "Times the watchdog found a worker without a recent heartbeat.",
+		}, []string{"worker"}),
+		poolSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "worker_pool_size",
+			Help: "Current number of workers in the autoscaling pool.",
+		}),
+		scalingEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "worker_scaling_events_total",
+			Help: "Autoscaling pool resize events.",
+		}, []string{"direction"}),
+		cronFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "worker_cron_failures_total",
+			Help: "Failed cron job runs.",
+		}, []string{"schedule"}),
+	}
+
+	reg.MustRegister(m.duplicates, m.panics, m.stuckWorkers, m.poolSize, m.scalingEvents, m.cronFailures)
+
+	return m
+}
+
+func (m *Metrics) duplicate(worker string) {
+	if m == nil {
+		return
+	}
+	m.duplicates.WithLabelValues(worker).Inc()
+}
+
+func (m *Metrics) panicked(worker string) {
+	if m == nil {
+		return
+	}
+	m.panics.WithLabelValues(worker).Inc()
+}
+
+func (m *Metrics) stuck(worker string) {
+	if m == nil {
+		return
+	}
+	m.stuckWorkers.WithLabelValues(worker).Inc()
+}
+
+func (m *Metrics) scaled(direction string, size int) {
+	if m == nil {
+		return
+	}
+	m.scalingEvents.WithLabelValues(direction).Inc()
+	m.poolSize.Set(float64(size))
+}
+
+func (m *Metrics) cronFailed(schedule string) {
+	if m == nil {
+		return
+	}
+	m.cronFailures.WithLabelValues(schedule).Inc()
+}
@@ -0,0 +1,57 @@
+// Package worker provides built-in middlewares for Worker handlers.
+// Place in: internal/worker/middleware.go
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Logging logs the outcome and duration of every item.
+// Installed by New as the outermost middleware.
+func Logging[T any](logger *slog.Logger) Middleware[T] {
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, item T) error {
+			start := time.Now()
+			err := next(ctx, item)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				logger.Error("item processing failed",
+					slog.Duration("elapsed", elapsed),
+					slog.String("error", err.Error()),
+				)
+				return err
+			}
+
+			logger.Debug("item processed",
+				slog.Duration("elapsed", elapsed),
+			)
+			return nil
+		}
+	}
+}
+
+// Tracing starts a span named after the worker for every item.
+// Usage: w.Use(worker.Tracing[EmailTask]("email-sender"))
+func Tracing[T any](name string) Middleware[T] {
+	tracer := otel.Tracer("worker")
+
+	return func(next Handler[T]) Handler[T] {
+		return func(ctx context.Context, item T) error {
+			ctx, span := tracer.Start(ctx, name)
+			defer span.End()
+
+			err := next(ctx, item)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
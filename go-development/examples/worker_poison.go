@@ -0,0 +1,165 @@
+// Package worker provides panic tracking and poison-item quarantine.
+// Place in: internal/worker/poison.go
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// DeadLetterQueue is a queue that can park items without further retries.
+type DeadLetterQueue[T any] interface {
+	Queue[T]
+
+	// DeadLetter moves item to the dead-letter store, bypassing retries.
+	DeadLetter(ctx context.Context, item *T, err error) error
+}
+
+// PanicError is returned for handlers that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// panicTracker counts panics per item ID. Shared by all workers in a pool,
+// since a retried item may land on any of them.
+type panicTracker struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newPanicTracker() *panicTracker {
+	return &panicTracker{counts: make(map[string]int)}
+}
+
+func (t *panicTracker) inc(id string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counts[id]++
+	return t.counts[id]
+}
+
+func (t *panicTracker) reset(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.counts, id)
+}
+
+// UseItemID sets how items are identified in logs and for panic tracking.
+// Without it the dedupe key is used, then fmt.Stringer. Call before Start.
+func (w *Worker[T]) UseItemID(fn func(T) string) {
+	w.idFn = fn
+}
+
+// UseItemID sets the item ID function on every worker in the pool. Call before Start.
+func (p *Pool[T]) UseItemID(fn func(T) string) {
+	for _, w := range p.workers {
+		w.UseItemID(fn)
+	}
+}
+
+// itemID returns an identifier for item, or "" if none is available.
+func (w *Worker[T]) itemID(item *T) string {
+	switch {
+	case w.idFn != nil:
+		return w.idFn(*item)
+	case w.dedupe != nil:
+		return w.dedupe.keyFn(*item)
+	}
+
+	if s, ok := any(*item).(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
+
+// fail hands a failed item back to the queue. Items that panicked
+// Config.MaxPanics times, or failed with an error that isn't retryable,
+// are dead-lettered instead of retried. final reports whether this was
+// the item's last attempt.
+func (w *Worker[T]) fail(ctx context.Context, item *T, handlerErr error) (final bool, err error) {
+	var pe *PanicError
+	if !errors.As(handlerErr, &pe) {
+		if dq, ok := w.queue.(DeadLetterQueue[T]); ok && !w.retryable(handlerErr) {
+			w.logger.Warn("dead-lettering item with permanent error",
+				slog.String("item_id", w.itemID(item)),
+				slog.String("error", handlerErr.Error()),
+			)
+			return true, dq.DeadLetter(ctx, item, handlerErr)
+		}
+		return w.queueFail(ctx, item, handlerErr)
+	}
+	if w.cfg.MaxPanics <= 0 {
+		return w.queueFail(ctx, item, handlerErr)
+	}
+
+	id := w.itemID(item)
+	if id == "" {
+		return w.queueFail(ctx, item, handlerErr)
+	}
+
+	panics := w.panics.inc(id)
+	if panics < w.cfg.MaxPanics {
+		return w.queueFail(ctx, item, handlerErr)
+	}
+	w.panics.reset(id)
+
+	w.logger.Error("quarantining poison item",
+		slog.String("item_id", id),
+		slog.Int("panics", panics),
+	)
+
+	if dq, ok := w.queue.(DeadLetterQueue[T]); ok {
+		return true, dq.DeadLetter(ctx, item, handlerErr)
+	}
+
+	// No dead-letter store: dropping beats panicking forever
+	return true, w.queue.Complete(ctx, item)
+}
+
+// queueFail calls Queue.Fail, asking a RetryQueue first whether the item
+// gets another attempt.
+func (w *Worker[T]) queueFail(ctx context.Context, item *T, handlerErr error) (final bool, err error) {
+	final = true
+	if rq, ok := w.queue.(RetryQueue[T]); ok {
+		final = !rq.WillRetry(item)
+	}
+	return final, w.queue.Fail(ctx, item, handlerErr)
+}
+
+// retryable applies Config.RetryIf, or by default rejects only errors
+// marked permanent on some branch.
+func (w *Worker[T]) retryable(err error) bool {
+	if w.cfg.RetryIf != nil {
+		return w.cfg.RetryIf(err)
+	}
+	return !markedPermanent(err)
+}
+
+// markedPermanent finds marks from errs.MarkPermanent or
+// errors.MarkPermanent without importing either package.
+func markedPermanent(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if m, ok := e.(interface{ Retryable() bool }); ok {
+			return !m.Retryable()
+		}
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			for _, branch := range joined.Unwrap() {
+				if markedPermanent(branch) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/worker"
+)
+
+// MemoryQueue tests. The Push/Close tests are only meaningful with -race:
+// go test -race ./internal/worker/...
+
+// ---------- Default Behavior Tests ----------
+
+func TestMemoryQueue_DefaultFailDrops(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](10)
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(1))
+	require.NoError(t, q.Push(2))
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.Fail(ctx, item, errors.New("boom")))
+
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.Complete(ctx, item))
+
+	assert.Zero(t, q.Len())
+	assert.Empty(t, q.DeadLetters())
+	assert.Equal(t, int64(1), q.Failed())
+	assert.Equal(t, int64(1), q.Completed())
+}
+
+// ---------- Requeue Tests ----------
+
+func TestMemoryQueue_RequeueThenDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[string](10, worker.WithRequeueOnFail(3))
+	ctx := context.Background()
+
+	require.NoError(t, q.Push("flaky"))
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		item, err := q.Pop(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, item, "attempt %d", attempt)
+		require.NoError(t, q.Fail(ctx, item, errors.New("boom")))
+	}
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, item)
+	assert.Equal(t, []string{"flaky"}, q.DeadLetters())
+	assert.Equal(t, int64(3), q.Failed())
+}
+
+func TestMemoryQueue_RequeueWithWorker(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](10, worker.WithRequeueOnFail(5))
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	w := worker.New("test", q, func(context.Context, int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, discardLogger, testConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	require.NoError(t, q.Push(42))
+
+	assert.Eventually(t, func() bool { return q.Completed() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(2), q.Failed())
+	assert.Empty(t, q.DeadLetters())
+}
+
+// ---------- Capacity Tests ----------
+
+func TestMemoryQueue_NonBlockingPushReturnsErrQueueFull(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](1, worker.WithNonBlockingPush())
+
+	require.NoError(t, q.Push(1))
+	assert.ErrorIs(t, q.Push(2), worker.ErrQueueFull)
+}
+
+func TestMemoryQueue_CloseUnblocksPush(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](1)
+	require.NoError(t, q.Push(1))
+
+	errCh := make(chan error)
+	go func() { errCh <- q.Push(2) }()
+
+	q.Close()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, worker.ErrQueueClosed)
+	case <-time.After(time.Second):
+		t.Fatal("Push still blocked after Close")
+	}
+}
+
+func TestMemoryQueue_ConcurrentPushClose(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := q.Push(j); err != nil {
+					assert.ErrorIs(t, err, worker.ErrQueueClosed)
+					return
+				}
+			}
+		}()
+	}
+
+	q.Close()
+	assert.ErrorIs(t, q.Push(1), worker.ErrQueueClosed)
+	assert.ErrorIs(t, q.PushAt(context.Background(), 1, time.Now()), worker.ErrQueueClosed)
+
+	wg.Wait()
+}
@@ -0,0 +1,486 @@
+// Package worker provides a Redis-backed queue for the generic Worker.
+// Place in: internal/worker/redis.go
+//
+// This example shows:
+// - Reliable queue: a Lua pop moves items to a processing list with a deadline
+// - Ack via LREM on Complete, requeue or dead-letter on Fail
+// - Janitor that re-queues items past a visibility timeout, counting attempts
+// - JSON serialization of T through the generic parameter
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------- Options ----------
+
+// RedisOption configures the Redis queue.
+type RedisOption func(*redisOptions)
+
+type redisOptions struct {
+	popTimeout        time.Duration
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	janitorInterval   time.Duration
+	maxAttempts       int
+	logger            *slog.Logger
+}
+
+func defaultRedisOptions() *redisOptions {
+	return &redisOptions{
+		popTimeout:        1 * time.Second,
+		pollInterval:      100 * time.Millisecond,
+		visibilityTimeout: 5 * time.Minute,
+		janitorInterval:   30 * time.Second,
+		maxAttempts:       3,
+		logger:            slog.Default(),
+	}
+}
+
+// WithPopTimeout sets how long Pop waits for an item.
+func WithPopTimeout(d time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		o.popTimeout = d
+	}
+}
+
+// WithPollInterval sets how often Pop checks an empty pending list while
+// it waits.
+func WithPollInterval(d time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		o.pollInterval = d
+	}
+}
+
+// WithVisibilityTimeout sets how long an item may stay in the processing
+// list before the janitor counts it as a failed attempt and puts it back
+// on the pending list.
+func WithVisibilityTimeout(d time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		o.visibilityTimeout = d
+	}
+}
+
+// WithJanitorInterval sets how often the janitor scans for stuck items.
+func WithJanitorInterval(d time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		o.janitorInterval = d
+	}
+}
+
+// WithMaxAttempts sets how many times an item is tried, by Fail or past
+// the visibility timeout, before it is moved to the dead-letter list.
+func WithMaxAttempts(n int) RedisOption {
+	return func(o *redisOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithRedisLogger sets the logger used by the janitor.
+func WithRedisLogger(logger *slog.Logger) RedisOption {
+	return func(o *redisOptions) {
+		o.logger = logger
+	}
+}
+
+// ---------- Redis Queue ----------
+
+// redisEnvelope wraps the payload so identical payloads stay distinct
+// list members and the attempt counter travels with the item.
+type redisEnvelope[T any] struct {
+	ID       string `json:"id"`
+	Attempts int    `json:"attempts"`
+	Payload  T      `json:"payload"`
+}
+
+// RedisQueue is a reliable queue backed by Redis lists.
+//
+// Keys (for name "emails"):
+//
+//	queue:emails:pending     LIST  items waiting to be processed
+//	queue:emails:processing  LIST  items popped but not yet acked
+//	queue:emails:deadlines   ZSET  processing items scored by visibility deadline
+//	queue:emails:dead        LIST  items that exhausted their attempts
+type RedisQueue[T any] struct {
+	client *redis.Client
+	opts   *redisOptions
+
+	pendingKey    string
+	processingKey string
+	deadlinesKey  string
+	deadKey       string
+
+	// inflight maps items handed to the worker back to their raw list members,
+	// so Complete and Fail can LREM the exact value that was popped.
+	mu       sync.Mutex
+	inflight map[*T]string
+}
+
+// NewRedisQueue creates a Redis-backed queue.
+// Pass the same *redis.Client the cache layer uses to share the connection pool.
+func NewRedisQueue[T any](client *redis.Client, name string, opts ...RedisOption) *RedisQueue[T] {
+	o := defaultRedisOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	prefix := "queue:" + name
+	return &RedisQueue[T]{
+		client:        client,
+		opts:          o,
+		pendingKey:    prefix + ":pending",
+		processingKey: prefix + ":processing",
+		deadlinesKey:  prefix + ":deadlines",
+		deadKey:       prefix + ":dead",
+		inflight:      make(map[*T]string),
+	}
+}
+
+// Push adds an item to the queue.
+func (q *RedisQueue[T]) Push(ctx context.Context, item T) error {
+	data, err := json.Marshal(redisEnvelope[T]{ID: uuid.NewString(), Payload: item})
+	if err != nil {
+		return fmt.Errorf("marshal item: %w", err)
+	}
+
+	if err := q.client.LPush(ctx, q.pendingKey, data).Err(); err != nil {
+		return fmt.Errorf("lpush: %w", err)
+	}
+
+	return nil
+}
+
+// popScript moves the oldest pending item to the processing list with
+// its visibility deadline, in one step: no item is ever processing
+// without a deadline for the janitor to find. The deadline is added
+// first, so if ZADD fails nothing has been popped.
+var popScript = redis.NewScript(`
+local raw = redis.call('LINDEX', KEYS[1], -1)
+if not raw then
+	return false
+end
+redis.call('ZADD', KEYS[3], ARGV[1], raw)
+redis.call('RPOPLPUSH', KEYS[1], KEYS[2])
+return raw
+`)
+
+// Pop waits up to the pop timeout and returns the next item or nil if none available.
+// The item is atomically moved to the processing list until acked.
+func (q *RedisQueue[T]) Pop(ctx context.Context) (*T, error) {
+	raw, err := q.popRaw(ctx)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+
+	var env redisEnvelope[T]
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		// Poison item: park it in the dead-letter list instead of looping on it
+		if dlqErr := q.moveToDead(ctx, raw, raw); dlqErr != nil {
+			return nil, fmt.Errorf("dead-letter undecodable item: %w", dlqErr)
+		}
+		return nil, fmt.Errorf("unmarshal item: %w", err)
+	}
+
+	item := &env.Payload
+	q.mu.Lock()
+	q.inflight[item] = raw
+	q.mu.Unlock()
+
+	return item, nil
+}
+
+// Complete removes the item from the processing list.
+func (q *RedisQueue[T]) Complete(ctx context.Context, item *T) error {
+	raw, err := q.release(item)
+	if err != nil {
+		return err
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.LRem(ctx, q.processingKey, 1, raw)
+	pipe.ZRem(ctx, q.deadlinesKey, raw)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("ack: %w", err)
+	}
+
+	return nil
+}
+
+// Fail requeues the item, or moves it to the dead-letter list
+// once it has been attempted maxAttempts times.
+func (q *RedisQueue[T]) Fail(ctx context.Context, item *T, _ error) error {
+	raw, err := q.release(item)
+	if err != nil {
+		return err
+	}
+
+	var env redisEnvelope[T]
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		return fmt.Errorf("unmarshal item: %w", err)
+	}
+	env.Attempts++
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("marshal item: %w", err)
+	}
+
+	if env.Attempts >= q.opts.maxAttempts {
+		return q.moveToDead(ctx, raw, string(data))
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.LRem(ctx, q.processingKey, 1, raw)
+	pipe.ZRem(ctx, q.deadlinesKey, raw)
+	pipe.LPush(ctx, q.pendingKey, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("requeue: %w", err)
+	}
+
+	return nil
+}
+
+// WillRetry reports whether Fail would requeue item rather than
+// dead-letter it. Implements RetryQueue.
+func (q *RedisQueue[T]) WillRetry(item *T) bool {
+	q.mu.Lock()
+	raw, ok := q.inflight[item]
+	q.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	var env struct {
+		Attempts int `json:"attempts"`
+	}
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		return false // Fail can't requeue it either
+	}
+	return env.Attempts+1 < q.opts.maxAttempts
+}
+
+// DeadLetter moves the item straight to the dead-letter list. Implements DeadLetterQueue.
+func (q *RedisQueue[T]) DeadLetter(ctx context.Context, item *T, _ error) error {
+	raw, err := q.release(item)
+	if err != nil {
+		return err
+	}
+	return q.moveToDead(ctx, raw, raw)
+}
+
+// popRaw runs popScript every poll interval until it returns an item,
+// the pop timeout passes ("", nil) or ctx is done. Scripts can't block
+// like BRPOPLPUSH, hence the polling.
+func (q *RedisQueue[T]) popRaw(ctx context.Context) (string, error) {
+	timeout := time.NewTimer(q.opts.popTimeout)
+	defer timeout.Stop()
+
+	for {
+		deadline := time.Now().Add(q.opts.visibilityTimeout)
+		raw, err := popScript.Run(ctx, q.client,
+			[]string{q.pendingKey, q.processingKey, q.deadlinesKey},
+			deadline.UnixMilli(),
+		).Text()
+		if err == nil {
+			return raw, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return "", fmt.Errorf("pop: %w", err)
+		}
+
+		poll := time.NewTimer(q.opts.pollInterval)
+		select {
+		case <-ctx.Done():
+			poll.Stop()
+			return "", ctx.Err()
+		case <-timeout.C:
+			poll.Stop()
+			return "", nil
+		case <-poll.C:
+		}
+	}
+}
+
+func (q *RedisQueue[T]) release(item *T) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	raw, ok := q.inflight[item]
+	if !ok {
+		return "", errors.New("item is not in flight")
+	}
+	delete(q.inflight, item)
+
+	return raw, nil
+}
+
+func (q *RedisQueue[T]) moveToDead(ctx context.Context, raw, data string) error {
+	pipe := q.client.TxPipeline()
+	pipe.LRem(ctx, q.processingKey, 1, raw)
+	pipe.ZRem(ctx, q.deadlinesKey, raw)
+	pipe.LPush(ctx, q.deadKey, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("dead-letter: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of pending items.
+func (q *RedisQueue[T]) Len(ctx context.Context) (int64, error) {
+	return q.client.LLen(ctx, q.pendingKey).Result()
+}
+
+// Depth returns the number of pending items. Implements DepthQueue.
+func (q *RedisQueue[T]) Depth(ctx context.Context) (int, error) {
+	n, err := q.Len(ctx)
+	return int(n), err
+}
+
+// DeadLen returns the number of items in the dead-letter list.
+func (q *RedisQueue[T]) DeadLen(ctx context.Context) (int64, error) {
+	return q.client.LLen(ctx, q.deadKey).Result()
+}
+
+// ---------- Janitor ----------
+
+// recoverLua defines recover(raw), shared by the janitor scripts: it counts
+// the lost delivery as an attempt, as Fail would, and moves the item back
+// to pending, or to the dead-letter list once attempts reach ARGV[1].
+// Envelopes are written by json.Marshal, so attempts always follows id;
+// one the pattern doesn't match can't be counted and is dead-lettered.
+const recoverLua = `
+local function recover(raw)
+	local attempts = string.match(raw, '^{"id":"[^"]*","attempts":(%d+),')
+	if not attempts then
+		redis.call('LPUSH', KEYS[3], raw)
+		return 'dead'
+	end
+	attempts = tonumber(attempts) + 1
+	local data = string.gsub(raw, '^({"id":"[^"]*","attempts":)%d+', '%1' .. attempts, 1)
+	if attempts >= tonumber(ARGV[1]) then
+		redis.call('LPUSH', KEYS[3], data)
+		return 'dead'
+	end
+	redis.call('RPUSH', KEYS[2], data)
+	return 'requeued'
+end
+local counts = {requeued = 0, dead = 0}
+`
+
+// requeueScript recovers processing items whose deadline has passed. It
+// reads only the expired range of the deadlines ZSET, so a tick costs the
+// number of stuck items, not the length of the processing list. Runs
+// atomically so a late Complete can't race with the requeue.
+var requeueScript = redis.NewScript(recoverLua + `
+for _, raw in ipairs(redis.call('ZRANGEBYSCORE', KEYS[4], '-inf', ARGV[2])) do
+	redis.call('ZREM', KEYS[4], raw)
+	if redis.call('LREM', KEYS[1], 1, raw) > 0 then
+		local outcome = recover(raw)
+		counts[outcome] = counts[outcome] + 1
+	end
+end
+return {counts.requeued, counts.dead}
+`)
+
+// orphanScript recovers processing items with no deadline at all:
+// popScript never leaves one, but a pop by an older release can. It
+// scans the whole processing list, so the janitor runs it only at start.
+var orphanScript = redis.NewScript(recoverLua + `
+for _, raw in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	if not redis.call('ZSCORE', KEYS[4], raw) then
+		redis.call('LREM', KEYS[1], 1, raw)
+		local outcome = recover(raw)
+		counts[outcome] = counts[outcome] + 1
+	end
+end
+return {counts.requeued, counts.dead}
+`)
+
+// RequeueStale puts items whose visibility timeout has expired back on the
+// pending list, counting the expiry as a failed attempt; items out of
+// attempts go to the dead-letter list instead. It returns how many items
+// went each way.
+func (q *RedisQueue[T]) RequeueStale(ctx context.Context) (requeued, dead int, err error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return q.recoverItems(ctx, requeueScript, now)
+}
+
+// RequeueOrphans does the same for processing items that have no deadline.
+// It scans the whole processing list; Run calls it once at start.
+func (q *RedisQueue[T]) RequeueOrphans(ctx context.Context) (requeued, dead int, err error) {
+	return q.recoverItems(ctx, orphanScript)
+}
+
+func (q *RedisQueue[T]) recoverItems(ctx context.Context, script *redis.Script, args ...any) (int, int, error) {
+	args = append([]any{q.opts.maxAttempts}, args...)
+	counts, err := script.Run(ctx, q.client,
+		[]string{q.processingKey, q.pendingKey, q.deadKey, q.deadlinesKey},
+		args...,
+	).Int64Slice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("recover items: %w", err)
+	}
+
+	return int(counts[0]), int(counts[1]), nil
+}
+
+// Run starts the janitor loop until context is cancelled.
+// Implements backend.BackgroundJob so it can be registered in initJobs.
+// Returns nil on clean shutdown.
+func (q *RedisQueue[T]) Run(ctx context.Context) error {
+	q.logRecovered(q.RequeueOrphans(ctx))
+
+	ticker := time.NewTicker(q.opts.janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			q.logRecovered(q.RequeueStale(ctx))
+		}
+	}
+}
+
+func (q *RedisQueue[T]) logRecovered(requeued, dead int, err error) {
+	if err != nil {
+		q.opts.logger.Error("janitor failed", slog.String("error", err.Error()))
+		return
+	}
+	if requeued > 0 || dead > 0 {
+		q.opts.logger.Warn("recovered stale items",
+			slog.Int("requeued", requeued),
+			slog.Int("dead_lettered", dead),
+		)
+	}
+}
+
+// ---------- Usage Example ----------
+
+// Example usage:
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+//
+//	queue := worker.NewRedisQueue[EmailTask](rdb, "emails",
+//	    worker.WithVisibilityTimeout(2*time.Minute),
+//	    worker.WithMaxAttempts(5),
+//	)
+//
+//	pool := worker.NewPool(5, queue, sendEmail, logger, worker.DefaultConfig())
+//	pool.Start(ctx)
+//
+//	// Janitor runs alongside the pool (backend.initJobs)
+//	be.jobs = append(be.jobs, queue)
+//
+//	queue.Push(ctx, EmailTask{To: "user@example.com", Subject: "Hello"})
@@ -0,0 +1,275 @@
+//go:build integration
+
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/worker"
+)
+
+// Redis queue tests run against miniredis.
+// Run with: go test -tags=integration ./internal/worker/...
+
+type emailTask struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+func newTestRedisQueue(t *testing.T, opts ...worker.RedisOption) (*worker.RedisQueue[emailTask], *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	opts = append([]worker.RedisOption{worker.WithPopTimeout(10 * time.Millisecond)}, opts...)
+	return worker.NewRedisQueue[emailTask](client, "emails", opts...), mr
+}
+
+func TestRedisQueue_PushPopComplete(t *testing.T) {
+	t.Parallel()
+
+	q, mr := newTestRedisQueue(t)
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "a@example.com", Subject: "Hello"}))
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "a@example.com", item.To)
+
+	processing, _ := mr.List("queue:emails:processing")
+	assert.Len(t, processing, 1)
+
+	require.NoError(t, q.Complete(ctx, item))
+
+	processing, _ = mr.List("queue:emails:processing")
+	assert.Empty(t, processing)
+}
+
+func TestRedisQueue_PopEmpty(t *testing.T) {
+	t.Parallel()
+
+	q, _ := newTestRedisQueue(t)
+
+	item, err := q.Pop(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, item)
+}
+
+func TestRedisQueue_FailRequeuesThenDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	q, _ := newTestRedisQueue(t, worker.WithMaxAttempts(2))
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "b@example.com"}))
+
+	// First failure: back to pending
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.Fail(ctx, item, errors.New("smtp down")))
+
+	n, err := q.Len(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// Second failure: dead-lettered
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.Fail(ctx, item, errors.New("smtp down")))
+
+	n, err = q.Len(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	dead, err := q.DeadLen(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dead)
+}
+
+func TestRedisQueue_RequeueStale(t *testing.T) {
+	t.Parallel()
+
+	q, _ := newTestRedisQueue(t, worker.WithVisibilityTimeout(time.Millisecond))
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "c@example.com"}))
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+
+	time.Sleep(5 * time.Millisecond)
+
+	requeued, dead, err := q.RequeueStale(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+	assert.Zero(t, dead)
+
+	// Late ack after requeue is harmless
+	require.NoError(t, q.Complete(ctx, item))
+
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "c@example.com", item.To)
+}
+
+func TestRedisQueue_RequeueStaleCountsAttempts(t *testing.T) {
+	t.Parallel()
+
+	q, mr := newTestRedisQueue(t, worker.WithVisibilityTimeout(time.Millisecond), worker.WithMaxAttempts(2))
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "h@example.com", Subject: `"attempts":9,`}))
+
+	// First expiry: back to pending with the attempt counted
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	time.Sleep(5 * time.Millisecond)
+
+	requeued, dead, err := q.RequeueStale(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+	assert.Zero(t, dead)
+	pending, _ := mr.List("queue:emails:pending")
+	require.Len(t, pending, 1)
+	assert.Contains(t, pending[0], `"attempts":1,`)
+
+	// Second expiry: out of attempts, dead-lettered instead of looping
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, `"attempts":9,`, item.Subject, "payload untouched")
+	time.Sleep(5 * time.Millisecond)
+
+	requeued, dead, err = q.RequeueStale(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, requeued)
+	assert.Equal(t, 1, dead)
+
+	n, err := q.Len(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	deadLen, err := q.DeadLen(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deadLen)
+}
+
+func TestRedisQueue_DeadLetterBypassesRetries(t *testing.T) {
+	t.Parallel()
+
+	q, mr := newTestRedisQueue(t, worker.WithMaxAttempts(5))
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "d@example.com"}))
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.DeadLetter(ctx, item, errors.New("panic")))
+
+	dead, err := q.DeadLen(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dead)
+
+	processing, _ := mr.List("queue:emails:processing")
+	assert.Empty(t, processing)
+}
+
+func TestRedisQueue_PopDeadlineFails(t *testing.T) {
+	t.Parallel()
+
+	q, mr := newTestRedisQueue(t)
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "e@example.com"}))
+
+	// A deadlines key of the wrong type makes the pop script's ZADD fail
+	require.NoError(t, mr.Set("queue:emails:deadlines", "not a zset"))
+
+	item, err := q.Pop(ctx)
+	require.Error(t, err)
+	assert.Nil(t, item)
+
+	pending, _ := mr.List("queue:emails:pending")
+	assert.Len(t, pending, 1, "nothing popped without a deadline")
+	assert.False(t, mr.Exists("queue:emails:processing"))
+
+	mr.Del("queue:emails:deadlines")
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "e@example.com", item.To)
+}
+
+func TestRedisQueue_RequeueWithoutDeadline(t *testing.T) {
+	t.Parallel()
+
+	q, mr := newTestRedisQueue(t)
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, emailTask{To: "f@example.com"}))
+
+	// An item popped without a deadline, as a BRPOPLPUSH whose ZADD never ran leaves it
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.RPopLPush(ctx, "queue:emails:pending", "queue:emails:processing").Err())
+
+	requeued, _, err := q.RequeueStale(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, requeued, "the stale scan reads only the deadlines")
+
+	requeued, _, err = q.RequeueOrphans(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "f@example.com", item.To)
+
+	requeued, _, err = q.RequeueOrphans(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, requeued, "a popped item has a deadline")
+}
+
+func TestRedisQueue_PopWaitsForPush(t *testing.T) {
+	t.Parallel()
+
+	q, _ := newTestRedisQueue(t, worker.WithPopTimeout(time.Second), worker.WithPollInterval(5*time.Millisecond))
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = q.Push(ctx, emailTask{To: "g@example.com"})
+	}()
+
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "g@example.com", item.To)
+}
+
+func TestRedisQueue_PopCanceled(t *testing.T) {
+	t.Parallel()
+
+	q, _ := newTestRedisQueue(t, worker.WithPopTimeout(time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	item, err := q.Pop(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, item)
+}
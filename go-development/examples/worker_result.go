@@ -0,0 +1,261 @@
+// Package worker provides job results for producers that wait on an outcome.
+// Place in: internal/worker/result.go
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"myapp/internal/cache"
+)
+
+// HandlerWithResult processes a single work item and returns a value.
+type HandlerWithResult[T, R any] func(ctx context.Context, item T) (R, error)
+
+// ResultStore persists job outcomes by job ID.
+type ResultStore[R any] interface {
+	// Set records the outcome of jobID. err is the handler error, if any.
+	Set(ctx context.Context, jobID string, r R, err error) error
+
+	// Get returns the outcome of jobID. ok is false when no result is stored
+	// yet; a non-nil error with ok=false is a lookup failure.
+	Get(ctx context.Context, jobID string) (r R, err error, ok bool)
+}
+
+// resultRecorder persists outcomes once the queue has acked an item.
+type resultRecorder[T any] interface {
+	record(ctx context.Context, item T, handlerErr error) error
+	discard(item T)
+}
+
+// results holds handler values between handling and ack.
+type results[T, R any] struct {
+	handler HandlerWithResult[T, R]
+	store   ResultStore[R]
+	jobID   func(T) string
+
+	mu      sync.Mutex
+	pending map[string]R
+}
+
+func newResults[T, R any](handler HandlerWithResult[T, R], store ResultStore[R], jobID func(T) string) *results[T, R] {
+	return &results[T, R]{
+		handler: handler,
+		store:   store,
+		jobID:   jobID,
+		pending: make(map[string]R),
+	}
+}
+
+func (r *results[T, R]) handle(ctx context.Context, item T) error {
+	val, err := r.handler(ctx, item)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.pending[r.jobID(item)] = val
+	r.mu.Unlock()
+
+	return nil
+}
+
+// record stores the value after Complete, or the error after the last
+// Fail; the worker doesn't call it for attempts that will be retried.
+func (r *results[T, R]) record(ctx context.Context, item T, handlerErr error) error {
+	id := r.jobID(item)
+
+	r.mu.Lock()
+	val, ok := r.pending[id]
+	delete(r.pending, id)
+	r.mu.Unlock()
+
+	// Nothing captured for this job ID, e.g. another worker already recorded it
+	if !ok && handlerErr == nil {
+		return nil
+	}
+
+	return r.store.Set(ctx, id, val, handlerErr)
+}
+
+// discard drops the value held for item, e.g. after Complete failed and
+// the item will be redelivered.
+func (r *results[T, R]) discard(item T) {
+	r.mu.Lock()
+	delete(r.pending, r.jobID(item))
+	r.mu.Unlock()
+}
+
+// NewWithResult creates a worker whose handler returns a value.
+// Outcomes are written to store under jobID(item) after the item is acked.
+func NewWithResult[T, R any](
+	name string,
+	queue Queue[T],
+	handler HandlerWithResult[T, R],
+	store ResultStore[R],
+	jobID func(T) string,
+	logger *slog.Logger,
+	cfg Config,
+) *Worker[T] {
+	rec := newResults(handler, store, jobID)
+
+	w := New(name, queue, rec.handle, logger, cfg)
+	w.results = rec
+	return w
+}
+
+// NewPoolWithResult creates a pool of workers sharing one result store.
+func NewPoolWithResult[T, R any](
+	count int,
+	queue Queue[T],
+	handler HandlerWithResult[T, R],
+	store ResultStore[R],
+	jobID func(T) string,
+	logger *slog.Logger,
+	cfg Config,
+) *Pool[T] {
+	rec := newResults(handler, store, jobID)
+
+	pool := NewPool(count, queue, rec.handle, logger, cfg)
+	for _, w := range pool.workers {
+		w.results = rec
+	}
+	return pool
+}
+
+// AwaitResult polls store until jobID has a result or ctx is done.
+func AwaitResult[R any](ctx context.Context, store ResultStore[R], jobID string, interval time.Duration) (R, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r, err, ok := store.Get(ctx, jobID)
+		if ok {
+			return r, err
+		}
+		if err != nil {
+			return r, fmt.Errorf("get result: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			var zero R
+			return zero, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ---------- Memory Result Store ----------
+
+type memoryResult[R any] struct {
+	val       R
+	err       error
+	expiresAt time.Time
+}
+
+// MemoryResultStore is an in-process ResultStore for tests and single-instance services.
+type MemoryResultStore[R any] struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	results map[string]memoryResult[R]
+}
+
+// NewMemoryResultStore creates a store that keeps results for ttl.
+func NewMemoryResultStore[R any](ttl time.Duration) *MemoryResultStore[R] {
+	return &MemoryResultStore[R]{
+		ttl:     ttl,
+		results: make(map[string]memoryResult[R]),
+	}
+}
+
+// Set records the outcome of jobID.
+func (s *MemoryResultStore[R]) Set(_ context.Context, jobID string, r R, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[jobID] = memoryResult[R]{val: r, err: err, expiresAt: time.Now().Add(s.ttl)}
+	return nil
+}
+
+// Get returns the outcome of jobID if it has not expired.
+func (s *MemoryResultStore[R]) Get(_ context.Context, jobID string) (R, error, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.results[jobID]
+	if !ok || time.Now().After(res.expiresAt) {
+		delete(s.results, jobID)
+		var zero R
+		return zero, nil, false
+	}
+
+	return res.val, res.err, true
+}
+
+// ---------- Cache Result Store ----------
+
+// cachedResult is the JSON form of a result; errors travel as their message.
+type cachedResult[R any] struct {
+	Value R      `json:"value"`
+	Error string `json:"error,omitempty"`
+}
+
+// CacheResultStore is a ResultStore backed by cache.Client, shared across instances.
+type CacheResultStore[R any] struct {
+	client cache.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewCacheResultStore creates a store keeping results under prefix for ttl.
+func NewCacheResultStore[R any](client cache.Client, prefix string, ttl time.Duration) *CacheResultStore[R] {
+	return &CacheResultStore[R]{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// Set records the outcome of jobID.
+func (s *CacheResultStore[R]) Set(ctx context.Context, jobID string, r R, err error) error {
+	obj := cachedResult[R]{Value: r}
+	if err != nil {
+		obj.Error = err.Error()
+	}
+
+	res, execErr := s.client.ExecBatch(ctx, "result.set",
+		cache.SetObjWithTTL(s.prefix+jobID, obj, s.ttl),
+	)
+	if execErr != nil {
+		return fmt.Errorf("set result: %w", execErr)
+	}
+	return res[0].Err()
+}
+
+// Get returns the outcome of jobID.
+func (s *CacheResultStore[R]) Get(ctx context.Context, jobID string) (R, error, bool) {
+	var zero R
+
+	var obj cachedResult[R]
+	res, err := s.client.ExecBatch(ctx, "result.get", cache.GetObj(s.prefix+jobID, &obj))
+	if err != nil {
+		return zero, fmt.Errorf("get result: %w", err), false
+	}
+	if err := res[0].Err(); err != nil {
+		return zero, fmt.Errorf("get result: %w", err), false
+	}
+	if res[0].Val() == nil {
+		return zero, nil, false // Cache miss
+	}
+
+	if obj.Error != "" {
+		return obj.Value, errors.New(obj.Error), true
+	}
+	return obj.Value, nil, true
+}
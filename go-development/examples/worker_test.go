@@ -0,0 +1,1163 @@
+package worker_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"myapp/internal/errs"
+	"myapp/internal/worker"
+)
+
+// fakeClock is a manually advanced clock.
+// After fires immediately and moves time forward by d.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// recordingQueue wraps MemoryQueue and reports Complete/Fail outcomes.
+type recordingQueue[T any] struct {
+	*worker.MemoryQueue[T]
+	done chan error
+}
+
+func newRecordingQueue[T any]() *recordingQueue[T] {
+	return &recordingQueue[T]{
+		MemoryQueue: worker.NewMemoryQueue[T](10),
+		done:        make(chan error, 100),
+	}
+}
+
+func (q *recordingQueue[T]) Complete(context.Context, *T) error {
+	q.done <- nil
+	return nil
+}
+
+func (q *recordingQueue[T]) Fail(_ context.Context, _ *T, err error) error {
+	q.done <- err
+	return nil
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func testConfig() worker.Config {
+	return worker.Config{PollInterval: time.Millisecond}
+}
+
+// runOne starts w, waits for one item outcome and stops the worker.
+func runOne[T any](t *testing.T, w *worker.Worker[T], q *recordingQueue[T]) error {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go w.Start(ctx)
+
+	select {
+	case err := <-q.done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("item was not processed")
+		return nil
+	}
+}
+
+// ---------- Middleware Tests ----------
+
+func TestWorker_UseOrder(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	trace := func(name string) worker.Middleware[int] {
+		return func(next worker.Handler[int]) worker.Handler[int] {
+			return func(ctx context.Context, item int) error {
+				calls = append(calls, name+":before")
+				err := next(ctx, item)
+				calls = append(calls, name+":after")
+				return err
+			}
+		}
+	}
+
+	q := newRecordingQueue[int]()
+	w := worker.New("test", q, func(context.Context, int) error {
+		calls = append(calls, "handler")
+		return nil
+	}, discardLogger, testConfig())
+	w.Use(trace("first"), trace("second"))
+
+	require.NoError(t, q.Push(1))
+	require.NoError(t, runOne(t, w, q))
+
+	assert.Equal(t, []string{
+		"first:before",
+		"second:before",
+		"handler",
+		"second:after",
+		"first:after",
+	}, calls)
+}
+
+func TestWorker_MiddlewarePanicIsRecovered(t *testing.T) {
+	t.Parallel()
+
+	handlerCalled := false
+
+	q := newRecordingQueue[int]()
+	w := worker.New("test", q, func(context.Context, int) error {
+		handlerCalled = true
+		return nil
+	}, discardLogger, testConfig())
+	w.Use(func(worker.Handler[int]) worker.Handler[int] {
+		return func(context.Context, int) error {
+			panic("buggy middleware")
+		}
+	})
+
+	require.NoError(t, q.Push(1))
+	err := runOne(t, w, q)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "buggy middleware")
+	assert.False(t, handlerCalled)
+}
+
+func TestWorker_HandlerErrorReachesFail(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	q := newRecordingQueue[int]()
+	w := worker.New("test", q, func(context.Context, int) error {
+		return errBoom
+	}, discardLogger, testConfig())
+
+	require.NoError(t, q.Push(1))
+	assert.ErrorIs(t, runOne(t, w, q), errBoom)
+}
+
+// retryQueue puts failed items back on the queue, like a real broker would.
+type retryQueue struct {
+	*worker.MemoryQueue[string]
+}
+
+func (q retryQueue) Fail(_ context.Context, item *string, _ error) error {
+	return q.Push(*item)
+}
+
+func TestPool_PoisonItemIsQuarantined(t *testing.T) {
+	t.Parallel()
+
+	var poisonCalls, healthy atomic.Int64
+
+	q := retryQueue{worker.NewMemoryQueue[string](20)}
+	cfg := testConfig()
+	cfg.MaxPanics = 3
+
+	pool := worker.NewPool(2, q, func(_ context.Context, item string) error {
+		if item == "poison" {
+			poisonCalls.Add(1)
+			panic("cannot decode")
+		}
+		healthy.Add(1)
+		return nil
+	}, discardLogger, cfg)
+	pool.UseItemID(func(item string) string { return item })
+
+	require.NoError(t, q.Push("poison"))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.Push(fmt.Sprintf("ok-%d", i)))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		return healthy.Load() == 10 && len(q.DeadLetters()) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, pool.Stop(context.Background()))
+	assert.Equal(t, []string{"poison"}, q.DeadLetters())
+	assert.Equal(t, int64(3), poisonCalls.Load())
+}
+
+func TestWorker_PanicReturnsPanicError(t *testing.T) {
+	t.Parallel()
+
+	q := newRecordingQueue[int]()
+	w := worker.New("test", q, func(context.Context, int) error {
+		panic("boom")
+	}, discardLogger, testConfig())
+
+	require.NoError(t, q.Push(1))
+
+	var pe *worker.PanicError
+	require.ErrorAs(t, runOne(t, w, q), &pe)
+	assert.Equal(t, "boom", pe.Value)
+	assert.NotEmpty(t, pe.Stack)
+}
+
+// ---------- Retry Classification Tests ----------
+
+// runUntilSettled runs handler on items until each is done or dead-lettered.
+func runUntilSettled(t *testing.T, cfg worker.Config, handler func(context.Context, string) error, items ...string) *worker.MemoryQueue[string] {
+	t.Helper()
+
+	q := worker.NewMemoryQueue[string](10, worker.WithRequeueOnFail(3))
+	w := worker.New("test", q, handler, discardLogger, cfg)
+	for _, item := range items {
+		require.NoError(t, q.Push(item))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		return q.Completed()+int64(len(q.DeadLetters())) == int64(len(items))
+	}, time.Second, time.Millisecond)
+	require.NoError(t, w.Stop(context.Background()))
+	return q
+}
+
+func TestWorker_PermanentErrorSkipsRetries(t *testing.T) {
+	t.Parallel()
+
+	var calls sync.Map
+	count := func(item string) int {
+		n, _ := calls.LoadOrStore(item, new(atomic.Int64))
+		return int(n.(*atomic.Int64).Add(1))
+	}
+
+	q := runUntilSettled(t, testConfig(), func(_ context.Context, item string) error {
+		n := count(item)
+		switch item {
+		case "bad-payload":
+			return errs.MarkPermanent(errs.Validationf("Job.Decode", "missing email"))
+		case "flaky":
+			if n < 2 {
+				return errs.Wrap("Job.Send", errs.ErrUnavailable)
+			}
+		case "unclassified":
+			return errors.New("boom")
+		}
+		return nil
+	}, "bad-payload", "flaky", "unclassified")
+
+	assert.ElementsMatch(t, []string{"bad-payload", "unclassified"}, q.DeadLetters())
+	n, _ := calls.Load("bad-payload")
+	assert.Equal(t, int64(1), n.(*atomic.Int64).Load(), "permanent: tried once")
+	n, _ = calls.Load("flaky")
+	assert.Equal(t, int64(2), n.(*atomic.Int64).Load(), "retried until it worked")
+	n, _ = calls.Load("unclassified")
+	assert.Equal(t, int64(3), n.(*atomic.Int64).Load(), "unknown errors keep their retries")
+}
+
+func TestWorker_RetryIf(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int64
+	cfg := testConfig()
+	cfg.RetryIf = errs.Retryable
+
+	q := runUntilSettled(t, cfg, func(_ context.Context, item string) error {
+		attempts.Add(1)
+		if item == "missing" {
+			return errs.NotFoundf("Job.Load", "userID=%s", "42")
+		}
+		return nil
+	}, "missing", "ok")
+
+	assert.Equal(t, []string{"missing"}, q.DeadLetters())
+	assert.Equal(t, int64(2), attempts.Load(), "not found isn't retried")
+}
+
+// ---------- Concurrency Tests ----------
+
+func TestWorker_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu             sync.Mutex
+		active, peak   int
+		itemsProcessed int
+	)
+
+	q := newRecordingQueue[int]()
+	cfg := testConfig()
+	cfg.Concurrency = 4
+
+	w := worker.New("test", q, func(context.Context, int) error {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		itemsProcessed++
+		mu.Unlock()
+		return nil
+	}, discardLogger, cfg)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.Push(i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	for i := 0; i < 10; i++ {
+		select {
+		case err := <-q.done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of 10 items completed", i)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 4, peak)
+	assert.Equal(t, 10, itemsProcessed)
+}
+
+// ---------- Deduplication Tests ----------
+
+func TestPool_DedupeConcurrentPushes(t *testing.T) {
+	t.Parallel()
+
+	type webhook struct {
+		EventID string
+	}
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+
+	q := newRecordingQueue[webhook]()
+	cfg := testConfig()
+	cfg.Concurrency = 2
+
+	pool := worker.NewPool(2, q, func(context.Context, webhook) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}, discardLogger, cfg)
+	pool.UseDeduper(worker.NewMemoryDeduper(), func(w webhook) string { return w.EventID }, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, q.Push(webhook{EventID: "evt-1"}))
+		}()
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-q.done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("item was not acked")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, calls)
+}
+
+// completeErrQueue is a recordingQueue whose Complete fails, as when the
+// broker connection drops after the handler succeeded.
+type completeErrQueue[T any] struct {
+	*recordingQueue[T]
+}
+
+var errComplete = errors.New("complete: connection reset")
+
+func (q completeErrQueue[T]) Complete(context.Context, *T) error {
+	q.done <- errComplete
+	return errComplete
+}
+
+func TestWorker_CompleteFailureReleasesDedupeKey(t *testing.T) {
+	t.Parallel()
+
+	q := completeErrQueue[string]{newRecordingQueue[string]()}
+	d := worker.NewMemoryDeduper()
+
+	w := worker.New("test", q, func(context.Context, string) error { return nil }, discardLogger, testConfig())
+	w.UseDeduper(d, func(s string) string { return s }, time.Hour)
+
+	require.NoError(t, q.Push("evt-1"))
+	require.ErrorIs(t, runOne(t, w, q.recordingQueue), errComplete)
+
+	require.Eventually(t, func() bool {
+		seen, err := d.Seen(context.Background(), "evt-1")
+		return err == nil && !seen
+	}, time.Second, time.Millisecond, "redelivery must not be dropped as a duplicate")
+}
+
+func TestMemoryDeduper_ReservationExpires(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	d := worker.NewMemoryDeduper(worker.WithReserveTTL(10 * time.Millisecond))
+
+	seen, err := d.Seen(ctx, "evt-1")
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	seen, err = d.Seen(ctx, "evt-1")
+	require.NoError(t, err)
+	assert.True(t, seen, "reserved while in progress")
+
+	time.Sleep(20 * time.Millisecond)
+	seen, err = d.Seen(ctx, "evt-1")
+	require.NoError(t, err)
+	assert.False(t, seen, "a crashed worker's reservation expires")
+}
+
+// ---------- Pause/Resume Tests ----------
+
+// countingQueue counts Pop calls.
+type countingQueue[T any] struct {
+	*worker.MemoryQueue[T]
+	pops atomic.Int64
+}
+
+func (q *countingQueue[T]) Pop(ctx context.Context) (*T, error) {
+	q.pops.Add(1)
+	return q.MemoryQueue.Pop(ctx)
+}
+
+func waitForState(t *testing.T, pool *worker.Pool[int], state worker.WorkerState) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		for _, s := range pool.Status() {
+			if s.State != state {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+func TestPool_PauseStopsPopping(t *testing.T) {
+	t.Parallel()
+
+	q := &countingQueue[int]{MemoryQueue: worker.NewMemoryQueue[int](10)}
+	pool := worker.NewPool(2, q, func(context.Context, int) error { return nil }, discardLogger, testConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	pool.Pause()
+	waitForState(t, pool, worker.StatePaused)
+
+	popsBefore := q.pops.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, popsBefore, q.pops.Load(), "paused workers must not pop")
+
+	pool.Resume()
+	require.Eventually(t, func() bool {
+		return q.pops.Load() > popsBefore
+	}, time.Second, time.Millisecond)
+}
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](10)
+	pool := worker.NewPool(1, q, func(context.Context, int) error { return nil }, discardLogger, testConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	handler := worker.NewAdminHandler(pool)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	waitForState(t, pool, worker.StatePaused)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"state":"paused"`)
+}
+
+// ---------- Graceful Drain Tests ----------
+
+func TestWorker_StartReturnsNilOnCancel(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](10)
+	w := worker.New("test", q, func(context.Context, int) error { return nil }, discardLogger, testConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(ctx) }()
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func TestWorker_StopWaitsForInFlightItem(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	var handlerErr error
+
+	q := worker.NewMemoryQueue[int](10)
+	cfg := testConfig()
+	cfg.DrainTimeout = time.Second
+
+	w := worker.New("test", q, func(ctx context.Context, _ int) error {
+		close(started)
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			handlerErr = ctx.Err()
+		}
+		return nil
+	}, discardLogger, cfg)
+
+	require.NoError(t, q.Push(1))
+	go w.Start(context.Background())
+	<-started
+
+	require.NoError(t, w.Stop(context.Background()))
+	assert.NoError(t, handlerErr, "handler context must survive shutdown within DrainTimeout")
+}
+
+func TestWorker_DrainTimeoutCancelsSlowHandler(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	var handlerErr error
+
+	q := worker.NewMemoryQueue[int](10)
+	cfg := testConfig()
+	cfg.DrainTimeout = 20 * time.Millisecond
+
+	w := worker.New("test", q, func(ctx context.Context, _ int) error {
+		close(started)
+		<-ctx.Done()
+		handlerErr = ctx.Err()
+		return handlerErr
+	}, discardLogger, cfg)
+
+	require.NoError(t, q.Push(1))
+	go w.Start(context.Background())
+	<-started
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, w.Stop(stopCtx))
+	assert.ErrorIs(t, handlerErr, context.Canceled)
+}
+
+func TestPool_StopDrainsQueue(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		processed int
+	)
+
+	q := worker.NewMemoryQueue[int](100)
+	cfg := testConfig()
+	cfg.DrainTimeout = time.Second
+	cfg.DrainQueue = true
+
+	pool := worker.NewPool(2, q, func(context.Context, int) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		processed++
+		mu.Unlock()
+		return nil
+	}, discardLogger, cfg)
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, q.Push(i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	cancel()
+
+	require.NoError(t, pool.Stop(context.Background()))
+	assert.Equal(t, 50, processed)
+	assert.Zero(t, q.Len())
+}
+
+// ---------- Delayed Queue Tests ----------
+
+func TestMemoryQueue_PushAt(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	q := worker.NewMemoryQueue[string](10).WithClock(clock)
+	ctx := context.Background()
+
+	require.NoError(t, q.PushAt(ctx, "later", clock.Now().Add(2*time.Hour)))
+	require.NoError(t, q.PushAt(ctx, "sooner", clock.Now().Add(1*time.Hour)))
+
+	// Nothing is due yet
+	item, err := q.Pop(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, item)
+
+	clock.Advance(1 * time.Hour)
+
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "sooner", *item)
+
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, item)
+
+	clock.Advance(1 * time.Hour)
+
+	item, err = q.Pop(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "later", *item)
+}
+
+// ---------- Periodic Job Tests ----------
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := 0
+	job := worker.Every(time.Minute, 10*time.Second, func(context.Context) error {
+		runs++
+		if runs == 3 {
+			cancel()
+		}
+		return nil
+	}, slog.Default()).WithClock(clock)
+
+	require.NoError(t, job.Run(ctx))
+	assert.Equal(t, 3, runs)
+
+	for _, wait := range clock.waits {
+		assert.GreaterOrEqual(t, wait, time.Minute)
+		assert.Less(t, wait, time.Minute+10*time.Second)
+	}
+}
+
+// ---------- Autoscaling Tests ----------
+
+func TestAutoscalingPool_ScalesWithDepth(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[int](100)
+	pool := worker.NewAutoscalingPool(1, 4, q, func(context.Context, int) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}, discardLogger, testConfig(),
+		worker.WithItemsPerWorker(10),
+		worker.WithSampleInterval(time.Millisecond),
+		worker.WithScaleDownAfter(3),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool.Start(ctx)
+	assert.Equal(t, 1, pool.Size())
+
+	for i := 0; i < 80; i++ {
+		require.NoError(t, q.Push(i))
+	}
+
+	// Depth 80 wants 8 workers, capped at max
+	assert.Eventually(t, func() bool { return pool.Size() == 4 }, time.Second, time.Millisecond)
+
+	// Drained queue shrinks back to min
+	assert.Eventually(t, func() bool {
+		return q.Len() == 0 && pool.Size() == 1
+	}, 2*time.Second, time.Millisecond)
+
+	require.NoError(t, pool.Stop(context.Background()))
+}
+
+// ---------- Result Tests ----------
+
+type reportJob struct {
+	ID    string
+	Pages int
+}
+
+func TestNewWithResult_AwaitResult(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[reportJob](10)
+	store := worker.NewMemoryResultStore[string](time.Minute)
+
+	w := worker.NewWithResult("reports", q,
+		func(_ context.Context, job reportJob) (string, error) {
+			if job.Pages == 0 {
+				return "", errors.New("empty report")
+			}
+			return fmt.Sprintf("s3://reports/%s.pdf", job.ID), nil
+		},
+		store,
+		func(job reportJob) string { return job.ID },
+		discardLogger, testConfig(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	require.NoError(t, q.Push(reportJob{ID: "r1", Pages: 3}))
+	require.NoError(t, q.Push(reportJob{ID: "r2"}))
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Second)
+	defer waitCancel()
+
+	url, err := worker.AwaitResult[string](waitCtx, store, "r1", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "s3://reports/r1.pdf", url)
+
+	_, err = worker.AwaitResult[string](waitCtx, store, "r2", time.Millisecond)
+	assert.EqualError(t, err, "empty report")
+}
+
+func TestNewWithResult_RecordsOnlyFinalAttempt(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[reportJob](10, worker.WithRequeueOnFail(3))
+	store := worker.NewMemoryResultStore[string](time.Minute)
+
+	var attempts atomic.Int64
+	retried := make(chan struct{})
+	release := make(chan struct{})
+
+	w := worker.NewWithResult("reports", q,
+		func(_ context.Context, job reportJob) (string, error) {
+			if attempts.Add(1) == 1 {
+				return "", errors.New("s3 timeout")
+			}
+			close(retried)
+			<-release
+			return "s3://reports/" + job.ID + ".pdf", nil
+		},
+		store,
+		func(job reportJob) string { return job.ID },
+		discardLogger, testConfig(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	require.NoError(t, q.Push(reportJob{ID: "r1", Pages: 1}))
+
+	select {
+	case <-retried:
+	case <-time.After(time.Second):
+		t.Fatal("item was not retried")
+	}
+	_, _, ok := store.Get(ctx, "r1")
+	assert.False(t, ok, "a failure that will be retried is not the outcome")
+	close(release)
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Second)
+	defer waitCancel()
+
+	url, err := worker.AwaitResult[string](waitCtx, store, "r1", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "s3://reports/r1.pdf", url)
+}
+
+func TestNewWithResult_RetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	q := worker.NewMemoryQueue[reportJob](10, worker.WithRequeueOnFail(2))
+	store := worker.NewMemoryResultStore[string](time.Minute)
+
+	var attempts atomic.Int64
+	w := worker.NewWithResult("reports", q,
+		func(context.Context, reportJob) (string, error) {
+			return "", fmt.Errorf("attempt %d failed", attempts.Add(1))
+		},
+		store,
+		func(job reportJob) string { return job.ID },
+		discardLogger, testConfig(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	require.NoError(t, q.Push(reportJob{ID: "r1"}))
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Second)
+	defer waitCancel()
+
+	_, err := worker.AwaitResult[string](waitCtx, store, "r1", time.Millisecond)
+	assert.EqualError(t, err, "attempt 2 failed", "the last attempt's error")
+}
+
+func TestMemoryResultStore_Expires(t *testing.T) {
+	t.Parallel()
+
+	store := worker.NewMemoryResultStore[int](time.Millisecond)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "job", 42, nil))
+
+	_, _, ok := store.Get(ctx, "missing")
+	assert.False(t, ok)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, _, ok = store.Get(ctx, "job")
+	assert.False(t, ok)
+}
+
+// ---------- Cron Tests ----------
+
+// manualClock fires timers only when the test calls Fire.
+type manualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  chan manualTimer
+	pending *manualTimer
+}
+
+type manualTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{now: now, timers: make(chan manualTimer, 10)}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	timer := manualTimer{at: c.Now().Add(d), c: make(chan time.Time, 1)}
+	c.timers <- timer
+	return timer.c
+}
+
+// Fire moves time to the next timer and fires it, then waits until the
+// job has scheduled its following timer, so the fire is fully handled.
+func (c *manualClock) Fire(t *testing.T) time.Time {
+	t.Helper()
+
+	timer := c.pending
+	if timer == nil {
+		timer = c.nextTimer(t)
+	}
+
+	c.mu.Lock()
+	c.now = timer.at
+	c.mu.Unlock()
+	timer.c <- timer.at
+
+	c.pending = c.nextTimer(t)
+	return timer.at
+}
+
+func (c *manualClock) nextTimer(t *testing.T) *manualTimer {
+	t.Helper()
+
+	select {
+	case timer := <-c.timers:
+		return &timer
+	case <-time.After(time.Second):
+		t.Fatal("no timer scheduled")
+		return nil
+	}
+}
+
+func TestParseCron_Next(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 0", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}, // day-of-month OR Sunday
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, 1, 31, 10, 31, 30, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+
+			schedule, err := worker.ParseCron(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(start))
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "@every -1s"} {
+		_, err := worker.ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronJob_FireTimesInTimezone(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	runs := make(chan struct{}, 10)
+
+	job, err := worker.NewCronJob("0 3 * * *", func(context.Context) error {
+		runs <- struct{}{}
+		return nil
+	}, discardLogger, worker.WithTimezone(berlin))
+	require.NoError(t, err)
+	job.WithClock(clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- job.Run(ctx) }()
+
+	// 03:00 Berlin is 02:00 UTC in winter
+	assert.Equal(t, time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC), clock.Fire(t).UTC())
+	<-runs
+	assert.Equal(t, time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC), clock.Fire(t).UTC())
+	<-runs
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestCronJob_Overlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy worker.OverlapPolicy
+		want   int64
+	}{
+		{"skip", worker.OverlapSkip, 1},
+		{"queue", worker.OverlapQueue, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			release := make(chan struct{})
+			started := make(chan struct{}, 10)
+			var calls atomic.Int64
+
+			job, err := worker.NewCronJob("@every 1m", func(context.Context) error {
+				calls.Add(1)
+				started <- struct{}{}
+				<-release
+				return nil
+			}, discardLogger, worker.WithOverlap(tt.policy))
+			require.NoError(t, err)
+			job.WithClock(clock)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- job.Run(ctx) }()
+
+			clock.Fire(t)
+			<-started
+
+			// Three more fires while the first run is blocked
+			for i := 0; i < 3; i++ {
+				clock.Fire(t)
+			}
+			assert.Equal(t, int64(1), calls.Load())
+
+			close(release)
+			if tt.want > 1 {
+				<-started
+			}
+
+			cancel()
+			require.NoError(t, <-done)
+			assert.Equal(t, tt.want, calls.Load())
+		})
+	}
+}
+
+func TestCronJob_StopOnError(t *testing.T) {
+	t.Parallel()
+
+	errBackup := errors.New("backup failed")
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	job, err := worker.NewCronJob("@daily", func(context.Context) error {
+		return errBackup
+	}, discardLogger, worker.WithStopOnError())
+	require.NoError(t, err)
+	job.WithClock(clock)
+
+	done := make(chan error)
+	go func() { done <- job.Run(context.Background()) }()
+
+	clock.Fire(t)
+	assert.ErrorIs(t, <-done, errBackup)
+}
+
+// ---------- Watchdog Tests ----------
+
+// syncBuffer is a goroutine-safe log sink.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPool_WatchdogReportsStuckWorker(t *testing.T) {
+	t.Parallel()
+
+	logs := &syncBuffer{}
+	reg := prometheus.NewRegistry()
+
+	cfg := testConfig()
+	cfg.StuckThreshold = 20 * time.Millisecond
+	cfg.Metrics = worker.NewMetrics(reg)
+
+	release := make(chan struct{})
+	q := worker.NewMemoryQueue[int](10)
+	pool := worker.NewPool(1, q, func(context.Context, int) error {
+		<-release
+		return nil
+	}, slog.New(slog.NewTextHandler(logs, nil)), cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	require.NoError(t, q.Push(1))
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "worker stuck")
+	}, time.Second, time.Millisecond)
+
+	status := pool.Status()[0]
+	assert.True(t, status.Stuck)
+	assert.Equal(t, worker.StateProcessing, status.State)
+	assert.Greater(t, status.CurrentItemAge, cfg.StuckThreshold)
+
+	// Reported once per stall, however long it lasts
+	time.Sleep(3 * cfg.StuckThreshold)
+	assert.Equal(t, 1, strings.Count(logs.String(), "worker stuck"))
+
+	stuck, err := testutil.GatherAndCount(reg, "worker_stuck_total")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stuck)
+
+	close(release)
+	assert.Eventually(t, func() bool { return !pool.Status()[0].Stuck }, time.Second, time.Millisecond)
+
+	require.NoError(t, pool.Stop(context.Background()))
+}
@@ -0,0 +1,74 @@
+// Package worker provides heartbeats and a stuck-worker watchdog.
+// Place in: internal/worker/watchdog.go
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// beat records that the worker is making progress.
+// Called on every loop iteration and around each item.
+func (w *Worker[T]) beat() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.heartbeat = time.Now()
+}
+
+// stuckLocked reports how long since the last heartbeat and whether that
+// exceeds Config.StuckThreshold. Paused and not-yet-started workers are
+// never stuck. Caller holds w.mu.
+func (w *Worker[T]) stuckLocked() (time.Duration, bool) {
+	if w.cfg.StuckThreshold <= 0 || w.heartbeat.IsZero() || w.state == StatePaused {
+		return 0, false
+	}
+
+	since := time.Since(w.heartbeat)
+	return since, since > w.cfg.StuckThreshold
+}
+
+// watchdog logs each worker once per stuck episode until ctx is done
+// or all workers have stopped.
+func (p *Pool[T]) watchdog(ctx context.Context, stopped <-chan struct{}) {
+	threshold := p.workers[0].cfg.StuckThreshold
+
+	ticker := time.NewTicker(threshold / 2)
+	defer ticker.Stop()
+
+	// Heartbeat already reported per worker, so a long stall logs once
+	reported := make(map[*Worker[T]]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopped:
+			return
+		case <-ticker.C:
+			for _, w := range p.workers {
+				w.mu.Lock()
+				since, stuck := w.stuckLocked()
+				heartbeat, state, busySince := w.heartbeat, w.state, w.busySince
+				w.mu.Unlock()
+
+				if !stuck || reported[w].Equal(heartbeat) {
+					continue
+				}
+				reported[w] = heartbeat
+
+				var itemAge time.Duration
+				if state == StateProcessing {
+					itemAge = time.Since(busySince)
+				}
+
+				w.logger.Error("worker stuck",
+					slog.Duration("since_heartbeat", since),
+					slog.Duration("current_item_age", itemAge),
+					slog.String("state", string(state)),
+				)
+				w.cfg.Metrics.stuck(w.name)
+			}
+		}
+	}
+}